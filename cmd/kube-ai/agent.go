@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/agent"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// createAgentCmd creates the agent command
+func createAgentCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	var maxSteps int
+	var outputFormat string
+	var showTranscript bool
+
+	cmd := &cobra.Command{
+		Use:   "agent [task]",
+		Short: "Investigate an open-ended question with read-only cluster tools",
+		Long: `Let the AI iteratively call a constrained set of read-only tools (list pods,
+get YAML, get logs, get events, top pods) to answer open-ended questions such as
+"why is checkout latency up?".
+
+Providers with native function calling (OpenAI, Ollama) use it directly; other
+providers are driven through a JSON tool protocol in the prompt.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task := strings.Join(args, " ")
+
+			// Create Kubernetes client with kubectl flags
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			if err := checkAccess(client, "agent", client.GetNamespace()); err != nil {
+				return err
+			}
+
+			a := agent.NewAgent(aiService, agent.DefaultTools(client), client.GetNamespace(), maxSteps)
+
+			progressf("Investigating in namespace %s (max %d steps)...\n", client.GetNamespace(), maxSteps)
+
+			result, err := a.Run(context.Background(), task)
+			if err != nil {
+				return fmt.Errorf("error running agent: %w", err)
+			}
+
+			switch outputFormat {
+			case "json":
+				jsonData, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error formatting JSON output: %w", err)
+				}
+				fmt.Println(string(jsonData))
+			default:
+				displayAgentResult(result, showTranscript)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&maxSteps, "max-steps", agent.DefaultMaxSteps, "Maximum number of tool calls the agent may make")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+	cmd.Flags().BoolVar(&showTranscript, "transcript", true, "Display the full tool-call transcript")
+
+	return cmd
+}
+
+// displayAgentResult outputs an agent run in human-readable format
+func displayAgentResult(result *agent.Result, showTranscript bool) {
+	if showTranscript {
+		fmt.Printf("\n====== %s ======\n", i18n.T("TRANSCRIPT"))
+		for _, step := range result.Steps {
+			args, _ := json.Marshal(step.Arguments)
+			fmt.Printf("\n--- Step %d: %s %s ---\n", step.Number, step.Tool, args)
+			if step.Thought != "" {
+				fmt.Printf("Thought: %s\n", step.Thought)
+			}
+			if step.Error != "" {
+				fmt.Printf("Error: %s\n", step.Error)
+			} else {
+				fmt.Println(step.Output)
+			}
+		}
+	}
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("ANSWER"))
+	fmt.Println(result.Answer)
+
+	if result.StepLimitReached {
+		fmt.Printf("\nNote: the step limit was reached after %d tool calls; the answer may be incomplete.\n", len(result.Steps))
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// createAnalysisCmd creates the analysis command group for saved analysis runs
+func createAnalysisCmd(aiService *ai.Service) *cobra.Command {
+	analysisCmd := &cobra.Command{
+		Use:   "analysis",
+		Short: "Manage and compare saved analysis runs",
+		Long: `Manage analysis runs saved with --save on analyze-logs and bundle analyze.
+
+Saved runs are stored in ~/.kube-ai/analyses and can be compared, for example
+before and after a fix was applied, to see whether the situation improved.`,
+	}
+
+	// List saved analyses
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved analysis runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := analyzers.DefaultAnalysisDir()
+			if err != nil {
+				return fmt.Errorf("error locating analysis directory: %w", err)
+			}
+
+			saved, err := analyzers.ListAnalyses(dir)
+			if err != nil {
+				return fmt.Errorf("error listing saved analyses: %w", err)
+			}
+			if len(saved) == 0 {
+				fmt.Printf("No saved analyses in %s. Use --save <name> on analyze-logs or bundle analyze.\n", dir)
+				return nil
+			}
+
+			fmt.Printf("Saved analyses (%s):\n", dir)
+			fmt.Println("-------------------")
+			for _, analysis := range saved {
+				fmt.Printf("%s: %s %s, severity %s\n", analysis.Name, analysis.CreatedAt.Local().Format("2006-01-02 15:04"),
+					analysis.Resource(), analysis.Analysis.Severity)
+			}
+			return nil
+		},
+	}
+
+	var outputFormat string
+	var assess bool
+
+	// Compare two saved analyses
+	diffCmd := &cobra.Command{
+		Use:   "diff [before] [after]",
+		Short: "Compare two saved analysis runs",
+		Long: `Compare two saved analysis runs: severity, root causes that were resolved,
+persist or are new, and error and warning rates. The AI then assesses whether
+the situation improved, for example after a fix was applied.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			before, err := loadSavedAnalysis(args[0])
+			if err != nil {
+				return err
+			}
+			after, err := loadSavedAnalysis(args[1])
+			if err != nil {
+				return err
+			}
+
+			if before.Resource() != after.Resource() || before.Namespace != after.Namespace {
+				fmt.Fprintf(os.Stderr, "Warning: comparing different resources (%s in %s and %s in %s)\n",
+					before.Resource(), before.Namespace, after.Resource(), after.Namespace)
+			}
+
+			comparison := analyzers.CompareAnalyses(before, after)
+			if assess {
+				if outputFormat != "json" {
+					progressf("Assessing changes...\n")
+				}
+				comparison.Assessment, err = analyzers.AssessComparison(context.Background(), aiService, before, after, comparison)
+				if err != nil {
+					return fmt.Errorf("error assessing changes: %w", err)
+				}
+			}
+
+			switch outputFormat {
+			case "json":
+				jsonData, err := json.MarshalIndent(comparison, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error formatting JSON output: %w", err)
+				}
+				fmt.Println(string(jsonData))
+			default:
+				displayComparison(before, after, comparison)
+			}
+			return nil
+		},
+	}
+
+	diffCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+	diffCmd.Flags().BoolVar(&assess, "assess", true, "Ask the AI whether the situation improved")
+
+	analysisCmd.AddCommand(listCmd)
+	analysisCmd.AddCommand(diffCmd)
+
+	return analysisCmd
+}
+
+// loadSavedAnalysis reads the analysis saved under name
+func loadSavedAnalysis(name string) (*analyzers.SavedAnalysis, error) {
+	dir, err := analyzers.DefaultAnalysisDir()
+	if err != nil {
+		return nil, fmt.Errorf("error locating analysis directory: %w", err)
+	}
+	path, err := analyzers.AnalysisPath(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return analyzers.LoadAnalysis(path)
+}
+
+// saveAnalysis stores an analysis result under name so it can be compared with later runs.
+// The confirmation is written to w so it stays out of JSON output.
+func saveAnalysis(w io.Writer, name string, saved analyzers.SavedAnalysis) error {
+	dir, err := analyzers.DefaultAnalysisDir()
+	if err != nil {
+		return fmt.Errorf("error locating analysis directory: %w", err)
+	}
+	path, err := analyzers.AnalysisPath(dir, name)
+	if err != nil {
+		return err
+	}
+
+	saved.Name = name
+	if saved.CreatedAt.IsZero() {
+		saved.CreatedAt = time.Now().UTC()
+	}
+	if err := saved.Save(path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Saved analysis as %q in %s\n", name, path)
+	return nil
+}
+
+// displayComparison prints how two analysis runs differ
+func displayComparison(before, after *analyzers.SavedAnalysis, comparison *analyzers.AnalysisComparison) {
+	resetColor := color(ansiReset)
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("ANALYSIS COMPARISON"))
+	fmt.Printf("%s: %s (%s) -> %s (%s)\n", before.Resource(), comparison.Before, before.CreatedAt.Local().Format("2006-01-02 15:04"),
+		comparison.After, after.CreatedAt.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("%s: %s%s%s -> %s%s%s\n", i18n.T("Severity"),
+		severityColor(comparison.SeverityBefore), comparison.SeverityBefore, resetColor,
+		severityColor(comparison.SeverityAfter), comparison.SeverityAfter, resetColor)
+	fmt.Printf("%s: %.2f/min -> %.2f/min\n", i18n.T("Error rate"), comparison.ErrorRateBefore, comparison.ErrorRateAfter)
+	fmt.Printf("%s: %.2f/min -> %.2f/min\n", i18n.T("Warning rate"), comparison.WarningRateBefore, comparison.WarningRateAfter)
+
+	printCauses := func(title string, causes []string) {
+		if len(causes) == 0 {
+			return
+		}
+		fmt.Printf("\n=== %s ===\n", i18n.T(title))
+		for _, cause := range causes {
+			fmt.Printf("- %s\n", cause)
+		}
+	}
+	printCauses("Resolved Root Causes", comparison.Resolved)
+	printCauses("Persisting Root Causes", comparison.Persisting)
+	printCauses("New Root Causes", comparison.New)
+
+	if comparison.Assessment != "" {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Assessment"))
+		fmt.Println(comparison.Assessment)
+	}
+}
+
+// currentContextName returns the kube context selected by the command's flags, or "" if it cannot be determined
+func currentContextName(cmd *cobra.Command) string {
+	clientConfig, err := k8s.GetClientConfigFromFlags(cmd)
+	if err != nil {
+		return ""
+	}
+	contextName, _ := k8s.CurrentContext(clientConfig)
+	return contextName
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/history"
+	"kube-ai/pkg/k8s"
+)
+
+// historyCommandWidth is how much of a command line the history list shows
+const historyCommandWidth = 48
+
+// credentialFlags are the flags left out of the command lines kept in the answer history, as they
+// hold or locate credentials; reruns use the credentials of the rerunning command instead
+var credentialFlags = map[string]bool{
+	"token":                 true,
+	"kubeconfig":            true,
+	"client-key":            true,
+	"client-certificate":    true,
+	"certificate-authority": true,
+	"username":              true,
+	"password":              true,
+}
+
+// historyRedactor masks the credentials of what the answer history keeps, on top of the redaction
+// applied to the prompt, so that they are never stored even when the prompt is sent unredacted
+var historyRedactor, _ = redact.New(redact.PolicyStandard, nil)
+
+// recordHistory keeps every answer of the AI provider to the command in the answer history
+func recordHistory(cmd *cobra.Command, args []string, aiService *ai.Service) {
+	dir, err := history.DefaultDir()
+	if err != nil {
+		return
+	}
+	store := history.NewStore(dir, 0)
+	command, commandArgs := commandKey(cmd), commandLine(cmd, args)
+	contextName, namespace := "", ""
+	if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+		contextName, namespace = k8s.CurrentContext(clientConfig)
+	}
+
+	// Consensus mode asks several models at once
+	var mu sync.Mutex
+	aiService.OnExchange(func(exchange ai.Exchange) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Redact the arguments as the prompt was, with the profile applied to the request
+		redactedArgs := make([]string, len(commandArgs))
+		for i, arg := range commandArgs {
+			redactedArgs[i] = historyRedactor.Redact(aiService.Redact(arg))
+		}
+		err := store.Add(&history.Entry{
+			Command:      command,
+			Args:         redactedArgs,
+			Context:      contextName,
+			Namespace:    namespace,
+			Provider:     exchange.Provider,
+			Model:        exchange.Model,
+			Prompt:       historyRedactor.Redact(exchange.Prompt),
+			Answer:       historyRedactor.Redact(exchange.Answer),
+			InputTokens:  exchange.InputTokens,
+			OutputTokens: exchange.OutputTokens,
+			Cost:         exchange.Cost,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: the answer was not kept in the history: %v\n", err)
+		}
+	})
+}
+
+// commandLine returns the arguments that run a command again: its path, its arguments and the
+// flags that were set, except those holding credentials
+func commandLine(cmd *cobra.Command, args []string) []string {
+	line := append(strings.Fields(commandKey(cmd)), args...)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if credentialFlags[flag.Name] {
+			return
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range slice.GetSlice() {
+				line = append(line, fmt.Sprintf("--%s=%s", flag.Name, value))
+			}
+			return
+		}
+		line = append(line, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+	return line
+}
+
+// isHistoryCmd reports whether a command manages the answer history, which it does not record
+func isHistoryCmd(cmd *cobra.Command) bool {
+	for ; cmd != nil && cmd.HasParent(); cmd = cmd.Parent() {
+		if cmd.Name() == "history" && !cmd.Parent().HasParent() {
+			return true
+		}
+	}
+	return false
+}
+
+// createHistoryCmd creates the history command, which lists, shows and reruns previous answers
+func createHistoryCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	var limit int
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List previous AI answers",
+		Long: `List the commands that asked the AI provider something, newest first, with the
+provider and model that answered and the estimated tokens and cost.
+
+Every answer is kept in ~/.kube-ai/answers with the command line, the prompt as
+it was sent (after redaction) and the target context and namespace. The command
+line is redacted like the prompt, and flags holding credentials, such as
+--token, are left out of it. The file is
+rotated past 5 MB, so about the last 10 MB of answers are kept. Set
+disableHistory: true in the configuration file to keep nothing; nothing is kept
+in stateless mode either.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			store, err := historyStore()
+			if err != nil {
+				return err
+			}
+			entries, err := store.List()
+			if err != nil {
+				return err
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[:limit]
+			}
+
+			if outputFormat == "json" {
+				if entries == nil {
+					entries = []history.Entry{}
+				}
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error formatting JSON output: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(entries) == 0 {
+				fmt.Printf("No answers in %s yet.\n", store.Dir())
+				return nil
+			}
+			fmt.Printf("%-8s  %-16s  %-*s  %-28s  %8s  %s\n", "ID", "TIME", historyCommandWidth, "COMMAND", "PROVIDER/MODEL", "TOKENS", "COST")
+			for _, entry := range entries {
+				command := strings.Join(entry.Args, " ")
+				if len(command) > historyCommandWidth {
+					command = command[:historyCommandWidth-3] + "..."
+				}
+				fmt.Printf("%-8s  %-16s  %-*s  %-28s  %8d  %s\n", entry.ID, entry.Time.Local().Format("2006-01-02 15:04"),
+					historyCommandWidth, command, providerModel(entry), entry.InputTokens+entry.OutputTokens, formatCost(entry.Cost))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Number of answers to list, newest first (0 for all)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	cmd.AddCommand(createHistoryShowCmd())
+	cmd.AddCommand(createHistoryRerunCmd(cfg, aiService))
+	cmd.AddCommand(createHistoryClearCmd())
+
+	return cmd
+}
+
+// createHistoryShowCmd creates the history show command
+func createHistoryShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a previous AI answer with its prompt",
+		Long:  `Show a previous answer with its command line, target, provider, model, estimated cost and the redacted prompt. IDs may be shortened to any unique prefix.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entry, err := historyEntry(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("ID:       %s\n", entry.ID)
+			fmt.Printf("Time:     %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"))
+			fmt.Printf("Command:  kubectl ai %s\n", strings.Join(entry.Args, " "))
+			if entry.Context != "" {
+				fmt.Printf("Context:  %s (namespace %s)\n", entry.Context, entry.Namespace)
+			}
+			fmt.Printf("Provider: %s\n", providerModel(*entry))
+			fmt.Printf("Tokens:   %d in, %d out (estimated), cost %s\n", entry.InputTokens, entry.OutputTokens, formatCost(entry.Cost))
+			fmt.Printf("\n=== Prompt ===\n%s\n", entry.Prompt)
+			fmt.Printf("\n=== Answer ===\n%s\n", entry.Answer)
+			return nil
+		},
+	}
+}
+
+// createHistoryRerunCmd creates the history rerun command
+func createHistoryRerunCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rerun [id]",
+		Short: "Run the command of a previous AI answer again",
+		Long: `Run the command of a previous answer again, with the same arguments and flags,
+against the current state of the cluster and the current configuration.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entry, err := historyEntry(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Running: kubectl ai %s\n", strings.Join(entry.Args, " "))
+
+			// A fresh command tree, so the flags of this invocation do not carry over
+			rootCmd := createRootCommand(cfg, aiService)
+			rootCmd.SetArgs(entry.Args)
+			return rootCmd.Execute()
+		},
+	}
+}
+
+// createHistoryClearCmd creates the history clear command
+func createHistoryClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Delete all previous AI answers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := historyStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Clear(); err != nil {
+				return err
+			}
+			fmt.Printf("Cleared the answer history in %s\n", store.Dir())
+			return nil
+		},
+	}
+}
+
+// historyStore returns the answer history in its default directory
+func historyStore() (*history.Store, error) {
+	dir, err := history.DefaultDir()
+	if err != nil {
+		return nil, fmt.Errorf("error locating the history directory: %w", err)
+	}
+	return history.NewStore(dir, 0), nil
+}
+
+// historyEntry returns the answer whose ID is or starts with id
+func historyEntry(id string) (*history.Entry, error) {
+	store, err := historyStore()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := store.Get(id)
+	if err != nil {
+		return nil, usageErrorf("%w", err)
+	}
+	return entry, nil
+}
+
+// providerModel returns the provider and model of an answer as provider/model
+func providerModel(entry history.Entry) string {
+	if entry.Model == "" {
+		return entry.Provider
+	}
+	return entry.Provider + "/" + entry.Model
+}
+
+// formatCost returns an estimated cost in US dollars, or - when the price of the model is unknown
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
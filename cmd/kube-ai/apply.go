@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/progress"
+)
+
+// applyOptions are the flags of commands that can apply the manifests the AI wrote. Changes are
+// always previewed as a server-side diff first, and applied once confirmed.
+type applyOptions struct {
+	enabled bool
+	// Whether to apply without asking for confirmation
+	yes bool
+}
+
+// addFlags adds --apply and --yes to a command
+func (o *applyOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.enabled, "apply", false, "Apply the manifest to the cluster after showing the changes (requires --allow-writes)")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Apply without asking for confirmation")
+}
+
+// validate checks, before anything is generated, that the changes can be confirmed: on a
+// terminal, or up front with --yes
+func (o applyOptions) validate() error {
+	if o.enabled && !o.yes && !progress.IsTerminal(os.Stdin) {
+		return usageErrorf("--apply asks for confirmation on a terminal; add --yes to apply without asking")
+	}
+	return nil
+}
+
+// apply previews and applies a manifest, asking for confirmation on standard input unless --yes
+// was given
+func (o applyOptions) apply(cmd *cobra.Command, content string) error {
+	applied, err := previewAndApply(cmd, content, func() bool {
+		return o.yes || confirmOnStdin("Apply these changes?")
+	})
+	for _, resource := range applied {
+		fmt.Printf("%s applied\n", resource)
+	}
+	return err
+}
+
+// confirmOnStdin asks a yes or no question on standard input, where anything but yes declines
+func confirmOnStdin(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		fmt.Println()
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// previewAndApply shows how applying a manifest would change the cluster selected by the
+// command's flags, from a server-side dry run, and applies it if confirm agrees. It returns the
+// applied resources, none when nothing would change or the changes were declined.
+func previewAndApply(cmd *cobra.Command, content string, confirm func() bool) ([]string, error) {
+	client, err := k8s.NewClientFromFlags(cmd)
+	if err != nil {
+		return nil, kubeErrorf("error creating Kubernetes client: %w", err)
+	}
+	if client.IsReadOnly() {
+		return nil, usageErrorf("applying requires --allow-writes; save or print the manifest instead")
+	}
+
+	ctx := context.Background()
+	diffs, err := client.DiffManifest(ctx, content, "")
+	if err != nil {
+		return nil, kubeErrorf("error comparing the manifest with the cluster: %w", err)
+	}
+
+	fmt.Printf("\n=== %s ===\n", i18n.T("PLANNED CHANGES"))
+	changed := false
+	for _, diff := range diffs {
+		switch {
+		case diff.Diff == "":
+			fmt.Printf("%s unchanged\n", diff.Resource)
+			continue
+		case diff.Created:
+			fmt.Printf("%s will be created\n", diff.Resource)
+		default:
+			fmt.Printf("%s will be changed\n", diff.Resource)
+		}
+		changed = true
+		printDiff(diff.Diff)
+	}
+	if !changed {
+		fmt.Println("Nothing to apply")
+		return nil, nil
+	}
+
+	if !confirm() {
+		fmt.Println("Not applied")
+		return nil, nil
+	}
+	applied, err := client.ApplyManifest(ctx, content, "")
+	if err != nil {
+		return applied, kubeErrorf("%w", err)
+	}
+	return applied, nil
+}
+
+// printDiff prints a unified diff, with removed lines in red, added ones in green and hunk
+// headers in cyan
+func printDiff(diff string) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		code := ""
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			code = ansiBold
+		case strings.HasPrefix(line, "@@"):
+			code = ansiCyan
+		case strings.HasPrefix(line, "-"):
+			code = ansiRed
+		case strings.HasPrefix(line, "+"):
+			code = ansiGreen
+		}
+		if code == "" {
+			fmt.Println(line)
+			continue
+		}
+		fmt.Println(color(code) + line + color(ansiReset))
+	}
+}
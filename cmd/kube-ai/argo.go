@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// argoReport is the JSON output of analyze-argo
+type argoReport struct {
+	*k8s.ArgoApplication
+	Analysis string `json:"analysis"`
+}
+
+// createAnalyzeArgoCmd creates the analyze-argo command
+func createAnalyzeArgoCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "analyze-argo <application>",
+		Short: "Explain why an Argo CD application is degraded or out of sync",
+		Long: `Read an Argo CD Application: its sources, sync and health status, last sync
+operation, conditions and the resources that are out of sync or not healthy.
+For out-of-sync resources, the live state is compared with the configuration
+Argo CD last applied.
+
+The AI explains why the application is Degraded or OutOfSync and proposes the
+minimal fix, telling whether it belongs in the source (a values or manifest
+change) or in the cluster.
+
+Applications are read from the argocd namespace unless -n is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			namespace := k8s.DefaultArgoNamespace
+			if cmd.Flags().Changed("namespace") {
+				namespace = client.GetNamespace()
+			}
+
+			ctx := context.Background()
+			app, err := client.GetArgoApplication(ctx, namespace, args[0])
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+
+			if outputFormat == "text" {
+				displayArgoApplication(app)
+				progressf("\nAnalyzing application...\n")
+			}
+			report := argoReport{ArgoApplication: app}
+			report.Analysis, err = analyzers.ExplainArgoApplication(ctx, aiService, app)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				if report.Resources == nil {
+					report.Resources = []k8s.ArgoResource{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(report.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayArgoApplication prints the status of an Argo CD application, its resources that are out
+// of sync or not healthy, and how their live state drifted
+func displayArgoApplication(app *k8s.ArgoApplication) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("ARGO CD APPLICATION"))
+	fmt.Printf("%s/%s\n", app.Namespace, app.Name)
+	for _, source := range app.Sources {
+		location := source.RepoURL
+		if source.Path != "" {
+			location += " " + source.Path
+		}
+		if source.Chart != "" {
+			location += " chart " + source.Chart
+		}
+		if source.TargetRevision != "" {
+			location += " @ " + source.TargetRevision
+		}
+		fmt.Printf("%-14s %s\n", "Source:", location)
+	}
+	fmt.Printf("%-14s %s\n", "Sync:", app.SyncStatus)
+	health := app.HealthStatus
+	if app.HealthMessage != "" {
+		health += " (" + app.HealthMessage + ")"
+	}
+	fmt.Printf("%-14s %s\n", "Health:", health)
+	if app.OperationPhase != "" {
+		fmt.Printf("%-14s %s %s\n", "Last sync:", app.OperationPhase, app.OperationMessage)
+	}
+	for _, condition := range app.Conditions {
+		fmt.Printf("%-14s %s\n", "Condition:", condition)
+	}
+
+	if len(app.Resources) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Resources"))
+		fmt.Printf("%-50s %-10s %-12s %s\n", "RESOURCE", "SYNC", "HEALTH", "MESSAGE")
+		for _, resource := range app.Resources {
+			fmt.Printf("%-50s %-10s %-12s %s\n", resource, resource.Status, resource.Health, resource.HealthMessage)
+		}
+	}
+
+	if len(app.Drifts) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Drift"))
+		for _, drift := range app.Drifts {
+			if drift.Note != "" {
+				fmt.Printf("%s: %s\n", drift.Resource, drift.Note)
+			} else {
+				fmt.Printf("%s:\n", drift.Resource)
+			}
+			for _, field := range drift.Fields {
+				fmt.Printf("  %s\n", field)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// compareWithBaseline compares log entries with the stored baseline of the workload.
+// When no baseline exists yet, or update is set, the entries are recorded as the new baseline
+// and nil is returned.
+func compareWithBaseline(cmd *cobra.Command, options logs.LogOptions, entries []logs.LogEntry, update bool) (*logs.BaselineDiff, error) {
+	dir, err := logs.DefaultBaselineDir()
+	if err != nil {
+		return nil, fmt.Errorf("error locating baseline directory: %w", err)
+	}
+
+	path := logs.BaselinePath(dir, currentContextName(cmd), options.Namespace, options.ResourceType, options.ResourceName)
+
+	baseline, err := logs.LoadBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseline == nil || update {
+		baseline = logs.NewBaseline(options.ResourceType, options.ResourceName, options.Namespace, entries)
+		if err := baseline.Save(path); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Recorded baseline of %d patterns from %d log entries in %s\n", len(baseline.Patterns), baseline.TotalEntries, path)
+		return nil, nil
+	}
+
+	return baseline.Compare(entries), nil
+}
+
+// displayBaselineDiff prints patterns that changed compared to the baseline
+func displayBaselineDiff(diff *logs.BaselineDiff) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("CHANGES FROM BASELINE"))
+	if !diff.HasDeviations() {
+		fmt.Printf("No new or unusual log patterns since the baseline of %s\n", diff.BaselineCreatedAt.Format("2006-01-02 15:04"))
+		return
+	}
+
+	printDeviations := func(title string, deviations []logs.PatternDeviation, format func(logs.PatternDeviation) string) {
+		if len(deviations) == 0 {
+			return
+		}
+		fmt.Printf("\n=== %s ===\n", i18n.T(title))
+		for _, d := range deviations {
+			fmt.Printf("- [%s] %s %s\n", d.Level, truncateLine(d.Pattern, 100), format(d))
+		}
+	}
+
+	printDeviations("New Patterns", diff.New, func(d logs.PatternDeviation) string {
+		return fmt.Sprintf("(%d)", d.Count)
+	})
+	printDeviations("Rare Patterns", diff.Rare, func(d logs.PatternDeviation) string {
+		return fmt.Sprintf("(%d)", d.Count)
+	})
+	printDeviations("Rate Changes", diff.RateChanges, func(d logs.PatternDeviation) string {
+		return fmt.Sprintf("(%.1f/min -> %.1f/min)", d.BaselineRate, d.CurrentRate)
+	})
+}
+
+// truncateLine shortens a single-line string to at most max characters
+func truncateLine(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
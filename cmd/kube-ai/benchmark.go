@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/benchmark"
+)
+
+// maxDisplayedOffenders limits the offenders printed per failed check in text output
+const maxDisplayedOffenders = 5
+
+// benchmarkReport is the JSON output of benchmark
+type benchmarkReport struct {
+	Results     []benchmark.Result `json:"results"`
+	Summary     map[string]int     `json:"summary"`
+	Remediation string             `json:"remediation,omitempty"`
+}
+
+// createBenchmarkCmd creates the benchmark command
+func createBenchmarkCmd(aiService *ai.Service) *cobra.Command {
+	var includeSystem bool
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "benchmark",
+		Short: "Check the cluster against a subset of the CIS Kubernetes Benchmark",
+		Long: `Run the CIS Kubernetes Benchmark recommendations of section 5 that can be checked
+from the cluster's API: cluster-admin bindings, wildcard and secrets access in
+roles, service account token mounting, privileged, host namespace and root
+containers, capabilities, hostPath volumes, Pod Security Admission labels,
+namespaces without NetworkPolicies, secrets in environment variables, seccomp
+profiles and use of the default namespace.
+
+Each check passes or fails deterministically, and checks whose resources cannot
+be read are skipped. The AI then ranks the failures by risk and writes a
+remediation for each. Control plane and node configuration are not checked.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			if err := checkAccess(client, "benchmark", ""); err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			results, err := benchmark.Run(ctx, client, benchmark.Options{IncludeSystem: includeSystem})
+			if err != nil {
+				return fmt.Errorf("error running benchmark: %w", err)
+			}
+			report := benchmarkReport{Results: results, Summary: benchmark.Summary(results)}
+
+			if outputFormat == "text" {
+				displayBenchmark(report)
+			}
+			if report.Summary[benchmark.StatusFail] > 0 {
+				if outputFormat == "text" {
+					progressf("\nWriting remediation...\n")
+				}
+				report.Remediation, err = analyzers.NarrateBenchmark(ctx, aiService, results)
+				if err != nil {
+					return err
+				}
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			if report.Remediation != "" {
+				fmt.Printf("\n====== %s ======\n", i18n.T("REMEDIATION"))
+				printMarkdown(report.Remediation)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&includeSystem, "include-system", false, "Also check the workloads of kube-system, kube-public and kube-node-lease")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayBenchmark prints the result of each check by section, with the first offenders of
+// failed checks, and a summary
+func displayBenchmark(report benchmarkReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("BENCHMARK"))
+	section := ""
+	for _, result := range report.Results {
+		if result.Section != section {
+			section = result.Section
+			fmt.Printf("\n=== %s ===\n", section)
+		}
+
+		status := "[PASS]"
+		switch result.Status {
+		case benchmark.StatusFail:
+			status = "[FAIL]"
+		case benchmark.StatusSkip:
+			status = "[SKIP]"
+		}
+		fmt.Printf("%-7s %-7s %-7s %s\n", status, result.ID, result.Severity, result.Title)
+
+		if result.Status == benchmark.StatusSkip {
+			fmt.Printf("        %s\n", result.Reason)
+			continue
+		}
+		for i, offender := range result.Offenders {
+			if i == maxDisplayedOffenders {
+				fmt.Printf("        ... and %d more\n", len(result.Offenders)-maxDisplayedOffenders)
+				break
+			}
+			fmt.Printf("        - %s\n", offender)
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n",
+		report.Summary[benchmark.StatusPass], report.Summary[benchmark.StatusFail], report.Summary[benchmark.StatusSkip])
+}
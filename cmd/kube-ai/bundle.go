@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/bundle"
+)
+
+// createBundleCmd creates the bundle command group
+func createBundleCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	bundleCmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Create and analyze offline incident bundles",
+		Long: `Package a workload's manifests, logs, events, and metrics into a tar.gz bundle
+that can be analyzed later or on a different machine (e.g. from an air-gapped
+cluster to an analyst laptop with LLM access).`,
+	}
+
+	var outputFile string
+	var tailLines int64
+	var previous bool
+
+	createCmd := &cobra.Command{
+		Use:   "create [resource-type] [resource-name]",
+		Short: "Collect an incident bundle for a workload",
+		Long:  "Collect manifests, logs, events, and metrics for a workload into a tar.gz file. No AI provider is contacted.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, resourceName := parseResourceArgs(args)
+			if resourceName == "" {
+				return usageErrorf("please provide a workload as <type> <name> or <type>/<name>")
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			namespace := client.GetNamespace()
+			if err := checkAccess(client, "bundle", namespace); err != nil {
+				return err
+			}
+			progressf("Collecting bundle for %s/%s in namespace %s...\n", resourceType, resourceName, namespace)
+
+			b, err := bundle.Collect(context.Background(), client, bundle.CreateOptions{
+				ResourceType: resourceType,
+				ResourceName: resourceName,
+				Namespace:    namespace,
+				TailLines:    tailLines,
+				Previous:     previous,
+			})
+			if err != nil {
+				return kubeErrorf("error collecting bundle: %w", err)
+			}
+
+			if outputFile == "" {
+				outputFile = fmt.Sprintf("kube-ai-bundle-%s-%s-%s.tar.gz",
+					namespace, resourceName, b.Metadata.CreatedAt.Format("20060102-150405"))
+			}
+
+			if err := b.Write(outputFile); err != nil {
+				return fmt.Errorf("error writing bundle: %w", err)
+			}
+
+			fmt.Printf("Bundle written to %s (%d manifests, %d log streams, %d events, %d pod metrics)\n",
+				outputFile, len(b.Manifests), len(b.Logs), len(b.Events), len(b.Metrics))
+			for _, warning := range b.Metadata.Warnings {
+				fmt.Printf("Warning: could not collect %s\n", warning)
+			}
+			return nil
+		},
+	}
+
+	createCmd.Flags().StringVarP(&outputFile, "output-file", "f", "", "Path of the bundle file to write")
+	createCmd.Flags().Int64VarP(&tailLines, "tail", "t", 1000, "Number of log lines to include per container")
+	createCmd.Flags().BoolVarP(&previous, "previous", "p", false, "Include logs from previously terminated containers")
+
+	var outputFormat string
+	var interactive bool
+	var saveName string
+
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze [bundle-file]",
+		Short: "Analyze a previously created incident bundle",
+		Long:  "Analyze an incident bundle offline. No cluster access is required.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interactive && outputFormat == "json" {
+				return usageErrorf("--interactive cannot be combined with --output json")
+			}
+
+			b, err := bundle.Read(args[0])
+			if err != nil {
+				return fmt.Errorf("error reading bundle: %w", err)
+			}
+
+			progressf("Analyzing bundle for %s/%s in namespace %s (collected %s)...\n",
+				b.Metadata.ResourceType, b.Metadata.ResourceName, b.Metadata.Namespace,
+				b.Metadata.CreatedAt.Format(time.RFC3339))
+
+			analyzer := analyzers.NewBundleAnalyzer(aiService)
+			result, summary, err := analyzer.AnalyzeBundle(context.Background(), b)
+			if err != nil {
+				return fmt.Errorf("error analyzing bundle: %w", err)
+			}
+
+			switch outputFormat {
+			case "json":
+				if err := displayJSONResults(summary, result, nil, nil, nil, nil, nil); err != nil {
+					return err
+				}
+			default:
+				displayFormattedResults(summary, result)
+			}
+			recordResult(result.Severity, result)
+
+			if saveName != "" {
+				progress := progressWriter(outputFormat == "json")
+				saved := analyzers.SavedAnalysis{
+					CreatedAt:    b.Metadata.CreatedAt,
+					Namespace:    b.Metadata.Namespace,
+					ResourceType: b.Metadata.ResourceType,
+					ResourceName: b.Metadata.ResourceName,
+					Summary:      summary,
+					Analysis:     *result,
+				}
+				if err := saveAnalysis(progress, saveName, saved); err != nil {
+					return fmt.Errorf("error saving analysis: %w", err)
+				}
+			}
+
+			if interactive {
+				conversation := analyzers.NewLogAnalyzer(aiService).NewConversation(b.LogEntries(), summary, result)
+				runFollowUpChat(conversation)
+			}
+			return nil
+		},
+	}
+
+	analyzeCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+	analyzeCmd.Flags().StringVar(&saveName, "save", "", "Save the analysis under this name for comparison with 'kube-ai analysis diff'")
+	analyzeCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "After the analysis, ask follow-up questions about it")
+
+	bundleCmd.AddCommand(createCmd)
+	bundleCmd.AddCommand(analyzeCmd)
+
+	return bundleCmd
+}
+
+// parseResourceArgs accepts either "<type> <name>" or "<type>/<name>"
+func parseResourceArgs(args []string) (string, string) {
+	if len(args) >= 2 {
+		return args[0], args[1]
+	}
+	if len(args) == 1 {
+		if resourceType, resourceName, ok := strings.Cut(args[0], "/"); ok {
+			return resourceType, resourceName
+		}
+	}
+	return "", ""
+}
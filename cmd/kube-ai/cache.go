@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/k8s"
+)
+
+// createCacheCmd creates the cache command
+func createCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of cluster lists",
+		Long: `With --cache, Kube-AI keeps the lists of pods, workloads, namespaces, service
+accounts and RBAC objects it reads, and the APIs it discovers, in ~/.kube-ai/cache,
+one directory per cluster and identity and readable only by you. The next command
+given --cache lists only the objects' metadata and fetches the objects whose
+resource version changed, instead of listing the whole cluster again. Cached pods
+and workloads keep their full specs, environment variables included; Secrets are
+never cached. Stateless mode refuses --cache.`,
+	}
+
+	// Remove the cache
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached list and discovered API",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := k8s.DefaultCacheDir()
+			if err != nil {
+				return fmt.Errorf("error finding the cache directory: %w", err)
+			}
+			if err := k8s.ClearCache(dir); err != nil {
+				return fmt.Errorf("error clearing the cache: %w", err)
+			}
+			fmt.Printf("Cleared cache: %s\n", dir)
+			return nil
+		},
+	}
+
+	cacheCmd.AddCommand(clearCmd)
+
+	return cacheCmd
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// capacityReport is the JSON output of capacity
+type capacityReport struct {
+	*k8s.ClusterCapacity
+	Pools []k8s.PoolCapacity `json:"pools"`
+	Plan  string             `json:"plan,omitempty"`
+}
+
+// createCapacityCmd creates the capacity command
+func createCapacityCmd(aiService *ai.Service) *cobra.Command {
+	var top int
+	var growth int
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "capacity",
+		Short: "Forecast cluster capacity and recommend node pool sizing",
+		Long: `Compare the allocatable resources of each node pool with what pods request and
+actually use, list pods waiting to be scheduled and recent cluster autoscaler or
+Karpenter activity, and get an AI forecast and node pool sizing recommendation.
+
+The largest workloads are shown with how many more of their pods fit on the
+current nodes, the headroom needed to reschedule them after losing a node.
+Usage is read from the metrics API when metrics-server is installed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if top < 1 {
+				return usageErrorf("--top must be at least 1")
+			}
+			if growth < 0 {
+				return usageErrorf("--growth must not be negative")
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			ctx := context.Background()
+			capacity, err := client.GetClusterCapacity(ctx, top)
+			if err != nil {
+				return kubeErrorf("error getting cluster capacity: %w", err)
+			}
+			if len(capacity.Nodes) == 0 {
+				return fmt.Errorf("no nodes found in the cluster")
+			}
+
+			if outputFormat == "text" {
+				displayCapacity(capacity)
+				progressf("\nForecasting capacity...\n")
+			}
+			report := capacityReport{ClusterCapacity: capacity, Pools: capacity.Pools()}
+			report.Plan, err = analyzers.PlanCapacity(ctx, aiService, capacity, growth)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("RECOMMENDATIONS"))
+			printMarkdown(report.Plan)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&top, "top", 10, "Number of largest workloads to check headroom for")
+	cmd.Flags().IntVar(&growth, "growth", 0, "Expected growth in demand to plan for, in percent")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayCapacity prints the node pools, pending pods, largest workloads and autoscaler activity
+func displayCapacity(capacity *k8s.ClusterCapacity) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("CAPACITY"))
+
+	fmt.Printf("\n=== %s ===\n", i18n.T("Node Pools"))
+	fmt.Printf("%-24s %-7s %-26s %-26s %s\n", "POOL", "NODES", "CPU (REQ/USED/ALLOC)", "MEMORY (REQ/USED/ALLOC)", "PODS")
+	for _, pool := range capacity.Pools() {
+		cpuUsed, memoryUsed := "-", "-"
+		if pool.UsedCPU >= 0 {
+			cpuUsed, memoryUsed = k8s.FormatCPU(pool.UsedCPU), k8s.FormatMemory(pool.UsedMemory)
+		}
+		fmt.Printf("%-24s %-7s %-26s %-26s %d/%d\n",
+			pool.Name,
+			fmt.Sprintf("%d/%d", pool.Schedulable, pool.Nodes),
+			fmt.Sprintf("%s/%s/%s", k8s.FormatCPU(pool.RequestedCPU), cpuUsed, k8s.FormatCPU(pool.AllocatableCPU)),
+			fmt.Sprintf("%s/%s/%s", k8s.FormatMemory(pool.RequestedMemory), memoryUsed, k8s.FormatMemory(pool.AllocatableMemory)),
+			pool.Pods, pool.AllocatablePods)
+	}
+	if !capacity.MetricsAvailable {
+		fmt.Println("Usage is not available: the metrics API is not installed")
+	}
+
+	if len(capacity.Pending) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Pending Pods"))
+		for _, pod := range capacity.Pending {
+			fmt.Printf("- %s/%s (%s CPU, %s memory)", pod.Namespace, pod.Name, k8s.FormatCPU(pod.CPU), k8s.FormatMemory(pod.Memory))
+			if pod.Reason != "" {
+				fmt.Printf(": %s", pod.Reason)
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Printf("\n=== %s ===\n", i18n.T("Largest Workloads"))
+	fmt.Printf("%-40s %-6s %-12s %-12s %s\n", "WORKLOAD", "PODS", "CPU/POD", "MEMORY/POD", "HEADROOM")
+	for _, workload := range capacity.LargestWorkloads {
+		nodes, fit := capacity.Headroom(workload)
+		fmt.Printf("%-40s %-6d %-12s %-12s %d more pods on %d nodes\n",
+			fmt.Sprintf("%s/%s/%s", workload.Namespace, workload.Kind, workload.Name),
+			workload.Pods, k8s.FormatCPU(workload.CPU), k8s.FormatMemory(workload.Memory), fit, nodes)
+	}
+
+	if len(capacity.AutoscalerEvents) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Autoscaler Events"))
+		for i, event := range capacity.AutoscalerEvents {
+			if i >= 10 {
+				fmt.Printf("... and %d more\n", len(capacity.AutoscalerEvents)-i)
+				break
+			}
+			fmt.Printf("- %s %s %s: %s\n", event.Time.Format("2006-01-02 15:04"), event.Reason, event.Object, event.Message)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// certificateReport is the JSON output of analyze certificate
+type certificateReport struct {
+	*k8s.CertificateIssuance
+	Analysis string `json:"analysis"`
+}
+
+// analyzeCertificate troubleshoots the issuance of a cert-manager Certificate, for analyze
+// certificate <name>
+func analyzeCertificate(cmd *cobra.Command, aiService *ai.Service, name, outputFormat string) error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return usageErrorf("unsupported output format %q for certificates, use text or json", outputFormat)
+	}
+
+	client, err := k8s.NewClientFromFlags(cmd)
+	if err != nil {
+		return kubeErrorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx := context.Background()
+	certificate, err := client.GetCertificateIssuance(ctx, client.GetNamespace(), name)
+	if err != nil {
+		return kubeErrorf("%w", err)
+	}
+
+	if outputFormat == "text" {
+		displayCertificateIssuance(certificate)
+		progressf("\nTroubleshooting...\n")
+	}
+	report := certificateReport{CertificateIssuance: certificate}
+	report.Analysis, err = analyzers.TroubleshootCertificate(ctx, aiService, certificate)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("error encoding report: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+	printMarkdown(report.Analysis)
+	return nil
+}
+
+// displayCertificateIssuance prints a Certificate, its issuer, the state of each resource of its
+// issuance and the problems detected in them
+func displayCertificateIssuance(certificate *k8s.CertificateIssuance) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("CERTIFICATE"))
+	fmt.Printf("%s/%s\n", certificate.Namespace, certificate.Name)
+	fmt.Printf("%-14s %s\n", "DNS names:", orNone(certificate.DNSNames))
+	fmt.Printf("%-14s %s\n", "Secret:", certificate.SecretName)
+	issuer := certificate.Issuer + ": Ready=" + certificate.IssuerReady
+	if certificate.IssuerMessage != "" {
+		issuer += " (" + certificate.IssuerMessage + ")"
+	}
+	fmt.Printf("%-14s %s\n", "Issuer:", issuer)
+	if certificate.NotAfter != "" {
+		fmt.Printf("%-14s %s\n", "Expires:", certificate.NotAfter)
+	}
+
+	fmt.Printf("\n=== %s ===\n", i18n.T("Resources"))
+	for _, resource := range certificate.Resources {
+		state := resource.State
+		if resource.Reason != "" {
+			state += " (" + resource.Reason + ")"
+		}
+		if resource.Type != "" {
+			state += " " + resource.Type + " " + resource.DNSName
+		}
+		fmt.Printf("%-50s %s\n", resource, state)
+		if resource.Message != "" {
+			fmt.Printf("  %s\n", resource.Message)
+		}
+	}
+
+	if len(certificate.Problems) > 0 {
+		fmt.Println()
+	}
+	for _, problem := range certificate.Problems {
+		fmt.Printf("%s%s%s: %s\n", severityColor("High"), problem.Problem, color(ansiReset), problem.Hint)
+	}
+}
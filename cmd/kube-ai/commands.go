@@ -5,20 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"net/http"
 	"os"
-	"os/signal"
+	"os/exec"
+	"slices"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"kube-ai/internal/config"
 	"kube-ai/pkg/ai"
 	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
 	"kube-ai/pkg/k8s"
 	"kube-ai/pkg/k8s/logs"
+	"kube-ai/pkg/k8s/manifest"
+	"kube-ai/pkg/progress"
 	"kube-ai/pkg/version"
 )
 
@@ -28,62 +33,366 @@ func createRootCommand(cfg *config.Config, aiService *ai.Service) *cobra.Command
 		Use:   "kube-ai",
 		Short: "AI-powered Kubernetes assistant",
 		Long:  `Kube-AI is an AI-powered assistant for Kubernetes, providing intelligent assistance for cluster management.`,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Errors are rendered by main, with their exit code
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			commandStarted = true
+			k8s.ResetSkippedSources()
+
+			// Keep progress messages out of standard output with --quiet, for shell pipelines
+			quietOutput, _ = cmd.Flags().GetBool("quiet")
+
+			// Print colors on terminals only, and not with --no-color or NO_COLOR
+			noColor, _ := cmd.Flags().GetBool("no-color")
+			setColorOutput(noColor)
+
+			// Load the configuration from --config, $KUBE_AI_CONFIG or ~/.kube-ai. Flags
+			// override environment variables, which override the file.
+			configPath, _ := cmd.Root().PersistentFlags().GetString("config")
+			loaded, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			*cfg = *loaded
+			aiService.Init(cfg)
+
+			// The cluster cache keeps cluster objects on disk, which stateless mode rules out
+			if cache, _ := cmd.Flags().GetBool("cache"); cache && cfg.Stateless() {
+				return usageErrorf("--cache writes the cluster cache, which %s disables", config.StatelessEnv)
+			}
+
+			// Show a spinner on terminals while AI answers are awaited, once Init has reset the
+			// service
+			aiService.OnRequest(requestSpinner())
+			suggestOnboarding(cmd, cfg)
+
+			// Refuse hosted providers from here on if local-only mode is enabled anywhere, so
+			// neither a profile nor a flag can send cluster data off the premises
+			localOnly, _ := cmd.Flags().GetBool("local-only")
+			if localOnly || cfg.LocalOnlyEnforced() {
+				aiService.EnforceLocalOnly()
+			}
+
 			// Update kubeconfig path in cfg if set via flag
 			kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
 			if kubeconfig != "" {
 				cfg.KubeConfigPath = kubeconfig
 			}
+
+			// Apply the output language, preferring the flag over the configuration
+			language, _ := cmd.Flags().GetString("language")
+			if language == "" {
+				language = cfg.Language
+			}
+			i18n.SetLanguage(language)
+			aiService.SetLanguage(language)
+
+			// Expose the target context and namespace to prompt templates
+			if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+				aiService.SetClusterContext(k8s.CurrentContext(clientConfig))
+			}
+
+			// Tell prompts which Kubernetes release they target and leave out suggestions it
+			// does not support; the cluster is only asked once a prompt needs it
+			aiService.SetClusterVersionLoader(func() (*ai.ClusterVersion, error) {
+				return loadClusterVersion(cmd)
+			})
+
+			// Start every prompt with the platform, network plugin, ingress classes and operators
+			// of the cluster when the cluster context is on, read once per invocation
+			clusterContext, _ := cmd.Flags().GetBool("cluster-context")
+			if clusterContext || cfg.ClusterContext {
+				aiService.SetClusterEnvironmentLoader(func() (*ai.ClusterEnvironment, error) {
+					return loadClusterEnvironment(cmd)
+				})
+			}
+
+			// Pick the model for the command's task when model routing is on
+			aiService.RouteTask(commandKey(cmd), commandTask(commandKey(cmd)))
+
+			// Apply the persona and prompt templates bound to the command, if any
+			if bound, binding, ok := cfg.CommandBinding(commandKey(cmd)); ok {
+				if err := aiService.ApplyCommandBinding(binding); err != nil {
+					return fmt.Errorf("error in the binding of command %q: %w", bound, err)
+				}
+			}
+
+			// Apply the profile named with --profile or bound to the target context and
+			// namespace, except while managing profiles so a broken one can be fixed
+			if !isProfileCmd(cmd) {
+				if err := applyProfile(cmd, cfg, aiService); err != nil {
+					return err
+				}
+			}
+
+			// Show prompts instead of sending them, so users can audit what would leave the cluster
+			showPrompt, _ := cmd.Flags().GetBool("show-prompt")
+			dryRunAI, _ := cmd.Flags().GetBool("dry-run-ai")
+			if showPrompt || dryRunAI {
+				aiService.SetDryRun(os.Stdout)
+			}
+
+			// Keep the answers of the AI provider for the history command
+			if !cfg.Stateless() && !cfg.DisableHistory && !isHistoryCmd(cmd) {
+				recordHistory(cmd, args, aiService)
+			}
+
+			// Capture the output for the sinks named with --notify, delivered once the command succeeds
+			if sinks, _ := cmd.Flags().GetStringSlice("notify"); len(sinks) > 0 {
+				return startNotification(cfg, sinks)
+			}
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			displaySkippedSources(os.Stderr, k8s.SkippedSources())
+			return sendNotification(cmd, args)
 		},
 	}
 
 	// Add standard kubectl flags to all commands
 	k8s.AddKubectlFlags(rootCmd)
 
+	// Language for AI answers and CLI section headers
+	rootCmd.PersistentFlags().String("language", "", "Language for AI answers and output headers (e.g. es, de, ja); defaults to the configured language")
+
+	// Standard output carries only the result, so it can be piped
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Print only the result (text, JSON or YAML) to standard output and progress messages to standard error")
+
+	// Colors and Markdown rendering on terminals, on top of NO_COLOR
+	rootCmd.PersistentFlags().Bool("no-color", false, "Print no colors and AI answers as raw Markdown, as NO_COLOR does (the default when output is not a terminal)")
+
+	// Configuration file, overriding $KUBE_AI_CONFIG and the default file
+	rootCmd.PersistentFlags().String("config", "", "Configuration file, YAML or JSON (default: $KUBE_AI_CONFIG or ~/.kube-ai/config.yaml, config.yml or config.json)")
+
+	// Configuration profile, overriding the one bound to the target context or namespace
+	rootCmd.PersistentFlags().String("profile", "", "Configuration profile to use (default: the profile bound to the context or namespace, or $KUBE_AI_PROFILE)")
+
+	// Prompt preview, to audit the data AI commands would send
+	rootCmd.PersistentFlags().Bool("show-prompt", false, "Print the prompt as it would be sent, after redaction and truncation, without calling the AI provider")
+	rootCmd.PersistentFlags().Bool("dry-run-ai", false, "Same as --show-prompt")
+
+	// Local-only mode, on top of the configuration file and $KUBE_AI_LOCAL_ONLY
+	rootCmd.PersistentFlags().Bool("local-only", false, "Fail instead of sending prompts to an AI provider whose endpoint is not localhost or a private network")
+
+	// Cluster context preamble of prompts, on top of the clusterContext key of the configuration file
+	rootCmd.PersistentFlags().Bool("cluster-context", false, "Start every prompt with the cluster's release, platform (EKS, GKE, AKS, on-premises...), network plugin, ingress classes and operators, so answers fit it")
+
+	// Delivery of results to the sinks of the configuration file
+	rootCmd.PersistentFlags().StringSlice("notify", nil, "Send the command's output to these sinks from the configuration file (webhook or smtp) once it succeeds")
+
 	// Add subcommands
 	rootCmd.AddCommand(createAnalyzeCmd(cfg, aiService))
 	rootCmd.AddCommand(createOptimizeCmd(cfg, aiService))
 	rootCmd.AddCommand(createScalingCmd(cfg, aiService))
 	rootCmd.AddCommand(createGenerateCmd(cfg, aiService))
 	rootCmd.AddCommand(createExplainCmd(cfg, aiService))
+	rootCmd.AddCommand(createExplainFieldCmd(aiService))
+	rootCmd.AddCommand(createUpgradeCheckCmd(aiService))
+	rootCmd.AddCommand(createCapacityCmd(aiService))
+	rootCmd.AddCommand(createRolloutRiskCmd(aiService))
+	rootCmd.AddCommand(createAnalyzeArgoCmd(aiService))
+	rootCmd.AddCommand(createAnalyzeFluxCmd(aiService))
+	rootCmd.AddCommand(createAnalyzeValuesCmd(aiService))
+	rootCmd.AddCommand(createAnalyzeMeshCmd(aiService))
+	rootCmd.AddCommand(createAnalyzeGPUCmd(aiService))
+	rootCmd.AddCommand(createExplainScalingEventsCmd(aiService))
+	rootCmd.AddCommand(createDiagnoseCmd(aiService))
+	rootCmd.AddCommand(createAnalyzeImagesCmd(aiService))
+	rootCmd.AddCommand(createBenchmarkCmd(aiService))
+	rootCmd.AddCommand(createAuditSecretsCmd(aiService))
+	rootCmd.AddCommand(createCtxCmd(cfg, aiService))
+	rootCmd.AddCommand(createSummarizeCmd(aiService))
+	rootCmd.AddCommand(createHowCmd(aiService))
+	rootCmd.AddCommand(createGetCmd(aiService))
 	rootCmd.AddCommand(createVersionCmd())
 
 	// Add log analysis command
 	rootCmd.AddCommand(createAnalyzeLogsCmd(cfg, aiService))
 
+	// Add agent command
+	rootCmd.AddCommand(createAgentCmd(cfg, aiService))
+
+	// Add incident bundle commands
+	rootCmd.AddCommand(createBundleCmd(cfg, aiService))
+
+	// Add incident enrichment command
+	rootCmd.AddCommand(createEnrichIncidentCmd(cfg, aiService))
+
+	// Add terminal dashboard command
+	rootCmd.AddCommand(createTUICmd(cfg, aiService))
+
+	// Add RBAC generation command
+	rootCmd.AddCommand(createRBACCmd())
+
+	// Add guided setup command
+	rootCmd.AddCommand(createOnboardCmd(cfg))
+
 	// Add configuration/provider management commands
 	rootCmd.AddCommand(createChatCmd(cfg, aiService))
 	rootCmd.AddCommand(createSetModelCmd(cfg, aiService))
 	rootCmd.AddCommand(createListModelsCmd(cfg, aiService))
+	rootCmd.AddCommand(createModelsCmd(cfg, aiService))
 	rootCmd.AddCommand(createSetProviderCmd(cfg, aiService))
 	rootCmd.AddCommand(createListProvidersCmd(cfg, aiService))
 	rootCmd.AddCommand(createSetApiKeyCmd(cfg, aiService))
+	rootCmd.AddCommand(createSetLanguageCmd(cfg))
 
 	// Add persona command
 	rootCmd.AddCommand(createPersonaCmd(cfg))
 
+	// Add configuration commands
+	rootCmd.AddCommand(createConfigCmd(cfg))
+
+	// Add configuration profile command
+	rootCmd.AddCommand(createProfileCmd(cfg))
+
+	// Add redaction preview command
+	rootCmd.AddCommand(createRedactTestCmd(aiService))
+
+	// Add prompt template commands
+	rootCmd.AddCommand(createPromptsCmd(aiService))
+
+	// Add saved analysis commands
+	rootCmd.AddCommand(createAnalysisCmd(aiService))
+
+	// Add answer history command
+	rootCmd.AddCommand(createHistoryCmd(cfg, aiService))
+
+	// Add cluster cache command
+	rootCmd.AddCommand(createCacheCmd())
+
+	// Add analyzer plugin command
+	rootCmd.AddCommand(createPluginsCmd())
+
 	return rootCmd
 }
 
 // createAnalyzeCmd creates the analyze command
 func createAnalyzeCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var filename string
+	var outputFormat string
+	var pluginNames []string
+	var selection selectorOptions
 
 	cmd := &cobra.Command{
 		Use:   "analyze [resource-type] [resource-name]",
 		Short: "Analyze Kubernetes resources",
-		Long:  `Analyze Kubernetes resources and provide insights and recommendations.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Analyze Kubernetes resources and provide insights and recommendations.
+
+With --output json, junit or github, the analysis is returned as structured findings.
+The JUnit report has one failing test case per finding, so CI systems such as
+Jenkins and GitLab can show them in their test report UIs. The github format
+emits GitHub Actions annotations on the --filename input so they show inline
+on pull request diffs. For --filename inputs, each finding references the path
+and line of the offending field, such as spec.template.spec.containers[0].resources.
+
+--filename - reads the input from standard input, such as kubectl get -o yaml
+output. kubectl describe output and logs, piped or in a file, are routed to
+their own analyses instead: the state of the described resource is explained,
+and logs are analyzed as analyze-logs does.
+
+--selector (-l) and --field-selector analyze every object of a resource type
+they match, such as analyze deployments -l app.kubernetes.io/part-of=checkout,
+together in one analysis, with --all-namespaces (-A) across namespaces. Objects
+opted out with the kube-ai.io/ignore annotation are left out.
+
+--plugin runs external analyzer plugins (kube-ai-analyzer-<name> executables on
+PATH, see kubectl ai plugins list) on the same input and adds their findings.
+
+analyze certificate <name> troubleshoots a cert-manager Certificate instead: it
+walks the Certificate to its CertificateRequest, ACME Order and Challenges and
+their events, and explains why issuance is stuck (DNS01 propagation, HTTP01
+routing, rate limits) with the next steps.
+
+analyze job <name> and analyze cronjob <name> explain why a Job fails or a
+CronJob never runs: they collect the backoffLimit status and failed pod logs
+across retries, the CronJob schedule and concurrency settings, and the events
+of missed or skipped runs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var deploymentYAML string
-			var err error
+			var source string
+			input := analyzers.PluginInput{}
+
+			switch outputFormat {
+			case "text", "json", "junit":
+			case "github":
+				if filename == "" {
+					return usageErrorf("--output github requires --filename so findings can be annotated on the file")
+				}
+			default:
+				return usageErrorf("unsupported output format %q (expected text, json, junit or github)", outputFormat)
+			}
+
+			if filename != "" && selection.set() {
+				return usageErrorf("--selector and --field-selector cannot be combined with --filename")
+			}
+
+			// cert-manager Certificates are troubleshot through their issuance chain
+			if filename == "" && len(args) >= 2 && k8s.IsCertificateKind(args[0]) {
+				if len(pluginNames) > 0 {
+					return usageErrorf("--plugin is not supported for certificates")
+				}
+				return analyzeCertificate(cmd, aiService, args[1], outputFormat)
+			}
+			// Jobs and CronJobs are analyzed through their runs and attempts
+			if filename == "" && len(args) >= 2 && k8s.IsJobKind(args[0]) {
+				if len(pluginNames) > 0 {
+					return usageErrorf("--plugin is not supported for jobs")
+				}
+				return analyzeJob(cmd, aiService, args[0], args[1], outputFormat)
+			}
 
 			if filename != "" {
-				// Read from file
-				data, err := os.ReadFile(filename)
+				// Read from file, or stdin for -
+				data, err := readInput(filename)
 				if err != nil {
-					log.Fatalf("Error reading file: %v", err)
+					return fmt.Errorf("error reading file: %w", err)
 				}
 				deploymentYAML = string(data)
+				source = filename
+				if filename == "-" {
+					source = "stdin"
+				}
+
+				// Piped describe output and logs have their own analyses
+				if kind := detectInput(deploymentYAML); kind == inputDescribe || kind == inputLogs {
+					if outputFormat != "text" {
+						return usageErrorf("--output %s needs a manifest, and %s holds %s", outputFormat, source, kind)
+					}
+					if len(pluginNames) > 0 {
+						return usageErrorf("--plugin needs a manifest, and %s holds %s", source, kind)
+					}
+					if kind == inputDescribe {
+						return explainDescribe(aiService, deploymentYAML)
+					}
+					return analyzeLogText(aiService, deploymentYAML)
+				}
+			} else if selection.set() {
+				if len(args) != 1 {
+					return usageErrorf("--selector and --field-selector take a resource type and no name, such as analyze deployments -l app=web")
+				}
+				client, err := k8s.NewClientFromFlags(cmd)
+				if err != nil {
+					return kubeErrorf("error creating Kubernetes client: %w", err)
+				}
+				namespace := client.GetNamespace()
+				if client.IsAllNamespaces() {
+					namespace = ""
+				}
+				if err := checkAccess(client, "analyze", namespace); err != nil {
+					return err
+				}
+
+				deploymentYAML, err = selectedManifests(context.Background(), client, selection, args[0])
+				if err != nil {
+					return err
+				}
+				source = fmt.Sprintf("%s matching %s", strings.ToLower(args[0]), selection)
+				input.Namespace = namespace
+				if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+					input.Context, _ = k8s.CurrentContext(clientConfig)
+				}
 			} else if len(args) >= 2 {
 				// Get from kubernetes
 				resourceType := args[0]
@@ -92,31 +401,100 @@ func createAnalyzeCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 				// Initialize the Kubernetes client with kubectl flags
 				client, err := k8s.NewClientFromFlags(cmd)
 				if err != nil {
-					log.Fatalf("Error creating Kubernetes client: %v", err)
+					return kubeErrorf("error creating Kubernetes client: %w", err)
 				}
 
 				// Get the namespace from the client (which respects kubectl flags)
 				namespace := client.GetNamespace()
+				if err := checkAccess(client, "analyze", namespace); err != nil {
+					return err
+				}
 
-				// This is a simplified example - in a real implementation,
-				// you would need to get the YAML representation of the resource
-				deploymentYAML = fmt.Sprintf("Resource type: %s, name: %s, namespace: %s",
-					resourceType, resourceName, namespace)
+				if _, err := checkOptOut(context.Background(), client, resourceType, resourceName, namespace); err != nil {
+					return err
+				}
+
+				deploymentYAML, err = client.GetResourceYAML(context.Background(), resourceType, resourceName, namespace)
+				if err != nil {
+					return kubeErrorf("error getting resource: %w", err)
+				}
+				source = fmt.Sprintf("%s/%s", strings.ToLower(resourceType), resourceName)
+				input.Namespace = namespace
+				if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+					input.Context, _ = k8s.CurrentContext(clientConfig)
+				}
 			} else {
-				log.Fatalf("Please provide resource type and name or use --filename flag")
+				return usageErrorf("please provide resource type and name or use --filename flag")
+			}
+
+			plugins, err := analyzers.SelectPlugins(pluginNames)
+			if err != nil {
+				return err
+			}
+			// Run plugins first, so a failing one stops the command before the AI is queried
+			input.Source, input.Manifest = source, deploymentYAML
+			pluginFindings, err := runPlugins(plugins, input)
+			if err != nil {
+				return err
 			}
 
-			result, err := aiService.AnalyzeDeployment(deploymentYAML)
+			if outputFormat == "text" {
+				result, err := aiService.AnalyzeDeployment(deploymentYAML)
+				if err != nil {
+					return fmt.Errorf("error analyzing deployment: %w", err)
+				}
+
+				printMarkdown(result)
+				if len(plugins) > 0 {
+					printPluginFindings(pluginFindings)
+				}
+				return nil
+			}
+
+			// Structured findings for machine-readable output
+			analyzer := analyzers.NewManifestAnalyzer(aiService)
+			result, err := analyzer.AnalyzeManifest(context.Background(), deploymentYAML)
 			if err != nil {
-				log.Fatalf("Error analyzing deployment: %v", err)
+				return fmt.Errorf("error analyzing manifest: %w", err)
 			}
+			result.Findings = append(result.Findings, pluginFindings...)
+			recordResult("", result)
 
-			fmt.Println(result)
+			// Point findings at the exact lines of file inputs
+			if filename != "" {
+				documents, err := manifest.Parse([]byte(deploymentYAML))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not locate findings in %s: %v\n", filename, err)
+				} else {
+					result.Locate(documents)
+				}
+			}
+
+			switch outputFormat {
+			case "junit":
+				if err := writeJUnitFindings(os.Stdout, source, result); err != nil {
+					return fmt.Errorf("error writing JUnit report: %w", err)
+				}
+			case "github":
+				if err := writeGitHubFindings(os.Stdout, filename, result); err != nil {
+					return fmt.Errorf("error writing GitHub annotations: %w", err)
+				}
+			default:
+				jsonData, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error formatting JSON output: %w", err)
+				}
+				fmt.Println(string(jsonData))
+			}
+			return nil
 		},
 	}
 
 	// Add command-specific flags (filename is not a standard kubectl flag)
-	cmd.Flags().StringVarP(&filename, "filename", "f", "", "YAML file to analyze")
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "YAML file to analyze, or - for stdin")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json, junit or github)")
+	cmd.Flags().StringArrayVar(&pluginNames, "plugin", nil, "Analyzer plugin to run as well, or all (repeatable)")
+	selection.addFlags(cmd, "objects of the resource type")
 
 	return cmd
 }
@@ -124,41 +502,114 @@ func createAnalyzeCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 // createOptimizeCmd creates the optimize command
 func createOptimizeCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var filename string
+	var patchFormat string
+	var out manifestOutput
+	var apply applyOptions
 
 	cmd := &cobra.Command{
 		Use:   "optimize [options]",
 		Short: "Optimize resource usage",
-		Long:  `Suggest optimizations for resource usage in Kubernetes deployments.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Suggest optimizations for resource usage in Kubernetes deployments.
+
+--out, --out-dir and --copy take the optimized manifest from the answer and
+write it to a file, to one file per resource, or copy it to the clipboard.
+--apply applies it to the cluster (requires --allow-writes) once the changes,
+shown as a diff against the live resources, are confirmed, or without asking
+with --yes.
+
+--patch strategic or --patch json prints only the fields the optimization
+changes, as a strategic merge patch or a JSON patch per resource, for GitOps
+repositories and kubectl patch. Custom resources get a JSON merge patch in
+place of a strategic one.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var resourceYAML string
 			var err error
 
+			if err := out.validate(); err != nil {
+				return err
+			}
+			if err := apply.validate(); err != nil {
+				return err
+			}
+			if patchFormat != "" && !slices.Contains(manifest.PatchFormats, patchFormat) {
+				return usageErrorf("unsupported patch format %q, use %s", patchFormat, strings.Join(manifest.PatchFormats, " or "))
+			}
+			if patchFormat != "" && out.dir != "" {
+				return usageErrorf("--patch cannot be combined with --out-dir; use --out")
+			}
+
 			if filename != "" {
 				// Read from file
 				data, err := os.ReadFile(filename)
 				if err != nil {
-					log.Fatalf("Error reading file: %v", err)
+					return fmt.Errorf("error reading file: %w", err)
 				}
 				resourceYAML = string(data)
 			} else {
-				log.Fatalf("Please provide a YAML file with --filename flag")
+				return usageErrorf("please provide a YAML file with --filename flag")
 			}
 
 			result, err := aiService.OptimizeResources(resourceYAML)
 			if err != nil {
-				log.Fatalf("Error optimizing resources: %v", err)
+				return fmt.Errorf("error optimizing resources: %w", err)
 			}
 
-			fmt.Println(result)
+			optimized := extractYAML(result)
+			if patchFormat != "" {
+				patches, added, err := manifest.CreatePatches([]byte(resourceYAML), []byte(optimized), patchFormat)
+				if err != nil {
+					return fmt.Errorf("error comparing the optimized manifest with %s: %w", filename, err)
+				}
+				for _, resource := range added {
+					fmt.Fprintf(os.Stderr, "Warning: %s is not in %s and is left out of the patch\n", resource, filename)
+				}
+				if len(patches) == 0 {
+					fmt.Fprintln(os.Stderr, "The optimized manifest changes no field")
+				} else if saved, err := out.deliver(formatPatches(patches)); err != nil {
+					return err
+				} else if !saved {
+					fmt.Println(formatPatches(patches))
+				}
+			} else {
+				saved, err := out.deliver(optimized)
+				if err != nil {
+					return err
+				}
+				if !saved {
+					printMarkdown(result)
+				}
+			}
+			if apply.enabled {
+				return apply.apply(cmd, optimized)
+			}
+			return nil
 		},
 	}
 
 	// Add command-specific flags
 	cmd.Flags().StringVarP(&filename, "filename", "f", "", "YAML file to optimize")
+	cmd.Flags().StringVar(&patchFormat, "patch", "", "Print only the changed fields, as a patch: "+strings.Join(manifest.PatchFormats, " or "))
+	out.addFlags(cmd)
+	apply.addFlags(cmd)
 
 	return cmd
 }
 
+// formatPatches renders resource patches as YAML documents, each headed by the kubectl patch
+// command that applies it
+func formatPatches(patches []manifest.ResourcePatch) string {
+	documents := make([]string, len(patches))
+	for i, patch := range patches {
+		command := fmt.Sprintf("# kubectl patch %s %s", strings.ToLower(patch.Kind), patch.Name)
+		if patch.Namespace != "" {
+			command += " -n " + patch.Namespace
+		}
+		command += fmt.Sprintf(" --type %s --patch-file <file>", patch.Type)
+		documents[i] = command + "\n" + strings.TrimSpace(string(patch.Patch))
+	}
+	return strings.Join(documents, "\n---\n")
+}
+
 // createScalingCmd creates the scaling command
 func createScalingCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var metricsFile string
@@ -168,7 +619,7 @@ func createScalingCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 		Use:   "suggest-scaling [resource-name]",
 		Short: "Suggest scaling strategies",
 		Long:  `Suggest optimal scaling strategies for Kubernetes workloads.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var resourceName string
 			var metricsData string
 			var configData string
@@ -181,7 +632,7 @@ func createScalingCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 			if metricsFile != "" {
 				data, err := os.ReadFile(metricsFile)
 				if err != nil {
-					log.Fatalf("Error reading metrics file: %v", err)
+					return fmt.Errorf("error reading metrics file: %w", err)
 				}
 				metricsData = string(data)
 			} else {
@@ -191,14 +642,14 @@ func createScalingCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 			if configFile != "" {
 				data, err := os.ReadFile(configFile)
 				if err != nil {
-					log.Fatalf("Error reading config file: %v", err)
+					return fmt.Errorf("error reading config file: %w", err)
 				}
 				configData = string(data)
 			} else if resourceName != "" {
 				// Initialize the Kubernetes client with kubectl flags
 				client, err := k8s.NewClientFromFlags(cmd)
 				if err != nil {
-					log.Fatalf("Error creating Kubernetes client: %v", err)
+					return kubeErrorf("error creating Kubernetes client: %w", err)
 				}
 
 				// Get the namespace from the client (which respects kubectl flags)
@@ -207,15 +658,16 @@ func createScalingCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 				// In a real implementation, you would get the current configuration from Kubernetes
 				configData = fmt.Sprintf("Resource: %s, Namespace: %s", resourceName, namespace)
 			} else {
-				log.Fatalf("Please provide a resource name or configuration file")
+				return usageErrorf("please provide a resource name or configuration file")
 			}
 
 			result, err := aiService.SuggestScalingStrategy(metricsData, configData)
 			if err != nil {
-				log.Fatalf("Error suggesting scaling strategy: %v", err)
+				return fmt.Errorf("error suggesting scaling strategy: %w", err)
 			}
 
-			fmt.Println(result)
+			printMarkdown(result)
+			return nil
 		},
 	}
 
@@ -229,37 +681,140 @@ func createScalingCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 // createGenerateCmd creates the generate command
 func createGenerateCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var descriptionFile string
+	var interactive bool
+	var opts generateOptions
 
 	cmd := &cobra.Command{
 		Use:   "generate [description]",
 		Short: "Generate Kubernetes manifests",
-		Long:  `Generate Kubernetes manifests from descriptions.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Generate Kubernetes manifests from descriptions.
+
+With --interactive, the generated manifest is validated and you can apply it
+(requires --allow-writes), accept it, or refine it with further instructions
+until it is right. --apply applies the generated manifest directly.
+
+Before anything is applied, the changes are shown as a diff against the live
+resources, from a server-side dry run, and applied once you confirm them, or
+without asking with --yes.
+
+With --stack, a complete application stack is generated: a Deployment, Service,
+Ingress, HorizontalPodAutoscaler, PodDisruptionBudget and NetworkPolicy sharing
+the name and labels given by --name. --out-dir writes one file per resource,
+for stacks optionally as a kustomize base or Helm chart scaffold (--layout).
+--out writes the manifest to a file and --copy copies it to the clipboard.
+
+When the cluster is reachable, custom resources mentioned in the description
+(or named with --crd) are generated from the schemas of the installed CRDs,
+and generated custom resources are validated against them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var description string
 			var err error
 
 			if descriptionFile != "" {
 				data, err := os.ReadFile(descriptionFile)
 				if err != nil {
-					log.Fatalf("Error reading description file: %v", err)
+					return fmt.Errorf("error reading description file: %w", err)
 				}
 				description = string(data)
 			} else if len(args) > 0 {
 				description = strings.Join(args, " ")
 			} else {
-				log.Fatalf("Please provide a description or a description file")
+				return usageErrorf("please provide a description or a description file")
+			}
+
+			if opts.stack {
+				if opts.name == "" {
+					return usageErrorf("--stack requires --name")
+				}
+				if errs := validation.IsDNS1035Label(opts.name); len(errs) > 0 {
+					return usageErrorf("invalid stack name %q: %s", opts.name, strings.Join(errs, "; "))
+				}
+			} else if cmd.Flags().Changed("layout") {
+				return usageErrorf("--layout requires --stack")
+			}
+			if err := opts.out.validate(); err != nil {
+				return err
+			}
+			if opts.layout != manifest.LayoutPlain && opts.out.dir == "" {
+				return usageErrorf("--layout %s requires --out-dir", opts.layout)
+			}
+
+			if interactive && opts.apply.enabled {
+				return usageErrorf("--apply cannot be combined with --interactive, which offers to apply the manifest")
+			}
+			if err := opts.apply.validate(); err != nil {
+				return err
 			}
 
-			result, err := aiService.GenerateManifest(description)
+			opts.customResources, opts.schemas, err = loadCustomResources(cmd, description, opts.crds)
 			if err != nil {
-				log.Fatalf("Error generating manifest: %v", err)
+				return err
+			}
+
+			if interactive {
+				return runGenerateLoop(cmd, aiService, description, opts)
+			}
+
+			result, err := opts.generate(aiService, description)
+			if err != nil {
+				return fmt.Errorf("error generating manifest: %w", err)
+			}
+
+			content := extractYAML(result)
+			if opts.stack {
+				for _, problem := range opts.validate(content) {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+				}
+				documents, err := labelStack(content, opts.name)
+				if err != nil {
+					return err
+				}
+				data, err := manifest.Join(documents)
+				if err != nil {
+					return fmt.Errorf("error encoding stack: %w", err)
+				}
+				content = string(data)
 			}
 
+			saved, err := opts.save(content)
+			if err != nil {
+				return err
+			}
+
+			if opts.apply.enabled {
+				if problems := opts.validate(content); len(problems) > 0 {
+					return fmt.Errorf("the generated manifest was not applied, as it is invalid:\n- %s", strings.Join(problems, "\n- "))
+				}
+				if !saved {
+					fmt.Printf("\n====== %s ======\n", i18n.T("GENERATED MANIFEST"))
+					fmt.Println(strings.TrimSpace(content))
+				}
+				return opts.apply.apply(cmd, content)
+			}
+			if saved {
+				return nil
+			}
+
+			if opts.stack {
+				fmt.Print(content)
+				return nil
+			}
 			fmt.Println(result)
+			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&descriptionFile, "file", "f", "", "File containing manifest description")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Validate the manifest and apply, accept or refine it in a loop")
+	opts.out.addFlags(cmd)
+	cmd.Flags().SetNormalizeFunc(outputFlagAliases)
+	cmd.Flags().BoolVar(&opts.stack, "stack", false, "Generate a complete application stack (Deployment, Service, Ingress, HPA, PDB, NetworkPolicy)")
+	cmd.Flags().StringVar(&opts.name, "name", "", "Name and app.kubernetes.io/name label of the stack resources")
+	cmd.Flags().StringVar(&opts.layout, "layout", manifest.LayoutPlain, "Layout of the stack directory: "+strings.Join(manifest.Layouts, ", "))
+	cmd.Flags().StringSliceVar(&opts.crds, "crd", nil, "Installed CRD to generate from, by name, kind or plural (repeatable)")
+	opts.apply.addFlags(cmd)
+
+	cmd.AddCommand(createGeneratePolicyCmd(aiService))
 
 	return cmd
 }
@@ -267,42 +822,106 @@ func createGenerateCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command
 // createExplainCmd creates the explain command
 func createExplainCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var errorFile string
+	var manifestFile string
+	var crds []string
 
 	cmd := &cobra.Command{
-		Use:   "explain [error-message]",
+		Use:   "explain [error-message | -]",
 		Short: "Explain Kubernetes errors",
-		Long:  `Explain Kubernetes errors in simple terms and suggest fixes.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Explain Kubernetes errors in simple terms and suggest fixes.
+
+When the cluster is reachable, the schemas of custom resources mentioned in the
+error (or named with --crd) are included, and a related manifest given with
+--manifest is validated against the installed CRDs.
+
+Input read from standard input (with - or no argument) or --file is routed by
+what it holds: kubectl describe output is explained as the state of its
+resource, logs are analyzed as analyze-logs does, and objects such as
+kubectl get -o yaml prints are analyzed as analyze does. Anything else is
+explained as an error.`,
+		Example: `  kubectl ai explain "Failed to pull image: ErrImagePull"
+  kubectl describe pod web-0 | kubectl ai explain -
+  kubectl logs web-0 --tail 200 | kubectl ai explain -`,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var errorMessage string
 			var err error
 
+			piped := true
 			if errorFile != "" {
-				data, err := os.ReadFile(errorFile)
+				data, err := readInput(errorFile)
 				if err != nil {
-					log.Fatalf("Error reading error file: %v", err)
+					return fmt.Errorf("error reading error file: %w", err)
 				}
 				errorMessage = string(data)
-			} else if len(args) > 0 {
+			} else if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
 				errorMessage = strings.Join(args, " ")
+				piped = false
 			} else {
 				// Try to read from stdin
 				stdinData, err := io.ReadAll(os.Stdin)
 				if err != nil || len(stdinData) == 0 {
-					log.Fatalf("Please provide an error message or use --file flag")
+					return usageErrorf("please provide an error message or use --file flag")
 				}
 				errorMessage = string(stdinData)
 			}
 
-			result, err := aiService.ExplainError(errorMessage)
+			if kind := detectInput(errorMessage); piped && kind != inputText {
+				if manifestFile != "" || len(crds) > 0 {
+					return usageErrorf("--manifest and --crd only apply to error messages, and the input is %s", kind)
+				}
+				switch kind {
+				case inputDescribe:
+					return explainDescribe(aiService, errorMessage)
+				case inputLogs:
+					return analyzeLogText(aiService, errorMessage)
+				default:
+					result, err := aiService.AnalyzeDeployment(errorMessage)
+					if err != nil {
+						return fmt.Errorf("error analyzing manifest: %w", err)
+					}
+					printMarkdown(result)
+					return nil
+				}
+			}
+
+			var manifestData []byte
+			if manifestFile != "" {
+				manifestData, err = os.ReadFile(manifestFile)
+				if err != nil {
+					return fmt.Errorf("error reading manifest: %w", err)
+				}
+			}
+
+			customResources, schemas, err := loadCustomResources(cmd, errorMessage+"\n"+string(manifestData), crds)
 			if err != nil {
-				log.Fatalf("Error explaining Kubernetes error: %v", err)
+				return err
 			}
 
-			fmt.Println(result)
+			var problems []string
+			if manifestData != nil {
+				problems = manifest.ValidateWithSchemas(manifestData, schemas)
+				if len(problems) > 0 {
+					fmt.Printf("\n=== %s ===\n", i18n.T("Validation"))
+					for _, problem := range problems {
+						fmt.Printf("- %s\n", problem)
+					}
+					fmt.Println()
+				}
+			}
+
+			result, err := aiService.ExplainError(errorMessage, customResources, problems)
+			if err != nil {
+				return fmt.Errorf("error explaining Kubernetes error: %w", err)
+			}
+
+			printMarkdown(result)
+			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&errorFile, "file", "f", "", "File containing error message")
+	cmd.Flags().StringVar(&manifestFile, "manifest", "", "Manifest related to the error, validated against the cluster's schemas")
+	cmd.Flags().StringSliceVar(&crds, "crd", nil, "Installed CRD to include the schema of, by name, kind or plural (repeatable)")
 
 	return cmd
 }
@@ -313,18 +932,19 @@ func createChatCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 		Use:   "chat [message]",
 		Short: "Chat about Kubernetes",
 		Long:  `Have a conversation about Kubernetes topics.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				log.Fatalf("Please provide a message to chat about")
+				return usageErrorf("please provide a message to chat about")
 			}
 
 			message := strings.Join(args, " ")
 			result, err := aiService.Chat(message)
 			if err != nil {
-				log.Fatalf("Error in chat: %v", err)
+				return fmt.Errorf("error in chat: %w", err)
 			}
 
-			fmt.Println(result)
+			printMarkdown(result)
+			return nil
 		},
 	}
 
@@ -333,38 +953,83 @@ func createChatCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 
 // createSetModelCmd creates the set-model command
 func createSetModelCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	var save bool
+
 	cmd := &cobra.Command{
 		Use:   "set-model [model-name]",
 		Short: "Set the default AI model",
 		Long:  `Set the default AI model to use for kube-ai commands.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				log.Fatalf("Please provide a model name")
+				return usageErrorf("please provide a model name")
 			}
 
 			modelName := args[0]
 			aiService.SetModelName(modelName)
 
 			fmt.Printf("Model set to: %s\n", modelName)
-			fmt.Println("Note: This is a temporary setting that will reset when the tool exits.")
-			fmt.Printf("To make this permanent, set the %s_DEFAULT_MODEL environment variable.\n",
-				strings.ToUpper(aiService.GetCurrentProvider()))
+			return persistSetting(cfg, save, config.ProviderEnvPrefix(aiService.GetCurrentProvider())+"_DEFAULT_MODEL", modelName)
 		},
 	}
 
+	cmd.Flags().BoolVar(&save, "save", false, "Save the model to the configuration file")
+
 	return cmd
 }
 
+// createSetLanguageCmd creates the set-language command
+func createSetLanguageCmd(cfg *config.Config) *cobra.Command {
+	var save bool
+
+	cmd := &cobra.Command{
+		Use:   "set-language [language]",
+		Short: "Set the language for AI answers and output",
+		Long: `Set the language the AI answers in and the CLI uses for section headers.
+Accepts a code or locale such as "es", "de_DE" or "pt-BR". Use "en" to reset to English.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			language := i18n.Normalize(args[0])
+			if i18n.IsEnglish(language) {
+				language = ""
+			}
+
+			cfg.UpdateLanguage(language)
+
+			fmt.Printf("Language set to: %s\n", i18n.LanguageName(language))
+			return persistSetting(cfg, save, "KUBE_AI_LANGUAGE", language)
+		},
+	}
+
+	cmd.Flags().BoolVar(&save, "save", false, "Save the language to the configuration file")
+
+	return cmd
+}
+
+// persistSetting saves the configuration when --save is given, or else shows how to keep a
+// setting for the current shell, since nothing is saved implicitly
+func persistSetting(cfg *config.Config, save bool, env, value string) error {
+	if !save {
+		fmt.Println("Not saved. Run again with --save to write it to the configuration file, or set it for this shell:")
+		fmt.Printf("  export %s=%q\n", env, value)
+		return nil
+	}
+	if err := cfg.SaveConfig(); err != nil {
+		return fmt.Errorf("error saving configuration: %w", err)
+	}
+	fmt.Printf("Saved to %s\n", cfg.Path())
+	return nil
+}
+
 // createListModelsCmd creates the list-models command
 func createListModelsCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list-models",
 		Short: "List available AI models",
 		Long:  `List available AI models from the current AI provider.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			result, err := aiService.ListModels()
 			if err != nil {
-				log.Fatalf("Error listing models: %v", err)
+				return fmt.Errorf("error listing models: %w", err)
 			}
 
 			// Add information about current provider and model
@@ -375,6 +1040,7 @@ func createListModelsCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comma
 			formattedOutput.WriteString(fmt.Sprintf("Current model: %s\n", aiService.GetCurrentModel()))
 
 			fmt.Println(formattedOutput.String())
+			return nil
 		},
 	}
 
@@ -383,19 +1049,21 @@ func createListModelsCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comma
 
 // createSetProviderCmd creates the set-provider command
 func createSetProviderCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	var save bool
+
 	cmd := &cobra.Command{
 		Use:   "set-provider [provider-name]",
 		Short: "Set the AI provider",
 		Long:  `Set the AI provider to use for kube-ai commands.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				log.Fatalf("Please provide a provider name")
+				return usageErrorf("please provide a provider name")
 			}
 
 			providerName := strings.ToLower(args[0])
 			err := aiService.SwitchProvider(providerName)
 			if err != nil {
-				log.Fatalf("Error switching provider: %v", err)
+				return fmt.Errorf("error switching provider: %w", err)
 			}
 
 			// Check if API key is required but not set
@@ -406,11 +1074,12 @@ func createSetProviderCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comm
 			}
 
 			fmt.Printf("Provider set to: %s\n", providerName)
-			fmt.Println("Note: This is a temporary setting that will reset when the tool exits.")
-			fmt.Println("To make this permanent, set the AI_PROVIDER environment variable.")
+			return persistSetting(cfg, save, "AI_PROVIDER", providerName)
 		},
 	}
 
+	cmd.Flags().BoolVar(&save, "save", false, "Save the provider to the configuration file")
+
 	return cmd
 }
 
@@ -420,11 +1089,12 @@ func createListProvidersCmd(cfg *config.Config, aiService *ai.Service) *cobra.Co
 		Use:   "list-providers",
 		Short: "List available AI providers",
 		Long:  `List available AI providers that can be used with kube-ai.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Print(aiService.ListProviders())
 			fmt.Printf("\nCurrent provider: %s\n", aiService.GetCurrentProvider())
 			fmt.Printf("Current model: %s\n", aiService.GetCurrentModel())
 			fmt.Println("\nTo change provider, use 'kubectl ai set-provider [provider-name]'")
+			return nil
 		},
 	}
 
@@ -434,14 +1104,15 @@ func createListProvidersCmd(cfg *config.Config, aiService *ai.Service) *cobra.Co
 // createSetApiKeyCmd creates the set-api-key command
 func createSetApiKeyCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var setGlobal bool
+	var save bool
 
 	cmd := &cobra.Command{
 		Use:   "set-api-key [provider] [api-key]",
 		Short: "Set the API key for an AI provider",
 		Long:  `Set the API key for an AI provider. This key will be used for authentication with the provider's API.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 2 {
-				log.Fatalf("Please provide both provider name and API key")
+				return usageErrorf("please provide both provider name and API key")
 			}
 
 			providerName := strings.ToLower(args[0])
@@ -449,7 +1120,7 @@ func createSetApiKeyCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comman
 
 			// Verify provider is valid
 			validProvider := false
-			for _, provider := range []string{"openai", "anthropic", "gemini"} {
+			for _, provider := range []string{"openai", "anthropic", "gemini", "openai-compatible"} {
 				if providerName == provider {
 					validProvider = true
 					break
@@ -457,10 +1128,10 @@ func createSetApiKeyCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comman
 			}
 
 			if !validProvider {
-				log.Fatalf("Unsupported provider for API key: %s", providerName)
+				return usageErrorf("unsupported provider for API key: %s", providerName)
 			}
 
-			// Set the API key and save configuration
+			// Set the API key
 			switch providerName {
 			case "openai":
 				cfg.OpenAIApiKey = apiKey
@@ -468,31 +1139,35 @@ func createSetApiKeyCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comman
 				cfg.AnthropicApiKey = apiKey
 			case "gemini":
 				cfg.GeminiApiKey = apiKey
-			}
-
-			// Save the configuration
-			if err := cfg.SaveConfig(); err != nil {
-				fmt.Printf("Warning: Failed to save configuration: %v\n", err)
+			case "openai-compatible":
+				cfg.OpenAICompatibleApiKey = apiKey
 			}
 
 			fmt.Printf("API key for %s has been set.\n", providerName)
-
-			if setGlobal {
-				fmt.Printf("To make this permanent, set the %s_API_KEY environment variable.\n",
-					strings.ToUpper(providerName))
+			if save {
+				if err := persistSetting(cfg, save, config.ProviderEnvPrefix(providerName)+"_API_KEY", apiKey); err != nil {
+					return err
+				}
+			} else {
+				// The key is not echoed back in an export line, unlike other settings
+				fmt.Printf("Not saved. Run again with --save to write it to the configuration file, or set the %s_API_KEY environment variable.\n",
+					config.ProviderEnvPrefix(providerName))
 			}
 
 			// If this is the current provider, update it
 			if providerName == aiService.GetCurrentProvider() {
 				err := aiService.SwitchProvider(providerName)
 				if err != nil {
-					log.Fatalf("Error updating provider with new API key: %v", err)
+					return fmt.Errorf("error updating provider with new API key: %w", err)
 				}
 			}
+			return nil
 		},
 	}
 
 	cmd.Flags().BoolVarP(&setGlobal, "global", "g", false, "Show instructions for setting API key globally")
+	_ = cmd.Flags().MarkDeprecated("global", "the instructions are always shown when the key is not saved")
+	cmd.Flags().BoolVar(&save, "save", false, "Save the API key to the configuration file")
 
 	return cmd
 }
@@ -501,13 +1176,40 @@ func createSetApiKeyCmd(cfg *config.Config, aiService *ai.Service) *cobra.Comman
 func createAnalyzeLogsCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
 	var container string
 	var tailLines int64
-	var sinceSeconds int64
+	var since string
+	var sinceTime string
+	var until string
 	var previous bool
 	var errorsOnly bool
 	var outputFormat string
 	var showLogs bool = true // Default to showing logs
 	var maxLogs int = 20     // Default to 20 logs
 	var tailLiveLogs bool    // New flag for live log tailing
+	var maxConcurrency int
+	var maxLinesPerPod int64
+	var grepPattern string
+	var excludePattern string
+	var minLevel string
+	var containersPattern string
+	var chunkTokens int
+	var analyzeInterval time.Duration
+	var useBaseline bool
+	var updateBaseline bool
+	var recordHistory bool
+	var exportFormat string
+	var exportPath string
+	var runAnalysis bool
+	var includeEvents bool
+	var includeUsage bool
+	var initContainers bool
+	var ephemeralContainers bool
+	var allContainers bool
+	var ignoreContainers []string
+	var interactive bool
+	var saveName string
+	var consensus int
+	var selection selectorOptions
+	var namespaces []string
 
 	cmd := &cobra.Command{
 		Use:   "analyze-logs [resource-type] [resource-name]",
@@ -517,115 +1219,300 @@ AI-powered troubleshooting insights, including potential issues and solutions.
 
 By default, the command will display the first 20 log entries being analyzed.
 Use --show-logs=false to hide logs or --max-logs to change the number of logs shown.
-Use --tail to continuously stream logs in real-time instead of analyzing a fixed set.`,
-		Args: cobra.MinimumNArgs(2),
-		Run: func(cmd *cobra.Command, args []string) {
+Use --tail to continuously stream logs in real-time instead of analyzing a fixed set.
+Use --consensus n to have the first n models listed under consensus in the
+configuration file analyze the logs independently, then merge their analyses
+and show where they agree and disagree.
+
+To troubleshoot a node, use "node <name>" to read its kubelet logs, or the logs
+of another node service with --container, such as containerd. They are read
+through the node log query of the API server, which requires the NodeLogQuery
+feature gate and enableSystemLogQuery in the kubelet configuration. Use
+"control-plane <component>" to read the logs of the control plane static pods in
+kube-system, such as kube-apiserver or etcd, or "control-plane all".
+
+To analyze the same app across namespaces, such as one per tenant, use
+--namespaces a,b,c or --all-namespaces (-A) with a resource name, or select the
+pods with --selector (-l) or --field-selector instead of naming a resource. The summary then
+attributes the entries and errors to their namespace. Collection across
+namespaces cannot be combined with --live, --baseline, --save or the timeline,
+and does not include lifecycle events, resource usage or the log history.
+
+Workloads annotated, or in a namespace annotated, with kube-ai.io/ignore: "true"
+are not analyzed. The logs of those annotated with kube-ai.io/redact-logs: "true"
+are masked with the strict redaction policy before they are shown or sent.
+
+With --history, a run or --live session adds the logs it reads to a rolling
+summary of the workload kept for 7 days in ~/.kube-ai/history: hourly error rates
+and counts of each log pattern. The analysis then includes its trend, so it can
+tell a new problem from a chronic one even after the cluster has rotated the old
+logs away. Without --history nothing is recorded or included. Stateless mode
+(KUBE_AI_STATELESS) refuses --history.
+
+Use --export csv or --export parquet to write the parsed log entries and their
+summary to the --export-path directory for notebooks and BI tools: one file each
+for the entries, the summary, the common error and warning patterns, and the
+pods with the most errors. Add --analyze=false to export without the AI step.`,
+		// Selectors select the pods in place of a named resource
+		Args: selectorArgs(cobra.MinimumNArgs(2), cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// Extract arguments
-			resourceType := args[0]
-			resourceName := args[1]
+			var resourceType, resourceName string
+			if len(args) >= 2 {
+				resourceType, resourceName = args[0], args[1]
+			}
 
-			// Create Kubernetes client with kubectl flags
-			client, err := k8s.NewClientFromFlags(cmd)
+			// Collect across namespaces for selectors, --namespaces or --all-namespaces
+			allNamespaces, _ := cmd.Flags().GetBool("all-namespaces")
+			spread := selection.set() || len(namespaces) > 0 || allNamespaces
+
+			// Compile log filters so they are applied during collection
+			filter, err := logs.NewLogFilter(grepPattern, excludePattern, minLevel, containersPattern)
 			if err != nil {
-				log.Fatalf("Error creating Kubernetes client: %v", err)
+				return err
 			}
 
-			// Create log collector
-			collector := logs.NewLogCollector(client.GetClientset())
-
 			// Prepare log options
 			var tl *int64
 			if tailLines > 0 {
 				tl = &tailLines
 			}
 
+			// Resolve the time window the way kubectl logs does: --since-time wins over --since
+			if sinceTime != "" && cmd.Flags().Changed("since") {
+				return usageErrorf("only one of --since or --since-time may be used")
+			}
+			now := time.Now()
+
 			var ss *int64
-			if sinceSeconds > 0 {
-				ss = &sinceSeconds
+			var st *metav1.Time
+			if sinceTime != "" {
+				t, err := time.Parse(time.RFC3339, sinceTime)
+				if err != nil {
+					return usageErrorf("invalid --since-time %q (expected RFC3339, e.g. 2024-05-01T10:00:00Z)", sinceTime)
+				}
+				st = &metav1.Time{Time: t}
+			} else if since != "" {
+				d, err := logs.ParseDuration(since)
+				if err != nil {
+					return usageErrorf("invalid --since: %w", err)
+				}
+				if seconds := int64(d.Seconds()); seconds > 0 {
+					ss = &seconds
+				}
 			}
 
-			// Get namespace from client which respects kubectl flags
-			namespace := client.GetNamespace()
+			var ut *metav1.Time
+			if until != "" {
+				if tailLiveLogs {
+					return usageErrorf("--until cannot be combined with --live")
+				}
+				t, err := logs.ParseTime(until, now)
+				if err != nil {
+					return usageErrorf("invalid --until: %w", err)
+				}
+				ut = &metav1.Time{Time: t}
+			}
 
 			options := logs.LogOptions{
-				ResourceType: resourceType,
-				ResourceName: resourceName,
-				Namespace:    namespace,
-				Container:    container,
-				TailLines:    tl,
-				SinceSeconds: ss,
-				Previous:     previous,
-				Follow:       tailLiveLogs,
+				ResourceType:  resourceType,
+				ResourceName:  resourceName,
+				Selector:      selection.label,
+				FieldSelector: selection.field,
+				Container:     container,
+				TailLines:     tl,
+				SinceSeconds:  ss,
+				SinceTime:     st,
+				UntilTime:     ut,
+				Previous:      previous,
+				Follow:        tailLiveLogs,
+
+				MaxConcurrency: maxConcurrency,
+				MaxLinesPerPod: maxLinesPerPod,
+				Filter:         filter,
+
+				InitContainers:      initContainers,
+				EphemeralContainers: ephemeralContainers,
+				AllContainers:       allContainers,
+				IgnoreContainers:    ignoreContainers,
 			}
 
-			// Collect logs
-			fmt.Printf("Collecting logs from %s/%s in namespace %s...\n", resourceType, resourceName, namespace)
+			if analyzeInterval > 0 && !tailLiveLogs {
+				return usageErrorf("--analyze-interval requires --live")
+			}
+			if (initContainers || ephemeralContainers || allContainers) && tailLiveLogs {
+				return usageErrorf("--all-containers, --init-containers and --ephemeral cannot be combined with --live")
+			}
+			if allContainers && container != "" {
+				return usageErrorf("--all-containers cannot be combined with --container")
+			}
+			nodeLogs := logs.IsNodeResource(resourceType)
+			if (nodeLogs || logs.IsControlPlaneResource(resourceType)) && tailLiveLogs {
+				return usageErrorf("--live is not supported for node and control plane logs")
+			}
+			if spread {
+				if allNamespaces && len(namespaces) > 0 {
+					return usageErrorf("--namespaces cannot be combined with --all-namespaces")
+				}
+				if nodeLogs || logs.IsControlPlaneResource(resourceType) {
+					return usageErrorf("selectors, --namespaces and --all-namespaces cannot be combined with node or control plane logs")
+				}
+				if tailLiveLogs || useBaseline || updateBaseline || saveName != "" || outputFormat == "timeline" || k8s.IsMultiCluster(cmd) {
+					return usageErrorf("selectors, --namespaces and --all-namespaces cannot be combined with --live, --baseline, --update-baseline, --save, --output timeline or multiple contexts")
+				}
+			}
+			if saveName != "" && (tailLiveLogs || k8s.IsMultiCluster(cmd)) {
+				return usageErrorf("--save cannot be combined with --live or multiple contexts")
+			}
+			if outputFormat != "text" && outputFormat != "json" && outputFormat != "timeline" {
+				return usageErrorf("unsupported output format %q, use text, json or timeline", outputFormat)
+			}
+			if outputFormat == "timeline" && (!includeEvents || nodeLogs || tailLiveLogs || k8s.IsMultiCluster(cmd)) {
+				return usageErrorf("--output timeline requires --events and cannot be combined with node logs, --live or multiple contexts")
+			}
+			if interactive && (tailLiveLogs || outputFormat == "json" || k8s.IsMultiCluster(cmd)) {
+				return usageErrorf("--interactive cannot be combined with --live, --output json or multiple contexts")
+			}
+			if (useBaseline || updateBaseline) && (tailLiveLogs || k8s.IsMultiCluster(cmd)) {
+				return usageErrorf("--baseline and --update-baseline cannot be combined with --live or multiple contexts")
+			}
+			if exportFormat != "" && exportWriters[exportFormat] == nil {
+				return usageErrorf("unsupported export format %q, use csv or parquet", exportFormat)
+			}
+			if recordHistory && cfg.Stateless() {
+				return usageErrorf("--history writes the log history, which %s disables", config.StatelessEnv)
+			}
+			if (exportFormat != "" || !runAnalysis) && (tailLiveLogs || k8s.IsMultiCluster(cmd)) {
+				return usageErrorf("--export and --analyze=false cannot be combined with --live or multiple contexts")
+			}
 
-			// Handle live tailing mode differently
-			if tailLiveLogs {
-				fmt.Println("Streaming logs in real-time (press Ctrl+C to stop)...")
+			// Ask several models for their own analysis when a consensus is requested
+			var members []*ai.Service
+			if cmd.Flags().Changed("consensus") {
+				if tailLiveLogs || k8s.IsMultiCluster(cmd) {
+					return usageErrorf("--consensus cannot be combined with --live or multiple contexts")
+				}
+				consensusModels, err := cfg.ConsensusModels(consensus)
+				if err != nil {
+					return usageErrorf("%w", err)
+				}
+				for _, model := range consensusModels {
+					member, err := aiService.WithProvider(model.Provider, model.Model)
+					if err != nil {
+						return fmt.Errorf("consensus model %s: %w", model, err)
+					}
+					members = append(members, member)
+				}
+			}
 
-				// Create context that can be canceled on interrupt
-				ctx, cancel := context.WithCancel(context.Background())
-				defer cancel()
+			// Run against several clusters concurrently when requested
+			if k8s.IsMultiCluster(cmd) {
+				if tailLiveLogs {
+					return usageErrorf("--live cannot be combined with --contexts or --all-contexts")
+				}
+				return runMultiClusterLogAnalysis(cmd, aiService, options, errorsOnly, outputFormat, chunkTokens)
+			}
 
-				// Setup signal handling for graceful exit
-				interruptChan := make(chan os.Signal, 1)
-				signal.Notify(interruptChan, os.Interrupt, syscall.SIGTERM)
+			// Create Kubernetes client with kubectl flags
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
 
-				// Start a goroutine that will cancel the context when interrupted
-				go func() {
-					<-interruptChan
-					fmt.Println("\nInterrupted, stopping log stream...")
-					cancel()
-				}()
+			// Create log collector
+			collector := logs.NewLogCollector(client.GetClientset())
 
-				// Stream logs in real-time
-				logChan := make(chan logs.LogEntry)
-				errChan := make(chan error)
+			// Get namespace from client which respects kubectl flags
+			namespace := client.GetNamespace()
+			if logs.IsControlPlaneResource(resourceType) {
+				namespace = logs.ControlPlaneNamespace
+			}
+			options.Namespace = namespace
 
-				go func() {
-					err := collector.StreamLogs(ctx, options, logChan, errChan)
-					if err != nil {
-						fmt.Printf("Error streaming logs: %v\n", err)
+			// Selectors alone select pods in the current namespace
+			spreadNamespaces := namespaces
+			if selection.set() && !allNamespaces && len(namespaces) == 0 {
+				spreadNamespaces = []string{namespace}
+			}
+			if spread {
+				accessNamespaces := spreadNamespaces
+				if allNamespaces {
+					accessNamespaces = []string{""}
+				}
+				for _, accessNamespace := range accessNamespaces {
+					if err := checkAccess(client, "analyze-logs", accessNamespace); err != nil {
+						return err
 					}
-				}()
-
-				// Process streamed logs
-				for {
-					select {
-					case entry, ok := <-logChan:
-						if !ok {
-							return
-						}
-						displayLogEntry(entry)
-					case err, ok := <-errChan:
-						if !ok {
-							return
-						}
-						fmt.Printf("Error: %v\n", err)
-					case <-ctx.Done():
-						return
+				}
+			} else if !nodeLogs {
+				if err := checkAccess(client, "analyze-logs", namespace); err != nil {
+					return err
+				}
+			}
+
+			// Keep progress out of JSON output, so standard output holds only the document
+			status := progressWriter(outputFormat == "json")
+
+			// Collect logs
+			switch {
+			case nodeLogs:
+				fmt.Fprintf(status, "Collecting %s logs from node %s...\n", valueOr(container, logs.DefaultNodeService), resourceName)
+			case spread:
+				fmt.Fprintf(status, "Collecting logs from %s...\n", describeLogSource(options, spreadNamespaces))
+			default:
+				fmt.Fprintf(status, "Collecting logs from %s/%s in namespace %s...\n", resourceType, resourceName, namespace)
+			}
+
+			// Respect the owners of workloads opted out of AI analysis; across namespaces each pod is
+			// checked as its logs are collected
+			var optOut k8s.OptOut
+			if !spread {
+				if optOut, err = checkOptOut(context.Background(), client, resourceType, resourceName, namespace); err != nil {
+					return err
+				}
+			}
+			redactor := logRedactor(optOut)
+			if redactor != nil {
+				fmt.Fprintf(status, "Masking logs with the %s redaction policy, as the %s annotation on %s asks\n", redactor.Policy(), k8s.RedactLogsAnnotation, optOut.RedactLogsBy)
+			}
+
+			// Handle live tailing mode differently
+			if tailLiveLogs {
+				var historyPath string
+				if recordHistory {
+					if historyPath, err = logHistoryPath(cmd, options); err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 					}
 				}
+				streamLogsLive(collector, aiService, options, analyzeInterval, errorsOnly, chunkTokens, redactor, historyPath)
+				return nil
 			}
 
-			// Normal log collection and analysis mode
-			logEntries, err := collector.GetResourceLogs(context.Background(), options)
+			// Normal log collection and analysis mode, counting the pods on terminals
+			counter := progress.NewCounter(os.Stderr, "Collecting logs", "pods")
+			options.Progress = counter.Set
+			var logEntries []logs.LogEntry
+			if spread {
+				logEntries, err = collectAcrossNamespaces(context.Background(), client, collector, options, spreadNamespaces)
+			} else {
+				logEntries, err = collector.GetResourceLogs(context.Background(), options)
+			}
+			counter.Finish()
 			if err != nil {
-				log.Fatalf("Error collecting logs: %v", err)
+				return kubeErrorf("error collecting logs: %w", err)
 			}
+			maskLogs(logEntries, redactor)
 
-			fmt.Printf("Collected %d log entries\n", len(logEntries))
+			fmt.Fprintf(status, "Collected %d log entries\n", len(logEntries))
 
-			// Display logs if requested
-			if showLogs {
+			// Display logs if requested, unless standard output only carries the result
+			if showLogs && outputFormat != "json" && !quietOutput {
 				logCount := len(logEntries)
 				if maxLogs > 0 && maxLogs < logCount {
 					logCount = maxLogs
 				}
 
-				fmt.Printf("\n====== LOG ENTRIES ======\n")
+				fmt.Printf("\n====== %s ======\n", i18n.T("LOG ENTRIES"))
 				fmt.Printf("Showing %d of %d log entries:\n\n", logCount, len(logEntries))
 
 				for i, entry := range logEntries {
@@ -633,6 +1520,10 @@ Use --tail to continuously stream logs in real-time instead of analyzing a fixed
 						break
 					}
 
+					// Pods are only unique within a namespace
+					if spread {
+						entry.PodName = entry.Namespace + "/" + entry.PodName
+					}
 					displayLogEntry(entry)
 				}
 
@@ -643,44 +1534,206 @@ Use --tail to continuously stream logs in real-time instead of analyzing a fixed
 			}
 
 			// Parse and analyze logs
-			fmt.Println("Analyzing logs...")
 			logSummary := logs.ParseLogs(logEntries)
 
+			// Export the parsed logs for notebooks and BI tools, whether or not they are analyzed
+			if exportFormat != "" {
+				paths, err := exportLogs(exportPath, exportFormat, logEntries, logSummary)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(status, "Exported the parsed logs to %s\n", strings.Join(paths, ", "))
+			}
+			if !runAnalysis {
+				return nil
+			}
+
+			fmt.Fprintln(status, "Analyzing logs...")
+
 			// Create log analyzer
 			analyzer := analyzers.NewLogAnalyzer(aiService)
+			analyzer.SetChunkTokens(chunkTokens)
+			// Keep progress of chunked analyses out of JSON output
+			analyzer.SetProgress(status)
+
+			// Emphasize what changed since the workload's recorded baseline
+			var baselineDiff *logs.BaselineDiff
+			if useBaseline || updateBaseline {
+				baselineDiff, err = compareWithBaseline(cmd, options, logEntries, updateBaseline)
+				if err != nil {
+					return fmt.Errorf("error comparing with baseline: %w", err)
+				}
+				if baselineDiff != nil && outputFormat != "json" {
+					displayBaselineDiff(baselineDiff)
+				}
+				analyzer.SetBaseline(baselineDiff)
+			}
+
+			// Add the logs to the workload's rolling history, which outlives the logs the cluster keeps
+			var trend *logs.LogTrend
+			if recordHistory && !spread {
+				path, err := logHistoryPath(cmd, options)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				} else {
+					trend = recordLogHistory(path, options, logEntries)
+				}
+				if outputFormat == "text" {
+					displayLogTrend(trend)
+				}
+				analyzer.SetTrend(trend)
+			}
+
+			// Correlate logs with restarts, events and readiness changes in the same window
+			var lifecycle *k8s.WorkloadLifecycle
+			// Nodes have no pods of their own to correlate with
+			if includeEvents && len(logEntries) > 0 && !nodeLogs && !spread {
+				lifecycle, err = client.GetWorkloadLifecycle(context.Background(), resourceType, resourceName, namespace, logSummary.TimeRange.Start, time.Now())
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not collect lifecycle events: %v\n", err)
+				} else {
+					// Merge restarts, rollouts, scaling and the error rate into one chronological view
+					lifecycle.Timeline, err = client.BuildTimeline(context.Background(), resourceType, resourceName, namespace, lifecycle, logEntries, logSummary.TimeRange.Start, time.Now())
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: could not build the timeline: %v\n", err)
+					}
+					switch outputFormat {
+					case "text":
+						displayLifecycle(lifecycle)
+					case "timeline":
+						displayTimeline(lifecycle.Timeline)
+					}
+				}
+				analyzer.SetLifecycle(lifecycle)
+			}
+
+			// Show live usage against requests and limits, so OOM kills and CPU throttling are not guessed at
+			var usage []k8s.ContainerUsage
+			if includeUsage && !nodeLogs && !spread {
+				pods, _, err := client.GetWorkloadPods(context.Background(), resourceType, resourceName, namespace)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not read resource usage: %v\n", err)
+				} else {
+					usage = collectResourceUsage(context.Background(), client, pods)
+				}
+				if outputFormat != "json" {
+					displayResourceUsage(usage)
+				}
+				analyzer.SetResourceUsage(usage)
+			}
+
+			// Tell the analysis which data sources could not be collected
+			skipped := k8s.SkippedSources()
+			analyzer.SetSkippedSources(skipped)
 
 			// Perform analysis
+			analyze := func(analyzer *analyzers.LogAnalyzer) (*analyzers.LogAnalysisResult, error) {
+				if errorsOnly {
+					return analyzer.AnalyzeErrorLogs(context.Background(), logEntries)
+				}
+				return analyzer.AnalyzeLogs(context.Background(), logEntries, logSummary)
+			}
 			var analysisResult *analyzers.LogAnalysisResult
-			if errorsOnly {
-				analysisResult, err = analyzer.AnalyzeErrorLogs(context.Background(), logEntries)
+			var consensusResult *analyzers.ConsensusResult
+			if members != nil {
+				consensusResult, err = analyzer.Consensus(context.Background(), members, logEntries, logSummary, analyze)
+				if err == nil {
+					analysisResult = consensusResult.Analysis
+				}
 			} else {
-				analysisResult, err = analyzer.AnalyzeLogs(context.Background(), logEntries, logSummary)
+				analysisResult, err = analyze(analyzer)
 			}
 
 			if err != nil {
-				log.Fatalf("Error analyzing logs: %v", err)
+				return fmt.Errorf("error analyzing logs: %w", err)
 			}
 
 			// Display results based on output format
 			switch outputFormat {
 			case "json":
-				displayJSONResults(logSummary, analysisResult)
+				if consensusResult != nil {
+					if err := displayConsensusJSON(logSummary, consensusResult, baselineDiff, trend, lifecycle, usage, skipped); err != nil {
+						return err
+					}
+				} else if err := displayJSONResults(logSummary, analysisResult, baselineDiff, trend, lifecycle, usage, skipped); err != nil {
+					return err
+				}
 			default:
 				displayFormattedResults(logSummary, analysisResult)
+				if consensusResult != nil {
+					displayConsensus(consensusResult)
+				}
 			}
+			if consensusResult != nil {
+				recordResult(analysisResult.Severity, consensusResult)
+			} else {
+				recordResult(analysisResult.Severity, analysisResult)
+			}
+
+			// Keep the run so it can be compared with later ones
+			if saveName != "" {
+				progress := progressWriter(outputFormat == "json")
+				saved := analyzers.SavedAnalysis{
+					Context:      currentContextName(cmd),
+					Namespace:    namespace,
+					ResourceType: resourceType,
+					ResourceName: resourceName,
+					Summary:      logSummary,
+					Analysis:     *analysisResult,
+				}
+				if err := saveAnalysis(progress, saveName, saved); err != nil {
+					return fmt.Errorf("error saving analysis: %w", err)
+				}
+			}
+
+			// Answer follow-up questions with the collected logs and analysis as context
+			if interactive {
+				runFollowUpChat(analyzer.NewConversation(logEntries, logSummary, analysisResult))
+			}
+			return nil
 		},
 	}
 
 	// Add command-specific flags (not available in standard kubectl)
 	cmd.Flags().StringVarP(&container, "container", "c", "", "Container name for pods with multiple containers")
+	selection.addFlags(cmd, "pods")
+	cmd.Flags().StringSliceVar(&namespaces, "namespaces", nil, "Collect from these namespaces, such as the tenants running the same app, and attribute the findings to each")
 	cmd.Flags().Int64VarP(&tailLines, "tail", "t", 1000, "Number of lines to include from the end of logs")
-	cmd.Flags().Int64VarP(&sinceSeconds, "since", "s", 3600, "Only return logs newer than a duration in seconds")
+	cmd.Flags().StringVarP(&since, "since", "s", "1h", "Only return logs newer than a relative duration like 30s, 15m, 2h or 1d")
+	cmd.Flags().StringVar(&sinceTime, "since-time", "", "Only return logs after a specific date (RFC3339)")
+	cmd.Flags().StringVar(&until, "until", "", "Only return logs older than an RFC3339 date or a relative duration like 30m")
 	cmd.Flags().BoolVarP(&previous, "previous", "p", false, "Include logs from previously terminated containers")
 	cmd.Flags().BoolVarP(&errorsOnly, "errors-only", "e", false, "Analyze only error logs")
-	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json or timeline)")
 	cmd.Flags().BoolVar(&showLogs, "show-logs", true, "Display log entries being analyzed")
 	cmd.Flags().IntVar(&maxLogs, "max-logs", 20, "Maximum number of logs to display")
 	cmd.Flags().BoolVar(&tailLiveLogs, "live", false, "Stream logs in real-time")
+	cmd.Flags().DurationVar(&analyzeInterval, "analyze-interval", 0, "With --live, analyze newly streamed logs at this interval (e.g. 2m) and alert when severity rises")
+	cmd.Flags().IntVar(&maxConcurrency, "max-concurrency", logs.DefaultMaxConcurrency, "Maximum number of pods to fetch logs from concurrently")
+	cmd.Flags().Int64Var(&maxLinesPerPod, "max-lines-per-pod", 0, "Maximum number of log lines to collect from each pod (0 for no cap beyond --tail)")
+	cmd.Flags().StringVar(&grepPattern, "grep", "", "Only analyze log lines matching this regular expression")
+	cmd.Flags().StringVar(&excludePattern, "exclude", "", "Drop log lines matching this regular expression")
+	cmd.Flags().StringVar(&minLevel, "level", "", "Minimum log level to analyze (debug, info, warn, error, fatal)")
+	cmd.Flags().BoolVar(&allContainers, "all-containers", false, "Collect logs from every container in the matched pods")
+	cmd.Flags().StringSliceVar(&ignoreContainers, "ignore-containers", logs.DefaultIgnoreContainers, "Containers to skip when collecting from several containers, such as service mesh sidecars")
+	cmd.Flags().BoolVar(&initContainers, "init-containers", false, "Also collect logs from init containers, where many startup failures appear")
+	cmd.Flags().BoolVar(&ephemeralContainers, "ephemeral", false, "Also collect logs from ephemeral debug containers")
+	cmd.Flags().StringVar(&containersPattern, "containers", "", "Only collect logs from containers whose name matches this regular expression")
+	cmd.Flags().BoolVar(&includeEvents, "events", true, "Correlate logs with pod restarts, Kubernetes events and readiness changes in the same time window")
+	cmd.Flags().BoolVar(&includeUsage, "usage", true, "Include live CPU and memory usage against requests and limits from the metrics API")
+	cmd.Flags().BoolVar(&useBaseline, "baseline", false, "Highlight log patterns that are new, rare, or changed in rate compared to the workload's recorded baseline (records one on first use)")
+	cmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "Record the collected logs as the workload's new baseline of normal behavior")
+	cmd.Flags().StringVar(&exportFormat, "export", "", "Export the parsed log entries and summary for notebooks and BI tools, as csv or parquet")
+	cmd.Flags().StringVar(&exportPath, "export-path", ".", "Directory to write the --export files to")
+	cmd.Flags().BoolVar(&runAnalysis, "analyze", true, "Analyze the logs with AI; use --analyze=false with --export to only export them")
+	cmd.Flags().BoolVar(&recordHistory, "history", false, "Add the logs to the workload's rolling 7-day summary in ~/.kube-ai/history and include its trend in the analysis")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "After the analysis, ask follow-up questions about it without collecting the logs again")
+	cmd.Flags().StringVar(&saveName, "save", "", "Save the analysis under this name for comparison with 'kube-ai analysis diff'")
+	cmd.Flags().IntVar(&consensus, "consensus", 0, "Analyze with the first n models listed under consensus in the configuration file concurrently, then merge their analyses highlighting agreements and disagreements")
+	cmd.Flags().IntVar(&chunkTokens, "chunk-tokens", analyzers.DefaultChunkTokens, "Estimated token budget per AI request, lowered to fit the model's context window when it is known; larger log volumes are analyzed in chunks and merged (0 to disable)")
+
+	// Allow running against several clusters at once
+	k8s.AddMultiClusterFlags(cmd)
 
 	return cmd
 }
@@ -692,20 +1745,25 @@ func displayLogEntry(entry logs.LogEntry) {
 
 	// Add colors based on log level
 	levelColor := ""
-	resetColor := "\033[0m"
+	resetColor := color(ansiReset)
 
 	switch entry.LogLevel {
 	case "ERROR", "FATAL":
-		levelColor = "\033[31m" // Red
+		levelColor = color(ansiRed)
 	case "WARN", "WARNING":
-		levelColor = "\033[33m" // Yellow
+		levelColor = color(ansiYellow)
 	case "INFO":
-		levelColor = "\033[32m" // Green
+		levelColor = color(ansiGreen)
 	}
 
-	// Print log entry with container name if available
+	// Print log entry with its source pod and container if available
 	containerInfo := ""
-	if entry.ContainerName != "" {
+	switch {
+	case entry.PodName != "" && entry.ContainerName != "":
+		containerInfo = fmt.Sprintf(" [%s/%s]", entry.PodName, entry.ContainerName)
+	case entry.PodName != "":
+		containerInfo = fmt.Sprintf(" [%s]", entry.PodName)
+	case entry.ContainerName != "":
 		containerInfo = fmt.Sprintf(" [%s]", entry.ContainerName)
 	}
 
@@ -719,97 +1777,141 @@ func displayLogEntry(entry logs.LogEntry) {
 }
 
 // displayJSONResults outputs analysis results in JSON format
-func displayJSONResults(summary logs.LogSummary, analysis *analyzers.LogAnalysisResult) {
-	// Combine summary and analysis into a single structure
+func displayJSONResults(summary logs.LogSummary, analysis *analyzers.LogAnalysisResult, baseline *logs.BaselineDiff, trend *logs.LogTrend, lifecycle *k8s.WorkloadLifecycle, usage []k8s.ContainerUsage, skipped []k8s.SkippedSource) error {
+	// Combine summary, analysis, baseline deviations, log trend, lifecycle events, resource usage
+	// and skipped data sources into a single structure
 	result := struct {
-		Summary  logs.LogSummary             `json:"summary"`
-		Analysis analyzers.LogAnalysisResult `json:"analysis"`
+		Summary   logs.LogSummary             `json:"summary"`
+		Analysis  analyzers.LogAnalysisResult `json:"analysis"`
+		Baseline  *logs.BaselineDiff          `json:"baseline,omitempty"`
+		Trend     *logs.LogTrend              `json:"trend,omitempty"`
+		Lifecycle *k8s.WorkloadLifecycle      `json:"lifecycle,omitempty"`
+		Usage     []k8s.ContainerUsage        `json:"usage,omitempty"`
+		Skipped   []k8s.SkippedSource         `json:"skippedSources,omitempty"`
 	}{
-		Summary:  summary,
-		Analysis: *analysis,
+		Summary:   summary,
+		Analysis:  *analysis,
+		Baseline:  baseline,
+		Trend:     trend,
+		Lifecycle: lifecycle,
+		Usage:     usage,
+		Skipped:   skipped,
 	}
 
 	// Convert to JSON
 	jsonData, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
-		log.Fatalf("Error formatting JSON output: %v", err)
+		return fmt.Errorf("error formatting JSON output: %w", err)
 	}
 
 	fmt.Println(string(jsonData))
+	return nil
 }
 
 // displayFormattedResults outputs analysis results in human-readable format
 func displayFormattedResults(summary logs.LogSummary, analysis *analyzers.LogAnalysisResult) {
-	// Determine severity color
-	var severityColor string
-	switch analysis.Severity {
-	case "Critical":
-		severityColor = "\033[1;31m" // Bold Red
-	case "High":
-		severityColor = "\033[31m" // Red
-	case "Medium":
-		severityColor = "\033[33m" // Yellow
-	case "Low":
-		severityColor = "\033[32m" // Green
-	default:
-		severityColor = "\033[0m" // Default
-	}
-
-	resetColor := "\033[0m"
+	resetColor := color(ansiReset)
 
 	// Display log summary
-	fmt.Println("\n====== LOG SUMMARY ======")
-	fmt.Printf("Total Entries: %d (%d errors, %d warnings)\n",
-		summary.TotalEntries, summary.ErrorCount, summary.WarningCount)
-	fmt.Printf("Time Range: %s to %s (%s)\n",
+	fmt.Printf("\n====== %s ======\n", i18n.T("LOG SUMMARY"))
+	fmt.Printf("%s: %d (%d %s, %d %s)\n", i18n.T("Total Entries"),
+		summary.TotalEntries, summary.ErrorCount, i18n.T("errors"), summary.WarningCount, i18n.T("warnings"))
+	fmt.Printf("%s: %s - %s (%s)\n", i18n.T("Time Range"),
 		summary.TimeRange.Start.Format(time.RFC3339),
 		summary.TimeRange.End.Format(time.RFC3339),
 		summary.TimeRange.Duration.String())
 
+	// Attribute the entries to their namespace when the logs span several
+	if len(summary.Namespaces) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Namespaces"))
+		for _, namespace := range summary.Namespaces {
+			fmt.Printf("- %s: %d (%d %s, %d %s)\n", namespace.Namespace,
+				namespace.TotalEntries, namespace.ErrorCount, i18n.T("errors"), namespace.WarningCount, i18n.T("warnings"))
+		}
+	}
+
 	// Display error hotspots
 	if len(summary.ErrorHotspots) > 0 {
-		fmt.Println("\n=== Error Hotspots ===")
+		fmt.Printf("\n=== %s ===\n", i18n.T("Error Hotspots"))
 		for _, hotspot := range summary.ErrorHotspots {
-			fmt.Printf("- %s: %d errors\n", hotspot.ResourceName, hotspot.ErrorCount)
+			fmt.Printf("- %s: %d %s\n", hotspot.ResourceName, hotspot.ErrorCount, i18n.T("errors"))
 		}
 	}
 
 	// Display analysis results
-	fmt.Println("\n====== AI ANALYSIS ======")
-	fmt.Printf("Severity: %s%s%s\n\n", severityColor, analysis.Severity, resetColor)
+	fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+	fmt.Printf("%s: %s%s%s\n\n", i18n.T("Severity"), severityColor(analysis.Severity), analysis.Severity, resetColor)
 
-	fmt.Println("=== Summary ===")
+	fmt.Printf("=== %s ===\n", i18n.T("Summary"))
 	fmt.Println(analysis.Summary)
 
-	fmt.Println("\n=== Root Causes ===")
+	fmt.Printf("\n=== %s ===\n", i18n.T("Root Causes"))
 	for i, cause := range analysis.RootCauses {
-		fmt.Printf("%d. %s\n", i+1, cause)
+		if cause.Confidence > 0 {
+			fmt.Printf("%d. %s (%s %d%%)\n", i+1, cause.Cause, i18n.T("confidence"), cause.Confidence)
+		} else {
+			fmt.Printf("%d. %s\n", i+1, cause.Cause)
+		}
+		displayEvidence(cause)
 	}
 
-	fmt.Println("\n=== Recommended Solutions ===")
+	fmt.Printf("\n=== %s ===\n", i18n.T("Recommended Solutions"))
 	for i, solution := range analysis.Solutions {
 		fmt.Printf("%d. %s\n", i+1, solution)
 	}
 
 	if len(analysis.AdditionalInfo) > 0 {
-		fmt.Println("\n=== Additional Information ===")
+		fmt.Printf("\n=== %s ===\n", i18n.T("Additional Information"))
 		for i, info := range analysis.AdditionalInfo {
 			fmt.Printf("%d. %s\n", i+1, info)
 		}
 	}
 }
 
+// displayEvidence prints the evidence quoted for a root cause, flagging quotes that
+// were not found in the collected logs or events
+func displayEvidence(cause analyzers.RootCause) {
+	unverified := make(map[string]bool, len(cause.UnverifiedEvidence))
+	for _, quote := range cause.UnverifiedEvidence {
+		unverified[quote] = true
+	}
+	for _, quote := range cause.Evidence {
+		if unverified[quote] {
+			fmt.Printf("   > %s %s(%s)%s\n", truncateLine(quote, 160), color(ansiYellow), i18n.T("not found in collected logs"), color(ansiReset))
+			continue
+		}
+		fmt.Printf("   > %s\n", truncateLine(quote, 160))
+	}
+}
+
+// severityColor returns the terminal color used to display a severity, or "" without colors
+func severityColor(severity string) string {
+	switch severity {
+	case "Critical":
+		return color(ansiBoldRed)
+	case "High":
+		return color(ansiRed)
+	case "Medium":
+		return color(ansiYellow)
+	case "Low":
+		return color(ansiGreen)
+	default:
+		return color(ansiReset)
+	}
+}
+
 // createVersionCmd creates the version command
 func createVersionCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Show version information",
 		Long:  `Display the version, git commit, and build information for kube-ai.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Kube-AI - Kubernetes AI Tool\n")
 			fmt.Printf("Version: %s\n", version.Version)
 			fmt.Printf("Commit: %s\n", version.GitCommit)
 			fmt.Printf("Built: %s\n", version.BuildDate)
+			return nil
 		},
 	}
 
@@ -829,7 +1931,7 @@ func createPersonaCmd(cfg *config.Config) *cobra.Command {
 		Use:   "list",
 		Short: "List available personas",
 		Long:  "Display all available personas, including default and custom ones",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			personas := cfg.ListPersonas()
 
 			fmt.Println("Available personas:")
@@ -841,52 +1943,91 @@ func createPersonaCmd(cfg *config.Config) *cobra.Command {
 					activeMarker = "*"
 				}
 
-				fmt.Printf("[%s] %s: %s\n", activeMarker, name, persona.Description)
+				fmt.Printf("[%s] %s: %s%s\n", activeMarker, name, persona.Description, personaPreferences(persona))
 			}
 
 			fmt.Println("\n* = currently active persona")
+			return nil
 		},
 	}
 
 	// Use a specific persona
+	var save bool
 	useCmd := &cobra.Command{
 		Use:   "use [persona-name]",
 		Short: "Set the active persona",
 		Long:  "Change the active persona used by the AI assistant",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			personaName := args[0]
 
 			err := cfg.SetPersona(personaName)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				return
+				return nil
 			}
 
 			fmt.Printf("Persona changed to: %s\n", personaName)
+			return persistSetting(cfg, save, "KUBE_AI_PERSONA", personaName)
 		},
 	}
+	useCmd.Flags().BoolVar(&save, "save", false, "Save the persona to the configuration file")
 
 	// Add a custom persona
+	var promptFile string
+	var edit bool
 	addCmd := &cobra.Command{
 		Use:   "add [name] [description] [system-prompt]",
 		Short: "Add a custom persona",
-		Long:  "Create a new custom persona with a specific system prompt",
-		Args:  cobra.ExactArgs(3),
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Create a new custom persona with a specific system prompt.
+
+The system prompt is the third argument, or is read with --from-file from a file
+(- for stdin), or written in your editor ($VISUAL, $EDITOR or vi) with --edit,
+starting from the file's content if both are given. The prompt must be between
+20 and 10000 characters long and cannot contain template placeholders such as
+{{.Namespace}} or ${VAR}, which are not expanded in system prompts.`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 			description := args[1]
-			systemPrompt := args[2]
 
-			err := cfg.AddCustomPersona(name, description, systemPrompt)
+			var systemPrompt string
+			switch {
+			case len(args) == 3 && (promptFile != "" || edit):
+				return usageErrorf("give the system prompt as an argument, or with --from-file or --edit, not both")
+			case len(args) == 3:
+				systemPrompt = args[2]
+			case promptFile == "" && !edit:
+				return usageErrorf("give the system prompt as an argument, or with --from-file or --edit")
+			}
+
+			if promptFile != "" {
+				data, err := readPromptFile(promptFile)
+				if err != nil {
+					return fmt.Errorf("error reading system prompt: %w", err)
+				}
+				systemPrompt = data
+			}
+			if edit {
+				edited, err := editSystemPrompt(name, description, systemPrompt)
+				if err != nil {
+					return err
+				}
+				systemPrompt = edited
+			}
+
+			err := cfg.AddCustomPersona(name, description, strings.TrimSpace(systemPrompt))
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				return
+				return nil
 			}
 
 			fmt.Printf("Added new persona: %s\n", name)
+			return nil
 		},
 	}
+	addCmd.Flags().StringVar(&promptFile, "from-file", "", "Read the system prompt from a file (- for stdin)")
+	addCmd.Flags().BoolVar(&edit, "edit", false, "Write the system prompt in $VISUAL or $EDITOR")
 
 	// Remove a custom persona
 	removeCmd := &cobra.Command{
@@ -894,24 +2035,202 @@ func createPersonaCmd(cfg *config.Config) *cobra.Command {
 		Short: "Remove a custom persona",
 		Long:  "Delete a custom persona (default personas cannot be removed)",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 
 			err := cfg.RemoveCustomPersona(name)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				return
+				return nil
 			}
 
 			fmt.Printf("Removed persona: %s\n", name)
+			return nil
+		},
+	}
+
+	// Export a persona for sharing
+	var exportFile string
+	exportCmd := &cobra.Command{
+		Use:   "export [name]",
+		Short: "Export a persona to a file",
+		Long: `Export a persona, with its prompt, description and model and temperature
+preferences, as a YAML file (JSON if the file name ends in .json) that others
+can add with persona import. Without -o the persona is printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON := strings.HasSuffix(strings.ToLower(exportFile), ".json")
+			data, err := cfg.ExportPersona(args[0], asJSON)
+			if err != nil {
+				return err
+			}
+
+			if exportFile == "" || exportFile == "-" {
+				fmt.Print(string(data))
+				return nil
+			}
+			if err := os.WriteFile(exportFile, data, 0644); err != nil {
+				return fmt.Errorf("error writing persona: %w", err)
+			}
+			fmt.Printf("Exported persona %s to %s\n", args[0], exportFile)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVarP(&exportFile, "output", "o", "", "File to write the persona to")
+
+	// Import a shared persona
+	var importName string
+	var force bool
+	importCmd := &cobra.Command{
+		Use:   "import [file|URL]",
+		Short: "Import a persona from a file or URL",
+		Long: `Add a persona exported with persona export, from a file, a URL or - for stdin,
+as a custom persona. An existing custom persona with the same name is only
+replaced with --force.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readPersonaSource(args[0])
+			if err != nil {
+				return err
+			}
+			file, err := config.ParsePersona(data)
+			if err != nil {
+				return err
+			}
+			name := file.Name
+			if importName != "" {
+				name = importName
+			}
+
+			if err := cfg.ImportPersona(name, file.AIPersona, force); err != nil {
+				return err
+			}
+			fmt.Printf("Imported persona: %s%s\n", name, personaPreferences(file.AIPersona))
+			fmt.Printf("Use it with: kubectl ai persona use %s --save\n", name)
+			return nil
 		},
 	}
+	importCmd.Flags().StringVar(&importName, "name", "", "Import the persona under another name")
+	importCmd.Flags().BoolVar(&force, "force", false, "Replace an existing custom persona with the same name")
 
 	// Add subcommands to persona command
 	personaCmd.AddCommand(listCmd)
 	personaCmd.AddCommand(useCmd)
 	personaCmd.AddCommand(addCmd)
 	personaCmd.AddCommand(removeCmd)
+	personaCmd.AddCommand(exportCmd)
+	personaCmd.AddCommand(importCmd)
 
 	return personaCmd
 }
+
+// promptTemplate is the starting point for a system prompt written with persona add --edit
+const promptTemplate = `# Write the system prompt of persona %q (%s) below.
+# Lines starting with # are ignored, and an empty prompt cancels the command.
+#
+# A good prompt says who the assistant is, what it focuses on and how it answers,
+# for example: "You are an SRE on call for production clusters. Prioritize
+# mitigation over root cause and give commands that can be copied as they are."
+# Placeholders such as {{.Namespace}} are not expanded in system prompts.
+
+%s`
+
+// editSystemPrompt opens the user's editor on a system prompt and returns the edited prompt
+// without comment lines
+func editSystemPrompt(name, description, prompt string) (string, error) {
+	file, err := os.CreateTemp("", "kube-ai-persona-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := fmt.Fprintf(file, promptTemplate, name, description, prompt); err != nil {
+		file.Close()
+		return "", fmt.Errorf("error writing temporary file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("error writing temporary file: %w", err)
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	editorArgs := append(strings.Fields(editor), file.Name())
+	editorCmd := exec.Command(editorArgs[0], editorArgs[1:]...)
+	editorCmd.Stdin, editorCmd.Stdout, editorCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("error running editor %s: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("error reading edited prompt: %w", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	edited := strings.TrimSpace(strings.Join(lines, "\n"))
+	if edited == "" {
+		return "", fmt.Errorf("empty system prompt, persona not added")
+	}
+	return edited, nil
+}
+
+// readPromptFile reads a system prompt from a file, or from stdin for -
+func readPromptFile(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// personaPreferences describes a persona's model and temperature preferences, if any
+func personaPreferences(persona config.AIPersona) string {
+	var preferences []string
+	if persona.Model != "" {
+		model := persona.Model
+		if persona.Provider != "" {
+			model = persona.Provider + "/" + model
+		}
+		preferences = append(preferences, "model "+model)
+	}
+	if persona.Temperature != nil {
+		preferences = append(preferences, fmt.Sprintf("temperature %.2g", *persona.Temperature))
+	}
+	if len(preferences) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(preferences, ", ") + ")"
+}
+
+// readPersonaSource reads a persona file from a path, an http(s) URL or - for stdin
+func readPersonaSource(source string) ([]byte, error) {
+	const maxSize = 1 << 20
+
+	switch {
+	case source == "-":
+		return io.ReadAll(io.LimitReader(os.Stdin, maxSize))
+	case strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"):
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("error downloading persona: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error downloading persona: %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxSize))
+	default:
+		return os.ReadFile(source)
+	}
+}
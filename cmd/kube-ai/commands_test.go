@@ -0,0 +1,2147 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/history"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/notify"
+)
+
+// webPod is a running pod whose logs the fake clientset serves as "fake logs"
+var webPod = &corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "web", Image: "nginx"}},
+	},
+	Status: corev1.PodStatus{Phase: corev1.PodRunning},
+}
+
+const logAnalysis = `{
+  "summary": "The web server is healthy",
+  "rootCauses": [],
+  "solutions": ["Nothing to do"],
+  "additionalInfo": [],
+  "severity": "Low"
+}`
+
+func TestAnalyzeLogs(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "pod", "web", "-o", "json", "--events=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+
+	// Progress and the collected logs stay out of the JSON document
+	var output struct {
+		Analysis analyzers.LogAnalysisResult `json:"analysis"`
+	}
+	if err := json.Unmarshal([]byte(res.stdout), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if output.Analysis.Summary != "The web server is healthy" || output.Analysis.Severity != "Low" {
+		t.Errorf("unexpected analysis: %+v", output.Analysis)
+	}
+
+	requests := h.provider.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 provider request, got %d", len(requests))
+	}
+	if !strings.Contains(requests[0].Prompt, "fake logs") {
+		t.Errorf("the prompt does not contain the collected logs:\n%s", requests[0].Prompt)
+	}
+}
+
+func TestAnalyzeLogsQuietJSON(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "pod", "web", "-o", "json", "--quiet", "--show-logs", "--events=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+
+	// Standard output is the JSON document alone, for piping into jq
+	var output map[string]any
+	if err := json.Unmarshal([]byte(res.stdout), &output); err != nil {
+		t.Fatalf("standard output is not a JSON document: %v\n%s", err, res.stdout)
+	}
+	if _, ok := output["analysis"]; !ok {
+		t.Errorf("the JSON output has no analysis: %s", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "Collected") {
+		t.Errorf("progress is not reported on standard error:\n%s", res.stderr)
+	}
+}
+
+func TestAnalyzeLogsProviderFailure(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Fail(errors.New("quota exceeded"))
+
+	res := h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false")
+	if res.code != exitProvider {
+		t.Fatalf("expected exit code %d, got %d: %v", exitProvider, res.code, res.err)
+	}
+	if !strings.Contains(res.stderr, "quota exceeded") {
+		t.Errorf("the provider error is not reported:\n%s", res.stderr)
+	}
+}
+
+func TestAnalyzeLogsControlPlane(t *testing.T) {
+	h := newHarness(t, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-apiserver-control-plane-1",
+			Namespace: "kube-system",
+			Labels:    map[string]string{"tier": "control-plane", "component": "kube-apiserver"},
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "kube-apiserver"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+	h.provider.Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "control-plane", "kube-apiserver", "--show-logs=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "control-plane/kube-apiserver in namespace kube-system") {
+		t.Errorf("the logs are not collected from kube-system:\n%s", res.stdout)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "fake logs") {
+		t.Errorf("the prompt does not contain the control plane logs: %+v", requests)
+	}
+
+	res = h.run("analyze-logs", "control-plane", "etcd", "--show-logs=false")
+	if res.code != exitKubernetes || !strings.Contains(res.stderr, "managed control planes") {
+		t.Errorf("expected a Kubernetes error for a missing component, got %d: %s", res.code, res.stderr)
+	}
+}
+
+func TestAnalyzeLogsTimeline(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	recent := metav1.NewTime(time.Now().Add(-4 * time.Minute))
+	owner := []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}}
+	h := newHarness(t,
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-7d9f", Namespace: "default", CreationTimestamp: recent, OwnerReferences: owner,
+				Annotations: map[string]string{"deployment.kubernetes.io/revision": "4"},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-7d9f-x2k4", Namespace: "default", Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "web",
+					RestartCount: 1,
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+					}},
+				}},
+			},
+		},
+		&autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+				MaxReplicas:    5,
+			},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.rescale", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "HorizontalPodAutoscaler", Name: "web", Namespace: "default"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "SuccessfulRescale",
+			Message:        "New size: 4; reason: cpu resource utilization (percentage of request) above target",
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-3 * time.Minute)),
+		},
+	)
+	h.provider.Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "deployment", "web", "-o", "timeline", "--show-logs=false", "--usage=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs -o timeline failed: %v\n%s", res.err, res.stderr)
+	}
+	rollout := strings.Index(res.stdout, "revision 4 rolled out with nginx:1.27")
+	scaling := strings.Index(res.stdout, "New size: 4")
+	oomKill := strings.Index(res.stdout, "terminated with OOMKilled")
+	if rollout < 0 || scaling < 0 || oomKill < 0 {
+		t.Fatalf("the timeline is missing the rollout, scaling or OOM kill:\n%s", res.stdout)
+	}
+	if !(rollout < scaling && scaling < oomKill) {
+		t.Errorf("the timeline is not in chronological order:\n%s", res.stdout)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "## Timeline") {
+		t.Errorf("the prompt does not contain the timeline: %+v", requests)
+	}
+
+	res = h.run("analyze-logs", "deployment", "web", "-o", "timeline", "--events=false")
+	if res.code != exitUsage {
+		t.Errorf("expected a usage error for -o timeline without --events, got %d", res.code)
+	}
+}
+
+func TestAnalyzeLogsOptOut(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	h := newHarness(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Annotations: map[string]string{"kube-ai.io/ignore": "true"}}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: map[string]string{"kube-ai.io/redact-logs": "true"}},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+		},
+	)
+	h.provider.Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "deployment", "checkout", "-n", "payments")
+	if res.code != exitUsage {
+		t.Fatalf("expected exit code %d for an ignored namespace, got %d: %v", exitUsage, res.code, res.err)
+	}
+	if !strings.Contains(res.err.Error(), "namespace/payments") {
+		t.Errorf("the error does not name the annotated namespace: %v", res.err)
+	}
+	if len(h.provider.Requests()) != 0 {
+		t.Fatalf("an ignored workload was sent to the provider")
+	}
+
+	res = h.run("analyze-logs", "deployment", "web", "--show-logs=false", "--events=false", "--usage=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "as the kube-ai.io/redact-logs annotation on deployment/web asks") {
+		t.Errorf("the logs were not masked:\n%s", res.stdout)
+	}
+}
+
+func TestAnalyzeLogsHistory(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond(logAnalysis).Respond(logAnalysis)
+
+	// Nothing may be written in stateless mode
+	if res := h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--usage=false", "--history"); res.code != exitUsage {
+		t.Errorf("expected --history to be refused in stateless mode, got %d: %v", res.code, res.err)
+	}
+
+	t.Setenv(config.StatelessEnv, "")
+	res := h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--usage=false", "--history")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+	paths, _ := filepath.Glob(filepath.Join(os.Getenv("HOME"), ".kube-ai", "history", "*", "default_pod_web.json"))
+	if len(paths) != 1 {
+		t.Fatalf("the log history was not recorded: %v", paths)
+	}
+	if info, err := os.Stat(paths[0]); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the log history to be readable by the user only, got %v (%v)", info.Mode(), err)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "## Last 7 Days") {
+		t.Errorf("the prompt does not include the trend of the log history: %+v", requests)
+	}
+
+	// The history is off by default
+	res = h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--usage=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+	requests = h.provider.Requests()
+	if len(requests) != 2 || strings.Contains(requests[1].Prompt, "## Last 7 Days") {
+		t.Errorf("the prompt includes the trend without --history: %+v", requests)
+	}
+}
+
+func TestAnalyzeLogsExport(t *testing.T) {
+	h := newHarness(t, webPod)
+	dir := t.TempDir()
+
+	res := h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--usage=false",
+		"--export", "csv", "--export-path", dir, "--analyze=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs --export csv failed: %v\n%s", res.err, res.stderr)
+	}
+	if len(h.provider.Requests()) != 0 {
+		t.Errorf("the logs were sent to the provider with --analyze=false")
+	}
+	entries, err := os.ReadFile(filepath.Join(dir, "entries.csv"))
+	if err != nil {
+		t.Fatalf("the entries were not exported: %v", err)
+	}
+	if !strings.HasPrefix(string(entries), "timestamp,pod,container,level,message,pattern,fields\n") || !strings.Contains(string(entries), "fake logs") {
+		t.Errorf("unexpected entries.csv:\n%s", entries)
+	}
+
+	res = h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--usage=false",
+		"--export", "parquet", "--export-path", dir, "--analyze=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs --export parquet failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, name := range []string{"entries", "summary", "patterns", "hotspots"} {
+		data, err := os.ReadFile(filepath.Join(dir, name+".parquet"))
+		if err != nil || !strings.HasPrefix(string(data), "PAR1") || !strings.HasSuffix(string(data), "PAR1") {
+			t.Errorf("%s.parquet is not a Parquet file: %v", name, err)
+		}
+	}
+
+	res = h.run("analyze-logs", "pod", "web", "--export", "xlsx")
+	if res.code != exitUsage {
+		t.Errorf("expected a usage error for an unsupported export format, got %d", res.code)
+	}
+}
+
+func TestAnalyzeLogsMissingWorkload(t *testing.T) {
+	h := newHarness(t)
+
+	res := h.run("analyze-logs", "deployment", "missing", "--show-logs=false")
+	if res.code != exitKubernetes {
+		t.Fatalf("expected exit code %d, got %d: %v", exitKubernetes, res.code, res.err)
+	}
+	if len(h.provider.Requests()) != 0 {
+		t.Errorf("the provider was queried although no logs were collected")
+	}
+}
+
+func TestAnalyzeLogsMissingPermissions(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.allowAccess(func(attributes authorizationv1.ResourceAttributes) bool {
+		return attributes.Subresource != "log"
+	})
+
+	res := h.run("analyze-logs", "pod", "web", "--events=false")
+	if res.code != exitKubernetes {
+		t.Fatalf("exit code = %d, want %d\n%s", res.code, exitKubernetes, res.stderr)
+	}
+	for _, want := range []string{"missing permissions for analyze-logs in namespace default: get pods/log", "kind: Role", "- pods/log"} {
+		if !strings.Contains(res.stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, res.stderr)
+		}
+	}
+	if strings.Contains(res.stdout, "Collecting logs") {
+		t.Errorf("logs were collected despite missing permissions:\n%s", res.stdout)
+	}
+}
+
+func TestAnalyzeLogsSkippedSources(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.clientset.PrependReactor("list", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "events"}, "")
+	})
+	h.provider.Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "pod", "web", "-o", "json", "--show-logs=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed despite optional data sources missing: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"Skipped data sources", "events: not served by the cluster", "metrics.k8s.io"} {
+		if !strings.Contains(res.stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, res.stderr)
+		}
+	}
+	if !strings.Contains(res.stdout, `"skippedSources"`) {
+		t.Errorf("the JSON output does not list the skipped data sources:\n%s", res.stdout)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "## Missing Data") {
+		t.Errorf("the prompt does not mention the missing data: %+v", requests)
+	}
+}
+
+func TestAnalyzeFindings(t *testing.T) {
+	h := newHarness(t)
+	h.provider.Respond(`{
+  "summary": "One issue",
+  "findings": [{
+    "title": "No resource limits",
+    "severity": "High",
+    "category": "resources",
+    "resource": "Deployment/web",
+    "field": "spec.template.spec.containers[0].resources",
+    "description": "The container has no limits",
+    "recommendation": "Set limits"
+  }]
+}`)
+
+	manifest := filepath.Join(t.TempDir(), "web.yaml")
+	err := os.WriteFile(manifest, []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := h.run("analyze", "-f", manifest, "-o", "json")
+	if res.err != nil {
+		t.Fatalf("analyze failed: %v\n%s", res.err, res.stderr)
+	}
+	var output analyzers.ManifestAnalysisResult
+	if err := json.Unmarshal([]byte(res.stdout), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if len(output.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", output.Findings)
+	}
+	// The missing resources field is located at its container
+	if finding := output.Findings[0]; finding.Line != 9 {
+		t.Errorf("expected the finding on line 9, got %d (%s)", finding.Line, finding.Field)
+	}
+}
+
+func TestAnalyzeFindingsClusterVersion(t *testing.T) {
+	h := newHarness(t)
+	h.clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{Major: "1", Minor: "17", GitVersion: "v1.17.17"}
+	h.provider.Respond(`{
+  "summary": "Two issues",
+  "findings": [{
+    "title": "No resource limits",
+    "severity": "High",
+    "category": "resources",
+    "resource": "Deployment/web",
+    "field": "spec.template.spec.containers[0].resources",
+    "description": "The container has no limits",
+    "recommendation": "Set limits"
+  }, {
+    "title": "Slow start is killed",
+    "severity": "Medium",
+    "category": "reliability",
+    "resource": "Deployment/web",
+    "field": "spec.template.spec.containers[0].livenessProbe",
+    "description": "The liveness probe fires before the app starts",
+    "recommendation": "Add a startupProbe"
+  }]
+}`)
+
+	manifest := filepath.Join(t.TempDir(), "web.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := h.run("analyze", "-f", manifest, "-o", "json")
+	if res.err != nil {
+		t.Fatalf("analyze failed: %v\n%s", res.err, res.stderr)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "Kubernetes 1.17") || !strings.Contains(requests[0].Prompt, "startupProbe (Kubernetes 1.18+)") {
+		t.Errorf("the prompt does not mention the cluster's release: %+v", requests)
+	}
+	var output analyzers.ManifestAnalysisResult
+	if err := json.Unmarshal([]byte(res.stdout), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if len(output.Findings) != 1 || output.Findings[0].Title != "No resource limits" {
+		t.Errorf("expected the startupProbe finding to be left out, got %+v", output.Findings)
+	}
+	if len(output.LeftOut) != 1 || !strings.Contains(output.LeftOut[0], "startupProbe") {
+		t.Errorf("the left out finding is not reported: %v", output.LeftOut)
+	}
+}
+
+func TestAnalyzeFindingsClusterContext(t *testing.T) {
+	h := newHarness(t,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-1-12", Labels: map[string]string{"eks.amazonaws.com/nodegroup": "default"}}},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "aws-node", Namespace: "kube-system"}},
+		&networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "alb"}, Spec: networkingv1.IngressClassSpec{Controller: "ingress.k8s.aws/alb"}},
+	)
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+	}})
+	h.provider.Respond(`{"summary": "No issues", "findings": []}`)
+
+	manifest := filepath.Join(t.TempDir(), "web.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := h.run("analyze", "-f", manifest, "-o", "json")
+	if res.err != nil {
+		t.Fatalf("analyze failed: %v\n%s", res.err, res.stderr)
+	}
+	if prompt := h.provider.Requests()[0].Prompt; strings.Contains(prompt, "## Cluster Context") {
+		t.Errorf("the cluster context is in the prompt without --cluster-context:\n%s", prompt)
+	}
+
+	h.provider.Respond(`{"summary": "No issues", "findings": []}`)
+	res = h.run("analyze", "-f", manifest, "-o", "json", "--cluster-context")
+	if res.err != nil {
+		t.Fatalf("analyze failed: %v\n%s", res.err, res.stderr)
+	}
+	prompt := h.provider.Requests()[1].Prompt
+	for _, want := range []string{"## Cluster Context", "Platform: EKS", "Network plugin: Amazon VPC CNI", "Ingress classes: alb (ingress.k8s.aws/alb)", "Operators and add-ons: cert-manager"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("the prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	h := newHarness(t)
+
+	for _, args := range [][]string{
+		{"analyze-logs", "pod"},
+		{"analyze", "-o", "xml", "-f", "web.yaml"},
+		{"no-such-command"},
+	} {
+		res := h.run(args...)
+		if res.code != exitUsage {
+			t.Errorf("%s: expected exit code %d, got %d: %v", strings.Join(args, " "), exitUsage, res.code, res.err)
+		}
+	}
+}
+
+func TestShowPrompt(t *testing.T) {
+	h := newHarness(t, webPod)
+
+	res := h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--usage=false", "--show-prompt")
+	if res.code != 0 {
+		t.Fatalf("analyze-logs --show-prompt failed: %v\n%s", res.err, res.stderr)
+	}
+	if len(h.provider.Requests()) != 0 {
+		t.Errorf("the provider was called in dry-run mode")
+	}
+	if !strings.Contains(res.stdout, "PROMPT PREVIEW") || !strings.Contains(res.stdout, "fake logs") {
+		t.Errorf("the prompt is not shown:\n%s", res.stdout)
+	}
+	if res.stderr != "" {
+		t.Errorf("unexpected error output:\n%s", res.stderr)
+	}
+}
+
+func TestRedactTest(t *testing.T) {
+	h := newHarness(t)
+	manifest := filepath.Join(t.TempDir(), "deploy.yaml")
+	if err := os.WriteFile(manifest, []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: registry.internal.corp/web:1.2
+        env:
+        - name: API_TOKEN
+          value: s3cr3t-value
+        - name: LOG_LEVEL
+          value: debug
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := h.run("redact-test", manifest, "-o", "json", "--pattern", `registry\.internal\.corp`, "--pattern", "spec.containers[].env[?name=~'.*TOKEN']")
+	if res.err != nil {
+		t.Fatalf("redact-test failed: %v\n%s", res.err, res.stderr)
+	}
+	var result redactTestResult
+	if err := json.Unmarshal([]byte(res.stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if strings.Contains(result.Redacted, "s3cr3t-value") || strings.Contains(result.Redacted, "internal.corp") {
+		t.Errorf("values are not redacted:\n%s", result.Redacted)
+	}
+	if !strings.Contains(result.Redacted, "API_TOKEN") || !strings.Contains(result.Redacted, "value: debug") {
+		t.Errorf("too much is redacted:\n%s", result.Redacted)
+	}
+	if len(result.Matches) != 2 || result.Matches[0].Value != "s3cr3t-value" {
+		t.Errorf("unexpected matches: %+v", result.Matches)
+	}
+	if len(h.provider.Requests()) != 0 {
+		t.Errorf("the provider was called")
+	}
+
+	res = h.run("redact-test", manifest, "--pattern", "(unclosed")
+	if res.code != exitUsage {
+		t.Errorf("expected a usage error for an invalid pattern, got %d", res.code)
+	}
+}
+
+func TestAnalyzeLogsConsensus(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond(logAnalysis).Respond(logAnalysis).Respond(`{
+  "summary": "The web server is healthy",
+  "rootCauses": [],
+  "solutions": ["Nothing to do"],
+  "additionalInfo": [],
+  "severity": "Low",
+  "agreements": ["The web server is healthy"],
+  "disagreements": [{"topic": "Severity", "positions": [{"model": "fake/a", "position": "Low"}, {"model": "fake/b", "position": "Medium"}]}]
+}`)
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(`consensus:
+- provider: fake
+  model: a
+- provider: fake
+  model: b
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.StatelessEnv, "")
+	t.Setenv(config.ConfigEnv, configFile)
+
+	res := h.run("analyze-logs", "pod", "web", "-o", "json", "--show-logs=false", "--events=false", "--consensus", "2")
+	if res.err != nil {
+		t.Fatalf("analyze-logs --consensus failed: %v\n%s", res.err, res.stderr)
+	}
+	var output struct {
+		Consensus analyzers.ConsensusResult `json:"consensus"`
+	}
+	if err := json.Unmarshal([]byte(res.stdout), &output); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if len(output.Consensus.Models) != 2 || len(output.Consensus.Agreements) != 1 || len(output.Consensus.Disagreements) != 1 {
+		t.Errorf("unexpected consensus: %+v", output.Consensus)
+	}
+
+	// Two independent analyses, then the merge
+	requests := h.provider.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 provider requests, got %d", len(requests))
+	}
+	if !strings.Contains(requests[2].Prompt, "analyzed independently by 2 different AI models") {
+		t.Errorf("the last request is not the merge prompt:\n%s", requests[2].Prompt)
+	}
+
+	// Fewer configured models than requested is a usage error
+	res = h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--consensus", "3")
+	if res.code != exitUsage {
+		t.Errorf("expected exit code %d, got %d: %v", exitUsage, res.code, res.err)
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond(logAnalysis)
+
+	var received notify.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte("sinks:\n  ops:\n    type: webhook\n    url: ${OPS_WEBHOOK}\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.StatelessEnv, "")
+	t.Setenv(config.ConfigEnv, configFile)
+	t.Setenv("OPS_WEBHOOK", server.URL)
+
+	res := h.run("analyze-logs", "pod", "web", "--show-logs=false", "--events=false", "--notify", "ops")
+	if res.err != nil {
+		t.Fatalf("analyze-logs --notify failed: %v\n%s", res.err, res.stderr)
+	}
+	if received.Command != "analyze-logs" || received.Severity != "Low" {
+		t.Errorf("unexpected notification: %+v", received)
+	}
+	// The output is still printed, and delivered without colors
+	if !strings.Contains(res.stdout, "The web server is healthy") || !strings.Contains(received.Text, "The web server is healthy") {
+		t.Errorf("the output is missing:\n%s\n%s", res.stdout, received.Text)
+	}
+	if strings.Contains(received.Text, "\033[") {
+		t.Errorf("the delivered output has color codes:\n%s", received.Text)
+	}
+
+	// Unknown sinks are rejected before anything runs
+	res = h.run("analyze-logs", "pod", "web", "--notify", "missing")
+	if res.code != exitUsage {
+		t.Errorf("expected exit code %d, got %d: %v", exitUsage, res.code, res.err)
+	}
+}
+
+func TestEnrichIncidentPagerDuty(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond(logAnalysis)
+
+	var note struct {
+		Note struct {
+			Content string `json:"content"`
+		} `json:"note"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token=pd-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /incidents/P123":
+			w.Write([]byte(`{"incident": {"id": "P123", "title": "Pod web is crash looping"}}`))
+		case "GET /incidents/P123/alerts":
+			w.Write([]byte(`{"alerts": [{"body": {"details": {"namespace": "default", "pod": "web"}}}]}`))
+		case "POST /incidents/P123/notes":
+			json.NewDecoder(r.Body).Decode(&note)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("PAGERDUTY_URL", server.URL)
+	t.Setenv("PAGERDUTY_API_KEY", "pd-key")
+	t.Setenv("PAGERDUTY_FROM", "oncall@example.com")
+
+	res := h.run("enrich-incident", "--pagerduty", "P123")
+	if res.err != nil {
+		t.Fatalf("enrich-incident failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(note.Note.Content, "pod/web in namespace default") || !strings.Contains(note.Note.Content, "The web server is healthy") {
+		t.Errorf("unexpected note:\n%s", note.Note.Content)
+	}
+
+	// The alert is part of the prompt, so the analysis explains it
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "Pod web is crash looping") {
+		t.Errorf("the prompt does not contain the alert: %+v", requests)
+	}
+
+	res = h.run("enrich-incident")
+	if res.code != exitUsage {
+		t.Errorf("expected exit code %d without an incident, got %d: %v", exitUsage, res.code, res.err)
+	}
+}
+
+func TestAnalyzeArgo(t *testing.T) {
+	h := newHarness(t)
+	h.provider.Respond("Scale web back to 3 replicas in the values file.")
+
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]interface{}{"name": "shop", "namespace": "argocd"},
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{"repoURL": "https://git.example.com/shop.git", "path": "chart", "targetRevision": "main"},
+		},
+		"status": map[string]interface{}{
+			"sync":   map[string]interface{}{"status": "OutOfSync"},
+			"health": map[string]interface{}{"status": "Degraded"},
+			"resources": []interface{}{
+				map[string]interface{}{"group": "apps", "kind": "Deployment", "namespace": "default", "name": "web", "status": "OutOfSync",
+					"health": map[string]interface{}{"status": "Degraded", "message": "Deployment exceeded its progress deadline"}},
+				map[string]interface{}{"kind": "Service", "namespace": "default", "name": "web", "status": "Synced"},
+			},
+		},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{"name": "web", "namespace": "default", "annotations": map[string]interface{}{
+			"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default"},"spec":{"replicas":3}}`,
+		}},
+		"spec": map[string]interface{}{"replicas": int64(1)},
+	}})
+
+	res := h.run("analyze-argo", "shop")
+	if res.err != nil {
+		t.Fatalf("analyze-argo failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "spec.replicas: 3 -> 1") || strings.Contains(res.stdout, "Service/default/web") {
+		t.Errorf("unexpected resources or drift:\n%s", res.stdout)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "Deployment exceeded its progress deadline") {
+		t.Errorf("the prompt does not describe the application: %+v", requests)
+	}
+
+	res = h.run("analyze-argo", "missing")
+	if res.code != exitKubernetes {
+		t.Errorf("expected exit code %d for a missing application, got %d: %v", exitKubernetes, res.code, res.err)
+	}
+}
+
+func TestAnalyzeFlux(t *testing.T) {
+	h := newHarness(t, &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "podinfo.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "HelmChart", Namespace: "default", Name: "default-podinfo"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InvalidChartReference",
+		Message:        "invalid chart reference: failed to get chart version for remote reference: no chart version found for podinfo-9.9.9",
+	})
+	h.provider.Respond("Version 9.9.9 of podinfo does not exist; pin 6.5.4.")
+
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "helm.toolkit.fluxcd.io/v2",
+		"kind":       "HelmRelease",
+		"metadata":   map[string]interface{}{"name": "podinfo", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"chart": map[string]interface{}{"spec": map[string]interface{}{"chart": "podinfo", "version": "9.9.9"}},
+		},
+		"status": map[string]interface{}{
+			"helmChart": "default/default-podinfo",
+			"conditions": []interface{}{map[string]interface{}{
+				"type": "Ready", "status": "False", "reason": "ArtifactFailed", "message": "HelmChart 'default/default-podinfo' is not ready",
+			}},
+		},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "source.toolkit.fluxcd.io/v1",
+		"kind":       "HelmChart",
+		"metadata":   map[string]interface{}{"name": "default-podinfo", "namespace": "default"},
+		"spec":       map[string]interface{}{"chart": "podinfo"},
+		"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+			"type": "Ready", "status": "False", "reason": "InvalidChartReference", "message": "invalid chart reference",
+		}}},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kustomize.toolkit.fluxcd.io/v1",
+		"kind":       "Kustomization",
+		"metadata":   map[string]interface{}{"name": "apps", "namespace": "default"},
+		"spec":       map[string]interface{}{"path": "./apps", "dependsOn": []interface{}{map[string]interface{}{"name": "infra"}}},
+		"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+			"type": "Ready", "status": "False", "reason": "DependencyNotReady", "message": "dependency 'default/infra' is not ready",
+		}}},
+	}})
+
+	res := h.run("analyze-flux")
+	if res.err != nil {
+		t.Fatalf("analyze-flux failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"HelmRelease default/podinfo", "chart not found", "Kustomization default/apps", "dependency not ready", "pin 6.5.4"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "no chart version found for podinfo-9.9.9") {
+		t.Errorf("the prompt does not contain the source events: %+v", requests)
+	}
+
+	res = h.run("analyze-flux", "gitrepository", "podinfo")
+	if res.code != exitUsage {
+		t.Errorf("expected exit code %d for an unsupported kind, got %d: %v", exitUsage, res.code, res.err)
+	}
+}
+
+func TestAnalyzeMesh(t *testing.T) {
+	h := newHarness(t,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews-1", Namespace: "default", Labels: map[string]string{"app": "reviews", "version": "v1"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "reviews"}, {Name: "istio-proxy"}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "reviews"}},
+		},
+	)
+	h.provider.Respond("Add subset v2 to the DestinationRule and use ISTIO_MUTUAL.")
+
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": "reviews", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"hosts": []interface{}{"reviews"},
+			"http": []interface{}{map[string]interface{}{
+				"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "reviews", "subset": "v2"}}},
+			}},
+		},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1",
+		"kind":       "DestinationRule",
+		"metadata":   map[string]interface{}{"name": "reviews", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"host":          "reviews",
+			"trafficPolicy": map[string]interface{}{"tls": map[string]interface{}{"mode": "DISABLE"}},
+			"subsets":       []interface{}{map[string]interface{}{"name": "v1", "labels": map[string]interface{}{"version": "v1"}}},
+		},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "security.istio.io/v1",
+		"kind":       "PeerAuthentication",
+		"metadata":   map[string]interface{}{"name": "default", "namespace": "default"},
+		"spec":       map[string]interface{}{"mtls": map[string]interface{}{"mode": "STRICT"}},
+	}})
+
+	res := h.run("analyze-mesh", "pod", "reviews-1")
+	if res.err != nil {
+		t.Fatalf("analyze-mesh failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"missing subset", "subset v2", "mTLS mismatch", "STRICT", "ISTIO_MUTUAL"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "missing subset") || !strings.Contains(requests[0].Prompt, "mTLS mismatch") {
+		t.Errorf("the prompt does not contain the findings: %+v", requests)
+	}
+}
+
+func TestAnalyzeCertificate(t *testing.T) {
+	h := newHarness(t, &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "shop-tls-1-1-2.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Challenge", Namespace: "default", Name: "shop-tls-1-1-2"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Presented",
+		Message:        "Presented challenge using DNS-01 challenge mechanism",
+	})
+	h.provider.Respond("The TXT record is in the wrong zone; delegate _acme-challenge.")
+
+	owner := func(kind, name, uid string) []interface{} {
+		return []interface{}{map[string]interface{}{"apiVersion": "v1", "kind": kind, "name": name, "uid": uid}}
+	}
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "shop-tls", "namespace": "default", "uid": "cert"},
+		"spec": map[string]interface{}{
+			"dnsNames":   []interface{}{"shop.example.com"},
+			"secretName": "shop-tls",
+			"issuerRef":  map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer"},
+		},
+		"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+			"type": "Ready", "status": "False", "reason": "DoesNotExist", "message": "Issuing certificate as Secret does not exist",
+		}}},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "ClusterIssuer",
+		"metadata":   map[string]interface{}{"name": "letsencrypt"},
+		"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+			"type": "Ready", "status": "True",
+		}}},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "CertificateRequest",
+		"metadata":   map[string]interface{}{"name": "shop-tls-1", "namespace": "default", "uid": "request", "ownerReferences": owner("Certificate", "shop-tls", "cert")},
+		"status": map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+			"type": "Ready", "status": "False", "reason": "Pending", "message": "Waiting on certificate issuance from order default/shop-tls-1-1",
+		}}},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "acme.cert-manager.io/v1",
+		"kind":       "Order",
+		"metadata":   map[string]interface{}{"name": "shop-tls-1-1", "namespace": "default", "uid": "order", "ownerReferences": owner("CertificateRequest", "shop-tls-1", "request")},
+		"status":     map[string]interface{}{"state": "pending"},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "acme.cert-manager.io/v1",
+		"kind":       "Challenge",
+		"metadata":   map[string]interface{}{"name": "shop-tls-1-1-2", "namespace": "default", "ownerReferences": owner("Order", "shop-tls-1-1", "order")},
+		"spec":       map[string]interface{}{"type": "DNS-01", "dnsName": "shop.example.com"},
+		"status": map[string]interface{}{
+			"state": "pending", "presented": true,
+			"reason": "Waiting for DNS-01 challenge propagation: DNS record for \"shop.example.com\" not yet propagated",
+		},
+	}})
+
+	res := h.run("analyze", "certificate", "shop-tls")
+	if res.err != nil {
+		t.Fatalf("analyze certificate failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"ClusterIssuer/letsencrypt: Ready=True", "Order/shop-tls-1-1", "Challenge/shop-tls-1-1-2", "DNS01 propagation", "delegate _acme-challenge"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "Presented challenge using DNS-01") {
+		t.Errorf("the prompt does not contain the challenge events: %+v", requests)
+	}
+}
+
+func TestDiagnoseDNS(t *testing.T) {
+	h := newHarness(t,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns-1", Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-dns"}},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "coredns", RestartCount: 7, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}}},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.10"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Data:       map[string]string{"Corefile": ".:53 {\n    kubernetes cluster.local in-addr.arpa ip6.arpa\n    forward . /etc/resolv.conf\n    cache 30\n}\n"},
+		},
+	)
+	h.provider.Respond("CoreDNS is crash looping on a forwarding loop; forward to the upstream resolvers.")
+
+	res := h.run("diagnose", "dns", "--symptom", "lookup db on 10.96.0.10:53: i/o timeout")
+	if res.err != nil {
+		t.Fatalf("diagnose dns failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"coredns-1 is not ready (CrashLoopBackOff)", "no ready endpoints", "no loop plugin", "forwarding loop"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "lookup db on 10.96.0.10:53: i/o timeout") || !strings.Contains(requests[0].Prompt, "forward . /etc/resolv.conf") {
+		t.Errorf("the prompt does not contain the symptoms and Corefile: %+v", requests)
+	}
+
+	res = h.run("diagnose", "dns", "--debug-pod")
+	if res.code != exitUsage {
+		t.Errorf("expected exit code %d for a debug pod in read-only mode, got %d: %v", exitUsage, res.code, res.err)
+	}
+}
+
+func TestAnalyzeCronJob(t *testing.T) {
+	forbid := int64(5)
+	h := newHarness(t,
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "default", UID: "cron"},
+			Spec: batchv1.CronJobSpec{
+				Schedule:                "0 * * * *",
+				ConcurrencyPolicy:       batchv1.ForbidConcurrent,
+				StartingDeadlineSeconds: &forbid,
+			},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "report-1", Namespace: "default", OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "report", UID: "cron"}}},
+			Spec:       batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"job-name": "report-1"}}},
+			Status: batchv1.JobStatus{
+				Failed:     7,
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "report-1-abcde", Namespace: "default", Labels: map[string]string{"job-name": "report-1"}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "report", State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+				}}},
+			},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "report.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "CronJob", Namespace: "default", Name: "report"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "MissSchedule",
+			Message:        "Missed scheduled time to start a job: 2026-10-16 10:00:00 +0000 UTC",
+		},
+	)
+	h.provider.Respond("The report script exits 1 on every attempt; fix the database URL.")
+
+	res := h.run("analyze", "cronjob", "report")
+	if res.err != nil {
+		t.Fatalf("analyze cronjob failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"Schedule:", "report-1", "BackoffLimitExceeded", "Error (exit code 1)", "startingDeadlineSeconds is 5", "MissSchedule", "fix the database URL"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "fake logs") || !strings.Contains(requests[0].Prompt, "Starting deadline: 5s") {
+		t.Errorf("the prompt does not contain the failed pod logs and schedule: %+v", requests)
+	}
+}
+
+func TestAnalyzeGPU(t *testing.T) {
+	gpus := corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+	h := newHarness(t,
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu-node", Labels: map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100-SXM4-40GB"}},
+			Status: corev1.NodeStatus{
+				Capacity:    gpus,
+				Allocatable: gpus,
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "train-0", Namespace: "default"},
+			Spec: corev1.PodSpec{NodeName: "gpu-node", Containers: []corev1.Container{{
+				Name: "train", Resources: corev1.ResourceRequirements{Limits: gpus},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "train-1", Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "train", Resources: corev1.ResourceRequirements{Limits: gpus},
+			}}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodPending,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/1 nodes are available: 1 Insufficient nvidia.com/gpu."}},
+			},
+		},
+	)
+	h.provider.Respond("The only A100 is taken by train-0; split it with MIG 3g.20gb.")
+
+	res := h.run("analyze-gpu")
+	if res.err != nil {
+		t.Fatalf("analyze-gpu failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"NVIDIA-A100-SXM4-40GB", "1/1", "all 1 GPUs of node gpu-node are requested", "Insufficient nvidia.com/gpu", "no GPU device plugin", "MIG 3g.20gb"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "default/train-1: Pending") {
+		t.Errorf("the prompt does not contain the GPU pods: %+v", requests)
+	}
+}
+
+func TestDiagnoseStatefulSet(t *testing.T) {
+	replicas := int32(3)
+	labels := map[string]string{"app": "etcd"}
+	h := newHarness(t,
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "default"},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:    &replicas,
+				ServiceName: "etcd",
+				Selector:    &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd", Image: "quay.io/coreos/etcd:v3.5.12"}}},
+				},
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.20", Selector: labels},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd-0", Namespace: "default", Labels: labels},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodPending,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "pod has unbound immediate PersistentVolumeClaims"}},
+			},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-etcd-0", Namespace: "default"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+	)
+	h.provider.Respond("Bind data-etcd-0 first; etcd-1 and etcd-2 follow in order.")
+
+	res := h.run("diagnose", "statefulset", "etcd")
+	if res.err != nil {
+		t.Fatalf("diagnose statefulset failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{
+		"Engine:          etcd",
+		"service etcd is not headless",
+		"claim data-etcd-0 of etcd-0 is Pending",
+		"etcd-1 is not created because etcd-0 is not ready",
+		"etcd-1 and etcd-2 follow in order",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "data-etcd-0") {
+		t.Errorf("the prompt does not contain the volume claims: %+v", requests)
+	}
+}
+
+func TestExplainScalingEvents(t *testing.T) {
+	replicas := int32(1)
+	labels := map[string]string{"app": "cluster-autoscaler"}
+	now := metav1.Now()
+	h := newHarness(t,
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-autoscaler", Namespace: "kube-system"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Selector: &metav1.LabelSelector{MatchLabels: labels}},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-autoscaler-7d9f", Namespace: "kube-system", Labels: labels},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-5c4b", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodPending,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available: 3 Insufficient memory."}},
+			},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "api-5c4b.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "api-5c4b"},
+			Source:         corev1.EventSource{Component: "cluster-autoscaler"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "NotTriggerScaleUp",
+			Message:        "pod didn't trigger scale-up: 1 max node group size reached",
+			LastTimestamp:  now,
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "node-2.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Node", Name: "node-2"},
+			Source:         corev1.EventSource{Component: "node-controller"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "RemovingNode",
+			Message:        "Node node-2 event: Removing Node node-2 from Controller",
+			LastTimestamp:  now,
+		},
+	)
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "karpenter.sh/v1",
+		"kind":       "NodePool",
+		"metadata":   map[string]interface{}{"name": "default"},
+		"spec": map[string]interface{}{
+			"limits":     map[string]interface{}{"cpu": "64"},
+			"disruption": map[string]interface{}{"consolidationPolicy": "WhenEmptyOrUnderutilized"},
+		},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		}},
+	}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "karpenter.sh/v1",
+		"kind":       "NodeClaim",
+		"metadata":   map[string]interface{}{"name": "default-x7k2p", "labels": map[string]interface{}{"karpenter.sh/nodepool": "default"}},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Launched", "status": "False", "reason": "InsufficientCapacityError", "message": "no m5.2xlarge capacity in us-east-1a"},
+		}},
+	}})
+	h.provider.Respond("The node group is at its maximum size; raise it to 5.")
+
+	res := h.run("explain-scaling-events")
+	if res.err != nil {
+		t.Fatalf("explain-scaling-events failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{
+		"cluster-autoscaler kube-system/cluster-autoscaler, 1/1 ready",
+		"default/api-5c4b",
+		"RemovingNode",
+		"Pod/default/api-5c4b did not trigger a scale-up: pod didn't trigger scale-up: 1 max node group size reached",
+		"NodeClaim default-x7k2p has Launched=False (InsufficientCapacityError)",
+		"NodePool:      default, ready=True",
+		"raise it to 5",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "3 Insufficient memory") {
+		t.Errorf("the prompt does not contain the pending pods: %+v", requests)
+	}
+}
+
+func TestSummarizeNamespace(t *testing.T) {
+	replicas := int32(2)
+	h := newHarness(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "shop"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "checkout", Image: "shop/checkout:1.4"}}}},
+			},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout-7d9f", Namespace: "shop", Labels: map[string]string{"app": "checkout"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "checkout", Image: "shop/checkout:1.4"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "checkout", RestartCount: 7,
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "shop"},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: map[string]string{"app": "payments"},
+				Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}}},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "checkout-7d9f.1", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "checkout-7d9f"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			LastTimestamp:  metav1.Now(),
+		},
+	)
+	h.provider.Respond("The shop namespace runs the checkout service of an online store; checkout is crash looping.")
+
+	res := h.run("summarize", "namespace", "shop")
+	if res.err != nil {
+		t.Fatalf("summarize namespace failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{
+		"shop/checkout:1.4",
+		"deployment checkout is degraded: 1/2 replicas ready",
+		"container checkout is waiting in CrashLoopBackOff (7 restarts)",
+		"Service payments selects no running pods",
+		"Back-off restarting failed container",
+		"online store",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "deployment checkout: 1/2 ready (Degraded), images shop/checkout:1.4") {
+		t.Errorf("the prompt does not contain the workloads: %+v", requests)
+	}
+}
+
+func TestOnboard(t *testing.T) {
+	h := newHarness(t)
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"models": [{"name": "qwen2.5:7b"}, {"name": "llama3.1:8b"}]}`))
+	}))
+	defer ollama.Close()
+	t.Setenv(config.StatelessEnv, "")
+	t.Setenv("OLLAMA_URL", ollama.URL)
+
+	// Accept the detected Ollama server, its URL and its first model
+	answers := filepath.Join(t.TempDir(), "answers")
+	if err := os.WriteFile(answers, []byte("\n\n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(answers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	saved := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = saved }()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	res := h.run("onboard", "--config", configPath)
+	if res.err != nil {
+		t.Fatalf("onboard failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"Found Ollama at " + ollama.URL + " with 2 models", "Model [qwen2.5:7b]", "Reached context", "Log analysis is allowed"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, res.stdout)
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"aiProvider: ollama", "defaultModel: qwen2.5:7b", "ollamaUrl: " + ollama.URL} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("the configuration is missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := newHarness(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	t.Setenv(config.StatelessEnv, "")
+	h.provider.Respond("The namespace runs nothing yet.").Respond("Still nothing.")
+
+	if res := h.run("summarize", "namespace", "shop", "-o", "json"); res.err != nil {
+		t.Fatalf("summarize failed: %v\n%s", res.err, res.stderr)
+	}
+
+	res := h.run("history")
+	if res.err != nil {
+		t.Fatalf("history failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "summarize namespace shop --output=json") || !strings.Contains(res.stdout, "fake") {
+		t.Fatalf("history does not list the command:\n%s", res.stdout)
+	}
+
+	res = h.run("history", "-o", "json")
+	var entries []history.Entry
+	if err := json.Unmarshal([]byte(res.stdout), &entries); err != nil || len(entries) != 1 {
+		t.Fatalf("expected one JSON entry, got %v:\n%s", err, res.stdout)
+	}
+	if entries[0].InputTokens == 0 || !strings.Contains(entries[0].Prompt, "shop") {
+		t.Errorf("entry is missing its prompt or tokens: %+v", entries[0])
+	}
+
+	res = h.run("history", "show", entries[0].ID[:4])
+	if res.err != nil || !strings.Contains(res.stdout, "The namespace runs nothing yet.") {
+		t.Fatalf("history show does not print the answer (%v):\n%s", res.err, res.stdout)
+	}
+
+	res = h.run("history", "rerun", entries[0].ID)
+	if res.err != nil {
+		t.Fatalf("history rerun failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "Still nothing.") || len(h.provider.Requests()) != 2 {
+		t.Errorf("rerun did not ask the provider again:\n%s", res.stdout)
+	}
+
+	if res := h.run("history", "show", "zzzz"); res.code != exitUsage {
+		t.Errorf("expected a usage error for an unknown ID, got %d", res.code)
+	}
+}
+
+func TestCtx(t *testing.T) {
+	h := newHarness(t)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	clusters := `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster: {server: "https://east.example.com"}
+- name: west
+  cluster: {server: "https://west.example.com"}
+users:
+- name: admin
+  user: {}
+contexts:
+- name: prod-us-east
+  context: {cluster: east, user: admin}
+- name: prod-us-west
+  context: {cluster: west, user: admin}
+current-context: prod-us-west
+`
+	if err := os.WriteFile(kubeconfig, []byte(clusters), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+	stored := filepath.Join(os.Getenv("HOME"), ".kube-ai", "context")
+
+	// Selecting a context prints an export for the shell by default
+	res := h.run("ctx", "pue")
+	if res.err != nil || res.stdout != "export KUBE_AI_CONTEXT=\"prod-us-east\"\n" {
+		t.Errorf("expected an export of the context, got %v:\n%s", res.err, res.stdout)
+	}
+	if _, err := os.Stat(stored); !os.IsNotExist(err) {
+		t.Errorf("the selection was stored without --save: %v", err)
+	}
+	if res := h.run("ctx", "pue", "--save"); res.code != exitUsage {
+		t.Errorf("expected --save to be refused in stateless mode, got %d: %v", res.code, res.err)
+	}
+
+	// The AI's pick is only stored once confirmed, or with --yes
+	t.Setenv(config.StatelessEnv, "")
+	if res := h.run("ctx", "--ask", "the east cluster", "--save", "--no-probe"); res.code != exitUsage || !strings.Contains(res.stderr, "--yes") {
+		t.Errorf("expected --ask --save to require confirmation, got %d: %v", res.code, res.err)
+	}
+	h.provider.Respond(`{"context": "prod-us-east", "reason": "its server is in us-east"}`)
+	res = h.run("ctx", "--ask", "the east cluster", "--save", "--yes", "--no-probe")
+	if res.err != nil {
+		t.Fatalf("ctx failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stderr, "prod-us-east: its server is in us-east") {
+		t.Errorf("the AI's choice is not shown:\n%s", res.stderr)
+	}
+	if data, err := os.ReadFile(stored); err != nil || strings.TrimSpace(string(data)) != "prod-us-east" {
+		t.Errorf("expected the context to be stored, got %q (%v)", data, err)
+	}
+}
+
+func TestRequestSpinner(t *testing.T) {
+	h := newHarness(t, webPod)
+	h.provider.Respond("The message means the image could not be pulled")
+	h.provider.Respond("Nothing is wrong with the pods")
+
+	// Count the requests the spinner is started for, and stopped after
+	var started, stopped int
+	restore := requestSpinner
+	requestSpinner = func() func() func() {
+		return func() func() {
+			started++
+			return func() { stopped++ }
+		}
+	}
+	t.Cleanup(func() { requestSpinner = restore })
+
+	if res := h.run("explain", "ImagePullBackOff"); res.err != nil {
+		t.Fatalf("explain failed: %v\n%s", res.err, res.stderr)
+	}
+	if started != 1 || stopped != 1 {
+		t.Errorf("expected the spinner for the explain request, started %d and stopped %d times", started, stopped)
+	}
+
+	// The agent sends its requests to the provider itself
+	if res := h.run("agent", "are the pods healthy?"); res.err != nil {
+		t.Fatalf("agent failed: %v\n%s", res.err, res.stderr)
+	}
+	if started != 2 || stopped != 2 {
+		t.Errorf("expected the spinner for the agent request, started %d and stopped %d times", started, stopped)
+	}
+}
+
+func TestHistoryRedaction(t *testing.T) {
+	h := newHarness(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	t.Setenv(config.StatelessEnv, "")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("redact:\n  - 'ticket-[0-9]+'\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h.provider.Respond("The image tag does not exist.")
+
+	res := h.run("explain", "pull failed for ticket-4821", "--token", "SUPERSECRETTOKEN123", "--config", configPath)
+	if res.err != nil {
+		t.Fatalf("explain failed: %v\n%s", res.err, res.stderr)
+	}
+
+	res = h.run("history", "-o", "json")
+	var entries []history.Entry
+	if err := json.Unmarshal([]byte(res.stdout), &entries); err != nil || len(entries) != 1 {
+		t.Fatalf("expected one JSON entry, got %v:\n%s", err, res.stdout)
+	}
+	args := strings.Join(entries[0].Args, " ")
+	if strings.Contains(args, "SUPERSECRETTOKEN123") || strings.Contains(args, "--token") {
+		t.Errorf("the token is kept in the history: %s", args)
+	}
+	if strings.Contains(args, "ticket-4821") || !strings.Contains(args, "--config=") {
+		t.Errorf("the arguments are not redacted as the prompt is: %s", args)
+	}
+
+	// Credentials are masked in what is kept even when the prompt is sent unredacted
+	h.provider.Respond("Rotate the leaked password.")
+	res = h.run("explain", "the app logs password=hunter2hunter2 at startup", "--config", configPath)
+	if res.err != nil {
+		t.Fatalf("explain failed: %v\n%s", res.err, res.stderr)
+	}
+	if requests := h.provider.Requests(); !strings.Contains(requests[len(requests)-1].Prompt, "hunter2hunter2") {
+		t.Fatalf("expected the prompt to be sent unredacted with the default policy")
+	}
+	res = h.run("history", "-o", "json")
+	if strings.Contains(res.stdout, "hunter2hunter2") {
+		t.Errorf("the password is kept in the history:\n%s", res.stdout)
+	}
+}
+
+func TestQuietOutput(t *testing.T) {
+	h := newHarness(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	h.provider.Respond("The namespace runs nothing yet.")
+
+	res := h.run("summarize", "namespace", "shop", "--quiet")
+	if res.err != nil {
+		t.Fatalf("summarize failed: %v\n%s", res.err, res.stderr)
+	}
+	if strings.Contains(res.stdout, "Summarizing the namespace...") || !strings.Contains(res.stderr, "Summarizing the namespace...") {
+		t.Errorf("progress is not on standard error:\nstdout:\n%s\nstderr:\n%s", res.stdout, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "The namespace runs nothing yet.") {
+		t.Errorf("the result is missing from standard output:\n%s", res.stdout)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	answer := "## Cause\nThe **probe** hits `/healthz` too early.\n```yaml\ninitialDelaySeconds: 10\n```"
+
+	// Output that is not a terminal, as in tests, keeps the Markdown as written
+	setColorOutput(false)
+	if got := renderMarkdown(answer); got != answer {
+		t.Errorf("expected the answer unchanged without colors, got %q", got)
+	}
+
+	colorOutput = true
+	defer func() { colorOutput = false }()
+	got := renderMarkdown(answer)
+	for _, want := range []string{ansiBold + "Cause" + ansiReset, ansiBold + "probe" + ansiReset, ansiCyan + "/healthz" + ansiReset, "    " + ansiCyan + "initialDelaySeconds: 10"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered answer is missing %q: %q", want, got)
+		}
+	}
+	if strings.Contains(got, "```") || strings.Contains(got, "##") {
+		t.Errorf("rendered answer still has Markdown markup: %q", got)
+	}
+}
+
+func TestGenerateOutput(t *testing.T) {
+	h := newHarness(t)
+	answer := "```yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n```"
+	h.provider.Respond(answer).Respond(answer)
+
+	dir := filepath.Join(t.TempDir(), "web")
+	res := h.run("generate", "a web server", "--out-dir", dir)
+	if res.err != nil {
+		t.Fatalf("generate failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, file := range []string{"deployment.yaml", "service.yaml"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v\n%s", file, err, res.stdout)
+		}
+		if !strings.Contains(string(data), "name: web") {
+			t.Errorf("%s does not hold its resource:\n%s", file, data)
+		}
+	}
+
+	// The earlier flag name still writes a single file
+	file := filepath.Join(t.TempDir(), "web.yaml")
+	if res := h.run("generate", "a web server", "--output-file", file); res.err != nil {
+		t.Fatalf("generate failed: %v\n%s", res.err, res.stderr)
+	}
+	if data, err := os.ReadFile(file); err != nil || !strings.Contains(string(data), "kind: Service") {
+		t.Errorf("expected the manifest in %s (%v):\n%s", file, err, data)
+	}
+
+	if res := h.run("generate", "a web server", "--out", file, "--out-dir", dir); res.code != exitUsage {
+		t.Errorf("expected a usage error for --out with --out-dir, got %d", res.code)
+	}
+}
+
+func TestGenerateApply(t *testing.T) {
+	h := newHarness(t)
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"replicas": int64(1)},
+	}})
+	answer := "```yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n```"
+
+	// Without a terminal to confirm on, --yes is required before anything is generated
+	if res := h.run("generate", "three web replicas", "--apply"); res.code != exitUsage {
+		t.Errorf("expected a usage error without --yes, got %d: %v", res.code, res.err)
+	}
+	if requests := h.provider.Requests(); len(requests) != 0 {
+		t.Errorf("expected no AI request, got %d", len(requests))
+	}
+
+	h.provider.Respond(answer)
+	if res := h.run("generate", "three web replicas", "--apply", "--yes"); res.code != exitUsage || !strings.Contains(res.stderr, "--allow-writes") {
+		t.Errorf("expected applying to require --allow-writes, got %d:\n%s", res.code, res.stderr)
+	}
+
+	h.provider.Respond(answer)
+	res := h.run("generate", "three web replicas", "--apply", "--yes", "--allow-writes")
+	if res.err != nil {
+		t.Fatalf("generate failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"deployment/web will be changed", "-  replicas: 1", "+  replicas: 3", "deployment/web applied"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("expected %q in the output:\n%s", want, res.stdout)
+		}
+	}
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	live, err := h.dynamic.Resource(deployments).Namespace("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("expected 3 replicas once applied, got %d", replicas)
+	}
+}
+
+func TestOptimizePatch(t *testing.T) {
+	h := newHarness(t)
+	file := filepath.Join(t.TempDir(), "web.yaml")
+	original := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        resources:
+          limits:
+            cpu: "2"
+`
+	if err := os.WriteFile(file, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	optimized := strings.Replace(original, `cpu: "2"`, "cpu: 500m\n            memory: 256Mi", 1)
+	answer := "Lower the CPU limit.\n```yaml\n" + optimized + "```"
+
+	h.provider.Respond(answer)
+	res := h.run("optimize", "-f", file, "--patch", "strategic")
+	if res.err != nil {
+		t.Fatalf("optimize failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"kubectl patch deployment web -n shop --type strategic", "kind: Deployment", "name: web", "cpu: 500m", "memory: 256Mi"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("expected %q in the strategic merge patch:\n%s", want, res.stdout)
+		}
+	}
+	for _, unchanged := range []string{"replicas", "image", "Lower the CPU limit"} {
+		if strings.Contains(res.stdout, unchanged) {
+			t.Errorf("expected only the changed fields, found %q:\n%s", unchanged, res.stdout)
+		}
+	}
+
+	h.provider.Respond(answer)
+	res = h.run("optimize", "-f", file, "--patch", "json")
+	if res.err != nil {
+		t.Fatalf("optimize failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{
+		"--type json",
+		"op: replace\n  path: /spec/template/spec/containers/0/resources/limits/cpu\n  value: 500m",
+		"op: add\n  path: /spec/template/spec/containers/0/resources/limits/memory\n  value: 256Mi",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("expected %q in the JSON patch:\n%s", want, res.stdout)
+		}
+	}
+
+	if res := h.run("optimize", "-f", file, "--patch", "xml"); res.code != exitUsage {
+		t.Errorf("expected a usage error for an unknown patch format, got %d", res.code)
+	}
+}
+
+func TestHow(t *testing.T) {
+	zone := map[string]string{"topology.kubernetes.io/zone": "us-east-1a"}
+	h := newHarness(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: zone}})
+	h.addObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]interface{}{"name": "node-a", "labels": map[string]interface{}{"topology.kubernetes.io/zone": "us-east-1a"}},
+	}})
+	answer := `{"commands": [
+		{"command": "kubectl get nodes -l topology.kubernetes.io/zone=us-east-1a", "explanation": "List the nodes in the zone"},
+		{"command": "kubectl cordon -l topology.kubernetes.io/zone=us-east-1a", "explanation": "Cordon them"},
+		{"command": "kubectl cordon node-b", "explanation": "Cordon another node"},
+		{"command": "kubectl get nodes | wc -l", "explanation": "Count the nodes"}
+	], "notes": "Cordoning does not evict running pods."}`
+
+	h.provider.Respond(answer)
+	res := h.run("how", "cordon all nodes in zone us-east-1a")
+	if res.err != nil {
+		t.Fatalf("how failed: %v\n%s", res.err, res.stderr)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "topology.kubernetes.io/zone: us-east-1a") {
+		t.Errorf("expected the node labels in the prompt, got %+v", requests)
+	}
+	for _, want := range []string{
+		"# Cordon them\nkubectl cordon -l topology.kubernetes.io/zone=us-east-1a\n#   -l topology.kubernetes.io/zone=us-east-1a matches 1 node",
+		"Warning: node node-b does not exist",
+		"needs a shell",
+		"Cordoning does not evict running pods.",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("expected %q in the output:\n%s", want, res.stdout)
+		}
+	}
+
+	// Nothing runs when a check found a problem, or a command writes in read-only mode
+	h.provider.Respond(answer)
+	if res := h.run("how", "cordon all nodes in zone us-east-1a", "--execute", "--yes"); res.err == nil || !strings.Contains(res.stderr, "found problems") {
+		t.Errorf("expected --execute to refuse commands with problems, got %v:\n%s", res.err, res.stderr)
+	}
+	h.provider.Respond(`{"commands": [{"command": "kubectl cordon -l topology.kubernetes.io/zone=us-east-1a", "explanation": "Cordon them"}], "notes": ""}`)
+	if res := h.run("how", "cordon all nodes in zone us-east-1a", "--execute", "--yes"); res.code != exitUsage || !strings.Contains(res.stderr, "--allow-writes") {
+		t.Errorf("expected --execute to require --allow-writes, got %d:\n%s", res.code, res.stderr)
+	}
+
+	// Suggested commands cannot pick another cluster or identity than kube-ai's flags
+	h.provider.Respond(`{"commands": [{"command": "kubectl get nodes --context=prod --token abc", "explanation": "List the nodes"}], "notes": ""}`)
+	res = h.run("how", "list the nodes")
+	for _, want := range []string{"Warning: --context changes the cluster", "Warning: --token changes the cluster"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("expected %q in the output:\n%s", want, res.stdout)
+		}
+	}
+}
+
+func TestKubectlConnectionArgs(t *testing.T) {
+	t.Setenv("KUBE_AI_CONTEXT", "")
+	cmd := &cobra.Command{Use: "how"}
+	k8s.AddKubectlFlags(cmd)
+	if err := cmd.ParseFlags([]string{"--context", "staging", "--server", "https://10.0.0.1:6443", "--token", "t0k3n",
+		"--user", "admin", "--cluster", "east", "--as", "jane", "--as-group", "ops", "--as-group", "dev",
+		"--certificate-authority", "/etc/ca.crt", "--insecure-skip-tls-verify", "-n", "payments"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(kubectlConnectionArgs(cmd), " ")
+	want := "--context staging --cluster east --user admin --server https://10.0.0.1:6443 --token t0k3n " +
+		"--certificate-authority /etc/ca.crt --as jane --namespace payments --as-group ops --as-group dev --insecure-skip-tls-verify"
+	if got != want {
+		t.Errorf("unexpected kubectl flags:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestClusterCacheOptIn(t *testing.T) {
+	h := newHarness(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	if res := h.run("summarize", "namespace", "shop", "--cache"); res.code != exitUsage {
+		t.Errorf("expected --cache to be refused in stateless mode, got %d: %v", res.code, res.err)
+	}
+	if len(h.provider.Requests()) != 0 {
+		t.Errorf("the command ran despite --cache in stateless mode")
+	}
+
+	cmd := &cobra.Command{Use: "summarize"}
+	k8s.AddKubectlFlags(cmd)
+	if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err != nil || clientConfig.Cache {
+		t.Errorf("expected the cache to be off by default, got %v (%v)", clientConfig.Cache, err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	h := newHarness(t)
+	pod := func(name string, age time.Duration, ready bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name":              name,
+				"namespace":         "payments",
+				"creationTimestamp": time.Now().Add(-age).UTC().Format(time.RFC3339),
+			},
+			"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "app"}}},
+			"status": map[string]interface{}{
+				"phase":             "Running",
+				"containerStatuses": []interface{}{map[string]interface{}{"name": "app", "ready": ready, "restartCount": int64(4)}},
+			},
+		}}
+	}
+	h.addObject(pod("stuck", 3*time.Hour, false))
+	h.addObject(pod("healthy", 3*time.Hour, true))
+	h.addObject(pod("starting", 10*time.Minute, false))
+
+	plan := `{"resource": "pods", "namespace": "payments", "allNamespaces": false, "labelSelector": "", "fieldSelector": "",
+		"filters": [{"field": "ready", "operator": "=", "value": "false"}, {"field": "age", "operator": ">", "value": "1h"}], "notes": ""}`
+	h.provider.Respond(plan)
+	res := h.run("get", "pods not ready in payments older than 1h")
+	if res.err != nil {
+		t.Fatalf("get failed: %v\n%s", res.err, res.stderr)
+	}
+	query, table, found := strings.Cut(res.stdout, "Query: pods -n payments where ready = false and age > 1h\n")
+	if !found || !strings.Contains(query, "Planning the query") {
+		t.Fatalf("expected the planned query before the table:\n%s", res.stdout)
+	}
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "NAME ") || !strings.Contains(lines[0], "RESTARTS") {
+		t.Fatalf("expected a header and one pod, got:\n%s", res.stdout)
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 5 || fields[0] != "stuck" || fields[1] != "0/1" || fields[3] != "4" || fields[4] != "3h" {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+
+	h.provider.Respond(`{"resource": "pods", "namespace": "payments", "allNamespaces": false, "labelSelector": "", "fieldSelector": "",
+		"filters": [{"field": "age", "operator": ">", "value": "soon"}], "notes": ""}`)
+	if res := h.run("get", "pods in payments"); res.err == nil || !strings.Contains(res.stderr, "invalid query") {
+		t.Errorf("expected an invalid plan to be refused, got %v:\n%s", res.err, res.stderr)
+	}
+	h.provider.Respond(`{"resource": "widgets", "namespace": "", "allNamespaces": false, "labelSelector": "", "fieldSelector": "", "filters": [], "notes": ""}`)
+	if res := h.run("get", "widgets"); res.err == nil || !strings.Contains(res.stderr, `no resource type "widgets"`) {
+		t.Errorf("expected an unknown resource type to be reported, got %v:\n%s", res.err, res.stderr)
+	}
+}
+
+func TestDetectInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  inputKind
+	}{
+		{"apiVersion: v1\nkind: Pod\nmetadata:\n  name: web-0\n", inputManifest},
+		{`{"apiVersion": "v1", "kind": "List", "items": []}`, inputManifest},
+		{"Name:         web-0\nNamespace:    shop\nStatus:       Running\nEvents:       <none>\n", inputDescribe},
+		{"2024-05-01T10:00:00Z INFO starting\n2024-05-01T10:00:01Z ERROR connection refused\n", inputLogs},
+		{`{"level":"error","msg":"timeout"}` + "\n" + `{"level":"info","msg":"retrying"}`, inputLogs},
+		{"Failed to pull image: ErrImagePull", inputText},
+		{"", inputText},
+	}
+	for _, test := range tests {
+		if got := detectInput(test.input); got != test.want {
+			t.Errorf("detectInput(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestExplainStdin(t *testing.T) {
+	h := newHarness(t)
+	pipe := func(input string) {
+		path := filepath.Join(t.TempDir(), "stdin")
+		if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+			t.Fatal(err)
+		}
+		stdin, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { stdin.Close() })
+		saved := os.Stdin
+		os.Stdin = stdin
+		t.Cleanup(func() { os.Stdin = saved })
+	}
+
+	pipe("Name:         web-0\nNamespace:    shop\nStatus:       Pending\nEvents:\n  Warning  FailedScheduling  0/3 nodes are available\n")
+	h.provider.Respond("web-0 cannot be scheduled: no node has enough memory.")
+	res := h.run("explain", "-")
+	if res.err != nil {
+		t.Fatalf("explain failed: %v\n%s", res.err, res.stderr)
+	}
+	if requests := h.provider.Requests(); len(requests) != 1 || !strings.Contains(requests[0].Prompt, "kubectl describe output") {
+		t.Errorf("expected the describe output to be explained, got %+v", requests)
+	}
+	if !strings.Contains(res.stdout, "no node has enough memory") {
+		t.Errorf("expected the explanation in the output:\n%s", res.stdout)
+	}
+
+	pipe("2024-05-01T10:00:00Z INFO starting\n2024-05-01T10:00:01Z ERROR connection refused\n")
+	h.provider.Respond(logAnalysis)
+	res = h.run("analyze", "-f", "-")
+	if res.err != nil {
+		t.Fatalf("analyze failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "LOG SUMMARY") {
+		t.Errorf("expected piped logs to be analyzed as logs:\n%s", res.stdout)
+	}
+
+	pipe("2024-05-01T10:00:00Z INFO starting\n2024-05-01T10:00:01Z ERROR connection refused\n")
+	if res := h.run("analyze", "-f", "-", "-o", "json"); res.code != exitUsage || !strings.Contains(res.stderr, "stdin holds logs") {
+		t.Errorf("expected -o json to need a manifest, got %d:\n%s", res.code, res.stderr)
+	}
+}
+
+func TestAnalyzeValues(t *testing.T) {
+	// A helm stand-in that renders a Deployment with the replicas of the values file, 1 by default
+	bin := t.TempDir()
+	script := `#!/bin/sh
+replicas=1
+while [ $# -gt 0 ]; do
+	if [ "$1" = "--values" ]; then replicas=$(sed -n 's/^replicas: //p' "$2"); fi
+	shift
+done
+printf 'apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: %s\n' "$replicas"
+`
+	if err := os.WriteFile(filepath.Join(bin, "helm"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Release web, as Helm stores it: base64 of gzipped JSON
+	var record bytes.Buffer
+	writer := gzip.NewWriter(&record)
+	writer.Write([]byte(`{"name": "web", "namespace": "shop", "version": 3, "config": {"replicas": 3},
+		"info": {"status": "deployed"}, "chart": {"metadata": {"name": "web", "version": "1.2.0"}}}`))
+	writer.Close()
+	h := newHarness(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.web.v3", Namespace: "shop", Labels: map[string]string{"owner": "helm", "name": "web", "version": "3"}},
+		Type:       "helm.sh/release.v1",
+		Data:       map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(record.Bytes()))},
+	})
+
+	values := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(values, []byte("# Fewer replicas\nreplicas: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h.provider.Respond("Scaling web from 3 to 1 replica removes its redundancy.")
+	res := h.run("analyze-values", values, "--chart", "./chart", "--release", "web", "-n", "shop")
+	if res.err != nil {
+		t.Fatalf("analyze-values failed: %v\n%s", res.err, res.stderr)
+	}
+	for _, want := range []string{"-replicas: 3\n+replicas: 1", "Deployment/web changed", "-  replicas: 3\n+  replicas: 1", "removes its redundancy"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("expected %q in the output:\n%s", want, res.stdout)
+		}
+	}
+	if requests := h.provider.Requests(); len(requests) != 1 || !strings.Contains(requests[0].Prompt, "release web in namespace shop (revision 3, chart web 1.2.0)") {
+		t.Errorf("expected the release in the prompt, got %+v", requests)
+	}
+
+	if res := h.run("analyze-values", values, "--chart", "./chart", "--release", "api", "-n", "shop"); res.err == nil || !strings.Contains(res.stderr, "release api not found") {
+		t.Errorf("expected a missing release to be reported, got %v:\n%s", res.err, res.stderr)
+	}
+}
+
+func TestAnalyzeLogsAcrossNamespaces(t *testing.T) {
+	tenantPod := func(namespace, name, app string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: app, Image: "nginx"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	h := newHarness(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-c", Annotations: map[string]string{"kube-ai.io/ignore": "true"}}},
+		tenantPod("tenant-a", "web-1", "web"),
+		tenantPod("tenant-a", "db-1", "db"),
+		tenantPod("tenant-b", "web-1", "web"),
+		tenantPod("tenant-c", "web-1", "web"),
+	)
+	h.provider.Respond(logAnalysis).Respond(logAnalysis)
+
+	res := h.run("analyze-logs", "-l", "app=web", "-A", "--show-logs=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "pods matching app=web in all namespaces") {
+		t.Errorf("the logs are not collected across namespaces:\n%s", res.stdout)
+	}
+	if !strings.Contains(res.stdout, "=== Namespaces ===\n- tenant-a: 1 (0 errors, 0 warnings)\n- tenant-b: 1") {
+		t.Errorf("the entries are not attributed to their namespace:\n%s", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "skipping pod tenant-c/web-1") {
+		t.Errorf("the pod in the ignored namespace is not skipped:\n%s", res.stderr)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "## Namespaces") || !strings.Contains(requests[0].Prompt, "- tenant-b: 1 entries") || !strings.Contains(requests[0].Prompt, "[tenant-a/web-1]") {
+		t.Fatalf("the prompt does not attribute the logs to their namespace: %+v", requests)
+	}
+	if strings.Contains(requests[0].Prompt, "tenant-c") || strings.Contains(requests[0].Prompt, "db-1") {
+		t.Errorf("the prompt contains logs of pods that were not selected:\n%s", requests[0].Prompt)
+	}
+
+	res = h.run("analyze-logs", "pod", "web-1", "--namespaces", "tenant-a,tenant-b", "--show-logs=false")
+	if res.err != nil {
+		t.Fatalf("analyze-logs failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "pod/web-1 in namespaces tenant-a, tenant-b") || !strings.Contains(res.stdout, "- tenant-b: 1") {
+		t.Errorf("the named pod is not collected from both namespaces:\n%s", res.stdout)
+	}
+
+	res = h.run("analyze-logs", "-l", "app=web", "-A", "--live")
+	if res.code != exitUsage {
+		t.Errorf("expected a usage error for --live across namespaces, got %d", res.code)
+	}
+	res = h.run("analyze-logs", "pod", "web-1", "-A", "--namespaces", "tenant-a")
+	if res.code != exitUsage {
+		t.Errorf("expected a usage error for --namespaces with --all-namespaces, got %d", res.code)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	partOf := map[string]string{"app.kubernetes.io/part-of": "checkout"}
+	deployment := func(name string, labels, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels, Annotations: annotations},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: name + ":1.0"}}},
+			}},
+		}
+	}
+	statefulSet := &appsv1.StatefulSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "orders-db", Namespace: "default", Labels: partOf},
+		Spec:       appsv1.StatefulSetSpec{ServiceName: "orders-db", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "orders-db"}}},
+	}
+	objects := []runtime.Object{
+		deployment("cart", partOf, nil),
+		deployment("payments", partOf, map[string]string{"kube-ai.io/ignore": "true"}),
+		deployment("search", nil, nil),
+		statefulSet,
+	}
+	h := newHarness(t, objects...)
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.addObject(&unstructured.Unstructured{Object: content})
+	}
+
+	h.provider.Respond("The checkout deployments set no resource requests.")
+	res := h.run("analyze", "deployments", "-l", "app.kubernetes.io/part-of=checkout")
+	if res.err != nil {
+		t.Fatalf("analyze failed: %v\n%s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stderr, "skipping default/payments") {
+		t.Errorf("the opted-out deployment is not skipped:\n%s", res.stderr)
+	}
+	requests := h.provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "cart:1.0") {
+		t.Fatalf("the prompt does not contain the selected deployment: %+v", requests)
+	}
+	if strings.Contains(requests[0].Prompt, "payments:1.0") || strings.Contains(requests[0].Prompt, "search:1.0") {
+		t.Errorf("the prompt contains deployments that were not selected:\n%s", requests[0].Prompt)
+	}
+
+	res = h.run("analyze", "deployments", "cart", "-l", "app=web")
+	if res.code != exitUsage {
+		t.Errorf("expected a usage error for a name with a selector, got %d", res.code)
+	}
+	res = h.run("analyze", "deployments", "-l", "app=none")
+	if res.code != exitKubernetes || !strings.Contains(res.stderr, "no deployments found matching app=none") {
+		t.Errorf("expected a Kubernetes error when nothing matches, got %d: %s", res.code, res.stderr)
+	}
+
+	h.provider.Respond("Create the orders-db service.")
+	res = h.run("diagnose", "statefulset", "-l", "app.kubernetes.io/part-of=checkout", "-o", "json")
+	if res.err != nil {
+		t.Fatalf("diagnose statefulset failed: %v\n%s", res.err, res.stderr)
+	}
+	var reports []statefulReport
+	if err := json.Unmarshal([]byte(res.stdout), &reports); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if len(reports) != 1 || reports[0].Name != "orders-db" || reports[0].Analysis != "Create the orders-db service." {
+		t.Errorf("unexpected reports: %+v", reports)
+	}
+}
+
+func TestFeaturePermissionsCoverCommands(t *testing.T) {
+	// Commands that read nothing from the cluster, or only the discovery and OpenAPI documents
+	// every user can read. onboard checks the access of analyze-logs.
+	offline := map[string]bool{
+		"analysis": true, "cache": true, "chat": true, "completion": true, "config": true,
+		"explain-field": true, "help": true, "history": true, "list-models": true,
+		"list-providers": true, "models": true, "onboard": true, "optimize": true, "persona": true,
+		"plugins": true, "profile": true, "prompts": true, "rbac": true, "redact-test": true,
+		"set-api-key": true, "set-language": true, "set-model": true, "set-provider": true,
+		"version": true,
+	}
+
+	root := createRootCommand(&config.Config{}, &ai.Service{})
+	for _, cmd := range root.Commands() {
+		if offline[cmd.Name()] {
+			continue
+		}
+		if _, ok := k8s.FeaturePermissions[cmd.Name()]; !ok {
+			t.Errorf("%s reads the cluster but has no FeaturePermissions entry", cmd.Name())
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/i18n"
+)
+
+// createConfigCmd creates the config command
+func createConfigCmd(cfg *config.Config) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "View and change the kube-ai configuration",
+		Long: `View and change the settings in the kube-ai configuration file.
+
+The file is given with --config, else $KUBE_AI_CONFIG, else the first of
+~/.kube-ai/config.yaml, config.yml and config.json that exists. Files ending in
+.yaml or .yml are YAML, others JSON. The file is only written by commands that
+edit the configuration, such as config set or set-provider --save. With
+KUBE_AI_STATELESS=true no file is read or written at all.
+
+Settings are resolved in this order, the first one set winning:
+  1. Command-line flags, such as --language or --profile
+  2. Environment variables, such as AI_PROVIDER or OPENAI_API_KEY
+  3. The configuration file
+  4. Defaults`,
+	}
+
+	// View the effective configuration
+	var outputFormat string
+	var showSecrets bool
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the effective configuration and where each setting comes from",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shown := cfg.Masked()
+			if showSecrets {
+				if err := cfg.Unlock(); err != nil {
+					return err
+				}
+				shown = cfg
+			}
+
+			switch outputFormat {
+			case "text":
+				displaySettings(cfg, showSecrets)
+			case "yaml":
+				data, err := yaml.Marshal(shown)
+				if err != nil {
+					return fmt.Errorf("error encoding configuration: %w", err)
+				}
+				fmt.Print(string(data))
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(shown); err != nil {
+					return fmt.Errorf("error encoding configuration: %w", err)
+				}
+			default:
+				return usageErrorf("unsupported output format %q, use text, yaml or json", outputFormat)
+			}
+			return nil
+		},
+	}
+	viewCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text, yaml or json")
+	viewCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show API keys instead of masking them")
+
+	// Set a value in the configuration file
+	setCmd := &cobra.Command{
+		Use:   "set [key] [value]",
+		Short: "Set a value in the configuration file",
+		Long: fmt.Sprintf(`Set a value in the configuration file.
+
+Keys: %s`, strings.Join(config.SettingKeys(), ", ")),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := validateSetting(cfg, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			warning, err := cfg.Set(args[0], value)
+			if err != nil {
+				return fmt.Errorf("error saving configuration: %w", err)
+			}
+			fmt.Printf("Set %s in %s\n", args[0], cfg.Path())
+			if warning != "" {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+			return nil
+		},
+	}
+
+	// Reset a value in the configuration file
+	unsetCmd := &cobra.Command{
+		Use:   "unset [key]",
+		Short: "Reset a value in the configuration file to its default",
+		Long: fmt.Sprintf(`Reset a value in the configuration file to its default.
+
+Keys: %s`, strings.Join(config.SettingKeys(), ", ")),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			warning, err := cfg.Unset(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Unset %s in %s\n", args[0], cfg.Path())
+			if warning != "" {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+			return nil
+		},
+	}
+
+	// Encrypt the API keys in the configuration file
+	var method string
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the API keys in the configuration file",
+		Long: `Encrypt the API keys in the configuration file with AES-256-GCM, so that they are
+never stored in cleartext. The key is derived from a passphrase, typed at the
+terminal or taken from KUBE_AI_CONFIG_PASSPHRASE, or with --method key read from
+KUBE_AI_CONFIG_KEY (32 bytes, base64-encoded) or the file KUBE_AI_CONFIG_KEY_FILE
+names, such as a mounted Kubernetes Secret or a KMS-backed secret store volume.
+
+Commands ask for the passphrase, or read the key, the first time they need an
+API key. Running encrypt on an encrypted configuration changes the passphrase or
+key.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.EnableEncryption(method); err != nil {
+				return err
+			}
+			fmt.Printf("API keys in %s are now encrypted\n", cfg.Path())
+			return nil
+		},
+	}
+	encryptCmd.Flags().StringVar(&method, "method", config.EncryptionPassphrase, "Where the encryption key comes from: passphrase or key")
+
+	// Store the API keys in cleartext again
+	decryptCmd := &cobra.Command{
+		Use:   "decrypt",
+		Short: "Store the API keys in the configuration file in cleartext again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.DisableEncryption(); err != nil {
+				return err
+			}
+			fmt.Printf("API keys in %s are now stored in cleartext\n", cfg.Path())
+			return nil
+		},
+	}
+
+	// Bind a persona or prompt templates to a command
+	var bindPersona string
+	var bindPrompts []string
+	bindCmd := &cobra.Command{
+		Use:   "bind [command]",
+		Short: "Bind a persona or prompt templates to a command",
+		Long: `Make a command use its own persona or prompt templates instead of the active
+persona and the templates of the same name. A binding also applies to the
+command's subcommands unless they have their own; it takes precedence over the
+persona of a configuration profile.
+
+--prompt replaces a built-in template with a template from the prompt template
+directory (~/.kube-ai/prompts), given as built-in=replacement.
+
+Examples:
+  kubectl ai config bind analyze-logs --persona sre-troubleshooter
+  kubectl ai config bind generate --persona manifest-author
+  kubectl ai config bind "generate policy" --prompt generate-policy=generate-policy-strict`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command, err := resolveCommand(cmd.Root(), args[0])
+			if err != nil {
+				return err
+			}
+			binding := config.CommandBinding{Persona: bindPersona}
+			if binding.Prompts, err = parsePromptBindings(bindPrompts); err != nil {
+				return err
+			}
+
+			if err := cfg.BindCommand(command, binding); err != nil {
+				return err
+			}
+			fmt.Printf("Bound %s%s\n", command, describeBinding(binding))
+			return nil
+		},
+	}
+	bindCmd.Flags().StringVar(&bindPersona, "persona", "", "Persona the command uses")
+	bindCmd.Flags().StringArrayVar(&bindPrompts, "prompt", nil, "Template to use in place of a built-in one, as built-in=replacement (repeatable)")
+
+	// Remove a command binding
+	unbindCmd := &cobra.Command{
+		Use:   "unbind [command]",
+		Short: "Remove the persona and prompt template binding of a command",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.UnbindCommand(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed the binding of %s\n", args[0])
+			return nil
+		},
+	}
+
+	// List command bindings
+	bindingsCmd := &cobra.Command{
+		Use:   "bindings",
+		Short: "List the persona and prompt template bindings of commands",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			commands := cfg.BoundCommands()
+			if len(commands) == 0 {
+				fmt.Printf("No command bindings; every command uses the active persona (%s)\n", cfg.ActivePersona)
+				return nil
+			}
+			for _, command := range commands {
+				fmt.Printf("%s%s\n", command, describeBinding(cfg.Commands[command]))
+			}
+			return nil
+		},
+	}
+
+	configCmd.AddCommand(viewCmd)
+	configCmd.AddCommand(setCmd)
+	configCmd.AddCommand(unsetCmd)
+	configCmd.AddCommand(encryptCmd)
+	configCmd.AddCommand(decryptCmd)
+	configCmd.AddCommand(bindCmd)
+	configCmd.AddCommand(unbindCmd)
+	configCmd.AddCommand(bindingsCmd)
+
+	return configCmd
+}
+
+// validateSetting checks a value for a setting whose values are restricted, returning it in
+// its canonical form
+func validateSetting(cfg *config.Config, key, value string) (string, error) {
+	switch strings.ToLower(key) {
+	case "aiprovider":
+		value = strings.ToLower(value)
+		if !validProvider(value) {
+			return "", fmt.Errorf("unknown provider %q (expected one of: %s)", value, strings.Join(providerNames(), ", "))
+		}
+	case "activepersona":
+		if _, ok := cfg.ListPersonas()[value]; !ok {
+			return "", fmt.Errorf("persona '%s' not found", value)
+		}
+	case "language":
+		value = i18n.Normalize(value)
+		if i18n.IsEnglish(value) {
+			value = ""
+		}
+	}
+	return value, nil
+}
+
+// displaySettings prints the settings with their values and sources
+func displaySettings(cfg *config.Config, showSecrets bool) {
+	path := cfg.Path()
+	if cfg.Stateless() {
+		path = fmt.Sprintf("none (stateless mode, %s is set)", config.StatelessEnv)
+	} else if _, err := os.Stat(path); os.IsNotExist(err) {
+		path += " (not created yet)"
+	}
+	fmt.Printf("Configuration file: %s\n", path)
+	if cfg.Encrypted() {
+		fmt.Printf("API keys: encrypted (%s)\n", cfg.Encryption.Method)
+	}
+	fmt.Println()
+
+	settings := cfg.Settings()
+	keyWidth := len("KEY")
+	for _, setting := range settings {
+		keyWidth = max(keyWidth, len(setting.Key))
+	}
+
+	fmt.Printf("%-*s %-40s %s\n", keyWidth, "KEY", "VALUE", "SOURCE")
+	for _, setting := range settings {
+		value := setting.Value
+		if setting.Secret && value != "" && !showSecrets {
+			value = config.MaskedValue
+		}
+		if setting.Locked {
+			value = "(encrypted)"
+		}
+		if value == "" {
+			value = "-"
+		}
+		source := setting.Source
+		if source == config.SourceEnv {
+			source = fmt.Sprintf("env (%s)", setting.Env)
+		}
+		fmt.Printf("%-*s %-40s %s\n", keyWidth, setting.Key, value, source)
+	}
+
+	fmt.Println("\nPrecedence: flags > environment variables > configuration file > defaults")
+}
+
+// commandKey returns a command's path without the root command, such as "generate policy"
+func commandKey(cmd *cobra.Command) string {
+	return strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+}
+
+// resolveCommand checks that a command path names a kube-ai command and returns its canonical
+// path, resolving aliases
+func resolveCommand(root *cobra.Command, command string) (string, error) {
+	found, rest, err := root.Find(strings.Fields(command))
+	if err != nil || found == root || len(rest) > 0 {
+		return "", fmt.Errorf("unknown command %q", command)
+	}
+	return commandKey(found), nil
+}
+
+// parsePromptBindings parses built-in=replacement template bindings, checking that both
+// templates exist
+func parsePromptBindings(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	promptDir, _ := prompts.DefaultDir()
+	renderer := prompts.NewRenderer(promptDir)
+
+	bindings := make(map[string]string, len(values))
+	for _, value := range values {
+		name, bound, ok := strings.Cut(value, "=")
+		if !ok || name == "" || bound == "" {
+			return nil, fmt.Errorf("invalid prompt binding %q, expected built-in=replacement", value)
+		}
+		if _, err := prompts.Default(name); err != nil {
+			return nil, err
+		}
+		if _, _, err := renderer.Source(bound); err != nil {
+			return nil, fmt.Errorf("replacement template %s: %w (create %s)", bound, err, renderer.Path(bound))
+		}
+		bindings[name] = bound
+	}
+	return bindings, nil
+}
+
+// describeBinding summarizes a command binding
+func describeBinding(binding config.CommandBinding) string {
+	var parts []string
+	if binding.Persona != "" {
+		parts = append(parts, "persona "+binding.Persona)
+	}
+	names := make([]string, 0, len(binding.Prompts))
+	for name := range binding.Prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("template %s in place of %s", binding.Prompts[name], name))
+	}
+	return ": " + strings.Join(parts, ", ")
+}
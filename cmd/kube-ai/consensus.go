@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// displayConsensus outputs what the models of a consensus analysis agree and disagree on
+func displayConsensus(result *analyzers.ConsensusResult) {
+	resetColor := color(ansiReset)
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("MODEL CONSENSUS"))
+	for _, model := range result.Models {
+		if model.Error != "" {
+			fmt.Printf("- %s: %sfailed: %s%s\n", model.Name, color(ansiRed), model.Error, resetColor)
+			continue
+		}
+		fmt.Printf("- %s: %s%s%s\n", model.Name, severityColor(model.Analysis.Severity), model.Analysis.Severity, resetColor)
+	}
+
+	if len(result.Agreements) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Agreements"))
+		for i, agreement := range result.Agreements {
+			fmt.Printf("%d. %s\n", i+1, agreement)
+		}
+	}
+
+	if len(result.Disagreements) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Disagreements"))
+		for i, disagreement := range result.Disagreements {
+			fmt.Printf("%d. %s\n", i+1, disagreement.Topic)
+			for _, position := range disagreement.Positions {
+				fmt.Printf("   - %s: %s\n", position.Model, position.Position)
+			}
+		}
+	}
+}
+
+// displayConsensusJSON outputs a consensus analysis as JSON: the merged analysis in place of a
+// single model's, with the comparison and each model's analysis under consensus
+func displayConsensusJSON(summary logs.LogSummary, result *analyzers.ConsensusResult, baseline *logs.BaselineDiff, trend *logs.LogTrend, lifecycle *k8s.WorkloadLifecycle, usage []k8s.ContainerUsage, skipped []k8s.SkippedSource) error {
+	comparison := *result
+	comparison.Analysis = nil
+
+	output := struct {
+		Summary   logs.LogSummary             `json:"summary"`
+		Analysis  analyzers.LogAnalysisResult `json:"analysis"`
+		Consensus analyzers.ConsensusResult   `json:"consensus"`
+		Baseline  *logs.BaselineDiff          `json:"baseline,omitempty"`
+		Trend     *logs.LogTrend              `json:"trend,omitempty"`
+		Lifecycle *k8s.WorkloadLifecycle      `json:"lifecycle,omitempty"`
+		Usage     []k8s.ContainerUsage        `json:"usage,omitempty"`
+		Skipped   []k8s.SkippedSource         `json:"skippedSources,omitempty"`
+	}{
+		Summary:   summary,
+		Analysis:  *result.Analysis,
+		Consensus: comparison,
+		Baseline:  baseline,
+		Trend:     trend,
+		Lifecycle: lifecycle,
+		Usage:     usage,
+		Skipped:   skipped,
+	}
+
+	jsonData, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON output: %w", err)
+	}
+	fmt.Println(string(jsonData))
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// maxSchemaLines limits the schema outline of each custom resource in a prompt
+const maxSchemaLines = 200
+
+// crdDiscoveryTimeout bounds looking up CRDs, so commands that do not need a cluster stay quick without one
+const crdDiscoveryTimeout = 5 * time.Second
+
+// loadCustomResources looks up the CRDs installed in the cluster. It returns the custom resources
+// named by references or mentioned in text, described for prompts, and the schemas of every CRD
+// for validation. A cluster that cannot be reached is only an error when references are given;
+// otherwise both results are empty.
+func loadCustomResources(cmd *cobra.Command, text string, references []string) ([]prompts.CustomResource, manifest.Schemas, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), crdDiscoveryTimeout)
+	defer cancel()
+
+	client, err := k8s.NewClientFromFlags(cmd)
+	var definitions []k8s.CustomResourceDefinition
+	if err == nil {
+		definitions, err = client.ListCustomResourceDefinitions(ctx)
+	}
+	if err != nil {
+		if len(references) > 0 {
+			return nil, nil, fmt.Errorf("error looking up custom resource definitions: %w", err)
+		}
+		return nil, nil, nil
+	}
+
+	schemas := make(manifest.Schemas)
+	for _, definition := range definitions {
+		for _, version := range definition.ServedVersions {
+			gvk := definition.GroupVersionKind()
+			gvk.Version = version
+			// Only the selected version's schema is known; other versions are accepted unchecked
+			if version == definition.Version {
+				schemas[gvk] = definition.Schema
+			} else {
+				schemas[gvk] = nil
+			}
+		}
+	}
+
+	selected := k8s.MentionedCustomResourceDefinitions(definitions, text)
+	for _, reference := range references {
+		definition := k8s.FindCustomResourceDefinition(definitions, reference)
+		if definition == nil {
+			return nil, nil, fmt.Errorf("no custom resource definition %q is installed in the cluster", reference)
+		}
+		if !containsDefinition(selected, definition.Name) {
+			selected = append(selected, *definition)
+		}
+	}
+
+	customResources := make([]prompts.CustomResource, 0, len(selected))
+	for _, definition := range selected {
+		customResource := prompts.CustomResource{Kind: definition.Kind, APIVersion: definition.APIVersion()}
+		if definition.Schema != nil {
+			customResource.Schema = manifest.SchemaOutline(definition.Schema, maxSchemaLines)
+		}
+		customResources = append(customResources, customResource)
+	}
+	return customResources, schemas, nil
+}
+
+// containsDefinition reports whether definitions contains the CRD with the given name
+func containsDefinition(definitions []k8s.CustomResourceDefinition, name string) bool {
+	for _, definition := range definitions {
+		if definition.Name == name {
+			return true
+		}
+	}
+	return false
+}
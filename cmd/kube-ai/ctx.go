@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/progress"
+)
+
+// createCtxCmd creates the ctx command
+func createCtxCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	var question string
+	var clearSelection bool
+	var shell bool
+	var save bool
+	var yes bool
+	var noProbe bool
+	var timeout time.Duration
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "ctx [context]",
+		Short: "List and switch the kubeconfig context of kube-ai commands",
+		Long: `Without arguments, list the kubeconfig contexts with their cluster, whether the
+cluster is reachable, its Kubernetes version and the regions of its nodes. The
+context kube-ai commands use is marked with *.
+
+With an argument, select the context it names. The argument can be part of
+the name or its initials, such as pue for prod-us-east; when several contexts
+match you are asked to pick one. With --ask, the AI picks the context a question
+describes from the contexts' names, servers and cluster metadata, for example
+--ask "which context points at the prod us-east cluster?".
+
+The selection is printed as an export of KUBE_AI_CONTEXT for the current shell
+only: eval "$(kubectl ai ctx prod)". With --save, it is stored instead and
+applies to all later kube-ai commands that are not given --context; a context
+picked with --ask is confirmed first unless --yes is given. Neither changes the
+kubeconfig's current-context used by kubectl.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if (save || clearSelection) && cfg.Stateless() {
+				return usageErrorf("--save and --clear store the selection, which %s disables; use the printed export instead", config.StatelessEnv)
+			}
+			if save && question != "" && !yes && !progress.IsTerminal(os.Stdin) {
+				return usageErrorf("--save asks to confirm the context the AI picks on a terminal; add --yes to store it without asking")
+			}
+			if clearSelection {
+				if err := k8s.SetSessionContext(""); err != nil {
+					return err
+				}
+				fmt.Println("kube-ai now uses the kubeconfig's current-context")
+				return nil
+			}
+
+			config, err := k8s.GetClientConfigFromFlags(cmd)
+			if err != nil {
+				return fmt.Errorf("error reading flags: %w", err)
+			}
+			ctx := context.Background()
+			probe := !noProbe && (len(args) == 0 || question != "")
+			if probe && outputFormat == "text" {
+				fmt.Fprintln(os.Stderr, "Checking clusters...")
+			}
+			contexts, err := k8s.DescribeContexts(ctx, config, probe, timeout)
+			if err != nil {
+				return err
+			}
+			if len(contexts) == 0 {
+				return fmt.Errorf("no contexts found in the kubeconfig")
+			}
+
+			var selected string
+			switch {
+			case question != "":
+				var reason string
+				selected, reason, err = analyzers.ChooseContext(ctx, aiService, question, contexts)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "%s: %s\n", selected, reason)
+			case len(args) == 1:
+				selected, err = selectContext(contexts, args[0])
+				if err != nil {
+					return err
+				}
+			default:
+				if outputFormat == "json" {
+					encoder := json.NewEncoder(os.Stdout)
+					encoder.SetIndent("", "  ")
+					if err := encoder.Encode(contexts); err != nil {
+						return fmt.Errorf("error encoding contexts: %w", err)
+					}
+					return nil
+				}
+				displayContexts(contexts, probe)
+				return nil
+			}
+
+			if !save {
+				fmt.Printf("export %s=%q\n", k8s.SessionContextEnv, selected)
+				if progress.IsTerminal(os.Stdout) {
+					fmt.Fprintf(os.Stderr, "Run eval \"$(kubectl ai ctx %s)\" to use it in this shell, or add --save to store it\n", selected)
+				}
+				return nil
+			}
+			// The AI may misread the question, so its pick is only stored once confirmed
+			if question != "" && !yes && !confirmOnStdin(fmt.Sprintf("Use context %q for all later kube-ai commands?", selected)) {
+				fmt.Println("Not saved")
+				return nil
+			}
+			if err := k8s.SetSessionContext(selected); err != nil {
+				return err
+			}
+			fmt.Printf("kube-ai now uses context %q\n", selected)
+			if env := os.Getenv(k8s.SessionContextEnv); env != "" && env != selected {
+				fmt.Fprintf(os.Stderr, "Warning: %s=%s overrides the selection in this shell\n", k8s.SessionContextEnv, env)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&question, "ask", "", "Let the AI pick the context a question describes, e.g. \"the prod us-east cluster\"")
+	cmd.Flags().BoolVar(&clearSelection, "clear", false, "Go back to the kubeconfig's current-context")
+	cmd.Flags().BoolVar(&save, "save", false, "Store the selection for all later kube-ai commands instead of printing an export of KUBE_AI_CONTEXT")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Store the context picked with --ask --save without asking for confirmation")
+	cmd.Flags().BoolVar(&shell, "shell", false, "Print an export of KUBE_AI_CONTEXT for eval")
+	_ = cmd.Flags().MarkDeprecated("shell", "printing an export is the default; use --save to store the selection")
+	cmd.Flags().BoolVar(&noProbe, "no-probe", false, "List contexts without contacting their clusters")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "Time limit for contacting each cluster")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format of the list: text or json")
+
+	return cmd
+}
+
+// selectContext resolves a context query, asking which context was meant when several match
+// and stdin is a terminal
+func selectContext(contexts []k8s.ContextInfo, query string) (string, error) {
+	names := make([]string, 0, len(contexts))
+	for _, info := range contexts {
+		names = append(names, info.Name)
+	}
+
+	matches := k8s.MatchContexts(names, query)
+	switch {
+	case len(matches) == 0:
+		return "", fmt.Errorf("no context matches %q (contexts: %s)", query, strings.Join(names, ", "))
+	case len(matches) == 1:
+		return matches[0], nil
+	case !term.IsTerminal(int(os.Stdin.Fd())):
+		return "", fmt.Errorf("%q matches several contexts: %s", query, strings.Join(matches, ", "))
+	}
+
+	for i, name := range matches {
+		fmt.Printf("%2d) %s\n", i+1, name)
+	}
+	fmt.Print("Context number: ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return "", fmt.Errorf("no context selected")
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || choice < 1 || choice > len(matches) {
+		return "", fmt.Errorf("invalid selection %q", scanner.Text())
+	}
+	return matches[choice-1], nil
+}
+
+// displayContexts prints the contexts with their cluster and, when probed, its status
+func displayContexts(contexts []k8s.ContextInfo, probed bool) {
+	if !probed {
+		fmt.Printf("  %-30s %-30s %-20s %s\n", "NAME", "CLUSTER", "NAMESPACE", "SERVER")
+		for _, info := range contexts {
+			fmt.Printf("%s %-30s %-30s %-20s %s\n", activeMarker(info), info.Name, info.Cluster, info.Namespace, info.Server)
+		}
+		return
+	}
+
+	fmt.Printf("  %-30s %-30s %-12s %-10s %s\n", "NAME", "CLUSTER", "STATUS", "VERSION", "REGION")
+	for _, info := range contexts {
+		status := "reachable"
+		if !info.Reachable {
+			status = "unreachable"
+		}
+		fmt.Printf("%s %-30s %-30s %-12s %-10s %s\n", activeMarker(info), info.Name, info.Cluster, status, info.Version, strings.Join(info.Regions, ","))
+	}
+
+	first := true
+	for _, info := range contexts {
+		if info.Error == "" {
+			continue
+		}
+		if first {
+			fmt.Println()
+			first = false
+		}
+		fmt.Printf("%s: %s\n", info.Name, info.Error)
+	}
+}
+
+// activeMarker marks the context kube-ai commands use
+func activeMarker(info k8s.ContextInfo) string {
+	if info.Active {
+		return "*"
+	}
+	return " "
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// CoreDNS log collection limits: pods read, lines read from each, and lines kept
+const (
+	maxDNSLogPods  = 2
+	dnsLogTail     = 500
+	maxDNSLogLines = 30
+)
+
+// dnsLogProblem matches CoreDNS log lines reporting errors, such as upstream timeouts, SERVFAIL
+// answers, forwarding loops and API server connection failures
+var dnsLogProblem = regexp.MustCompile(`\[(ERROR|WARNING|FATAL)\]|plugin/loop|i/o timeout|SERVFAIL`)
+
+// dnsReport is the JSON output of diagnose dns
+type dnsReport struct {
+	*k8s.DNSDiagnosis
+	Logs     []string `json:"logs"`
+	Analysis string   `json:"analysis"`
+}
+
+// createDiagnoseCmd creates the diagnose command, grouping cluster-wide diagnostics
+func createDiagnoseCmd(aiService *ai.Service) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "Diagnose cluster services",
+		Long: `Diagnose the services every workload of the cluster depends on, and workloads
+that need more context than a single resource, such as clustered data stores.`,
+	}
+
+	cmd.AddCommand(createDiagnoseDNSCmd(aiService))
+	cmd.AddCommand(createDiagnoseStatefulCmd(aiService))
+
+	return cmd
+}
+
+// createDiagnoseDNSCmd creates the diagnose dns command
+func createDiagnoseDNSCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+	var debugPod bool
+	var image string
+	var lookups []string
+	var symptoms []string
+
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "Diagnose name resolution in the cluster",
+		Long: `Check the health of the cluster DNS: the CoreDNS deployment and pods, the
+Corefile, the kube-dns service and its endpoints, and the errors CoreDNS logs.
+
+With --debug-pod, a short-lived pod is created in the namespace to resolve the
+--lookup names and print its resolv.conf, exactly as an application pod would.
+The pod is deleted afterwards. As it creates a pod, it requires --allow-writes.
+
+Pass the errors applications report with --symptom, such as "dial tcp: lookup
+db on 10.96.0.10:53: no such host", and the AI explains them from what was found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			if debugPod && client.IsReadOnly() {
+				return usageErrorf("--debug-pod creates a pod and requires --allow-writes")
+			}
+
+			ctx := context.Background()
+			diagnosis, err := client.GetDNSDiagnosis(ctx)
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+			report := dnsReport{DNSDiagnosis: diagnosis}
+			report.Logs = collectDNSLogs(ctx, logs.NewLogCollector(client.GetClientset()), diagnosis)
+			if pods, _, err := client.GetWorkloadPods(ctx, "deployment", "coredns", "kube-system"); err == nil {
+				diagnosis.Usage = collectResourceUsage(ctx, client, pods)
+				diagnosis.Findings = append(diagnosis.Findings, k8s.UsageFindings(diagnosis.Usage)...)
+			}
+
+			if debugPod {
+				progress := progressWriter(outputFormat == "json")
+				fmt.Fprintf(progress, "Running lookups from a debug pod in namespace %s...\n", client.GetNamespace())
+				diagnosis.Lookups, err = client.RunDNSLookups(ctx, client.GetNamespace(), image, lookups)
+				if err != nil {
+					return kubeErrorf("%w", err)
+				}
+			}
+
+			if outputFormat == "text" {
+				displayDNSDiagnosis(diagnosis, report.Logs)
+				progressf("\nDiagnosing...\n")
+			}
+			report.Analysis, err = analyzers.DiagnoseDNS(ctx, aiService, diagnosis, report.Logs, symptoms)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(report.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&debugPod, "debug-pod", false, "Run lookups from a short-lived debug pod (requires --allow-writes)")
+	cmd.Flags().StringVar(&image, "image", k8s.DefaultDNSDebugImage, "Image of the debug pod, which must provide sh and nslookup")
+	cmd.Flags().StringSliceVar(&lookups, "lookup", []string{"kubernetes.default", "kubernetes.default.svc.cluster.local", "example.com"}, "Names the debug pod resolves")
+	cmd.Flags().StringArrayVar(&symptoms, "symptom", nil, "An error message applications report, such as a failed lookup (repeatable)")
+
+	return cmd
+}
+
+// collectDNSLogs returns the error and warning lines of the recent logs of a few CoreDNS pods.
+// Pods whose logs cannot be read are skipped.
+func collectDNSLogs(ctx context.Context, collector *logs.LogCollector, diagnosis *k8s.DNSDiagnosis) []string {
+	lines := []string{}
+	tail := int64(dnsLogTail)
+	for i, pod := range diagnosis.Pods {
+		if i == maxDNSLogPods {
+			break
+		}
+		entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+			Namespace:    "kube-system",
+			ResourceName: pod.Name,
+			TailLines:    &tail,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the logs of pod %s: %v\n", pod.Name, err)
+			continue
+		}
+		for _, entry := range entries {
+			if dnsLogProblem.MatchString(entry.Content) {
+				lines = append(lines, pod.Name+": "+strings.TrimSpace(entry.Content))
+			}
+		}
+	}
+	if len(lines) > maxDNSLogLines {
+		lines = lines[len(lines)-maxDNSLogLines:]
+	}
+	return lines
+}
+
+// displayDNSDiagnosis prints the state of CoreDNS, its errors, the debug pod lookups and the
+// problems found
+func displayDNSDiagnosis(diagnosis *k8s.DNSDiagnosis, dnsLogs []string) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("CLUSTER DNS"))
+	if diagnosis.Deployment != "" {
+		fmt.Printf("%-14s %s, %d/%d ready\n", "Deployment:", diagnosis.Deployment, diagnosis.ReadyReplicas, diagnosis.Replicas)
+	}
+	for _, pod := range diagnosis.Pods {
+		status := "ready"
+		if !pod.Ready {
+			status = "not ready"
+		}
+		if pod.Reason != "" {
+			status += " (" + pod.Reason + ")"
+		}
+		fmt.Printf("%-14s %s on %s: %s, %d restarts\n", "Pod:", pod.Name, pod.Node, status, pod.Restarts)
+	}
+	if diagnosis.ServiceIP != "" {
+		fmt.Printf("%-14s %s, endpoints %s\n", "Service:", diagnosis.ServiceIP, orNone(diagnosis.Endpoints))
+	}
+	if diagnosis.NodeLocalDNS {
+		fmt.Printf("%-14s %s\n", "Node cache:", "NodeLocal DNSCache")
+	}
+
+	if len(dnsLogs) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Errors"))
+		for _, line := range dnsLogs {
+			fmt.Println(line)
+		}
+	}
+
+	if len(diagnosis.Lookups) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Lookups"))
+		for _, lookup := range diagnosis.Lookups {
+			if lookup.Failed {
+				fmt.Printf("%s%s: FAILED%s\n", severityColor("High"), lookup.Name, color(ansiReset))
+			} else {
+				fmt.Printf("%s:\n", lookup.Name)
+			}
+			for _, line := range strings.Split(lookup.Output, "\n") {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+
+	displayResourceUsage(diagnosis.Usage)
+
+	if len(diagnosis.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range diagnosis.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+}
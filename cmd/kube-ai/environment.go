@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/k8s"
+)
+
+// clusterEnvironmentTimeout bounds reading the environment of the target cluster for the cluster
+// context preamble, so prompts are not held up by an unreachable cluster
+const clusterEnvironmentTimeout = 10 * time.Second
+
+// loadClusterEnvironment reads the platform, network plugin, ingress classes and operators of
+// the target cluster, for the cluster context preamble of prompts
+func loadClusterEnvironment(cmd *cobra.Command) (*ai.ClusterEnvironment, error) {
+	client, err := k8s.NewClientFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterEnvironmentTimeout)
+	defer cancel()
+	env, err := client.GetClusterEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ai.ClusterEnvironment{
+		Platform:       env.Platform,
+		CNI:            env.CNI,
+		IngressClasses: env.IngressClasses,
+		Operators:      env.Operators,
+	}, nil
+}
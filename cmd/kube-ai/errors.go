@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"kube-ai/pkg/ai"
+)
+
+// Exit codes telling apart why a command failed, so scripts can retry an unreachable cluster or
+// provider but not a mistyped command
+const (
+	// Any other error
+	exitFailure = 1
+	// Invalid arguments or flags
+	exitUsage = 2
+	// The Kubernetes API could not be reached or refused the request
+	exitKubernetes = 3
+	// The AI provider could not be reached or refused the request
+	exitProvider = 4
+)
+
+// commandError is an error returned by a command with the exit code it maps to
+type commandError struct {
+	code int
+	err  error
+}
+
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// usageErrorf returns an error about invalid arguments or flags
+func usageErrorf(format string, args ...interface{}) error {
+	return &commandError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// kubeErrorf returns an error about reaching or querying the Kubernetes API
+func kubeErrorf(format string, args ...interface{}) error {
+	return &commandError{code: exitKubernetes, err: fmt.Errorf(format, args...)}
+}
+
+// commandStarted is set once cobra has parsed the command line and runs the command; errors
+// returned before, such as unknown commands or flags and wrong argument counts, are usage errors
+var commandStarted bool
+
+// exitCode returns the exit code for an error returned by a command. Errors from the Kubernetes
+// API and the AI provider are recognized even when a command does not mark them.
+func exitCode(err error) int {
+	var commandErr *commandError
+	var providerErr *ai.ProviderError
+	var status apierrors.APIStatus
+	switch {
+	case !commandStarted:
+		return exitUsage
+	case errors.As(err, &commandErr):
+		return commandErr.code
+	case errors.As(err, &providerErr):
+		return exitProvider
+	case errors.As(err, &status):
+		return exitKubernetes
+	}
+	return exitFailure
+}
+
+// renderError writes the error a command failed with, and for usage errors where to find the
+// usage, and returns the exit code. A command stopped by dry-run mode after showing its prompt
+// succeeded.
+func renderError(w io.Writer, cmd *cobra.Command, err error) int {
+	if errors.Is(err, ai.ErrDryRun) {
+		return 0
+	}
+
+	message := err.Error()
+	if rest, ok := strings.CutPrefix(message, "error "); ok {
+		fmt.Fprintf(w, "Error %s\n", rest)
+	} else {
+		fmt.Fprintf(w, "Error: %s\n", message)
+	}
+
+	code := exitCode(err)
+	if code == exitUsage && cmd != nil {
+		fmt.Fprintf(w, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return code
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// maxFieldLines limits the nested fields shown for a field
+const maxFieldLines = 150
+
+// createExplainFieldCmd creates the explain-field command
+func createExplainFieldCmd(aiService *ai.Service) *cobra.Command {
+	var apiVersion string
+
+	cmd := &cobra.Command{
+		Use:   "explain-field <resource.field.path>",
+		Short: "Explain a resource field using the cluster's schema",
+		Long: `Explain a resource field like kubectl explain, with a practical explanation,
+examples and common pitfalls.
+
+The field documentation comes from the OpenAPI schema the cluster publishes, so it
+matches the cluster's Kubernetes version and works for custom resources too.
+For example: kube-ai explain-field deployment.spec.strategy`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			field, err := client.FieldSchema(context.Background(), args[0], apiVersion)
+			if err != nil {
+				return err
+			}
+
+			documentation := formatFieldSchema(field)
+			fmt.Printf("\n====== %s ======\n", i18n.T("FIELD SCHEMA"))
+			fmt.Println(documentation)
+
+			name := field.Kind
+			if field.Path != "" {
+				name = field.Path
+			}
+			result, err := aiService.ExplainField(field.Kind, field.APIVersion, name, documentation)
+			if err != nil {
+				return fmt.Errorf("error explaining field: %w", err)
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("EXPLANATION"))
+			printMarkdown(result)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&apiVersion, "api-version", "", "API version of the resource, such as autoscaling/v2 (default: the preferred version)")
+
+	return cmd
+}
+
+// formatFieldSchema describes a field like kubectl explain: kind, version, type, description and
+// nested fields
+func formatFieldSchema(field *k8s.FieldSchema) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "KIND:        %s\n", field.Kind)
+	fmt.Fprintf(&sb, "VERSION:     %s\n", field.APIVersion)
+	if field.Path != "" {
+		fmt.Fprintf(&sb, "FIELD:       %s <%s>\n", field.Path, manifest.SchemaType(field.Schema))
+	}
+	if len(field.Schema.Enum) > 0 {
+		values := make([]string, len(field.Schema.Enum))
+		for i, value := range field.Schema.Enum {
+			values[i] = fmt.Sprint(value)
+		}
+		fmt.Fprintf(&sb, "ENUM:        %s\n", strings.Join(values, ", "))
+	}
+	if field.Schema.Default != nil {
+		fmt.Fprintf(&sb, "DEFAULT:     %v\n", field.Schema.Default)
+	}
+
+	if description := strings.TrimSpace(field.Schema.Description); description != "" {
+		sb.WriteString("\nDESCRIPTION:\n")
+		for _, line := range strings.Split(description, "\n") {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+
+	outline := manifest.FieldOutline(field.Schema, maxFieldLines)
+	if field.Path == "" {
+		outline = manifest.SchemaOutline(field.Schema, maxFieldLines)
+	}
+	if outline != "" {
+		sb.WriteString("\nFIELDS:\n")
+		for _, line := range strings.Split(outline, "\n") {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
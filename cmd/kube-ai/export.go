@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kube-ai/pkg/k8s/logs"
+	"kube-ai/pkg/report"
+)
+
+// exportWriters are the formats parsed logs can be exported in, by --export value
+var exportWriters = map[string]func(*os.File, report.Table) error{
+	"csv":     func(f *os.File, table report.Table) error { return report.WriteCSV(f, table) },
+	"parquet": func(f *os.File, table report.Table) error { return report.WriteParquet(f, table) },
+}
+
+// exportLogs writes parsed log entries and their summary to dir as one file per table, in the
+// csv or parquet format, and returns the paths written
+func exportLogs(dir, format string, entries []logs.LogEntry, summary logs.LogSummary) ([]string, error) {
+	write, ok := exportWriters[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format %q, use csv or parquet", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating export directory: %w", err)
+	}
+
+	tables := []struct {
+		name  string
+		table report.Table
+	}{
+		{"entries", logEntriesTable(entries)},
+		{"summary", logSummaryTable(summary)},
+		{"patterns", logPatternsTable(summary)},
+		{"hotspots", errorHotspotsTable(summary)},
+	}
+	var paths []string
+	for _, t := range tables {
+		path := filepath.Join(dir, t.name+"."+format)
+		file, err := os.Create(path)
+		if err != nil {
+			return paths, fmt.Errorf("error creating %s: %w", path, err)
+		}
+		err = write(file, t.table)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return paths, fmt.Errorf("error writing %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// logEntriesTable has one row per log entry, with its pattern fingerprint and structured fields
+// as a JSON object
+func logEntriesTable(entries []logs.LogEntry) report.Table {
+	table := report.Table{Columns: []report.Column{
+		{Name: "timestamp", Type: report.TimestampColumn},
+		{Name: "pod", Type: report.StringColumn},
+		{Name: "container", Type: report.StringColumn},
+		{Name: "level", Type: report.StringColumn},
+		{Name: "message", Type: report.StringColumn},
+		{Name: "pattern", Type: report.StringColumn},
+		{Name: "fields", Type: report.StringColumn},
+	}}
+	for _, entry := range entries {
+		fields := ""
+		if len(entry.Data) > 0 {
+			if data, err := json.Marshal(entry.Data); err == nil {
+				fields = string(data)
+			}
+		}
+		table.Append(entry.Timestamp, entry.PodName, entry.ContainerName, entry.LogLevel, entry.Content, logs.Fingerprint(entry), fields)
+	}
+	return table
+}
+
+// logSummaryTable has a single row with the totals and time range of the logs
+func logSummaryTable(summary logs.LogSummary) report.Table {
+	table := report.Table{Columns: []report.Column{
+		{Name: "start", Type: report.TimestampColumn},
+		{Name: "end", Type: report.TimestampColumn},
+		{Name: "duration_seconds", Type: report.Int64Column},
+		{Name: "total_entries", Type: report.Int64Column},
+		{Name: "error_count", Type: report.Int64Column},
+		{Name: "warning_count", Type: report.Int64Column},
+		{Name: "potential_issues", Type: report.StringColumn},
+	}}
+	table.Append(summary.TimeRange.Start, summary.TimeRange.End, int64(summary.TimeRange.Duration.Seconds()),
+		int64(summary.TotalEntries), int64(summary.ErrorCount), int64(summary.WarningCount), strings.Join(summary.PotentialIssues, "\n"))
+	return table
+}
+
+// logPatternsTable has one row per common error and warning pattern
+func logPatternsTable(summary logs.LogSummary) report.Table {
+	table := report.Table{Columns: []report.Column{
+		{Name: "kind", Type: report.StringColumn},
+		{Name: "pattern", Type: report.StringColumn},
+		{Name: "count", Type: report.Int64Column},
+		{Name: "example", Type: report.StringColumn},
+	}}
+	add := func(kind string, patterns []logs.LogPattern) {
+		for _, pattern := range patterns {
+			example := ""
+			if len(pattern.Examples) > 0 {
+				example = pattern.Examples[0].Content
+			}
+			table.Append(kind, pattern.Pattern, int64(pattern.Count), example)
+		}
+	}
+	add("error", summary.CommonErrors)
+	add("warning", summary.CommonWarnings)
+	return table
+}
+
+// errorHotspotsTable has one row per pod with errors
+func errorHotspotsTable(summary logs.LogSummary) report.Table {
+	table := report.Table{Columns: []report.Column{
+		{Name: "pod", Type: report.StringColumn},
+		{Name: "error_count", Type: report.Int64Column},
+	}}
+	for _, hotspot := range summary.ErrorHotspots {
+		table.Append(hotspot.ResourceName, int64(hotspot.ErrorCount))
+	}
+	return table
+}
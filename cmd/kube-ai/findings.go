@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/report"
+)
+
+// writeJUnitFindings writes manifest findings as a JUnit report with one failing test case per
+// finding, so CI systems can show them in their test report UIs. Inputs without findings are
+// reported as a single passing test case.
+func writeJUnitFindings(w io.Writer, source string, result *analyzers.ManifestAnalysisResult) error {
+	suite := report.JUnitSuite{Name: source}
+
+	for _, finding := range result.Findings {
+		className := "kube-ai." + finding.Category
+		if finding.Resource != "" {
+			className += "." + finding.Resource
+		}
+
+		var details strings.Builder
+		fmt.Fprintf(&details, "Severity: %s\n", finding.Severity)
+		fmt.Fprintf(&details, "Category: %s\n", finding.Category)
+		if finding.Resource != "" {
+			fmt.Fprintf(&details, "Resource: %s\n", finding.Resource)
+		}
+		if finding.Field != "" {
+			fmt.Fprintf(&details, "Field: %s\n", finding.Field)
+		}
+		if finding.Line > 0 {
+			fmt.Fprintf(&details, "Location: %s:%d\n", source, finding.Line)
+		}
+		if finding.Source != "" {
+			fmt.Fprintf(&details, "Reported by: %s\n", finding.Source)
+		}
+		fmt.Fprintf(&details, "\n%s\n", finding.Description)
+		if finding.Recommendation != "" {
+			fmt.Fprintf(&details, "\nRecommendation: %s\n", finding.Recommendation)
+		}
+
+		suite.Cases = append(suite.Cases, report.JUnitCase{
+			Name:      fmt.Sprintf("[%s] %s", finding.Severity, finding.Title),
+			ClassName: className,
+			Failure: &report.JUnitFailure{
+				Message: finding.Description,
+				Type:    finding.Severity,
+				Details: details.String(),
+			},
+		})
+	}
+
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, report.JUnitCase{
+			Name:      "No issues found",
+			ClassName: "kube-ai",
+		})
+	}
+
+	return report.WriteJUnit(w, "kube-ai analyze", suite)
+}
+
+// writeGitHubFindings writes manifest findings as GitHub Actions annotations on the lines of the
+// analyzed file, so they show inline on pull request diffs
+func writeGitHubFindings(w io.Writer, filename string, result *analyzers.ManifestAnalysisResult) error {
+	annotations := make([]report.GitHubAnnotation, 0, len(result.Findings))
+	for _, finding := range result.Findings {
+		message := finding.Description
+		if finding.Recommendation != "" {
+			message += "\n\nRecommendation: " + finding.Recommendation
+		}
+
+		annotations = append(annotations, report.GitHubAnnotation{
+			Level:   annotationLevel(finding.Severity),
+			File:    filepath.ToSlash(filepath.Clean(filename)),
+			Line:    finding.Line,
+			Title:   fmt.Sprintf("kube-ai: %s (%s)", finding.Title, finding.Severity),
+			Message: message,
+		})
+	}
+	return report.WriteGitHubAnnotations(w, annotations)
+}
+
+// annotationLevel maps a finding severity to a GitHub annotation level
+func annotationLevel(severity string) string {
+	switch severity {
+	case "Critical", "High":
+		return "error"
+	case "Low":
+		return "notice"
+	default:
+		return "warning"
+	}
+}
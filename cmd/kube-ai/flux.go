@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// maxFluxObjects limits the Flux objects troubleshot in one prompt
+const maxFluxObjects = 10
+
+// fluxReport is the JSON output of analyze-flux
+type fluxReport struct {
+	Objects  []*k8s.FluxObject `json:"objects"`
+	Analysis string            `json:"analysis,omitempty"`
+}
+
+// createAnalyzeFluxCmd creates the analyze-flux command
+func createAnalyzeFluxCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "analyze-flux [kustomization|helmrelease <name>]",
+		Short: "Troubleshoot Flux Kustomizations and HelmReleases that are not ready",
+		Long: `Troubleshoot a Flux Kustomization or HelmRelease, or without arguments every one
+in the namespace (or all namespaces with -A) that is not ready.
+
+The command collects each object's conditions, its source (GitRepository,
+OCIRepository, HelmRepository or HelmChart) with its conditions, its
+dependencies and the recent events of both. Common failure modes are detected
+from them: chart not found, values schema errors, dependency not ready, source
+or authentication failures, kustomize build, dry-run and health check failures.
+The AI explains each failure and what to change to fix it.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 && len(args) != 2 {
+				return usageErrorf("expected no arguments, or a kind and a name such as helmrelease podinfo")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			ctx := context.Background()
+			var report fluxReport
+			if len(args) == 2 {
+				if _, err := k8s.FluxGroupKind(args[0]); err != nil {
+					return usageErrorf("%w", err)
+				}
+				object, err := client.GetFluxObject(ctx, args[0], client.GetNamespace(), args[1])
+				if err != nil {
+					return kubeErrorf("%w", err)
+				}
+				report.Objects = []*k8s.FluxObject{object}
+			} else {
+				namespace := client.GetNamespace()
+				if client.IsAllNamespaces() {
+					namespace = ""
+				}
+				report.Objects, err = client.ListFailingFluxObjects(ctx, namespace)
+				if err != nil {
+					return kubeErrorf("error listing Flux objects: %w", err)
+				}
+			}
+
+			if len(report.Objects) == 0 {
+				if outputFormat == "json" {
+					report.Objects = []*k8s.FluxObject{}
+					return encodeFluxReport(report)
+				}
+				fmt.Println("All Kustomizations and HelmReleases are ready.")
+				return nil
+			}
+			if outputFormat == "text" {
+				displayFluxObjects(report.Objects)
+				progressf("\nTroubleshooting...\n")
+			}
+
+			objects := report.Objects
+			if len(objects) > maxFluxObjects {
+				fmt.Fprintf(os.Stderr, "Troubleshooting the first %d of %d objects\n", maxFluxObjects, len(objects))
+				objects = objects[:maxFluxObjects]
+			}
+			report.Analysis, err = analyzers.TroubleshootFlux(ctx, aiService, objects)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				return encodeFluxReport(report)
+			}
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(report.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// encodeFluxReport prints the JSON output of analyze-flux
+func encodeFluxReport(report fluxReport) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("error encoding report: %w", err)
+	}
+	return nil
+}
+
+// displayFluxObjects prints the readiness, source, dependencies and detected failure modes of
+// Flux objects
+func displayFluxObjects(objects []*k8s.FluxObject) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("FLUX OBJECTS"))
+	for i, object := range objects {
+		if i > 0 {
+			fmt.Println()
+		}
+		ready := object.Ready()
+		status := ready.Status
+		if ready.Reason != "" {
+			status += " (" + ready.Reason + ")"
+		}
+		if object.Suspended {
+			status += ", suspended"
+		}
+		fmt.Printf("%s %s/%s: Ready=%s\n", object.Kind, object.Namespace, object.Name, status)
+		if ready.Message != "" {
+			fmt.Printf("  %s\n", ready.Message)
+		}
+		if source := object.Source; source != nil {
+			sourceStatus := "not found"
+			if !source.Missing {
+				sourceStatus = "Ready=Unknown"
+				for _, condition := range source.Conditions {
+					if condition.Type == "Ready" {
+						sourceStatus = "Ready=" + condition.Status
+					}
+				}
+			}
+			fmt.Printf("  %-12s %s %s/%s: %s\n", "Source:", source.Kind, source.Namespace, source.Name, sourceStatus)
+		}
+		for _, dependency := range object.DependsOn {
+			fmt.Printf("  %-12s %s: Ready=%s\n", "Depends on:", dependency.Name, dependency.Ready)
+		}
+		for _, failure := range object.Failures {
+			fmt.Printf("  %s%s%s: %s\n", severityColor("High"), failure.Mode, color(ansiReset), failure.Hint)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// generateOptions are the output settings of the generate command
+type generateOptions struct {
+	// File or directory to write the manifest to, and whether to copy it to the clipboard
+	out manifestOutput
+	// Whether to generate a complete application stack
+	stack bool
+	// Name of the stack
+	name string
+	// Layout of the stack directory
+	layout string
+	// CRDs whose schemas to generate from, in addition to those mentioned in the description
+	crds []string
+	// Whether to apply the manifest once its changes are previewed and confirmed
+	apply applyOptions
+
+	// Custom resources for the prompt and schemas for validation, from the cluster's CRDs
+	customResources []prompts.CustomResource
+	schemas         manifest.Schemas
+}
+
+// generate generates the manifest or stack described by description
+func (o generateOptions) generate(aiService *ai.Service, description string) (string, error) {
+	if o.stack {
+		return aiService.GenerateStack(description, o.name)
+	}
+	return aiService.GenerateManifest(description, o.customResources)
+}
+
+// validate validates a generated manifest, including custom resources against the cluster's CRDs,
+// and for stacks that every stack resource is present
+func (o generateOptions) validate(content string) []string {
+	problems := manifest.ValidateWithSchemas([]byte(content), o.schemas)
+	if !o.stack {
+		return problems
+	}
+	documents, err := manifest.Parse([]byte(content))
+	if err != nil {
+		return problems
+	}
+	return append(problems, manifest.CheckStack(documents, o.name)...)
+}
+
+// save copies a generated manifest to the clipboard if asked and writes it to the output file or
+// directory, stacks in their layout. It returns false when no file or directory was requested.
+func (o generateOptions) save(content string) (bool, error) {
+	if o.stack {
+		documents, err := labelStack(content, o.name)
+		if err != nil {
+			return false, err
+		}
+		data, err := manifest.Join(documents)
+		if err != nil {
+			return false, err
+		}
+		content = strings.TrimSpace(string(data))
+		if o.out.dir != "" {
+			o.out.toClipboard(content)
+			written, err := manifest.WriteStack(o.out.dir, o.name, o.layout, documents)
+			for _, path := range written {
+				fmt.Printf("Wrote %s\n", path)
+			}
+			return true, err
+		}
+	}
+	return o.out.deliver(content)
+}
+
+// labelStack parses the manifests of a stack and labels every resource as part of the stack
+func labelStack(content, name string) ([]manifest.Document, error) {
+	documents, err := manifest.Parse([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	for i := range documents {
+		documents[i].SetLabel("app.kubernetes.io/part-of", name)
+	}
+	return documents, nil
+}
+
+// runGenerateLoop generates a manifest, validates it and lets the user apply, accept, refine or
+// cancel it. Refinements regenerate the manifest with the earlier instructions as context, and
+// the loop continues until the user applies, accepts or cancels.
+func runGenerateLoop(cmd *cobra.Command, aiService *ai.Service, description string, opts generateOptions) error {
+	progressf("Generating manifest...\n")
+	response, err := opts.generate(aiService, description)
+	if err != nil {
+		return fmt.Errorf("error generating manifest: %w", err)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	readLine := func(prompt string) (string, bool) {
+		fmt.Print(prompt)
+		if !scanner.Scan() {
+			fmt.Println()
+			return "", false
+		}
+		return strings.TrimSpace(scanner.Text()), true
+	}
+
+	var instructions []string
+	for {
+		current := extractYAML(response)
+		fmt.Printf("\n====== %s ======\n", i18n.T("GENERATED MANIFEST"))
+		fmt.Println(current)
+
+		problems := opts.validate(current)
+		fmt.Printf("\n=== %s ===\n", i18n.T("Validation"))
+		if len(problems) == 0 {
+			fmt.Println("The manifest is valid")
+		}
+		for _, problem := range problems {
+			fmt.Printf("- %s\n", problem)
+		}
+
+		choice, ok := readLine("\nApply, accept, refine or cancel? [apply/accept/refine/cancel]: ")
+		if !ok {
+			return nil
+		}
+
+		switch strings.ToLower(choice) {
+		case "apply", "ap":
+			if len(problems) > 0 {
+				fmt.Println("Fix the validation problems before applying, e.g. with refine")
+				continue
+			}
+			applied, err := previewAndApply(cmd, current, func() bool {
+				if opts.apply.yes {
+					return true
+				}
+				answer, ok := readLine("Apply these changes? [y/N]: ")
+				return ok && (strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"))
+			})
+			for _, resource := range applied {
+				fmt.Printf("%s applied\n", resource)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				continue
+			}
+			if len(applied) == 0 {
+				continue
+			}
+			return nil
+
+		case "accept", "ac", "yes", "y":
+			_, err := opts.save(current)
+			return err
+
+		case "refine", "r":
+			instruction, ok := readLine("What should change? ")
+			if !ok {
+				return nil
+			}
+			if instruction == "" && len(problems) == 0 {
+				continue
+			}
+			if instruction != "" {
+				instructions = append(instructions, instruction)
+			}
+
+			progressf("Refining manifest...\n")
+			refined, err := aiService.RefineManifest(description, current, instructions, problems, opts.customResources)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error refining manifest: %v\n", err)
+				continue
+			}
+			response = refined
+
+		case "cancel", "c", "quit", "exit":
+			fmt.Println("Cancelled")
+			return nil
+
+		default:
+			fmt.Println("Please answer apply, accept, refine or cancel")
+		}
+	}
+}
+
+// extractYAML returns the YAML of an AI response, joining fenced yaml code blocks when present
+func extractYAML(response string) string {
+	var blocks []string
+	rest := response
+	for {
+		start := strings.Index(rest, "```")
+		if start < 0 {
+			break
+		}
+		rest = rest[start+3:]
+		// Skip the language tag
+		newline := strings.Index(rest, "\n")
+		if newline < 0 {
+			break
+		}
+		language := strings.TrimSpace(rest[:newline])
+		rest = rest[newline+1:]
+
+		end := strings.Index(rest, "```")
+		if end < 0 {
+			end = len(rest)
+		}
+		if language == "" || language == "yaml" || language == "yml" {
+			blocks = append(blocks, strings.TrimSpace(rest[:end]))
+		}
+		if end == len(rest) {
+			break
+		}
+		rest = rest[end+3:]
+	}
+
+	if len(blocks) == 0 {
+		return strings.TrimSpace(response)
+	}
+	return strings.Join(blocks, "\n---\n")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/k8s"
+)
+
+// getReport is the JSON output of get
+type getReport struct {
+	Question string `json:"question"`
+	// The query planned for the question, as kubectl get and filters
+	Query string              `json:"query"`
+	Notes string              `json:"notes,omitempty"`
+	Items []map[string]string `json:"items"`
+}
+
+// createGetCmd creates the get command
+func createGetCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "get <question>",
+		Short: "List the resources a question in plain words asks for",
+		Long: `Answer a question such as "pods not ready in payments older than 1h" with the
+matching objects, printed as kubectl get does.
+
+The AI only plans the query: it translates the question into a resource type,
+namespace, label and field selectors and filters on fields of the objects,
+such as age, ready, status, restarts or any path like spec.nodeName. The
+query is then run against the cluster, so every object listed exists and
+matches; the AI never writes the answer itself. The planned query is printed
+first, to check that it asks what was meant.`,
+		Example: `  kubectl ai get "pods not ready in payments older than 1h"
+  kubectl ai get "deployments in all namespaces with fewer ready replicas than wanted"
+  kubectl ai get "nodes that are cordoned" -o json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			question := strings.Join(args, " ")
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			ctx := context.Background()
+			commandContext, err := client.GetCommandContext(ctx)
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+
+			progress := progressWriter(outputFormat == "json")
+			fmt.Fprintln(progress, "Planning the query...")
+			plan, err := analyzers.PlanQuery(ctx, aiService, question, client.GetNamespace(), commandContext)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(progress, "Query: %s\n", plan.ResourceQuery)
+			if plan.Notes != "" {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", plan.Notes)
+			}
+
+			result, err := client.RunQuery(ctx, plan.ResourceQuery)
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+
+			if outputFormat == "json" {
+				report := getReport{Question: question, Query: plan.ResourceQuery.String(), Notes: plan.Notes, Items: []map[string]string{}}
+				for _, row := range result.Rows {
+					item := make(map[string]string, len(row))
+					for i, column := range result.Columns {
+						item[strings.ToLower(column)] = row[i]
+					}
+					report.Items = append(report.Items, item)
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding query results: %w", err)
+				}
+				return nil
+			}
+
+			if len(result.Rows) == 0 {
+				fmt.Fprintln(os.Stderr, "No resources found")
+				return nil
+			}
+			displayQueryResult(result)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayQueryResult prints the objects a query selected as a table, in the layout of kubectl get
+func displayQueryResult(result *k8s.QueryResult) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(result.Columns, "\t"))
+	for _, row := range result.Rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	writer.Flush()
+}
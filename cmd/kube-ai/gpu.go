@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// GPU pod log collection limits: pods read, lines read from each, and error lines kept from each
+const (
+	maxGPULogPods  = 5
+	gpuLogTail     = 300
+	maxGPULogLines = 10
+)
+
+// gpuLogError matches log lines of CUDA, NCCL and NVML failures and GPU out of memory errors
+var gpuLogError = regexp.MustCompile(`(?i)CUDA (error|out of memory)|cudaError|CUBLAS_STATUS|CUDNN_STATUS|NCCL (error|WARN)|failed to initialize NVML|no CUDA-capable device|driver version is insufficient|out of memory|\bXid\b`)
+
+// gpuReport is the JSON output of analyze-gpu
+type gpuReport struct {
+	*k8s.GPUReport
+	Analysis string `json:"analysis"`
+}
+
+// createAnalyzeGPUCmd creates the analyze-gpu command
+func createAnalyzeGPUCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "analyze-gpu",
+		Short: "Analyze GPU nodes, device plugins and GPU workloads",
+		Long: `Check the GPU setup of the cluster and the pods requesting GPUs in the namespace
+(or all namespaces with -A): the nvidia.com/gpu and MIG capacity and allocatable
+of each node against what its pods request, the health of the device plugin
+DaemonSets, GPU pods that cannot be scheduled or restart, and CUDA, NCCL, NVML
+and out of memory errors in their logs.
+
+The AI explains the failures and recommends GPU requests, node selectors,
+tolerations and MIG or time-slicing configurations.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			namespace := client.GetNamespace()
+			if client.IsAllNamespaces() {
+				namespace = ""
+			}
+
+			ctx := context.Background()
+			report, err := client.GetGPUReport(ctx, namespace)
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+			collectGPULogs(ctx, logs.NewLogCollector(client.GetClientset()), report)
+
+			if outputFormat == "text" {
+				displayGPUReport(report)
+				progressf("\nAnalyzing GPU workloads...\n")
+			}
+			output := gpuReport{GPUReport: report}
+			output.Analysis, err = analyzers.AnalyzeGPUWorkloads(ctx, aiService, report)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(output); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(output.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// collectGPULogs reads the GPU errors in the recent logs of the GPU pods that restarted or failed.
+// Pods whose logs cannot be read are skipped.
+func collectGPULogs(ctx context.Context, collector *logs.LogCollector, report *k8s.GPUReport) {
+	tail := int64(gpuLogTail)
+	read := 0
+	for i := range report.Pods {
+		pod := &report.Pods[i]
+		if pod.Node == "" || (pod.Restarts == 0 && pod.Phase != "Failed") || read == maxGPULogPods {
+			continue
+		}
+		read++
+		entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+			Namespace:    pod.Namespace,
+			ResourceName: pod.Name,
+			Previous:     pod.Restarts > 0,
+			TailLines:    &tail,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the logs of pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+			continue
+		}
+		for _, entry := range entries {
+			if gpuLogError.MatchString(entry.Content) {
+				pod.Errors = append(pod.Errors, strings.TrimSpace(entry.Content))
+			}
+		}
+		if len(pod.Errors) > maxGPULogLines {
+			pod.Errors = pod.Errors[len(pod.Errors)-maxGPULogLines:]
+		}
+	}
+}
+
+// displayGPUReport prints the GPU nodes with their allocation, the device plugins, the GPU pods
+// and the problems found
+func displayGPUReport(report *k8s.GPUReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("GPUS"))
+	if len(report.Nodes) > 0 {
+		fmt.Printf("%-30s %-8s %-34s %-12s %s\n", "NODE", "READY", "PRODUCT", "REQUESTED", "RESOURCES")
+		for _, node := range report.Nodes {
+			var allocatable, requested int64
+			var resources []string
+			for name, count := range node.Allocatable {
+				allocatable += count
+				requested += node.Requested[name]
+				resources = append(resources, fmt.Sprintf("%s=%d", name, count))
+			}
+			sort.Strings(resources)
+			product := node.Labels["nvidia.com/gpu.product"]
+			if product == "" {
+				product = "-"
+			}
+			fmt.Printf("%-30s %-8t %-34s %-12s %s\n", node.Name, node.Ready, product, fmt.Sprintf("%d/%d", requested, allocatable), strings.Join(resources, ","))
+		}
+	}
+	for _, plugin := range report.DevicePlugins {
+		fmt.Printf("%-16s %s/%s %d/%d ready\n", "Device plugin:", plugin.Namespace, plugin.Name, plugin.Ready, plugin.Desired)
+	}
+
+	if len(report.Pods) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("GPU Pods"))
+		for _, pod := range report.Pods {
+			status := pod.Phase
+			if pod.Reason != "" {
+				status += " (" + pod.Reason + ")"
+			}
+			node := pod.Node
+			if node == "" {
+				node = "-"
+			}
+			fmt.Printf("%-50s %-24s %-30s %d restarts\n", pod.Namespace+"/"+pod.Name, status, node, pod.Restarts)
+			for _, line := range pod.Errors {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+
+	if len(report.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range report.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+}
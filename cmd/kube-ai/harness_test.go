@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s"
+)
+
+// harness runs kube-ai commands end to end against a fake clientset and a fake AI provider. The
+// configuration is stateless, so nothing is read from or written to the user's home directory.
+type harness struct {
+	t         *testing.T
+	provider  *providers.FakeProvider
+	clientset *fake.Clientset
+	dynamic   *dynamicfake.FakeDynamicClient
+	// Objects served through the dynamic client
+	objects []runtime.Object
+}
+
+// result is the outcome of a command run by the harness
+type result struct {
+	stdout string
+	stderr string
+	err    error
+	// Exit code main would exit with
+	code int
+}
+
+// newHarness sets up a harness whose cluster holds the given objects. Commands use the
+// provider's scripted responses; add them with h.provider.Respond before running a command.
+func newHarness(t *testing.T, objects ...runtime.Object) *harness {
+	t.Helper()
+	h := &harness{
+		t:         t,
+		provider:  providers.NewFakeProvider(),
+		clientset: fake.NewSimpleClientset(objects...),
+		dynamic:   newDynamicClient(),
+	}
+	h.allowAccess(func(authorizationv1.ResourceAttributes) bool { return true })
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", "")
+	t.Setenv(config.StatelessEnv, "true")
+	t.Setenv("AI_PROVIDER", string(providers.ProviderTypeFake))
+	for _, env := range []string{"OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY", ProfileEnv, "KUBE_AI_CONTEXT", "KUBE_AI_LANGUAGE", "KUBE_AI_PERSONA"} {
+		t.Setenv(env, "")
+	}
+
+	providers.Register(providers.ProviderTypeFake, h.provider.Create)
+	t.Cleanup(func() { providers.Register(providers.ProviderTypeFake, nil) })
+
+	restore := k8s.UseClientFactory(func(cfg k8s.ClientConfig) (*k8s.Client, error) {
+		return k8s.NewClientForClientset(h.clientset, h.dynamic, cfg), nil
+	})
+	t.Cleanup(restore)
+
+	return h
+}
+
+// addObject adds an object served through the dynamic client, such as a custom resource, and
+// makes its kind discoverable
+func (h *harness) addObject(obj *unstructured.Unstructured) {
+	h.t.Helper()
+	gvk := obj.GroupVersionKind()
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+
+	var list *metav1.APIResourceList
+	for _, existing := range h.clientset.Resources {
+		if existing.GroupVersion == gvk.GroupVersion().String() {
+			list = existing
+		}
+	}
+	if list == nil {
+		list = &metav1.APIResourceList{GroupVersion: gvk.GroupVersion().String()}
+		h.clientset.Resources = append(h.clientset.Resources, list)
+	}
+	found := false
+	for _, existing := range list.APIResources {
+		found = found || existing.Name == resource.Resource
+	}
+	if !found {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       resource.Resource,
+			Kind:       gvk.Kind,
+			Namespaced: obj.GetNamespace() != "",
+			Verbs:      metav1.Verbs{"get", "list"},
+		})
+	}
+
+	h.objects = append(h.objects, obj)
+	h.dynamic = newDynamicClient(h.objects...)
+}
+
+// newDynamicClient returns a fake dynamic client serving objects. The fake only lists kinds it
+// was created with, so those of the objects and CRDs are registered.
+func newDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+	}
+	for _, obj := range objects {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		resource, _ := meta.UnsafeGuessKindToResource(gvk)
+		listKinds[resource] = gvk.Kind + "List"
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	client.PrependReactor("patch", "*", serveApply(client.Tracker()))
+	return client
+}
+
+// serveApply handles server-side apply like an API server does, which the fake only does for
+// typed objects: the applied fields are merged into the live object, or create it, and dry runs
+// return the result without storing it
+func serveApply(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(k8stesting.PatchActionImpl)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		applied := &unstructured.Unstructured{}
+		if err := applied.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+
+		resource, namespace := patch.GetResource(), patch.GetNamespace()
+		live, err := tracker.Get(resource, namespace, patch.GetName())
+		obj := applied
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return true, nil, err
+		default:
+			obj = live.(*unstructured.Unstructured).DeepCopy()
+			mergeFields(obj.Object, applied.Object)
+		}
+
+		switch {
+		case len(patch.PatchOptions.DryRun) > 0:
+		case live == nil:
+			err = tracker.Create(resource, obj, namespace)
+		default:
+			err = tracker.Update(resource, obj, namespace)
+		}
+		return true, obj, err
+	}
+}
+
+// mergeFields sets the fields of src in dst, merging nested objects
+func mergeFields(dst, src map[string]interface{}) {
+	for key, value := range src {
+		nested, isMap := value.(map[string]interface{})
+		existing, hasMap := dst[key].(map[string]interface{})
+		if isMap && hasMap {
+			mergeFields(existing, nested)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// allowAccess decides the SelfSubjectAccessReviews of the caller
+func (h *harness) allowAccess(allowed func(authorizationv1.ResourceAttributes) bool) {
+	h.clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = allowed(*review.Spec.ResourceAttributes)
+		return true, review, nil
+	})
+}
+
+// run runs a kube-ai command line, capturing what it writes to standard output and error
+func (h *harness) run(args ...string) result {
+	h.t.Helper()
+
+	cfg := &config.Config{}
+	aiService := &ai.Service{}
+	rootCmd := createRootCommand(cfg, aiService)
+	rootCmd.SetArgs(args)
+	commandStarted = false
+
+	var res result
+	res.stdout, res.stderr = capture(h.t, func() {
+		cmd, err := rootCmd.ExecuteC()
+		finishNotification()
+		if err != nil {
+			res.err = err
+			res.code = renderError(os.Stderr, cmd, err)
+		}
+	})
+	return res
+}
+
+// capture runs a function with the process's standard output and error redirected, since
+// commands write to them directly
+func capture(t *testing.T, run func()) (string, string) {
+	t.Helper()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outDone, errDone := drain(outReader), drain(errReader)
+	os.Stdout, os.Stderr = outWriter, errWriter
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+	}()
+
+	run()
+
+	outWriter.Close()
+	errWriter.Close()
+	return (<-outDone).String(), (<-errDone).String()
+}
+
+// drain reads a pipe to the end in the background
+func drain(r io.ReadCloser) <-chan *bytes.Buffer {
+	done := make(chan *bytes.Buffer, 1)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		r.Close()
+		done <- &buf
+	}()
+	return done
+}
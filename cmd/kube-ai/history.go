@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// logHistoryPath returns the file that stores the rolling log history of the workload
+func logHistoryPath(cmd *cobra.Command, options logs.LogOptions) (string, error) {
+	dir, err := logs.DefaultHistoryDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating log history directory: %w", err)
+	}
+	return logs.HistoryPath(dir, currentContextName(cmd), options.Namespace, options.ResourceType, options.ResourceName), nil
+}
+
+// recordLogHistory adds log entries to the rolling history stored at path and returns the trend
+// of the last 7 days. The history only adds context to an analysis, so failures are reported as
+// warnings and yield a nil trend.
+func recordLogHistory(path string, options logs.LogOptions, entries []logs.LogEntry) *logs.LogTrend {
+	history, err := logs.LoadHistory(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not read the log history: %v\n", err)
+		return nil
+	}
+	if history == nil {
+		history = logs.NewLogHistory(options.ResourceType, options.ResourceName, options.Namespace)
+	}
+	if history.Record(entries, time.Now()) > 0 {
+		if err := history.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save the log history: %v\n", err)
+		}
+	}
+	return history.Trend()
+}
+
+// displayLogTrend prints the recorded errors of the workload per day
+func displayLogTrend(trend *logs.LogTrend) {
+	// A single day adds nothing to the summary of the current logs
+	if trend == nil || len(trend.Days) < 2 {
+		return
+	}
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("LAST 7 DAYS"))
+	for _, day := range trend.Days {
+		fmt.Printf("%s  %6d entries  %5d errors (%4.1f%%)", day.Date.Format("2006-01-02"), day.Entries, day.Errors, day.ErrorPercent)
+		if day.PeakErrors > 0 {
+			fmt.Printf("  peak %d at %s", day.PeakErrors, day.PeakHour.Local().Format("15:04"))
+		}
+		fmt.Println()
+	}
+}
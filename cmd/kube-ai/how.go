@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/progress"
+)
+
+// howCommand is a suggested kubectl command with what checking it against the cluster found
+type howCommand struct {
+	analyzers.SuggestedCommand
+	k8s.CommandCheck
+	// Words of the command, when it could be split without a shell
+	args []string
+}
+
+// howReport is the JSON output of how
+type howReport struct {
+	Task     string       `json:"task"`
+	Commands []howCommand `json:"commands"`
+	Notes    string       `json:"notes,omitempty"`
+}
+
+// createHowCmd creates the how command
+func createHowCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+	var execute, yes bool
+
+	cmd := &cobra.Command{
+		Use:   "how <task>",
+		Short: "Suggest the kubectl commands for a task",
+		Long: `Have the AI write the exact kubectl commands for a task described in plain
+words, such as "cordon all nodes in zone us-east-1a". The AI is given the
+cluster's namespaces, node labels and resource types, and every command is
+checked against the cluster: its namespace, resource types and named objects
+must exist, and label selectors are matched to show how many objects they
+select.
+
+The commands are only printed. --execute runs them in order with kubectl once
+you confirm, or without asking with --yes; commands that change the cluster
+require --allow-writes, and nothing runs when a check found a problem.`,
+		Example: `  kubectl ai how "cordon all nodes in zone us-east-1a"
+  kubectl ai how "restart every deployment labelled app=checkout" -n shop
+  kubectl ai how "scale the web deployment to 5 replicas" --execute --allow-writes`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if execute && outputFormat == "json" {
+				return usageErrorf("--execute cannot be combined with -o json")
+			}
+			if execute && !yes && !progress.IsTerminal(os.Stdin) {
+				return usageErrorf("--execute asks for confirmation on a terminal; add --yes to run the commands without asking")
+			}
+			task := strings.Join(args, " ")
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			ctx := context.Background()
+			commandContext, err := client.GetCommandContext(ctx)
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+
+			fmt.Fprintln(progressWriter(outputFormat == "json"), "Finding the kubectl commands...")
+			suggestion, err := analyzers.SuggestCommands(ctx, aiService, task, client.GetNamespace(), commandContext)
+			if err != nil {
+				return err
+			}
+
+			report := howReport{Task: task, Notes: suggestion.Notes, Commands: make([]howCommand, len(suggestion.Commands))}
+			for i, suggested := range suggestion.Commands {
+				command := howCommand{SuggestedCommand: suggested}
+				command.args, err = k8s.SplitCommandLine(suggested.Command)
+				if err != nil {
+					command.Problems = []string{err.Error()}
+				} else {
+					command.CommandCheck = client.CheckKubectlCommand(ctx, command.args)
+				}
+				report.Commands[i] = command
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding commands: %w", err)
+				}
+				return nil
+			}
+			displayHowReport(report)
+
+			if !execute {
+				return nil
+			}
+			return executeCommands(cmd, client, report.Commands, yes)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&execute, "execute", false, "Run the commands with kubectl after confirmation")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Run the commands without asking for confirmation")
+
+	return cmd
+}
+
+// displayHowReport prints the suggested commands as a shell script: each command after a comment
+// explaining it, with what checking it found
+func displayHowReport(report howReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("KUBECTL COMMANDS"))
+	if len(report.Commands) == 0 {
+		fmt.Println("# No commands suggested")
+	}
+	for _, command := range report.Commands {
+		fmt.Printf("\n%s# %s%s\n", color(ansiBlue), command.Explanation, color(ansiReset))
+		fmt.Println(command.Command)
+		for _, note := range command.Notes {
+			fmt.Printf("%s#   %s%s\n", color(ansiGreen), note, color(ansiReset))
+		}
+		for _, problem := range command.Problems {
+			fmt.Printf("%s#   Warning: %s%s\n", color(ansiYellow), problem, color(ansiReset))
+		}
+	}
+	if report.Notes != "" {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Notes"))
+		printMarkdown(report.Notes)
+	}
+}
+
+// executeCommands runs the suggested commands in order with kubectl, against the cluster selected
+// by the command's flags, once confirmed. Nothing runs when a check found a problem, or when a
+// command changes the cluster without --allow-writes.
+func executeCommands(cmd *cobra.Command, client *k8s.Client, commands []howCommand, yes bool) error {
+	if len(commands) == 0 {
+		return nil
+	}
+	for _, command := range commands {
+		if len(command.Problems) > 0 {
+			return fmt.Errorf("not running the commands, as checking %q found problems; review and run them yourself", command.Command)
+		}
+	}
+	for _, command := range commands {
+		if !command.ReadOnly && client.IsReadOnly() {
+			return usageErrorf("%q changes the cluster, which requires --allow-writes", command.Command)
+		}
+	}
+	kubectl, err := exec.LookPath("kubectl")
+	if err != nil {
+		return fmt.Errorf("kubectl is needed to run the commands: %w", err)
+	}
+
+	fmt.Println()
+	if !yes && !confirmOnStdin(fmt.Sprintf("Run these %d commands?", len(commands))) {
+		fmt.Println("Not run")
+		return nil
+	}
+
+	connection := kubectlConnectionArgs(cmd)
+	for _, command := range commands {
+		fmt.Printf("\n$ %s\n", command.Command)
+		run := exec.Command(kubectl, append(connection, command.args[1:]...)...)
+		run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := run.Run(); err != nil {
+			return fmt.Errorf("%q failed: %w", command.Command, err)
+		}
+	}
+	return nil
+}
+
+// kubectlConnectionArgs returns the kubectl flags that select the same cluster, credentials,
+// identity and namespace as kube-ai's own flags. They come before the command's arguments, which
+// may only choose another namespace: CheckKubectlCommand reports the other connection flags as
+// problems, so suggested commands cannot run against another cluster or as another user.
+func kubectlConnectionArgs(cmd *cobra.Command) []string {
+	var args []string
+	contextName, _ := cmd.Flags().GetString("context")
+	if contextName == "" {
+		contextName = k8s.SessionContext()
+	}
+	if contextName != "" {
+		args = append(args, "--context", contextName)
+	}
+	for _, flag := range []string{"kubeconfig", "cluster", "user", "server", "token", "certificate-authority", "as", "namespace"} {
+		if value, _ := cmd.Flags().GetString(flag); value != "" {
+			args = append(args, "--"+flag, value)
+		}
+	}
+	groups, _ := cmd.Flags().GetStringArray("as-group")
+	for _, group := range groups {
+		args = append(args, "--as-group", group)
+	}
+	if insecure, _ := cmd.Flags().GetBool("insecure-skip-tls-verify"); insecure {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+	return args
+}
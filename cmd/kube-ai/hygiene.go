@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/hygiene"
+)
+
+// findingTitles are the section titles of the kinds of secret hygiene findings
+var findingTitles = map[string]string{
+	hygiene.FindingLongLivedToken:   "Long-Lived Tokens",
+	hygiene.FindingAutomountedToken: "Unneeded Token Mounts",
+	hygiene.FindingBroadSecret:      "Broadly Mounted Secrets",
+	hygiene.FindingRegistrySecret:   "Registry Credentials",
+	hygiene.FindingUnusedSecret:     "Unused Secrets",
+}
+
+// secretAuditReport is the JSON output of audit-secrets
+type secretAuditReport struct {
+	*hygiene.Report
+	Plan string `json:"plan,omitempty"`
+}
+
+// createAuditSecretsCmd creates the audit-secrets command
+func createAuditSecretsCmd(aiService *ai.Service) *cobra.Command {
+	var broadThreshold int
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "audit-secrets",
+		Short: "Audit service account tokens and secrets for hygiene problems",
+		Long: `Find legacy long-lived service account token secrets, pods that mount the token
+of a service account with no RBAC bindings, secrets nothing references, secrets
+used by many workloads, and registry credentials that nothing in their namespace
+pulls with or that are copied across namespaces. Use -A for all namespaces.
+
+Secret values are never printed or sent to the AI; registry credentials are only
+hashed to find copies. The AI prioritizes the findings into cleanup steps.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			namespace := client.GetNamespace()
+			if client.IsAllNamespaces() {
+				namespace = ""
+			}
+			if err := checkAccess(client, "audit-secrets", namespace); err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			audit, err := hygiene.Audit(ctx, client, namespace, hygiene.Options{BroadThreshold: broadThreshold})
+			if err != nil {
+				return kubeErrorf("error auditing secrets: %w", err)
+			}
+			report := secretAuditReport{Report: audit}
+
+			if outputFormat == "text" {
+				displaySecretAudit(audit)
+			}
+			if len(audit.Findings) > 0 {
+				if outputFormat == "text" {
+					progressf("\nWriting cleanup plan...\n")
+				}
+				report.Plan, err = analyzers.PlanSecretCleanup(ctx, aiService, audit)
+				if err != nil {
+					return err
+				}
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			if report.Plan != "" {
+				fmt.Printf("\n====== %s ======\n", i18n.T("CLEANUP PLAN"))
+				printMarkdown(report.Plan)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&broadThreshold, "broad-threshold", 5, "Number of workloads using a secret from which it is reported as broadly mounted")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displaySecretAudit prints the findings of a secret hygiene audit by kind
+func displaySecretAudit(report *hygiene.Report) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("SECRET HYGIENE"))
+	fmt.Printf("%d secrets, %d service accounts, %d findings\n", report.Secrets, report.ServiceAccounts, len(report.Findings))
+
+	kind := ""
+	for _, finding := range report.Findings {
+		if finding.Kind != kind {
+			kind = finding.Kind
+			fmt.Printf("\n=== %s ===\n", i18n.T(findingTitles[kind]))
+		}
+		fmt.Printf("%-7s %s\n", finding.Severity, finding.Object)
+		fmt.Printf("        %s\n", finding.Detail)
+	}
+
+	for _, skipped := range report.Skipped {
+		fmt.Printf("\nNot checked: %s\n", skipped)
+	}
+	if report.Ignored > 0 {
+		fmt.Printf("\n%d findings left out by the %s annotation\n", report.Ignored, k8s.IgnoreAnnotation)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/images"
+)
+
+// imagesReport is the JSON output of analyze-images
+type imagesReport struct {
+	Scanner string         `json:"scanner,omitempty"`
+	Images  []images.Image `json:"images"`
+	Plan    string         `json:"plan"`
+}
+
+// createAnalyzeImagesCmd creates the analyze-images command
+func createAnalyzeImagesCmd(aiService *ai.Service) *cobra.Command {
+	var scanner string
+	var severity string
+	var scanTimeout time.Duration
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "analyze-images",
+		Short: "Analyze container images for mutable tags and vulnerabilities",
+		Long: `List the images the pods of a namespace run, with the workloads that use them,
+and flag latest and other mutable tags, pods running different builds of the same
+tag, and images pulled from Docker Hub. Use -A for all namespaces.
+
+With --scanner trivy or --scanner grype, each image is scanned for CVEs with the
+scanner binary, which must be installed and able to pull the images. The AI then
+prioritizes the findings into an actionable patching plan.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if scanner != "" && scanner != images.ScannerTrivy && scanner != images.ScannerGrype {
+				return usageErrorf("unsupported scanner %q, use %s", scanner, strings.Join(images.Scanners, " or "))
+			}
+			if severity != "" && !images.ValidSeverity(severity) {
+				return usageErrorf("unsupported severity %q, use critical, high, medium, low or negligible", severity)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			namespace := client.GetNamespace()
+			if client.IsAllNamespaces() {
+				namespace = ""
+			}
+
+			ctx := context.Background()
+			report := imagesReport{Scanner: scanner}
+			report.Images, err = images.Inventory(ctx, client, namespace)
+			if err != nil {
+				return kubeErrorf("error listing images: %w", err)
+			}
+			if len(report.Images) == 0 {
+				return fmt.Errorf("no pods found")
+			}
+
+			// Images are printed as soon as they are scanned, since scanning many takes a while
+			if outputFormat == "text" {
+				fmt.Printf("\n====== %s ======\n", i18n.T("IMAGES"))
+			}
+			for i := range report.Images {
+				image := &report.Images[i]
+				if scanner != "" {
+					fmt.Fprintf(os.Stderr, "Scanning %s (%d/%d)...\n", image.Image, i+1, len(report.Images))
+					scanCtx, cancel := context.WithTimeout(ctx, scanTimeout)
+					vulnerabilities, err := images.Scan(scanCtx, scanner, image.Image)
+					cancel()
+					if err != nil {
+						image.ScanError = err.Error()
+					}
+					for _, vulnerability := range vulnerabilities {
+						if severity == "" || vulnerability.AtLeast(severity) {
+							image.Vulnerabilities = append(image.Vulnerabilities, vulnerability)
+						}
+					}
+				}
+				if outputFormat == "text" {
+					displayImage(*image, scanner)
+				}
+			}
+
+			if outputFormat == "text" {
+				progressf("\nWriting patching plan...\n")
+			}
+			report.Plan, err = analyzers.PlanImagePatching(ctx, aiService, report.Images, scanner)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("PATCHING PLAN"))
+			printMarkdown(report.Plan)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scanner, "scanner", "", "Vulnerability scanner to run on each image: trivy or grype (default: none)")
+	cmd.Flags().StringVar(&severity, "severity", "", "Minimum vulnerability severity to report: critical, high, medium, low or negligible (default: all)")
+	cmd.Flags().DurationVar(&scanTimeout, "scan-timeout", 5*time.Minute, "Time limit for scanning one image")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayImage prints an image with its workloads, tag problems and vulnerability counts
+func displayImage(image images.Image, scanner string) {
+	fmt.Printf("\n%s\n", image.Image)
+	fmt.Printf("  %-18s %s\n", "Workloads:", strings.Join(image.Workloads, ", "))
+	for _, issue := range image.Issues {
+		fmt.Printf("  - %s\n", issue)
+	}
+	if scanner == "" {
+		return
+	}
+	switch {
+	case image.ScanError != "":
+		fmt.Printf("  %-18s %s\n", "Not scanned:", image.ScanError)
+	case len(image.Vulnerabilities) == 0:
+		fmt.Printf("  %-18s none\n", "Vulnerabilities:")
+	default:
+		fixable := 0
+		for _, vulnerability := range image.Vulnerabilities {
+			if vulnerability.Fixable() {
+				fixable++
+			}
+		}
+		fmt.Printf("  %-18s %s (%d fixable)\n", "Vulnerabilities:", analyzers.FormatSeverityCounts(image.Vulnerabilities), fixable)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/incident"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// incidentReport is the JSON output of enrich-incident
+type incidentReport struct {
+	Incident *incident.Incident           `json:"incident"`
+	Workload incident.Workload            `json:"workload"`
+	Summary  logs.LogSummary              `json:"logSummary"`
+	Analysis *analyzers.LogAnalysisResult `json:"analysis"`
+	Posted   bool                         `json:"posted"`
+}
+
+// createEnrichIncidentCmd creates the enrich-incident command
+func createEnrichIncidentCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	var pagerDutyID string
+	var opsgenieID string
+	var resource string
+	var since string
+	var tailLines int64
+	var post bool
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "enrich-incident (--pagerduty <incident-id> | --opsgenie <alert-id>)",
+		Short: "Diagnose the workload an incident's alert implicates and post the analysis to the incident",
+		Long: `Read a PagerDuty incident or Opsgenie alert, identify the Kubernetes workload its
+alert payload implicates from labels such as namespace, deployment, statefulset
+or pod, analyze the workload's recent logs and events in the light of the alert,
+and post the analysis back as a note on the incident.
+
+The API keys are read from pagerdutyApiKey and opsgenieApiKey (or
+$PAGERDUTY_API_KEY and $OPSGENIE_API_KEY); PagerDuty also needs pagerdutyFrom,
+the email of the user notes are posted as. Use --resource when the alert does
+not name a workload, and -n to override the namespace it names.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if (pagerDutyID == "") == (opsgenieID == "") {
+				return usageErrorf("exactly one of --pagerduty or --opsgenie is required")
+			}
+			duration, err := logs.ParseDuration(since)
+			if err != nil {
+				return usageErrorf("invalid --since: %w", err)
+			}
+
+			client, id, err := incidentClient(cfg, pagerDutyID, opsgenieID)
+			if err != nil {
+				return err
+			}
+
+			// Progress goes to stderr with JSON output
+			progress := progressWriter(outputFormat == "json")
+
+			ctx := context.Background()
+			inc, err := client.Get(ctx, id)
+			if err != nil {
+				return fmt.Errorf("error reading incident %s: %w", id, err)
+			}
+			fmt.Fprintf(progress, "Incident %s: %s\n", inc.ID, inc.Title)
+
+			// Find the workload, letting flags override what the alert names
+			workload, err := inc.Workload()
+			if resource != "" {
+				kind, name, ok := strings.Cut(resource, "/")
+				if !ok || kind == "" || name == "" {
+					return usageErrorf("invalid --resource %q, use kind/name such as deployment/api", resource)
+				}
+				workload.Kind, workload.Name, err = kind, name, nil
+			}
+			if err != nil {
+				return usageErrorf("%w", err)
+			}
+
+			k8sClient, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			if cmd.Flags().Changed("namespace") || workload.Namespace == "" {
+				workload.Namespace = k8sClient.GetNamespace()
+			}
+			fmt.Fprintf(progress, "Collecting logs from %s in namespace %s...\n", workload, workload.Namespace)
+
+			seconds := int64(duration.Seconds())
+			options := logs.LogOptions{
+				Namespace:    workload.Namespace,
+				ResourceType: workload.Kind,
+				ResourceName: workload.Name,
+				Container:    workload.Container,
+				TailLines:    &tailLines,
+				SinceSeconds: &seconds,
+			}
+			collector := logs.NewLogCollector(k8sClient.GetClientset())
+			logEntries, err := collector.GetResourceLogs(ctx, options)
+			if err != nil {
+				return kubeErrorf("error collecting logs: %w", err)
+			}
+			logSummary := logs.ParseLogs(logEntries)
+
+			analyzer := analyzers.NewLogAnalyzer(aiService)
+			analyzer.SetProgress(progress)
+			analyzer.SetAlert(inc.Alert())
+			if len(logEntries) > 0 {
+				lifecycle, err := k8sClient.GetWorkloadLifecycle(ctx, workload.Kind, workload.Name, workload.Namespace, logSummary.TimeRange.Start, time.Now())
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not collect lifecycle events: %v\n", err)
+				} else {
+					analyzer.SetLifecycle(lifecycle)
+				}
+			}
+
+			fmt.Fprintf(progress, "Analyzing %d log entries...\n", len(logEntries))
+			analysis, err := analyzer.AnalyzeLogs(ctx, logEntries, logSummary)
+			if err != nil {
+				return fmt.Errorf("error analyzing logs: %w", err)
+			}
+
+			report := incidentReport{Incident: inc, Workload: workload, Summary: logSummary, Analysis: analysis}
+			if post {
+				if err := client.AddNote(ctx, id, incidentNote(workload, logSummary, analysis)); err != nil {
+					return fmt.Errorf("error posting the analysis to incident %s: %w", id, err)
+				}
+				report.Posted = true
+				fmt.Fprintf(progress, "Posted the analysis to incident %s\n", id)
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+			} else {
+				displayFormattedResults(logSummary, analysis)
+			}
+			recordResult(analysis.Severity, report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&pagerDutyID, "pagerduty", "", "ID of the PagerDuty incident to enrich")
+	cmd.Flags().StringVar(&opsgenieID, "opsgenie", "", "ID or tiny ID of the Opsgenie alert to enrich")
+	cmd.Flags().StringVar(&resource, "resource", "", "Workload to diagnose as kind/name, when the alert does not name one")
+	cmd.Flags().StringVarP(&since, "since", "s", "1h", "Analyze logs newer than a relative duration like 30m, 2h or 1d")
+	cmd.Flags().Int64VarP(&tailLines, "tail", "t", 1000, "Number of lines to include from the end of logs")
+	cmd.Flags().BoolVar(&post, "post", true, "Post the analysis as a note on the incident")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+
+	return cmd
+}
+
+// incidentClient creates the client of the incident management service chosen by flags
+func incidentClient(cfg *config.Config, pagerDutyID, opsgenieID string) (incident.Client, string, error) {
+	if pagerDutyID != "" {
+		apiKey, err := cfg.GetIncidentAPIKey(config.IncidentPagerDuty)
+		if err != nil {
+			return nil, "", err
+		}
+		return incident.NewPagerDuty(cfg.PagerDutyURL, apiKey, cfg.PagerDutyFrom), pagerDutyID, nil
+	}
+	apiKey, err := cfg.GetIncidentAPIKey(config.IncidentOpsgenie)
+	if err != nil {
+		return nil, "", err
+	}
+	return incident.NewOpsgenie(cfg.OpsgenieURL, apiKey), opsgenieID, nil
+}
+
+// incidentNote formats an analysis as the plain-text note posted to an incident
+func incidentNote(workload incident.Workload, summary logs.LogSummary, analysis *analyzers.LogAnalysisResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "kube-ai analysis of %s in namespace %s\n", workload, workload.Namespace)
+	fmt.Fprintf(&b, "Severity: %s\n", analysis.Severity)
+	fmt.Fprintf(&b, "Logs: %d entries (%d errors, %d warnings)\n\n", summary.TotalEntries, summary.ErrorCount, summary.WarningCount)
+	fmt.Fprintf(&b, "Summary:\n%s\n", analysis.Summary)
+
+	if len(analysis.RootCauses) > 0 {
+		b.WriteString("\nRoot causes:\n")
+		for i, cause := range analysis.RootCauses {
+			if cause.Confidence > 0 {
+				fmt.Fprintf(&b, "%d. %s (confidence %d%%)\n", i+1, cause.Cause, cause.Confidence)
+			} else {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, cause.Cause)
+			}
+			for _, evidence := range cause.Evidence {
+				fmt.Fprintf(&b, "   > %s\n", evidence)
+			}
+		}
+	}
+	if len(analysis.Solutions) > 0 {
+		b.WriteString("\nRecommended solutions:\n")
+		for i, solution := range analysis.Solutions {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, solution)
+		}
+	}
+	return b.String()
+}
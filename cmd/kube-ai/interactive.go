@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+)
+
+// runFollowUpChat reads follow-up questions about a completed analysis from stdin and answers
+// them until the user types exit or quit, sends an empty line, or closes stdin
+func runFollowUpChat(conversation *analyzers.Conversation) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("FOLLOW-UP QUESTIONS"))
+	fmt.Println("Ask about the analysis, e.g. \"show me more about cause #2\". Press Enter on an empty line or type exit to quit.")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("\n> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
+
+		question := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(question) {
+		case "", "exit", "quit":
+			return
+		}
+
+		answer, err := conversation.Ask(context.Background(), question)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		fmt.Printf("\n%s\n", answer)
+	}
+}
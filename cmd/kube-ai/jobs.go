@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// Failed attempt log collection limits: pods read, most recent first, and lines kept from each
+const (
+	maxJobLogPods   = 3
+	jobLogTailLines = 40
+)
+
+// jobReport is the JSON output of analyze job and analyze cronjob
+type jobReport struct {
+	*k8s.JobReport
+	Analysis string `json:"analysis"`
+}
+
+// analyzeJob explains why a Job or CronJob fails or never runs, for analyze job <name> and
+// analyze cronjob <name>
+func analyzeJob(cmd *cobra.Command, aiService *ai.Service, kind, name, outputFormat string) error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return usageErrorf("unsupported output format %q for jobs, use text or json", outputFormat)
+	}
+
+	client, err := k8s.NewClientFromFlags(cmd)
+	if err != nil {
+		return kubeErrorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx := context.Background()
+	report, err := client.GetJobReport(ctx, kind, client.GetNamespace(), name)
+	if err != nil {
+		return kubeErrorf("%w", err)
+	}
+	collectJobLogs(ctx, logs.NewLogCollector(client.GetClientset()), report)
+
+	if outputFormat == "text" {
+		displayJobReport(report)
+		progressf("\nAnalyzing...\n")
+	}
+	output := jobReport{JobReport: report}
+	output.Analysis, err = analyzers.ExplainJobFailure(ctx, aiService, report)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("error encoding report: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+	printMarkdown(output.Analysis)
+	return nil
+}
+
+// collectJobLogs reads the last log lines of the most recent failed attempts, across the runs
+// of the report. Pods whose logs cannot be read, often because they were already deleted, are
+// skipped.
+func collectJobLogs(ctx context.Context, collector *logs.LogCollector, report *k8s.JobReport) {
+	tail := int64(jobLogTailLines)
+	read := 0
+	for i := len(report.Jobs) - 1; i >= 0 && read < maxJobLogPods; i-- {
+		pods := report.Jobs[i].Pods
+		for j := len(pods) - 1; j >= 0 && read < maxJobLogPods; j-- {
+			pod := &pods[j]
+			if !pod.Failed() {
+				continue
+			}
+			read++
+			entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+				Namespace:    report.Namespace,
+				ResourceName: pod.Name,
+				Container:    pod.Container,
+				TailLines:    &tail,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not read the logs of pod %s: %v\n", pod.Name, err)
+				continue
+			}
+			for _, entry := range entries {
+				pod.Logs = append(pod.Logs, strings.TrimRight(entry.Content, "\n"))
+			}
+		}
+	}
+}
+
+// displayJobReport prints the schedule of a CronJob, the outcome and attempts of each run, and
+// the problems found
+func displayJobReport(report *k8s.JobReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("JOB RUNS"))
+	fmt.Printf("%s %s/%s\n", report.Kind, report.Namespace, report.Name)
+	if cronJob := report.CronJob; cronJob != nil {
+		schedule := cronJob.Schedule
+		if cronJob.TimeZone != "" {
+			schedule += " (" + cronJob.TimeZone + ")"
+		}
+		if cronJob.Suspended {
+			schedule += ", suspended"
+		}
+		fmt.Printf("%-16s %s\n", "Schedule:", schedule)
+		fmt.Printf("%-16s %s\n", "Concurrency:", cronJob.ConcurrencyPolicy)
+		fmt.Printf("%-16s %s\n", "Last scheduled:", valueOr(cronJob.LastScheduleTime, "never"))
+		fmt.Printf("%-16s %s\n", "Last success:", valueOr(cronJob.LastSuccessfulTime, "never"))
+	}
+
+	if len(report.Jobs) > 0 {
+		fmt.Println()
+		fmt.Printf("%-40s %-10s %-10s %s\n", "JOB", "STATUS", "ATTEMPTS", "REASON")
+		for _, run := range report.Jobs {
+			attempts := fmt.Sprintf("%d/%d", run.Failed+run.Succeeded, run.BackoffLimit+1)
+			fmt.Printf("%-40s %-10s %-10s %s\n", run.Name, run.Status, attempts, run.Reason)
+			for _, pod := range run.Pods {
+				if pod.Reason == "" {
+					continue
+				}
+				outcome := pod.Reason
+				if pod.ExitCode != 0 {
+					outcome += fmt.Sprintf(" (exit code %d)", pod.ExitCode)
+				}
+				fmt.Printf("  %s: %s\n", pod.Name, outcome)
+			}
+		}
+	}
+
+	if len(report.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range report.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// displayLifecycle prints restarts and lifecycle events found around the analyzed logs
+func displayLifecycle(lifecycle *k8s.WorkloadLifecycle) {
+	if !lifecycle.HasEvents() {
+		return
+	}
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("LIFECYCLE EVENTS"))
+	for _, restart := range lifecycle.Restarts {
+		fmt.Printf("- %s/%s: %d restarts", restart.Pod, restart.Container, restart.RestartCount)
+		if restart.LastTerminationReason != "" {
+			fmt.Printf(" (last: %s, exit code %d)", restart.LastTerminationReason, restart.LastExitCode)
+		}
+		fmt.Println()
+	}
+
+	resetColor := color(ansiReset)
+	for _, event := range lifecycle.Events {
+		eventColor := ""
+		if event.Type == "Warning" {
+			eventColor = color(ansiYellow)
+		}
+		fmt.Printf("%s [%s%s%s] %s", event.Time.Format("2006-01-02 15:04:05"), eventColor, event.Reason, resetColor, event.Object)
+		if event.Message != "" {
+			fmt.Printf(": %s", truncateLine(event.Message, 120))
+		}
+		if event.Count > 1 {
+			fmt.Printf(" (x%d)", event.Count)
+		}
+		fmt.Println()
+	}
+}
+
+// timelineColors highlights the kinds of timeline entries
+var timelineColors = map[string]string{
+	k8s.TimelineOOMKill: ansiRed,
+	k8s.TimelineRestart: ansiYellow,
+	k8s.TimelineErrors:  ansiYellow,
+	k8s.TimelineRollout: ansiCyan,
+	k8s.TimelineScaling: ansiBlue,
+}
+
+// displayTimeline prints restarts, OOM kills, rollouts, scaling and the error rate of the logs in
+// chronological order
+func displayTimeline(timeline []k8s.TimelineEntry) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("TIMELINE"))
+	if len(timeline) == 0 {
+		fmt.Println("No restarts, rollouts, scaling or errors in the time window")
+		return
+	}
+	for _, entry := range timeline {
+		fmt.Printf("%s %s%-8s%s %-40s %s\n", entry.Time.Format("2006-01-02 15:04:05"), color(timelineColors[entry.Kind]), entry.Kind, color(ansiReset), entry.Object, truncateLine(entry.Message, 120))
+	}
+}
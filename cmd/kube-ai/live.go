@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// historyInterval is how often streamed logs are added to the workload's log history
+const historyInterval = time.Minute
+
+// liveAnalysis is the result of analyzing one window of streamed logs
+type liveAnalysis struct {
+	start, end time.Time
+	entries    int
+	result     *analyzers.LogAnalysisResult
+	err        error
+}
+
+// streamLogsLive prints logs as they arrive and, when interval is set, periodically analyzes
+// the lines accumulated since the previous analysis, alerting when severity rises. When
+// historyPath is set, the lines are also added to the workload's log history.
+func streamLogsLive(collector *logs.LogCollector, aiService *ai.Service, options logs.LogOptions, interval time.Duration, errorsOnly bool, chunkTokens int, redactor *redact.Redactor, historyPath string) {
+	progressf("Streaming logs in real-time (press Ctrl+C to stop)...\n")
+	if interval > 0 {
+		progressf("Analyzing new logs every %s\n", interval)
+	}
+	// Log lines keep printing while windows are analyzed, so no spinner is drawn between them
+	aiService.OnRequest(nil)
+
+	// Create context that can be canceled on interrupt
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Setup signal handling for graceful exit
+	interruptChan := make(chan os.Signal, 1)
+	signal.Notify(interruptChan, os.Interrupt, syscall.SIGTERM)
+
+	// Start a goroutine that will cancel the context when interrupted
+	go func() {
+		<-interruptChan
+		progressf("\nInterrupted, stopping log stream...\n")
+		cancel()
+	}()
+
+	// Stream logs in real-time
+	logChan := make(chan logs.LogEntry)
+	errChan := make(chan error)
+
+	go func() {
+		err := collector.StreamLogs(ctx, options, logChan, errChan)
+		if err != nil {
+			fmt.Printf("Error streaming logs: %v\n", err)
+		}
+	}()
+
+	// A nil ticker channel never fires, which disables periodic analysis
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	analyzer := analyzers.NewLogAnalyzer(aiService)
+	analyzer.SetChunkTokens(chunkTokens)
+
+	// Add the streamed logs to the history every minute and once streaming stops
+	var unrecorded []logs.LogEntry
+	var historyTick <-chan time.Time
+	if historyPath != "" {
+		analyzer.SetTrend(recordLogHistory(historyPath, options, nil))
+		ticker := time.NewTicker(historyInterval)
+		defer ticker.Stop()
+		historyTick = ticker.C
+		defer func() { recordLogHistory(historyPath, options, unrecorded) }()
+	}
+
+	var window []logs.LogEntry
+	var lastSeverity string
+	running := false
+	results := make(chan liveAnalysis, 1)
+
+	// Process streamed logs
+	for {
+		select {
+		case entry, ok := <-logChan:
+			if !ok {
+				return
+			}
+			if redactor != nil {
+				entry = maskLogEntry(entry, redactor)
+			}
+			displayLogEntry(entry)
+			if interval > 0 {
+				window = append(window, entry)
+			}
+			if historyPath != "" {
+				unrecorded = append(unrecorded, entry)
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				return
+			}
+			fmt.Printf("Error: %v\n", err)
+		case <-tick:
+			// Let a slow analysis finish; its window keeps growing meanwhile
+			if running || len(window) == 0 {
+				continue
+			}
+			// Refresh the trend only while no analysis is reading it
+			if historyPath != "" {
+				analyzer.SetTrend(recordLogHistory(historyPath, options, unrecorded))
+				unrecorded = nil
+			}
+			running = true
+			go analyzeWindow(ctx, analyzer, window, errorsOnly, results)
+			window = nil
+		case <-historyTick:
+			if len(unrecorded) > 0 {
+				recordLogHistory(historyPath, options, unrecorded)
+				unrecorded = nil
+			}
+		case analysis := <-results:
+			running = false
+			displayLiveAnalysis(analysis, lastSeverity)
+			if analysis.err == nil {
+				lastSeverity = analysis.result.Severity
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// analyzeWindow analyzes one window of streamed logs and sends the result
+func analyzeWindow(ctx context.Context, analyzer *analyzers.LogAnalyzer, window []logs.LogEntry, errorsOnly bool, results chan<- liveAnalysis) {
+	summary := logs.ParseLogs(window)
+	analysis := liveAnalysis{
+		start:   summary.TimeRange.Start,
+		end:     summary.TimeRange.End,
+		entries: len(window),
+	}
+
+	if errorsOnly {
+		analysis.result, analysis.err = analyzer.AnalyzeErrorLogs(ctx, window)
+	} else {
+		analysis.result, analysis.err = analyzer.AnalyzeLogs(ctx, window, summary)
+	}
+
+	results <- analysis
+}
+
+// displayLiveAnalysis prints the analysis of a streamed window, with an alert if severity rose
+func displayLiveAnalysis(analysis liveAnalysis, lastSeverity string) {
+	// In dry-run mode the window's prompt was shown instead
+	if errors.Is(analysis.err, ai.ErrDryRun) {
+		return
+	}
+	resetColor := color(ansiReset)
+
+	fmt.Printf("\n====== %s: %s - %s (%d) ======\n", i18n.T("AI ANALYSIS"),
+		analysis.start.Format("15:04:05"), analysis.end.Format("15:04:05"), analysis.entries)
+
+	if analysis.err != nil {
+		fmt.Printf("Error analyzing logs: %v\n\n", analysis.err)
+		return
+	}
+
+	result := analysis.result
+	if lastSeverity != "" && analyzers.SeverityRank(result.Severity) > analyzers.SeverityRank(lastSeverity) {
+		fmt.Printf("%sALERT: severity rose from %s to %s%s\n", color(ansiBoldRed), lastSeverity, result.Severity, resetColor)
+	}
+
+	fmt.Printf("%s: %s%s%s\n", i18n.T("Severity"), severityColor(result.Severity), result.Severity, resetColor)
+	fmt.Printf("%s: %s\n", i18n.T("Summary"), result.Summary)
+	if len(result.RootCauses) > 0 {
+		fmt.Printf("%s: %s\n", i18n.T("Root Causes"), result.RootCauses[0].Cause)
+	}
+	if len(result.Solutions) > 0 {
+		fmt.Printf("%s: %s\n", i18n.T("Recommended Solutions"), result.Solutions[0])
+	}
+	fmt.Println()
+}
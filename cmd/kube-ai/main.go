@@ -1,7 +1,6 @@
 package main
 
 import (
-	"log"
 	"os"
 
 	"kube-ai/internal/config"
@@ -9,16 +8,17 @@ import (
 )
 
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
+	// The configuration and AI service are set up by the root command once the flags are
+	// parsed, so that --config can select the configuration file
+	cfg := &config.Config{}
+	aiService := &ai.Service{}
 
-	// Create AI service
-	aiService := ai.NewService(cfg)
-
-	// Create and execute the root command
+	// Create and execute the root command. Commands return their errors, which are rendered
+	// here with an exit code telling usage, Kubernetes and AI provider errors apart.
 	rootCmd := createRootCommand(cfg, aiService)
-	if err := rootCmd.Execute(); err != nil {
-		log.Printf("Error executing command: %v", err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	finishNotification()
+	if err != nil {
+		os.Exit(renderError(os.Stderr, cmd, err))
 	}
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// Sidecar log collection limits: pods read, lines read from each, and lines kept
+const (
+	maxProxyLogPods  = 2
+	proxyLogTail     = 500
+	maxProxyLogLines = 40
+)
+
+// proxyLogProblem matches Envoy log lines worth correlating with the mesh configuration: warnings
+// and errors, and access log entries with a 4xx or 5xx status or a response flag such as NR or UH
+var proxyLogProblem = regexp.MustCompile(`\[(warning|error|critical)\]|" [45]\d\d |\b(NR|UH|UF|UO|URX|NC|UC|UT|LR|DC|UAEX|RL|DPE|UPE|UMSDR)\b`)
+
+// meshReport is the JSON output of analyze-mesh
+type meshReport struct {
+	*k8s.MeshConfig
+	ProxyLogs []string `json:"proxyLogs"`
+	Analysis  string   `json:"analysis"`
+}
+
+// createAnalyzeMeshCmd creates the analyze-mesh command
+func createAnalyzeMeshCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+	var proxyLogs bool
+
+	cmd := &cobra.Command{
+		Use:   "analyze-mesh <resource-type> <resource-name>",
+		Short: "Analyze the Istio configuration of a workload",
+		Long: `Collect the Istio configuration that applies to a workload: its sidecar
+injection status, the services selecting it, and the VirtualServices,
+DestinationRules, Gateways and PeerAuthentications that route or secure its
+traffic.
+
+Routing conflicts, shadowed routes, missing subsets, subsets matching no pods,
+mTLS mismatches and pods missing their sidecar are detected, and correlated with
+errors and response flags in the Envoy sidecar logs. The AI explains what
+traffic they break and how to fix the configuration.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			ctx := context.Background()
+			mesh, err := client.GetMeshConfig(ctx, args[0], args[1], client.GetNamespace())
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+			report := meshReport{MeshConfig: mesh, ProxyLogs: []string{}}
+			if proxyLogs {
+				report.ProxyLogs = collectProxyLogs(ctx, logs.NewLogCollector(client.GetClientset()), mesh)
+			}
+
+			if outputFormat == "text" {
+				displayMeshConfig(mesh, report.ProxyLogs)
+				progressf("\nAnalyzing mesh configuration...\n")
+			}
+			report.Analysis, err = analyzers.AnalyzeMeshConfig(ctx, aiService, mesh, report.ProxyLogs)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(report.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&proxyLogs, "proxy-logs", true, "Correlate with errors and response flags in the Envoy sidecar logs")
+
+	return cmd
+}
+
+// collectProxyLogs returns the problem lines of the recent Envoy sidecar logs of a few of the
+// workload's pods. Pods whose logs cannot be read are skipped.
+func collectProxyLogs(ctx context.Context, collector *logs.LogCollector, mesh *k8s.MeshConfig) []string {
+	lines := []string{}
+	tail := int64(proxyLogTail)
+	read := 0
+	for _, pod := range mesh.Pods {
+		if !pod.Sidecar || read == maxProxyLogPods {
+			continue
+		}
+		read++
+		entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+			Namespace:    mesh.Namespace,
+			ResourceName: pod.Name,
+			Container:    k8s.IstioProxyContainer,
+			TailLines:    &tail,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the sidecar logs of pod %s: %v\n", pod.Name, err)
+			continue
+		}
+		for _, entry := range entries {
+			if proxyLogProblem.MatchString(entry.Content) {
+				lines = append(lines, pod.Name+": "+strings.TrimSpace(entry.Content))
+			}
+		}
+	}
+	if len(lines) > maxProxyLogLines {
+		lines = lines[len(lines)-maxProxyLogLines:]
+	}
+	return lines
+}
+
+// displayMeshConfig prints the sidecar injection status and Istio objects of a workload, the
+// problems detected in them and the problem lines of its sidecar logs
+func displayMeshConfig(mesh *k8s.MeshConfig, proxyLogs []string) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("MESH CONFIGURATION"))
+	fmt.Printf("%s %s/%s\n", mesh.Kind, mesh.Namespace, mesh.Name)
+	sidecars := 0
+	for _, pod := range mesh.Pods {
+		if pod.Sidecar {
+			sidecars++
+		}
+	}
+	fmt.Printf("%-20s %s\n", "Injection:", mesh.NamespaceInjection)
+	fmt.Printf("%-20s %d of %d pods\n", "Sidecars:", sidecars, len(mesh.Pods))
+	fmt.Printf("%-20s %s\n", "mTLS:", mesh.MTLSMode)
+	fmt.Printf("%-20s %s\n", "Services:", orNone(mesh.Services))
+
+	var objects []string
+	for _, group := range [][]k8s.MeshObject{mesh.VirtualServices, mesh.DestinationRules, mesh.Gateways, mesh.PeerAuthentications} {
+		for _, object := range group {
+			objects = append(objects, object.String())
+		}
+	}
+	if len(objects) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Resources"))
+		for _, object := range objects {
+			fmt.Printf("- %s\n", object)
+		}
+	}
+
+	if len(mesh.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range mesh.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+
+	if len(proxyLogs) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Sidecar Logs"))
+		for _, line := range proxyLogs {
+			fmt.Println(line)
+		}
+	}
+}
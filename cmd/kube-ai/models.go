@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/models"
+)
+
+// commandTasks are what AI commands need from a model when model routing is on, by command
+// path; subcommands inherit their parent's needs, and unlisted commands need a medium model
+var commandTasks = map[string]models.Requirements{
+	"chat":            {Tier: models.TierSmall},
+	"explain":         {Tier: models.TierSmall},
+	"explain-field":   {Tier: models.TierSmall},
+	"analyze-logs":    {Tier: models.TierLarge},
+	"upgrade-check":   {Tier: models.TierLarge},
+	"benchmark":       {Tier: models.TierLarge},
+	"bundle":          {Tier: models.TierLarge},
+	"enrich-incident": {Tier: models.TierLarge},
+	"agent":           {Tier: models.TierLarge, Capabilities: []models.Capability{models.CapabilityTools}},
+}
+
+// commandTask returns what a command needs from a model
+func commandTask(command string) models.Requirements {
+	words := strings.Fields(command)
+	for i := len(words); i > 0; i-- {
+		if needs, ok := commandTasks[strings.Join(words[:i], " ")]; ok {
+			return needs
+		}
+	}
+	return models.Requirements{Tier: models.TierMedium}
+}
+
+// createModelsCmd creates the models command
+func createModelsCmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	modelsCmd := &cobra.Command{
+		Use:   "models",
+		Short: "Show the model registry and the models commands are routed to",
+		Long: `Show the model registry: the context window, price and capabilities of known
+models, built in or added under models in the configuration file.
+
+With model routing on (kube-ai config set modelRouting auto), each command uses the
+cheapest model of the provider that is capable enough for its task: small for
+explanations and chat, medium for analyses, large for log analyses, upgrade checks
+and the agent. Route tiers or commands to specific models under routes in the
+configuration file.`,
+	}
+
+	// List the registry
+	var outputFormat string
+	var all bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the known models of the current provider",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider := aiService.GetCurrentProvider()
+			if all {
+				provider = ""
+			}
+			list := aiService.Models().Models(provider)
+			switch outputFormat {
+			case "text":
+				if len(list) == 0 {
+					fmt.Printf("No known models for %s: add them under models in the configuration file\n", provider)
+					return nil
+				}
+				fmt.Printf("%-12s %-28s %-7s %9s %10s %10s  %s\n", "PROVIDER", "MODEL", "TIER", "CONTEXT", "INPUT $/M", "OUTPUT $/M", "CAPABILITIES")
+				for _, model := range list {
+					capabilities := "-"
+					if len(model.Capabilities) > 0 {
+						names := make([]string, 0, len(model.Capabilities))
+						for _, capability := range model.Capabilities {
+							names = append(names, string(capability))
+						}
+						capabilities = strings.Join(names, ",")
+					}
+					fmt.Printf("%-12s %-28s %-7s %9d %10.3f %10.3f  %s\n", model.Provider, model.Name, model.Tier,
+						model.ContextTokens, model.InputCost, model.OutputCost, capabilities)
+				}
+			case "json":
+				if list == nil {
+					list = []models.Model{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(list); err != nil {
+					return fmt.Errorf("error encoding models: %w", err)
+				}
+			default:
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+	listCmd.Flags().BoolVar(&all, "all", false, "List the models of every provider")
+
+	// Show the routes
+	routesCmd := &cobra.Command{
+		Use:   "routes",
+		Short: "Show the model each command is routed to",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cfg.RoutingEnabled() {
+				fmt.Printf("Model routing is off: every command uses %s. Turn it on with:\n", cfg.DefaultModel)
+				fmt.Println("  kubectl ai config set modelRouting auto")
+				fmt.Println("\nRoutes once it is on:")
+			}
+
+			commands := make([]string, 0, len(commandTasks)+1)
+			for command := range commandTasks {
+				commands = append(commands, command)
+			}
+			sort.Strings(commands)
+			commands = append(commands, "(other commands)")
+
+			fmt.Printf("%-18s %-7s %-28s %s\n", "COMMAND", "TIER", "MODEL", "REASON")
+			for _, command := range commands {
+				needs := commandTask(command)
+				model, reason := aiService.Route(command, needs)
+				fmt.Printf("%-18s %-7s %-28s %s\n", command, needs.Tier, model, reason)
+			}
+			return nil
+		},
+	}
+
+	modelsCmd.AddCommand(listCmd)
+	modelsCmd.AddCommand(routesCmd)
+
+	return modelsCmd
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// runMultiClusterLogAnalysis analyzes the same resource in several clusters concurrently
+// and prints a merged comparison report
+func runMultiClusterLogAnalysis(cmd *cobra.Command, aiService *ai.Service, options logs.LogOptions, errorsOnly bool, outputFormat string, chunkTokens int) error {
+	clients, err := k8s.NewClientsFromFlags(cmd)
+	if err != nil {
+		return kubeErrorf("error creating Kubernetes clients: %w", err)
+	}
+
+	contexts := make([]string, 0, len(clients))
+	for _, cc := range clients {
+		contexts = append(contexts, cc.Context)
+	}
+	fmt.Fprintf(progressWriter(outputFormat == "json"), "Analyzing %s/%s across %d clusters: %s\n",
+		options.ResourceType, options.ResourceName, len(clients), strings.Join(contexts, ", "))
+
+	ctx := context.Background()
+	analyzer := analyzers.NewLogAnalyzer(aiService)
+	analyzer.SetChunkTokens(chunkTokens)
+	results := make([]analyzers.ClusterLogAnalysis, len(clients))
+
+	var wg sync.WaitGroup
+	for i, cc := range clients {
+		wg.Add(1)
+		go func(i int, cc k8s.ClusterClient) {
+			defer wg.Done()
+
+			clusterOptions := options
+			clusterOptions.Namespace = cc.Client.GetNamespace()
+			result := analyzers.ClusterLogAnalysis{Cluster: cc.Context, Namespace: clusterOptions.Namespace}
+
+			optOut, err := cc.Client.GetOptOut(ctx, options.ResourceType, options.ResourceName, clusterOptions.Namespace)
+			if err == nil && optOut.IgnoredBy != "" {
+				err = fmt.Errorf("opted out of AI analysis by the %s annotation on %s", k8s.IgnoreAnnotation, optOut.IgnoredBy)
+			}
+			if err != nil {
+				result.Error = err.Error()
+				results[i] = result
+				return
+			}
+
+			collector := logs.NewLogCollector(cc.Client.GetClientset())
+			logEntries, err := collector.GetResourceLogs(ctx, clusterOptions)
+			if err != nil {
+				result.Error = fmt.Sprintf("error collecting logs: %v", err)
+				results[i] = result
+				return
+			}
+			maskLogs(logEntries, logRedactor(optOut))
+
+			summary := logs.ParseLogs(logEntries)
+			result.TotalEntries = summary.TotalEntries
+			result.ErrorCount = summary.ErrorCount
+			result.WarningCount = summary.WarningCount
+
+			if errorsOnly {
+				result.Analysis, err = analyzer.AnalyzeErrorLogs(ctx, logEntries)
+			} else {
+				result.Analysis, err = analyzer.AnalyzeLogs(ctx, logEntries, summary)
+			}
+			if err != nil {
+				result.Error = fmt.Sprintf("error analyzing logs: %v", err)
+			}
+
+			results[i] = result
+		}(i, cc)
+	}
+	wg.Wait()
+
+	report := analyzers.MergeClusterAnalyses(results)
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON output: %w", err)
+		}
+		fmt.Println(string(jsonData))
+	default:
+		displayMultiClusterReport(report)
+	}
+	return nil
+}
+
+// displayMultiClusterReport outputs a multi-cluster comparison in human-readable format
+func displayMultiClusterReport(report *analyzers.MultiClusterReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("CLUSTER COMPARISON"))
+	fmt.Printf("%-30s %-20s %8s %8s %8s  %s\n", "CLUSTER", "NAMESPACE", "ENTRIES", "ERRORS", "WARNINGS", strings.ToUpper(i18n.T("Severity")))
+	for _, result := range report.Clusters {
+		severity := "-"
+		if result.Analysis != nil {
+			severity = result.Analysis.Severity
+		}
+		if result.Error != "" {
+			severity = "failed"
+		}
+		fmt.Printf("%-30s %-20s %8d %8d %8d  %s\n",
+			result.Cluster, result.Namespace, result.TotalEntries, result.ErrorCount, result.WarningCount, severity)
+	}
+
+	for _, result := range report.Clusters {
+		if result.Error != "" {
+			fmt.Printf("\n[%s] %s\n", result.Cluster, result.Error)
+		}
+	}
+
+	if report.Severity != "" {
+		fmt.Printf("\n%s: %s\n", i18n.T("Severity"), report.Severity)
+	}
+
+	for _, result := range report.Clusters {
+		if result.Analysis != nil && result.Analysis.Summary != "" {
+			fmt.Printf("\n=== %s: %s ===\n", i18n.T("Summary"), result.Cluster)
+			fmt.Println(result.Analysis.Summary)
+		}
+	}
+
+	if len(report.RootCauses) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Root Causes"))
+		for i, finding := range report.RootCauses {
+			fmt.Printf("%d. [%s] %s\n", i+1, strings.Join(finding.Clusters, ", "), finding.Text)
+		}
+	}
+
+	if len(report.Solutions) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Recommended Solutions"))
+		for i, finding := range report.Solutions {
+			fmt.Printf("%d. [%s] %s\n", i+1, strings.Join(finding.Clusters, ", "), finding.Text)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// describeLogSource names what analyze-logs collects from across namespaces, for progress messages
+func describeLogSource(options logs.LogOptions, namespaces []string) string {
+	source := options.ResourceType + "/" + options.ResourceName
+	if options.SelectsPods() {
+		source = "pods matching " + options.Selectors()
+	}
+	if len(namespaces) == 0 {
+		return source + " in all namespaces"
+	}
+	if len(namespaces) == 1 {
+		return source + " in namespace " + namespaces[0]
+	}
+	return source + " in namespaces " + strings.Join(namespaces, ", ")
+}
+
+// collectAcrossNamespaces collects the logs of a resource, or of the pods matching selectors, in
+// several namespaces (all namespaces if none are given) and merges them by timestamp. A named
+// resource is skipped in the namespaces it does not exist in.
+func collectAcrossNamespaces(ctx context.Context, client *k8s.Client, collector *logs.LogCollector, options logs.LogOptions, namespaces []string) ([]logs.LogEntry, error) {
+	var entries []logs.LogEntry
+	if options.SelectsPods() && len(namespaces) == 0 {
+		// Selectors list the matching pods of every namespace at once
+		options.Namespace = ""
+		collected, err := collector.GetResourceLogs(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		entries = collected
+	} else {
+		if len(namespaces) == 0 {
+			list, err := client.GetClientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("error listing namespaces: %w", err)
+			}
+			for _, ns := range list.Items {
+				namespaces = append(namespaces, ns.Name)
+			}
+		}
+
+		for _, namespace := range namespaces {
+			options.Namespace = namespace
+			collected, err := collector.GetResourceLogs(ctx, options)
+			if apierrors.IsNotFound(err) && !options.SelectsPods() {
+				continue
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: namespace %s: %v\n", namespace, err)
+				continue
+			}
+			entries = append(entries, collected...)
+		}
+	}
+
+	entries, err := applyPodOptOuts(ctx, client, entries)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no logs found for %s", describeLogSource(options, namespaces))
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// applyPodOptOuts drops the logs of pods whose owners opted them out of AI analysis, on the pod,
+// its workload or its namespace, and masks the logs of those opted out of sending them unmasked.
+// Across namespaces each pod is checked on its own rather than refusing the whole collection.
+func applyPodOptOuts(ctx context.Context, client *k8s.Client, entries []logs.LogEntry) ([]logs.LogEntry, error) {
+	ignored := make(map[string]bool)
+	redactors := make(map[string]*redact.Redactor)
+	kept := entries[:0]
+	for _, entry := range entries {
+		pod := entry.Namespace + "/" + entry.PodName
+		redactor, checked := redactors[pod]
+		if !checked && !ignored[pod] {
+			optOut, err := client.GetOptOut(ctx, "pod", entry.PodName, entry.Namespace)
+			if err != nil {
+				return nil, kubeErrorf("error reading opt-out annotations: %w", err)
+			}
+			if optOut.IgnoredBy != "" {
+				fmt.Fprintf(os.Stderr, "Warning: skipping pod %s, opted out of AI analysis by the %s annotation on %s\n", pod, k8s.IgnoreAnnotation, optOut.IgnoredBy)
+				ignored[pod] = true
+			} else {
+				redactor = logRedactor(optOut)
+				redactors[pod] = redactor
+			}
+		}
+
+		if ignored[pod] {
+			continue
+		}
+		if redactor != nil {
+			entry = maskLogEntry(entry, redactor)
+		}
+		kept = append(kept, entry)
+	}
+	return kept, nil
+}
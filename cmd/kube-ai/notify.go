@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/notify"
+)
+
+// notifyTimeout bounds delivering a command's result to each sink
+const notifyTimeout = 30 * time.Second
+
+// ansiEscape matches the terminal color codes commands print
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// pendingNotification is the running command's output being captured for --notify, if any
+var pendingNotification *notification
+
+// notification captures what a command prints to standard output, while still printing it,
+// so it can be delivered to sinks once the command succeeds
+type notification struct {
+	sinks map[string]notify.Sink
+	// Sink names in the order given
+	names []string
+
+	stdout *os.File
+	writer *os.File
+	done   chan *bytes.Buffer
+
+	// Set by commands that assess a severity or produce a structured result
+	severity string
+	result   interface{}
+}
+
+// startNotification creates the named sinks and starts capturing standard output
+func startNotification(cfg *config.Config, names []string) error {
+	n := &notification{sinks: make(map[string]notify.Sink), names: names}
+	for _, name := range names {
+		sinkConfig, err := cfg.GetSink(name)
+		if err != nil {
+			return usageErrorf("--notify: %w", err)
+		}
+		sink, err := notify.NewSink(sinkConfig)
+		if err != nil {
+			return fmt.Errorf("sink '%s': %w", name, err)
+		}
+		n.sinks[name] = sink
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("error capturing output for --notify: %w", err)
+	}
+	n.stdout, n.writer = os.Stdout, writer
+	n.done = make(chan *bytes.Buffer, 1)
+	go func(stdout *os.File) {
+		var buf bytes.Buffer
+		io.Copy(io.MultiWriter(stdout, &buf), reader)
+		reader.Close()
+		n.done <- &buf
+	}(os.Stdout)
+
+	os.Stdout = writer
+	pendingNotification = n
+	return nil
+}
+
+// stop restores standard output and returns what the command printed, without colors
+func (n *notification) stop() string {
+	os.Stdout = n.stdout
+	n.writer.Close()
+	return ansiEscape.ReplaceAllString((<-n.done).String(), "")
+}
+
+// recordResult attaches a command's severity and structured result to its notification, if
+// --notify is set
+func recordResult(severity string, result interface{}) {
+	if pendingNotification != nil {
+		pendingNotification.severity = severity
+		pendingNotification.result = result
+	}
+}
+
+// sendNotification delivers the output of a command that succeeded to the sinks named with
+// --notify. Every sink is tried; the errors of those that fail are returned together.
+func sendNotification(cmd *cobra.Command, args []string) error {
+	n := pendingNotification
+	if n == nil {
+		return nil
+	}
+	pendingNotification = nil
+	text := n.stop()
+
+	message := notify.Notification{
+		Title:    strings.Join(append([]string{cmd.CommandPath()}, args...), " "),
+		Command:  commandKey(cmd),
+		Severity: n.severity,
+		Text:     text,
+		Result:   n.result,
+		Time:     time.Now(),
+	}
+	if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+		message.Context, message.Namespace = k8s.CurrentContext(clientConfig)
+	}
+
+	var failures []string
+	for _, name := range n.names {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		err := n.sinks[name].Send(ctx, message)
+		cancel()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Sent the result to %s\n", name)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("error notifying %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// finishNotification restores standard output when a command failed while its output was
+// captured; nothing is sent for failed commands
+func finishNotification() {
+	if pendingNotification != nil {
+		pendingNotification.stop()
+		pendingNotification = nil
+	}
+}
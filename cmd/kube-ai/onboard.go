@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s"
+)
+
+// onboardTimeout bounds each check of the onboarding wizard: detecting Ollama and reaching the
+// cluster
+const onboardTimeout = 5 * time.Second
+
+// onboardProvider is an AI provider offered by the onboarding wizard, with the settings it needs
+type onboardProvider struct {
+	name        string
+	description string
+	// Settings of its server URL and API key, if it has them
+	urlKey    string
+	apiKeyKey string
+	// Whether it cannot work without an API key
+	keyRequired bool
+}
+
+// onboardProviders are the providers the onboarding wizard offers, in order
+var onboardProviders = []onboardProvider{
+	{name: "ollama", description: "models run locally by Ollama; nothing leaves this machine", urlKey: "ollamaUrl"},
+	{name: "openai", description: "OpenAI API, with an API key", apiKeyKey: "openaiApiKey", keyRequired: true},
+	{name: "anthropic", description: "Anthropic API, with an API key", apiKeyKey: "anthropicApiKey", keyRequired: true},
+	{name: "gemini", description: "Google Gemini API, with an API key", apiKeyKey: "geminiApiKey", keyRequired: true},
+	{name: "anythingllm", description: "AnythingLLM server", urlKey: "anythingLlmUrl"},
+	{name: "llamacpp", description: "llama.cpp server (llama-server)", urlKey: "llamaCppUrl"},
+	{name: "openai-compatible", description: "server with an OpenAI-compatible API, such as vLLM, LocalAI or LM Studio", urlKey: "openaiCompatibleUrl", apiKeyKey: "openaiCompatibleApiKey"},
+}
+
+// createOnboardCmd creates the onboard command
+func createOnboardCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "onboard",
+		Short: "Set up kube-ai step by step",
+		Long: `Set up kube-ai interactively: detect a local Ollama server and its models,
+choose the AI provider, its URL or API key and the model, offer to encrypt the
+API key with a passphrase, write the configuration file, and check that the
+current kubeconfig context reaches the cluster with the permissions log
+analysis needs.
+
+Press Enter to keep the default shown in brackets. Run it again at any time to
+change the provider.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfg.Stateless() {
+				return usageErrorf("onboard writes the configuration file, which %s disables", config.StatelessEnv)
+			}
+			w := &wizard{scanner: bufio.NewScanner(os.Stdin)}
+
+			fmt.Printf("Welcome to kube-ai. Answers are saved to %s.\n\n", cfg.Path())
+
+			// Offer Ollama first when it runs, since it keeps cluster data on this machine
+			ollamaModels, ollamaErr := detectOllama(cfg.OllamaURL)
+			defaultProvider := cfg.AIProvider
+			if ollamaErr == nil {
+				fmt.Printf("Found Ollama at %s with %d models.\n", cfg.OllamaURL, len(ollamaModels))
+				defaultProvider = "ollama"
+			} else {
+				fmt.Printf("No Ollama server at %s.\n", cfg.OllamaURL)
+			}
+
+			provider, err := w.chooseProvider(defaultProvider)
+			if err != nil {
+				return err
+			}
+			values := map[string]string{"aiProvider": provider.name}
+
+			if provider.urlKey != "" {
+				current := cfg.GetProviderURL(provider.name)
+				url, err := w.ask(fmt.Sprintf("%s URL", provider.name), current)
+				if err != nil {
+					return err
+				}
+				values[provider.urlKey] = url
+				if provider.name == "ollama" && url != cfg.OllamaURL {
+					ollamaModels, ollamaErr = detectOllama(url)
+				}
+				if provider.name == "ollama" && ollamaErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Ollama does not answer at %s: %v\n", url, ollamaErr)
+				}
+			}
+
+			apiKey := ""
+			if provider.apiKeyKey != "" {
+				current := cfg.GetAPIKey(provider.name)
+				label := fmt.Sprintf("%s API key", provider.name)
+				if current != "" {
+					label += " (Enter keeps the current one)"
+				} else if !provider.keyRequired {
+					label += " (Enter for none)"
+				}
+				if apiKey, err = w.askSecret(label); err != nil {
+					return err
+				}
+				switch {
+				case apiKey != "":
+					values[provider.apiKeyKey] = apiKey
+				case current == "" && provider.keyRequired:
+					return usageErrorf("%s needs an API key", provider.name)
+				}
+			}
+
+			defaultModel := config.DefaultModelFor(provider.name)
+			if cfg.AIProvider == provider.name && cfg.DefaultModel != "" {
+				defaultModel = cfg.DefaultModel
+			}
+			if provider.name == "ollama" && len(ollamaModels) > 0 {
+				fmt.Printf("Models pulled in Ollama: %s\n", strings.Join(ollamaModels, ", "))
+				if !slices.Contains(ollamaModels, defaultModel) {
+					defaultModel = ollamaModels[0]
+				}
+			}
+			model, err := w.ask("Model", defaultModel)
+			if err != nil {
+				return err
+			}
+			values["defaultModel"] = model
+
+			// API keys can only be encrypted when the passphrase can be typed or is in the environment
+			encrypt := false
+			canEncrypt := term.IsTerminal(int(os.Stdin.Fd())) || os.Getenv(config.PassphraseEnv) != ""
+			if apiKey != "" && !cfg.Encrypted() && canEncrypt {
+				if encrypt, err = w.confirm("Encrypt API keys in the configuration file with a passphrase?", true); err != nil {
+					return err
+				}
+			}
+
+			for _, key := range []string{"aiProvider", provider.urlKey, provider.apiKeyKey, "defaultModel"} {
+				value, ok := values[key]
+				if !ok {
+					continue
+				}
+				warning, err := cfg.Stage(key, value)
+				if err != nil {
+					return err
+				}
+				if warning != "" {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+				}
+			}
+			if encrypt {
+				err = cfg.EnableEncryption(config.EncryptionPassphrase)
+			} else {
+				err = cfg.SaveConfig()
+			}
+			if err != nil {
+				return fmt.Errorf("error saving configuration: %w", err)
+			}
+			fmt.Printf("\nSaved to %s\n", cfg.Path())
+			if apiKey != "" && !cfg.Encrypted() {
+				fmt.Println("The API key is stored in cleartext; run 'kubectl ai config encrypt' to encrypt it.")
+			}
+
+			checkClusterAccess(cmd)
+
+			fmt.Println("\nAll set. Try 'kubectl ai summarize namespace' or 'kubectl ai analyze-logs deployment <name>'.")
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// detectOllama returns the models of the Ollama server at url, failing when none answers
+func detectOllama(url string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), onboardTimeout)
+	defer cancel()
+	return providers.OllamaModels(ctx, url)
+}
+
+// checkClusterAccess reports whether the current context reaches its cluster and has the
+// permissions of log analysis. Problems are reported, not returned: kube-ai still works on files.
+func checkClusterAccess(cmd *cobra.Command) {
+	fmt.Println("\nChecking cluster access...")
+	client, err := k8s.NewClientFromFlags(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no usable kubeconfig: %v\n", err)
+		return
+	}
+	contextName, namespace := "", client.GetNamespace()
+	if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+		contextName, _ = k8s.CurrentContext(clientConfig)
+	}
+
+	version, err := client.GetClientset().Discovery().ServerVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot reach the cluster of context %s: %v\n", contextName, err)
+		return
+	}
+	fmt.Printf("Reached context %s (Kubernetes %s)\n", contextName, version.GitVersion)
+
+	ctx, cancel := context.WithTimeout(context.Background(), onboardTimeout)
+	defer cancel()
+	var permErr *k8s.PermissionError
+	switch err := client.CheckFeatureAccess(ctx, "analyze-logs", namespace); {
+	case errors.As(err, &permErr):
+		fmt.Fprintf(os.Stderr, "Warning: %v\nRun 'kubectl ai rbac for-self --features analyze-logs --namespaced' for the RBAC a cluster administrator can apply.\n", permErr)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: could not check permissions: %v\n", err)
+	default:
+		fmt.Printf("Log analysis is allowed in namespace %s\n", namespace)
+	}
+}
+
+// suggestOnboarding points new users at the onboarding wizard while no configuration file
+// exists, on a terminal only so that scripts see no extra output
+func suggestOnboarding(cmd *cobra.Command, cfg *config.Config) {
+	if cfg.Stateless() || cmd.Name() == "onboard" || os.Getenv("AI_PROVIDER") != "" || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return
+	}
+	if _, err := os.Stat(cfg.Path()); errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "No kube-ai configuration yet: run 'kubectl ai onboard' to choose an AI provider.")
+	}
+}
+
+// wizard asks the questions of the onboarding wizard on standard input
+type wizard struct {
+	scanner *bufio.Scanner
+}
+
+// ask asks a question, returning the answer or the default for an empty answer
+func (w *wizard) ask(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	if !w.scanner.Scan() {
+		fmt.Println()
+		return "", fmt.Errorf("onboarding cancelled")
+	}
+	answer := strings.TrimSpace(w.scanner.Text())
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// askSecret asks for a secret, without echoing it on a terminal
+func (w *wizard) askSecret(question string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return w.ask(question, "")
+	}
+	fmt.Printf("%s: ", question)
+	secret, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", question, err)
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// confirm asks a yes or no question
+func (w *wizard) confirm(question string, defaultYes bool) (bool, error) {
+	choices := "y/N"
+	if defaultYes {
+		choices = "Y/n"
+	}
+	answer, err := w.ask(fmt.Sprintf("%s [%s]", question, choices), "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return defaultYes, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// chooseProvider lists the providers and asks for one, by number or name
+func (w *wizard) chooseProvider(defaultName string) (onboardProvider, error) {
+	fmt.Println("\nAI providers:")
+	for i, provider := range onboardProviders {
+		fmt.Printf("%2d) %-18s %s\n", i+1, provider.name, provider.description)
+	}
+	for {
+		answer, err := w.ask("Provider", defaultName)
+		if err != nil {
+			return onboardProvider{}, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(onboardProviders) {
+			return onboardProviders[n-1], nil
+		}
+		for _, provider := range onboardProviders {
+			if strings.EqualFold(provider.name, answer) {
+				return provider, nil
+			}
+		}
+		fmt.Printf("Unknown provider %q; enter a number or a name from the list.\n", answer)
+	}
+}
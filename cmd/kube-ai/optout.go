@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// checkOptOut refuses to analyze a workload its owners opted out of with the kube-ai.io/ignore
+// annotation, on the workload or its namespace, and returns its other opt-outs
+func checkOptOut(ctx context.Context, client *k8s.Client, resourceType, name, namespace string) (k8s.OptOut, error) {
+	optOut, err := client.GetOptOut(ctx, resourceType, name, namespace)
+	if err != nil {
+		return optOut, kubeErrorf("error reading opt-out annotations: %w", err)
+	}
+	if optOut.IgnoredBy != "" {
+		return optOut, usageErrorf("%s/%s is opted out of AI analysis by the %s annotation on %s", resourceType, name, k8s.IgnoreAnnotation, optOut.IgnoredBy)
+	}
+	return optOut, nil
+}
+
+// logRedactor returns the redactor masking the logs of a workload opted out with the
+// kube-ai.io/redact-logs annotation, or nil when its logs are sent as collected
+func logRedactor(optOut k8s.OptOut) *redact.Redactor {
+	if optOut.RedactLogsBy == "" {
+		return nil
+	}
+	redactor, _ := redact.New(redact.PolicyStrict, nil)
+	return redactor
+}
+
+// maskLogs redacts the content and structured data of log entries in place
+func maskLogs(entries []logs.LogEntry, redactor *redact.Redactor) {
+	if redactor == nil {
+		return
+	}
+	for i := range entries {
+		entries[i] = maskLogEntry(entries[i], redactor)
+	}
+}
+
+// maskLogEntry returns a log entry with its content and structured data redacted
+func maskLogEntry(entry logs.LogEntry, redactor *redact.Redactor) logs.LogEntry {
+	entry.Content = redactor.Redact(entry.Content)
+	if entry.Data != nil {
+		data := make(map[string]string, len(entry.Data))
+		for key, value := range entry.Data {
+			data[key] = redactor.Redact(value)
+		}
+		entry.Data = data
+	}
+	return entry
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// clipboardCommands are the commands that copy their standard input to the clipboard, by
+// platform, in order of preference
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip.exe"}},
+	"linux":   {{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}, {"clip.exe"}},
+}
+
+// manifestOutput is where the YAML a command generates goes besides standard output: a file, a
+// directory with one file per resource, and the clipboard
+type manifestOutput struct {
+	file string
+	dir  string
+	copy bool
+}
+
+// addFlags adds --out, --out-dir and --copy to a command
+func (o *manifestOutput) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.file, "out", "", "Write the manifest to this file instead of printing it")
+	cmd.Flags().StringVar(&o.dir, "out-dir", "", "Write the manifest to this directory instead of printing it, one file per resource")
+	cmd.Flags().BoolVar(&o.copy, "copy", false, "Also copy the manifest to the clipboard")
+}
+
+// validate checks that the flags do not name two destinations
+func (o manifestOutput) validate() error {
+	if o.file != "" && o.dir != "" {
+		return usageErrorf("--out and --out-dir cannot be combined")
+	}
+	return nil
+}
+
+// deliver copies content to the clipboard if asked, then writes it to the output file or
+// directory. It returns false when neither was requested, so the caller prints it.
+func (o manifestOutput) deliver(content string) (bool, error) {
+	o.toClipboard(content)
+	switch {
+	case o.dir != "":
+		documents, err := manifest.Parse([]byte(content))
+		if err != nil {
+			return false, err
+		}
+		if len(documents) == 0 {
+			return false, fmt.Errorf("no Kubernetes resources to write to %s", o.dir)
+		}
+		written, err := manifest.WriteStack(o.dir, "", manifest.LayoutPlain, documents)
+		for _, path := range written {
+			fmt.Printf("Wrote %s\n", path)
+		}
+		return true, err
+	case o.file != "":
+		if err := os.WriteFile(o.file, []byte(strings.TrimSpace(content)+"\n"), 0644); err != nil {
+			return false, fmt.Errorf("error writing manifest: %w", err)
+		}
+		fmt.Printf("Manifest written to %s\n", o.file)
+		return true, nil
+	}
+	return false, nil
+}
+
+// toClipboard copies content to the clipboard if --copy is set. Failing to is only a warning:
+// the manifest is still printed or written.
+func (o manifestOutput) toClipboard(content string) {
+	if !o.copy {
+		return
+	}
+	if err := copyToClipboard(strings.TrimSpace(content) + "\n"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	progressf("Copied the manifest to the clipboard\n")
+}
+
+// copyToClipboard copies text to the clipboard with the first clipboard command installed
+func copyToClipboard(text string) error {
+	var tried []string
+	for _, command := range clipboardCommands[runtime.GOOS] {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			tried = append(tried, command[0])
+			continue
+		}
+		copyCmd := exec.Command(command[0], command[1:]...)
+		copyCmd.Stdin = strings.NewReader(text)
+		if output, err := copyCmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("error copying to the clipboard with %s: %v %s", command[0], err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	if len(tried) == 0 {
+		return fmt.Errorf("copying to the clipboard is not supported on %s", runtime.GOOS)
+	}
+	return fmt.Errorf("cannot copy to the clipboard: none of %s is installed", strings.Join(tried, ", "))
+}
+
+// outputFlagAliases keeps the earlier names of the output flags of generate working
+func outputFlagAliases(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	switch name {
+	case "output-file":
+		name = "out"
+	case "output-dir":
+		name = "out-dir"
+	}
+	return pflag.NormalizedName(name)
+}
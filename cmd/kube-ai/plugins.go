@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai/analyzers"
+)
+
+// createPluginsCmd creates the plugins command
+func createPluginsCmd() *cobra.Command {
+	pluginsCmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Manage external analyzer plugins",
+		Long: `External analyzer plugins add checks to kube-ai analyze without forking it, such
+as proprietary policies. A plugin is any executable named kube-ai-analyzer-<name>
+on PATH. It receives the analyzed input as JSON on standard input:
+
+  {"apiVersion": "kube-ai/v1", "kind": "AnalyzerInput", "source": "deployment/web",
+   "namespace": "default", "context": "prod", "manifest": "<YAML>"}
+
+and writes its findings as JSON to standard output:
+
+  {"findings": [{"title": "...", "severity": "High", "category": "security",
+    "resource": "Deployment/web", "field": "spec.template.spec.containers[0].image",
+    "description": "...", "recommendation": "..."}]}
+
+A non-zero exit status fails the analysis, with what the plugin wrote to standard
+error as the reason.`,
+	}
+
+	// List plugins
+	var outputFormat string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the analyzer plugins found on PATH",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins := analyzers.DiscoverPlugins()
+			switch outputFormat {
+			case "text":
+				if len(plugins) == 0 {
+					fmt.Printf("No analyzer plugins found: add %s<name> executables to PATH\n", analyzers.PluginPrefix)
+					return nil
+				}
+				for _, plugin := range plugins {
+					fmt.Printf("%-25s %s\n", plugin.Name, plugin.Path)
+				}
+			case "json":
+				if plugins == nil {
+					plugins = []analyzers.Plugin{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(plugins); err != nil {
+					return fmt.Errorf("error encoding plugins: %w", err)
+				}
+			default:
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text or json)")
+
+	pluginsCmd.AddCommand(listCmd)
+
+	return pluginsCmd
+}
+
+// runPlugins runs analyzer plugins on an input and returns their findings. A failing plugin is
+// an error, so checks a team relies on are never silently skipped.
+func runPlugins(plugins []analyzers.Plugin, input analyzers.PluginInput) ([]analyzers.ManifestFinding, error) {
+	var findings []analyzers.ManifestFinding
+	for _, plugin := range plugins {
+		pluginFindings, err := plugin.Run(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, pluginFindings...)
+	}
+	return findings, nil
+}
+
+// printPluginFindings prints the findings of analyzer plugins after a text analysis
+func printPluginFindings(findings []analyzers.ManifestFinding) {
+	fmt.Println("\nPlugin findings:")
+	if len(findings) == 0 {
+		fmt.Println("  No issues found")
+		return
+	}
+	for _, finding := range findings {
+		fmt.Printf("- [%s] %s (%s", finding.Severity, finding.Title, finding.Source)
+		if finding.Resource != "" {
+			fmt.Printf(", %s", finding.Resource)
+		}
+		fmt.Println(")")
+		if finding.Description != "" {
+			fmt.Printf("  %s\n", finding.Description)
+		}
+		if finding.Recommendation != "" {
+			fmt.Printf("  Recommendation: %s\n", finding.Recommendation)
+		}
+	}
+}
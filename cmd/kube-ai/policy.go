@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// maxPolicyFixes limits how often an invalid generated policy is sent back to be fixed
+const maxPolicyFixes = 2
+
+// createGeneratePolicyCmd creates the generate policy command
+func createGeneratePolicyCmd(aiService *ai.Service) *cobra.Command {
+	var engine string
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "policy <intent>",
+		Short: "Generate a Kyverno or Gatekeeper admission policy",
+		Long: `Generate an admission policy from a requirement, such as
+"disallow privileged pods except in kube-system": a Kyverno ClusterPolicy, or with
+--engine gatekeeper an OPA Gatekeeper ConstraintTemplate and constraint.
+
+The policy is checked before it is output: the kinds and fields the engine needs,
+and for Gatekeeper the structure of the Rego. When the check fails, the AI is
+asked to fix the problems, and the command fails if they remain.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if engine != manifest.EngineKyverno && engine != manifest.EngineGatekeeper {
+				return usageErrorf("unsupported policy engine %q, use %s", engine, strings.Join(manifest.Engines, " or "))
+			}
+			intent := strings.Join(args, " ")
+
+			fmt.Fprintln(os.Stderr, "Generating policy...")
+			response, err := aiService.GeneratePolicy(intent, engine)
+			if err != nil {
+				return fmt.Errorf("error generating policy: %w", err)
+			}
+			content := extractYAML(response)
+
+			problems := checkPolicy(content, engine)
+			for attempt := 0; len(problems) > 0 && attempt < maxPolicyFixes; attempt++ {
+				fmt.Fprintf(os.Stderr, "Fixing %d problem(s) in the policy...\n", len(problems))
+				response, err = aiService.RefineManifest(intent, content, nil, problems, nil)
+				if err != nil {
+					return fmt.Errorf("error fixing policy: %w", err)
+				}
+				content = extractYAML(response)
+				problems = checkPolicy(content, engine)
+			}
+			if len(problems) > 0 {
+				fmt.Println(content)
+				for _, problem := range problems {
+					fmt.Fprintf(os.Stderr, "- %s\n", problem)
+				}
+				return fmt.Errorf("the generated policy is not valid")
+			}
+
+			if outputFile == "" {
+				fmt.Println(content)
+				return nil
+			}
+			if err := os.WriteFile(outputFile, []byte(content+"\n"), 0644); err != nil {
+				return fmt.Errorf("error writing policy: %w", err)
+			}
+			fmt.Printf("Policy written to %s\n", outputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&engine, "engine", manifest.EngineKyverno, "Policy engine: "+strings.Join(manifest.Engines, " or "))
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "Write the policy to this file")
+
+	return cmd
+}
+
+// checkPolicy parses a generated policy and checks it for the engine
+func checkPolicy(content, engine string) []string {
+	documents, err := manifest.Parse([]byte(content))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return manifest.CheckPolicy(documents, engine)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/k8s"
+)
+
+// ProfileEnv is the environment variable naming the configuration profile of kube-ai commands,
+// overriding the profile bound to the target context or namespace
+const ProfileEnv = "KUBE_AI_PROFILE"
+
+// createProfileCmd creates the profile command
+func createProfileCmd(cfg *config.Config) *cobra.Command {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage configuration profiles",
+		Long: `Manage named configuration profiles: an AI provider and model, persona,
+temperature and redaction policy applied together. A profile bound to kube
+contexts or namespaces is applied automatically whenever a command targets them,
+for example a strict-redaction, local Ollama profile for every prod-* context.
+The --profile flag or the KUBE_AI_PROFILE environment variable selects a
+profile explicitly.`,
+	}
+
+	// List profiles
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List configuration profiles",
+		Long:  "Display the configuration profiles and the contexts and namespaces they are bound to",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := cfg.ProfileNames()
+			if len(names) == 0 {
+				fmt.Println("No profiles configured. Create one with: kubectl ai profile set <name>")
+				return nil
+			}
+
+			active, _, _, _ := selectProfile(cmd, cfg)
+			fmt.Printf("  %-20s %-12s %-25s %-10s %s\n", "NAME", "PROVIDER", "MODEL", "REDACTION", "BOUND TO")
+			for _, name := range names {
+				profile := cfg.Profiles[name]
+				marker := " "
+				if name == active {
+					marker = "*"
+				}
+				fmt.Printf("%s %-20s %-12s %-25s %-10s %s\n", marker, name, valueOr(profile.Provider, "-"),
+					valueOr(profile.Model, "-"), valueOr(profile.Redaction, redact.PolicyOff), describeBindings(profile))
+			}
+			fmt.Println("\n* = profile applied to the current context and namespace")
+			return nil
+		},
+	}
+
+	// Show a profile
+	showCmd := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show a configuration profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, ok := cfg.Profiles[args[0]]
+			if !ok {
+				return fmt.Errorf("profile '%s' not found", args[0])
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(profile); err != nil {
+				return fmt.Errorf("error encoding profile: %w", err)
+			}
+			return nil
+		},
+	}
+
+	// Create or update a profile
+	var profile config.Profile
+	var temperature float32
+	setCmd := &cobra.Command{
+		Use:   "set [name]",
+		Short: "Create or update a configuration profile",
+		Long: `Create a configuration profile, or update the settings of an existing one given
+as flags. --bind-context and --bind-namespace take names or glob patterns and can be
+repeated; a profile bound to both only applies where both match. When several
+profiles match, one bound to the namespace wins over one bound to the context.
+
+Example:
+  kubectl ai profile set prod --provider ollama --model llama3 --redaction strict --bind-context 'prod-*'`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			updated := cfg.Profiles[name]
+			flags := cmd.Flags()
+
+			if flags.Changed("provider") {
+				updated.Provider = strings.ToLower(profile.Provider)
+				if updated.Provider != "" && !validProvider(updated.Provider) {
+					return fmt.Errorf("unknown provider %q", updated.Provider)
+				}
+			}
+			if flags.Changed("model") {
+				updated.Model = profile.Model
+			}
+			if flags.Changed("persona") {
+				updated.Persona = profile.Persona
+			}
+			if flags.Changed("temperature") {
+				updated.Temperature = &temperature
+			}
+			if flags.Changed("redaction") {
+				if _, err := redact.New(profile.Redaction, nil); err != nil {
+					return err
+				}
+				updated.Redaction = profile.Redaction
+			}
+			if flags.Changed("bind-context") {
+				updated.Contexts = profile.Contexts
+			}
+			if flags.Changed("bind-namespace") {
+				updated.Namespaces = profile.Namespaces
+			}
+
+			if err := cfg.SetProfile(name, updated); err != nil {
+				return err
+			}
+			fmt.Printf("Saved profile: %s\n", name)
+			return nil
+		},
+	}
+	setCmd.Flags().StringVar(&profile.Provider, "provider", "", "AI provider: "+strings.Join(providerNames(), ", "))
+	setCmd.Flags().StringVar(&profile.Model, "model", "", "Model name (default: the provider's configured or default model)")
+	setCmd.Flags().StringVar(&profile.Persona, "persona", "", "Persona to use")
+	setCmd.Flags().Float32Var(&temperature, "temperature", 0, "Sampling temperature (0-2) for every AI request")
+	setCmd.Flags().StringVar(&profile.Redaction, "redaction", "", "Redaction policy for prompts: "+strings.Join(redact.Policies, ", "))
+	setCmd.Flags().StringArrayVar(&profile.Contexts, "bind-context", nil, "Bind to kube contexts matching a name or glob pattern (repeatable)")
+	setCmd.Flags().StringArrayVar(&profile.Namespaces, "bind-namespace", nil, "Bind to namespaces matching a name or glob pattern (repeatable)")
+
+	// Remove a profile
+	removeCmd := &cobra.Command{
+		Use:   "remove [name]",
+		Short: "Remove a configuration profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.RemoveProfile(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed profile: %s\n", args[0])
+			return nil
+		},
+	}
+
+	// Show the profile applied to the current context and namespace
+	currentCmd := &cobra.Command{
+		Use:   "current",
+		Short: "Show the profile applied to the current context and namespace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, selected, reason, err := selectProfile(cmd, cfg)
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				fmt.Printf("No profile applies: %s\n", reason)
+				fmt.Printf("Provider: %s, redaction: %s\n", cfg.AIProvider, redact.PolicyOff)
+				return nil
+			}
+			fmt.Printf("Profile: %s (%s)\n", name, reason)
+			fmt.Printf("Provider: %s\n", valueOr(selected.Provider, cfg.AIProvider))
+			fmt.Printf("Model: %s\n", valueOr(selected.Model, "provider default"))
+			fmt.Printf("Persona: %s\n", valueOr(selected.Persona, cfg.ActivePersona))
+			if selected.Temperature != nil {
+				fmt.Printf("Temperature: %.2f\n", *selected.Temperature)
+			}
+			fmt.Printf("Redaction: %s\n", valueOr(selected.Redaction, redact.PolicyOff))
+			return nil
+		},
+	}
+
+	profileCmd.AddCommand(listCmd)
+	profileCmd.AddCommand(showCmd)
+	profileCmd.AddCommand(setCmd)
+	profileCmd.AddCommand(removeCmd)
+	profileCmd.AddCommand(currentCmd)
+
+	return profileCmd
+}
+
+// selectProfile returns the profile applying to a command: the one named with --profile or
+// KUBE_AI_PROFILE, else the one bound to the target context and namespace. The reason explains
+// the choice.
+func selectProfile(cmd *cobra.Command, cfg *config.Config) (string, config.Profile, string, error) {
+	name, _ := cmd.Flags().GetString("profile")
+	source := "--profile"
+	if name == "" {
+		name, source = os.Getenv(ProfileEnv), ProfileEnv
+	}
+	if name != "" {
+		profile, ok := cfg.Profiles[name]
+		if !ok {
+			return "", config.Profile{}, "", fmt.Errorf("profile '%s' from %s not found", name, source)
+		}
+		return name, profile, "selected with " + source, nil
+	}
+
+	var contextName, namespace string
+	if clientConfig, err := k8s.GetClientConfigFromFlags(cmd); err == nil {
+		contextName, namespace = k8s.CurrentContext(clientConfig)
+	}
+	name, profile, ok := cfg.ResolveProfile(contextName, namespace)
+	if !ok {
+		return "", config.Profile{}, fmt.Sprintf("none is bound to context %q, namespace %q", contextName, namespace), nil
+	}
+	return name, profile, fmt.Sprintf("bound to context %q, namespace %q", contextName, namespace), nil
+}
+
+// applyProfile applies the profile selected for a command to the AI service. An unknown or
+// invalid profile is an error rather than a silent fallback, since the fallback might send
+// cluster data to a different provider without redaction.
+func applyProfile(cmd *cobra.Command, cfg *config.Config, aiService *ai.Service) error {
+	name, profile, _, err := selectProfile(cmd, cfg)
+	if err != nil || name == "" {
+		return err
+	}
+	return aiService.ApplyProfile(name, profile)
+}
+
+// isProfileCmd reports whether a command belongs to the profile command, which must keep working
+// when a profile is broken
+func isProfileCmd(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "profile" && c.Parent() != nil && !c.Parent().HasParent() {
+			return true
+		}
+	}
+	return false
+}
+
+// describeBindings summarizes the contexts and namespaces a profile is bound to
+func describeBindings(profile config.Profile) string {
+	var bindings []string
+	if len(profile.Contexts) > 0 {
+		bindings = append(bindings, "contexts "+strings.Join(profile.Contexts, ","))
+	}
+	if len(profile.Namespaces) > 0 {
+		bindings = append(bindings, "namespaces "+strings.Join(profile.Namespaces, ","))
+	}
+	if len(bindings) == 0 {
+		return "-"
+	}
+	return strings.Join(bindings, "; ")
+}
+
+// validProvider reports whether a provider name is supported
+func validProvider(name string) bool {
+	for _, providerType := range providers.GetProviderTypes() {
+		if string(providerType) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// providerNames returns the names of the supported providers
+func providerNames() []string {
+	var names []string
+	for _, providerType := range providers.GetProviderTypes() {
+		names = append(names, string(providerType))
+	}
+	return names
+}
+
+// valueOr returns value, or fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"kube-ai/pkg/progress"
+)
+
+// quietOutput is set by --quiet: standard output only carries the result of the command, and
+// progress messages go to standard error
+var quietOutput bool
+
+// requestSpinner returns the function called when a prompt is sent to the AI provider, which
+// draws a spinner on terminals until the answer arrives
+var requestSpinner = func() func() (done func()) {
+	return progress.NewSpinner(os.Stderr, "Waiting for the AI provider").Start
+}
+
+// progressWriter returns where progress messages go: standard error with --quiet or when the
+// result is machine-readable, such as JSON, and standard output otherwise
+func progressWriter(machineReadable bool) io.Writer {
+	if quietOutput || machineReadable {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// progressf prints a progress message, such as "Collecting logs...", that is not part of the
+// result
+func progressf(format string, args ...interface{}) {
+	fmt.Fprintf(progressWriter(false), format, args...)
+}
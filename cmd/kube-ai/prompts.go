@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+)
+
+// createPromptsCmd creates the prompts command group
+func createPromptsCmd(aiService *ai.Service) *cobra.Command {
+	promptsCmd := &cobra.Command{
+		Use:   "prompts",
+		Short: "Manage prompt templates",
+		Long: `Manage the Go text/template files used to build AI prompts.
+
+Any <name>.tmpl file in ~/.kube-ai/prompts overrides the built-in template of the
+same name, so teams can tune language, tone and constraints without forking.
+Templates can use {{.Persona.ID}}, {{.Persona.Description}}, {{.Cluster.Context}}
+and {{.Cluster.Namespace}} in addition to their task-specific variables.`,
+	}
+
+	// List prompt templates
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List prompt templates",
+		Long:  "Display all prompt templates and whether they are overridden",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Printf("Prompt templates (overrides in %s):\n", aiService.GetPromptRenderer().Dir())
+			fmt.Println("-------------------")
+
+			for _, name := range prompts.Names() {
+				_, overridden, err := aiService.GetPromptRenderer().Source(name)
+				source := "built-in"
+				switch {
+				case err != nil:
+					source = fmt.Sprintf("error: %v", err)
+				case overridden:
+					source = aiService.GetPromptRenderer().Path(name)
+				}
+				fmt.Printf("%s: %s\n", name, source)
+			}
+			return nil
+		},
+	}
+
+	// Show a prompt template
+	showCmd := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show a prompt template",
+		Long:  "Print the template text in effect, including any override",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text, _, err := aiService.GetPromptRenderer().Source(args[0])
+			if err != nil {
+				return fmt.Errorf("error loading prompt template: %w", err)
+			}
+			fmt.Print(text)
+			return nil
+		},
+	}
+
+	// Write the built-in templates for editing
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Copy the built-in templates into the override directory",
+		Long:  "Write the built-in templates to ~/.kube-ai/prompts for editing. Existing files are left untouched.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			written, err := aiService.GetPromptRenderer().WriteDefaults()
+			if err != nil {
+				return fmt.Errorf("error writing prompt templates: %w", err)
+			}
+
+			if len(written) == 0 {
+				fmt.Printf("All prompt templates already exist in %s\n", aiService.GetPromptRenderer().Dir())
+				return nil
+			}
+			for _, path := range written {
+				fmt.Printf("Wrote %s\n", path)
+			}
+			return nil
+		},
+	}
+
+	promptsCmd.AddCommand(listCmd)
+	promptsCmd.AddCommand(showCmd)
+	promptsCmd.AddCommand(initCmd)
+
+	return promptsCmd
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/k8s"
+)
+
+// createRBACCmd creates the rbac command group
+func createRBACCmd() *cobra.Command {
+	rbacCmd := &cobra.Command{
+		Use:   "rbac",
+		Short: "Generate minimal RBAC for kube-ai",
+		Long:  "Generate RBAC manifests granting exactly the read-only permissions kube-ai features need",
+	}
+
+	var features string
+	var name string
+	var subject string
+	var namespaced bool
+	var out manifestOutput
+
+	forSelfCmd := &cobra.Command{
+		Use:   "for-self",
+		Short: "Generate a minimal role for the current user",
+		Long: `Generate a Role or ClusterRole (plus binding) that grants only the permissions
+required by the selected kube-ai features. The binding subject defaults to the
+identity of the current credentials.
+
+Subjects can be a user name, "group:<name>" or "serviceaccount:<namespace>:<name>".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := out.validate(); err != nil {
+				return err
+			}
+			options := k8s.RBACOptions{
+				Name:     name,
+				Features: k8s.FeatureNames(),
+				Subject:  subject,
+			}
+
+			if features != "" {
+				options.Features = strings.Split(features, ",")
+			}
+
+			// Only contact the cluster when we need the namespace or the caller's identity
+			if namespaced || subject == "" {
+				client, err := k8s.NewClientFromFlags(cmd)
+				if err != nil {
+					return kubeErrorf("error creating Kubernetes client: %w", err)
+				}
+
+				if namespaced {
+					options.Namespace = client.GetNamespace()
+				}
+
+				if subject == "" {
+					username, err := client.WhoAmI(context.Background())
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: %v; generating role without a binding\n", err)
+					} else {
+						options.Subject = username
+					}
+				}
+			}
+
+			manifest, err := k8s.GenerateRBACManifest(options)
+			if err != nil {
+				return fmt.Errorf("error generating RBAC manifest: %w", err)
+			}
+
+			saved, err := out.deliver(manifest)
+			if err != nil || saved {
+				return err
+			}
+			fmt.Print(manifest)
+			return nil
+		},
+	}
+
+	forSelfCmd.Flags().StringVar(&features, "features", "", fmt.Sprintf("Comma-separated features to grant (default: all of %s)", strings.Join(k8s.FeatureNames(), ", ")))
+	forSelfCmd.Flags().StringVar(&name, "name", "kube-ai-readonly", "Name of the generated role and binding")
+	forSelfCmd.Flags().StringVar(&subject, "subject", "", "Subject to bind (defaults to the current user)")
+	forSelfCmd.Flags().BoolVar(&namespaced, "namespaced", false, "Generate a namespaced Role in the current namespace instead of a ClusterRole")
+	out.addFlags(forSelfCmd)
+
+	rbacCmd.AddCommand(forSelfCmd)
+
+	return rbacCmd
+}
+
+// checkAccess checks that the caller has every permission a feature needs in a namespace ("" for
+// all namespaces) before any data is collected, so missing permissions are reported up front
+// with the RBAC to request instead of as a Forbidden error half way through. When the access
+// reviews themselves fail, the command carries on with a warning.
+func checkAccess(client *k8s.Client, feature, namespace string) error {
+	ctx := context.Background()
+	err := client.CheckFeatureAccess(ctx, feature, namespace)
+	if err == nil {
+		return nil
+	}
+
+	var permErr *k8s.PermissionError
+	if !errors.As(err, &permErr) {
+		fmt.Fprintf(os.Stderr, "Warning: %v; continuing without checking permissions\n", err)
+		return nil
+	}
+
+	options := k8s.RBACOptions{
+		Name:        "kube-ai-" + feature,
+		Namespace:   namespace,
+		Permissions: permErr.Missing,
+	}
+	// A Role cannot grant access to cluster-scoped resources
+	for _, perm := range permErr.Missing {
+		if k8s.IsClusterScopedResource(perm.Resource) {
+			options.Namespace = ""
+		}
+	}
+	if username, err := client.WhoAmI(ctx); err == nil {
+		options.Subject = username
+	}
+
+	manifest, err := k8s.GenerateRBACManifest(options)
+	if err != nil {
+		return kubeErrorf("%w", permErr)
+	}
+	return kubeErrorf("%w\n\nAsk a cluster administrator to apply RBAC such as:\n\n%s", permErr, manifest)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/i18n"
+)
+
+// redactTestResult is the JSON output of redact-test
+type redactTestResult struct {
+	Policy   string         `json:"policy"`
+	Patterns []string       `json:"patterns,omitempty"`
+	Redacted string         `json:"redacted"`
+	Matches  []redact.Match `json:"matches"`
+}
+
+// createRedactTestCmd creates the redact-test command
+func createRedactTestCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+	var policy string
+	var patterns []string
+
+	cmd := &cobra.Command{
+		Use:   "redact-test [file]",
+		Short: "Preview what redaction would scrub from a file or standard input",
+		Long: `Preview what would be scrubbed from text before it is sent to the AI provider.
+The text is read from the file, or from standard input when no file or - is
+given, and redacted with the policy of the profile that applies (or --policy)
+and the patterns of the redact list of the configuration. Patterns are regular
+expressions, or field paths such as spec.containers[].env[?name=~'.*TOKEN']
+whose values are masked in YAML and JSON documents. Try new ones with --pattern
+before adding them to the configuration.
+
+The redacted text is printed, followed by each value masked and the rule or
+pattern that masked it. Nothing is sent to the AI provider.
+
+Example:
+  kubectl get deployment web -o yaml | kubectl ai redact-test --pattern "spec.containers[].env[?name=~'.*TOKEN']"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if !cmd.Flags().Changed("policy") {
+				policy = aiService.GetRedactionPolicy()
+			}
+			patterns = append(append([]string{}, aiService.GetRedactionPatterns()...), patterns...)
+			redactor, err := redact.New(policy, patterns)
+			if err != nil {
+				return usageErrorf("%w", err)
+			}
+
+			var data []byte
+			if len(args) == 0 || args[0] == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return usageErrorf("error reading input: %w", err)
+			}
+
+			result := redactTestResult{Policy: redactor.Policy(), Patterns: patterns}
+			result.Redacted, result.Matches = redactor.Preview(string(data))
+			if result.Matches == nil {
+				result.Matches = []redact.Match{}
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(result); err != nil {
+					return fmt.Errorf("error encoding result: %w", err)
+				}
+				return nil
+			}
+			displayRedactTest(result)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().StringVar(&policy, "policy", "", "Redaction policy to preview instead of the applied profile's: "+strings.Join(redact.Policies, ", "))
+	cmd.Flags().StringArrayVar(&patterns, "pattern", nil, "Regular expression or field path to try on top of the configured ones (repeatable)")
+
+	return cmd
+}
+
+// displayRedactTest prints the redacted text with the masks highlighted, then each masked value
+func displayRedactTest(result redactTestResult) {
+	fmt.Printf("Policy: %s", result.Policy)
+	if len(result.Patterns) > 0 {
+		fmt.Printf(", %d custom patterns", len(result.Patterns))
+	}
+	fmt.Println()
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("REDACTED TEXT"))
+	fmt.Println(strings.ReplaceAll(strings.TrimRight(result.Redacted, "\n"), redact.Mask, severityColor("High")+redact.Mask+color(ansiReset)))
+
+	fmt.Printf("\n=== %s ===\n", i18n.T("Redactions"))
+	if len(result.Matches) == 0 {
+		fmt.Println("Nothing would be redacted")
+		return
+	}
+	for _, match := range result.Matches {
+		fmt.Printf("%-40s %s\n", match.Rule, truncateLine(match.Value, 80))
+	}
+	fmt.Printf("\n%d values would be redacted\n", len(result.Matches))
+}
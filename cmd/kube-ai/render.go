@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ANSI codes of the terminal colors and styles commands print
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiRed     = "\033[31m"
+	ansiBoldRed = "\033[1;31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiBlue    = "\033[34m"
+	ansiCyan    = "\033[36m"
+)
+
+// colorOutput is whether commands print colors and render the Markdown of AI answers
+var colorOutput bool
+
+// markdownHeading, markdownBold and markdownCode match the Markdown that AI answers commonly use
+var (
+	markdownHeading = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	markdownBold    = regexp.MustCompile(`\*\*([^*\n]+)\*\*|__([^_\n]+)__`)
+	markdownCode    = regexp.MustCompile("`([^`\n]+)`")
+)
+
+// setColorOutput turns colors on when standard output is a terminal, unless --no-color is given,
+// NO_COLOR is set (https://no-color.org) or the terminal is dumb
+func setColorOutput(noColor bool) {
+	colorOutput = !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" &&
+		term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// color returns an ANSI code, or "" when colors are off
+func color(code string) string {
+	if !colorOutput {
+		return ""
+	}
+	return code
+}
+
+// printMarkdown prints an AI answer, rendering its Markdown headings, emphasis and code blocks
+// on terminals and leaving it as written otherwise, so files and pipes get the Markdown
+func printMarkdown(text string) {
+	fmt.Println(renderMarkdown(text))
+}
+
+// renderMarkdown renders the Markdown of text with terminal styles when colors are on
+func renderMarkdown(text string) string {
+	if !colorOutput {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	rendered := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		// Code blocks are indented and colored, without their fences
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			rendered = append(rendered, "    "+ansiCyan+line+ansiReset)
+			continue
+		}
+
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			rendered = append(rendered, ansiBold+markdownBold.ReplaceAllString(match[1], "$1$2")+ansiReset)
+			continue
+		}
+		line = markdownBold.ReplaceAllString(line, ansiBold+"$1$2"+ansiReset)
+		line = markdownCode.ReplaceAllString(line, ansiCyan+"$1"+ansiReset)
+		rendered = append(rendered, line)
+	}
+	return strings.Join(rendered, "\n")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// rolloutRiskReport is the JSON output of rollout-risk
+type rolloutRiskReport struct {
+	*k8s.RolloutProfile
+	Risks      []string `json:"risks"`
+	Assessment string   `json:"assessment"`
+}
+
+// createRolloutRiskCmd creates the rollout-risk command
+func createRolloutRiskCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "rollout-risk <resource-type> <resource-name>",
+		Short: "Assess the rollout and disruption risk of a workload",
+		Long: `Check how a deployment, statefulset or daemonset behaves during rollouts, node
+drains, cluster upgrades and spot reclaims: its replicas, maxUnavailable and
+maxSurge, PodDisruptionBudgets, topology spread, anti-affinity, readiness probes
+and where its pods run.
+
+The AI rates the risk, such as a single replica with no PodDisruptionBudget on a
+spot node pool, and suggests concrete spec changes.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			ctx := context.Background()
+			profile, err := client.GetRolloutProfile(ctx, args[0], args[1], client.GetNamespace())
+			if err != nil {
+				return err
+			}
+			report := rolloutRiskReport{RolloutProfile: profile, Risks: profile.Risks()}
+
+			if outputFormat == "text" {
+				displayRolloutProfile(profile, report.Risks)
+				progressf("\nAssessing rollout risk...\n")
+			}
+			report.Assessment, err = analyzers.AssessRolloutRisk(ctx, aiService, profile, report.Risks)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				if report.Risks == nil {
+					report.Risks = []string{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("RISK ASSESSMENT"))
+			printMarkdown(report.Assessment)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayRolloutProfile prints the rollout settings and pod placement of a workload and the
+// risks detected in them
+func displayRolloutProfile(profile *k8s.RolloutProfile, risks []string) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("ROLLOUT PROFILE"))
+	fmt.Printf("%s %s/%s\n", profile.Kind, profile.Namespace, profile.Name)
+	fmt.Printf("%-20s %d desired, %d ready\n", "Replicas:", profile.Replicas, profile.ReadyReplicas)
+	if profile.Autoscaler != "" {
+		fmt.Printf("%-20s %s\n", "Autoscaler:", profile.Autoscaler)
+	}
+	strategy := profile.Strategy
+	if profile.MaxUnavailable != "" {
+		strategy += fmt.Sprintf(" (maxUnavailable %s = %d, maxSurge %s = %d)",
+			profile.MaxUnavailable, profile.MaxUnavailablePods, profile.MaxSurge, profile.MaxSurgePods)
+	}
+	fmt.Printf("%-20s %s\n", "Strategy:", strategy)
+	fmt.Printf("%-20s %s\n", "Topology spread:", orNone(profile.TopologySpread))
+	fmt.Printf("%-20s %s\n", "Anti-affinity:", orNone(profile.AntiAffinity))
+
+	budgets := make([]string, 0, len(profile.Budgets))
+	for _, budget := range profile.Budgets {
+		budgets = append(budgets, fmt.Sprintf("%s (%d disruptions allowed)", budget.Name, budget.DisruptionsAllowed))
+	}
+	fmt.Printf("%-20s %s\n", "Disruption budgets:", orNone(budgets))
+
+	if len(profile.Placement) > 0 {
+		fmt.Printf("\n%-40s %-30s %-16s %s\n", "POD", "NODE", "ZONE", "POOL")
+		for _, placement := range profile.Placement {
+			pool := placement.Pool
+			if placement.Spot {
+				pool += " (spot)"
+			}
+			fmt.Printf("%-40s %-30s %-16s %s\n", placement.Pod, placement.Node, placement.Zone, pool)
+		}
+	}
+
+	if len(risks) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, risk := range risks {
+			fmt.Printf("- %s\n", risk)
+		}
+	}
+}
+
+// orNone joins values for display, or returns none when there are none
+func orNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, "; ")
+}
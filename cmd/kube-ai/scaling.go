@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// Autoscaler log collection limits: pods read per autoscaler, lines read from each, and lines kept
+// per autoscaler
+const (
+	maxScalingLogPods  = 2
+	scalingLogTail     = 2000
+	maxScalingLogLines = 40
+)
+
+// scalingLogDecision matches cluster-autoscaler and Karpenter log lines about scale-up and
+// scale-down decisions, consolidation and disruption, launched and removed nodes, and failures
+var scalingLogDecision = regexp.MustCompile(`(?i)scale.?up|scale.?down|unschedulable|could not schedule|incompatible|no expansion|max (node group|cluster) size|backoff|unregistered|removing|consolidat|disrupt|launched|deleted node|insufficient|error|failed`)
+
+// scalingReport is the JSON output of explain-scaling-events
+type scalingReport struct {
+	*k8s.ScalingReport
+	Analysis string `json:"analysis"`
+}
+
+// createExplainScalingEventsCmd creates the explain-scaling-events command
+func createExplainScalingEventsCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "explain-scaling-events",
+		Short: "Explain why pods stayed Pending or nodes were removed by the cluster autoscaler or Karpenter",
+		Long: `Explain the decisions of the node autoscaler in plain language. The command finds
+the cluster-autoscaler or Karpenter deployment and collects, over the --since
+window, its scaling decisions and errors from its logs, the cluster-autoscaler
+status ConfigMap, Karpenter NodePools and the NodeClaims that did not become
+ready nodes, nodes that are not ready, and the scale-up, scale-down,
+consolidation and node events. Pending pods are taken from the namespace (or all
+namespaces with -A).
+
+The AI explains why each pending pod got no new node and why nodes were removed,
+and recommends changes to requests, limits and disruption settings.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			window, err := logs.ParseDuration(since)
+			if err != nil {
+				return usageErrorf("invalid --since: %w", err)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			namespace := client.GetNamespace()
+			if client.IsAllNamespaces() {
+				namespace = ""
+			}
+
+			ctx := context.Background()
+			report, err := client.GetScalingReport(ctx, namespace, time.Now().Add(-window))
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+			collectScalingLogs(ctx, logs.NewLogCollector(client.GetClientset()), report, window)
+
+			if outputFormat == "text" {
+				displayScalingReport(report)
+				progressf("\nExplaining scaling decisions...\n")
+			}
+			output := scalingReport{ScalingReport: report}
+			output.Analysis, err = analyzers.ExplainScalingEvents(ctx, aiService, report)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(output); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(output.Analysis)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().StringVar(&since, "since", "1h", "Explain the scaling events of this past window, like 30m, 2h or 1d")
+
+	return cmd
+}
+
+// collectScalingLogs reads the scaling decisions and errors the autoscalers logged in the window.
+// Pods whose logs cannot be read are skipped.
+func collectScalingLogs(ctx context.Context, collector *logs.LogCollector, report *k8s.ScalingReport, window time.Duration) {
+	tail := int64(scalingLogTail)
+	seconds := int64(window.Seconds())
+	for i := range report.Autoscalers {
+		autoscaler := &report.Autoscalers[i]
+		for j, pod := range autoscaler.Pods {
+			if j == maxScalingLogPods {
+				break
+			}
+			options := logs.LogOptions{Namespace: autoscaler.Namespace, ResourceName: pod, TailLines: &tail}
+			if seconds > 0 {
+				options.SinceSeconds = &seconds
+			}
+			entries, err := collector.GetPodLogs(ctx, options)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not read the logs of pod %s/%s: %v\n", autoscaler.Namespace, pod, err)
+				continue
+			}
+			for _, entry := range entries {
+				if scalingLogDecision.MatchString(entry.Content) {
+					autoscaler.Logs = append(autoscaler.Logs, strings.TrimSpace(entry.Content))
+				}
+			}
+		}
+		if len(autoscaler.Logs) > maxScalingLogLines {
+			autoscaler.Logs = autoscaler.Logs[len(autoscaler.Logs)-maxScalingLogLines:]
+		}
+	}
+}
+
+// displayScalingReport prints the autoscalers, Karpenter's node pools, the pending pods, the
+// scaling events and the problems found
+func displayScalingReport(report *k8s.ScalingReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("NODE AUTOSCALING"))
+	fmt.Printf("%-14s %s\n", "Since:", report.Since.Format("2006-01-02 15:04:05"))
+	if len(report.Autoscalers) == 0 {
+		fmt.Printf("%-14s %s\n", "Autoscaler:", "none")
+	}
+	for _, autoscaler := range report.Autoscalers {
+		fmt.Printf("%-14s %s %s/%s, %d/%d ready\n", "Autoscaler:", autoscaler.Kind, autoscaler.Namespace, autoscaler.Name, autoscaler.Ready, autoscaler.Desired)
+	}
+	for _, pool := range report.NodePools {
+		fmt.Printf("%-14s %s, ready=%s, %d nodes\n", "NodePool:", pool.Name, pool.Ready, pool.Nodes)
+	}
+
+	if len(report.PendingPods) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Pending Pods"))
+		for _, pod := range report.PendingPods {
+			fmt.Printf("%-50s %-10s %s\n", pod.Namespace+"/"+pod.Name, pod.Age, pod.Message)
+		}
+	}
+
+	if len(report.Events) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Scaling Events"))
+		for _, event := range report.Events {
+			fmt.Printf("%s %-24s %-40s %s\n", event.Time.Format("15:04:05"), event.Reason, event.Object, event.Message)
+		}
+	}
+
+	if len(report.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range report.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+}
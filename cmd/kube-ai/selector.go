@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/k8s"
+)
+
+// maxSelectedObjects is how many objects a selector can target in one run, to keep the prompt and
+// the number of AI requests bounded
+const maxSelectedObjects = 20
+
+// selectorOptions selects the objects a command acts on by label and field, as kubectl does,
+// instead of naming each of them
+type selectorOptions struct {
+	label string
+	field string
+}
+
+// addFlags adds --selector (-l) and --field-selector to a command, describing what they select
+func (o *selectorOptions) addFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().StringVarP(&o.label, "selector", "l", "", fmt.Sprintf("Select the %s matching this label selector instead of naming one, such as app.kubernetes.io/part-of=checkout", what))
+	cmd.Flags().StringVar(&o.field, "field-selector", "", fmt.Sprintf("Select the %s matching this field selector instead of naming one, such as metadata.namespace!=default", what))
+}
+
+// set reports whether either selector was given
+func (o selectorOptions) set() bool {
+	return o.label != "" || o.field != ""
+}
+
+// String describes the selectors for messages, such as app=web,status.phase=Running
+func (o selectorOptions) String() string {
+	var selectors []string
+	for _, selector := range []string{o.label, o.field} {
+		if selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	return strings.Join(selectors, ",")
+}
+
+// selectorArgs validates the positional arguments of a command with the named validator, or with
+// the selected one when --selector or --field-selector selects the objects instead
+func selectorArgs(named, selected cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("selector") || cmd.Flags().Changed("field-selector") {
+			return selected(cmd, args)
+		}
+		return named(cmd, args)
+	}
+}
+
+// selectObjects lists the objects of a resource type the selectors match, in the namespace of
+// the client or in every namespace with --all-namespaces. Beyond maxSelectedObjects the rest are
+// left out with a warning.
+func (o selectorOptions) selectObjects(ctx context.Context, client *k8s.Client, resource string) ([]k8s.SelectedObject, error) {
+	objects, err := client.SelectObjects(ctx, k8s.ResourceQuery{
+		Resource:      resource,
+		AllNamespaces: client.IsAllNamespaces(),
+		LabelSelector: o.label,
+		FieldSelector: o.field,
+	})
+	if err != nil {
+		return nil, kubeErrorf("%w", err)
+	}
+	if len(objects) == 0 {
+		return nil, kubeErrorf("no %s found matching %s", resource, o)
+	}
+	if len(objects) > maxSelectedObjects {
+		fmt.Fprintf(os.Stderr, "Warning: %d %s match %s; only the first %d are used, narrow the selector to include the rest\n", len(objects), resource, o, maxSelectedObjects)
+		objects = objects[:maxSelectedObjects]
+	}
+	return objects, nil
+}
+
+// selectedManifests returns the objects of a resource type the selectors match as one
+// multi-document manifest, leaving out those opted out of AI analysis
+func selectedManifests(ctx context.Context, client *k8s.Client, selection selectorOptions, resourceType string) (string, error) {
+	objects, err := selection.selectObjects(ctx, client, resourceType)
+	if err != nil {
+		return "", err
+	}
+
+	var documents []string
+	for _, object := range objects {
+		optOut, err := client.GetOptOut(ctx, resourceType, object.Name, object.Namespace)
+		if err != nil {
+			return "", kubeErrorf("error reading opt-out annotations: %w", err)
+		}
+		if optOut.IgnoredBy != "" {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s, opted out of AI analysis by the %s annotation on %s\n", object, k8s.IgnoreAnnotation, optOut.IgnoredBy)
+			continue
+		}
+		document, err := client.GetResourceYAML(ctx, resourceType, object.Name, object.Namespace)
+		if err != nil {
+			return "", kubeErrorf("error getting resource: %w", err)
+		}
+		documents = append(documents, strings.TrimSuffix(document, "\n")+"\n")
+	}
+	if len(documents) == 0 {
+		return "", usageErrorf("every %s matching %s is opted out of AI analysis", resourceType, selection)
+	}
+	return strings.Join(documents, "---\n"), nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"kube-ai/pkg/k8s"
+)
+
+// displaySkippedSources lists the optional data sources a command went without, such as
+// metrics-server or a CRD the cluster does not serve, so gaps in the report are not mistaken
+// for healthy signals
+func displaySkippedSources(w io.Writer, skipped []k8s.SkippedSource) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nSkipped data sources (the report does not cover them):")
+	for _, source := range skipped {
+		fmt.Fprintf(w, "  - %s: %s\n", source.Source, source.Reason)
+	}
+}
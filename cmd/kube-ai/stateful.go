@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// Member log collection limits: pods read, lines read from each, and lines kept from each
+const (
+	maxStatefulLogPods  = 5
+	statefulLogTail     = 500
+	maxStatefulLogLines = 15
+)
+
+// statefulReport is the JSON output of diagnose statefulset
+type statefulReport struct {
+	*k8s.StatefulReport
+	Analysis string `json:"analysis"`
+}
+
+// createDiagnoseStatefulCmd creates the diagnose statefulset command
+func createDiagnoseStatefulCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+	var selection selectorOptions
+
+	cmd := &cobra.Command{
+		Use:     "statefulset <name>",
+		Aliases: []string{"sts", "stateful"},
+		Short:   "Troubleshoot a StatefulSet running a database or other clustered data store",
+		Long: `Troubleshoot a StatefulSet as a clustered data store. For each ordinal, the
+command collects the pod, its volume claims and their binding events, and whether
+the headless service publishes its DNS record. It also reads the rollout state
+(pod management policy, partition, revisions) and the operator managing the
+StatefulSet, and scans member logs for quorum, leader election, replication and
+storage errors of the engine it runs: etcd, ZooKeeper, Kafka, Postgres, MySQL,
+MongoDB, Redis, Elasticsearch, RabbitMQ or Cassandra.
+
+The AI explains the failure with stateful semantics in mind, such as ordered
+rollout and quorum, and proposes recovery steps in a safe order.
+
+--selector (-l) and --field-selector troubleshoot every StatefulSet they match
+in place of a named one, one after the other, with --all-namespaces (-A) across
+namespaces. With --output json the reports are printed as a list.`,
+		Args: selectorArgs(cobra.ExactArgs(1), cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			ctx := context.Background()
+			if !selection.set() {
+				output, err := diagnoseStatefulSet(ctx, client, aiService, client.GetNamespace(), args[0], outputFormat)
+				if err != nil {
+					return err
+				}
+				return displayStatefulOutput(output, outputFormat)
+			}
+
+			statefulSets, err := selection.selectObjects(ctx, client, "statefulsets")
+			if err != nil {
+				return err
+			}
+			outputs := make([]statefulReport, 0, len(statefulSets))
+			for _, statefulSet := range statefulSets {
+				if outputFormat == "text" {
+					fmt.Printf("\n====== StatefulSet %s ======\n", statefulSet)
+				}
+				output, err := diagnoseStatefulSet(ctx, client, aiService, statefulSet.Namespace, statefulSet.Name, outputFormat)
+				if err != nil {
+					return err
+				}
+				if outputFormat == "text" {
+					displayStatefulOutput(output, outputFormat)
+				}
+				outputs = append(outputs, *output)
+			}
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(outputs); err != nil {
+					return fmt.Errorf("error encoding reports: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	selection.addFlags(cmd, "StatefulSets")
+
+	return cmd
+}
+
+// diagnoseStatefulSet collects the report of a StatefulSet and has the AI troubleshoot it. The
+// report is printed as it is collected for text output.
+func diagnoseStatefulSet(ctx context.Context, client *k8s.Client, aiService *ai.Service, namespace, name, outputFormat string) (*statefulReport, error) {
+	report, err := client.GetStatefulReport(ctx, namespace, name)
+	if err != nil {
+		return nil, kubeErrorf("%w", err)
+	}
+	collectStatefulLogs(ctx, logs.NewLogCollector(client.GetClientset()), report)
+	if pods, _, err := client.GetWorkloadPods(ctx, "statefulset", report.Name, report.Namespace); err == nil {
+		report.Usage = collectResourceUsage(ctx, client, pods)
+		report.Findings = append(report.Findings, k8s.UsageFindings(report.Usage)...)
+	}
+
+	if outputFormat == "text" {
+		displayStatefulReport(report)
+		progressf("\nTroubleshooting...\n")
+	}
+	output := &statefulReport{StatefulReport: report}
+	output.Analysis, err = analyzers.TroubleshootStateful(ctx, aiService, report)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// displayStatefulOutput prints the AI's analysis of a StatefulSet, or its whole report as JSON
+func displayStatefulOutput(output *statefulReport, outputFormat string) error {
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("error encoding report: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+	printMarkdown(output.Analysis)
+	return nil
+}
+
+// collectStatefulLogs reads the quorum, replication and storage errors in the recent logs of the
+// StatefulSet's members. Pods whose logs cannot be read are skipped.
+func collectStatefulLogs(ctx context.Context, collector *logs.LogCollector, report *k8s.StatefulReport) {
+	pattern := report.LogPattern()
+	tail := int64(statefulLogTail)
+	read := 0
+	for i := range report.Pods {
+		pod := &report.Pods[i]
+		if pod.Missing || pod.Node == "" || read == maxStatefulLogPods {
+			continue
+		}
+		read++
+		entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+			Namespace:    report.Namespace,
+			ResourceName: pod.Name,
+			TailLines:    &tail,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the logs of pod %s: %v\n", pod.Name, err)
+			continue
+		}
+		for _, entry := range entries {
+			if pattern.MatchString(entry.Content) {
+				pod.Logs = append(pod.Logs, strings.TrimSpace(entry.Content))
+			}
+		}
+		if len(pod.Logs) > maxStatefulLogLines {
+			pod.Logs = pod.Logs[len(pod.Logs)-maxStatefulLogLines:]
+		}
+	}
+}
+
+// displayStatefulReport prints the rollout of a StatefulSet, each member with its claims and DNS
+// record, and the problems found
+func displayStatefulReport(report *k8s.StatefulReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("STATEFULSET"))
+	fmt.Printf("%s/%s\n", report.Namespace, report.Name)
+	if report.Engine != "" {
+		fmt.Printf("%-16s %s\n", "Engine:", report.Engine)
+	}
+	if report.Operator != "" {
+		fmt.Printf("%-16s %s\n", "Operator:", report.Operator)
+	}
+	fmt.Printf("%-16s %d/%d ready\n", "Replicas:", report.ReadyReplicas, report.Replicas)
+	rollout := report.PodManagementPolicy + ", " + report.UpdateStrategy
+	if report.Partition > 0 {
+		rollout += fmt.Sprintf(" (partition %d)", report.Partition)
+	}
+	fmt.Printf("%-16s %s\n", "Rollout:", rollout)
+	service := report.ServiceName
+	switch {
+	case report.ServiceMissing:
+		service += " (missing)"
+	case !report.ServiceHeadless:
+		service += " (not headless)"
+	}
+	fmt.Printf("%-16s %s\n", "Service:", service)
+
+	fmt.Println()
+	fmt.Printf("%-30s %-10s %-8s %-8s %-10s %s\n", "POD", "PHASE", "READY", "DNS", "RESTARTS", "CLAIMS")
+	for _, pod := range report.Pods {
+		phase := pod.Phase
+		if pod.Missing {
+			phase = "Missing"
+		}
+		dns := "no"
+		if pod.DNSPublished {
+			dns = "yes"
+		}
+		var claims []string
+		for _, claim := range pod.Claims {
+			claims = append(claims, claim.Name+"="+claim.Phase)
+		}
+		fmt.Printf("%-30s %-10s %-8t %-8s %-10d %s\n", pod.Name, phase, pod.Ready, dns, pod.Restarts, strings.Join(claims, ","))
+		for _, line := range pod.Logs {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	displayResourceUsage(report.Usage)
+
+	if len(report.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range report.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+}
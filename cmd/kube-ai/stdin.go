@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/k8s/logs"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// inputKind is what kubectl output, or other text, an input holds
+type inputKind int
+
+const (
+	// An error message or other free text
+	inputText inputKind = iota
+	// Objects in YAML or JSON, such as kubectl get -o yaml prints
+	inputManifest
+	// kubectl describe output
+	inputDescribe
+	// kubectl logs output
+	inputLogs
+)
+
+// String names an input kind for messages
+func (k inputKind) String() string {
+	switch k {
+	case inputManifest:
+		return "a manifest"
+	case inputDescribe:
+		return "kubectl describe output"
+	case inputLogs:
+		return "logs"
+	default:
+		return "text"
+	}
+}
+
+var (
+	// describeField matches the fields kubectl describe starts its sections with
+	describeField = regexp.MustCompile(`(?m)^(Namespace|Labels|Annotations|Status|Events|Conditions|Containers|Selector|Type):`)
+	// logLine matches the start of a log line: a timestamp, a level or a JSON object
+	logLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}|\[?\d{2}:\d{2}:\d{2}|[IWEF]\d{4} \d{2}:|\{"|time=|ts=|level=|\[?(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL)\b)`)
+)
+
+// detectInput tells what an input holds, such as piped kubectl output, from its content
+func detectInput(data string) inputKind {
+	text := strings.TrimSpace(data)
+	if text == "" {
+		return inputText
+	}
+
+	if strings.HasPrefix(text, "Name:") && len(describeField.FindAllString(text, 3)) >= 2 {
+		return inputDescribe
+	}
+	if strings.Contains(text, "apiVersion") {
+		if documents, err := manifest.Parse([]byte(text)); err == nil {
+			for _, document := range documents {
+				if document.Kind != "" {
+					return inputManifest
+				}
+			}
+		}
+	}
+
+	var lines, logLines int
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		lines++
+		if logLine.MatchString(line) {
+			logLines++
+		}
+	}
+	if lines >= 2 && logLines*2 >= lines {
+		return inputLogs
+	}
+	return inputText
+}
+
+// readInput reads a file, or standard input for -
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// explainDescribe explains the state of a resource from its kubectl describe output
+func explainDescribe(aiService *ai.Service, output string) error {
+	result, err := aiService.ExplainDescribe(output)
+	if err != nil {
+		return fmt.Errorf("error explaining describe output: %w", err)
+	}
+	printMarkdown(result)
+	return nil
+}
+
+// analyzeLogText analyzes logs given as text, such as piped kubectl logs output, as analyze-logs
+// does the logs it collects
+func analyzeLogText(aiService *ai.Service, text string) error {
+	entries := logs.ParseLogText(text, "", "")
+	summary := logs.ParseLogs(entries)
+
+	analyzer := analyzers.NewLogAnalyzer(aiService)
+	analyzer.SetProgress(progressWriter(false))
+	progressf("Analyzing %d log entries...\n", len(entries))
+	analysis, err := analyzer.AnalyzeLogs(context.Background(), entries, summary)
+	if err != nil {
+		return fmt.Errorf("error analyzing logs: %w", err)
+	}
+
+	displayFormattedResults(summary, analysis)
+	recordResult(analysis.Severity, analysis)
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// namespaceSummaryReport is the JSON output of summarize namespace
+type namespaceSummaryReport struct {
+	*k8s.NamespaceSummary
+	Narrative string `json:"narrative"`
+}
+
+// createSummarizeCmd creates the summarize command
+func createSummarizeCmd(aiService *ai.Service) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "summarize",
+		Short: "Summarize what runs in part of the cluster",
+	}
+
+	cmd.AddCommand(createSummarizeNamespaceCmd(aiService))
+
+	return cmd
+}
+
+// createSummarizeNamespaceCmd creates the summarize namespace command
+func createSummarizeNamespaceCmd(aiService *ai.Service) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "namespace [name]",
+		Short: "Give a short narrative overview of a namespace",
+		Long: `List the workloads with their images, pods, services, ingresses, resource
+quotas and warning events of the last 24 hours in a namespace (the current one
+by default), and have the AI tell in a few paragraphs what the namespace
+appears to run, how healthy it is and its notable risks, such as failing
+workloads, services without endpoints, hosts without TLS or exhausted quotas.
+
+Meant for engineers onboarding to an unfamiliar namespace.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+			namespace := client.GetNamespace()
+			if len(args) == 1 {
+				namespace = args[0]
+			}
+			if err := checkAccess(client, "summarize", namespace); err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			summary, err := client.GetNamespaceSummary(ctx, namespace)
+			if err != nil {
+				return kubeErrorf("%w", err)
+			}
+
+			if outputFormat == "text" {
+				displayNamespaceSummary(summary)
+				progressf("\nSummarizing the namespace...\n")
+			}
+			output := namespaceSummaryReport{NamespaceSummary: summary}
+			output.Narrative, err = analyzers.SummarizeNamespace(ctx, aiService, summary)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(output); err != nil {
+					return fmt.Errorf("error encoding summary: %w", err)
+				}
+				return nil
+			}
+
+			fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+			printMarkdown(output.Narrative)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayNamespaceSummary prints the workloads, pod counts, services, ingresses, quotas and
+// recent warning events of a namespace, and the risks found
+func displayNamespaceSummary(summary *k8s.NamespaceSummary) {
+	fmt.Printf("\n====== %s: %s ======\n", i18n.T("NAMESPACE SUMMARY"), summary.Namespace)
+	if len(summary.Workloads) > 0 {
+		fmt.Printf("%-12s %-36s %-10s %s\n", "KIND", "NAME", "READY", "IMAGES")
+		for _, workload := range summary.Workloads {
+			ready := fmt.Sprintf("%d/%d", workload.Ready, workload.Desired)
+			if workload.Kind == "cronjob" {
+				ready = "-"
+			}
+			fmt.Printf("%-12s %-36s %-10s %s\n", workload.Kind, workload.Name, ready, strings.Join(workload.Images, ","))
+		}
+	}
+	pods := summary.Pods
+	fmt.Printf("Pods: %d (%d running, %d pending, %d succeeded, %d failed), %d restarts\n",
+		pods.Total, pods.Running, pods.Pending, pods.Succeeded, pods.Failed, pods.Restarts)
+
+	for _, service := range summary.Services {
+		fmt.Printf("Service %s (%s): %s\n", service.Name, service.Type, strings.Join(service.Ports, ", "))
+	}
+	for _, ingress := range summary.Ingresses {
+		fmt.Printf("Ingress %s: %s -> %s\n", ingress.Name, strings.Join(ingress.Hosts, ", "), strings.Join(ingress.Backends, ", "))
+	}
+	for _, quota := range summary.Quotas {
+		usage := make([]string, 0, len(quota.Usage))
+		for resource, used := range quota.Usage {
+			usage = append(usage, fmt.Sprintf("%s=%s", resource, used))
+		}
+		sort.Strings(usage)
+		fmt.Printf("ResourceQuota %s: %s\n", quota.Name, strings.Join(usage, " "))
+	}
+
+	if len(summary.Events) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Recent Warnings"))
+		for _, event := range summary.Events {
+			fmt.Printf("[%s] %s %s: %s\n", event.Time.Format("2006-01-02 15:04:05"), event.Object, event.Reason, event.Message)
+		}
+	}
+
+	if len(summary.Findings) > 0 {
+		fmt.Printf("\n=== %s ===\n", i18n.T("Risks"))
+		for _, finding := range summary.Findings {
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/tui"
+)
+
+// createTUICmd creates the tui command
+func createTUICmd(cfg *config.Config, aiService *ai.Service) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Interactive terminal dashboard",
+		Long: `Open an interactive terminal dashboard that lists namespaces and workloads with
+health indicators. Selecting a workload collects its logs and events and runs an
+AI analysis; the chat pane then answers follow-up questions with the workload's
+manifest, events and analysis as context.
+
+Keys: ↑/↓ or j/k to move, enter to select, esc to go back, tab to switch
+between the list and the chat input, pgup/pgdown to scroll, q to quit.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Create Kubernetes client with kubectl flags
+			client, err := k8s.NewClientFromFlags(cmd)
+			if err != nil {
+				return kubeErrorf("error creating Kubernetes client: %w", err)
+			}
+
+			// The dashboard owns the screen, so no spinner is drawn over it
+			aiService.OnRequest(nil)
+			dashboard := tui.NewDashboard(context.Background(), client, aiService)
+			if err := tui.NewProgram(dashboard).Run(); err != nil {
+				return fmt.Errorf("error running TUI: %w", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/upgrade"
+)
+
+// upgradeReport is the JSON output of upgrade-check
+type upgradeReport struct {
+	Target        string            `json:"target"`
+	ServerVersion string            `json:"serverVersion,omitempty"`
+	Findings      []upgrade.Finding `json:"findings"`
+	Skipped       []string          `json:"skipped,omitempty"`
+	Plan          string            `json:"plan,omitempty"`
+}
+
+// createUpgradeCheckCmd creates the upgrade-check command
+func createUpgradeCheckCmd(aiService *ai.Service) *cobra.Command {
+	var target string
+	var files []string
+	var skipCluster bool
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "upgrade-check",
+		Short: "Check for deprecated and removed APIs before a Kubernetes upgrade",
+		Long: `Check live objects and manifest files for API versions, annotations and fields
+that the target Kubernetes release removed or deprecated, and get an AI-written
+migration plan with converted manifests.
+
+Live objects are checked by the API version they were last applied with
+(kubectl.kubernetes.io/last-applied-configuration), since the API server serves
+every object in all supported versions. Use -n or -A to choose the namespaces.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetVersion, err := upgrade.ParseVersion(target)
+			if err != nil {
+				return err
+			}
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if skipCluster && len(files) == 0 {
+				return usageErrorf("--skip-cluster requires manifest files to check (-f)")
+			}
+
+			ctx := context.Background()
+			report := upgradeReport{Target: targetVersion.String()}
+
+			if !skipCluster {
+				client, err := k8s.NewClientFromFlags(cmd)
+				if err != nil {
+					return kubeErrorf("error creating Kubernetes client: %w", err)
+				}
+				if info, err := client.GetClientset().Discovery().ServerVersion(); err == nil {
+					if current, err := upgrade.ParseVersion(info.GitVersion); err == nil {
+						report.ServerVersion = current.String()
+					}
+				}
+
+				namespace := client.GetNamespace()
+				if client.IsAllNamespaces() {
+					namespace = ""
+				}
+				findings, skipped, err := upgrade.ScanCluster(ctx, client, namespace, targetVersion)
+				if err != nil {
+					return kubeErrorf("error scanning cluster: %w", err)
+				}
+				report.Findings = append(report.Findings, findings...)
+				report.Skipped = append(report.Skipped, skipped...)
+			}
+
+			if len(files) > 0 {
+				findings, skipped, err := upgrade.ScanFiles(files, targetVersion)
+				if err != nil {
+					return fmt.Errorf("error scanning manifests: %w", err)
+				}
+				report.Findings = append(report.Findings, findings...)
+				report.Skipped = append(report.Skipped, skipped...)
+			}
+			upgrade.SortFindings(report.Findings)
+
+			if len(report.Findings) > 0 {
+				if outputFormat == "text" {
+					progressf("Writing migration plan...\n")
+				}
+				report.Plan, err = analyzers.PlanUpgrade(ctx, aiService, report.Target, report.ServerVersion, report.Findings)
+				if err != nil {
+					return err
+				}
+			}
+
+			if outputFormat == "json" {
+				if report.Findings == nil {
+					report.Findings = []upgrade.Finding{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding report: %w", err)
+				}
+				return nil
+			}
+
+			displayUpgradeReport(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "target", "", "Kubernetes version to upgrade to, such as 1.31")
+	cmd.Flags().StringSliceVarP(&files, "filename", "f", nil, "Manifest file or directory to check (repeatable)")
+	cmd.Flags().BoolVar(&skipCluster, "skip-cluster", false, "Only check manifest files, not live objects")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	cmd.MarkFlagRequired("target")
+
+	return cmd
+}
+
+// displayUpgradeReport prints the findings of an upgrade check and the migration plan
+func displayUpgradeReport(report upgradeReport) {
+	fmt.Printf("\n====== %s ======\n", i18n.T("UPGRADE CHECK"))
+	if report.ServerVersion != "" {
+		fmt.Printf("Kubernetes %s -> %s\n", report.ServerVersion, report.Target)
+	} else {
+		fmt.Printf("Kubernetes %s\n", report.Target)
+	}
+
+	if len(report.Findings) == 0 {
+		fmt.Println("No removed or deprecated APIs found")
+	}
+	for _, group := range []struct {
+		severity string
+		title    string
+	}{
+		{upgrade.SeverityRemoved, "Removed"},
+		{upgrade.SeverityDeprecated, "Deprecated"},
+	} {
+		printed := false
+		for _, finding := range report.Findings {
+			if finding.Severity != group.severity {
+				continue
+			}
+			if !printed {
+				fmt.Printf("\n=== %s ===\n", i18n.T(group.title))
+				printed = true
+			}
+			fmt.Printf("- %s\n", finding)
+		}
+	}
+
+	if len(report.Skipped) > 0 {
+		fmt.Println("\nNot checked:")
+		for _, skipped := range report.Skipped {
+			fmt.Printf("- %s\n", skipped)
+		}
+	}
+
+	if report.Plan != "" {
+		fmt.Printf("\n====== %s ======\n", i18n.T("MIGRATION PLAN"))
+		printMarkdown(report.Plan)
+	}
+}
+
+// clusterVersionTimeout bounds reading the release of the target cluster for prompts, so AI
+// commands on files are not held up by an unreachable cluster
+const clusterVersionTimeout = 5 * time.Second
+
+// loadClusterVersion reads the release and feature gates of the target cluster, for prompts and
+// for leaving out suggestions the cluster does not support
+func loadClusterVersion(cmd *cobra.Command) (*ai.ClusterVersion, error) {
+	client, err := k8s.NewClientFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterVersionTimeout)
+	defer cancel()
+	cluster, err := upgrade.DetectCluster(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &ai.ClusterVersion{Version: cluster.Version.String(), Check: cluster.CheckSuggestion}
+	for _, feature := range cluster.Unsupported() {
+		version.Unsupported = append(version.Unsupported, feature.String())
+	}
+	return version, nil
+}
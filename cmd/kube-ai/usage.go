@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+)
+
+// collectResourceUsage returns the live usage of the containers of pods against their requests
+// and limits. It is best effort: without metrics-server, nothing is returned and the metrics API
+// is reported among the skipped data sources.
+func collectResourceUsage(ctx context.Context, client *k8s.Client, pods []corev1.Pod) []k8s.ContainerUsage {
+	usage, err := client.GetResourceUsage(ctx, pods)
+	if err != nil {
+		return nil
+	}
+	return usage
+}
+
+// displayResourceUsage prints the usage of each container next to its requests and limits,
+// highlighting those close to their limits
+func displayResourceUsage(usage []k8s.ContainerUsage) {
+	if len(usage) == 0 {
+		return
+	}
+	fmt.Printf("\n=== %s ===\n", i18n.T("Resource Usage"))
+	fmt.Printf("%-50s %-8s %-18s %-8s %-18s %s\n", "CONTAINER", "CPU", "CPU REQ/LIMIT", "MEMORY", "MEMORY REQ/LIMIT", "RESTARTS")
+	for _, u := range usage {
+		line := fmt.Sprintf("%-50s %-8s %-18s %-8s %-18s %d", u.Pod+"/"+u.Container,
+			u.CPU, valueOr(u.CPURequest, "-")+"/"+valueOr(u.CPULimit, "-"),
+			u.Memory, valueOr(u.MemoryRequest, "-")+"/"+valueOr(u.MemoryLimit, "-"), u.Restarts)
+		if u.LastTermination != "" {
+			line += " (" + u.LastTermination + ")"
+		}
+		if len(k8s.UsageFindings([]k8s.ContainerUsage{u})) > 0 {
+			line = severityColor("High") + line + color(ansiReset)
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/helm"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// valuesReport is the JSON output of analyze-values
+type valuesReport struct {
+	Chart string `json:"chart"`
+	// Release compared with, nil when compared with the chart's defaults
+	Release    *helm.Release           `json:"release,omitempty"`
+	ValuesDiff string                  `json:"valuesDiff"`
+	Resources  []manifest.DocumentDiff `json:"resources"`
+	Analysis   string                  `json:"analysis,omitempty"`
+}
+
+// createAnalyzeValuesCmd creates the analyze-values command
+func createAnalyzeValuesCmd(aiService *ai.Service) *cobra.Command {
+	var chart, releaseName, outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "analyze-values <values-file> --chart <chart>",
+		Short: "Explain what a change of Helm values does to the rendered resources",
+		Long: `Review a change of a Helm chart's values, such as a pull request that only
+changes values.yaml.
+
+The chart is rendered with helm template twice: with the values of the
+release named with --release, read from the cluster, and with the values file
+in their place. Without --release the chart's default values are compared
+instead. The values diff and the resources the rendering adds, removes or
+changes are shown, and the AI explains what the change does and the risks of
+rolling it out, such as restarts, downtime, lost volumes or security changes.
+
+Requires helm on PATH. Reading the release requires access to the Secrets
+Helm stores releases in.`,
+		Example: `  kubectl ai analyze-values values.yaml --chart ./chart --release web -n shop
+  kubectl ai analyze-values values.yaml --chart ./chart`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return usageErrorf("unsupported output format %q, use text or json", outputFormat)
+			}
+			if chart == "" {
+				return usageErrorf("--chart is required")
+			}
+			data, err := readInput(args[0])
+			if err != nil {
+				return fmt.Errorf("error reading values: %w", err)
+			}
+			values, err := helm.NormalizeValues(data)
+			if err != nil {
+				return usageErrorf("%s: %w", args[0], err)
+			}
+
+			ctx := context.Background()
+			report := valuesReport{Chart: chart, Resources: []manifest.DocumentDiff{}}
+			renderName, namespace := "release-name", "default"
+			if releaseName != "" {
+				client, err := k8s.NewClientFromFlags(cmd)
+				if err != nil {
+					return kubeErrorf("error creating Kubernetes client: %w", err)
+				}
+				namespace = client.GetNamespace()
+				if report.Release, err = helm.GetRelease(ctx, client.GetClientset(), namespace, releaseName); err != nil {
+					return kubeErrorf("%w", err)
+				}
+				renderName = releaseName
+			} else if flagNamespace, _ := cmd.Flags().GetString("namespace"); flagNamespace != "" {
+				namespace = flagNamespace
+			}
+
+			progress := progressWriter(outputFormat == "json")
+			fmt.Fprintf(progress, "Rendering %s...\n", chart)
+			var current []byte
+			if report.Release != nil {
+				current = report.Release.Values
+			}
+			before, err := helm.Render(ctx, chart, renderName, namespace, current)
+			if err != nil {
+				return err
+			}
+			after, err := helm.Render(ctx, chart, renderName, namespace, values)
+			if err != nil {
+				return err
+			}
+			currentName := "chart defaults"
+			if report.Release != nil {
+				currentName = fmt.Sprintf("release %s revision %d", report.Release.Name, report.Release.Revision)
+			}
+			report.ValuesDiff = manifest.Diff(string(current), string(values), currentName, args[0])
+			if resources, err := manifest.DiffDocuments(before, after); err != nil {
+				return fmt.Errorf("error comparing the rendered manifests: %w", err)
+			} else if resources != nil {
+				report.Resources = resources
+			}
+
+			if report.ValuesDiff == "" && len(report.Resources) == 0 {
+				fmt.Fprintf(progress, "The values are the same as the %s\n", currentName)
+			} else {
+				fmt.Fprintln(progress, "Analyzing the impact of the change...")
+				report.Analysis, err = analyzers.ExplainValuesImpact(ctx, aiService, chart, report.Release, report.ValuesDiff, report.Resources)
+				if err != nil {
+					return err
+				}
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return fmt.Errorf("error encoding values report: %w", err)
+				}
+				return nil
+			}
+			displayValuesReport(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&chart, "chart", "", "Chart directory, archive or reference to render, as helm template takes it")
+	cmd.Flags().StringVar(&releaseName, "release", "", "Release whose values to compare with, in the namespace of -n (default: the chart's default values)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// displayValuesReport prints the values diff, the rendered resources that change and the AI's
+// analysis of the change
+func displayValuesReport(report valuesReport) {
+	fmt.Printf("\n=== %s ===\n", i18n.T("Values Changes"))
+	if report.ValuesDiff == "" {
+		fmt.Println("None")
+	} else {
+		printDiff(report.ValuesDiff)
+	}
+
+	fmt.Printf("\n=== %s ===\n", i18n.T("Rendered Changes"))
+	if len(report.Resources) == 0 {
+		fmt.Println("None")
+	}
+	for _, resource := range report.Resources {
+		fmt.Printf("%s %s\n", resource.Resource, resource.Change)
+		if resource.Change == "changed" {
+			printDiff(resource.Diff)
+		}
+	}
+
+	if report.Analysis != "" {
+		fmt.Printf("\n====== %s ======\n", i18n.T("AI ANALYSIS"))
+		printMarkdown(report.Analysis)
+	}
+}
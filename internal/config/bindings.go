@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CommandBinding sets the persona and prompt templates of a command, instead of the active
+// persona and the templates of the same name
+type CommandBinding struct {
+	Persona string `json:"persona,omitempty"`
+	// Template to render in place of a built-in template, by built-in template name. The
+	// replacement is a template in the prompt template directory, such as
+	// ~/.kube-ai/prompts/log-analysis-sre.tmpl for log-analysis-sre.
+	Prompts map[string]string `json:"prompts,omitempty"`
+}
+
+// BoundCommands returns the sorted commands with bindings
+func (c *Config) BoundCommands() []string {
+	commands := make([]string, 0, len(c.Commands))
+	for command := range c.Commands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// BindCommand sets the binding of a command, given as its path without the kube-ai prefix such
+// as "analyze-logs" or "generate policy", and saves the configuration
+func (c *Config) BindCommand(command string, binding CommandBinding) error {
+	command = normalizeCommand(command)
+	if command == "" {
+		return fmt.Errorf("command is required")
+	}
+	if binding.Persona == "" && len(binding.Prompts) == 0 {
+		return fmt.Errorf("a binding needs a persona or prompt templates")
+	}
+	if binding.Persona != "" {
+		if _, ok := c.ListPersonas()[binding.Persona]; !ok {
+			return fmt.Errorf("persona '%s' not found", binding.Persona)
+		}
+	}
+
+	if c.Commands == nil {
+		c.Commands = make(map[string]CommandBinding)
+	}
+	c.Commands[command] = binding
+	return c.SaveConfig()
+}
+
+// UnbindCommand removes the binding of a command and saves the configuration
+func (c *Config) UnbindCommand(command string) error {
+	command = normalizeCommand(command)
+	if _, ok := c.Commands[command]; !ok {
+		return fmt.Errorf("command '%s' has no binding", command)
+	}
+	delete(c.Commands, command)
+	return c.SaveConfig()
+}
+
+// CommandBinding returns the binding applying to a command: its own, else the nearest parent
+// command's, so that a binding of generate also applies to generate policy
+func (c *Config) CommandBinding(command string) (string, CommandBinding, bool) {
+	words := strings.Fields(command)
+	for i := len(words); i > 0; i-- {
+		path := strings.Join(words[:i], " ")
+		if binding, ok := c.Commands[path]; ok {
+			return path, binding, true
+		}
+	}
+	return "", CommandBinding{}, false
+}
+
+// normalizeCommand collapses the spaces of a command path
+func normalizeCommand(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
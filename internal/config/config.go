@@ -5,12 +5,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"kube-ai/pkg/ai/redact"
 )
 
 // AIPersona defines an AI assistant personality
 type AIPersona struct {
 	Description  string `json:"description"`
 	SystemPrompt string `json:"systemPrompt"`
+
+	// Preferred model, used with the provider if one is named, else with any provider, unless a
+	// profile selects a model
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Preferred sampling temperature, unless a profile sets one
+	Temperature *float32 `json:"temperature,omitempty"`
 }
 
 // DefaultPersonas provides a set of predefined AI personas
@@ -53,158 +66,382 @@ type Config struct {
 	OllamaURL      string `json:"ollamaUrl"`
 	AnythingLLMURL string `json:"anythingLlmUrl"`
 
+	// Local servers speaking the OpenAI API: llama.cpp, and any other such as
+	// text-generation-webui or vLLM, with an optional API key
+	LlamaCppURL            string `json:"llamaCppUrl,omitempty"`
+	OpenAICompatibleURL    string `json:"openaiCompatibleUrl,omitempty"`
+	OpenAICompatibleApiKey string `json:"openaiCompatibleApiKey,omitempty"`
+
+	// Incident management services enriched by enrich-incident
+	PagerDutyApiKey string `json:"pagerdutyApiKey,omitempty"`
+	PagerDutyFrom   string `json:"pagerdutyFrom,omitempty"`
+	PagerDutyURL    string `json:"pagerdutyUrl,omitempty"`
+	OpsgenieApiKey  string `json:"opsgenieApiKey,omitempty"`
+	OpsgenieURL     string `json:"opsgenieUrl,omitempty"`
+
+	// Context window of the model in tokens, overriding what local servers report; empty
+	// means the size is asked from the server, if it can tell
+	ContextSize string `json:"contextSize,omitempty"`
+
+	// Proxy and TLS settings of the connection to each provider, by provider name
+	Transport map[string]ProviderTransport `json:"transport,omitempty"`
+
 	// Default model name for the active provider
 	DefaultModel string `json:"defaultModel"`
 
+	// Whether commands pick their model by task (auto) or use the default model (off)
+	ModelRouting string `json:"modelRouting,omitempty"`
+	// Models for tiers (small, medium, large) and command paths, overriding the cheapest
+	// sufficient model picked by routing
+	Routes map[string]string `json:"routes,omitempty"`
+	// Models added to the model registry, or correcting built-in ones, by name
+	Models map[string]ModelInfo `json:"models,omitempty"`
+
+	// Providers and models asked for their own analysis in consensus mode, in order of use
+	Consensus []ConsensusModel `json:"consensus,omitempty"`
+
+	// Destinations of command results delivered with --notify, by name
+	Sinks map[string]Sink `json:"sinks,omitempty"`
+
 	// Persona configuration
 	ActivePersona  string               `json:"activePersona"`
 	CustomPersonas map[string]AIPersona `json:"customPersonas"`
+
+	// Language for AI answers and CLI output (e.g. "es", "de"); empty means English
+	Language string `json:"language,omitempty"`
+
+	// Refuse AI providers whose endpoint is not local or private, such as hosted APIs
+	LocalOnly bool `json:"localOnly,omitempty"`
+
+	// Start every prompt with the platform, network plugin, ingress classes and operators of the
+	// target cluster, as --cluster-context does
+	ClusterContext bool `json:"clusterContext,omitempty"`
+
+	// Do not keep the answers of the AI provider in ~/.kube-ai/answers for the history command
+	DisableHistory bool `json:"disableHistory,omitempty"`
+
+	// Regular expressions and field paths such as spec.containers[].env[?name=~'.*TOKEN'] whose
+	// values are masked in every prompt, on top of the redaction policy
+	Redact []string `json:"redact,omitempty"`
+
+	// Named AI defaults bound to kube contexts and namespaces
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// Personas and prompt templates of specific commands, by command path
+	Commands map[string]CommandBinding `json:"commands,omitempty"`
+
+	// How API keys are encrypted in the file; nil when they are stored in cleartext
+	Encryption *Encryption `json:"encryption,omitempty"`
+
+	// Where the configuration was loaded from: the file or stateless mode, the settings the file sets, and the
+	// settings overridden by environment variables with their file and environment values
+	path       string
+	stateless  bool
+	fileKeys   map[string]bool
+	fileValues map[string]string
+	envValues  map[string]string
+
+	// Encrypted secrets not decrypted yet, and the encryption key once they are
+	sealed map[string]string
+	key    []byte
 }
 
-// getConfigFilePath returns the path to the configuration file
-func getConfigFilePath() (string, error) {
+// ConfigEnv is the environment variable pointing at an alternate configuration file
+const ConfigEnv = "KUBE_AI_CONFIG"
+
+// StatelessEnv is the environment variable enabling stateless mode, in which no configuration
+// file is read or written and settings come from environment variables and defaults only
+const StatelessEnv = "KUBE_AI_STATELESS"
+
+// LocalOnlyEnv is the environment variable enabling local-only mode, in addition to the
+// localOnly key of the configuration file and the --local-only flag
+const LocalOnlyEnv = "KUBE_AI_LOCAL_ONLY"
+
+// ErrStateless is returned when saving the configuration in stateless mode
+var ErrStateless = fmt.Errorf("the configuration is not saved in stateless mode (%s is set)", StatelessEnv)
+
+// configFileNames are the configuration files looked up in ~/.kube-ai, in order
+var configFileNames = []string{"config.yaml", "config.yml", "config.json"}
+
+// DefaultConfigPath returns the configuration file used when none is given: the first of
+// config.yaml, config.yml and config.json that exists in ~/.kube-ai, else config.json
+func DefaultConfigPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
-	// Create the .kube-ai directory if it doesn't exist
 	kubeAIDir := filepath.Join(homeDir, ".kube-ai")
-	if err := os.MkdirAll(kubeAIDir, 0755); err != nil {
-		return "", err
+	for _, name := range configFileNames {
+		path := filepath.Join(kubeAIDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
 	}
-
 	return filepath.Join(kubeAIDir, "config.json"), nil
 }
 
-// SaveConfig saves the configuration to a file
+// isYAML reports whether a configuration file is YAML, going by its extension
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Path returns the configuration file the configuration is loaded from and saved to, or ""
+// in stateless mode
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Stateless reports whether the configuration is in stateless mode
+func (c *Config) Stateless() bool {
+	return c.stateless
+}
+
+// statelessEnabled reports whether KUBE_AI_STATELESS enables stateless mode
+func statelessEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(StatelessEnv))
+	return enabled
+}
+
+// LocalOnlyEnforced reports whether the configuration file or KUBE_AI_LOCAL_ONLY enables
+// local-only mode. Either one is enough: neither can disable what the other enables.
+func (c *Config) LocalOnlyEnforced() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(LocalOnlyEnv))
+	return c.LocalOnly || enabled
+}
+
+// SaveConfig saves the configuration to its file, in the file's format. Settings taken from
+// environment variables are saved with their value from the file, so that they stay overrides.
+// Nothing saves the configuration implicitly: only commands that edit it, or are given --save.
 func (c *Config) SaveConfig() error {
-	configPath, err := getConfigFilePath()
-	if err != nil {
-		return err
+	if c.stateless {
+		return ErrStateless
+	}
+	if c.Encryption != nil && c.key == nil && c.hasCleartextSecrets() {
+		if err := c.Unlock(); err != nil {
+			return err
+		}
+	}
+	if c.path == "" {
+		path, err := DefaultConfigPath()
+		if err != nil {
+			return err
+		}
+		c.path = path
+	}
+
+	saved := *c
+	for key, value := range c.envValues {
+		if field := lookupSetting(key).field(&saved); *field == value {
+			*field = c.fileValues[key]
+		}
+	}
+	if c.Encryption != nil {
+		if err := c.encryptSecrets(&saved); err != nil {
+			return err
+		}
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	var data []byte
+	var err error
+	if isYAML(c.path) {
+		data, err = yaml.Marshal(&saved)
+	} else {
+		data, err = json.MarshalIndent(&saved, "", "  ")
+	}
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	// Create the configuration directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
 }
 
-// LoadConfig loads configuration from environment variables or saved config
-func LoadConfig() *Config {
+// LoadConfig loads the configuration with the precedence, from highest to lowest: environment
+// variables, then the configuration file, then defaults. Command-line flags are applied on top
+// by the commands. The file is the given path, else $KUBE_AI_CONFIG, else the default path; it
+// is YAML if its extension is .yaml or .yml and JSON otherwise. A missing file is not created,
+// and in stateless mode no file is read at all.
+func LoadConfig(path string) (*Config, error) {
+	stateless := statelessEnabled()
+	if stateless {
+		path = ""
+	} else if path == "" {
+		path = os.Getenv(ConfigEnv)
+	}
+	if path == "" && !stateless {
+		var err error
+		if path, err = DefaultConfigPath(); err != nil {
+			return nil, fmt.Errorf("error locating configuration file: %w", err)
+		}
+	}
+
 	config := &Config{
 		CustomPersonas: make(map[string]AIPersona),
+		path:           path,
+		stateless:      stateless,
+		fileKeys:       make(map[string]bool),
+		fileValues:     make(map[string]string),
+		envValues:      make(map[string]string),
+		sealed:         make(map[string]string),
 	}
 
-	// Try to load from saved config file first
-	configPath, err := getConfigFilePath()
-	if err == nil {
-		data, err := os.ReadFile(configPath)
-		if err == nil {
-			if err := json.Unmarshal(data, config); err == nil {
-				// Successfully loaded from config file
-
-				// Initialize custom personas map if it's nil
-				if config.CustomPersonas == nil {
-					config.CustomPersonas = make(map[string]AIPersona)
-				}
+	// Defaults, overridden by the file
+	for _, s := range settings {
+		*s.field(config) = s.defaultValue(config)
+	}
 
-				return config
+	if !stateless {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := config.unmarshal(data); err != nil {
+				return nil, fmt.Errorf("error parsing %s: %w", path, err)
 			}
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
 		}
 	}
 
-	// If config file doesn't exist or couldn't be loaded, use defaults and environment variables
+	// Encrypted API keys are decrypted when first needed
+	config.sealSecrets()
 
-	// Try to load kubeconfig from standard location
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		config.KubeConfigPath = filepath.Join(homeDir, ".kube", "config")
+	// Check the redaction patterns up front, so a typo cannot send prompts unredacted
+	if _, err := redact.New(redact.PolicyOff, config.Redact); err != nil {
+		return nil, fmt.Errorf("error in %s: %w", path, err)
 	}
 
-	// Override with environment variables if present
-	if kubePath := os.Getenv("KUBECONFIG"); kubePath != "" {
-		config.KubeConfigPath = kubePath
+	// The default model follows the file's provider
+	if !config.fileKeys["defaultModel"] {
+		config.DefaultModel = defaultModel(config.AIProvider)
 	}
 
-	// Load AI Provider configuration
-	config.AIProvider = os.Getenv("AI_PROVIDER")
-	if config.AIProvider == "" {
-		config.AIProvider = "ollama" // Default provider
+	// Initialize custom personas map if the file cleared it
+	if config.CustomPersonas == nil {
+		config.CustomPersonas = make(map[string]AIPersona)
 	}
 
-	// Load API keys for various providers
-	config.OpenAIApiKey = os.Getenv("OPENAI_API_KEY")
-	config.AnthropicApiKey = os.Getenv("ANTHROPIC_API_KEY")
-	config.GeminiApiKey = os.Getenv("GEMINI_API_KEY")
-
-	// Load provider URLs
-	config.OllamaURL = os.Getenv("OLLAMA_URL")
-	if config.OllamaURL == "" {
-		config.OllamaURL = "http://localhost:11434"
+	// Override with environment variables if present
+	fileProvider := config.AIProvider
+	for _, s := range settings {
+		env := s.envVar(config)
+		if env == "" {
+			continue
+		}
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		field := s.field(config)
+		config.fileValues[s.key] = *field
+		config.envValues[s.key] = value
+		*field = value
 	}
 
-	config.AnythingLLMURL = os.Getenv("ANYTHINGLLM_URL")
-	if config.AnythingLLMURL == "" {
-		config.AnythingLLMURL = "http://localhost:3001"
+	// Load default model based on provider. When the environment selects another provider than
+	// the file, the file's model belongs to that other provider.
+	if config.AIProvider != fileProvider && config.envValues["defaultModel"] == "" {
+		config.fileValues["defaultModel"] = config.DefaultModel
+		config.envValues["defaultModel"] = defaultModel(config.AIProvider)
+		config.DefaultModel = config.envValues["defaultModel"]
+	}
+	if config.DefaultModel == "" {
+		config.DefaultModel = defaultModel(config.AIProvider)
 	}
 
-	// Load default model based on provider
-	switch config.AIProvider {
-	case "ollama":
-		config.DefaultModel = os.Getenv("OLLAMA_DEFAULT_MODEL")
-		if config.DefaultModel == "" {
-			config.DefaultModel = "llama3.3"
+	return config, nil
+}
+
+// unmarshal decodes a configuration file over the defaults, recording which settings it sets
+func (c *Config) unmarshal(data []byte) error {
+	var keys map[string]interface{}
+	if isYAML(c.path) {
+		if err := yaml.Unmarshal(data, c); err != nil {
+			return err
 		}
-	case "openai":
-		config.DefaultModel = os.Getenv("OPENAI_DEFAULT_MODEL")
-		if config.DefaultModel == "" {
-			config.DefaultModel = "gpt-3.5-turbo"
+		if err := yaml.Unmarshal(data, &keys); err != nil {
+			return err
 		}
-	case "anthropic":
-		config.DefaultModel = os.Getenv("ANTHROPIC_DEFAULT_MODEL")
-		if config.DefaultModel == "" {
-			config.DefaultModel = "claude-3-haiku-20240307"
+	} else {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
 		}
-	case "gemini":
-		config.DefaultModel = os.Getenv("GEMINI_DEFAULT_MODEL")
-		if config.DefaultModel == "" {
-			config.DefaultModel = "gemini-1.5-pro"
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return err
 		}
-	case "anythingllm":
-		// AnythingLLM doesn't need a default model as it's configured on the server
-		config.DefaultModel = "default"
 	}
-
-	// Set default persona
-	config.ActivePersona = os.Getenv("KUBE_AI_PERSONA")
-	if config.ActivePersona == "" {
-		config.ActivePersona = "kubernetes-expert" // Default persona
+	for key := range keys {
+		c.fileKeys[key] = true
 	}
+	return nil
+}
 
-	// Save the initial config
-	if err := config.SaveConfig(); err != nil {
-		// Log the error but continue, as this is not critical
-		fmt.Printf("Warning: Failed to save initial configuration: %v\n", err)
-	}
+// DefaultModelFor returns the model a provider uses unless another one is configured
+func DefaultModelFor(provider string) string {
+	return defaultModel(provider)
+}
 
-	return config
+// defaultModel returns the default model of a provider
+func defaultModel(provider string) string {
+	switch provider {
+	case "ollama":
+		return "llama3.3"
+	case "openai":
+		return "gpt-3.5-turbo"
+	case "anthropic":
+		return "claude-3-haiku-20240307"
+	case "gemini":
+		return "gemini-1.5-pro"
+	case "anythingllm", "llamacpp", "openai-compatible":
+		// These serve the model configured on the server
+		return "default"
+	default:
+		return ""
+	}
 }
 
-// GetAPIKey returns the API key for the specified provider
+// GetAPIKey returns the API key for the specified provider, decrypting it if needed
 func (c *Config) GetAPIKey(provider string) string {
+	var key *string
+	var name string
 	switch provider {
 	case "openai":
-		return c.OpenAIApiKey
+		key, name = &c.OpenAIApiKey, "openaiApiKey"
 	case "anthropic":
-		return c.AnthropicApiKey
+		key, name = &c.AnthropicApiKey, "anthropicApiKey"
 	case "gemini":
-		return c.GeminiApiKey
+		key, name = &c.GeminiApiKey, "geminiApiKey"
+	case "openai-compatible":
+		key, name = &c.OpenAICompatibleApiKey, "openaiCompatibleApiKey"
 	default:
 		return ""
 	}
+
+	if _, sealed := c.sealed[name]; sealed && *key == "" {
+		if err := c.Unlock(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot use the %s API key: %v\n", provider, err)
+		}
+	}
+	return *key
+}
+
+// ProviderEnvPrefix returns the prefix of a provider's environment variables, such as
+// OPENAI_COMPATIBLE for openai-compatible
+func ProviderEnvPrefix(provider string) string {
+	return strings.ToUpper(strings.ReplaceAll(provider, "-", "_"))
+}
+
+// GetContextSize returns the context window set for the model in tokens, or 0 if none is set
+func (c *Config) GetContextSize() int {
+	size, err := strconv.Atoi(strings.TrimSpace(c.ContextSize))
+	if err != nil || size < 0 {
+		return 0
+	}
+	return size
 }
 
 // GetProviderURL returns the URL for the specified provider
@@ -214,25 +451,29 @@ func (c *Config) GetProviderURL(provider string) string {
 		return c.OllamaURL
 	case "anythingllm":
 		return c.AnythingLLMURL
+	case "llamacpp":
+		return c.LlamaCppURL
+	case "openai-compatible":
+		return c.OpenAICompatibleURL
 	default:
 		return ""
 	}
 }
 
-// UpdateProvider updates the current AI provider
+// UpdateProvider updates the current AI provider; call SaveConfig to persist it
 func (c *Config) UpdateProvider(provider string) {
 	c.AIProvider = provider
-	if err := c.SaveConfig(); err != nil {
-		fmt.Printf("Warning: Failed to save provider configuration: %v\n", err)
-	}
 }
 
-// UpdateModel updates the default model for the current provider
+// UpdateModel updates the default model for the current provider; call SaveConfig to persist it
 func (c *Config) UpdateModel(model string) {
 	c.DefaultModel = model
-	if err := c.SaveConfig(); err != nil {
-		fmt.Printf("Warning: Failed to save model configuration: %v\n", err)
-	}
+}
+
+// UpdateLanguage updates the language for AI answers and CLI output; call SaveConfig to persist
+// it
+func (c *Config) UpdateLanguage(language string) {
+	c.Language = language
 }
 
 // GetCurrentPersona returns the currently active persona
@@ -269,7 +510,7 @@ func (c *Config) ListPersonas() map[string]AIPersona {
 	return allPersonas
 }
 
-// SetPersona sets the active persona by ID
+// SetPersona sets the active persona by ID; call SaveConfig to persist it
 func (c *Config) SetPersona(personaID string) error {
 	// Check if the persona exists
 	if _, ok := DefaultPersonas[personaID]; !ok {
@@ -279,10 +520,6 @@ func (c *Config) SetPersona(personaID string) error {
 	}
 
 	c.ActivePersona = personaID
-	if err := c.SaveConfig(); err != nil {
-		return fmt.Errorf("failed to save persona configuration: %w", err)
-	}
-
 	return nil
 }
 
@@ -299,8 +536,8 @@ func (c *Config) AddCustomPersona(name string, description string, systemPrompt
 	}
 
 	// Validate the required fields
-	if systemPrompt == "" {
-		return fmt.Errorf("system prompt is required")
+	if err := ValidateSystemPrompt(systemPrompt); err != nil {
+		return err
 	}
 
 	// Add persona
@@ -0,0 +1,30 @@
+package config
+
+import "fmt"
+
+// ConsensusModel is a provider and model whose analysis is compared with the others' in
+// consensus mode
+type ConsensusModel struct {
+	Provider string `json:"provider"`
+	// Model of the provider; empty uses the provider's default model
+	Model string `json:"model,omitempty"`
+}
+
+// String returns the model as provider/model
+func (m ConsensusModel) String() string {
+	if m.Model == "" {
+		return m.Provider
+	}
+	return m.Provider + "/" + m.Model
+}
+
+// ConsensusModels returns the first n configured consensus models
+func (c *Config) ConsensusModels(n int) ([]ConsensusModel, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("consensus needs at least 2 models, got %d", n)
+	}
+	if len(c.Consensus) < n {
+		return nil, fmt.Errorf("consensus of %d models needs %d entries under consensus in the configuration file, found %d", n, n, len(c.Consensus))
+	}
+	return c.Consensus[:n], nil
+}
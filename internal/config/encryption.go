@@ -0,0 +1,311 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Environment variables providing the key of an encrypted configuration
+const (
+	// Passphrase the key is derived from, instead of prompting for it
+	PassphraseEnv = "KUBE_AI_CONFIG_PASSPHRASE"
+	// Base64-encoded 32-byte key
+	KeyEnv = "KUBE_AI_CONFIG_KEY"
+	// File holding the key, raw or base64-encoded, such as a mounted Kubernetes Secret or a
+	// secret store CSI volume backed by a cloud KMS
+	KeyFileEnv = "KUBE_AI_CONFIG_KEY_FILE"
+)
+
+// Encryption methods: the key is derived from a passphrase, or provided directly
+const (
+	EncryptionPassphrase = "passphrase"
+	EncryptionKey        = "key"
+)
+
+// encryptedPrefix marks encrypted values in the configuration file
+const encryptedPrefix = "enc:v1:"
+
+// passphraseIterations is the PBKDF2-SHA256 work factor for passphrase-derived keys
+const passphraseIterations = 600000
+
+// checkPlaintext is encrypted into Encryption.Check to recognize a wrong key even when no
+// secret is set
+const checkPlaintext = "kube-ai"
+
+// Encryption describes how the secret settings of the configuration file, such as API keys,
+// are encrypted. They are sealed with AES-256-GCM under a key derived from a passphrase with
+// PBKDF2-SHA256, or under a key provided in the environment.
+type Encryption struct {
+	Method     string `json:"method"`
+	Salt       string `json:"salt,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	// An encrypted known value, to tell a wrong key from a corrupted value
+	Check string `json:"check"`
+}
+
+// Encrypted reports whether the configuration file's secrets are encrypted
+func (c *Config) Encrypted() bool {
+	return c.Encryption != nil
+}
+
+// Locked reports whether encrypted secrets have not been decrypted yet
+func (c *Config) Locked() bool {
+	return len(c.sealed) > 0 && c.key == nil
+}
+
+// Unlock decrypts the encrypted secrets, obtaining the key from the environment or, for a
+// passphrase, by prompting for it on a terminal
+func (c *Config) Unlock() error {
+	if c.Encryption == nil || c.key != nil {
+		return nil
+	}
+	key, err := c.Encryption.deriveKey(false)
+	if err != nil {
+		return err
+	}
+	if check, err := open(key, c.Encryption.Check); err != nil || check != checkPlaintext {
+		return fmt.Errorf("wrong passphrase or key for the encrypted configuration")
+	}
+
+	for name, sealed := range c.sealed {
+		value, err := open(key, sealed)
+		if err != nil {
+			return fmt.Errorf("error decrypting %s: %w", name, err)
+		}
+		if _, overridden := c.envValues[name]; overridden {
+			c.fileValues[name] = value
+		} else {
+			*lookupSetting(name).field(c) = value
+		}
+	}
+	c.sealed = make(map[string]string)
+	c.key = key
+	return nil
+}
+
+// EnableEncryption encrypts the secret settings from now on, with a key derived from a new
+// passphrase or provided in the environment, and saves the configuration. On an encrypted
+// configuration it changes the key.
+func (c *Config) EnableEncryption(method string) error {
+	if err := c.Unlock(); err != nil {
+		return err
+	}
+
+	encryption := &Encryption{Method: method}
+	switch method {
+	case EncryptionPassphrase:
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("error generating salt: %w", err)
+		}
+		encryption.Salt = base64.StdEncoding.EncodeToString(salt)
+		encryption.Iterations = passphraseIterations
+	case EncryptionKey:
+	default:
+		return fmt.Errorf("unknown encryption method %q (expected %s or %s)", method, EncryptionPassphrase, EncryptionKey)
+	}
+
+	key, err := encryption.deriveKey(true)
+	if err != nil {
+		return err
+	}
+	if encryption.Check, err = seal(key, checkPlaintext); err != nil {
+		return err
+	}
+
+	c.Encryption, c.key = encryption, key
+	return c.SaveConfig()
+}
+
+// DisableEncryption decrypts the secret settings and saves them in cleartext
+func (c *Config) DisableEncryption() error {
+	if c.Encryption == nil {
+		return fmt.Errorf("the configuration is not encrypted")
+	}
+	if err := c.Unlock(); err != nil {
+		return err
+	}
+	c.Encryption, c.key = nil, nil
+	return c.SaveConfig()
+}
+
+// sealSecrets moves the encrypted values read from the file out of the settings, until Unlock
+// decrypts them
+func (c *Config) sealSecrets() {
+	for _, s := range settings {
+		if field := s.field(c); s.secret && strings.HasPrefix(*field, encryptedPrefix) {
+			c.sealed[s.key] = *field
+			*field = ""
+		}
+	}
+}
+
+// hasCleartextSecrets reports whether saving the configuration writes any secret that is not
+// already encrypted, leaving out the values taken from environment variables
+func (c *Config) hasCleartextSecrets() bool {
+	for _, s := range settings {
+		if !s.secret {
+			continue
+		}
+		value := *s.field(c)
+		if env, ok := c.envValues[s.key]; ok && value == env {
+			value = c.fileValues[s.key]
+		}
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptSecrets replaces the secret settings of a configuration about to be saved with their
+// encrypted values. Secrets that are still sealed are saved as they were read.
+func (c *Config) encryptSecrets(saved *Config) error {
+	for _, s := range settings {
+		if !s.secret {
+			continue
+		}
+		field := s.field(saved)
+		if *field == "" {
+			*field = c.sealed[s.key]
+			continue
+		}
+		sealed, err := seal(c.key, *field)
+		if err != nil {
+			return err
+		}
+		*field = sealed
+	}
+	return nil
+}
+
+// deriveKey returns the encryption key: derived from the passphrase in the environment or
+// typed at the terminal, twice when confirm is set, or read from the environment
+func (e *Encryption) deriveKey(confirm bool) ([]byte, error) {
+	if e.Method == EncryptionKey {
+		return keyFromEnv()
+	}
+
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		var err error
+		if passphrase, err = promptPassphrase(confirm); err != nil {
+			return nil, err
+		}
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %w", err)
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, e.Iterations, 32)
+}
+
+// keyFromEnv reads a 32-byte key from KUBE_AI_CONFIG_KEY or the file KUBE_AI_CONFIG_KEY_FILE
+// names
+func keyFromEnv() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if encoded == "" {
+		path := os.Getenv(KeyFileEnv)
+		if path == "" {
+			return nil, fmt.Errorf("the configuration key is not set: set %s or %s", KeyEnv, KeyFileEnv)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading configuration key: %w", err)
+		}
+		if len(data) == 32 {
+			return data, nil
+		}
+		encoded = string(data)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("the configuration key must be 32 bytes, base64-encoded (e.g. openssl rand -base64 32)")
+	}
+	return key, nil
+}
+
+// promptPassphrase reads a passphrase from the terminal without echoing it
+func promptPassphrase(confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no configuration passphrase: set %s or run in a terminal to enter it", PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Configuration passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("the passphrase cannot be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		repeated, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("error reading passphrase: %w", err)
+		}
+		if string(repeated) != string(passphrase) {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+// seal encrypts a value with AES-256-GCM, returning it with the encrypted value prefix
+func seal(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error generating nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value sealed with seal
+func open(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed")
+	}
+	return string(plaintext), nil
+}
+
+// newAEAD returns AES-256-GCM for a key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encryptedConfig saves a configuration holding an OpenAI API key, encrypted with a method,
+// and returns its path
+func encryptedConfig(t *testing.T, method string) string {
+	t.Helper()
+	t.Setenv(StatelessEnv, "")
+	t.Setenv("OPENAI_API_KEY", "")
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.OpenAIApiKey = "sk-test-secret"
+	if err := cfg.EnableEncryption(method); err != nil {
+		t.Fatalf("EnableEncryption(%s): %v", method, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-test-secret") {
+		t.Fatalf("the API key is saved in cleartext:\n%s", data)
+	}
+	if !strings.Contains(string(data), encryptedPrefix) {
+		t.Fatalf("no encrypted value saved:\n%s", data)
+	}
+	return path
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{EncryptionPassphrase, EncryptionKey} {
+		t.Run(method, func(t *testing.T) {
+			t.Setenv(PassphraseEnv, "correct horse battery staple")
+			t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString(key))
+			path := encryptedConfig(t, method)
+
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if !cfg.Locked() || cfg.OpenAIApiKey != "" {
+				t.Fatalf("the API key was decrypted before it was needed")
+			}
+			if got := cfg.GetAPIKey("openai"); got != "sk-test-secret" {
+				t.Fatalf("GetAPIKey = %q, want sk-test-secret", got)
+			}
+
+			// Saving again keeps the key encrypted and readable
+			if err := cfg.SaveConfig(); err != nil {
+				t.Fatalf("SaveConfig: %v", err)
+			}
+			cfg, err = LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if err := cfg.Unlock(); err != nil || cfg.OpenAIApiKey != "sk-test-secret" {
+				t.Fatalf("after saving again, Unlock = %v and the API key is %q", err, cfg.OpenAIApiKey)
+			}
+		})
+	}
+}
+
+func TestEncryptionWrongPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	path := encryptedConfig(t, EncryptionPassphrase)
+
+	t.Setenv(PassphraseEnv, "incorrect horse battery staple")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err := cfg.Unlock(); err == nil || !strings.Contains(err.Error(), "wrong passphrase or key") {
+		t.Fatalf("Unlock = %v, want a wrong passphrase error", err)
+	}
+	if !cfg.Locked() || cfg.OpenAIApiKey != "" {
+		t.Errorf("the API key was decrypted with a wrong passphrase")
+	}
+}
+
+func TestEncryptionTamperedCiphertext(t *testing.T) {
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	path := encryptedConfig(t, EncryptionPassphrase)
+
+	// Flip one bit of the encrypted API key
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file map[string]interface{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	sealed, ok := file["openaiApiKey"].(string)
+	if !ok || !strings.HasPrefix(sealed, encryptedPrefix) {
+		t.Fatalf("openaiApiKey is not encrypted: %v", file["openaiApiKey"])
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	file["openaiApiKey"] = encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+	if data, err = json.Marshal(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err := cfg.Unlock(); err == nil || !strings.Contains(err.Error(), "error decrypting openaiApiKey") {
+		t.Fatalf("Unlock = %v, want a decryption error for openaiApiKey", err)
+	}
+	if cfg.OpenAIApiKey != "" {
+		t.Errorf("a tampered API key was decrypted to %q", cfg.OpenAIApiKey)
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import "fmt"
+
+// Incident management services
+const (
+	IncidentPagerDuty = "pagerduty"
+	IncidentOpsgenie  = "opsgenie"
+)
+
+// GetIncidentAPIKey returns the API key of an incident management service, decrypting it if
+// needed
+func (c *Config) GetIncidentAPIKey(service string) (string, error) {
+	var key *string
+	var name string
+	switch service {
+	case IncidentPagerDuty:
+		key, name = &c.PagerDutyApiKey, "pagerdutyApiKey"
+	case IncidentOpsgenie:
+		key, name = &c.OpsgenieApiKey, "opsgenieApiKey"
+	default:
+		return "", fmt.Errorf("unknown incident management service %q", service)
+	}
+
+	if _, sealed := c.sealed[name]; sealed && *key == "" {
+		if err := c.Unlock(); err != nil {
+			return "", fmt.Errorf("cannot use the %s API key: %w", service, err)
+		}
+	}
+	if *key == "" {
+		return "", fmt.Errorf("no %s API key set: set %s with kubectl ai config set or %s", service, name, lookupSetting(name).env)
+	}
+	return *key, nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Persona file identifiers
+const (
+	PersonaAPIVersion = "kube-ai/v1"
+	PersonaKind       = "Persona"
+)
+
+// PersonaFile is a persona exported for sharing, for example by a platform team distributing
+// vetted personas to its engineers
+type PersonaFile struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	AIPersona  `json:",inline"`
+}
+
+// ExportPersona returns a persona as a persona file, in JSON or else YAML
+func (c *Config) ExportPersona(name string, asJSON bool) ([]byte, error) {
+	persona, ok := c.ListPersonas()[name]
+	if !ok {
+		return nil, fmt.Errorf("persona '%s' not found", name)
+	}
+
+	file := PersonaFile{APIVersion: PersonaAPIVersion, Kind: PersonaKind, Name: name, AIPersona: persona}
+	if asJSON {
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return yaml.Marshal(file)
+}
+
+// ParsePersona reads and validates a persona file, in YAML or JSON
+func ParsePersona(data []byte) (PersonaFile, error) {
+	var file PersonaFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return PersonaFile{}, fmt.Errorf("invalid persona file: %w", err)
+	}
+
+	switch {
+	case file.Kind != PersonaKind:
+		return PersonaFile{}, fmt.Errorf("invalid persona file: kind is %q, expected %s", file.Kind, PersonaKind)
+	case file.APIVersion != PersonaAPIVersion:
+		return PersonaFile{}, fmt.Errorf("invalid persona file: unsupported apiVersion %q, expected %s", file.APIVersion, PersonaAPIVersion)
+	}
+	if err := validatePersona(file.Name, file.AIPersona); err != nil {
+		return PersonaFile{}, err
+	}
+	return file, nil
+}
+
+// ImportPersona adds a persona as a custom persona and saves the configuration. An existing
+// custom persona is only replaced with overwrite set; built-in personas are never replaced.
+func (c *Config) ImportPersona(name string, persona AIPersona, overwrite bool) error {
+	if err := validatePersona(name, persona); err != nil {
+		return err
+	}
+	if _, ok := DefaultPersonas[name]; ok {
+		return fmt.Errorf("cannot override built-in persona '%s'", name)
+	}
+	if _, ok := c.CustomPersonas[name]; ok && !overwrite {
+		return fmt.Errorf("persona '%s' already exists (use --force to replace it)", name)
+	}
+
+	if c.CustomPersonas == nil {
+		c.CustomPersonas = make(map[string]AIPersona)
+	}
+	c.CustomPersonas[name] = persona
+	return c.SaveConfig()
+}
+
+// Length limits of a persona's system prompt, in characters
+const (
+	MinSystemPromptLength = 20
+	MaxSystemPromptLength = 10000
+)
+
+// placeholderPattern matches template placeholders, which are sent to the AI as they are since
+// system prompts are not rendered
+var placeholderPattern = regexp.MustCompile(`\{\{[^}]*\}\}|\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// ValidateSystemPrompt checks a system prompt's length and that it has no unexpanded placeholders
+func ValidateSystemPrompt(prompt string) error {
+	length := utf8.RuneCountInString(strings.TrimSpace(prompt))
+	switch {
+	case length == 0:
+		return fmt.Errorf("system prompt is required")
+	case length < MinSystemPromptLength:
+		return fmt.Errorf("system prompt is too short (%d characters, at least %d)", length, MinSystemPromptLength)
+	case length > MaxSystemPromptLength:
+		return fmt.Errorf("system prompt is too long (%d characters, at most %d)", length, MaxSystemPromptLength)
+	}
+	if placeholders := placeholderPattern.FindAllString(prompt, -1); len(placeholders) > 0 {
+		return fmt.Errorf("system prompt contains placeholders that are not expanded: %s", strings.Join(placeholders, ", "))
+	}
+	return nil
+}
+
+// validatePersona checks a persona's name, prompt and preferences
+func validatePersona(name string, persona AIPersona) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("persona name is required")
+	case strings.ContainsAny(name, " \t\n/"):
+		return fmt.Errorf("invalid persona name %q: it cannot contain spaces or slashes", name)
+	case persona.Temperature != nil && (*persona.Temperature < 0 || *persona.Temperature > 2):
+		return fmt.Errorf("persona '%s': temperature must be between 0 and 2", name)
+	case persona.Provider != "" && defaultModel(persona.Provider) == "":
+		return fmt.Errorf("persona '%s': unknown provider %q", name, persona.Provider)
+	}
+	if err := ValidateSystemPrompt(persona.SystemPrompt); err != nil {
+		return fmt.Errorf("persona '%s': %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Profile is a named set of AI defaults, applied automatically to the kube contexts and
+// namespaces it is bound to or explicitly with --profile
+type Profile struct {
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Persona  string `json:"persona,omitempty"`
+	// Sampling temperature for every AI request, overriding the per-command defaults
+	Temperature *float32 `json:"temperature,omitempty"`
+	// Redaction policy for prompts: off, standard or strict
+	Redaction string `json:"redaction,omitempty"`
+
+	// Kube contexts and namespaces the profile is bound to, as names or glob patterns such as
+	// prod-*. A profile bound to both only applies where both match.
+	Contexts   []string `json:"contexts,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// ProfileNames returns the sorted names of the configured profiles
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetProfile creates or replaces a profile and saves the configuration
+func (c *Config) SetProfile(name string, profile Profile) error {
+	if name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if profile.Temperature != nil && (*profile.Temperature < 0 || *profile.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if profile.Persona != "" {
+		if _, ok := c.ListPersonas()[profile.Persona]; !ok {
+			return fmt.Errorf("persona '%s' not found", profile.Persona)
+		}
+	}
+	for _, pattern := range append(append([]string{}, profile.Contexts...), profile.Namespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	c.Profiles[name] = profile
+	return c.SaveConfig()
+}
+
+// RemoveProfile deletes a profile and saves the configuration
+func (c *Config) RemoveProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	delete(c.Profiles, name)
+	return c.SaveConfig()
+}
+
+// ResolveProfile returns the profile bound to a kube context and namespace. A profile bound to
+// both the context and the namespace wins over one bound to the namespace only, which wins
+// over one bound to the context only; ties go to the first name in alphabetical order.
+func (c *Config) ResolveProfile(contextName, namespace string) (string, Profile, bool) {
+	best, bestScore := "", 0
+	for _, name := range c.ProfileNames() {
+		profile := c.Profiles[name]
+		if len(profile.Contexts) == 0 && len(profile.Namespaces) == 0 {
+			continue
+		}
+		if len(profile.Contexts) > 0 && !matchesAny(profile.Contexts, contextName) {
+			continue
+		}
+		if len(profile.Namespaces) > 0 && !matchesAny(profile.Namespaces, namespace) {
+			continue
+		}
+
+		score := 0
+		if len(profile.Contexts) > 0 {
+			score++
+		}
+		if len(profile.Namespaces) > 0 {
+			score += 2
+		}
+		if score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	if best == "" {
+		return "", Profile{}, false
+	}
+	return best, c.Profiles[best], true
+}
+
+// matchesAny reports whether a value matches any of the names or glob patterns
+func matchesAny(patterns []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
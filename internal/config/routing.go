@@ -0,0 +1,46 @@
+package config
+
+import "strings"
+
+// ModelInfo describes a model for the model registry, adding a model or correcting a
+// built-in one
+type ModelInfo struct {
+	Provider string `json:"provider"`
+	// Context window in tokens
+	ContextTokens int `json:"contextTokens,omitempty"`
+	// Prices in US dollars per million tokens
+	InputCostPerMTok  float64 `json:"inputCostPerMTok,omitempty"`
+	OutputCostPerMTok float64 `json:"outputCostPerMTok,omitempty"`
+	// How capable the model is: small, medium or large
+	Tier string `json:"tier"`
+	// Features the model supports: tools, structured
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Model routing modes
+const (
+	RoutingOff  = "off"
+	RoutingAuto = "auto"
+)
+
+// RoutingEnabled reports whether commands pick their model by task instead of using the
+// default model
+func (c *Config) RoutingEnabled() bool {
+	return strings.EqualFold(c.ModelRouting, RoutingAuto)
+}
+
+// Route returns the model configured for a command path or, failing that, a tier, and the key
+// that matched. Commands fall back to their parent command's route.
+func (c *Config) Route(command, tier string) (string, string) {
+	words := strings.Fields(command)
+	for i := len(words); i > 0; i-- {
+		path := strings.Join(words[:i], " ")
+		if model := c.Routes[path]; model != "" {
+			return model, path
+		}
+	}
+	if model := c.Routes[tier]; model != "" {
+		return model, tier
+	}
+	return "", ""
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Sources of a setting's value, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// setting is a configuration value that can be viewed and changed with kube-ai config, keyed by
+// its name in the configuration file
+type setting struct {
+	key         string
+	description string
+	// Environment variable overriding the file; envVar computes it for settings whose variable
+	// depends on other settings
+	env    string
+	envVar func(c *Config) string
+	// Whether the value is a credential, masked when viewed
+	secret bool
+	field  func(c *Config) *string
+	// Value restored by unset
+	defaultValue func(c *Config) string
+}
+
+// settings lists the configurable values; the AI provider comes before the model, whose
+// environment variable depends on the provider
+var settings = []setting{
+	{key: "kubeConfigPath", env: "KUBECONFIG", description: "Path to the kubeconfig file",
+		field: func(c *Config) *string { return &c.KubeConfigPath }, defaultValue: defaultKubeConfigPath},
+	{key: "aiProvider", env: "AI_PROVIDER", description: "AI provider: ollama, openai, anthropic, gemini, anythingllm, llamacpp or openai-compatible",
+		field: func(c *Config) *string { return &c.AIProvider }, defaultValue: constant("ollama")},
+	{key: "defaultModel", description: "Model of the AI provider",
+		envVar: func(c *Config) string { return ProviderEnvPrefix(c.AIProvider) + "_DEFAULT_MODEL" },
+		field:  func(c *Config) *string { return &c.DefaultModel },
+		defaultValue: func(c *Config) string {
+			return defaultModel(c.AIProvider)
+		}},
+	{key: "openaiApiKey", env: "OPENAI_API_KEY", description: "OpenAI API key", secret: true,
+		field: func(c *Config) *string { return &c.OpenAIApiKey }, defaultValue: constant("")},
+	{key: "anthropicApiKey", env: "ANTHROPIC_API_KEY", description: "Anthropic API key", secret: true,
+		field: func(c *Config) *string { return &c.AnthropicApiKey }, defaultValue: constant("")},
+	{key: "geminiApiKey", env: "GEMINI_API_KEY", description: "Google Gemini API key", secret: true,
+		field: func(c *Config) *string { return &c.GeminiApiKey }, defaultValue: constant("")},
+	{key: "ollamaUrl", env: "OLLAMA_URL", description: "URL of the Ollama server",
+		field: func(c *Config) *string { return &c.OllamaURL }, defaultValue: constant("http://localhost:11434")},
+	{key: "anythingLlmUrl", env: "ANYTHINGLLM_URL", description: "URL of the AnythingLLM server",
+		field: func(c *Config) *string { return &c.AnythingLLMURL }, defaultValue: constant("http://localhost:3001")},
+	{key: "llamaCppUrl", env: "LLAMACPP_URL", description: "URL of the llama.cpp server",
+		field: func(c *Config) *string { return &c.LlamaCppURL }, defaultValue: constant("http://localhost:8080")},
+	{key: "openaiCompatibleUrl", env: "OPENAI_COMPATIBLE_URL", description: "URL of the OpenAI-compatible API of a local server, including /v1",
+		field: func(c *Config) *string { return &c.OpenAICompatibleURL }, defaultValue: constant("http://localhost:5000/v1")},
+	{key: "openaiCompatibleApiKey", env: "OPENAI_COMPATIBLE_API_KEY", description: "API key of the OpenAI-compatible server, if it requires one", secret: true,
+		field: func(c *Config) *string { return &c.OpenAICompatibleApiKey }, defaultValue: constant("")},
+	{key: "pagerdutyApiKey", env: "PAGERDUTY_API_KEY", description: "PagerDuty REST API key, for enrich-incident", secret: true,
+		field: func(c *Config) *string { return &c.PagerDutyApiKey }, defaultValue: constant("")},
+	{key: "pagerdutyFrom", env: "PAGERDUTY_FROM", description: "Email of the PagerDuty user that incident notes are posted as",
+		field: func(c *Config) *string { return &c.PagerDutyFrom }, defaultValue: constant("")},
+	{key: "pagerdutyUrl", env: "PAGERDUTY_URL", description: "URL of the PagerDuty REST API; https://api.eu.pagerduty.com for EU accounts",
+		field: func(c *Config) *string { return &c.PagerDutyURL }, defaultValue: constant("https://api.pagerduty.com")},
+	{key: "opsgenieApiKey", env: "OPSGENIE_API_KEY", description: "Opsgenie API integration key, for enrich-incident", secret: true,
+		field: func(c *Config) *string { return &c.OpsgenieApiKey }, defaultValue: constant("")},
+	{key: "opsgenieUrl", env: "OPSGENIE_URL", description: "URL of the Opsgenie API; https://api.eu.opsgenie.com for EU accounts",
+		field: func(c *Config) *string { return &c.OpsgenieURL }, defaultValue: constant("https://api.opsgenie.com")},
+	{key: "contextSize", env: "KUBE_AI_CONTEXT_SIZE", description: "Context window of the model in tokens; empty asks local servers for it",
+		field: func(c *Config) *string { return &c.ContextSize }, defaultValue: constant("")},
+	{key: "modelRouting", env: "KUBE_AI_MODEL_ROUTING", description: "Model routing: auto picks the cheapest sufficient model for each command, off uses the default model",
+		field: func(c *Config) *string { return &c.ModelRouting }, defaultValue: constant(RoutingOff)},
+	{key: "activePersona", env: "KUBE_AI_PERSONA", description: "Persona of the AI assistant",
+		field: func(c *Config) *string { return &c.ActivePersona }, defaultValue: constant("kubernetes-expert")},
+	{key: "language", env: "KUBE_AI_LANGUAGE", description: "Language for AI answers and CLI output; empty means English",
+		field: func(c *Config) *string { return &c.Language }, defaultValue: constant("")},
+}
+
+// init fills in envVar for the settings with a fixed environment variable
+func init() {
+	for i := range settings {
+		if settings[i].envVar == nil {
+			env := settings[i].env
+			settings[i].envVar = func(*Config) string { return env }
+		}
+	}
+}
+
+// constant returns a default value function for a fixed value
+func constant(value string) func(*Config) string {
+	return func(*Config) string { return value }
+}
+
+// defaultKubeConfigPath returns ~/.kube/config, or "" without a home directory
+func defaultKubeConfigPath(*Config) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".kube", "config")
+}
+
+// lookupSetting returns the setting for a key; it panics on unknown keys, which are a bug
+func lookupSetting(key string) setting {
+	s, ok := findSetting(key)
+	if !ok {
+		panic(fmt.Sprintf("unknown setting %q", key))
+	}
+	return s
+}
+
+// findSetting returns the setting for a key, ignoring case
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if strings.EqualFold(s.key, key) {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// SettingKeys returns the keys of the settings that can be set with kube-ai config set
+func SettingKeys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// SettingValue is a setting's effective value and where it comes from
+type SettingValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Env    string `json:"env,omitempty"`
+	Secret bool   `json:"secret,omitempty"`
+	// Whether the value is encrypted in the file and has not been decrypted
+	Locked      bool   `json:"locked,omitempty"`
+	Description string `json:"description"`
+}
+
+// Settings returns the effective value of every setting with its source
+func (c *Config) Settings() []SettingValue {
+	values := make([]SettingValue, 0, len(settings))
+	for _, s := range settings {
+		source, env := c.source(s), s.envVar(c)
+		if source == SourceEnv && os.Getenv(env) == "" {
+			// A default model for a provider selected in the environment
+			env = lookupSetting("aiProvider").env
+		}
+		values = append(values, SettingValue{
+			Key:         s.key,
+			Value:       *s.field(c),
+			Source:      source,
+			Env:         env,
+			Secret:      s.secret,
+			Locked:      c.sealed[s.key] != "" && *s.field(c) == "",
+			Description: s.description,
+		})
+	}
+	return values
+}
+
+// source returns where a setting's value comes from
+func (c *Config) source(s setting) string {
+	if value, ok := c.envValues[s.key]; ok && *s.field(c) == value {
+		return SourceEnv
+	}
+	if c.fileKeys[s.key] || *s.field(c) != s.defaultValue(c) {
+		return SourceFile
+	}
+	return SourceDefault
+}
+
+// Set changes a setting in the configuration file. The change is shadowed while the setting's
+// environment variable is set, which the returned warning explains.
+func (c *Config) Set(key, value string) (string, error) {
+	warning, err := c.Stage(key, value)
+	if err != nil {
+		return "", err
+	}
+	if err := c.SaveConfig(); err != nil {
+		return "", err
+	}
+	return warning, nil
+}
+
+// Stage changes a setting as Set does, without saving the configuration, so that several
+// settings are written at once by the next SaveConfig
+func (c *Config) Stage(key, value string) (string, error) {
+	s, ok := findSetting(key)
+	if !ok {
+		return "", unknownSettingError(key)
+	}
+	*s.field(c) = value
+	delete(c.envValues, s.key)
+	delete(c.sealed, s.key)
+	if c.fileKeys != nil {
+		c.fileKeys[s.key] = true
+	}
+	return c.shadowWarning(s), nil
+}
+
+// Unset resets a setting in the configuration file to its default
+func (c *Config) Unset(key string) (string, error) {
+	s, ok := findSetting(key)
+	if !ok {
+		return "", unknownSettingError(key)
+	}
+	*s.field(c) = s.defaultValue(c)
+	delete(c.envValues, s.key)
+	delete(c.sealed, s.key)
+	delete(c.fileKeys, s.key)
+	if err := c.SaveConfig(); err != nil {
+		return "", err
+	}
+	return c.shadowWarning(s), nil
+}
+
+// shadowWarning explains that a setting's environment variable overrides the file, if it does
+func (c *Config) shadowWarning(s setting) string {
+	env := s.envVar(c)
+	if env == "" || os.Getenv(env) == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s is set in the environment and overrides the configuration file", env)
+}
+
+// unknownSettingError lists the valid keys
+func unknownSettingError(key string) error {
+	keys := SettingKeys()
+	sort.Strings(keys)
+	return fmt.Errorf("unknown setting %q (expected one of: %s)", key, strings.Join(keys, ", "))
+}
+
+// Masked returns a copy of the configuration with its credentials masked, for display
+func (c *Config) Masked() *Config {
+	masked := *c
+	for _, s := range settings {
+		if field := s.field(&masked); s.secret && (*field != "" || c.sealed[s.key] != "") {
+			*field = MaskedValue
+		}
+	}
+	return &masked
+}
+
+// MaskedValue replaces credentials in displayed configuration
+const MaskedValue = "********"
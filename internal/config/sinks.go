@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// Sink types
+const (
+	SinkWebhook = "webhook"
+	SinkSMTP    = "smtp"
+)
+
+// Sink is a destination that command results are delivered to with --notify. Values may
+// reference environment variables as ${NAME}, to keep tokens and passwords out of the file.
+type Sink struct {
+	// webhook or smtp
+	Type string `json:"type"`
+
+	// Webhook: URL the result is posted to as JSON, and headers added to the request
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// SMTP: server as host:port, sender, recipients and optional credentials. Port 465 uses
+	// implicit TLS; other ports upgrade with STARTTLS when the server offers it.
+	Server   string   `json:"server,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+}
+
+// GetSink returns a configured sink by name, with environment variables expanded
+func (c *Config) GetSink(name string) (Sink, error) {
+	sink, ok := c.Sinks[name]
+	if !ok {
+		return Sink{}, fmt.Errorf("sink '%s' not found in the configuration file", name)
+	}
+
+	sink.URL = os.ExpandEnv(sink.URL)
+	if sink.Headers != nil {
+		headers := make(map[string]string, len(sink.Headers))
+		for key, value := range sink.Headers {
+			headers[key] = os.ExpandEnv(value)
+		}
+		sink.Headers = headers
+	}
+	sink.Server = os.ExpandEnv(sink.Server)
+	sink.Username = os.ExpandEnv(sink.Username)
+	sink.Password = os.ExpandEnv(sink.Password)
+	return sink, nil
+}
@@ -0,0 +1,20 @@
+package config
+
+// ProviderTransport configures the HTTP connection to an AI provider
+type ProviderTransport struct {
+	// Proxy URL; empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY, and "direct" ignores them
+	Proxy string `json:"proxy,omitempty"`
+	// PEM bundle of certificate authorities trusted in addition to the system ones, such as
+	// the CA of a TLS-intercepting corporate gateway
+	CAFile string `json:"caFile,omitempty"`
+	// PEM client certificate and key for mutual TLS
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// Minimum TLS version: 1.0, 1.1, 1.2 or 1.3
+	TLSMinVersion string `json:"tlsMinVersion,omitempty"`
+}
+
+// GetTransport returns the connection settings of a provider, empty if it has none
+func (c *Config) GetTransport(provider string) ProviderTransport {
+	return c.Transport[provider]
+}
@@ -0,0 +1,307 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/ai/providers"
+)
+
+// DefaultMaxSteps is the default number of tool calls the agent may make
+const DefaultMaxSteps = 10
+
+// Step records a single tool invocation made by the agent
+type Step struct {
+	// Number is the 1-based position of the step in the run
+	Number int `json:"number"`
+	// Thought is any free text the model produced alongside the call
+	Thought string `json:"thought,omitempty"`
+	// Tool that was called
+	Tool string `json:"tool"`
+	// Arguments passed to the tool
+	Arguments map[string]interface{} `json:"arguments"`
+	// Output returned by the tool
+	Output string `json:"output,omitempty"`
+	// Error returned by the tool, if any
+	Error string `json:"error,omitempty"`
+}
+
+// Result is the outcome of an agent run, including the full transcript
+type Result struct {
+	// Task given to the agent
+	Task string `json:"task"`
+	// Steps taken, in order
+	Steps []Step `json:"steps"`
+	// Answer is the model's final answer
+	Answer string `json:"answer"`
+	// StepLimitReached is true if the agent stopped before producing an answer
+	StepLimitReached bool `json:"stepLimitReached"`
+	// Mode is "native" when provider function-calling was used, otherwise "prompted"
+	Mode string `json:"mode"`
+}
+
+// Agent answers open-ended questions by iteratively calling read-only cluster tools
+type Agent struct {
+	aiService *ai.Service
+	tools     map[string]Tool
+	order     []Tool
+	maxSteps  int
+	namespace string
+}
+
+// NewAgent creates a new agent with the given tools
+func NewAgent(aiService *ai.Service, tools []Tool, namespace string, maxSteps int) *Agent {
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	toolMap := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		toolMap[tool.Definition.Name] = tool
+	}
+
+	return &Agent{
+		aiService: aiService,
+		tools:     toolMap,
+		order:     tools,
+		maxSteps:  maxSteps,
+		namespace: namespace,
+	}
+}
+
+// Run executes the task, calling tools until the model answers or the step limit is hit
+func (a *Agent) Run(ctx context.Context, task string) (*Result, error) {
+	if toolProvider, ok := a.aiService.GetProvider().(providers.ToolCallingProvider); ok {
+		return a.runNative(ctx, toolProvider, task)
+	}
+
+	return a.runPrompted(ctx, task)
+}
+
+// promptedTool describes a tool in the instructions of providers lacking function calling
+type promptedTool struct {
+	Name        string
+	Description string
+	// Parameters as a JSON schema
+	Parameters string
+}
+
+// systemPrompt builds the agent instructions on top of the active persona. With prompted tools
+// the instructions describe the tools and the JSON protocol to call them.
+func (a *Agent) systemPrompt(prompted bool) (string, error) {
+	var tools []promptedTool
+	if prompted {
+		for _, tool := range a.order {
+			params, _ := json.Marshal(tool.Definition.Parameters)
+			tools = append(tools, promptedTool{Name: tool.Definition.Name, Description: tool.Definition.Description, Parameters: string(params)})
+		}
+	}
+	instructions, err := a.aiService.RenderPrompt(prompts.AgentInstructions, map[string]interface{}{
+		"Namespace": a.namespace,
+		"MaxSteps":  a.maxSteps,
+		"Tools":     tools,
+	})
+	if err != nil {
+		return "", err
+	}
+	return a.aiService.GetSystemPrompt() + "\n\n" + strings.TrimSpace(instructions), nil
+}
+
+// runNative drives the loop using the provider's function-calling API
+func (a *Agent) runNative(ctx context.Context, provider providers.ToolCallingProvider, task string) (*Result, error) {
+	result := &Result{Task: task, Mode: "native"}
+
+	definitions := make([]providers.ToolDefinition, 0, len(a.order))
+	for _, tool := range a.order {
+		definitions = append(definitions, tool.Definition)
+	}
+
+	system, err := a.systemPrompt(false)
+	if err != nil {
+		return result, err
+	}
+	messages := []providers.ChatMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: a.aiService.Redact(task)},
+	}
+
+	// Show the conversation and the tools offered instead of starting it in dry-run mode
+	toolNames := make([]string, 0, len(definitions))
+	for _, definition := range definitions {
+		toolNames = append(toolNames, definition.Name)
+	}
+	if err := a.aiService.PreviewPrompt(messages[0].Content, messages[1].Content+"\n\nTools offered: "+strings.Join(toolNames, ", ")); err != nil {
+		return result, err
+	}
+	if err := a.aiService.CheckLocalOnly(); err != nil {
+		return result, err
+	}
+
+	for len(result.Steps) < a.maxSteps {
+		done := a.aiService.RequestStarted()
+		response, err := provider.ChatWithTools(ctx, messages, definitions, 0.2)
+		done()
+		if err != nil {
+			return result, fmt.Errorf("error getting agent response: %w", &ai.ProviderError{Provider: a.aiService.GetCurrentProvider(), Err: err})
+		}
+
+		if len(response.ToolCalls) == 0 {
+			result.Answer = response.Content
+			return result, nil
+		}
+
+		messages = append(messages, providers.ChatMessage{
+			Role:      "assistant",
+			Content:   response.Content,
+			ToolCalls: response.ToolCalls,
+		})
+
+		for _, call := range response.ToolCalls {
+			step := a.execute(ctx, len(result.Steps)+1, call.Name, call.Arguments)
+			step.Thought = response.Content
+			result.Steps = append(result.Steps, step)
+
+			messages = append(messages, providers.ChatMessage{
+				Role:       "tool",
+				Content:    a.aiService.Redact(stepOutput(step)),
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	// Ask for a final answer without tools once the budget is spent
+	messages = append(messages, providers.ChatMessage{
+		Role:    "user",
+		Content: "The tool call limit has been reached. Give your best final answer with the evidence gathered so far.",
+	})
+	done := a.aiService.RequestStarted()
+	response, err := provider.ChatWithTools(ctx, messages, nil, 0.2)
+	done()
+	if err != nil {
+		return result, fmt.Errorf("error getting final agent answer: %w", &ai.ProviderError{Provider: a.aiService.GetCurrentProvider(), Err: err})
+	}
+
+	result.Answer = response.Content
+	result.StepLimitReached = true
+	return result, nil
+}
+
+// promptedReply is the JSON protocol used with providers lacking function calling
+type promptedReply struct {
+	Thought   string                 `json:"thought"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Answer    string                 `json:"answer"`
+}
+
+// runPrompted drives the loop by describing the tools in the prompt and parsing JSON replies
+func (a *Agent) runPrompted(ctx context.Context, task string) (*Result, error) {
+	result := &Result{Task: task, Mode: "prompted"}
+
+	system, err := a.systemPrompt(true)
+	if err != nil {
+		return result, err
+	}
+
+	var transcript strings.Builder
+	transcript.WriteString("Task: ")
+	transcript.WriteString(task)
+	transcript.WriteString("\n")
+
+	for len(result.Steps) < a.maxSteps {
+		response, err := a.aiService.ChatCompletion(system, transcript.String(), 0.2)
+		if err != nil {
+			return result, fmt.Errorf("error getting agent response: %w", &ai.ProviderError{Provider: a.aiService.GetCurrentProvider(), Err: err})
+		}
+
+		reply, ok := parsePromptedReply(response)
+		if !ok {
+			// Treat unstructured output as the final answer
+			result.Answer = strings.TrimSpace(response)
+			return result, nil
+		}
+
+		if reply.Tool == "" {
+			result.Answer = reply.Answer
+			return result, nil
+		}
+
+		step := a.execute(ctx, len(result.Steps)+1, reply.Tool, reply.Arguments)
+		step.Thought = reply.Thought
+		result.Steps = append(result.Steps, step)
+
+		args, _ := json.Marshal(step.Arguments)
+		transcript.WriteString(fmt.Sprintf("\nStep %d: called %s %s\nResult:\n%s\n", step.Number, step.Tool, args, stepOutput(step)))
+	}
+
+	transcript.WriteString("\nThe tool call limit has been reached. Reply with {\"answer\": \"...\"} using the evidence gathered so far.\n")
+	response, err := a.aiService.ChatCompletion(system, transcript.String(), 0.2)
+	if err != nil {
+		return result, fmt.Errorf("error getting final agent answer: %w", &ai.ProviderError{Provider: a.aiService.GetCurrentProvider(), Err: err})
+	}
+
+	if reply, ok := parsePromptedReply(response); ok && reply.Answer != "" {
+		result.Answer = reply.Answer
+	} else {
+		result.Answer = strings.TrimSpace(response)
+	}
+	result.StepLimitReached = true
+	return result, nil
+}
+
+// execute runs a single tool call and records it as a step
+func (a *Agent) execute(ctx context.Context, number int, name string, args map[string]interface{}) Step {
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+
+	step := Step{Number: number, Tool: name, Arguments: args}
+
+	tool, ok := a.tools[name]
+	if !ok {
+		step.Error = fmt.Sprintf("unknown tool %q", name)
+		return step
+	}
+
+	output, err := tool.Run(ctx, args)
+	if err != nil {
+		step.Error = err.Error()
+		return step
+	}
+
+	step.Output = truncateOutput(output)
+	return step
+}
+
+// stepOutput returns the text sent back to the model for a step
+func stepOutput(step Step) string {
+	if step.Error != "" {
+		return "ERROR: " + step.Error
+	}
+	return step.Output
+}
+
+// parsePromptedReply extracts the JSON reply from a model response
+func parsePromptedReply(response string) (promptedReply, bool) {
+	var reply promptedReply
+
+	jsonStart := strings.Index(response, "{")
+	jsonEnd := strings.LastIndex(response, "}")
+	if jsonStart < 0 || jsonEnd <= jsonStart {
+		return reply, false
+	}
+
+	if err := json.Unmarshal([]byte(response[jsonStart:jsonEnd+1]), &reply); err != nil {
+		return reply, false
+	}
+
+	if reply.Tool == "" && reply.Answer == "" {
+		return reply, false
+	}
+
+	return reply, true
+}
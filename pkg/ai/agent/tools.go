@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// maxToolOutput caps the size of a single tool result sent back to the model
+const maxToolOutput = 8000
+
+// Tool is a read-only operation the agent can perform against the cluster
+type Tool struct {
+	// Definition is the schema presented to the model
+	Definition providers.ToolDefinition
+	// Run executes the tool with the decoded arguments
+	Run func(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// DefaultTools returns the constrained, read-only tool set used by the agent
+func DefaultTools(client *k8s.Client) []Tool {
+	return []Tool{
+		listPodsTool(client),
+		getYAMLTool(client),
+		getLogsTool(client),
+		getEventsTool(client),
+		topPodsTool(client),
+	}
+}
+
+// listPodsTool lists pods with their phase, readiness and restart counts
+func listPodsTool(client *k8s.Client) Tool {
+	return Tool{
+		Definition: providers.ToolDefinition{
+			Name:        "list_pods",
+			Description: "List pods in a namespace with status, readiness, restarts and node",
+			Parameters: objectSchema(map[string]interface{}{
+				"namespace":      stringProperty("Namespace to list (defaults to the current namespace)"),
+				"label_selector": stringProperty("Optional label selector, e.g. app=checkout"),
+			}),
+		},
+		Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			pods, err := client.ListPods(ctx, stringArg(args, "namespace"), stringArg(args, "label_selector"))
+			if err != nil {
+				return "", err
+			}
+
+			if len(pods) == 0 {
+				return "No pods found.", nil
+			}
+
+			var sb strings.Builder
+			sb.WriteString("NAME\tPHASE\tREADY\tRESTARTS\tNODE\tAGE\n")
+			for _, pod := range pods {
+				ready, total, restarts := 0, len(pod.Status.ContainerStatuses), int32(0)
+				for _, status := range pod.Status.ContainerStatuses {
+					if status.Ready {
+						ready++
+					}
+					restarts += status.RestartCount
+				}
+				sb.WriteString(fmt.Sprintf("%s\t%s\t%d/%d\t%d\t%s\t%s\n",
+					pod.Name, pod.Status.Phase, ready, total, restarts, pod.Spec.NodeName,
+					time.Since(pod.CreationTimestamp.Time).Round(time.Second)))
+			}
+
+			return sb.String(), nil
+		},
+	}
+}
+
+// getYAMLTool returns the YAML definition of a resource
+func getYAMLTool(client *k8s.Client) Tool {
+	return Tool{
+		Definition: providers.ToolDefinition{
+			Name:        "get_yaml",
+			Description: "Get the YAML definition and status of a resource (pod, deployment, statefulset, daemonset, service, configmap, job, cronjob, ingress, hpa, pdb, pvc, node). Secrets are not available.",
+			Parameters: objectSchema(map[string]interface{}{
+				"kind":      stringProperty("Resource kind, e.g. deployment"),
+				"name":      stringProperty("Resource name"),
+				"namespace": stringProperty("Namespace (defaults to the current namespace)"),
+			}, "kind", "name"),
+		},
+		Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			return client.GetResourceYAML(ctx, stringArg(args, "kind"), stringArg(args, "name"), stringArg(args, "namespace"))
+		},
+	}
+}
+
+// getLogsTool returns recent log lines from a pod
+func getLogsTool(client *k8s.Client) Tool {
+	return Tool{
+		Definition: providers.ToolDefinition{
+			Name:        "get_logs",
+			Description: "Get the most recent log lines of a pod container",
+			Parameters: objectSchema(map[string]interface{}{
+				"pod":        stringProperty("Pod name"),
+				"container":  stringProperty("Container name (optional for single-container pods)"),
+				"namespace":  stringProperty("Namespace (defaults to the current namespace)"),
+				"tail_lines": map[string]interface{}{"type": "integer", "description": "Number of lines to return (default 100)"},
+				"previous":   map[string]interface{}{"type": "boolean", "description": "Return logs of the previous terminated container"},
+			}, "pod"),
+		},
+		Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			namespace := stringArg(args, "namespace")
+			if namespace == "" {
+				namespace = client.GetNamespace()
+			}
+
+			tailLines := int64(100)
+			if v, ok := args["tail_lines"].(float64); ok && v > 0 {
+				tailLines = int64(v)
+			}
+			previous, _ := args["previous"].(bool)
+
+			collector := logs.NewLogCollector(client.GetClientset())
+			entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+				ResourceType: "pod",
+				ResourceName: stringArg(args, "pod"),
+				Namespace:    namespace,
+				Container:    stringArg(args, "container"),
+				TailLines:    &tailLines,
+				Previous:     previous,
+			})
+			if err != nil {
+				return "", err
+			}
+
+			if len(entries) == 0 {
+				return "No log lines returned.", nil
+			}
+
+			var sb strings.Builder
+			for _, entry := range entries {
+				sb.WriteString(entry.Content)
+				sb.WriteString("\n")
+			}
+			return sb.String(), nil
+		},
+	}
+}
+
+// getEventsTool returns recent events, optionally for a single object
+func getEventsTool(client *k8s.Client) Tool {
+	return Tool{
+		Definition: providers.ToolDefinition{
+			Name:        "get_events",
+			Description: "Get recent Kubernetes events in a namespace, optionally for a single object",
+			Parameters: objectSchema(map[string]interface{}{
+				"namespace":   stringProperty("Namespace (defaults to the current namespace)"),
+				"object_name": stringProperty("Only return events for the object with this name"),
+			}),
+		},
+		Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			events, err := client.ListEvents(ctx, stringArg(args, "namespace"), stringArg(args, "object_name"))
+			if err != nil {
+				return "", err
+			}
+
+			if len(events) == 0 {
+				return "No events found.", nil
+			}
+
+			// Most recent events first
+			sort.Slice(events, func(i, j int) bool {
+				return events[i].LastTimestamp.After(events[j].LastTimestamp.Time)
+			})
+
+			var sb strings.Builder
+			sb.WriteString("LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE\n")
+			for _, event := range events {
+				sb.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s/%s\t%d\t%s\n",
+					event.LastTimestamp.Format(time.RFC3339), event.Type, event.Reason,
+					strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name,
+					event.Count, event.Message))
+			}
+			return sb.String(), nil
+		},
+	}
+}
+
+// topPodsTool returns live CPU and memory usage per container
+func topPodsTool(client *k8s.Client) Tool {
+	return Tool{
+		Definition: providers.ToolDefinition{
+			Name:        "top_pods",
+			Description: "Get live CPU and memory usage per pod container from the metrics API",
+			Parameters: objectSchema(map[string]interface{}{
+				"namespace": stringProperty("Namespace (defaults to the current namespace)"),
+			}),
+		},
+		Run: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			metrics, err := client.GetPodMetrics(ctx, stringArg(args, "namespace"))
+			if err != nil {
+				return "", err
+			}
+
+			if len(metrics) == 0 {
+				return "No pod metrics available.", nil
+			}
+
+			var sb strings.Builder
+			sb.WriteString("POD\tCONTAINER\tCPU\tMEMORY\n")
+			for _, pod := range metrics {
+				for _, container := range pod.Containers {
+					sb.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\n", pod.Name, container.Name, container.CPU, container.Memory))
+				}
+			}
+			return sb.String(), nil
+		},
+	}
+}
+
+// objectSchema builds a JSON schema object with the given properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// stringProperty builds a JSON schema string property
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+// stringArg returns a string argument or an empty string if missing
+func stringArg(args map[string]interface{}, name string) string {
+	if v, ok := args[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// truncateOutput limits tool output so a single call cannot exhaust the model context
+func truncateOutput(output string) string {
+	if len(output) <= maxToolOutput {
+		return output
+	}
+	return output[:maxToolOutput] + fmt.Sprintf("\n... (truncated %d bytes)", len(output)-maxToolOutput)
+}
@@ -0,0 +1,28 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// ExplainArgoApplication asks the AI why an Argo CD Application is degraded or out of sync, and
+// for the minimal fix in its source or in the cluster
+func ExplainArgoApplication(ctx context.Context, aiService *ai.Service, app *k8s.ArgoApplication) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.ArgoApplication, map[string]interface{}{
+		"App": app,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI Argo CD application analysis: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,58 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/benchmark"
+)
+
+// maxBenchmarkOffenders limits the offenders listed per failed check in the remediation prompt
+const maxBenchmarkOffenders = 20
+
+// failedCheck is a failed benchmark check, for the remediation prompt
+type failedCheck struct {
+	benchmark.Result
+	// Number of offenders not listed
+	More int
+}
+
+// NarrateBenchmark asks the AI to rank the failed benchmark checks by risk and to write a
+// remediation for each. Passed and skipped checks are only listed by ID.
+func NarrateBenchmark(ctx context.Context, aiService *ai.Service, results []benchmark.Result) (string, error) {
+	var failed []failedCheck
+	var passed, skipped []string
+	for _, result := range results {
+		switch result.Status {
+		case benchmark.StatusFail:
+			entry := failedCheck{Result: result}
+			if len(entry.Offenders) > maxBenchmarkOffenders {
+				entry.More = len(entry.Offenders) - maxBenchmarkOffenders
+				entry.Offenders = entry.Offenders[:maxBenchmarkOffenders]
+			}
+			failed = append(failed, entry)
+		case benchmark.StatusPass:
+			passed = append(passed, result.ID)
+		default:
+			skipped = append(skipped, result.ID)
+		}
+	}
+
+	prompt, err := aiService.RenderPrompt(prompts.BenchmarkRemediation, map[string]interface{}{
+		"Failed":  failed,
+		"Passed":  passed,
+		"Skipped": skipped,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI benchmark remediation: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,91 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/bundle"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// maxManifestChars caps how much of each manifest is included in the prompt
+const maxManifestChars = 6000
+
+// BundleAnalyzer analyzes offline incident bundles
+type BundleAnalyzer struct {
+	aiService *ai.Service
+}
+
+// NewBundleAnalyzer creates a new bundle analyzer
+func NewBundleAnalyzer(aiService *ai.Service) *BundleAnalyzer {
+	return &BundleAnalyzer{
+		aiService: aiService,
+	}
+}
+
+// AnalyzeBundle uses AI to analyze the manifests, logs, events and metrics in a bundle
+func (a *BundleAnalyzer) AnalyzeBundle(ctx context.Context, b *bundle.Bundle) (*LogAnalysisResult, logs.LogSummary, error) {
+	entries := b.LogEntries()
+	summary := logs.ParseLogs(entries)
+
+	prompt, err := a.buildBundlePrompt(b, entries, summary)
+	if err != nil {
+		return nil, summary, err
+	}
+
+	result, err := queryAnalysis(ctx, a.aiService, prompt)
+	if err != nil {
+		return nil, summary, fmt.Errorf("error getting AI analysis: %w", err)
+	}
+
+	return result, summary, nil
+}
+
+// bundleManifest is a manifest of a bundle as shown in the prompt
+type bundleManifest struct {
+	Name    string
+	Content string
+}
+
+// maxBundleSamples caps how many error lines of a bundle are included in the prompt
+const maxBundleSamples = 20
+
+// buildBundlePrompt creates a prompt describing the full incident bundle
+func (a *BundleAnalyzer) buildBundlePrompt(b *bundle.Bundle, entries []logs.LogEntry, summary logs.LogSummary) (string, error) {
+	names := make([]string, 0, len(b.Manifests))
+	for name := range b.Manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	manifests := make([]bundleManifest, 0, len(names))
+	for _, name := range names {
+		manifest := b.Manifests[name]
+		if len(manifest) > maxManifestChars {
+			manifest = manifest[:maxManifestChars] + "\n# ... truncated\n"
+		}
+		manifests = append(manifests, bundleManifest{Name: name, Content: manifest})
+	}
+
+	var samples []logs.LogEntry
+	for _, entry := range entries {
+		if entry.LogLevel == "ERROR" || entry.LogLevel == "FATAL" {
+			samples = append(samples, entry)
+			if len(samples) >= maxBundleSamples {
+				break
+			}
+		}
+	}
+
+	return a.aiService.RenderPrompt(prompts.BundleAnalysis, map[string]interface{}{
+		"Metadata":  b.Metadata,
+		"Manifests": manifests,
+		"Events":    b.Events,
+		"Metrics":   b.Metrics,
+		"Summary":   summary,
+		"Samples":   samples,
+	})
+}
@@ -0,0 +1,78 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// maxCapacityEvents limits the autoscaler events sent in a capacity plan
+const maxCapacityEvents = 30
+
+// PlanCapacity asks the AI for a forecast and node pool sizing recommendation from the capacity
+// of the cluster, planning for the given percentage of growth
+func PlanCapacity(ctx context.Context, aiService *ai.Service, capacity *k8s.ClusterCapacity, growth int) (string, error) {
+	var pools, pending, workloads, events []string
+	for _, pool := range capacity.Pools() {
+		pools = append(pools, FormatPoolCapacity(pool))
+	}
+	for _, pod := range capacity.Pending {
+		pending = append(pending, fmt.Sprintf("%s/%s (requests %s CPU, %s memory): %s",
+			pod.Namespace, pod.Name, k8s.FormatCPU(pod.CPU), k8s.FormatMemory(pod.Memory), pod.Reason))
+	}
+	for _, workload := range capacity.LargestWorkloads {
+		nodes, fit := capacity.Headroom(workload)
+		workloads = append(workloads, fmt.Sprintf("%s %s/%s: %d pods of %s CPU, %s memory; %d more fit on %d nodes",
+			workload.Kind, workload.Namespace, workload.Name, workload.Pods,
+			k8s.FormatCPU(workload.CPU), k8s.FormatMemory(workload.Memory), fit, nodes))
+	}
+	for i, event := range capacity.AutoscalerEvents {
+		if i >= maxCapacityEvents {
+			break
+		}
+		events = append(events, fmt.Sprintf("%s %s %s: %s",
+			event.Time.Format("2006-01-02 15:04"), event.Reason, event.Object, event.Message))
+	}
+
+	prompt, err := aiService.RenderPrompt(prompts.CapacityPlan, map[string]interface{}{
+		"Pools":            pools,
+		"Pending":          pending,
+		"Workloads":        workloads,
+		"Events":           events,
+		"AutoscalerStatus": strings.TrimSpace(capacity.AutoscalerStatus),
+		"MetricsAvailable": capacity.MetricsAvailable,
+		"Growth":           growth,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI capacity plan: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// FormatPoolCapacity describes the allocatable, requested and used resources of a node pool
+func FormatPoolCapacity(pool k8s.PoolCapacity) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d nodes (%d schedulable)", pool.Name, pool.Nodes, pool.Schedulable)
+	if pool.InstanceType != "" && pool.InstanceType != pool.Name {
+		fmt.Fprintf(&sb, " of %s", pool.InstanceType)
+	}
+	fmt.Fprintf(&sb, "; CPU %s of %s requested", k8s.FormatCPU(pool.RequestedCPU), k8s.FormatCPU(pool.AllocatableCPU))
+	if pool.UsedCPU >= 0 {
+		fmt.Fprintf(&sb, ", %s used", k8s.FormatCPU(pool.UsedCPU))
+	}
+	fmt.Fprintf(&sb, "; memory %s of %s requested", k8s.FormatMemory(pool.RequestedMemory), k8s.FormatMemory(pool.AllocatableMemory))
+	if pool.UsedMemory >= 0 {
+		fmt.Fprintf(&sb, ", %s used", k8s.FormatMemory(pool.UsedMemory))
+	}
+	fmt.Fprintf(&sb, "; %d of %d pods", pool.Pods, pool.AllocatablePods)
+	return sb.String()
+}
@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// TroubleshootCertificate asks the AI why a cert-manager Certificate is not issued, given its
+// issuer, the CertificateRequest, Order and Challenges of its issuance, their events and the
+// detected problems, and what to do next
+func TroubleshootCertificate(ctx context.Context, aiService *ai.Service, certificate *k8s.CertificateIssuance) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.CertificateIssuance, map[string]interface{}{
+		"Certificate": certificate,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI certificate troubleshooting: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,178 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/logs"
+	"kube-ai/pkg/progress"
+)
+
+// DefaultChunkTokens is the default estimated token budget for the logs sent in a single request
+const DefaultChunkTokens = 24000
+
+// entryOverhead approximates the characters added to each line for its timestamp, pod and level
+const entryOverhead = 48
+
+// LogChunk is a contiguous time window of log entries analyzed in one request
+type LogChunk struct {
+	// Position of the chunk, starting at 1
+	Index int
+	// Time of the first entry
+	Start time.Time
+	// Time of the last entry
+	End time.Time
+	// Entries in chronological order
+	Entries []logs.LogEntry
+}
+
+// ChunkAnalysis is the analysis of a single chunk, passed to the reduce prompt
+type ChunkAnalysis struct {
+	// Position of the chunk, starting at 1
+	Index int
+	// Time of the first entry
+	Start time.Time
+	// Time of the last entry
+	End time.Time
+	// Number of entries in the chunk
+	Entries int
+	// Analysis of the chunk
+	Result *LogAnalysisResult
+}
+
+// estimateTokens roughly estimates the prompt tokens needed for log entries (about 4 characters per token)
+func estimateTokens(entries []logs.LogEntry) int {
+	chars := 0
+	for _, entry := range entries {
+		chars += len(entry.Content) + entryOverhead
+	}
+	return chars / 4
+}
+
+// splitIntoChunks splits entries into chronological chunks whose estimated size fits the token budget
+func splitIntoChunks(entries []logs.LogEntry, maxTokens int) []LogChunk {
+	sorted := make([]logs.LogEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var chunks []LogChunk
+	start, tokens := 0, 0
+	for i, entry := range sorted {
+		entryTokens := (len(entry.Content) + entryOverhead) / 4
+		if i > start && tokens+entryTokens > maxTokens {
+			chunks = append(chunks, newLogChunk(len(chunks)+1, sorted[start:i]))
+			start, tokens = i, 0
+		}
+		tokens += entryTokens
+	}
+	if start < len(sorted) {
+		chunks = append(chunks, newLogChunk(len(chunks)+1, sorted[start:]))
+	}
+	return chunks
+}
+
+// newLogChunk creates a chunk from chronologically sorted entries
+func newLogChunk(index int, entries []logs.LogEntry) LogChunk {
+	return LogChunk{
+		Index:   index,
+		Start:   entries[0].Timestamp,
+		End:     entries[len(entries)-1].Timestamp,
+		Entries: entries,
+	}
+}
+
+// chunkBudget returns the token budget per request, reduced to fit the model's context window
+// when it is smaller, or 0 when chunked analysis is disabled
+func (a *LogAnalyzer) chunkBudget() int {
+	if a.chunkTokens <= 0 {
+		return 0
+	}
+	return a.aiService.PromptBudget(a.chunkTokens)
+}
+
+// needsChunking reports whether entries are too large to analyze in a single request
+func (a *LogAnalyzer) needsChunking(entries []logs.LogEntry) bool {
+	budget := a.chunkBudget()
+	return budget > 0 && estimateTokens(entries) > budget
+}
+
+// analyzeChunked analyzes each time window separately (map) and merges the results with a final prompt (reduce)
+func (a *LogAnalyzer) analyzeChunked(ctx context.Context, entries []logs.LogEntry, summary logs.LogSummary) (*LogAnalysisResult, error) {
+	chunks := splitIntoChunks(entries, a.chunkBudget())
+	analyses := make([]ChunkAnalysis, 0, len(chunks))
+
+	started := time.Now()
+	for i, chunk := range chunks {
+		// Chunks are analyzed in turn, so the ones done tell how long the rest will take
+		eta := ""
+		if remaining := progress.FormatRemaining(progress.Remaining(time.Since(started), i, len(chunks))); remaining != "" {
+			eta = ", " + remaining
+		}
+		a.progressf("Analyzing chunk %d/%d (%s to %s, %d entries%s)...\n",
+			chunk.Index, len(chunks), chunk.Start.Format(time.RFC3339), chunk.End.Format(time.RFC3339), len(chunk.Entries), eta)
+
+		prompt, err := a.aiService.RenderPrompt(prompts.LogChunk, map[string]interface{}{
+			"Index":   chunk.Index,
+			"Total":   len(chunks),
+			"Start":   chunk.Start,
+			"End":     chunk.End,
+			"Entries": chunk.Entries,
+			"Summary": logs.ParseLogs(chunk.Entries),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := queryAnalysis(ctx, a.aiService, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("error getting AI analysis of chunk %d/%d: %w", chunk.Index, len(chunks), err)
+		}
+
+		analyses = append(analyses, ChunkAnalysis{
+			Index:   chunk.Index,
+			Start:   chunk.Start,
+			End:     chunk.End,
+			Entries: len(chunk.Entries),
+			Result:  result,
+		})
+	}
+
+	// A single chunk needs no merging
+	if len(analyses) == 1 {
+		return analyses[0].Result, nil
+	}
+
+	a.progressf("Merging %d chunk analyses...\n", len(analyses))
+
+	prompt, err := a.aiService.RenderPrompt(prompts.LogReduce, map[string]interface{}{
+		"Summary":   summary,
+		"Chunks":    analyses,
+		"Baseline":  a.baseline,
+		"Trend":     a.trend,
+		"Lifecycle": a.lifecycle,
+		"Usage":     a.usage,
+		"Alert":     a.alert,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := queryAnalysis(ctx, a.aiService, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("error merging chunk analyses: %w", err)
+	}
+
+	return result, nil
+}
+
+// progressf reports progress of a long-running analysis if a progress writer is set
+func (a *LogAnalyzer) progressf(format string, args ...interface{}) {
+	if a.progress != nil {
+		fmt.Fprintf(a.progress, format, args...)
+	}
+}
@@ -0,0 +1,127 @@
+package analyzers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// logLines returns entries a second apart, each content being 4*tokens-entryOverhead characters
+// so that it is estimated at the given tokens
+func logLines(count, tokens int) []logs.LogEntry {
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	entries := make([]logs.LogEntry, count)
+	for i := range entries {
+		entries[i] = logs.LogEntry{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Content:   strings.Repeat("x", 4*tokens-entryOverhead),
+		}
+	}
+	return entries
+}
+
+func TestSplitIntoChunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []logs.LogEntry
+		maxTokens int
+		// Entries of each chunk
+		want []int
+	}{
+		{"fits in one chunk", logLines(5, 20), 100, []int{5}},
+		{"exact budgets", logLines(6, 20), 40, []int{2, 2, 2}},
+		{"remainder", logLines(7, 20), 60, []int{3, 3, 1}},
+		{"entry larger than the budget", logLines(3, 50), 20, []int{1, 1, 1}},
+		{"no entries", nil, 100, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitIntoChunks(tt.entries, tt.maxTokens)
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+			total := 0
+			for i, chunk := range chunks {
+				if chunk.Index != i+1 || len(chunk.Entries) != tt.want[i] {
+					t.Errorf("chunk %d has index %d and %d entries, want %d", i, chunk.Index, len(chunk.Entries), tt.want[i])
+				}
+				if !chunk.Start.Equal(chunk.Entries[0].Timestamp) || !chunk.End.Equal(chunk.Entries[len(chunk.Entries)-1].Timestamp) {
+					t.Errorf("chunk %d spans %s to %s, not its entries", i, chunk.Start, chunk.End)
+				}
+				total += len(chunk.Entries)
+			}
+			if total != len(tt.entries) {
+				t.Errorf("chunks hold %d entries, want %d", total, len(tt.entries))
+			}
+		})
+	}
+}
+
+func TestSplitIntoChunksSortsByTime(t *testing.T) {
+	entries := logLines(4, 20)
+	entries[0], entries[3] = entries[3], entries[0]
+
+	chunks := splitIntoChunks(entries, 40)
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if chunk.End.Before(chunk.Start) {
+			t.Errorf("chunk %d is not in chronological order: %s to %s", chunk.Index, chunk.Start, chunk.End)
+		}
+	}
+	if !chunks[0].End.Before(chunks[1].Start) {
+		t.Errorf("chunks overlap: %s after %s", chunks[0].End, chunks[1].Start)
+	}
+	// The input is not reordered
+	if !entries[0].Timestamp.After(entries[3].Timestamp) {
+		t.Errorf("splitIntoChunks sorted its input")
+	}
+}
+
+func TestAnalyzeLogsChunked(t *testing.T) {
+	fake := providers.NewFakeProvider()
+	providers.Register(providers.ProviderTypeFake, fake.Create)
+	defer providers.Register(providers.ProviderTypeFake, nil)
+	for _, summary := range []string{"first window", "second window", "third window", "merged"} {
+		fake.Respond(`{"summary": "` + summary + `", "rootCauses": [], "solutions": [], "additionalInfo": [], "severity": "Low"}`)
+	}
+
+	analyzer := NewLogAnalyzer(ai.NewService(&config.Config{AIProvider: string(providers.ProviderTypeFake)}))
+	analyzer.SetChunkTokens(40)
+	var progress strings.Builder
+	analyzer.SetProgress(&progress)
+
+	entries := logLines(6, 20)
+	result, err := analyzer.AnalyzeLogs(context.Background(), entries, logs.ParseLogs(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary != "merged" {
+		t.Errorf("expected the merged analysis, got %q", result.Summary)
+	}
+	if requests := fake.Requests(); len(requests) != 4 {
+		t.Errorf("expected 3 chunk requests and 1 merge, got %d", len(requests))
+	}
+	for _, want := range []string{"Analyzing chunk 1/3", "Analyzing chunk 3/3", "Merging 3 chunk analyses"} {
+		if !strings.Contains(progress.String(), want) {
+			t.Errorf("progress does not report %q:\n%s", want, progress.String())
+		}
+	}
+
+	// Within the budget, the logs are analyzed in a single request
+	fake.Respond(`{"summary": "whole", "rootCauses": [], "solutions": [], "additionalInfo": [], "severity": "Low"}`)
+	analyzer.SetChunkTokens(1000)
+	if result, err = analyzer.AnalyzeLogs(context.Background(), entries, logs.ParseLogs(entries)); err != nil || result.Summary != "whole" {
+		t.Errorf("expected a single analysis, got %+v, %v", result, err)
+	}
+	if requests := fake.Requests(); len(requests) != 5 {
+		t.Errorf("expected one more request, got %d in total", len(requests))
+	}
+}
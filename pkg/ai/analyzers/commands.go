@@ -0,0 +1,89 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s"
+)
+
+// SuggestedCommand is a kubectl command the AI suggests for a task
+type SuggestedCommand struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation"`
+}
+
+// CommandSuggestion is the AI's answer to how to do a task with kubectl
+type CommandSuggestion struct {
+	// Commands to run, in order
+	Commands []SuggestedCommand `json:"commands"`
+	// Caveats, such as the disruption the commands cause or what is missing to complete the task
+	Notes string `json:"notes,omitempty"`
+}
+
+// commandSuggestionSchema is the JSON schema of CommandSuggestion, used to request
+// schema-constrained responses
+var commandSuggestionSchema = providers.ResponseSchema{
+	Name:        "kubectl_commands",
+	Description: "kubectl commands that accomplish a task",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"commands": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"command":     map[string]interface{}{"type": "string"},
+						"explanation": map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"command", "explanation"},
+					"additionalProperties": false,
+				},
+			},
+			"notes": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"commands", "notes"},
+		"additionalProperties": false,
+	},
+}
+
+// SuggestCommands asks the AI for the kubectl commands that accomplish a task, given the
+// namespaces, node labels and resource types of the cluster so that commands refer to what exists
+func SuggestCommands(ctx context.Context, aiService *ai.Service, task, namespace string, commandContext *k8s.CommandContext) (*CommandSuggestion, error) {
+	prompt, err := aiService.RenderPrompt(prompts.KubectlCommands, map[string]interface{}{
+		"Task":      task,
+		"Namespace": namespace,
+		"Context":   commandContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, structured, err := aiService.QueryStructured(ctx, prompt, commandSuggestionSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error getting AI command suggestions: %w", err)
+	}
+	if !structured {
+		start := strings.Index(response, "{")
+		end := strings.LastIndex(response, "}")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("error parsing AI response: no JSON object found")
+		}
+		response = response[start : end+1]
+	}
+
+	var suggestion CommandSuggestion
+	if err := json.Unmarshal([]byte(response), &suggestion); err != nil {
+		return nil, fmt.Errorf("error parsing AI response: %w", err)
+	}
+	for i := range suggestion.Commands {
+		suggestion.Commands[i].Command = strings.TrimSpace(suggestion.Commands[i].Command)
+	}
+	return &suggestion, nil
+}
@@ -0,0 +1,202 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// ConsensusMember is the analysis of one of the models in a consensus run
+type ConsensusMember struct {
+	// Model as provider/model
+	Name string `json:"name"`
+	// The model's own analysis
+	Analysis *LogAnalysisResult `json:"analysis,omitempty"`
+	// Error that prevented the analysis, if any
+	Error string `json:"error,omitempty"`
+}
+
+// ModelPosition is what one model concluded on a disputed point
+type ModelPosition struct {
+	Model    string `json:"model"`
+	Position string `json:"position"`
+}
+
+// Disagreement is a point the models concluded differently on
+type Disagreement struct {
+	Topic     string          `json:"topic"`
+	Positions []ModelPosition `json:"positions"`
+}
+
+// ConsensusResult is the merged analysis of several models, with what they agree and disagree on
+type ConsensusResult struct {
+	// Analysis merged from the models' analyses
+	Analysis *LogAnalysisResult `json:"analysis,omitempty"`
+	// Conclusions every model reached
+	Agreements []string `json:"agreements"`
+	// Points the models concluded differently on
+	Disagreements []Disagreement `json:"disagreements"`
+	// Each model's own analysis
+	Models []ConsensusMember `json:"models"`
+}
+
+// consensusSchema is the JSON schema of the merged consensus analysis: a log analysis with
+// agreements and disagreements added
+var consensusSchema = func() providers.ResponseSchema {
+	properties := map[string]interface{}{
+		"agreements": stringList,
+		"disagreements": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"topic": map[string]interface{}{"type": "string"},
+					"positions": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"model":    map[string]interface{}{"type": "string"},
+								"position": map[string]interface{}{"type": "string"},
+							},
+							"required":             []string{"model", "position"},
+							"additionalProperties": false,
+						},
+					},
+				},
+				"required":             []string{"topic", "positions"},
+				"additionalProperties": false,
+			},
+		},
+	}
+	for name, property := range logAnalysisSchema.Schema["properties"].(map[string]interface{}) {
+		properties[name] = property
+	}
+	required := append([]string{}, logAnalysisSchema.Schema["required"].([]string)...)
+
+	return providers.ResponseSchema{
+		Name:        "consensus_analysis",
+		Description: "Merged analysis of Kubernetes logs by several models, with their agreements and disagreements",
+		Schema: map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             append(required, "agreements", "disagreements"),
+			"additionalProperties": false,
+		},
+	}
+}()
+
+// Consensus runs the same analysis with each of the given services concurrently, each using
+// another model, then has the analyzer's own service merge the analyses and point out where the
+// models agree and disagree. analyze runs the analysis with an analyzer configured like this one.
+// At least two models must return an analysis for them to be compared, and the merged evidence
+// is checked against the collected entries.
+func (a *LogAnalyzer) Consensus(ctx context.Context, members []*ai.Service, entries []logs.LogEntry, summary logs.LogSummary, analyze func(*LogAnalyzer) (*LogAnalysisResult, error)) (*ConsensusResult, error) {
+	results := make([]ConsensusMember, len(members))
+	errs := make([]error, len(members))
+
+	a.progressf("Analyzing with %d models...\n", len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member *ai.Service) {
+			defer wg.Done()
+
+			analyzer := *a
+			analyzer.aiService = member
+			// Progress of concurrent chunked analyses would interleave
+			analyzer.progress = nil
+
+			results[i].Name = member.GetCurrentProvider() + "/" + member.GetCurrentModel()
+			results[i].Analysis, errs[i] = analyze(&analyzer)
+			if errs[i] != nil {
+				results[i].Error = errs[i].Error()
+			}
+		}(i, member)
+	}
+	wg.Wait()
+
+	var analyzed []ConsensusMember
+	var failures []string
+	for i, result := range results {
+		if errors.Is(errs[i], ai.ErrDryRun) {
+			return nil, errs[i]
+		}
+		if result.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Name, result.Error))
+			continue
+		}
+		analyzed = append(analyzed, result)
+	}
+	if len(analyzed) < 2 {
+		return nil, fmt.Errorf("%d of %d models returned an analysis, at least 2 are needed for a consensus: %s",
+			len(analyzed), len(members), strings.Join(failures, "; "))
+	}
+
+	a.progressf("Comparing %d analyses...\n", len(analyzed))
+	prompt, err := a.aiService.RenderPrompt(prompts.ConsensusMerge, map[string]interface{}{
+		"Summary": summary,
+		"Members": analyzed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, structured, err := a.aiService.QueryStructured(ctx, prompt, consensusSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error merging analyses: %w", err)
+	}
+	result, err := decodeConsensus(response, structured)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing AI response: %w", err)
+	}
+	verifyEvidence(result.Analysis, entries, a.lifecycle)
+	result.Models = results
+	return result, nil
+}
+
+// decodeConsensus decodes the merged consensus analysis, extracting the JSON from free text
+// when the response was not schema-constrained
+func decodeConsensus(response string, structured bool) (*ConsensusResult, error) {
+	if !structured {
+		start := strings.Index(response, "{")
+		end := strings.LastIndex(response, "}")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("no JSON object found")
+		}
+		response = response[start : end+1]
+	}
+
+	analysis, err := decodeAnalysis(response)
+	if err != nil {
+		return nil, err
+	}
+	var comparison struct {
+		Agreements    []string       `json:"agreements"`
+		Disagreements []Disagreement `json:"disagreements"`
+	}
+	if err := json.Unmarshal([]byte(response), &comparison); err != nil {
+		return nil, fmt.Errorf("error parsing response JSON: %w", err)
+	}
+
+	result := &ConsensusResult{
+		Analysis:      analysis,
+		Agreements:    comparison.Agreements,
+		Disagreements: comparison.Disagreements,
+	}
+	if result.Agreements == nil {
+		result.Agreements = []string{}
+	}
+	if result.Disagreements == nil {
+		result.Disagreements = []Disagreement{}
+	}
+	return result, nil
+}
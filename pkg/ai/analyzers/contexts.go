@@ -0,0 +1,54 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// contextChoice is the AI's answer to a context selection question
+type contextChoice struct {
+	Context string `json:"context"`
+	Reason  string `json:"reason"`
+}
+
+// ChooseContext asks the AI which kubeconfig context a question refers to, such as "which
+// context points at the prod us-east cluster?", from the contexts' names, servers and probed
+// cluster metadata. It returns the context, which is one of the given ones, and why it was chosen.
+func ChooseContext(ctx context.Context, aiService *ai.Service, question string, contexts []k8s.ContextInfo) (string, string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.ChooseContext, map[string]interface{}{
+		"Question": question,
+		"Contexts": contexts,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting AI context selection: %w", err)
+	}
+
+	var choice contextChoice
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end <= start {
+		return "", "", fmt.Errorf("the AI did not answer with a context: %s", strings.TrimSpace(answer))
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &choice); err != nil {
+		return "", "", fmt.Errorf("error reading the AI's context selection: %w", err)
+	}
+	if choice.Context == "" {
+		return "", "", fmt.Errorf("no context matches: %s", choice.Reason)
+	}
+	for _, info := range contexts {
+		if info.Name == choice.Context {
+			return choice.Context, choice.Reason, nil
+		}
+	}
+	return "", "", fmt.Errorf("the AI chose %q, which is not a context in the kubeconfig", choice.Context)
+}
@@ -0,0 +1,30 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// DiagnoseDNS asks the AI why name resolution fails in the cluster, given the state of CoreDNS,
+// its errors, lookups run from a debug pod and the errors applications report
+func DiagnoseDNS(ctx context.Context, aiService *ai.Service, diagnosis *k8s.DNSDiagnosis, logs, symptoms []string) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.DNSDiagnosis, map[string]interface{}{
+		"DNS":      diagnosis,
+		"Logs":     logs,
+		"Symptoms": symptoms,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI DNS diagnosis: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
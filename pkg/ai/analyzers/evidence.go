@@ -0,0 +1,74 @@
+package analyzers
+
+import (
+	"regexp"
+	"strings"
+
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// minEvidenceLength is the shortest log line that is matched against a longer quote,
+// so short lines such as "done" do not vouch for unrelated evidence
+const minEvidenceLength = 12
+
+// quotePrefix matches the timestamp, pod and level tags models copy from prompt log lines
+var quotePrefix = regexp.MustCompile(`^(\[[^\]]*\]\s*)+`)
+
+// quoteSuffix matches the occurrence counts added to sampled prompt log lines
+var quoteSuffix = regexp.MustCompile(`\s*\((seen \d+ times|x\d+)\)$`)
+
+// verifyEvidence checks that the evidence quoted for each root cause appears in the collected
+// log entries or lifecycle events, recording quotes that could not be found so operators can
+// tell verifiable conclusions from unsupported ones
+func verifyEvidence(result *LogAnalysisResult, entries []logs.LogEntry, lifecycle *k8s.WorkloadLifecycle) {
+	if result == nil {
+		return
+	}
+
+	var sources []string
+	for _, entry := range entries {
+		sources = append(sources, normalizeQuote(entry.Content))
+	}
+	if lifecycle != nil {
+		for _, event := range lifecycle.Events {
+			sources = append(sources, normalizeQuote(event.Reason+" "+event.Object+": "+event.Message))
+		}
+		for _, restart := range lifecycle.Restarts {
+			sources = append(sources, normalizeQuote(restart.Pod+"/"+restart.Container+" "+restart.LastTerminationReason))
+		}
+	}
+
+	for i := range result.RootCauses {
+		cause := &result.RootCauses[i]
+		cause.UnverifiedEvidence = nil
+		for _, quote := range cause.Evidence {
+			if !quoteFound(normalizeQuote(quote), sources) {
+				cause.UnverifiedEvidence = append(cause.UnverifiedEvidence, quote)
+			}
+		}
+	}
+}
+
+// quoteFound reports whether a quote is part of a source line, or a source line is part of the quote
+func quoteFound(quote string, sources []string) bool {
+	if quote == "" {
+		return false
+	}
+	for _, source := range sources {
+		if strings.Contains(source, quote) || (len(source) >= minEvidenceLength && strings.Contains(quote, source)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeQuote strips prompt decorations and truncation marks and collapses whitespace
+func normalizeQuote(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.Trim(s, "\"'`")
+	s = quotePrefix.ReplaceAllString(s, "")
+	s = quoteSuffix.ReplaceAllString(s, "")
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "..."), "…")
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
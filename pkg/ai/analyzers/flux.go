@@ -0,0 +1,28 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// TroubleshootFlux asks the AI why Flux Kustomizations and HelmReleases are not ready, given their
+// conditions, sources, dependencies, events and detected failure modes, and how to fix them
+func TroubleshootFlux(ctx context.Context, aiService *ai.Service, objects []*k8s.FluxObject) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.FluxTroubleshoot, map[string]interface{}{
+		"Objects": objects,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI Flux troubleshooting: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
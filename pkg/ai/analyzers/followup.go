@@ -0,0 +1,120 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// maxRelatedLines caps the log lines added to a follow-up question about specific root causes
+const maxRelatedLines = 30
+
+// causeReference matches references to numbered root causes such as "#2" or "cause 2"
+var causeReference = regexp.MustCompile(`(?i)(?:#|cause\s+(?:#|no\.?\s*)?)(\d+)`)
+
+// FollowUpTurn is one question and answer of a follow-up conversation
+type FollowUpTurn struct {
+	Question string
+	Answer   string
+}
+
+// Conversation answers follow-up questions about a completed analysis, keeping the collected
+// logs and previous turns as context so nothing has to be collected again
+type Conversation struct {
+	aiService *ai.Service
+	entries   []logs.LogEntry
+	summary   logs.LogSummary
+	result    *LogAnalysisResult
+	samples   []LogSample
+	baseline  *logs.BaselineDiff
+	trend     *logs.LogTrend
+	lifecycle *k8s.WorkloadLifecycle
+	usage     []k8s.ContainerUsage
+	history   []FollowUpTurn
+}
+
+// NewConversation starts a follow-up conversation about an analysis of log entries, carrying over
+// the analyzer's baseline deviations, log trend, lifecycle events and resource usage
+func (a *LogAnalyzer) NewConversation(entries []logs.LogEntry, summary logs.LogSummary, result *LogAnalysisResult) *Conversation {
+	return &Conversation{
+		aiService: a.aiService,
+		entries:   entries,
+		summary:   summary,
+		result:    result,
+		// Follow-up questions may be about any part of the logs, so sample more widely than the analysis did
+		samples:   sampleLogs(entries, 30, 15, 10, 10),
+		baseline:  a.baseline,
+		trend:     a.trend,
+		lifecycle: a.lifecycle,
+		usage:     a.usage,
+	}
+}
+
+// Ask answers a follow-up question and records it in the conversation history
+func (c *Conversation) Ask(ctx context.Context, question string) (string, error) {
+	prompt, err := c.aiService.RenderPrompt(prompts.LogFollowUp, map[string]interface{}{
+		"Summary":   c.summary,
+		"Samples":   c.samples,
+		"Related":   c.relatedEntries(question),
+		"Result":    c.result,
+		"Baseline":  c.baseline,
+		"Trend":     c.trend,
+		"Lifecycle": c.lifecycle,
+		"Usage":     c.usage,
+		"History":   c.history,
+		"Question":  question,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := c.aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI answer: %w", err)
+	}
+	answer = strings.TrimSpace(answer)
+
+	c.history = append(c.history, FollowUpTurn{Question: question, Answer: answer})
+	return answer, nil
+}
+
+// relatedEntries returns the log lines quoted as evidence for the root causes a question refers to
+func (c *Conversation) relatedEntries(question string) []logs.LogEntry {
+	var quotes []string
+	for _, match := range causeReference.FindAllStringSubmatch(question, -1) {
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 || number > len(c.result.RootCauses) {
+			continue
+		}
+		for _, quote := range c.result.RootCauses[number-1].Evidence {
+			if normalized := normalizeQuote(quote); normalized != "" {
+				quotes = append(quotes, normalized)
+			}
+		}
+	}
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	var related []logs.LogEntry
+	for _, entry := range c.entries {
+		content := []string{normalizeQuote(entry.Content)}
+		for _, quote := range quotes {
+			if quoteFound(quote, content) {
+				related = append(related, entry)
+				break
+			}
+		}
+		if len(related) >= maxRelatedLines {
+			break
+		}
+	}
+	return related
+}
@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// AnalyzeGPUWorkloads asks the AI why GPU workloads fail or cannot be scheduled, given the GPU
+// nodes, device plugins, GPU pods with the CUDA and out of memory errors in their logs, and the
+// detected problems, and for requests, node selector and MIG recommendations
+func AnalyzeGPUWorkloads(ctx context.Context, aiService *ai.Service, report *k8s.GPUReport) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.GPUWorkloads, map[string]interface{}{
+		"GPU": report,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI GPU analysis: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,28 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/hygiene"
+)
+
+// PlanSecretCleanup asks the AI to prioritize the findings of a secret hygiene audit into
+// ordered cleanup steps
+func PlanSecretCleanup(ctx context.Context, aiService *ai.Service, report *hygiene.Report) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.SecretCleanup, map[string]interface{}{
+		"Report": report,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI cleanup plan: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
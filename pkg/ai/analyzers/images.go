@@ -0,0 +1,82 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/images"
+)
+
+// maxImageVulnerabilities limits the vulnerabilities listed per image in a patching plan
+const maxImageVulnerabilities = 15
+
+// patchImage is an image with problems, for the patching plan prompt
+type patchImage struct {
+	Image     string
+	Workloads []string
+	Issues    []string
+	// Vulnerability counts by severity, such as 2 CRITICAL, 14 HIGH
+	Counts          string
+	Vulnerabilities []images.Vulnerability
+	// Number of vulnerabilities not listed
+	More      int
+	ScanError string
+}
+
+// PlanImagePatching asks the AI to prioritize the tag problems and vulnerabilities of the images
+// into a patching plan. Images without problems are only counted.
+func PlanImagePatching(ctx context.Context, aiService *ai.Service, imageList []images.Image, scanner string) (string, error) {
+	var problems []patchImage
+	clean := 0
+	for _, image := range imageList {
+		if len(image.Issues) == 0 && len(image.Vulnerabilities) == 0 && image.ScanError == "" {
+			clean++
+			continue
+		}
+		entry := patchImage{
+			Image:     image.Image,
+			Workloads: image.Workloads,
+			Issues:    image.Issues,
+			Counts:    FormatSeverityCounts(image.Vulnerabilities),
+			ScanError: image.ScanError,
+		}
+		entry.Vulnerabilities = image.Vulnerabilities
+		if len(entry.Vulnerabilities) > maxImageVulnerabilities {
+			entry.More = len(entry.Vulnerabilities) - maxImageVulnerabilities
+			entry.Vulnerabilities = entry.Vulnerabilities[:maxImageVulnerabilities]
+		}
+		problems = append(problems, entry)
+	}
+
+	prompt, err := aiService.RenderPrompt(prompts.ImagePatchPlan, map[string]interface{}{
+		"Images":  problems,
+		"Clean":   clean,
+		"Total":   len(imageList),
+		"Scanner": scanner,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI patching plan: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// FormatSeverityCounts summarizes vulnerabilities by severity, most severe first, such as
+// 2 CRITICAL, 14 HIGH
+func FormatSeverityCounts(vulnerabilities []images.Vulnerability) string {
+	counts := images.CountBySeverity(vulnerabilities)
+	var parts []string
+	for _, severity := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "NEGLIGIBLE", "UNKNOWN"} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
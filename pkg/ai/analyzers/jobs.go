@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// ExplainJobFailure asks the AI why a Job or CronJob fails or never runs, given its settings, its
+// runs with the pods and logs of their attempts, the events recorded for them and the detected
+// problems
+func ExplainJobFailure(ctx context.Context, aiService *ai.Service, report *k8s.JobReport) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.JobFailure, map[string]interface{}{
+		"Report": report,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI job analysis: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"strings"
-	"time"
 
 	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
 	"kube-ai/pkg/k8s/logs"
 )
 
@@ -16,8 +19,8 @@ type LogAnalysisResult struct {
 	// Summary of the logs
 	Summary string `json:"summary"`
 
-	// Identified root causes
-	RootCauses []string `json:"rootCauses"`
+	// Identified root causes, most likely first
+	RootCauses []RootCause `json:"rootCauses"`
 
 	// Potential solutions
 	Solutions []string `json:"solutions"`
@@ -29,158 +32,172 @@ type LogAnalysisResult struct {
 	Severity string `json:"severity"`
 }
 
+// RootCause is a likely cause of the analyzed issues with the evidence supporting it
+type RootCause struct {
+	// Description of the cause
+	Cause string `json:"cause"`
+
+	// Confidence in the cause from 0 to 100
+	Confidence int `json:"confidence"`
+
+	// Log lines and events quoted as supporting the cause
+	Evidence []string `json:"evidence"`
+
+	// Quoted evidence that could not be found in the collected logs or events
+	UnverifiedEvidence []string `json:"unverifiedEvidence,omitempty"`
+}
+
+// UnmarshalJSON accepts both root cause objects and the plain strings returned for
+// prompt templates that predate confidence and evidence
+func (r *RootCause) UnmarshalJSON(data []byte) error {
+	var cause string
+	if err := json.Unmarshal(data, &cause); err == nil {
+		*r = RootCause{Cause: cause}
+		return nil
+	}
+
+	// Models sometimes answer with a fraction instead of a percentage
+	var decoded struct {
+		Cause              string   `json:"cause"`
+		Confidence         float64  `json:"confidence"`
+		Evidence           []string `json:"evidence"`
+		UnverifiedEvidence []string `json:"unverifiedEvidence"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Confidence > 0 && decoded.Confidence < 1 {
+		decoded.Confidence *= 100
+	}
+
+	*r = RootCause{
+		Cause:              decoded.Cause,
+		Confidence:         int(math.Round(math.Max(0, math.Min(100, decoded.Confidence)))),
+		Evidence:           decoded.Evidence,
+		UnverifiedEvidence: decoded.UnverifiedEvidence,
+	}
+	return nil
+}
+
 // LogAnalyzer handles AI analysis of Kubernetes logs
 type LogAnalyzer struct {
 	aiService *ai.Service
+	// Estimated token budget per request; larger log volumes are analyzed in chunks (0 disables chunking)
+	chunkTokens int
+	// Destination for progress messages of chunked analyses (nil for none)
+	progress io.Writer
+	// Deviations from the workload's baseline to emphasize in prompts (nil for none)
+	baseline *logs.BaselineDiff
+	// Rolling summary of the workload's logs over the last days (nil for none)
+	trend *logs.LogTrend
+	// Restarts and lifecycle events of the workload in the same time window (nil for none)
+	lifecycle *k8s.WorkloadLifecycle
+	// Live resource usage of the workload's containers (nil for none)
+	usage []k8s.ContainerUsage
+	// Optional data sources left out of the collection (nil for none)
+	skipped []k8s.SkippedSource
+	// Alert the analysis was requested for ("" for none)
+	alert string
 }
 
 // NewLogAnalyzer creates a new log analyzer
 func NewLogAnalyzer(aiService *ai.Service) *LogAnalyzer {
 	return &LogAnalyzer{
-		aiService: aiService,
+		aiService:   aiService,
+		chunkTokens: DefaultChunkTokens,
 	}
 }
 
-// AnalyzeLogs uses AI to analyze log entries and provide insights
-func (a *LogAnalyzer) AnalyzeLogs(ctx context.Context, logEntries []logs.LogEntry, summary logs.LogSummary) (*LogAnalysisResult, error) {
-	// Prepare the AI prompt with log information
-	prompt := a.buildLogAnalysisPrompt(logEntries, summary)
+// SetChunkTokens sets the estimated token budget per request (0 disables chunked analysis)
+func (a *LogAnalyzer) SetChunkTokens(tokens int) {
+	a.chunkTokens = tokens
+}
 
-	// Call the AI service for analysis
-	response, err := a.aiService.Query(ctx, prompt)
-	if err != nil {
-		return nil, fmt.Errorf("error getting AI analysis: %w", err)
-	}
+// SetProgress sets where progress of chunked analyses is reported
+func (a *LogAnalyzer) SetProgress(w io.Writer) {
+	a.progress = w
+}
 
-	// Parse the AI response into a structured result
-	result, err := parseAIResponse(response)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing AI response: %w", err)
-	}
+// SetBaseline sets deviations from the workload's baseline so the analysis focuses on what changed
+func (a *LogAnalyzer) SetBaseline(diff *logs.BaselineDiff) {
+	a.baseline = diff
+}
 
-	return result, nil
+// SetTrend sets the summary of the workload's logs recorded over the last days, so a new problem
+// can be told from a chronic one
+func (a *LogAnalyzer) SetTrend(trend *logs.LogTrend) {
+	a.trend = trend
 }
 
-// buildLogAnalysisPrompt creates a prompt for the AI to analyze logs
-func (a *LogAnalyzer) buildLogAnalysisPrompt(logEntries []logs.LogEntry, summary logs.LogSummary) string {
-	var sb strings.Builder
-
-	// System context
-	sb.WriteString("You are an expert Kubernetes troubleshooter. Analyze these logs to identify issues, ")
-	sb.WriteString("determine root causes, and suggest solutions.\n\n")
-
-	// Add summary statistics
-	sb.WriteString("## Log Summary\n")
-	sb.WriteString(fmt.Sprintf("- Total log entries: %d\n", summary.TotalEntries))
-	sb.WriteString(fmt.Sprintf("- Error count: %d\n", summary.ErrorCount))
-	sb.WriteString(fmt.Sprintf("- Warning count: %d\n", summary.WarningCount))
-	sb.WriteString(fmt.Sprintf("- Time range: %s to %s (%s)\n\n",
-		summary.TimeRange.Start.Format(time.RFC3339),
-		summary.TimeRange.End.Format(time.RFC3339),
-		summary.TimeRange.Duration.String()))
-
-	// Add error hotspots
-	if len(summary.ErrorHotspots) > 0 {
-		sb.WriteString("## Error Hotspots\n")
-		for _, hotspot := range summary.ErrorHotspots {
-			sb.WriteString(fmt.Sprintf("- %s: %d errors\n", hotspot.ResourceName, hotspot.ErrorCount))
-		}
-		sb.WriteString("\n")
-	}
+// SetLifecycle sets the workload's restarts and lifecycle events so log errors can be correlated with them
+func (a *LogAnalyzer) SetLifecycle(lifecycle *k8s.WorkloadLifecycle) {
+	a.lifecycle = lifecycle
+}
 
-	// Add common errors
-	if len(summary.CommonErrors) > 0 {
-		sb.WriteString("## Common Errors\n")
-		for _, pattern := range summary.CommonErrors {
-			sb.WriteString(fmt.Sprintf("- Pattern: %s (count: %d)\n", pattern.Pattern, pattern.Count))
-			if len(pattern.Examples) > 0 {
-				sb.WriteString(fmt.Sprintf("  Example: %s\n", pattern.Examples[0].Content))
-			}
-		}
-		sb.WriteString("\n")
-	}
+// SetResourceUsage sets the live usage of the workload's containers against their requests and
+// limits, so memory pressure and CPU throttling can be told from application errors
+func (a *LogAnalyzer) SetResourceUsage(usage []k8s.ContainerUsage) {
+	a.usage = usage
+}
 
-	// Add potential issues already detected
-	if len(summary.PotentialIssues) > 0 {
-		sb.WriteString("## Detected Issues\n")
-		for _, issue := range summary.PotentialIssues {
-			sb.WriteString(fmt.Sprintf("- %s\n", issue))
-		}
-		sb.WriteString("\n")
-	}
+// SetSkippedSources sets the optional data sources that could not be collected, so the analysis
+// does not read their absence as a healthy signal
+func (a *LogAnalyzer) SetSkippedSources(skipped []k8s.SkippedSource) {
+	a.skipped = skipped
+}
 
-	// Add representative log samples
-	// We'll include a mix of errors, warnings, and regular logs
-	sb.WriteString("## Log Samples\n")
+// SetAlert sets the alert the analysis was requested for, so the analysis explains it
+func (a *LogAnalyzer) SetAlert(alert string) {
+	a.alert = alert
+}
 
-	// Add error samples (up to 10)
-	errorCount := 0
-	for _, entry := range logEntries {
-		if entry.LogLevel == "ERROR" || entry.LogLevel == "FATAL" {
-			sb.WriteString(fmt.Sprintf("[%s] [%s] %s\n",
-				entry.Timestamp.Format(time.RFC3339),
-				entry.LogLevel,
-				entry.Content))
-			errorCount++
-			if errorCount >= 10 {
-				break
-			}
+// AnalyzeLogs uses AI to analyze log entries and provide insights
+func (a *LogAnalyzer) AnalyzeLogs(ctx context.Context, logEntries []logs.LogEntry, summary logs.LogSummary) (*LogAnalysisResult, error) {
+	// Logs too large for one request are analyzed window by window and merged
+	if a.needsChunking(logEntries) {
+		result, err := a.analyzeChunked(ctx, logEntries, summary)
+		if err != nil {
+			return nil, err
 		}
+		verifyEvidence(result, logEntries, a.lifecycle)
+		dropUnsupportedSolutions(a.aiService, result)
+		return result, nil
 	}
 
-	// Add warning samples (up to 5)
-	warningCount := 0
-	for _, entry := range logEntries {
-		if entry.LogLevel == "WARN" || entry.LogLevel == "WARNING" {
-			sb.WriteString(fmt.Sprintf("[%s] [%s] %s\n",
-				entry.Timestamp.Format(time.RFC3339),
-				entry.LogLevel,
-				entry.Content))
-			warningCount++
-			if warningCount >= 5 {
-				break
-			}
-		}
+	// Prepare the AI prompt with log information
+	prompt, err := a.buildLogAnalysisPrompt(logEntries, summary)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add some regular logs for context (up to 5)
-	infoCount := 0
-	for _, entry := range logEntries {
-		if entry.LogLevel == "INFO" {
-			sb.WriteString(fmt.Sprintf("[%s] [%s] %s\n",
-				entry.Timestamp.Format(time.RFC3339),
-				entry.LogLevel,
-				entry.Content))
-			infoCount++
-			if infoCount >= 5 {
-				break
-			}
-		}
+	// Call the AI service for a structured analysis
+	result, err := queryAnalysis(ctx, a.aiService, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("error getting AI analysis: %w", err)
 	}
 
-	sb.WriteString("\n")
-
-	// Add request for specific analysis
-	sb.WriteString("## Analysis Request\n")
-	sb.WriteString("Based on the logs and summary provided, please analyze the following:\n")
-	sb.WriteString("1. Provide a brief summary of the issues observed in the logs\n")
-	sb.WriteString("2. Identify the most likely root causes of the issues\n")
-	sb.WriteString("3. Suggest specific solutions to address the problems\n")
-	sb.WriteString("4. Add any additional information or context that might be helpful\n")
-	sb.WriteString("5. Assess the severity (Low, Medium, High, Critical)\n\n")
-
-	sb.WriteString("Format your response as JSON with the following structure:\n")
-	sb.WriteString("```json\n")
-	sb.WriteString("{\n")
-	sb.WriteString("  \"summary\": \"Brief description of the issues\",\n")
-	sb.WriteString("  \"rootCauses\": [\"Cause 1\", \"Cause 2\", ...],\n")
-	sb.WriteString("  \"solutions\": [\"Solution 1\", \"Solution 2\", ...],\n")
-	sb.WriteString("  \"additionalInfo\": [\"Info 1\", \"Info 2\", ...],\n")
-	sb.WriteString("  \"severity\": \"Low|Medium|High|Critical\"\n")
-	sb.WriteString("}\n")
-	sb.WriteString("```\n")
-
-	return sb.String()
+	verifyEvidence(result, logEntries, a.lifecycle)
+	dropUnsupportedSolutions(a.aiService, result)
+	return result, nil
+}
+
+// buildLogAnalysisPrompt creates a prompt for the AI to analyze logs from the log-analysis template
+func (a *LogAnalyzer) buildLogAnalysisPrompt(logEntries []logs.LogEntry, summary logs.LogSummary) (string, error) {
+	// Include distinct error (up to 10) and warning (up to 5) patterns, lines around
+	// an error spike (up to 5) and regular logs (up to 5)
+	samples := sampleLogs(logEntries, 10, 5, 5, 5)
+
+	return a.aiService.RenderPrompt(prompts.LogAnalysis, map[string]interface{}{
+		"Entries":   logEntries,
+		"Summary":   summary,
+		"Samples":   samples,
+		"Baseline":  a.baseline,
+		"Trend":     a.trend,
+		"Lifecycle": a.lifecycle,
+		"Usage":     a.usage,
+		"Skipped":   a.skipped,
+		"Alert":     a.alert,
+	})
 }
 
 // parseAIResponse parses the AI response into a structured LogAnalysisResult
@@ -196,7 +213,7 @@ func parseAIResponse(response string) (*LogAnalysisResult, error) {
 		// Create a default result
 		result := &LogAnalysisResult{
 			Summary:        "The AI provided an unstructured response.",
-			RootCauses:     []string{},
+			RootCauses:     []RootCause{},
 			Solutions:      []string{},
 			AdditionalInfo: []string{response},
 			Severity:       "Medium",
@@ -222,7 +239,7 @@ func parseAIResponse(response string) (*LogAnalysisResult, error) {
 				result.Summary += line + " "
 			case strings.Contains(section, "root") || strings.Contains(section, "cause"):
 				if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") {
-					result.RootCauses = append(result.RootCauses, strings.TrimLeft(line, "- *"))
+					result.RootCauses = append(result.RootCauses, RootCause{Cause: strings.TrimLeft(line, "- *")})
 				}
 			case strings.Contains(section, "solution") || strings.Contains(section, "recommend"):
 				if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") {
@@ -249,33 +266,8 @@ func parseAIResponse(response string) (*LogAnalysisResult, error) {
 		return result, nil
 	}
 
-	// Extract the JSON part
-	jsonStr := response[jsonStart : jsonEnd+1]
-
-	// Try to parse the JSON
-	var result LogAnalysisResult
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		return nil, fmt.Errorf("error parsing response JSON: %w", err)
-	}
-
-	// Ensure we have valid values for required fields
-	if result.Summary == "" {
-		result.Summary = "No summary provided by AI analysis."
-	}
-
-	if len(result.RootCauses) == 0 {
-		result.RootCauses = []string{"No root causes identified in AI analysis."}
-	}
-
-	if len(result.Solutions) == 0 {
-		result.Solutions = []string{"No solutions provided by AI analysis."}
-	}
-
-	if result.Severity == "" {
-		result.Severity = "Medium"
-	}
-
-	return &result, nil
+	// Extract and decode the JSON part
+	return decodeAnalysis(response[jsonStart : jsonEnd+1])
 }
 
 // AnalyzeErrorLogs focuses analysis specifically on error logs
@@ -291,7 +283,7 @@ func (a *LogAnalyzer) AnalyzeErrorLogs(ctx context.Context, logEntries []logs.Lo
 	if len(errorLogs) == 0 {
 		return &LogAnalysisResult{
 			Summary:        "No error logs found",
-			RootCauses:     []string{"No errors detected in logs"},
+			RootCauses:     []RootCause{{Cause: "No errors detected in logs", Confidence: 100}},
 			Solutions:      []string{"No action needed"},
 			AdditionalInfo: []string{"The logs contain no error or fatal level entries"},
 			Severity:       "Low",
@@ -301,96 +293,45 @@ func (a *LogAnalyzer) AnalyzeErrorLogs(ctx context.Context, logEntries []logs.Lo
 	// Create a summary just for the error logs
 	summary := logs.ParseLogs(errorLogs)
 
-	// Build a specialized prompt for error analysis
-	prompt := a.buildErrorAnalysisPrompt(errorLogs, summary)
+	if a.needsChunking(errorLogs) {
+		result, err := a.analyzeChunked(ctx, errorLogs, summary)
+		if err != nil {
+			return nil, err
+		}
+		verifyEvidence(result, logEntries, a.lifecycle)
+		dropUnsupportedSolutions(a.aiService, result)
+		return result, nil
+	}
 
-	// Call the AI service for analysis
-	response, err := a.aiService.Query(ctx, prompt)
+	// Build a specialized prompt for error analysis
+	prompt, err := a.buildErrorAnalysisPrompt(errorLogs, summary)
 	if err != nil {
-		return nil, fmt.Errorf("error getting AI error analysis: %w", err)
+		return nil, err
 	}
 
-	// Parse the AI response
-	result, err := parseAIResponse(response)
+	// Call the AI service for a structured analysis
+	result, err := queryAnalysis(ctx, a.aiService, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing AI error analysis response: %w", err)
+		return nil, fmt.Errorf("error getting AI error analysis: %w", err)
 	}
 
+	verifyEvidence(result, logEntries, a.lifecycle)
+	dropUnsupportedSolutions(a.aiService, result)
 	return result, nil
 }
 
-// buildErrorAnalysisPrompt creates a specialized prompt for error analysis
-func (a *LogAnalyzer) buildErrorAnalysisPrompt(errorLogs []logs.LogEntry, summary logs.LogSummary) string {
-	var sb strings.Builder
-
-	// System context
-	sb.WriteString("You are an expert Kubernetes troubleshooter. Analyze these error logs to identify issues, ")
-	sb.WriteString("determine root causes, and suggest solutions. Focus specifically on the errors.\n\n")
-
-	// Add summary statistics
-	sb.WriteString("## Error Log Summary\n")
-	sb.WriteString(fmt.Sprintf("- Total error entries: %d\n", summary.TotalEntries))
-	sb.WriteString(fmt.Sprintf("- Time range: %s to %s (%s)\n\n",
-		summary.TimeRange.Start.Format(time.RFC3339),
-		summary.TimeRange.End.Format(time.RFC3339),
-		summary.TimeRange.Duration.String()))
-
-	// Add error hotspots
-	if len(summary.ErrorHotspots) > 0 {
-		sb.WriteString("## Error Hotspots\n")
-		for _, hotspot := range summary.ErrorHotspots {
-			sb.WriteString(fmt.Sprintf("- %s: %d errors\n", hotspot.ResourceName, hotspot.ErrorCount))
-		}
-		sb.WriteString("\n")
-	}
-
-	// Add common errors
-	if len(summary.CommonErrors) > 0 {
-		sb.WriteString("## Common Errors\n")
-		for _, pattern := range summary.CommonErrors {
-			sb.WriteString(fmt.Sprintf("- Pattern: %s (count: %d)\n", pattern.Pattern, pattern.Count))
-			if len(pattern.Examples) > 0 {
-				sb.WriteString(fmt.Sprintf("  Example: %s\n", pattern.Examples[0].Content))
-			}
-		}
-		sb.WriteString("\n")
-	}
-
-	// Add error log samples (up to 20)
-	sb.WriteString("## Error Log Samples\n")
-	sampleCount := 0
-	for _, entry := range errorLogs {
-		sb.WriteString(fmt.Sprintf("[%s] [%s] [%s] %s\n",
-			entry.Timestamp.Format(time.RFC3339),
-			entry.PodName,
-			entry.LogLevel,
-			entry.Content))
-		sampleCount++
-		if sampleCount >= 20 {
-			break
-		}
-	}
-	sb.WriteString("\n")
-
-	// Add request for specific analysis
-	sb.WriteString("## Analysis Request\n")
-	sb.WriteString("Based on the error logs provided, please analyze the following:\n")
-	sb.WriteString("1. Provide a brief summary of the errors observed\n")
-	sb.WriteString("2. Identify the most likely root causes of the errors\n")
-	sb.WriteString("3. Suggest specific solutions to address the problems\n")
-	sb.WriteString("4. Add any additional information or context that might be helpful\n")
-	sb.WriteString("5. Assess the severity (Low, Medium, High, Critical)\n\n")
-
-	sb.WriteString("Format your response as JSON with the following structure:\n")
-	sb.WriteString("```json\n")
-	sb.WriteString("{\n")
-	sb.WriteString("  \"summary\": \"Brief description of the errors\",\n")
-	sb.WriteString("  \"rootCauses\": [\"Cause 1\", \"Cause 2\", ...],\n")
-	sb.WriteString("  \"solutions\": [\"Solution 1\", \"Solution 2\", ...],\n")
-	sb.WriteString("  \"additionalInfo\": [\"Info 1\", \"Info 2\", ...],\n")
-	sb.WriteString("  \"severity\": \"Low|Medium|High|Critical\"\n")
-	sb.WriteString("}\n")
-	sb.WriteString("```\n")
-
-	return sb.String()
+// buildErrorAnalysisPrompt creates a specialized prompt for error analysis from the log-error-analysis template
+func (a *LogAnalyzer) buildErrorAnalysisPrompt(errorLogs []logs.LogEntry, summary logs.LogSummary) (string, error) {
+	// Include up to 20 distinct error patterns, earliest occurrence of each
+	return a.aiService.RenderPrompt(prompts.LogErrorAnalysis, map[string]interface{}{
+		"Entries":   errorLogs,
+		"Summary":   summary,
+		"Samples":   sampleLogs(errorLogs, 20, 0, 0, 0),
+		"Baseline":  a.baseline,
+		"Trend":     a.trend,
+		"Lifecycle": a.lifecycle,
+		"Usage":     a.usage,
+		"Skipped":   a.skipped,
+		"Alert":     a.alert,
+	})
 }
@@ -0,0 +1,157 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// ManifestFinding is a single issue found in a Kubernetes manifest
+type ManifestFinding struct {
+	// Short title of the issue
+	Title string `json:"title"`
+
+	// Severity level (Low, Medium, High, Critical)
+	Severity string `json:"severity"`
+
+	// Category of the issue (security, reliability, resources, best-practice)
+	Category string `json:"category"`
+
+	// Resource the issue was found in, as kind/name
+	Resource string `json:"resource"`
+
+	// Dotted path of the offending field (e.g. spec.template.spec.containers[0].resources), if any
+	Field string `json:"field"`
+
+	// Explanation of the issue and its impact
+	Description string `json:"description"`
+
+	// How to fix the issue
+	Recommendation string `json:"recommendation"`
+
+	// Line of the field in the analyzed file (0 if unknown)
+	Line int `json:"line,omitempty"`
+
+	// Analyzer plugin that reported the issue, as plugin:<name> (empty for the AI analysis)
+	Source string `json:"source,omitempty"`
+}
+
+// ManifestAnalysisResult represents the AI-generated findings for a set of manifests
+type ManifestAnalysisResult struct {
+	// Overall assessment of the manifests
+	Summary string `json:"summary"`
+
+	// Issues found, most severe first
+	Findings []ManifestFinding `json:"findings"`
+
+	// Findings left out because their recommendation needs a feature the cluster does not support
+	LeftOut []string `json:"leftOut,omitempty"`
+}
+
+// manifestAnalysisSchema is the JSON schema of ManifestAnalysisResult, used to request schema-constrained responses
+var manifestAnalysisSchema = providers.ResponseSchema{
+	Name:        "manifest_analysis",
+	Description: "Issues found in Kubernetes manifests",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"summary": map[string]interface{}{"type": "string"},
+			"findings": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"title":    map[string]interface{}{"type": "string"},
+						"severity": map[string]interface{}{"type": "string", "enum": []string{"Low", "Medium", "High", "Critical"}},
+						"category": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"security", "reliability", "resources", "best-practice"},
+						},
+						"resource":       map[string]interface{}{"type": "string", "description": "kind/name of the resource"},
+						"field":          map[string]interface{}{"type": "string", "description": "Dotted path of the offending field, or empty"},
+						"description":    map[string]interface{}{"type": "string"},
+						"recommendation": map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"title", "severity", "category", "resource", "field", "description", "recommendation"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"summary", "findings"},
+		"additionalProperties": false,
+	},
+}
+
+// ManifestAnalyzer turns Kubernetes manifests into structured findings
+type ManifestAnalyzer struct {
+	aiService *ai.Service
+}
+
+// NewManifestAnalyzer creates a new manifest analyzer
+func NewManifestAnalyzer(aiService *ai.Service) *ManifestAnalyzer {
+	return &ManifestAnalyzer{
+		aiService: aiService,
+	}
+}
+
+// AnalyzeManifest uses AI to find security, reliability and resource issues in manifests
+func (a *ManifestAnalyzer) AnalyzeManifest(ctx context.Context, manifest string) (*ManifestAnalysisResult, error) {
+	prompt, err := a.aiService.RenderPrompt(prompts.ManifestFindings, map[string]interface{}{
+		"Manifest": manifest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, structured, err := a.aiService.QueryStructured(ctx, prompt, manifestAnalysisSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error getting AI analysis: %w", err)
+	}
+
+	if !structured {
+		start := strings.Index(response, "{")
+		end := strings.LastIndex(response, "}")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("error parsing AI response: no JSON object found")
+		}
+		response = response[start : end+1]
+	}
+
+	var result ManifestAnalysisResult
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		return nil, fmt.Errorf("error parsing AI response: %w", err)
+	}
+
+	for i := range result.Findings {
+		if result.Findings[i].Severity == "" {
+			result.Findings[i].Severity = "Medium"
+		}
+	}
+	dropUnsupportedFindings(a.aiService, &result)
+	return &result, nil
+}
+
+// Locate resolves the resource and field of each finding in the parsed manifest file, setting
+// the finding's line and normalizing its field to the path found in the file. Fields that do not
+// exist, such as a missing resources section, point at their closest existing parent.
+func (r *ManifestAnalysisResult) Locate(documents []manifest.Document) {
+	for i := range r.Findings {
+		finding := &r.Findings[i]
+		document := manifest.Find(documents, finding.Resource)
+		if document == nil {
+			continue
+		}
+
+		location := document.Locate(finding.Field)
+		finding.Line = location.Line
+		if location.Exact && location.Path != "" {
+			finding.Field = location.Path
+		}
+	}
+}
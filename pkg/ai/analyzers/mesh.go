@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// AnalyzeMeshConfig asks the AI to analyze the Istio configuration of a workload, given the
+// problems detected in it and the errors in its Envoy sidecar logs, and to suggest fixes
+func AnalyzeMeshConfig(ctx context.Context, aiService *ai.Service, mesh *k8s.MeshConfig, proxyLogs []string) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.MeshConfig, map[string]interface{}{
+		"Mesh":      mesh,
+		"ProxyLogs": proxyLogs,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI service mesh analysis: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
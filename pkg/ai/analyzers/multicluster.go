@@ -0,0 +1,122 @@
+package analyzers
+
+import (
+	"sort"
+	"strings"
+)
+
+// severityRank orders severities from least to most severe
+var severityRank = map[string]int{
+	"Low":      1,
+	"Medium":   2,
+	"High":     3,
+	"Critical": 4,
+}
+
+// SeverityRank returns the rank of a severity (higher is more severe, 0 if unknown)
+func SeverityRank(severity string) int {
+	return severityRank[severity]
+}
+
+// ClusterLogAnalysis is the log analysis of a single cluster in a multi-cluster run
+type ClusterLogAnalysis struct {
+	// Kubeconfig context the analysis ran against
+	Cluster string `json:"cluster"`
+	// Namespace that was analyzed
+	Namespace string `json:"namespace"`
+	// Number of log entries collected
+	TotalEntries int `json:"totalEntries"`
+	// Number of error entries
+	ErrorCount int `json:"errorCount"`
+	// Number of warning entries
+	WarningCount int `json:"warningCount"`
+	// AI analysis of the cluster's logs
+	Analysis *LogAnalysisResult `json:"analysis,omitempty"`
+	// Error that prevented the analysis, if any
+	Error string `json:"error,omitempty"`
+}
+
+// ClusterFinding is a root cause or solution tagged with the clusters it was reported for
+type ClusterFinding struct {
+	// The finding text
+	Text string `json:"text"`
+	// Clusters the finding was reported for
+	Clusters []string `json:"clusters"`
+}
+
+// MultiClusterReport merges per-cluster analyses into a comparison report
+type MultiClusterReport struct {
+	// Per-cluster results, in the order the clusters were given
+	Clusters []ClusterLogAnalysis `json:"clusters"`
+	// Highest severity reported by any cluster
+	Severity string `json:"severity"`
+	// Root causes, deduplicated across clusters
+	RootCauses []ClusterFinding `json:"rootCauses"`
+	// Solutions, deduplicated across clusters
+	Solutions []ClusterFinding `json:"solutions"`
+}
+
+// MergeClusterAnalyses builds a comparison report, grouping identical findings across clusters
+// so issues common to every cluster stand out from cluster-specific ones
+func MergeClusterAnalyses(results []ClusterLogAnalysis) *MultiClusterReport {
+	report := &MultiClusterReport{Clusters: results}
+
+	rootCauses := newFindingSet()
+	solutions := newFindingSet()
+
+	for _, result := range results {
+		if result.Analysis == nil {
+			continue
+		}
+		if severityRank[result.Analysis.Severity] > severityRank[report.Severity] {
+			report.Severity = result.Analysis.Severity
+		}
+		for _, cause := range result.Analysis.RootCauses {
+			rootCauses.add(cause.Cause, result.Cluster)
+		}
+		for _, solution := range result.Analysis.Solutions {
+			solutions.add(solution, result.Cluster)
+		}
+	}
+
+	report.RootCauses = rootCauses.sorted()
+	report.Solutions = solutions.sorted()
+
+	return report
+}
+
+// findingSet deduplicates findings case-insensitively while preserving first-seen order
+type findingSet struct {
+	index    map[string]int
+	findings []ClusterFinding
+}
+
+func newFindingSet() *findingSet {
+	return &findingSet{index: make(map[string]int)}
+}
+
+func (s *findingSet) add(text, cluster string) {
+	key := strings.ToLower(strings.TrimSpace(text))
+	if key == "" {
+		return
+	}
+	if i, ok := s.index[key]; ok {
+		for _, existing := range s.findings[i].Clusters {
+			if existing == cluster {
+				return
+			}
+		}
+		s.findings[i].Clusters = append(s.findings[i].Clusters, cluster)
+		return
+	}
+	s.index[key] = len(s.findings)
+	s.findings = append(s.findings, ClusterFinding{Text: text, Clusters: []string{cluster}})
+}
+
+// sorted returns findings reported by the most clusters first
+func (s *findingSet) sorted() []ClusterFinding {
+	sort.SliceStable(s.findings, func(i, j int) bool {
+		return len(s.findings[i].Clusters) > len(s.findings[j].Clusters)
+	})
+	return s.findings
+}
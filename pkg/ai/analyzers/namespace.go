@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// SummarizeNamespace asks the AI for a short narrative of a namespace: what it appears to run,
+// its health and its notable risks, given its workloads, services, ingresses, quotas, recent
+// warning events and the detected risks
+func SummarizeNamespace(ctx context.Context, aiService *ai.Service, summary *k8s.NamespaceSummary) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.NamespaceSummary, map[string]interface{}{
+		"Summary": summary,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI namespace summary: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
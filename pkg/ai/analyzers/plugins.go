@@ -0,0 +1,220 @@
+package analyzers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PluginPrefix is the name prefix of the executables on PATH discovered as analyzer plugins
+const PluginPrefix = "kube-ai-analyzer-"
+
+// PluginAPIVersion is the version of the JSON contract between kube-ai and analyzer plugins
+const PluginAPIVersion = "kube-ai/v1"
+
+// pluginTimeout bounds the run of a single analyzer plugin
+const pluginTimeout = 2 * time.Minute
+
+// maxPluginOutput bounds the output read from an analyzer plugin
+const maxPluginOutput = 10 << 20
+
+// Plugin is an external analyzer: an executable named kube-ai-analyzer-<name> on PATH
+type Plugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// PluginInput is the JSON document written to an analyzer plugin's standard input
+type PluginInput struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// What was analyzed: a file name, or kind/name of a cluster resource
+	Source string `json:"source"`
+
+	// Namespace of a cluster resource, empty for files
+	Namespace string `json:"namespace,omitempty"`
+
+	// Kube context of a cluster resource, empty for files
+	Context string `json:"context,omitempty"`
+
+	// The manifests analyzed, as YAML
+	Manifest string `json:"manifest"`
+}
+
+// PluginOutput is the JSON document an analyzer plugin writes to its standard output
+type PluginOutput struct {
+	Findings []ManifestFinding `json:"findings"`
+}
+
+// DiscoverPlugins finds the analyzer plugins on PATH. When several directories hold a plugin of
+// the same name, the first one wins, as it would for a shell.
+func DiscoverPlugins() []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// SelectPlugins returns the discovered plugins with the given names, or all of them for "all"
+func SelectPlugins(names []string) ([]Plugin, error) {
+	discovered := DiscoverPlugins()
+	var selected []Plugin
+	for _, name := range names {
+		if name == "all" {
+			return discovered, nil
+		}
+		found := false
+		for _, plugin := range discovered {
+			if plugin.Name == name {
+				selected = append(selected, plugin)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("analyzer plugin %q not found: no %s%s executable on PATH", name, PluginPrefix, name)
+		}
+	}
+	return selected, nil
+}
+
+// Run invokes the plugin with the input as JSON on its standard input and parses the findings
+// from its standard output. A plugin reports failure with a non-zero exit status; what it wrote
+// to standard error is included in the error.
+func (p Plugin) Run(ctx context.Context, input PluginInput) ([]ManifestFinding, error) {
+	input.APIVersion = PluginAPIVersion
+	input.Kind = "AnalyzerInput"
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding plugin input: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &limitedWriter{w: &stdout, n: maxPluginOutput}
+	cmd.Stderr = &limitedWriter{w: &stderr, n: 64 << 10}
+	cmd.Env = append(os.Environ(), "KUBE_AI_PLUGIN_API_VERSION="+PluginAPIVersion)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("analyzer plugin %s timed out after %s", p.Name, pluginTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("analyzer plugin %s failed: %w: %s", p.Name, err, message)
+		}
+		return nil, fmt.Errorf("analyzer plugin %s failed: %w", p.Name, err)
+	}
+
+	var output PluginOutput
+	decoder := json.NewDecoder(&stdout)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&output); err != nil {
+		return nil, fmt.Errorf("analyzer plugin %s returned invalid output: %w", p.Name, err)
+	}
+
+	for i := range output.Findings {
+		finding := &output.Findings[i]
+		if finding.Title == "" {
+			return nil, fmt.Errorf("analyzer plugin %s returned a finding without a title", p.Name)
+		}
+		finding.Severity = normalizeSeverity(finding.Severity)
+		if finding.Category == "" {
+			finding.Category = "best-practice"
+		}
+		finding.Source = "plugin:" + p.Name
+	}
+	return output.Findings, nil
+}
+
+// normalizeSeverity maps a plugin's severity to one of Low, Medium, High and Critical,
+// defaulting to Medium
+func normalizeSeverity(severity string) string {
+	for _, known := range []string{"Low", "Medium", "High", "Critical"} {
+		if strings.EqualFold(severity, known) {
+			return known
+		}
+	}
+	return "Medium"
+}
+
+// pluginName returns the plugin name of an executable file name
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, PluginPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, PluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+// isExecutable reports whether a path is a regular file that can be executed
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return info.Mode()&0111 != 0
+}
+
+// limitedWriter discards what is written past its limit, so a runaway plugin cannot exhaust
+// memory; the truncated output then fails to parse
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.n <= 0 {
+		return len(p), nil
+	}
+	written := p
+	if len(written) > l.n {
+		written = written[:l.n]
+	}
+	l.n -= len(written)
+	if _, err := l.w.Write(written); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
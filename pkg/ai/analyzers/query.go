@@ -0,0 +1,89 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/k8s"
+)
+
+// QueryPlan is the AI's translation of a question about the cluster into a typed query
+type QueryPlan struct {
+	k8s.ResourceQuery
+	// What the query leaves out of the question, if anything
+	Notes string `json:"notes,omitempty"`
+}
+
+// queryPlanSchema is the JSON schema of QueryPlan, used to request schema-constrained responses
+var queryPlanSchema = providers.ResponseSchema{
+	Name:        "resource_query",
+	Description: "A query for Kubernetes objects of one resource type",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"resource":      map[string]interface{}{"type": "string"},
+			"namespace":     map[string]interface{}{"type": "string"},
+			"allNamespaces": map[string]interface{}{"type": "boolean"},
+			"labelSelector": map[string]interface{}{"type": "string"},
+			"fieldSelector": map[string]interface{}{"type": "string"},
+			"filters": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"field":    map[string]interface{}{"type": "string"},
+						"operator": map[string]interface{}{"type": "string", "enum": k8s.FilterOperators},
+						"value":    map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"field", "operator", "value"},
+					"additionalProperties": false,
+				},
+			},
+			"notes": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"resource", "namespace", "allNamespaces", "labelSelector", "fieldSelector", "filters", "notes"},
+		"additionalProperties": false,
+	},
+}
+
+// PlanQuery asks the AI to translate a question about the cluster into a query. The AI only
+// plans: the query is run against the cluster, so the answer comes from the objects themselves.
+func PlanQuery(ctx context.Context, aiService *ai.Service, question, namespace string, commandContext *k8s.CommandContext) (*QueryPlan, error) {
+	prompt, err := aiService.RenderPrompt(prompts.ResourceQuery, map[string]interface{}{
+		"Query":          question,
+		"Namespace":      namespace,
+		"Context":        commandContext,
+		"ComputedFields": k8s.ComputedFields,
+		"Operators":      k8s.FilterOperators,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, structured, err := aiService.QueryStructured(ctx, prompt, queryPlanSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error getting AI query plan: %w", err)
+	}
+	if !structured {
+		start := strings.Index(response, "{")
+		end := strings.LastIndex(response, "}")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("error parsing AI response: no JSON object found")
+		}
+		response = response[start : end+1]
+	}
+
+	var plan QueryPlan
+	if err := json.Unmarshal([]byte(response), &plan); err != nil {
+		return nil, fmt.Errorf("error parsing AI response: %w", err)
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, fmt.Errorf("the AI planned an invalid query: %w", err)
+	}
+	return &plan, nil
+}
@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// AssessRolloutRisk asks the AI to assess the rollout and eviction risk of a workload from its
+// rollout profile and the risks detected in it, and to suggest spec changes
+func AssessRolloutRisk(ctx context.Context, aiService *ai.Service, profile *k8s.RolloutProfile, risks []string) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.RolloutRisk, map[string]interface{}{
+		"Profile": profile,
+		"Risks":   risks,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI rollout risk assessment: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,137 @@
+package analyzers
+
+import (
+	"sort"
+	"time"
+
+	"kube-ai/pkg/k8s/logs"
+)
+
+// spikeWindow is how far around the start of an error spike entries are sampled
+const spikeWindow = time.Minute
+
+// LogSample is a representative log entry of a distinct pattern
+type LogSample struct {
+	logs.LogEntry
+	// Number of entries sharing the sample's pattern
+	Occurrences int
+}
+
+// patternGroup collects the entries sharing a fingerprint
+type patternGroup struct {
+	first int
+	count int
+}
+
+// sampleLogs selects representative entries for a prompt: the earliest occurrence of each distinct
+// error and warning pattern (most frequent first), entries around the largest error spike, and a
+// few distinct regular entries for context. Samples are returned in chronological order.
+func sampleLogs(entries []logs.LogEntry, maxErrors, maxWarnings, maxSpike, maxInfo int) []LogSample {
+	// Fingerprinting is comparatively expensive, so do it once per entry
+	keys := make([]string, len(entries))
+	counts := make(map[string]int, len(entries))
+	for index, entry := range entries {
+		keys[index] = logs.Fingerprint(entry)
+		counts[keys[index]]++
+	}
+
+	selected := make(map[int]bool)
+	var samples []LogSample
+	add := func(index int) {
+		if selected[index] {
+			return
+		}
+		selected[index] = true
+		samples = append(samples, LogSample{
+			LogEntry:    entries[index],
+			Occurrences: counts[keys[index]],
+		})
+	}
+
+	for _, index := range distinctPatterns(entries, keys, maxErrors, "ERROR", "FATAL") {
+		add(index)
+	}
+	for _, index := range distinctPatterns(entries, keys, maxWarnings, "WARN", "WARNING") {
+		add(index)
+	}
+
+	// Show what happened as errors spiked, skipping patterns that are already represented
+	if spike, ok := logs.FindErrorSpike(entries); ok {
+		seen := make(map[string]bool)
+		for index := range selected {
+			seen[keys[index]] = true
+		}
+		added := 0
+		for index, entry := range entries {
+			if added >= maxSpike {
+				break
+			}
+			if entry.Timestamp.Before(spike.Add(-spikeWindow)) || entry.Timestamp.After(spike.Add(spikeWindow)) {
+				continue
+			}
+			key := keys[index]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			add(index)
+			added++
+		}
+	}
+
+	for _, index := range distinctPatterns(entries, keys, maxInfo, "INFO") {
+		add(index)
+	}
+
+	// Present samples chronologically so the AI can follow events across pods
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+	return samples
+}
+
+// distinctPatterns returns the indexes of the earliest entry of up to limit distinct patterns
+// with one of the given levels, most frequent patterns first
+func distinctPatterns(entries []logs.LogEntry, keys []string, limit int, levels ...string) []int {
+	groups := make(map[string]*patternGroup)
+	var order []string
+	for index, entry := range entries {
+		if !hasLevel(entry, levels) {
+			continue
+		}
+		key := keys[index]
+		group, ok := groups[key]
+		if !ok {
+			group = &patternGroup{first: index}
+			groups[key] = group
+			order = append(order, key)
+		} else if entry.Timestamp.Before(entries[group.first].Timestamp) {
+			group.first = index
+		}
+		group.count++
+	}
+
+	// Order by frequency, keeping first-seen order among equally frequent patterns
+	sort.SliceStable(order, func(i, j int) bool {
+		return groups[order[i]].count > groups[order[j]].count
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	indexes := make([]int, 0, len(order))
+	for _, key := range order {
+		indexes = append(indexes, groups[key].first)
+	}
+	return indexes
+}
+
+// hasLevel reports whether an entry has one of the given log levels
+func hasLevel(entry logs.LogEntry, levels []string) bool {
+	for _, level := range levels {
+		if entry.LogLevel == level {
+			return true
+		}
+	}
+	return false
+}
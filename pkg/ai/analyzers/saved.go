@@ -0,0 +1,259 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/logs"
+)
+
+// similarCauseThreshold is the share of significant words two root causes must have in common
+// to be treated as the same cause across runs
+const similarCauseThreshold = 0.5
+
+// SavedAnalysis is an analysis result stored under a name so later runs can be compared with it
+type SavedAnalysis struct {
+	// Name the analysis was saved under
+	Name string `json:"name"`
+	// Time the analyzed logs were collected
+	CreatedAt time.Time `json:"createdAt"`
+	// Kube context the logs were collected from
+	Context string `json:"context,omitempty"`
+	// Namespace of the analyzed resource
+	Namespace string `json:"namespace,omitempty"`
+	// Kind of the analyzed resource
+	ResourceType string `json:"resourceType,omitempty"`
+	// Name of the analyzed resource
+	ResourceName string `json:"resourceName,omitempty"`
+	// Summary of the analyzed logs
+	Summary logs.LogSummary `json:"summary"`
+	// AI analysis result
+	Analysis LogAnalysisResult `json:"analysis"`
+}
+
+// Resource returns the analyzed resource as type/name
+func (s *SavedAnalysis) Resource() string {
+	if s.ResourceType == "" {
+		return s.ResourceName
+	}
+	return strings.ToLower(s.ResourceType) + "/" + s.ResourceName
+}
+
+// ErrorRate returns the errors per minute in the analyzed logs
+func (s *SavedAnalysis) ErrorRate() float64 {
+	return ratePerMinute(s.Summary.ErrorCount, s.Summary.TimeRange.Duration)
+}
+
+// WarningRate returns the warnings per minute in the analyzed logs
+func (s *SavedAnalysis) WarningRate() float64 {
+	return ratePerMinute(s.Summary.WarningCount, s.Summary.TimeRange.Duration)
+}
+
+// ratePerMinute converts a count over a duration to a rate, treating short spans as one minute
+func ratePerMinute(count int, d time.Duration) float64 {
+	minutes := d.Minutes()
+	if minutes < 1 {
+		minutes = 1
+	}
+	return float64(count) / minutes
+}
+
+// DefaultAnalysisDir returns the default directory for saved analyses (~/.kube-ai/analyses)
+func DefaultAnalysisDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube-ai", "analyses"), nil
+}
+
+// AnalysisPath returns the file that stores the analysis saved under name
+func AnalysisPath(dir, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid analysis name %q", name)
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// LoadAnalysis reads a saved analysis
+func LoadAnalysis(path string) (*SavedAnalysis, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no analysis saved as %q", strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading saved analysis: %w", err)
+	}
+
+	var saved SavedAnalysis
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing saved analysis %s: %w", path, err)
+	}
+	return &saved, nil
+}
+
+// ListAnalyses returns the analyses saved in dir, oldest first
+func ListAnalyses(dir string) ([]*SavedAnalysis, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make([]*SavedAnalysis, 0, len(paths))
+	for _, path := range paths {
+		analysis, err := LoadAnalysis(path)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, analysis)
+	}
+
+	sort.Slice(saved, func(i, j int) bool {
+		return saved[i].CreatedAt.Before(saved[j].CreatedAt)
+	})
+	return saved, nil
+}
+
+// Save writes the analysis to path, creating parent directories as needed. It is only readable by
+// the user, as it quotes the analyzed logs.
+func (s *SavedAnalysis) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating analysis directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding analysis: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing analysis: %w", err)
+	}
+	return nil
+}
+
+// AnalysisComparison describes how a later analysis run differs from an earlier one
+type AnalysisComparison struct {
+	// Name of the earlier run
+	Before string `json:"before"`
+	// Name of the later run
+	After string `json:"after"`
+	// Severity of the earlier run
+	SeverityBefore string `json:"severityBefore"`
+	// Severity of the later run
+	SeverityAfter string `json:"severityAfter"`
+	// Errors per minute in the earlier run
+	ErrorRateBefore float64 `json:"errorRateBefore"`
+	// Errors per minute in the later run
+	ErrorRateAfter float64 `json:"errorRateAfter"`
+	// Warnings per minute in the earlier run
+	WarningRateBefore float64 `json:"warningRateBefore"`
+	// Warnings per minute in the later run
+	WarningRateAfter float64 `json:"warningRateAfter"`
+	// Root causes only found in the earlier run
+	Resolved []string `json:"resolved,omitempty"`
+	// Root causes found in both runs
+	Persisting []string `json:"persisting,omitempty"`
+	// Root causes only found in the later run
+	New []string `json:"new,omitempty"`
+	// AI assessment of whether the situation improved
+	Assessment string `json:"assessment,omitempty"`
+}
+
+// CompareAnalyses compares severity, root causes, and error and warning rates of two runs
+func CompareAnalyses(before, after *SavedAnalysis) *AnalysisComparison {
+	comparison := &AnalysisComparison{
+		Before:            before.Name,
+		After:             after.Name,
+		SeverityBefore:    before.Analysis.Severity,
+		SeverityAfter:     after.Analysis.Severity,
+		ErrorRateBefore:   before.ErrorRate(),
+		ErrorRateAfter:    after.ErrorRate(),
+		WarningRateBefore: before.WarningRate(),
+		WarningRateAfter:  after.WarningRate(),
+	}
+
+	// Root causes are worded differently from run to run, so match them by shared words
+	matched := make([]bool, len(after.Analysis.RootCauses))
+	for _, cause := range before.Analysis.RootCauses {
+		found := false
+		for i, later := range after.Analysis.RootCauses {
+			if !matched[i] && similarCauses(cause.Cause, later.Cause) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if found {
+			comparison.Persisting = append(comparison.Persisting, cause.Cause)
+		} else {
+			comparison.Resolved = append(comparison.Resolved, cause.Cause)
+		}
+	}
+	for i, cause := range after.Analysis.RootCauses {
+		if !matched[i] {
+			comparison.New = append(comparison.New, cause.Cause)
+		}
+	}
+
+	return comparison
+}
+
+// similarCauses reports whether two root cause descriptions share most of their significant words
+func similarCauses(a, b string) bool {
+	wordsA, wordsB := significantWords(a), significantWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	smaller := len(wordsA)
+	if len(wordsB) < smaller {
+		smaller = len(wordsB)
+	}
+	return float64(shared)/float64(smaller) >= similarCauseThreshold
+}
+
+// significantWords returns the lower-cased words of a text longer than three characters
+func significantWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		if len(word) > 3 {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// AssessComparison asks the AI whether the situation improved between two runs, for example after a fix was applied
+func AssessComparison(ctx context.Context, aiService *ai.Service, before, after *SavedAnalysis, comparison *AnalysisComparison) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.AnalysisDiff, map[string]interface{}{
+		"Before":     before,
+		"After":      after,
+		"Comparison": comparison,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI assessment: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
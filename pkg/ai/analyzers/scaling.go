@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// ExplainScalingEvents asks the AI to explain in plain language why pods stayed pending and why
+// nodes were added or removed, given the autoscalers with their logs, Karpenter's node pools and
+// node claims, the pending pods, the scaling events and the detected problems
+func ExplainScalingEvents(ctx context.Context, aiService *ai.Service, report *k8s.ScalingReport) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.ScalingEvents, map[string]interface{}{
+		"Scaling": report,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI scaling explanation: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
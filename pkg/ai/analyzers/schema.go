@@ -0,0 +1,100 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/providers"
+)
+
+// stringList is the JSON schema of a list of strings
+var stringList = map[string]interface{}{
+	"type":  "array",
+	"items": map[string]interface{}{"type": "string"},
+}
+
+// logAnalysisSchema is the JSON schema of LogAnalysisResult, used to request schema-constrained responses
+var logAnalysisSchema = providers.ResponseSchema{
+	Name:        "log_analysis",
+	Description: "Analysis of Kubernetes logs with root causes, solutions and severity",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"summary": map[string]interface{}{"type": "string", "description": "Brief description of the issues"},
+			"rootCauses": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"cause":      map[string]interface{}{"type": "string"},
+						"confidence": map[string]interface{}{"type": "integer", "description": "Confidence in the cause from 0 to 100"},
+						"evidence": map[string]interface{}{
+							"type":        "array",
+							"description": "Log lines or events supporting the cause, quoted verbatim",
+							"items":       map[string]interface{}{"type": "string"},
+						},
+					},
+					"required":             []string{"cause", "confidence", "evidence"},
+					"additionalProperties": false,
+				},
+			},
+			"solutions":      stringList,
+			"additionalInfo": stringList,
+			"severity": map[string]interface{}{
+				"type": "string",
+				"enum": []string{"Low", "Medium", "High", "Critical"},
+			},
+		},
+		"required":             []string{"summary", "rootCauses", "solutions", "additionalInfo", "severity"},
+		"additionalProperties": false,
+	},
+}
+
+// queryAnalysis asks the AI for a log analysis, requesting schema-constrained JSON when the
+// provider supports it and falling back to extracting JSON from free text otherwise
+func queryAnalysis(ctx context.Context, aiService *ai.Service, prompt string) (*LogAnalysisResult, error) {
+	response, structured, err := aiService.QueryStructured(ctx, prompt, logAnalysisSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *LogAnalysisResult
+	if structured {
+		result, err = decodeAnalysis(response)
+	} else {
+		result, err = parseAIResponse(response)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing AI response: %w", err)
+	}
+	return result, nil
+}
+
+// decodeAnalysis decodes a JSON analysis and fills in missing required fields
+func decodeAnalysis(jsonStr string) (*LogAnalysisResult, error) {
+	var result LogAnalysisResult
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("error parsing response JSON: %w", err)
+	}
+
+	// Ensure we have valid values for required fields
+	if result.Summary == "" {
+		result.Summary = "No summary provided by AI analysis."
+	}
+
+	if len(result.RootCauses) == 0 {
+		result.RootCauses = []RootCause{{Cause: "No root causes identified in AI analysis."}}
+	}
+
+	if len(result.Solutions) == 0 {
+		result.Solutions = []string{"No solutions provided by AI analysis."}
+	}
+
+	if result.Severity == "" {
+		result.Severity = "Medium"
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s"
+)
+
+// TroubleshootStateful asks the AI why a StatefulSet's members are missing, not ready or out of
+// quorum, given its rollout, members, volume claims, DNS records and quorum log lines, and for
+// recovery steps that respect ordinal order and quorum
+func TroubleshootStateful(ctx context.Context, aiService *ai.Service, report *k8s.StatefulReport) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.StatefulTroubleshoot, map[string]interface{}{
+		"Stateful": report,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI StatefulSet troubleshooting: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
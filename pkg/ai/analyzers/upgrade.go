@@ -0,0 +1,50 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/upgrade"
+)
+
+// maxUpgradeManifests limits the manifests sent for conversion in an upgrade plan
+const maxUpgradeManifests = 20
+
+// upgradeManifest is a manifest document to convert, for the upgrade plan prompt
+type upgradeManifest struct {
+	Source string
+	YAML   string
+}
+
+// PlanUpgrade asks the AI for a migration plan to the target release for the findings of an
+// upgrade check, including converted versions of the affected manifest files
+func PlanUpgrade(ctx context.Context, aiService *ai.Service, target, current string, findings []upgrade.Finding) (string, error) {
+	var manifests []upgradeManifest
+	seen := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.Manifest == "" || seen[finding.Source] || len(manifests) >= maxUpgradeManifests {
+			continue
+		}
+		seen[finding.Source] = true
+		manifests = append(manifests, upgradeManifest{Source: finding.Source, YAML: strings.TrimSpace(finding.Manifest)})
+	}
+
+	prompt, err := aiService.RenderPrompt(prompts.UpgradePlan, map[string]interface{}{
+		"Target":    target,
+		"Current":   current,
+		"Findings":  findings,
+		"Manifests": manifests,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI migration plan: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,33 @@
+package analyzers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/k8s/helm"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// ExplainValuesImpact asks the AI what a change of a chart's values does to the rendered
+// resources and the risks of rolling it out. release is nil when the values are compared with the
+// chart's defaults rather than a release's.
+func ExplainValuesImpact(ctx context.Context, aiService *ai.Service, chart string, release *helm.Release, valuesDiff string, resources []manifest.DocumentDiff) (string, error) {
+	prompt, err := aiService.RenderPrompt(prompts.ValuesImpact, map[string]interface{}{
+		"Chart":      chart,
+		"Release":    release,
+		"ValuesDiff": valuesDiff,
+		"Resources":  resources,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := aiService.Query(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("error getting AI values impact analysis: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
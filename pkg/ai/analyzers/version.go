@@ -0,0 +1,29 @@
+package analyzers
+
+import (
+	"fmt"
+
+	"kube-ai/pkg/ai"
+)
+
+// dropUnsupportedSolutions leaves out the solutions that rely on features the cluster's release
+// does not support, noting each in the additional information
+func dropUnsupportedSolutions(aiService *ai.Service, result *LogAnalysisResult) {
+	kept, dropped := aiService.FilterSuggestions(result.Solutions)
+	result.Solutions = kept
+	result.AdditionalInfo = append(result.AdditionalInfo, dropped...)
+}
+
+// dropUnsupportedFindings leaves out the findings whose recommendation relies on a feature the
+// cluster's release does not support, noting each in LeftOut
+func dropUnsupportedFindings(aiService *ai.Service, result *ManifestAnalysisResult) {
+	kept := result.Findings[:0]
+	for _, finding := range result.Findings {
+		if feature := aiService.UnsupportedFeature(finding.Recommendation); feature != "" {
+			result.LeftOut = append(result.LeftOut, fmt.Sprintf("%s: the recommendation needs %s", finding.Title, feature))
+			continue
+		}
+		kept = append(kept, finding)
+	}
+	result.Findings = kept
+}
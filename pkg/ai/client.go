@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -67,8 +68,8 @@ func (c *OllamaClient) GenerateResponse(model, prompt string, options Options) (
 		return "", fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	fmt.Printf("Debug - Sending request to: %s\n", c.BaseURL+"/api/generate")
-	fmt.Printf("Debug - Request body: %s\n", string(requestBody))
+	fmt.Fprintf(os.Stderr, "Debug - Sending request to: %s\n", c.BaseURL+"/api/generate")
+	fmt.Fprintf(os.Stderr, "Debug - Request body: %s\n", string(requestBody))
 
 	resp, err := c.Client.Post(c.BaseURL+"/api/generate", "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
@@ -124,8 +125,8 @@ func (c *OllamaClient) ChatCompletion(model string, messages []Message, options
 		return "", fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	fmt.Printf("Debug - Sending request to: %s\n", c.BaseURL+"/api/chat")
-	fmt.Printf("Debug - Request body: %s\n", string(requestBody))
+	fmt.Fprintf(os.Stderr, "Debug - Sending request to: %s\n", c.BaseURL+"/api/chat")
+	fmt.Fprintf(os.Stderr, "Debug - Request body: %s\n", string(requestBody))
 
 	resp, err := c.Client.Post(c.BaseURL+"/api/chat", "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
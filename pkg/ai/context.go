@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"kube-ai/pkg/ai/providers"
+)
+
+// contextTimeout bounds asking a provider for the context window of its model
+const contextTimeout = 5 * time.Second
+
+// Rough token arithmetic, in line with the log chunking estimates
+const (
+	// Characters per token
+	charsPerToken = 4
+	// Tokens kept for a prompt's instructions around the data it carries
+	instructionTokens = 1024
+	// Smallest data budget, so tiny context windows still get some data
+	minPromptBudget = 256
+)
+
+// contextMu guards the context sizes cached by services, which concurrent analyses share
+var contextMu sync.Mutex
+
+// ContextSize returns the context window of the model in tokens: the contextSize setting if it
+// is set, else what the provider reports, else the size in the model registry, else 0 when it
+// is unknown. A provider is asked once per model, and never while local-only mode refuses it.
+func (s *Service) ContextSize() int {
+	if size := s.config.GetContextSize(); size > 0 {
+		return size
+	}
+	if size := s.reportedContextSize(); size > 0 {
+		return size
+	}
+	if model, ok := s.models.Lookup(s.provider.GetName(), s.provider.GetModelName()); ok {
+		return model.ContextTokens
+	}
+	return 0
+}
+
+// reportedContextSize asks the provider for the context window of its model, once per model
+func (s *Service) reportedContextSize() int {
+	sizer, ok := s.provider.(providers.ContextSizeProvider)
+	if !ok || s.CheckLocalOnly() != nil {
+		return 0
+	}
+
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	key := s.provider.GetName() + "/" + s.provider.GetModelName()
+	if size, ok := s.contextSizes[key]; ok {
+		return size
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+	size, err := sizer.ContextSize(ctx)
+	if err != nil {
+		size = 0
+	}
+	if s.contextSizes == nil {
+		s.contextSizes = make(map[string]int)
+	}
+	s.contextSizes[key] = size
+	return size
+}
+
+// PromptBudget returns the estimated tokens available for the data of a single request: the
+// given budget, reduced when the model's context window is known to be smaller, leaving room
+// for the prompt's instructions and the answer
+func (s *Service) PromptBudget(tokens int) int {
+	size := s.ContextSize()
+	if size == 0 {
+		return tokens
+	}
+	available := max(size-responseTokens(size)-instructionTokens, minPromptBudget)
+	return min(tokens, available)
+}
+
+// responseTokens returns the tokens of a context window kept free for the answer
+func responseTokens(contextSize int) int {
+	return min(contextSize/4, 2048)
+}
+
+// fitContext cuts the middle out of a prompt too long for the model's context window, keeping
+// its beginning and end where prompts put their instructions. Prompts are left alone when the
+// window is unknown.
+func (s *Service) fitContext(systemPrompt, prompt string) string {
+	size := s.ContextSize()
+	if size == 0 {
+		return prompt
+	}
+	maxChars := max((size-responseTokens(size))*charsPerToken-len(systemPrompt), minPromptBudget*charsPerToken)
+	if len(prompt) <= maxChars {
+		return prompt
+	}
+
+	head := maxChars / 2
+	for head > 0 && !utf8.RuneStart(prompt[head]) {
+		head--
+	}
+	tail := len(prompt) - (maxChars - head)
+	for tail < len(prompt) && !utf8.RuneStart(prompt[tail]) {
+		tail++
+	}
+	cut := tail - head
+	fmt.Fprintf(os.Stderr, "Warning: the prompt was cut by %d characters to fit the model's context window of %d tokens\n", cut, size)
+	return prompt[:head] + fmt.Sprintf("\n\n[... %d characters cut to fit the context window ...]\n\n", cut) + prompt[tail:]
+}
@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"kube-ai/pkg/i18n"
+)
+
+// ErrDryRun is returned instead of a response in dry-run mode, once the prompt has been shown
+var ErrDryRun = errors.New("dry run: the prompt was not sent to the AI provider")
+
+// previewMu keeps the previews of concurrent requests, such as multi-cluster analyses, apart
+var previewMu sync.Mutex
+
+// SetDryRun makes the service write every prompt to w, exactly as it would be sent after
+// redaction and truncation, instead of sending it. A nil writer turns dry-run mode off.
+func (s *Service) SetDryRun(w io.Writer) {
+	s.dryRun = w
+}
+
+// PreviewPrompt shows a prompt that is about to be sent and returns ErrDryRun in dry-run mode;
+// otherwise it does nothing and returns nil. The prompt must already be redacted.
+func (s *Service) PreviewPrompt(systemPrompt, prompt string) error {
+	if s.dryRun == nil {
+		return nil
+	}
+
+	previewMu.Lock()
+	defer previewMu.Unlock()
+	fmt.Fprintf(s.dryRun, "\n====== %s ======\n", i18n.T("PROMPT PREVIEW"))
+	redaction := s.GetRedactionPolicy()
+	if patterns := s.GetRedactionPatterns(); len(patterns) > 0 {
+		redaction += fmt.Sprintf(" and %d custom patterns", len(patterns))
+	}
+	fmt.Fprintf(s.dryRun, "Provider: %s, model: %s, redaction: %s\n", s.GetCurrentProvider(), s.GetCurrentModel(), redaction)
+	fmt.Fprintf(s.dryRun, "\n--- System prompt ---\n%s\n", systemPrompt)
+	fmt.Fprintf(s.dryRun, "\n--- Prompt ---\n%s\n", prompt)
+	fmt.Fprintln(s.dryRun, "\nNot sent. Steps that depend on the answer were skipped.")
+	return ErrDryRun
+}
@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"strings"
+	"sync"
+
+	"kube-ai/pkg/ai/prompts"
+)
+
+// ClusterEnvironment is what the cluster context preamble of prompts tells of the target cluster
+type ClusterEnvironment struct {
+	// Platform or cloud provider, such as EKS, GKE or kind
+	Platform string
+	// Network plugins, such as Cilium
+	CNI []string
+	// Ingress classes, as name (controller)
+	IngressClasses []string
+	// Operators and add-ons, such as cert-manager
+	Operators []string
+}
+
+// SetClusterEnvironmentLoader turns on the cluster context preamble: every prompt starts with
+// the release, platform, network plugin, ingress classes and operators of the target cluster,
+// so answers fit it. The environment is read once, when a prompt first needs it. A nil loader
+// turns the preamble off; when the loader fails, prompts go without it.
+func (s *Service) SetClusterEnvironmentLoader(load func() (*ClusterEnvironment, error)) {
+	s.environmentLoader = load
+	s.environmentOnce = &sync.Once{}
+	s.environment = nil
+}
+
+// clusterEnvironment returns the environment of the target cluster, or nil when the preamble is
+// off or the environment is unknown
+func (s *Service) clusterEnvironment() *ClusterEnvironment {
+	if s.environmentLoader == nil {
+		return nil
+	}
+	s.environmentOnce.Do(func() {
+		if environment, err := s.environmentLoader(); err == nil {
+			s.environment = environment
+		}
+	})
+	return s.environment
+}
+
+// withClusterContext prepends the cluster context preamble to a rendered prompt when it is on
+func (s *Service) withClusterContext(name, prompt string, data map[string]interface{}) (string, error) {
+	if s.environmentLoader == nil || name == prompts.ClusterContext {
+		return prompt, nil
+	}
+	preamble, err := s.prompts.Render(prompts.ClusterContext, data)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(preamble) == "" {
+		return prompt, nil
+	}
+	return strings.TrimRight(preamble, "\n") + "\n\n" + prompt, nil
+}
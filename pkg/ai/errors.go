@@ -0,0 +1,28 @@
+package ai
+
+// ProviderError is an error returned by the AI provider, such as an unreachable endpoint, a
+// rejected API key or an exhausted quota, as opposed to an error in kube-ai or the cluster
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// providerError wraps an error returned by the provider, leaving nil and already wrapped
+// errors as they are
+func (s *Service) providerError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ProviderError); ok {
+		return err
+	}
+	return &ProviderError{Provider: s.GetCurrentProvider(), Err: err}
+}
@@ -0,0 +1,56 @@
+package ai
+
+// Exchange is a prompt answered by the AI provider, as it was sent after redaction
+type Exchange struct {
+	Provider string
+	Model    string
+	Prompt   string
+	Answer   string
+	// Estimated tokens of the prompt, with the system prompt, and of the answer
+	InputTokens  int
+	OutputTokens int
+	// Estimated price in US dollars, from the model registry; 0 when the price is unknown
+	Cost float64
+}
+
+// OnExchange sets a function called with every prompt the provider answers, such as to keep a
+// history of answers. Prompts shown in dry-run mode or refused in local-only mode are not
+// answered, so they are not passed.
+func (s *Service) OnExchange(record func(Exchange)) {
+	s.onExchange = record
+}
+
+// recordExchange passes an answered prompt to the OnExchange function, if one is set
+func (s *Service) recordExchange(systemPrompt, prompt, answer string) {
+	if s.onExchange == nil {
+		return
+	}
+	exchange := Exchange{
+		Provider:     s.provider.GetName(),
+		Model:        s.provider.GetModelName(),
+		Prompt:       prompt,
+		Answer:       answer,
+		InputTokens:  (len(systemPrompt) + len(prompt)) / charsPerToken,
+		OutputTokens: len(answer) / charsPerToken,
+	}
+	if model, ok := s.models.Lookup(exchange.Provider, exchange.Model); ok {
+		exchange.Cost = (float64(exchange.InputTokens)*model.InputCost + float64(exchange.OutputTokens)*model.OutputCost) / 1e6
+	}
+	s.onExchange(exchange)
+}
+
+// OnRequest sets a function called when a prompt is sent to the provider, such as to show a
+// spinner while the answer is awaited. It returns the function called once the answer arrives.
+func (s *Service) OnRequest(start func() (done func())) {
+	s.onRequest = start
+}
+
+// RequestStarted calls the OnRequest function, returning the function to call once answered.
+// Code sending requests to the provider itself, such as the agent's tool-calling loop, calls it
+// around each request.
+func (s *Service) RequestStarted() func() {
+	if s.onRequest == nil {
+		return func() {}
+	}
+	return s.onRequest()
+}
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+
+	"kube-ai/pkg/ai/providers"
+)
+
+// ErrNotLocal is returned in local-only mode for a provider whose endpoint is not on this
+// machine or a private network
+var ErrNotLocal = errors.New("local-only mode")
+
+// EnforceLocalOnly turns local-only mode on: from then on the service refuses to switch to or
+// send prompts to any provider whose endpoint is not local or private, so no cluster data can
+// reach a hosted API. The mode cannot be turned off again.
+func (s *Service) EnforceLocalOnly() {
+	s.localOnly = true
+}
+
+// LocalOnly reports whether local-only mode is on
+func (s *Service) LocalOnly() bool {
+	return s.localOnly
+}
+
+// CheckLocalOnly returns an error in local-only mode if the provider in use is not local. Code
+// that talks to the provider directly, such as the agent's tool calling loop, must call it
+// before sending anything.
+func (s *Service) CheckLocalOnly() error {
+	return s.checkLocal(s.provider)
+}
+
+// checkLocal returns an error in local-only mode if a provider's endpoint is not local
+func (s *Service) checkLocal(provider providers.Provider) error {
+	if !s.localOnly {
+		return nil
+	}
+	endpoint, ok := provider.(providers.EndpointProvider)
+	if !ok {
+		return fmt.Errorf("%w: the endpoint of provider %s is unknown", ErrNotLocal, provider.GetName())
+	}
+	if !providers.IsLocalEndpoint(endpoint.Endpoint()) {
+		return fmt.Errorf("%w: provider %s sends prompts to %s, which is not a local or private endpoint", ErrNotLocal, provider.GetName(), endpoint.Endpoint())
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tier is how capable a model is, and how demanding a task is: a task can be routed to any
+// model of its tier or above
+type Tier string
+
+// Tiers, from least to most capable
+const (
+	TierSmall  Tier = "small"
+	TierMedium Tier = "medium"
+	TierLarge  Tier = "large"
+)
+
+// rank orders the tiers
+func (t Tier) rank() int {
+	switch t {
+	case TierSmall:
+		return 1
+	case TierMedium:
+		return 2
+	case TierLarge:
+		return 3
+	}
+	return 0
+}
+
+// ParseTier parses a tier name
+func ParseTier(name string) (Tier, error) {
+	tier := Tier(strings.ToLower(name))
+	if tier.rank() == 0 {
+		return "", fmt.Errorf("unknown model tier %q (expected small, medium or large)", name)
+	}
+	return tier, nil
+}
+
+// Capability is a feature a task may need from a model
+type Capability string
+
+// Capabilities
+const (
+	// Native function calling, used by the agent
+	CapabilityTools Capability = "tools"
+	// Responses constrained to a JSON schema
+	CapabilityStructured Capability = "structured"
+)
+
+// Model describes a model of a provider
+type Model struct {
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
+	// Context window in tokens
+	ContextTokens int `json:"contextTokens"`
+	// List prices in US dollars per million tokens; 0 for local models
+	InputCost  float64 `json:"inputCostPerMTok"`
+	OutputCost float64 `json:"outputCostPerMTok"`
+	Tier       Tier    `json:"tier"`
+	// Features the model supports
+	Capabilities []Capability `json:"capabilities,omitempty"`
+}
+
+// Has reports whether the model supports a capability
+func (m Model) Has(capability Capability) bool {
+	for _, c := range m.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost returns the blended price per million tokens, weighing input three times as much as
+// output since analyses send far more than they get back
+func (m Model) Cost() float64 {
+	return (3*m.InputCost + m.OutputCost) / 4
+}
+
+// Requirements are what a task needs from a model
+type Requirements struct {
+	Tier         Tier
+	Capabilities []Capability
+}
+
+// Satisfies reports whether the model meets a task's requirements
+func (m Model) Satisfies(needs Requirements) bool {
+	if m.Tier.rank() < needs.Tier.rank() {
+		return false
+	}
+	for _, capability := range needs.Capabilities {
+		if !m.Has(capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// Registry is the set of known models: the built-in ones, with the configured ones added or
+// replacing them. A nil registry has no models.
+type Registry struct {
+	models []Model
+}
+
+// NewRegistry returns the built-in models with the given models added; a model with the name
+// and provider of a built-in one replaces it
+func NewRegistry(extra []Model) *Registry {
+	r := &Registry{}
+	for _, model := range builtin {
+		r.add(model)
+	}
+	for _, model := range extra {
+		r.add(model)
+	}
+	sort.SliceStable(r.models, func(i, j int) bool {
+		a, b := r.models[i], r.models[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Cost() != b.Cost() {
+			return a.Cost() < b.Cost()
+		}
+		if a.Tier.rank() != b.Tier.rank() {
+			return a.Tier.rank() < b.Tier.rank()
+		}
+		return a.Name < b.Name
+	})
+	return r
+}
+
+// add adds a model, replacing the one of the same provider and name
+func (r *Registry) add(model Model) {
+	for i, existing := range r.models {
+		if existing.Provider == model.Provider && existing.Name == model.Name {
+			r.models[i] = model
+			return
+		}
+	}
+	r.models = append(r.models, model)
+}
+
+// Models returns the models of a provider, or of every provider for "", cheapest first
+func (r *Registry) Models(provider string) []Model {
+	if r == nil {
+		return nil
+	}
+	var models []Model
+	for _, model := range r.models {
+		if provider == "" || model.Provider == provider {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// Lookup returns a model of a provider by name
+func (r *Registry) Lookup(provider, name string) (Model, bool) {
+	for _, model := range r.Models(provider) {
+		if model.Name == name {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
+
+// Cheapest returns the cheapest model of a provider meeting a task's requirements, preferring
+// the least capable tier among equally priced models such as local ones
+func (r *Registry) Cheapest(provider string, needs Requirements) (Model, bool) {
+	for _, model := range r.Models(provider) {
+		if model.Satisfies(needs) {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
+
+// builtin lists well-known models with their list prices at the time of writing; configure
+// models to correct them or add others
+var builtin = []Model{
+	{Provider: "openai", Name: "gpt-4o-mini", ContextTokens: 128000, InputCost: 0.15, OutputCost: 0.60, Tier: TierMedium,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "openai", Name: "gpt-4o", ContextTokens: 128000, InputCost: 2.50, OutputCost: 10, Tier: TierLarge,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "openai", Name: "gpt-3.5-turbo", ContextTokens: 16385, InputCost: 0.50, OutputCost: 1.50, Tier: TierSmall,
+		Capabilities: []Capability{CapabilityTools}},
+	{Provider: "openai", Name: "gpt-4-turbo", ContextTokens: 128000, InputCost: 10, OutputCost: 30, Tier: TierLarge,
+		Capabilities: []Capability{CapabilityTools}},
+
+	{Provider: "anthropic", Name: "claude-3-haiku-20240307", ContextTokens: 200000, InputCost: 0.25, OutputCost: 1.25, Tier: TierSmall,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "anthropic", Name: "claude-3-5-haiku-20241022", ContextTokens: 200000, InputCost: 0.80, OutputCost: 4, Tier: TierMedium,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "anthropic", Name: "claude-3-5-sonnet-20241022", ContextTokens: 200000, InputCost: 3, OutputCost: 15, Tier: TierLarge,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "anthropic", Name: "claude-3-opus-20240229", ContextTokens: 200000, InputCost: 15, OutputCost: 75, Tier: TierLarge,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+
+	{Provider: "gemini", Name: "gemini-1.5-flash", ContextTokens: 1048576, InputCost: 0.075, OutputCost: 0.30, Tier: TierMedium,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "gemini", Name: "gemini-2.0-flash", ContextTokens: 1048576, InputCost: 0.10, OutputCost: 0.40, Tier: TierMedium,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+	{Provider: "gemini", Name: "gemini-1.5-pro", ContextTokens: 2097152, InputCost: 1.25, OutputCost: 5, Tier: TierLarge,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+
+	// Only the default local model is listed, since routing to a model that is not pulled
+	// would fail; configure the other models you have
+	{Provider: "ollama", Name: "llama3.3", ContextTokens: 131072, Tier: TierLarge,
+		Capabilities: []Capability{CapabilityTools, CapabilityStructured}},
+}
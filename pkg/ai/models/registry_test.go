@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestCheapest(t *testing.T) {
+	registry := NewRegistry([]Model{
+		// Corrects a built-in model
+		{Provider: "openai", Name: "gpt-4o", ContextTokens: 128000, InputCost: 0.10, OutputCost: 0.40, Tier: TierLarge},
+		{Provider: "ollama", Name: "llama3.2", ContextTokens: 131072, Tier: TierSmall},
+	})
+
+	for _, test := range []struct {
+		provider string
+		needs    Requirements
+		want     string
+	}{
+		{"openai", Requirements{Tier: TierLarge}, "gpt-4o"},
+		// The corrected gpt-4o no longer has tools
+		{"openai", Requirements{Tier: TierLarge, Capabilities: []Capability{CapabilityTools}}, "gpt-4-turbo"},
+		{"anthropic", Requirements{Tier: TierSmall}, "claude-3-haiku-20240307"},
+		// Free local models go to the least capable one sufficient
+		{"ollama", Requirements{Tier: TierSmall}, "llama3.2"},
+		{"ollama", Requirements{Tier: TierMedium}, "llama3.3"},
+	} {
+		model, ok := registry.Cheapest(test.provider, test.needs)
+		if !ok || model.Name != test.want {
+			t.Errorf("Cheapest(%s, %+v) = %s, want %s", test.provider, test.needs, model.Name, test.want)
+		}
+	}
+
+	if _, ok := registry.Cheapest("anythingllm", Requirements{Tier: TierSmall}); ok {
+		t.Error("expected no model for a provider without known models")
+	}
+}
@@ -0,0 +1,225 @@
+package prompts
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Names of the built-in prompt templates
+const (
+	AnalyzeDeployment    = "analyze-deployment"
+	OptimizeResources    = "optimize-resources"
+	SuggestScaling       = "suggest-scaling"
+	GenerateManifest     = "generate-manifest"
+	GenerateStack        = "generate-stack"
+	GeneratePolicy       = "generate-policy"
+	RefineManifest       = "refine-manifest"
+	ExplainError         = "explain-error"
+	ExplainField         = "explain-field"
+	ExplainDescribe      = "explain-describe"
+	LogAnalysis          = "log-analysis"
+	LogErrorAnalysis     = "log-error-analysis"
+	LogChunk             = "log-chunk"
+	LogReduce            = "log-reduce"
+	LogFollowUp          = "log-followup"
+	AnalysisDiff         = "analysis-diff"
+	ManifestFindings     = "manifest-findings"
+	UpgradePlan          = "upgrade-plan"
+	CapacityPlan         = "capacity-plan"
+	RolloutRisk          = "rollout-risk"
+	ImagePatchPlan       = "image-patch-plan"
+	BenchmarkRemediation = "benchmark-remediation"
+	SecretCleanup        = "secret-cleanup"
+	ChooseContext        = "choose-context"
+	ConsensusMerge       = "consensus-merge"
+	ArgoApplication      = "argo-application"
+	FluxTroubleshoot     = "flux-troubleshoot"
+	MeshConfig           = "mesh-config"
+	CertificateIssuance  = "certificate-issuance"
+	DNSDiagnosis         = "dns-diagnosis"
+	JobFailure           = "job-failure"
+	GPUWorkloads         = "gpu-workloads"
+	StatefulTroubleshoot = "stateful-troubleshoot"
+	ScalingEvents        = "scaling-events"
+	NamespaceSummary     = "namespace-summary"
+	KubectlCommands      = "kubectl-commands"
+	ResourceQuery        = "resource-query"
+	ValuesImpact         = "values-impact"
+	BundleAnalysis       = "bundle-analysis"
+	AgentInstructions    = "agent-instructions"
+	DashboardChat        = "dashboard-chat"
+	// Preamble of every prompt when the cluster context is on
+	ClusterContext = "cluster-context"
+)
+
+// templateExt is the file extension of prompt templates
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// PersonaInfo describes the active persona, available to templates as .Persona
+type PersonaInfo struct {
+	ID          string
+	Description string
+}
+
+// ClusterInfo describes the target cluster, available to templates as .Cluster
+type ClusterInfo struct {
+	Context   string
+	Namespace string
+	// Kubernetes release of the cluster, such as 1.27, when known
+	Version string
+	// Features the cluster's release does not support, such as "startupProbe (Kubernetes 1.18+)"
+	Unsupported []string
+	// Platform, network plugins, ingress classes and operators of the cluster, when the cluster
+	// context is on
+	Platform       string
+	CNI            []string
+	IngressClasses []string
+	Operators      []string
+}
+
+// CustomResource describes a custom resource installed in the cluster, for prompts that generate
+// or explain custom resources
+type CustomResource struct {
+	Kind       string
+	APIVersion string
+	// Outline of the fields of its schema
+	Schema string
+}
+
+// funcs are the helper functions available to every template
+var funcs = template.FuncMap{
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+	"join":    strings.Join,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"inc":     func(i int) int { return i + 1 },
+}
+
+// Renderer renders prompt templates, preferring override files in a directory over the built-in defaults
+type Renderer struct {
+	dir string
+}
+
+// NewRenderer creates a renderer that looks for override templates in dir
+func NewRenderer(dir string) *Renderer {
+	return &Renderer{dir: dir}
+}
+
+// DefaultDir returns the default directory for override templates (~/.kube-ai/prompts)
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube-ai", "prompts"), nil
+}
+
+// Names returns the sorted names of the built-in templates
+func Names() []string {
+	entries, _ := fs.ReadDir(defaultTemplates, "templates")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), templateExt))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the text of a built-in template
+func Default(name string) (string, error) {
+	data, err := defaultTemplates.ReadFile("templates/" + name + templateExt)
+	if err != nil {
+		return "", fmt.Errorf("unknown prompt template %q (known templates: %s)", name, strings.Join(Names(), ", "))
+	}
+	return string(data), nil
+}
+
+// Dir returns the directory searched for override templates
+func (r *Renderer) Dir() string {
+	return r.dir
+}
+
+// Path returns the override file path for a template
+func (r *Renderer) Path(name string) string {
+	return filepath.Join(r.dir, name+templateExt)
+}
+
+// Source returns the text of a template and whether it comes from an override file
+func (r *Renderer) Source(name string) (string, bool, error) {
+	if r.dir != "" {
+		data, err := os.ReadFile(r.Path(name))
+		if err == nil {
+			return string(data), true, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", false, fmt.Errorf("error reading prompt template %s: %w", r.Path(name), err)
+		}
+	}
+
+	text, err := Default(name)
+	return text, false, err
+}
+
+// Render executes a template with the given variables
+func (r *Renderer) Render(name string, vars map[string]interface{}) (string, error) {
+	text, overridden, err := r.Source(name)
+	if err != nil {
+		return "", err
+	}
+
+	source := "built-in"
+	if overridden {
+		source = r.Path(name)
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing prompt template %s (%s): %w", name, source, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("error rendering prompt template %s (%s): %w", name, source, err)
+	}
+
+	return sb.String(), nil
+}
+
+// WriteDefaults copies the built-in templates into the override directory, keeping existing files
+func (r *Renderer) WriteDefaults() ([]string, error) {
+	if r.dir == "" {
+		return nil, fmt.Errorf("no prompt template directory configured")
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating prompt template directory: %w", err)
+	}
+
+	var written []string
+	for _, name := range Names() {
+		path := r.Path(name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		text, err := Default(name)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			return written, fmt.Errorf("error writing prompt template %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
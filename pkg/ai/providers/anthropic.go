@@ -171,6 +171,11 @@ func (p *AnthropicProvider) RequiresAPIKey() bool {
 	return true
 }
 
+// Endpoint returns the URL of the Anthropic API the provider sends requests to
+func (p *AnthropicProvider) Endpoint() string {
+	return p.config.BaseURL
+}
+
 // GenerateCompletion sends a prompt to Anthropic and returns the response
 func (p *AnthropicProvider) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
 	// For Anthropic, we'll use a Kubernetes-specific system prompt
@@ -178,3 +183,92 @@ func (p *AnthropicProvider) GenerateCompletion(ctx context.Context, prompt strin
 
 	return p.ChatCompletion(systemPrompt, prompt, 0.7)
 }
+
+// AnthropicTool describes a tool the model can be forced to call
+type AnthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// AnthropicToolRequest represents a messages request that forces a specific tool call
+type AnthropicToolRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+	Tools       []AnthropicTool    `json:"tools"`
+	ToolChoice  struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"tool_choice"`
+}
+
+// ChatStructured requests a response constrained to a JSON schema by forcing a tool call
+// whose input schema is the response schema
+func (p *AnthropicProvider) ChatStructured(ctx context.Context, systemPrompt string, userMessage string, schema ResponseSchema, temperature float32) (string, error) {
+	if p.config.APIKey == "" {
+		return "", fmt.Errorf("Anthropic API key is required")
+	}
+
+	request := AnthropicToolRequest{
+		Model:       p.config.ModelName,
+		MaxTokens:   4096,
+		System:      systemPrompt,
+		Messages:    []AnthropicMessage{{Role: "user", Content: userMessage}},
+		Temperature: float64(temperature),
+		Tools: []AnthropicTool{{
+			Name:        schema.Name,
+			Description: schema.Description,
+			InputSchema: schema.Schema,
+		}},
+	}
+	request.ToolChoice.Type = "tool"
+	request.ToolChoice.Name = schema.Name
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/v1/messages", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", p.config.APIKey)
+	req.Header.Set("Anthropic-Version", "2023-06-01")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error making request to Anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("error from Anthropic API: status code %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var response struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("error decoding response: %w", err)
+	}
+
+	// The forced tool call's input is the structured response
+	for _, content := range response.Content {
+		if content.Type == "tool_use" && content.Name == schema.Name {
+			return string(content.Input), nil
+		}
+	}
+
+	return "", fmt.Errorf("no structured response returned")
+}
@@ -181,6 +181,11 @@ func (p *AnythingLLMProvider) RequiresAPIKey() bool {
 	return false
 }
 
+// Endpoint returns the URL of the AnythingLLM API the provider sends requests to
+func (p *AnythingLLMProvider) Endpoint() string {
+	return p.config.BaseURL
+}
+
 // GenerateCompletion sends a prompt to AnythingLLM and returns the response
 func (p *AnythingLLMProvider) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
 	// For AnythingLLM, we'll use the existing ChatCompletion method with an empty system prompt
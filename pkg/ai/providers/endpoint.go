@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// EndpointProvider is implemented by providers that can tell where they send requests
+type EndpointProvider interface {
+	// Endpoint returns the base URL requests are sent to, or "" for providers that run in
+	// process and send nothing over the network
+	Endpoint() string
+}
+
+// localSuffixes are the host name suffixes resolved inside a cluster or on the local network
+var localSuffixes = []string{".localhost", ".local", ".svc", ".svc.cluster.local", ".cluster.local"}
+
+// IsLocalEndpoint reports whether an endpoint URL points at this machine or a private network:
+// a loopback, private or link-local address, localhost, a Kubernetes service name such as
+// vllm.ai.svc, or a single-label host name. The empty endpoint of an in-process provider is
+// local. Host names are not resolved, so a public name pointing at a private address is not
+// considered local.
+func IsLocalEndpoint(endpoint string) bool {
+	if endpoint == "" {
+		return true
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range localSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
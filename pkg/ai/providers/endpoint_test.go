@@ -0,0 +1,28 @@
+package providers
+
+import "testing"
+
+func TestIsLocalEndpoint(t *testing.T) {
+	for endpoint, local := range map[string]bool{
+		"":                                                 true,
+		"http://localhost:11434":                           true,
+		"http://127.0.0.1:8080":                            true,
+		"http://[::1]:8080":                                true,
+		"http://10.0.3.7:8000/v1":                          true,
+		"http://192.168.1.20:11434":                        true,
+		"http://[fd00::12]:8000":                           true,
+		"http://ollama:11434":                              true,
+		"http://vllm.ai.svc.cluster.local:8000":            true,
+		"http://vllm.ai.svc:8000":                          true,
+		"http://gpu-box.local:11434":                       true,
+		"https://api.openai.com/v1":                        false,
+		"https://generativelanguage.googleapis.com/v1beta": false,
+		"http://8.8.8.8":                                   false,
+		"http://localhost.example.com":                     false,
+		"not a url":                                        false,
+	} {
+		if got := IsLocalEndpoint(endpoint); got != local {
+			t.Errorf("IsLocalEndpoint(%q) = %v, want %v", endpoint, got, local)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package providers
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 )
 
 // ProviderType represents the type of AI provider
@@ -16,30 +18,105 @@ const (
 	ProviderTypeAnythingLLM ProviderType = "anythingllm"
 )
 
+// Factory creates a provider from its configuration
+type Factory func(config ProviderConfig) (Provider, error)
+
+// registered holds the provider types added with Register
+var (
+	registeredMu sync.RWMutex
+	registered   = make(map[ProviderType]Factory)
+)
+
+// Register adds a provider type, such as a FakeProvider in tests or a provider maintained outside
+// kube-ai, so configurations can select it by name. Registering a built-in or already registered
+// type replaces it; a nil factory removes a registered type.
+func Register(providerType ProviderType, factory Factory) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	if factory == nil {
+		delete(registered, providerType)
+		return
+	}
+	registered[providerType] = factory
+}
+
 // GetProviderTypes returns a list of supported provider types
 func GetProviderTypes() []ProviderType {
-	return []ProviderType{
+	types := []ProviderType{
 		ProviderTypeOllama,
 		ProviderTypeOpenAI,
 		ProviderTypeAnthropicAI,
 		ProviderTypeGemini,
 		ProviderTypeAnythingLLM,
+		ProviderTypeLlamaCpp,
+		ProviderTypeOpenAICompatible,
+	}
+
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	var extra []ProviderType
+	for providerType := range registered {
+		if !isBuiltIn(providerType) {
+			extra = append(extra, providerType)
+		}
 	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	return append(types, extra...)
+}
+
+// isBuiltIn reports whether a provider type is implemented by kube-ai
+func isBuiltIn(providerType ProviderType) bool {
+	switch providerType {
+	case ProviderTypeOllama, ProviderTypeOpenAI, ProviderTypeAnthropicAI, ProviderTypeGemini, ProviderTypeAnythingLLM,
+		ProviderTypeLlamaCpp, ProviderTypeOpenAICompatible:
+		return true
+	}
+	return false
 }
 
 // CreateProvider creates a provider of the specified type
 func CreateProvider(providerType ProviderType, config ProviderConfig) (Provider, error) {
+	registeredMu.RLock()
+	factory, ok := registered[providerType]
+	registeredMu.RUnlock()
+	if ok {
+		return factory(config)
+	}
+
+	client, err := NewHTTPClient(config.Transport)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring the connection to %s: %w", providerType, err)
+	}
+
 	switch providerType {
 	case ProviderTypeOllama:
-		return NewOllamaProvider(config.BaseURL, config.ModelName), nil
+		p := NewOllamaProvider(config.BaseURL, config.ModelName)
+		p.client = client
+		return p, nil
 	case ProviderTypeOpenAI:
-		return NewOpenAIProvider(config.APIKey, config.ModelName), nil
+		p := NewOpenAIProvider(config.APIKey, config.ModelName)
+		p.client = client
+		return p, nil
 	case ProviderTypeAnthropicAI:
-		return NewAnthropicProvider(config.APIKey, config.ModelName), nil
+		p := NewAnthropicProvider(config.APIKey, config.ModelName)
+		p.client = client
+		return p, nil
 	case ProviderTypeGemini:
-		return NewGeminiProvider(config.APIKey, config.ModelName), nil
+		p := NewGeminiProvider(config.APIKey, config.ModelName)
+		p.client = client
+		return p, nil
 	case ProviderTypeAnythingLLM:
-		return NewAnythingLLMProvider(config.BaseURL, config.APIKey), nil
+		p := NewAnythingLLMProvider(config.BaseURL, config.APIKey)
+		p.client = client
+		return p, nil
+	case ProviderTypeLlamaCpp:
+		p := NewLlamaCppProvider(config.BaseURL, config.APIKey, config.ModelName)
+		p.client = client
+		return p, nil
+	case ProviderTypeOpenAICompatible:
+		p := NewOpenAICompatibleProvider(config.BaseURL, config.APIKey, config.ModelName)
+		p.client = client
+		return p, nil
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
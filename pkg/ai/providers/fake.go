@@ -0,0 +1,238 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProviderTypeFake is the provider type of FakeProvider once registered with Register. It is
+// not listed by GetProviderTypes until then.
+const ProviderTypeFake ProviderType = "fake"
+
+// FakeResponse is a scripted reply of a FakeProvider
+type FakeResponse struct {
+	// Content returned as the reply
+	Content string
+	// Tool calls returned to tool-enabled requests
+	ToolCalls []ToolCall
+	// Error returned instead of a reply, to inject provider failures
+	Err error
+	// Delay before replying, added to the provider's latency
+	Delay time.Duration
+}
+
+// FakeRequest is a request received by a FakeProvider
+type FakeRequest struct {
+	// Method called, such as ChatCompletion or ChatStructured
+	Method       string
+	SystemPrompt string
+	Prompt       string
+	// Conversation of tool-enabled requests
+	Messages []ChatMessage
+	// Tools offered to tool-enabled requests
+	Tools []ToolDefinition
+	// Schema of structured requests
+	Schema      *ResponseSchema
+	Temperature float32
+}
+
+// FakeProvider is a Provider returning scripted responses, for testing kube-ai and code built on
+// it without an AI provider. Responses are returned in order, one per request; a request beyond
+// the script fails, so tests notice unexpected calls. It supports structured output and tool
+// calling, and is safe for concurrent use.
+type FakeProvider struct {
+	mu        sync.Mutex
+	modelName string
+	latency   time.Duration
+	responses []FakeResponse
+	fallback  *FakeResponse
+	requests  []FakeRequest
+}
+
+// NewFakeProvider creates a fake provider replying with the given responses in order
+func NewFakeProvider(responses ...string) *FakeProvider {
+	p := &FakeProvider{modelName: "fake-model"}
+	for _, response := range responses {
+		p.Respond(response)
+	}
+	return p
+}
+
+// Respond adds a reply to the script
+func (p *FakeProvider) Respond(content string) *FakeProvider {
+	return p.RespondWith(FakeResponse{Content: content})
+}
+
+// Fail adds a failure to the script
+func (p *FakeProvider) Fail(err error) *FakeProvider {
+	return p.RespondWith(FakeResponse{Err: err})
+}
+
+// RespondWith adds a response to the script
+func (p *FakeProvider) RespondWith(response FakeResponse) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = append(p.responses, response)
+	return p
+}
+
+// RespondAlways replies to every request beyond the script with the given response, instead of
+// failing
+func (p *FakeProvider) RespondAlways(response FakeResponse) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fallback = &response
+	return p
+}
+
+// SetLatency delays every reply, to exercise timeouts and progress output
+func (p *FakeProvider) SetLatency(latency time.Duration) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = latency
+	return p
+}
+
+// Requests returns the requests received so far
+func (p *FakeProvider) Requests() []FakeRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeRequest(nil), p.requests...)
+}
+
+// Remaining returns the number of scripted responses not consumed yet
+func (p *FakeProvider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.responses)
+}
+
+// Create returns the fake provider itself, taking the model from the configuration if one is set.
+// It has the signature of a Factory, so the fake can be registered with
+// Register(ProviderTypeFake, fake.Create) and selected by the configuration like any provider.
+func (p *FakeProvider) Create(config ProviderConfig) (Provider, error) {
+	if config.ModelName != "" {
+		p.SetModelName(config.ModelName)
+	}
+	return p, nil
+}
+
+// reply records a request and returns the next scripted response after its delay
+func (p *FakeProvider) reply(ctx context.Context, request FakeRequest) (FakeResponse, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, request)
+	number := len(p.requests)
+	var response FakeResponse
+	switch {
+	case len(p.responses) > 0:
+		response = p.responses[0]
+		p.responses = p.responses[1:]
+	case p.fallback != nil:
+		response = *p.fallback
+	default:
+		p.mu.Unlock()
+		return FakeResponse{}, fmt.Errorf("fake provider: no scripted response for request %d (%s)", number, request.Method)
+	}
+	delay := p.latency + response.Delay
+	p.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return FakeResponse{}, ctx.Err()
+		}
+	}
+	if response.Err != nil {
+		return FakeResponse{}, response.Err
+	}
+	return response, nil
+}
+
+// GenerateResponse returns the next scripted response
+func (p *FakeProvider) GenerateResponse(prompt string, temperature float64) (string, error) {
+	response, err := p.reply(context.Background(), FakeRequest{Method: "GenerateResponse", Prompt: prompt, Temperature: float32(temperature)})
+	return response.Content, err
+}
+
+// ChatCompletion returns the next scripted response
+func (p *FakeProvider) ChatCompletion(systemPrompt string, userMessage string, temperature float32) (string, error) {
+	response, err := p.reply(context.Background(), FakeRequest{
+		Method:       "ChatCompletion",
+		SystemPrompt: systemPrompt,
+		Prompt:       userMessage,
+		Temperature:  temperature,
+	})
+	return response.Content, err
+}
+
+// ChatStructured returns the next scripted response, which should be the JSON document the
+// schema describes
+func (p *FakeProvider) ChatStructured(ctx context.Context, systemPrompt string, userMessage string, schema ResponseSchema, temperature float32) (string, error) {
+	response, err := p.reply(ctx, FakeRequest{
+		Method:       "ChatStructured",
+		SystemPrompt: systemPrompt,
+		Prompt:       userMessage,
+		Schema:       &schema,
+		Temperature:  temperature,
+	})
+	return response.Content, err
+}
+
+// ChatWithTools returns the next scripted response with its tool calls
+func (p *FakeProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, tools []ToolDefinition, temperature float32) (*ToolResponse, error) {
+	response, err := p.reply(ctx, FakeRequest{
+		Method:      "ChatWithTools",
+		Messages:    append([]ChatMessage(nil), messages...),
+		Tools:       tools,
+		Temperature: temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ToolResponse{Content: response.Content, ToolCalls: response.ToolCalls}, nil
+}
+
+// GenerateCompletion returns the next scripted response
+func (p *FakeProvider) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	response, err := p.reply(ctx, FakeRequest{Method: "GenerateCompletion", Prompt: prompt})
+	return response.Content, err
+}
+
+// ListModels returns the fake model
+func (p *FakeProvider) ListModels() (string, error) {
+	return "Available models:\n- " + p.GetModelName() + "\n", nil
+}
+
+// GetName returns the name of the provider
+func (p *FakeProvider) GetName() string {
+	return string(ProviderTypeFake)
+}
+
+// GetModelName returns the name of the currently used model
+func (p *FakeProvider) GetModelName() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.modelName
+}
+
+// SetModelName sets the model to use
+func (p *FakeProvider) SetModelName(modelName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.modelName = modelName
+}
+
+// RequiresAPIKey returns false, the fake needs no credentials
+func (p *FakeProvider) RequiresAPIKey() bool {
+	return false
+}
+
+// Endpoint returns "", the fake runs in process and sends nothing over the network
+func (p *FakeProvider) Endpoint() string {
+	return ""
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeProviderScript(t *testing.T) {
+	fake := NewFakeProvider("first").Fail(errors.New("rate limited")).Respond("third")
+
+	if response, err := fake.ChatCompletion("system", "one", 0.3); err != nil || response != "first" {
+		t.Fatalf("expected the first response, got %q, %v", response, err)
+	}
+	if _, err := fake.ChatCompletion("system", "two", 0.3); err == nil || err.Error() != "rate limited" {
+		t.Fatalf("expected the injected failure, got %v", err)
+	}
+	if response, err := fake.GenerateCompletion(context.Background(), "three"); err != nil || response != "third" {
+		t.Fatalf("expected the third response, got %q, %v", response, err)
+	}
+	if _, err := fake.ChatCompletion("system", "four", 0.3); err == nil {
+		t.Fatal("expected an error once the script is exhausted")
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 4 || requests[1].Prompt != "two" || requests[2].Method != "GenerateCompletion" {
+		t.Errorf("unexpected requests: %+v", requests)
+	}
+}
+
+func TestFakeProviderLatency(t *testing.T) {
+	fake := NewFakeProvider("slow").SetLatency(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := fake.ChatStructured(ctx, "system", "prompt", ResponseSchema{Name: "test"}, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to time out, got %v", err)
+	}
+}
+
+func TestFakeProviderRegister(t *testing.T) {
+	fake := NewFakeProvider()
+	Register(ProviderTypeFake, fake.Create)
+	defer Register(ProviderTypeFake, nil)
+
+	provider, err := CreateProvider(ProviderTypeFake, ProviderConfig{ModelName: "scripted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider != fake || provider.GetModelName() != "scripted" {
+		t.Errorf("expected the registered fake with the configured model, got %v", provider)
+	}
+
+	types := GetProviderTypes()
+	if types[len(types)-1] != ProviderTypeFake {
+		t.Errorf("the registered provider is not listed: %v", types)
+	}
+}
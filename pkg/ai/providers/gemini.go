@@ -32,8 +32,10 @@ type GeminiContent struct {
 
 // GeminiGenerationConfig represents the generation config for Gemini
 type GeminiGenerationConfig struct {
-	Temperature     float64 `json:"temperature"`
-	MaxOutputTokens int     `json:"maxOutputTokens"`
+	Temperature      float64                `json:"temperature"`
+	MaxOutputTokens  int                    `json:"maxOutputTokens"`
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
 }
 
 // GeminiResponse represents a response from the Gemini API
@@ -76,6 +78,24 @@ func (p *GeminiProvider) GenerateResponse(prompt string, temperature float64) (s
 
 // ChatCompletion generates a response from a conversation
 func (p *GeminiProvider) ChatCompletion(systemPrompt string, userMessage string, temperature float32) (string, error) {
+	return p.generate(context.Background(), systemPrompt, userMessage, GeminiGenerationConfig{
+		Temperature:     float64(temperature),
+		MaxOutputTokens: 4096,
+	})
+}
+
+// ChatStructured requests a response constrained to a JSON schema using Gemini's responseSchema
+func (p *GeminiProvider) ChatStructured(ctx context.Context, systemPrompt string, userMessage string, schema ResponseSchema, temperature float32) (string, error) {
+	return p.generate(ctx, systemPrompt, userMessage, GeminiGenerationConfig{
+		Temperature:      float64(temperature),
+		MaxOutputTokens:  4096,
+		ResponseMimeType: "application/json",
+		ResponseSchema:   geminiSchema(schema.Schema),
+	})
+}
+
+// generate sends a single-turn request to the generateContent API
+func (p *GeminiProvider) generate(ctx context.Context, systemPrompt string, userMessage string, generationConfig GeminiGenerationConfig) (string, error) {
 	if p.config.APIKey == "" {
 		return "", fmt.Errorf("Gemini API key is required")
 	}
@@ -98,11 +118,8 @@ func (p *GeminiProvider) ChatCompletion(systemPrompt string, userMessage string,
 	}
 
 	request := GeminiRequest{
-		Contents: []GeminiContent{content},
-		GenerationConfig: GeminiGenerationConfig{
-			Temperature:     float64(temperature),
-			MaxOutputTokens: 4096,
-		},
+		Contents:         []GeminiContent{content},
+		GenerationConfig: generationConfig,
 	}
 
 	requestBody, err := json.Marshal(request)
@@ -111,7 +128,13 @@ func (p *GeminiProvider) ChatCompletion(systemPrompt string, userMessage string,
 	}
 
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", p.config.BaseURL, p.config.ModelName, p.config.APIKey)
-	resp, err := p.client.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("error making request to Gemini: %w", err)
 	}
@@ -166,6 +189,11 @@ func (p *GeminiProvider) RequiresAPIKey() bool {
 	return true
 }
 
+// Endpoint returns the URL of the Gemini API the provider sends requests to
+func (p *GeminiProvider) Endpoint() string {
+	return p.config.BaseURL
+}
+
 // GenerateCompletion sends a prompt to Gemini and returns the response
 func (p *GeminiProvider) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
 	// For Gemini, we'll provide a specific Kubernetes-focused system prompt
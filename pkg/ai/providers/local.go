@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Provider types of local servers speaking the OpenAI API
+const (
+	ProviderTypeLlamaCpp         ProviderType = "llamacpp"
+	ProviderTypeOpenAICompatible ProviderType = "openai-compatible"
+)
+
+// ContextSizeProvider is implemented by providers that can report the context window of their model
+type ContextSizeProvider interface {
+	// ContextSize returns the context window of the model in tokens, or 0 if it is unknown
+	ContextSize(ctx context.Context) (int, error)
+}
+
+// NewLlamaCppProvider creates a provider for a llama.cpp server (llama-server), given the URL
+// of the server without the /v1 suffix. The server serves the model it was started with,
+// whatever model is requested, and needs no API key unless started with --api-key.
+func NewLlamaCppProvider(baseURL string, apiKey string, modelName string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	p := NewOpenAICompatibleProvider(strings.TrimSuffix(baseURL, "/")+"/v1", apiKey, modelName)
+	p.name = string(ProviderTypeLlamaCpp)
+	return p
+}
+
+// NewOpenAICompatibleProvider creates a provider for a server implementing the OpenAI chat
+// completions API, such as text-generation-webui, vLLM, LocalAI or LM Studio, given the URL of
+// the API including its /v1 suffix. The API key is only sent if one is set.
+func NewOpenAICompatibleProvider(baseURL string, apiKey string, modelName string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = "http://localhost:5000/v1"
+	}
+	if modelName == "" {
+		modelName = "default"
+	}
+	return &OpenAIProvider{
+		config: ProviderConfig{
+			BaseURL:   strings.TrimSuffix(baseURL, "/"),
+			APIKey:    apiKey,
+			ModelName: modelName,
+		},
+		client:      &http.Client{},
+		name:        string(ProviderTypeOpenAICompatible),
+		keyOptional: true,
+	}
+}
+
+// ContextSize asks the server for the context window of the model: llama.cpp reports it at
+// /props, and servers such as vLLM and llama.cpp report it in their model list. OpenAI does
+// not report it, so the size is unknown there.
+func (p *OpenAIProvider) ContextSize(ctx context.Context) (int, error) {
+	if p.name == "openai" {
+		return 0, nil
+	}
+
+	if p.name == string(ProviderTypeLlamaCpp) {
+		var props struct {
+			NCtx                      int `json:"n_ctx"`
+			DefaultGenerationSettings struct {
+				NCtx int `json:"n_ctx"`
+			} `json:"default_generation_settings"`
+		}
+		err := p.getJSON(ctx, strings.TrimSuffix(p.config.BaseURL, "/v1")+"/props", &props)
+		if err == nil && props.DefaultGenerationSettings.NCtx > 0 {
+			return props.DefaultGenerationSettings.NCtx, nil
+		}
+		if err == nil && props.NCtx > 0 {
+			return props.NCtx, nil
+		}
+	}
+
+	var models struct {
+		Data []struct {
+			ID          string `json:"id"`
+			MaxModelLen int    `json:"max_model_len"`
+			Meta        struct {
+				NCtxTrain int `json:"n_ctx_train"`
+			} `json:"meta"`
+		} `json:"data"`
+	}
+	if err := p.getJSON(ctx, p.config.BaseURL+"/models", &models); err != nil {
+		return 0, err
+	}
+	for _, model := range models.Data {
+		// A single model is the one served, whatever its name
+		if model.ID != p.config.ModelName && len(models.Data) > 1 {
+			continue
+		}
+		if model.MaxModelLen > 0 {
+			return model.MaxModelLen, nil
+		}
+		return model.Meta.NCtxTrain, nil
+	}
+	return 0, nil
+}
+
+// getJSON fetches a JSON document from the server
+func (p *OpenAIProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	p.authorize(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request to %s: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error from %s: status code %d for %s", p.name, resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLlamaCppProvider(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/props":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"default_generation_settings": map[string]int{"n_ctx": 4096},
+			})
+		case "/v1/chat/completions":
+			authorization = r.Header.Get("Authorization")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := CreateProvider(ProviderTypeLlamaCpp, ProviderConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.RequiresAPIKey() {
+		t.Error("llama.cpp should not require an API key")
+	}
+
+	response, err := provider.ChatCompletion("system", "hello", 0.2)
+	if err != nil || response != "ok" {
+		t.Fatalf("unexpected response %q: %v", response, err)
+	}
+	if authorization != "" {
+		t.Errorf("an Authorization header was sent without an API key: %q", authorization)
+	}
+
+	size, err := provider.(ContextSizeProvider).ContextSize(context.Background())
+	if err != nil || size != 4096 {
+		t.Errorf("expected a context size of 4096, got %d: %v", size, err)
+	}
+}
+
+func TestOpenAICompatibleContextSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"id": "small", "max_model_len": 2048},
+				{"id": "large", "max_model_len": 32768},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenAICompatibleProvider(server.URL+"/v1", "", "large")
+	size, err := provider.ContextSize(context.Background())
+	if err != nil || size != 32768 {
+		t.Errorf("expected the context size of the selected model, got %d: %v", size, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -82,8 +83,8 @@ func (p *OllamaProvider) GenerateResponse(prompt string, temperature float64) (s
 		return "", fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	fmt.Printf("Debug - Sending request to: %s\n", p.config.BaseURL+"/api/generate")
-	fmt.Printf("Debug - Request body: %s\n", string(requestBody))
+	fmt.Fprintf(os.Stderr, "Debug - Sending request to: %s\n", p.config.BaseURL+"/api/generate")
+	fmt.Fprintf(os.Stderr, "Debug - Request body: %s\n", string(requestBody))
 
 	resp, err := p.client.Post(p.config.BaseURL+"/api/generate", "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
@@ -146,8 +147,8 @@ func (p *OllamaProvider) ChatCompletion(systemPrompt string, userMessage string,
 		return "", fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	fmt.Printf("Debug - Sending request to: %s\n", p.config.BaseURL+"/api/chat")
-	fmt.Printf("Debug - Request body: %s\n", string(requestBody))
+	fmt.Fprintf(os.Stderr, "Debug - Sending request to: %s\n", p.config.BaseURL+"/api/chat")
+	fmt.Fprintf(os.Stderr, "Debug - Request body: %s\n", string(requestBody))
 
 	resp, err := p.client.Post(p.config.BaseURL+"/api/chat", "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
@@ -225,6 +226,37 @@ func (p *OllamaProvider) ListModels() (string, error) {
 	return buf.String(), nil
 }
 
+// OllamaModels returns the names of the models pulled on the Ollama server at baseURL. It fails
+// when no Ollama server answers there, which tells whether Ollama runs.
+func OllamaModels(ctx context.Context, baseURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error contacting Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from Ollama: %s", resp.Status)
+	}
+
+	var response struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	names := make([]string, 0, len(response.Models))
+	for _, model := range response.Models {
+		names = append(names, model.Name)
+	}
+	return names, nil
+}
+
 // GetName returns the name of the provider
 func (p *OllamaProvider) GetName() string {
 	return "ollama"
@@ -245,6 +277,11 @@ func (p *OllamaProvider) RequiresAPIKey() bool {
 	return false
 }
 
+// Endpoint returns the URL of the Ollama API the provider sends requests to
+func (p *OllamaProvider) Endpoint() string {
+	return p.config.BaseURL
+}
+
 // GenerateCompletion sends a prompt to Ollama and returns the response
 func (p *OllamaProvider) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
 	// Create the request body
@@ -315,3 +352,100 @@ func (p *OllamaProvider) GenerateCompletion(ctx context.Context, prompt string)
 
 	return responseText.String(), nil
 }
+
+// OllamaToolMessage represents a message in a tool-enabled Ollama conversation
+type OllamaToolMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+}
+
+// OllamaToolCall represents a function call requested by an Ollama model
+type OllamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// OllamaTool represents a function made available to an Ollama model
+type OllamaTool struct {
+	Type     string         `json:"type"`
+	Function ToolDefinition `json:"function"`
+}
+
+// OllamaToolRequest represents a chat request with tools to the Ollama API
+type OllamaToolRequest struct {
+	Model    string              `json:"model"`
+	Messages []OllamaToolMessage `json:"messages"`
+	Tools    []OllamaTool        `json:"tools,omitempty"`
+	Stream   bool                `json:"stream"`
+	Options  OllamaOptions       `json:"options,omitempty"`
+}
+
+// ChatWithTools sends a conversation with function definitions to Ollama
+func (p *OllamaProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, tools []ToolDefinition, temperature float32) (*ToolResponse, error) {
+	request := OllamaToolRequest{
+		Model:  p.config.ModelName,
+		Stream: false,
+		Options: OllamaOptions{
+			Temperature: float64(temperature),
+		},
+	}
+
+	for _, tool := range tools {
+		request.Tools = append(request.Tools, OllamaTool{Type: "function", Function: tool})
+	}
+
+	for _, msg := range messages {
+		ollamaMsg := OllamaToolMessage{Role: msg.Role, Content: msg.Content}
+		for _, call := range msg.ToolCalls {
+			var ollamaCall OllamaToolCall
+			ollamaCall.Function.Name = call.Name
+			ollamaCall.Function.Arguments = call.Arguments
+			ollamaMsg.ToolCalls = append(ollamaMsg.ToolCalls, ollamaCall)
+		}
+		request.Messages = append(request.Messages, ollamaMsg)
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/api/chat", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error from Ollama API: status code %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var response struct {
+		Message OllamaToolMessage `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	result := &ToolResponse{Content: response.Message.Content}
+	for i, call := range response.Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			// Ollama does not assign call IDs, so synthesize stable ones
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+
+	return result, nil
+}
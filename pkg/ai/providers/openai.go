@@ -14,6 +14,11 @@ import (
 type OpenAIProvider struct {
 	config ProviderConfig
 	client *http.Client
+
+	// Name of the provider; servers compatible with the OpenAI API reuse the implementation
+	name string
+	// Whether requests are sent without an API key when none is set, for local servers
+	keyOptional bool
 }
 
 // OpenAIChatRequest represents a chat request to the OpenAI API
@@ -64,6 +69,7 @@ func NewOpenAIProvider(apiKey string, modelName string) *OpenAIProvider {
 			ModelName: modelName,
 		},
 		client: &http.Client{},
+		name:   "openai",
 	}
 }
 
@@ -75,8 +81,8 @@ func (p *OpenAIProvider) GenerateResponse(prompt string, temperature float64) (s
 
 // ChatCompletion generates a response from a conversation
 func (p *OpenAIProvider) ChatCompletion(systemPrompt string, userMessage string, temperature float32) (string, error) {
-	if p.config.APIKey == "" {
-		return "", fmt.Errorf("OpenAI API key is required")
+	if err := p.checkAPIKey(); err != nil {
+		return "", err
 	}
 
 	messages := []OpenAIChatMessage{
@@ -108,7 +114,7 @@ func (p *OpenAIProvider) ChatCompletion(systemPrompt string, userMessage string,
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	p.authorize(req)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -135,8 +141,8 @@ func (p *OpenAIProvider) ChatCompletion(systemPrompt string, userMessage string,
 
 // ListModels returns a list of available models from OpenAI
 func (p *OpenAIProvider) ListModels() (string, error) {
-	if p.config.APIKey == "" {
-		return "", fmt.Errorf("OpenAI API key is required")
+	if err := p.checkAPIKey(); err != nil {
+		return "", err
 	}
 
 	req, err := http.NewRequest("GET", p.config.BaseURL+"/models", nil)
@@ -144,7 +150,7 @@ func (p *OpenAIProvider) ListModels() (string, error) {
 		return "", fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	p.authorize(req)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -164,6 +170,16 @@ func (p *OpenAIProvider) ListModels() (string, error) {
 
 	// Format the output
 	var buf strings.Builder
+
+	// Compatible servers serve their own models, all of them usable
+	if p.name != "openai" {
+		buf.WriteString("Available Models:\n")
+		for _, model := range response.Data {
+			buf.WriteString(fmt.Sprintf("- %s\n", model.ID))
+		}
+		return buf.String(), nil
+	}
+
 	buf.WriteString("Available OpenAI Models:\n")
 
 	for _, model := range response.Data {
@@ -180,7 +196,7 @@ func (p *OpenAIProvider) ListModels() (string, error) {
 
 // GetName returns the name of the provider
 func (p *OpenAIProvider) GetName() string {
-	return "openai"
+	return p.name
 }
 
 // GetModelName returns the name of the currently used model
@@ -195,7 +211,27 @@ func (p *OpenAIProvider) SetModelName(modelName string) {
 
 // RequiresAPIKey returns true if the provider requires an API key
 func (p *OpenAIProvider) RequiresAPIKey() bool {
-	return true
+	return !p.keyOptional
+}
+
+// checkAPIKey returns an error if the provider needs an API key and none is set
+func (p *OpenAIProvider) checkAPIKey() error {
+	if p.config.APIKey == "" && !p.keyOptional {
+		return fmt.Errorf("OpenAI API key is required")
+	}
+	return nil
+}
+
+// authorize adds the API key to a request, if one is set
+func (p *OpenAIProvider) authorize(req *http.Request) {
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+}
+
+// Endpoint returns the URL of the OpenAI-compatible API the provider sends requests to
+func (p *OpenAIProvider) Endpoint() string {
+	return p.config.BaseURL
 }
 
 // GenerateCompletion sends a prompt to OpenAI and returns the response
@@ -223,13 +259,13 @@ func (p *OpenAIProvider) GenerateCompletion(ctx context.Context, prompt string)
 	}
 
 	// Create the HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("error creating request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	p.authorize(req)
 
 	// Send the request
 	resp, err := p.client.Do(req)
@@ -276,3 +312,211 @@ func (p *OpenAIProvider) GenerateCompletion(ctx context.Context, prompt string)
 func float32ToFloat64(f float32) float64 {
 	return float64(f)
 }
+
+// OpenAIToolMessage represents a message in a tool-enabled conversation
+type OpenAIToolMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIToolCall represents a function call requested by the model
+type OpenAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// OpenAITool represents a function made available to the model
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function ToolDefinition `json:"function"`
+}
+
+// OpenAIToolRequest represents a chat request with tools to the OpenAI API
+type OpenAIToolRequest struct {
+	Model       string              `json:"model"`
+	Messages    []OpenAIToolMessage `json:"messages"`
+	Tools       []OpenAITool        `json:"tools,omitempty"`
+	Temperature float64             `json:"temperature"`
+}
+
+// ChatWithTools sends a conversation with function definitions to OpenAI
+func (p *OpenAIProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, tools []ToolDefinition, temperature float32) (*ToolResponse, error) {
+	if err := p.checkAPIKey(); err != nil {
+		return nil, err
+	}
+
+	request := OpenAIToolRequest{
+		Model:       p.config.ModelName,
+		Temperature: float32ToFloat64(temperature),
+	}
+
+	for _, tool := range tools {
+		request.Tools = append(request.Tools, OpenAITool{Type: "function", Function: tool})
+	}
+
+	for _, msg := range messages {
+		openAIMsg := OpenAIToolMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			args, err := json.Marshal(call.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("error marshaling tool arguments: %w", err)
+			}
+			openAICall := OpenAIToolCall{ID: call.ID, Type: "function"}
+			openAICall.Function.Name = call.Name
+			openAICall.Function.Arguments = string(args)
+			openAIMsg.ToolCalls = append(openAIMsg.ToolCalls, openAICall)
+		}
+		request.Messages = append(request.Messages, openAIMsg)
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	p.authorize(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to OpenAI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error from OpenAI API: status code %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var response struct {
+		Choices []struct {
+			Message OpenAIToolMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no response choices returned")
+	}
+
+	message := response.Choices[0].Message
+	result := &ToolResponse{Content: message.Content}
+	for _, call := range message.ToolCalls {
+		args := make(map[string]interface{})
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("error decoding arguments for tool %s: %w", call.Function.Name, err)
+			}
+		}
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: args,
+		})
+	}
+
+	return result, nil
+}
+
+// OpenAIResponseFormat asks the OpenAI API to return JSON matching a schema
+type OpenAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Schema      map[string]interface{} `json:"schema"`
+		Strict      bool                   `json:"strict"`
+	} `json:"json_schema"`
+}
+
+// OpenAIStructuredRequest represents a chat request with a response format to the OpenAI API
+type OpenAIStructuredRequest struct {
+	Model          string               `json:"model"`
+	Messages       []OpenAIChatMessage  `json:"messages"`
+	Temperature    float64              `json:"temperature"`
+	ResponseFormat OpenAIResponseFormat `json:"response_format"`
+}
+
+// ChatStructured requests a response constrained to a JSON schema using OpenAI structured outputs
+func (p *OpenAIProvider) ChatStructured(ctx context.Context, systemPrompt string, userMessage string, schema ResponseSchema, temperature float32) (string, error) {
+	if err := p.checkAPIKey(); err != nil {
+		return "", err
+	}
+
+	request := OpenAIStructuredRequest{
+		Model:       p.config.ModelName,
+		Temperature: float32ToFloat64(temperature),
+	}
+	if systemPrompt != "" {
+		request.Messages = append(request.Messages, OpenAIChatMessage{Role: "system", Content: systemPrompt})
+	}
+	request.Messages = append(request.Messages, OpenAIChatMessage{Role: "user", Content: userMessage})
+
+	request.ResponseFormat.Type = "json_schema"
+	request.ResponseFormat.JSONSchema.Name = schema.Name
+	request.ResponseFormat.JSONSchema.Description = schema.Description
+	request.ResponseFormat.JSONSchema.Schema = schema.Schema
+	request.ResponseFormat.JSONSchema.Strict = true
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	p.authorize(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error making request to OpenAI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("error from OpenAI API: status code %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+				Refusal string `json:"refusal"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("error decoding response: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("no response choices returned")
+	}
+	if refusal := response.Choices[0].Message.Refusal; refusal != "" {
+		return "", fmt.Errorf("OpenAI refused the request: %s", refusal)
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
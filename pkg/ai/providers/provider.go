@@ -36,4 +36,7 @@ type ProviderConfig struct {
 	BaseURL   string
 	APIKey    string
 	ModelName string
+	// HTTP connection settings; factories of registered providers can apply them with
+	// NewHTTPClient
+	Transport TransportConfig
 }
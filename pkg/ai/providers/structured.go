@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"context"
+	"strings"
+)
+
+// ResponseSchema describes the JSON document a structured request must return
+type ResponseSchema struct {
+	// Identifier of the schema (letters, digits, underscores)
+	Name string
+	// Description of what the document contains
+	Description string
+	// JSON schema of the document; must describe an object
+	Schema map[string]interface{}
+}
+
+// StructuredOutputProvider is implemented by providers that can constrain responses to a JSON schema
+type StructuredOutputProvider interface {
+	// ChatStructured sends a conversation and returns a JSON document matching the schema
+	ChatStructured(ctx context.Context, systemPrompt string, userMessage string, schema ResponseSchema, temperature float32) (string, error)
+}
+
+// geminiSchema converts a JSON schema to the OpenAPI subset accepted by Gemini's responseSchema,
+// which uses upper-case type names and does not support additionalProperties
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "additionalProperties":
+			continue
+		case "type":
+			if name, ok := value.(string); ok {
+				value = strings.ToUpper(name)
+			}
+		case "properties":
+			if properties, ok := value.(map[string]interface{}); ok {
+				convertedProperties := make(map[string]interface{}, len(properties))
+				for name, property := range properties {
+					if propertySchema, ok := property.(map[string]interface{}); ok {
+						property = geminiSchema(propertySchema)
+					}
+					convertedProperties[name] = property
+				}
+				value = convertedProperties
+			}
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				value = geminiSchema(items)
+			}
+		}
+		converted[key] = value
+	}
+	return converted
+}
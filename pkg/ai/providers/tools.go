@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"context"
+)
+
+// ToolDefinition describes a function the model is allowed to call
+type ToolDefinition struct {
+	// Name of the tool as presented to the model
+	Name string `json:"name"`
+	// Description of what the tool does and when to use it
+	Description string `json:"description"`
+	// JSON schema describing the tool arguments
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall represents a single tool invocation requested by the model
+type ToolCall struct {
+	// ID assigned by the provider (may be empty for providers without IDs)
+	ID string `json:"id,omitempty"`
+	// Name of the tool to invoke
+	Name string `json:"name"`
+	// Arguments decoded from the model output
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// ChatMessage is a provider-neutral message in a multi-turn conversation
+type ChatMessage struct {
+	// Role is one of system, user, assistant or tool
+	Role string `json:"role"`
+	// Content of the message
+	Content string `json:"content"`
+	// ToolCalls requested by the assistant in this message
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	// ToolCallID links a tool result back to the call that produced it
+	ToolCallID string `json:"toolCallId,omitempty"`
+}
+
+// ToolResponse is the model's reply to a tool-enabled chat request
+type ToolResponse struct {
+	// Content is the free-text part of the reply
+	Content string
+	// ToolCalls requested by the model; empty when the model has finished
+	ToolCalls []ToolCall
+}
+
+// ToolCallingProvider is implemented by providers that support native function calling
+type ToolCallingProvider interface {
+	// ChatWithTools sends a conversation together with the available tools
+	ChatWithTools(ctx context.Context, messages []ChatMessage, tools []ToolDefinition, temperature float32) (*ToolResponse, error)
+}
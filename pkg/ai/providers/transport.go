@@ -0,0 +1,151 @@
+package providers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyDirect is the proxy setting that connects directly, ignoring the proxy environment variables
+const ProxyDirect = "direct"
+
+// TransportConfig configures the HTTP connection to a provider, for corporate networks with
+// proxies, TLS-intercepting gateways or mutual TLS
+type TransportConfig struct {
+	// Proxy URL, such as http://proxy.corp:3128 or socks5://127.0.0.1:1080; a URL without a
+	// scheme is an HTTP proxy. Empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY, and "direct"
+	// ignores them. Hosts listed in NO_PROXY bypass the proxy either way.
+	Proxy string
+	// PEM bundle of certificate authorities trusted in addition to the system ones
+	CAFile string
+	// PEM client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+	// Minimum TLS version: 1.0, 1.1, 1.2 or 1.3; empty uses Go's default of 1.2
+	TLSMinVersion string
+}
+
+// IsZero reports whether the configuration leaves every setting to its default
+func (t TransportConfig) IsZero() bool {
+	return t == TransportConfig{}
+}
+
+// NewHTTPClient returns an HTTP client connecting as the configuration says. A zero
+// configuration gives a client with Go's default transport.
+func NewHTTPClient(t TransportConfig) (*http.Client, error) {
+	if t.IsZero() {
+		return &http.Client{}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := t.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+
+	tlsConfig, err := t.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// proxyFunc returns the transport's proxy selection
+func (t TransportConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	switch t.Proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect:
+		return nil, nil
+	}
+
+	proxy := t.Proxy
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", t.Proxy)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q in %q: use http, https or socks5", proxyURL.Scheme, t.Proxy)
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	config := httpproxy.Config{HTTPProxy: proxyURL.String(), HTTPSProxy: proxyURL.String(), NoProxy: noProxy}
+	proxyForURL := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyForURL(req.URL)
+	}, nil
+}
+
+// tlsConfig returns the transport's TLS configuration
+func (t TransportConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if t.TLSMinVersion != "" {
+		version, err := parseTLSVersion(t.TLSMinVersion)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if t.CAFile != "" {
+		data, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, fmt.Errorf("a client certificate needs both a certificate file and a key file")
+		}
+		certificate, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// parseTLSVersion parses a TLS version such as 1.2 or TLS1.2
+func parseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(version), "tls") {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q: use 1.0, 1.1, 1.2 or 1.3", version)
+}
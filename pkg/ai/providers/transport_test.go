@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.corp")
+
+	client, err := NewHTTPClient(TransportConfig{Proxy: "proxy.corp:3128", TLSMinVersion: "1.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.Transport.(*http.Transport)
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("unexpected minimum TLS version %x", transport.TLSClientConfig.MinVersion)
+	}
+
+	for target, want := range map[string]string{
+		"https://api.openai.com/v1/chat/completions": "http://proxy.corp:3128",
+		"https://llm.internal.corp/v1":               "",
+	} {
+		req, _ := http.NewRequest("GET", target, nil)
+		proxy, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if got != want {
+			t.Errorf("proxy for %s = %q, want %q", target, got, want)
+		}
+	}
+}
+
+func TestNewHTTPClientErrors(t *testing.T) {
+	for name, config := range map[string]TransportConfig{
+		"proxy scheme":    {Proxy: "ftp://proxy.corp"},
+		"TLS version":     {TLSMinVersion: "1.4"},
+		"missing key":     {CertFile: "client.crt"},
+		"missing CA file": {CAFile: "/nonexistent/ca.pem"},
+	} {
+		if _, err := NewHTTPClient(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
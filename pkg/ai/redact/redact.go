@@ -0,0 +1,382 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Redaction policies, from least to most aggressive
+const (
+	// Send prompts unchanged
+	PolicyOff = "off"
+	// Mask credentials: private keys, tokens, cloud access keys, passwords and URL credentials
+	PolicyStandard = "standard"
+	// Also mask IP addresses, email addresses and long encoded values such as Secret data
+	PolicyStrict = "strict"
+)
+
+// Policies lists the redaction policies
+var Policies = []string{PolicyOff, PolicyStandard, PolicyStrict}
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// rule is a pattern whose matches are redacted. With group set, only that submatch is masked,
+// so that keys such as password: stay readable.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	group   int
+}
+
+// standardRules mask credentials
+var standardRules = []rule{
+	{name: "private key", pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "bearer token", pattern: regexp.MustCompile(`(?i)\b(bearer|basic)\s+([A-Za-z0-9._~+/=-]{8,})`), group: 2},
+	{name: "JWT", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`)},
+	{name: "AWS access key", pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "API key", pattern: regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{30,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`)},
+	{name: "URL credentials", pattern: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`), group: 1},
+	{name: "credential env var", pattern: regexp.MustCompile(`(?i)name:\s*["']?[\w.-]*(password|passwd|secret|token|api[_-]?key|access[_-]?key|credentials?)[\w.-]*["']?[ \t]*\r?\n[ \t]*value:\s*["']?([^\s"']+)`), group: 2},
+	{name: "credential value", pattern: regexp.MustCompile(`(?i)["']?[\w.-]*(password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|credentials?|private[_-]?key)["']?[ \t]*[:=][ \t]*["']?([^\s"',}]+)`), group: 2},
+}
+
+// strictRules mask identifying details on top of the standard rules
+var strictRules = []rule{
+	{name: "IP address", pattern: regexp.MustCompile(`\b(25[0-5]|2[0-4]\d|1?\d?\d)(\.(25[0-5]|2[0-4]\d|1?\d?\d)){3}\b`)},
+	{name: "email address", pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{name: "encoded value", pattern: regexp.MustCompile(`\b[A-Za-z0-9+/]{40,}={0,2}`)},
+}
+
+// Redactor masks sensitive values in text sent to an AI provider
+type Redactor struct {
+	policy string
+	rules  []rule
+	// Fields masked in the YAML and JSON documents of the text
+	paths []fieldPath
+	// User-defined patterns, as configured
+	patterns []string
+}
+
+// Match is a value a redactor masked, with the rule or user-defined pattern that matched it
+type Match struct {
+	Rule  string `json:"rule"`
+	Value string `json:"value"`
+}
+
+// New creates a redactor for a policy and user-defined patterns; an empty policy means off.
+// Patterns are regular expressions whose matches are masked, or field paths such as
+// spec.containers[].env[?name=~'.*TOKEN'] whose values are masked in YAML and JSON documents.
+func New(policy string, patterns []string) (*Redactor, error) {
+	var redactor *Redactor
+	switch policy {
+	case "", PolicyOff:
+		redactor = &Redactor{policy: PolicyOff}
+	case PolicyStandard:
+		redactor = &Redactor{policy: policy, rules: standardRules}
+	case PolicyStrict:
+		rules := append(append([]rule{}, standardRules...), strictRules...)
+		redactor = &Redactor{policy: policy, rules: rules}
+	default:
+		return nil, fmt.Errorf("unknown redaction policy %q (expected %s)", policy, strings.Join(Policies, ", "))
+	}
+
+	redactor.patterns = patterns
+	for _, pattern := range patterns {
+		if path, ok, err := parseFieldPath(pattern); ok {
+			if err != nil {
+				return nil, fmt.Errorf("invalid redaction field path %q: %w", pattern, err)
+			}
+			redactor.paths = append(redactor.paths, path)
+			continue
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		redactor.rules = append(redactor.rules, rule{name: "pattern " + pattern, pattern: compiled})
+	}
+	return redactor, nil
+}
+
+// Policy returns the redactor's policy
+func (r *Redactor) Policy() string {
+	return r.policy
+}
+
+// Patterns returns the user-defined patterns applied on top of the policy
+func (r *Redactor) Patterns() []string {
+	return r.patterns
+}
+
+// Redact returns the text with the values matched by the policy's rules and the user-defined
+// patterns masked
+func (r *Redactor) Redact(text string) string {
+	text, _ = r.Preview(text)
+	return text
+}
+
+// Preview redacts the text like Redact and also returns the values masked, in order of the rules
+func (r *Redactor) Preview(text string) (string, []Match) {
+	if r == nil {
+		return text, nil
+	}
+	var matches []Match
+	record := func(rule, value string) {
+		matches = append(matches, Match{Rule: rule, Value: value})
+	}
+
+	// Fields first, while the documents still parse
+	if len(r.paths) > 0 {
+		text = r.redactDocuments(text, record)
+	}
+	for _, rule := range r.rules {
+		if rule.group == 0 {
+			text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+				if match != Mask {
+					record(rule.name, match)
+				}
+				return Mask
+			})
+			continue
+		}
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := rule.pattern.FindStringSubmatchIndex(match)
+			start, end := groups[2*rule.group], groups[2*rule.group+1]
+			if start < 0 || match[start:end] == Mask {
+				return match
+			}
+			record(rule.name, match[start:end])
+			return match[:start] + Mask + match[end:]
+		})
+	}
+	return text, matches
+}
+
+// fencedBlock matches a fenced YAML or JSON block of a prompt
+var fencedBlock = regexp.MustCompile("(?s)```(yaml|yml|json)\n(.*?)\n```")
+
+// pathStep matches a step of a field path: a key, optionally followed by [] for every item of
+// a list or by a filter on the items such as [?name=~'.*TOKEN'] or [?name='API_KEY']
+var pathStep = regexp.MustCompile(`^([A-Za-z_][\w-]*)(\[\]|\[\?([A-Za-z_][\w-]*)(=~|=)'([^']*)'\])?$`)
+
+// fieldPath selects fields of YAML and JSON documents. It matches at any depth, so
+// spec.containers[] also selects the containers of a Deployment's pod template.
+type fieldPath struct {
+	source string
+	steps  []fieldStep
+}
+
+// fieldStep is a key of a field path, with whether it goes into the items of a list and the
+// filter the items must pass
+type fieldStep struct {
+	key   string
+	items bool
+	// Field of the items whose value must match filter
+	filterField string
+	filter      *regexp.Regexp
+}
+
+// parseFieldPath parses a user-defined pattern as a field path. It reports whether the pattern
+// is one: dotted keys, or a key with a list selector; anything else is a regular expression.
+func parseFieldPath(pattern string) (fieldPath, bool, error) {
+	parts := splitFieldPath(pattern)
+	path := fieldPath{source: pattern}
+	for _, part := range parts {
+		match := pathStep.FindStringSubmatch(part)
+		if match == nil {
+			return fieldPath{}, false, nil
+		}
+		step := fieldStep{key: match[1], items: match[2] != ""}
+		if match[3] != "" {
+			expression := regexp.QuoteMeta(match[5])
+			if match[4] == "=~" {
+				expression = match[5]
+			}
+			filter, err := regexp.Compile("^(?:" + expression + ")$")
+			if err != nil {
+				return fieldPath{}, true, err
+			}
+			step.filterField, step.filter = match[3], filter
+		}
+		path.steps = append(path.steps, step)
+	}
+	if len(path.steps) < 2 && !strings.Contains(pattern, "[") {
+		return fieldPath{}, false, nil
+	}
+	return path, true, nil
+}
+
+// splitFieldPath splits a field path on the dots outside brackets
+func splitFieldPath(path string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range path {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				parts = append(parts, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, path[start:])
+}
+
+// redactDocuments masks the fields selected by the field paths in the fenced YAML and JSON
+// blocks of the text, or in the whole text when it is a document itself
+func (r *Redactor) redactDocuments(text string, record func(rule, value string)) string {
+	if redacted, ok := r.redactDocument(text, strings.HasPrefix(strings.TrimSpace(text), "{"), record); ok {
+		return redacted
+	}
+	return fencedBlock.ReplaceAllStringFunc(text, func(block string) string {
+		match := fencedBlock.FindStringSubmatch(block)
+		isJSON := match[1] == "json"
+		var documents []string
+		changed := false
+		for _, document := range strings.Split(match[2], "\n---\n") {
+			if redacted, ok := r.redactDocument(document, isJSON, record); ok {
+				document, changed = redacted, true
+			}
+			documents = append(documents, document)
+		}
+		if !changed {
+			return block
+		}
+		return "```" + match[1] + "\n" + strings.Join(documents, "\n---\n") + "\n```"
+	})
+}
+
+// redactDocument masks the selected fields of a YAML or JSON object, reporting whether any was
+// masked. Masked documents are marshaled again, so their keys end up sorted.
+func (r *Redactor) redactDocument(document string, isJSON bool, record func(rule, value string)) (string, bool) {
+	var node map[string]interface{}
+	if err := yaml.Unmarshal([]byte(document), &node); err != nil || node == nil {
+		return document, false
+	}
+	masked := false
+	for _, path := range r.paths {
+		if path.mask(node, func(value string) { record("field "+path.source, value) }) {
+			masked = true
+		}
+	}
+	if !masked {
+		return document, false
+	}
+	var data []byte
+	var err error
+	if isJSON {
+		data, err = json.MarshalIndent(node, "", "  ")
+	} else {
+		data, err = yaml.Marshal(node)
+	}
+	if err != nil {
+		return document, false
+	}
+	return strings.TrimSuffix(string(data), "\n"), true
+}
+
+// mask masks the fields the path selects at any depth of a node, reporting whether it masked any
+func (p fieldPath) mask(node interface{}, record func(value string)) bool {
+	masked := p.maskAt(node, p.steps, record)
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for _, child := range n {
+			if p.mask(child, record) {
+				masked = true
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if p.mask(child, record) {
+				masked = true
+			}
+		}
+	}
+	return masked
+}
+
+// maskAt masks the fields the steps select from a node
+func (p fieldPath) maskAt(node interface{}, steps []fieldStep, record func(value string)) bool {
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	step := steps[0]
+	value, ok := object[step.key]
+	if !ok {
+		return false
+	}
+	if !step.items {
+		if len(steps) == 1 {
+			object[step.key] = maskValue(value, record)
+			return true
+		}
+		return p.maskAt(value, steps[1:], record)
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+	masked := false
+	for i, item := range list {
+		if !step.matches(item) {
+			continue
+		}
+		if len(steps) == 1 {
+			list[i] = maskValue(item, record)
+			masked = true
+		} else if p.maskAt(item, steps[1:], record) {
+			masked = true
+		}
+	}
+	return masked
+}
+
+// matches reports whether a list item passes the step's filter
+func (s fieldStep) matches(item interface{}) bool {
+	if s.filter == nil {
+		return true
+	}
+	object, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	value, ok := object[s.filterField]
+	return ok && s.filter.MatchString(fmt.Sprint(value))
+}
+
+// maskValue masks a value. In objects, the name field stays readable so the masked entry, such
+// as an environment variable, can still be told apart.
+func maskValue(value interface{}, record func(value string)) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key != "name" {
+				v[key] = maskValue(child, record)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = maskValue(child, record)
+		}
+		return v
+	case nil:
+		return nil
+	default:
+		if text := fmt.Sprint(v); text != Mask {
+			record(text)
+		}
+		return Mask
+	}
+}
@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"kube-ai/internal/config"
+	"kube-ai/pkg/ai/models"
+)
+
+// task is what the running command needs from a model, when model routing is on
+type task struct {
+	command string
+	needs   models.Requirements
+}
+
+// Models returns the model registry: the built-in models with the configured ones
+func (s *Service) Models() *models.Registry {
+	return s.models
+}
+
+// RouteTask switches to the model picked for a command when model routing is on, unless the
+// persona prefers a model; a profile selecting a model still overrides it
+func (s *Service) RouteTask(command string, needs models.Requirements) {
+	if !s.config.RoutingEnabled() {
+		return
+	}
+	s.task = &task{command: command, needs: needs}
+	s.applyRoute()
+	s.applyPersonaModel()
+}
+
+// Route returns the model the provider in use would be given for a command and why: the model
+// routed to the command or its tier in the configuration, else the cheapest model of the
+// registry meeting the requirements, else the default model
+func (s *Service) Route(command string, needs models.Requirements) (string, string) {
+	provider := s.provider.GetName()
+	if model, key := s.config.Route(command, string(needs.Tier)); model != "" && s.servesModel(provider, model) {
+		return model, "route for " + key
+	}
+	if model, ok := s.models.Cheapest(provider, needs); ok {
+		return model.Name, "cheapest " + string(needs.Tier) + " model"
+	}
+	return s.defaultModel(provider), "default model"
+}
+
+// applyRoute switches to the model routed to the running command, if model routing is on
+func (s *Service) applyRoute() {
+	if s.task == nil {
+		return
+	}
+	model, _ := s.Route(s.task.command, s.task.needs)
+	s.provider.SetModelName(model)
+}
+
+// servesModel reports whether a routed model can belong to a provider: models the registry
+// only knows for other providers are skipped, so routes set for one provider don't break
+// profiles selecting another
+func (s *Service) servesModel(provider, model string) bool {
+	if _, ok := s.models.Lookup(provider, model); ok {
+		return true
+	}
+	for _, known := range s.models.Models("") {
+		if known.Name == model {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultModel returns the model a provider uses without routing
+func (s *Service) defaultModel(provider string) string {
+	if provider == s.config.AIProvider {
+		return s.config.DefaultModel
+	}
+	return defaultModels[provider]
+}
+
+// modelRegistry builds the model registry from the configuration
+func modelRegistry(cfg *config.Config) *models.Registry {
+	var configured []models.Model
+	for name, info := range cfg.Models {
+		model := models.Model{
+			Provider:      info.Provider,
+			Name:          name,
+			ContextTokens: info.ContextTokens,
+			InputCost:     info.InputCostPerMTok,
+			OutputCost:    info.OutputCostPerMTok,
+			Tier:          models.Tier(info.Tier),
+		}
+		for _, capability := range info.Capabilities {
+			model.Capabilities = append(model.Capabilities, models.Capability(capability))
+		}
+		configured = append(configured, model)
+	}
+	return models.NewRegistry(configured)
+}
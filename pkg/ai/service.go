@@ -3,47 +3,105 @@ package ai
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
 
 	"kube-ai/internal/config"
+	"kube-ai/pkg/ai/models"
+	"kube-ai/pkg/ai/prompts"
 	"kube-ai/pkg/ai/providers"
+	"kube-ai/pkg/ai/redact"
+	"kube-ai/pkg/i18n"
 )
 
 // Service provides AI capabilities for Kubernetes operations
 type Service struct {
 	provider providers.Provider
 	config   *config.Config
+	prompts  *prompts.Renderer
+	cluster  prompts.ClusterInfo
+	language string
+
+	// Set by the active profile, if any
+	profile     string
+	persona     string
+	temperature *float32
+	redactor    *redact.Redactor
+
+	// Set by the running command's binding, if any
+	commandPersona string
+	promptBindings map[string]string
+
+	// Where prompts are shown instead of being sent, in dry-run mode
+	dryRun io.Writer
+
+	// Whether providers must have a local or private endpoint
+	localOnly bool
+
+	// Context windows reported by providers, by provider and model; 0 when unknown
+	contextSizes map[string]int
+
+	// Reads the release of the target cluster once a prompt needs it (nil for none)
+	versionLoader func() (*ClusterVersion, error)
+	versionOnce   *sync.Once
+	version       *ClusterVersion
+
+	// Reads the environment of the target cluster for the cluster context preamble (nil when off)
+	environmentLoader func() (*ClusterEnvironment, error)
+	environmentOnce   *sync.Once
+	environment       *ClusterEnvironment
+
+	// Called with every answered prompt, and when a prompt is sent (nil for none)
+	onExchange func(Exchange)
+	onRequest  func() func()
+
+	// Known models, and the running command's task when model routing is on
+	models *models.Registry
+	task   *task
 }
 
 // NewService creates a new AI service
 func NewService(cfg *config.Config) *Service {
+	s := &Service{}
+	s.Init(cfg)
+	return s
+}
+
+// Init sets the service up for a configuration, replacing any previous setup. It lets commands
+// created before the configuration is loaded share the service.
+func (s *Service) Init(cfg *config.Config) {
 	// Create provider based on configuration
 	providerType := providers.ProviderType(cfg.AIProvider)
-	providerConfig := providers.ProviderConfig{
-		BaseURL:   cfg.GetProviderURL(cfg.AIProvider),
-		APIKey:    cfg.GetAPIKey(cfg.AIProvider),
-		ModelName: cfg.DefaultModel,
-	}
-
-	provider, err := providers.CreateProvider(providerType, providerConfig)
+	provider, err := providers.CreateProvider(providerType, providerConfig(cfg, cfg.AIProvider, cfg.DefaultModel))
 	if err != nil {
 		// Fallback to Ollama if provider creation fails
-		fmt.Printf("Error initializing provider '%s': %v, falling back to Ollama\n", cfg.AIProvider, err)
+		fmt.Fprintf(os.Stderr, "Error initializing provider '%s': %v, falling back to Ollama\n", cfg.AIProvider, err)
 		provider = providers.NewOllamaProvider(cfg.OllamaURL, cfg.DefaultModel)
-		// Also update config to reflect the fallback
+		// Also update config to reflect the fallback, for this run only
 		cfg.AIProvider = "ollama"
-		if saveErr := cfg.SaveConfig(); saveErr != nil {
-			fmt.Printf("Warning: Failed to save fallback provider configuration: %v\n", saveErr)
-		}
 	}
 
-	return &Service{
+	// Prompt overrides are optional, so a missing home directory only disables them
+	promptDir, _ := prompts.DefaultDir()
+
+	// The user-defined redaction patterns apply without a profile; they are checked when the
+	// configuration is loaded
+	redactor, _ := redact.New(redact.PolicyOff, cfg.Redact)
+
+	*s = Service{
 		provider: provider,
 		config:   cfg,
+		prompts:  prompts.NewRenderer(promptDir),
+		redactor: redactor,
+		language: cfg.Language,
+		models:   modelRegistry(cfg),
 	}
+	s.applyPersonaModel()
 }
 
-// SwitchProvider changes the AI provider
+// SwitchProvider changes the AI provider; the configuration is updated but not saved
 func (s *Service) SwitchProvider(providerName string) error {
 	providerType := providers.ProviderType(providerName)
 
@@ -81,30 +139,22 @@ func (s *Service) SwitchProvider(providerName string) error {
 		if s.config.DefaultModel == "" || strings.Contains(s.config.DefaultModel, "llama") {
 			s.config.DefaultModel = "gemini-1.5-pro"
 		}
-	case "anythingllm":
+	case "anythingllm", "llamacpp", "openai-compatible":
 		s.config.DefaultModel = "default"
 	}
 
 	// Create new provider
-	providerConfig := providers.ProviderConfig{
-		BaseURL:   s.config.GetProviderURL(providerName),
-		APIKey:    s.config.GetAPIKey(providerName),
-		ModelName: s.config.DefaultModel,
-	}
-
-	provider, err := providers.CreateProvider(providerType, providerConfig)
+	provider, err := providers.CreateProvider(providerType, providerConfig(s.config, providerName, s.config.DefaultModel))
 	if err != nil {
 		return fmt.Errorf("error creating provider: %w", err)
 	}
+	if err := s.checkLocal(provider); err != nil {
+		return err
+	}
 
 	// Update service provider
 	s.provider = provider
 
-	// Save configuration
-	if err := s.config.SaveConfig(); err != nil {
-		return fmt.Errorf("error saving configuration: %w", err)
-	}
-
 	return nil
 }
 
@@ -121,74 +171,184 @@ func (s *Service) GetProvider() providers.Provider {
 
 // AnalyzeDeployment analyzes a Kubernetes deployment
 func (s *Service) AnalyzeDeployment(deploymentYAML string) (string, error) {
-	prompt := fmt.Sprintf("Analyze this Kubernetes deployment and provide insights and recommendations:\n\n%s", deploymentYAML)
+	prompt, err := s.RenderPrompt(prompts.AnalyzeDeployment, map[string]interface{}{"DeploymentYAML": deploymentYAML})
+	if err != nil {
+		return "", err
+	}
 
 	// Get current persona system prompt for context
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, prompt, 0.7)
+	return s.complete(systemPrompt, prompt, 0.7)
 }
 
 // OptimizeResources suggests optimizations for resource usage
 func (s *Service) OptimizeResources(resourcesYAML string) (string, error) {
-	prompt := fmt.Sprintf("Suggest optimizations for these Kubernetes resource definitions to improve efficiency and performance:\n\n%s", resourcesYAML)
+	prompt, err := s.RenderPrompt(prompts.OptimizeResources, map[string]interface{}{"ResourcesYAML": resourcesYAML})
+	if err != nil {
+		return "", err
+	}
 
 	// Get current persona system prompt for context
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, prompt, 0.7)
+	return s.complete(systemPrompt, prompt, 0.7)
 }
 
 // SuggestScalingStrategy suggests scaling strategies
 func (s *Service) SuggestScalingStrategy(metricsData, currentConfig string) (string, error) {
-	prompt := fmt.Sprintf("Based on the following metrics and current configuration, suggest an optimal scaling strategy for this Kubernetes workload:\n\nMetrics:\n%s\n\nCurrent Configuration:\n%s",
-		metricsData, currentConfig)
+	prompt, err := s.RenderPrompt(prompts.SuggestScaling, map[string]interface{}{
+		"MetricsData":   metricsData,
+		"CurrentConfig": currentConfig,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Get current persona system prompt for context
+	systemPrompt := s.systemPrompt()
+
+	return s.complete(systemPrompt, prompt, 0.7)
+}
+
+// GenerateManifest generates a Kubernetes manifest, following the schemas of the given custom resources
+func (s *Service) GenerateManifest(description string, customResources []prompts.CustomResource) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.GenerateManifest, map[string]interface{}{
+		"Description":     description,
+		"CustomResources": customResources,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Get current persona system prompt for context
+	systemPrompt := s.systemPrompt()
+
+	return s.complete(systemPrompt, prompt, 0.7)
+}
+
+// GenerateStack generates the manifests of a complete application stack: a Deployment, Service,
+// Ingress, HorizontalPodAutoscaler, PodDisruptionBudget and NetworkPolicy sharing name and labels
+func (s *Service) GenerateStack(description, name string) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.GenerateStack, map[string]interface{}{
+		"Description": description,
+		"Name":        name,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Get current persona system prompt for context
+	systemPrompt := s.systemPrompt()
+
+	return s.complete(systemPrompt, prompt, 0.7)
+}
+
+// GeneratePolicy generates an admission policy for the engine (gatekeeper or kyverno) that
+// enforces the intent, such as "disallow privileged pods except in kube-system"
+func (s *Service) GeneratePolicy(intent, engine string) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.GeneratePolicy, map[string]interface{}{
+		"Intent": intent,
+		"Engine": engine,
+	})
+	if err != nil {
+		return "", err
+	}
 
 	// Get current persona system prompt for context
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, prompt, 0.7)
+	// Policies must be exact rather than creative
+	return s.complete(systemPrompt, prompt, 0.3)
 }
 
-// GenerateManifest generates a Kubernetes manifest
-func (s *Service) GenerateManifest(description string) (string, error) {
-	prompt := fmt.Sprintf("Generate a valid Kubernetes manifest for the following description:\n\n%s\n\nPlease provide a complete YAML manifest.",
-		description)
+// RefineManifest regenerates a manifest with additional instructions, keeping the original
+// description, the earlier instructions and any validation problems of the current manifest as context
+func (s *Service) RefineManifest(description, manifest string, instructions, problems []string, customResources []prompts.CustomResource) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.RefineManifest, map[string]interface{}{
+		"Description":     description,
+		"Manifest":        manifest,
+		"Instructions":    instructions,
+		"Problems":        problems,
+		"CustomResources": customResources,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Get current persona system prompt for context
+	systemPrompt := s.systemPrompt()
+
+	// Keep refinements close to the current manifest
+	return s.complete(systemPrompt, prompt, 0.3)
+}
+
+// ExplainError explains Kubernetes errors, using the schemas of the custom resources involved and
+// the validation problems of a related manifest when given
+func (s *Service) ExplainError(errorMessage string, customResources []prompts.CustomResource, problems []string) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.ExplainError, map[string]interface{}{
+		"ErrorMessage":    errorMessage,
+		"CustomResources": customResources,
+		"Problems":        problems,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Get current persona system prompt for context
+	systemPrompt := s.systemPrompt()
+
+	return s.complete(systemPrompt, prompt, 0.7)
+}
+
+// ExplainDescribe explains the state of a resource from its kubectl describe output, and how to
+// fix it when it is unhealthy
+func (s *Service) ExplainDescribe(describeOutput string) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.ExplainDescribe, map[string]interface{}{"DescribeOutput": describeOutput})
+	if err != nil {
+		return "", err
+	}
 
 	// Get current persona system prompt for context
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, prompt, 0.7)
+	return s.complete(systemPrompt, prompt, 0.7)
 }
 
-// ExplainError explains Kubernetes errors
-func (s *Service) ExplainError(errorMessage string) (string, error) {
-	prompt := fmt.Sprintf("Explain the following Kubernetes error in simple terms and suggest how to fix it:\n\n%s",
-		errorMessage)
+// ExplainField explains a resource field in practical terms, with examples and common pitfalls,
+// based on the schema the cluster publishes for it
+func (s *Service) ExplainField(kind, apiVersion, field, schema string) (string, error) {
+	prompt, err := s.RenderPrompt(prompts.ExplainField, map[string]interface{}{
+		"Kind":       kind,
+		"APIVersion": apiVersion,
+		"Field":      field,
+		"Schema":     schema,
+	})
+	if err != nil {
+		return "", err
+	}
 
 	// Get current persona system prompt for context
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, prompt, 0.7)
+	return s.complete(systemPrompt, prompt, 0.7)
 }
 
 // Chat allows general conversation about Kubernetes
 func (s *Service) Chat(userMessage string) (string, error) {
 	// Get the current persona from config
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, userMessage, 0.7)
+	return s.complete(systemPrompt, userMessage, 0.7)
 }
 
 // ListModels lists available models from the current provider
 func (s *Service) ListModels() (string, error) {
-	return s.provider.ListModels()
+	if err := s.CheckLocalOnly(); err != nil {
+		return "", err
+	}
+	models, err := s.provider.ListModels()
+	return models, s.providerError(err)
 }
 
 // ListProviders returns a list of available AI providers
@@ -221,25 +381,325 @@ func (s *Service) GetCurrentModel() string {
 
 // GetCurrentPersona returns the name of the currently active persona
 func (s *Service) GetCurrentPersona() string {
-	return s.config.ActivePersona
+	name, _ := s.currentPersona()
+	return name
+}
+
+// GetSystemPrompt returns the system prompt of the currently active persona
+func (s *Service) GetSystemPrompt() string {
+	return s.systemPrompt()
+}
+
+// SetLanguage sets the language the AI is asked to answer in
+func (s *Service) SetLanguage(language string) {
+	s.language = language
+}
+
+// systemPrompt returns the active persona's system prompt with the language instruction applied
+func (s *Service) systemPrompt() string {
+	_, persona := s.currentPersona()
+	return persona.SystemPrompt + s.languageInstruction()
+}
+
+// currentPersona returns the persona bound to the running command if there is one, else the
+// active profile's persona if it has one, else the configured persona
+func (s *Service) currentPersona() (string, config.AIPersona) {
+	if s.commandPersona != "" {
+		if persona, ok := s.config.ListPersonas()[s.commandPersona]; ok {
+			return s.commandPersona, persona
+		}
+	}
+	if s.persona != "" {
+		if persona, ok := s.config.ListPersonas()[s.persona]; ok {
+			return s.persona, persona
+		}
+	}
+	return s.config.ActivePersona, s.config.GetCurrentPersona()
+}
+
+// languageInstruction asks the AI to answer in the configured language while keeping
+// identifiers and structured output in English so responses can still be parsed
+func (s *Service) languageInstruction() string {
+	if i18n.IsEnglish(s.language) {
+		return ""
+	}
+	return fmt.Sprintf("\n\nAlways answer in %s. Keep Kubernetes resource names, field names, commands, code, JSON keys and severity levels in English.",
+		i18n.LanguageName(s.language))
+}
+
+// SetClusterContext records the kubeconfig context and namespace that prompts refer to
+func (s *Service) SetClusterContext(contextName, namespace string) {
+	s.cluster = prompts.ClusterInfo{Context: contextName, Namespace: namespace}
+}
+
+// GetPromptRenderer returns the renderer used for prompt templates
+func (s *Service) GetPromptRenderer() *prompts.Renderer {
+	return s.prompts
+}
+
+// RenderPrompt renders a prompt template with the active persona and cluster context
+func (s *Service) RenderPrompt(name string, vars map[string]interface{}) (string, error) {
+	cluster := s.cluster
+	if version := s.clusterVersion(); version != nil {
+		cluster.Version = version.Version
+		cluster.Unsupported = version.Unsupported
+	}
+	if environment := s.clusterEnvironment(); environment != nil {
+		cluster.Platform = environment.Platform
+		cluster.CNI = environment.CNI
+		cluster.IngressClasses = environment.IngressClasses
+		cluster.Operators = environment.Operators
+	}
+
+	personaID, persona := s.currentPersona()
+	data := map[string]interface{}{
+		"Persona": prompts.PersonaInfo{
+			ID:          personaID,
+			Description: persona.Description,
+		},
+		"Cluster":  cluster,
+		"Language": i18n.LanguageName(s.language),
+	}
+	for key, value := range vars {
+		data[key] = value
+	}
+
+	if bound, ok := s.promptBindings[name]; ok {
+		name = bound
+	}
+	prompt, err := s.prompts.Render(name, data)
+	if err != nil {
+		return "", err
+	}
+	return s.withClusterContext(name, prompt, data)
+}
+
+// ApplyCommandBinding applies a command's persona and prompt templates for this run
+func (s *Service) ApplyCommandBinding(binding config.CommandBinding) error {
+	if binding.Persona != "" {
+		if _, ok := s.config.ListPersonas()[binding.Persona]; !ok {
+			return fmt.Errorf("persona '%s' not found", binding.Persona)
+		}
+	}
+	for name, bound := range binding.Prompts {
+		if _, _, err := s.prompts.Source(bound); err != nil {
+			return fmt.Errorf("prompt template %s bound in place of %s: %w", bound, name, err)
+		}
+	}
+
+	s.commandPersona = binding.Persona
+	s.promptBindings = binding.Prompts
+	s.applyPersonaModel()
+	return nil
 }
 
 // Query sends a single query to the AI provider and returns the response
 func (s *Service) Query(ctx context.Context, prompt string) (string, error) {
 	// Use the current persona's system prompt
-	persona := s.config.GetCurrentPersona()
-	systemPrompt := persona.SystemPrompt
+	systemPrompt := s.systemPrompt()
 
-	return s.provider.ChatCompletion(systemPrompt, prompt, 0.3)
+	return s.complete(systemPrompt, prompt, 0.3)
+}
+
+// QueryStructured sends a prompt and asks for a JSON document matching schema. Providers that
+// support schema-constrained output are guaranteed to return bare JSON; for others the prompt's own
+// formatting instructions apply. The second return value reports whether the output was constrained.
+func (s *Service) QueryStructured(ctx context.Context, prompt string, schema providers.ResponseSchema) (string, bool, error) {
+	structured, ok := s.provider.(providers.StructuredOutputProvider)
+	if !ok {
+		response, err := s.Query(ctx, prompt)
+		return response, false, err
+	}
+
+	systemPrompt := s.systemPrompt()
+	redacted := s.fitContext(systemPrompt, s.Redact(prompt))
+	if err := s.PreviewPrompt(systemPrompt, redacted); err != nil {
+		return "", true, err
+	}
+	if err := s.CheckLocalOnly(); err != nil {
+		return "", true, err
+	}
+	done := s.RequestStarted()
+	response, err := structured.ChatStructured(ctx, systemPrompt, redacted, schema, s.temp(0.3))
+	done()
+	if err != nil {
+		return "", true, s.providerError(err)
+	}
+	s.recordExchange(systemPrompt, redacted, response)
+	return response, true, nil
 }
 
 // ChatCompletion sends a general chat request to the AI provider
 func (s *Service) ChatCompletion(systemPrompt string, userMessage string, temperature float32) (string, error) {
 	// If no system prompt provided, use the current persona's system prompt
 	if systemPrompt == "" {
-		persona := s.config.GetCurrentPersona()
-		systemPrompt = persona.SystemPrompt
+		systemPrompt = s.systemPrompt()
+	} else {
+		systemPrompt += s.languageInstruction()
+	}
+
+	return s.complete(systemPrompt, userMessage, temperature)
+}
+
+// ApplyProfile applies a configuration profile for this run: its provider and model, persona,
+// temperature and redaction policy. Nothing is saved to the configuration.
+func (s *Service) ApplyProfile(name string, profile config.Profile) error {
+	redactor, err := redact.New(profile.Redaction, s.config.Redact)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+
+	if profile.Provider != "" || profile.Model != "" {
+		providerName := profile.Provider
+		if providerName == "" {
+			providerName = s.config.AIProvider
+		}
+		model := profile.Model
+		if model == "" {
+			model = defaultModels[providerName]
+			if providerName == s.config.AIProvider {
+				model = s.config.DefaultModel
+			}
+		}
+
+		provider, err := providers.CreateProvider(providers.ProviderType(providerName), providerConfig(s.config, providerName, model))
+		if err != nil {
+			return fmt.Errorf("profile %s: error creating provider %s: %w", name, providerName, err)
+		}
+		if err := s.checkLocal(provider); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+		s.provider = provider
+	}
+
+	if profile.Persona != "" {
+		if _, ok := s.config.ListPersonas()[profile.Persona]; !ok {
+			return fmt.Errorf("profile %s: persona '%s' not found", name, profile.Persona)
+		}
+	}
+
+	s.profile = name
+	s.persona = profile.Persona
+	s.temperature = profile.Temperature
+	s.redactor = redactor
+	if profile.Model == "" {
+		s.applyRoute()
+		s.applyPersonaModel()
+	}
+	return nil
+}
+
+// WithProvider returns a copy of the service using another provider and model, keeping the
+// profile, persona, redaction, language and modes, so the same prompts can be sent to several
+// models concurrently. An empty model uses the provider's default model.
+func (s *Service) WithProvider(providerName, model string) (*Service, error) {
+	if model == "" {
+		model = s.defaultModel(providerName)
+	}
+	provider, err := providers.CreateProvider(providers.ProviderType(providerName), providerConfig(s.config, providerName, model))
+	if err != nil {
+		return nil, fmt.Errorf("error creating provider %s: %w", providerName, err)
+	}
+	if err := s.checkLocal(provider); err != nil {
+		return nil, err
+	}
+
+	fork := *s
+	fork.provider = provider
+	return &fork, nil
+}
+
+// GetActiveProfile returns the name of the applied profile, or "" if none applies
+func (s *Service) GetActiveProfile() string {
+	return s.profile
+}
+
+// GetRedactionPolicy returns the redaction policy applied to prompts
+func (s *Service) GetRedactionPolicy() string {
+	if s.redactor == nil {
+		return redact.PolicyOff
+	}
+	return s.redactor.Policy()
+}
+
+// GetRedactionPatterns returns the user-defined redaction patterns applied to prompts
+func (s *Service) GetRedactionPatterns() []string {
+	if s.redactor == nil {
+		return nil
 	}
+	return s.redactor.Patterns()
+}
+
+// Redact masks the sensitive values in text with the active redaction policy and patterns
+func (s *Service) Redact(text string) string {
+	return s.redactor.Redact(text)
+}
+
+// complete sends a prompt to the provider with the profile's redaction and temperature applied
+func (s *Service) complete(systemPrompt, prompt string, temperature float32) (string, error) {
+	redacted := s.fitContext(systemPrompt, s.Redact(prompt))
+	if err := s.PreviewPrompt(systemPrompt, redacted); err != nil {
+		return "", err
+	}
+	if err := s.CheckLocalOnly(); err != nil {
+		return "", err
+	}
+	done := s.RequestStarted()
+	response, err := s.provider.ChatCompletion(systemPrompt, redacted, s.temp(temperature))
+	done()
+	if err != nil {
+		return "", s.providerError(err)
+	}
+	s.recordExchange(systemPrompt, redacted, response)
+	return response, nil
+}
+
+// temp returns the profile's temperature if it sets one, else the persona's preferred
+// temperature if it has one, else the given default
+func (s *Service) temp(temperature float32) float32 {
+	if s.temperature != nil {
+		return *s.temperature
+	}
+	if _, persona := s.currentPersona(); persona.Temperature != nil {
+		return *persona.Temperature
+	}
+	return temperature
+}
+
+// applyPersonaModel switches to the active persona's preferred model, if it has one for the
+// provider in use
+func (s *Service) applyPersonaModel() {
+	_, persona := s.currentPersona()
+	if persona.Model == "" || persona.Provider != "" && persona.Provider != s.provider.GetName() {
+		return
+	}
+	s.provider.SetModelName(persona.Model)
+}
+
+// providerConfig returns the configuration of a provider with the given model
+func providerConfig(cfg *config.Config, providerName, model string) providers.ProviderConfig {
+	transport := cfg.GetTransport(providerName)
+	return providers.ProviderConfig{
+		BaseURL:   cfg.GetProviderURL(providerName),
+		APIKey:    cfg.GetAPIKey(providerName),
+		ModelName: model,
+		Transport: providers.TransportConfig{
+			Proxy:         transport.Proxy,
+			CAFile:        transport.CAFile,
+			CertFile:      transport.CertFile,
+			KeyFile:       transport.KeyFile,
+			TLSMinVersion: transport.TLSMinVersion,
+		},
+	}
+}
 
-	return s.provider.ChatCompletion(systemPrompt, userMessage, temperature)
+// defaultModels are the models used when a profile selects a provider without a model
+var defaultModels = map[string]string{
+	"ollama":            "llama3.3",
+	"openai":            "gpt-3.5-turbo",
+	"anthropic":         "claude-3-haiku-20240307",
+	"gemini":            "gemini-1.5-pro",
+	"anythingllm":       "default",
+	"llamacpp":          "default",
+	"openai-compatible": "default",
 }
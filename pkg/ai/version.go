@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ClusterVersion is what prompts and suggestion filtering know of the target cluster's release
+type ClusterVersion struct {
+	// Release as major.minor, such as 1.27
+	Version string
+	// Features the cluster does not support, described for prompts
+	Unsupported []string
+	// Check returns the unsupported feature a suggestion relies on, or "" when there is none
+	Check func(suggestion string) string
+}
+
+// SetClusterVersionLoader sets how the release of the target cluster is read. It is read once,
+// when a prompt or a filter first needs it, so commands that never query the AI do not contact
+// the cluster. Without a loader, or when it fails, prompts do not mention a release and nothing
+// is filtered.
+func (s *Service) SetClusterVersionLoader(load func() (*ClusterVersion, error)) {
+	s.versionLoader = load
+	s.versionOnce = &sync.Once{}
+	s.version = nil
+}
+
+// clusterVersion returns the release of the target cluster, or nil when it is unknown
+func (s *Service) clusterVersion() *ClusterVersion {
+	if s.versionLoader == nil {
+		return nil
+	}
+	s.versionOnce.Do(func() {
+		if version, err := s.versionLoader(); err == nil {
+			s.version = version
+		}
+	})
+	return s.version
+}
+
+// FilterSuggestions drops the suggestions relying on features the cluster's release does not
+// support. It returns the kept suggestions and a note for each dropped one.
+func (s *Service) FilterSuggestions(suggestions []string) ([]string, []string) {
+	version := s.clusterVersion()
+	if version == nil || version.Check == nil {
+		return suggestions, nil
+	}
+
+	kept := make([]string, 0, len(suggestions))
+	var dropped []string
+	for _, suggestion := range suggestions {
+		if feature := version.Check(suggestion); feature != "" {
+			dropped = append(dropped, fmt.Sprintf("Left out a suggestion that needs %s, which Kubernetes %s does not support: %s", feature, version.Version, suggestion))
+			continue
+		}
+		kept = append(kept, suggestion)
+	}
+	return kept, dropped
+}
+
+// UnsupportedFeature returns the feature a suggestion relies on that the cluster's release does
+// not support, or "" when it supports them all or its release is unknown
+func (s *Service) UnsupportedFeature(suggestion string) string {
+	version := s.clusterVersion()
+	if version == nil || version.Check == nil {
+		return ""
+	}
+	return version.Check(suggestion)
+}
@@ -0,0 +1,196 @@
+// Package history keeps the answers of the AI provider, with the commands and prompts that led
+// to them, so they can be looked up and rerun later
+package history
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the size of the history file past which it is rotated
+	DefaultMaxSize = 5 * 1024 * 1024
+
+	currentFile = "answers.jsonl"
+	rotatedFile = "answers.1.jsonl"
+)
+
+// Entry is an answer of the AI provider and the command it answered
+type Entry struct {
+	// Short random identifier
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Command path, such as analyze-logs, and the arguments and flags it was run with
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Target cluster context and namespace
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	// Prompt as it was sent, after redaction
+	Prompt string `json:"prompt"`
+	Answer string `json:"answer"`
+	// Estimated tokens and price in US dollars
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	Cost         float64 `json:"cost,omitempty"`
+}
+
+// Store keeps entries in a directory, as JSON lines. Once the file grows past its maximum size
+// it is rotated, replacing the previous rotated file, so at most twice that size is kept.
+type Store struct {
+	dir     string
+	maxSize int64
+}
+
+// DefaultDir returns the default directory for the answer history (~/.kube-ai/answers)
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube-ai", "answers"), nil
+}
+
+// NewStore returns the store in dir, rotated past maxSize bytes (DefaultMaxSize if 0)
+func NewStore(dir string, maxSize int64) *Store {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Store{dir: dir, maxSize: maxSize}
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add stores an entry, giving it an ID and time if it has none
+func (s *Store) Add(entry *Entry) error {
+	if entry.ID == "" {
+		id := make([]byte, 4)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("error generating history ID: %w", err)
+		}
+		entry.ID = hex.EncodeToString(id)
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding history entry: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+	path := filepath.Join(s.dir, currentFile)
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(data)) >= s.maxSize {
+		if err := os.Rename(path, filepath.Join(s.dir, rotatedFile)); err != nil {
+			return fmt.Errorf("error rotating history: %w", err)
+		}
+	}
+
+	// Prompts and answers may describe the cluster, so only the user can read them
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening history: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing history: %w", err)
+	}
+	return file.Close()
+}
+
+// List returns the stored entries, newest first
+func (s *Store) List() ([]Entry, error) {
+	var entries []Entry
+	for _, name := range []string{rotatedFile, currentFile} {
+		read, err := readEntries(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, read...)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// Get returns the entry whose ID is or starts with id, failing when none or several match
+func (s *Store) Get(id string) (*Entry, error) {
+	if id == "" {
+		return nil, fmt.Errorf("no history ID given")
+	}
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Entry
+	for i := range entries {
+		if !strings.HasPrefix(entries[i].ID, id) {
+			continue
+		}
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+		if found != nil {
+			return nil, fmt.Errorf("history ID %q is ambiguous", id)
+		}
+		found = &entries[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no history entry %q", id)
+	}
+	return found, nil
+}
+
+// Clear deletes all stored entries
+func (s *Store) Clear() error {
+	for _, name := range []string{currentFile, rotatedFile} {
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error clearing history: %w", err)
+		}
+	}
+	return nil
+}
+
+// readEntries reads the entries of a history file in the order they were added, skipping lines
+// that cannot be parsed, such as one cut short by a crash
+func readEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), DefaultMaxSize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history %s: %w", path, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,43 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStoreRotation(t *testing.T) {
+	store := NewStore(t.TempDir(), 1024)
+	for i := 0; i < 20; i++ {
+		entry := &Entry{Command: "explain", Args: []string{"explain", fmt.Sprint(i)}, Answer: strings.Repeat("x", 100)}
+		if err := store.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || len(entries) >= 20 {
+		t.Fatalf("expected rotation to drop old entries, got %d", len(entries))
+	}
+	if got := entries[0].Args[1]; got != "19" {
+		t.Errorf("newest entry is %s, want 19", got)
+	}
+
+	found, err := store.Get(entries[1].ID[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != entries[1].ID {
+		t.Errorf("Get returned %s, want %s", found.ID, entries[1].ID)
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := store.List(); len(entries) != 0 {
+		t.Errorf("expected no entries after Clear, got %d", len(entries))
+	}
+}
@@ -0,0 +1,656 @@
+package i18n
+
+import (
+	"strings"
+	"sync"
+)
+
+// languageNames maps supported language codes to their English names, used when instructing the AI
+var languageNames = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"it": "Italian",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"ko": "Korean",
+}
+
+// catalogs holds translations of the CLI's own output, keyed by the English text
+var catalogs = map[string]map[string]string{
+	"es": {
+		"LOG ENTRIES":                 "ENTRADAS DE LOG",
+		"LOG SUMMARY":                 "RESUMEN DE LOGS",
+		"AI ANALYSIS":                 "ANÁLISIS DE IA",
+		"CLUSTER COMPARISON":          "COMPARACIÓN DE CLÚSTERES",
+		"TRANSCRIPT":                  "TRANSCRIPCIÓN",
+		"ANSWER":                      "RESPUESTA",
+		"Error Hotspots":              "Focos de errores",
+		"Summary":                     "Resumen",
+		"Root Causes":                 "Causas raíz",
+		"Recommended Solutions":       "Soluciones recomendadas",
+		"Additional Information":      "Información adicional",
+		"Severity":                    "Severidad",
+		"Total Entries":               "Entradas totales",
+		"Time Range":                  "Rango de tiempo",
+		"errors":                      "errores",
+		"warnings":                    "advertencias",
+		"CHANGES FROM BASELINE":       "CAMBIOS RESPECTO A LA LÍNEA BASE",
+		"New Patterns":                "Patrones nuevos",
+		"Rare Patterns":               "Patrones poco frecuentes",
+		"Rate Changes":                "Cambios de frecuencia",
+		"LIFECYCLE EVENTS":            "EVENTOS DEL CICLO DE VIDA",
+		"confidence":                  "confianza",
+		"not found in collected logs": "no encontrado en los logs recopilados",
+		"FOLLOW-UP QUESTIONS":         "PREGUNTAS DE SEGUIMIENTO",
+		"ANALYSIS COMPARISON":         "COMPARACIÓN DE ANÁLISIS",
+		"Error rate":                  "Tasa de errores",
+		"Warning rate":                "Tasa de advertencias",
+		"Resolved Root Causes":        "Causas raíz resueltas",
+		"Persisting Root Causes":      "Causas raíz persistentes",
+		"New Root Causes":             "Causas raíz nuevas",
+		"Assessment":                  "Evaluación",
+		"GENERATED MANIFEST":          "MANIFIESTO GENERADO",
+		"PLANNED CHANGES":             "CAMBIOS PREVISTOS",
+		"Validation":                  "Validación",
+		"FIELD SCHEMA":                "ESQUEMA DEL CAMPO",
+		"EXPLANATION":                 "EXPLICACIÓN",
+		"UPGRADE CHECK":               "COMPROBACIÓN DE ACTUALIZACIÓN",
+		"Removed":                     "Eliminado",
+		"Deprecated":                  "Obsoleto",
+		"MIGRATION PLAN":              "PLAN DE MIGRACIÓN",
+		"CAPACITY":                    "CAPACIDAD",
+		"Node Pools":                  "Grupos de nodos",
+		"Pending Pods":                "Pods pendientes",
+		"Largest Workloads":           "Cargas de trabajo más grandes",
+		"Autoscaler Events":           "Eventos del autoescalador",
+		"RECOMMENDATIONS":             "RECOMENDACIONES",
+		"ROLLOUT PROFILE":             "PERFIL DE DESPLIEGUE",
+		"Risks":                       "Riesgos",
+		"KUBECTL COMMANDS":            "COMANDOS DE KUBECTL",
+		"Notes":                       "Notas",
+		"Namespaces":                  "Espacios de nombres",
+		"Values Changes":              "Valores modificados",
+		"Rendered Changes":            "Cambios renderizados",
+		"RISK ASSESSMENT":             "EVALUACIÓN DE RIESGOS",
+		"IMAGES":                      "IMÁGENES",
+		"PATCHING PLAN":               "PLAN DE PARCHEO",
+		"BENCHMARK":                   "BENCHMARK",
+		"REMEDIATION":                 "REMEDIACIÓN",
+		"SECRET HYGIENE":              "HIGIENE DE SECRETOS",
+		"Long-Lived Tokens":           "Tokens de larga duración",
+		"Unneeded Token Mounts":       "Montajes de tokens innecesarios",
+		"Broadly Mounted Secrets":     "Secretos montados ampliamente",
+		"Registry Credentials":        "Credenciales de registro",
+		"Unused Secrets":              "Secretos sin usar",
+		"CLEANUP PLAN":                "PLAN DE LIMPIEZA",
+		"PROMPT PREVIEW":              "VISTA PREVIA DEL PROMPT",
+		"MODEL CONSENSUS":             "CONSENSO DE MODELOS",
+		"Agreements":                  "Coincidencias",
+		"Disagreements":               "Discrepancias",
+		"ARGO CD APPLICATION":         "APLICACIÓN DE ARGO CD",
+		"Resources":                   "Recursos",
+		"Drift":                       "Desviaciones",
+		"FLUX OBJECTS":                "OBJETOS DE FLUX",
+		"MESH CONFIGURATION":          "CONFIGURACIÓN DE MALLA",
+		"Sidecar Logs":                "Logs del sidecar",
+		"CERTIFICATE":                 "CERTIFICADO",
+		"CLUSTER DNS":                 "DNS DEL CLÚSTER",
+		"Errors":                      "Errores",
+		"Lookups":                     "Resoluciones",
+		"JOB RUNS":                    "EJECUCIONES DEL JOB",
+		"GPUS":                        "GPU",
+		"GPU Pods":                    "Pods con GPU",
+		"STATEFULSET":                 "STATEFULSET",
+		"NODE AUTOSCALING":            "AUTOESCALADO DE NODOS",
+		"Scaling Events":              "Eventos de escalado",
+		"Resource Usage":              "Uso de recursos",
+		"TIMELINE":                    "CRONOLOGÍA",
+		"REDACTED TEXT":               "TEXTO REDACTADO",
+		"Redactions":                  "Redacciones",
+		"LAST 7 DAYS":                 "ÚLTIMOS 7 DÍAS",
+		"NAMESPACE SUMMARY":           "RESUMEN DEL NAMESPACE",
+		"Recent Warnings":             "Advertencias recientes",
+	},
+	"fr": {
+		"LOG ENTRIES":                 "ENTRÉES DE LOG",
+		"LOG SUMMARY":                 "RÉSUMÉ DES LOGS",
+		"AI ANALYSIS":                 "ANALYSE IA",
+		"CLUSTER COMPARISON":          "COMPARAISON DES CLUSTERS",
+		"TRANSCRIPT":                  "TRANSCRIPTION",
+		"ANSWER":                      "RÉPONSE",
+		"Error Hotspots":              "Points chauds d'erreurs",
+		"Summary":                     "Résumé",
+		"Root Causes":                 "Causes principales",
+		"Recommended Solutions":       "Solutions recommandées",
+		"Additional Information":      "Informations complémentaires",
+		"Severity":                    "Gravité",
+		"Total Entries":               "Entrées totales",
+		"Time Range":                  "Période",
+		"errors":                      "erreurs",
+		"warnings":                    "avertissements",
+		"CHANGES FROM BASELINE":       "CHANGEMENTS PAR RAPPORT À LA RÉFÉRENCE",
+		"New Patterns":                "Nouveaux motifs",
+		"Rare Patterns":               "Motifs rares",
+		"Rate Changes":                "Variations de fréquence",
+		"LIFECYCLE EVENTS":            "ÉVÉNEMENTS DU CYCLE DE VIE",
+		"confidence":                  "confiance",
+		"not found in collected logs": "introuvable dans les logs collectés",
+		"FOLLOW-UP QUESTIONS":         "QUESTIONS DE SUIVI",
+		"ANALYSIS COMPARISON":         "COMPARAISON DES ANALYSES",
+		"Error rate":                  "Taux d'erreurs",
+		"Warning rate":                "Taux d'avertissements",
+		"Resolved Root Causes":        "Causes principales résolues",
+		"Persisting Root Causes":      "Causes principales persistantes",
+		"New Root Causes":             "Nouvelles causes principales",
+		"Assessment":                  "Évaluation",
+		"GENERATED MANIFEST":          "MANIFESTE GÉNÉRÉ",
+		"PLANNED CHANGES":             "MODIFICATIONS PRÉVUES",
+		"Validation":                  "Validation",
+		"FIELD SCHEMA":                "SCHÉMA DU CHAMP",
+		"EXPLANATION":                 "EXPLICATION",
+		"UPGRADE CHECK":               "VÉRIFICATION DE MISE À NIVEAU",
+		"Removed":                     "Supprimé",
+		"Deprecated":                  "Obsolète",
+		"MIGRATION PLAN":              "PLAN DE MIGRATION",
+		"CAPACITY":                    "CAPACITÉ",
+		"Node Pools":                  "Pools de nœuds",
+		"Pending Pods":                "Pods en attente",
+		"Largest Workloads":           "Charges de travail les plus grandes",
+		"Autoscaler Events":           "Événements de l'autoscaler",
+		"RECOMMENDATIONS":             "RECOMMANDATIONS",
+		"ROLLOUT PROFILE":             "PROFIL DE DÉPLOIEMENT",
+		"Risks":                       "Risques",
+		"KUBECTL COMMANDS":            "COMMANDES KUBECTL",
+		"Notes":                       "Remarques",
+		"Namespaces":                  "Espaces de noms",
+		"Values Changes":              "Valeurs modifiées",
+		"Rendered Changes":            "Modifications rendues",
+		"RISK ASSESSMENT":             "ÉVALUATION DES RISQUES",
+		"IMAGES":                      "IMAGES",
+		"PATCHING PLAN":               "PLAN DE CORRECTIFS",
+		"BENCHMARK":                   "BENCHMARK",
+		"REMEDIATION":                 "REMÉDIATION",
+		"SECRET HYGIENE":              "HYGIÈNE DES SECRETS",
+		"Long-Lived Tokens":           "Jetons de longue durée",
+		"Unneeded Token Mounts":       "Montages de jetons inutiles",
+		"Broadly Mounted Secrets":     "Secrets largement montés",
+		"Registry Credentials":        "Identifiants de registre",
+		"Unused Secrets":              "Secrets inutilisés",
+		"CLEANUP PLAN":                "PLAN DE NETTOYAGE",
+		"PROMPT PREVIEW":              "APERÇU DU PROMPT",
+		"MODEL CONSENSUS":             "CONSENSUS DES MODÈLES",
+		"Agreements":                  "Points d'accord",
+		"Disagreements":               "Points de désaccord",
+		"ARGO CD APPLICATION":         "APPLICATION ARGO CD",
+		"Resources":                   "Ressources",
+		"Drift":                       "Dérives",
+		"FLUX OBJECTS":                "OBJETS FLUX",
+		"MESH CONFIGURATION":          "CONFIGURATION DU MAILLAGE",
+		"Sidecar Logs":                "Journaux du sidecar",
+		"CERTIFICATE":                 "CERTIFICAT",
+		"CLUSTER DNS":                 "DNS DU CLUSTER",
+		"Errors":                      "Erreurs",
+		"Lookups":                     "Résolutions",
+		"JOB RUNS":                    "EXÉCUTIONS DU JOB",
+		"GPUS":                        "GPU",
+		"GPU Pods":                    "Pods GPU",
+		"STATEFULSET":                 "STATEFULSET",
+		"NODE AUTOSCALING":            "AUTOSCALING DES NŒUDS",
+		"Scaling Events":              "Événements de scaling",
+		"Resource Usage":              "Utilisation des ressources",
+		"TIMELINE":                    "CHRONOLOGIE",
+		"REDACTED TEXT":               "TEXTE MASQUÉ",
+		"Redactions":                  "Masquages",
+		"LAST 7 DAYS":                 "7 DERNIERS JOURS",
+		"NAMESPACE SUMMARY":           "RÉSUMÉ DU NAMESPACE",
+		"Recent Warnings":             "Avertissements récents",
+	},
+	"de": {
+		"LOG ENTRIES":                 "LOG-EINTRÄGE",
+		"LOG SUMMARY":                 "LOG-ZUSAMMENFASSUNG",
+		"AI ANALYSIS":                 "KI-ANALYSE",
+		"CLUSTER COMPARISON":          "CLUSTER-VERGLEICH",
+		"TRANSCRIPT":                  "PROTOKOLL",
+		"ANSWER":                      "ANTWORT",
+		"Error Hotspots":              "Fehler-Hotspots",
+		"Summary":                     "Zusammenfassung",
+		"Root Causes":                 "Ursachen",
+		"Recommended Solutions":       "Empfohlene Lösungen",
+		"Additional Information":      "Weitere Informationen",
+		"Severity":                    "Schweregrad",
+		"Total Entries":               "Einträge gesamt",
+		"Time Range":                  "Zeitraum",
+		"errors":                      "Fehler",
+		"warnings":                    "Warnungen",
+		"CHANGES FROM BASELINE":       "ÄNDERUNGEN GEGENÜBER DER BASELINE",
+		"New Patterns":                "Neue Muster",
+		"Rare Patterns":               "Seltene Muster",
+		"Rate Changes":                "Häufigkeitsänderungen",
+		"LIFECYCLE EVENTS":            "LEBENSZYKLUS-EREIGNISSE",
+		"confidence":                  "Konfidenz",
+		"not found in collected logs": "nicht in den gesammelten Logs gefunden",
+		"FOLLOW-UP QUESTIONS":         "RÜCKFRAGEN",
+		"ANALYSIS COMPARISON":         "ANALYSEVERGLEICH",
+		"Error rate":                  "Fehlerrate",
+		"Warning rate":                "Warnungsrate",
+		"Resolved Root Causes":        "Behobene Ursachen",
+		"Persisting Root Causes":      "Fortbestehende Ursachen",
+		"New Root Causes":             "Neue Ursachen",
+		"Assessment":                  "Bewertung",
+		"GENERATED MANIFEST":          "GENERIERTES MANIFEST",
+		"PLANNED CHANGES":             "GEPLANTE ÄNDERUNGEN",
+		"Validation":                  "Validierung",
+		"FIELD SCHEMA":                "FELDSCHEMA",
+		"EXPLANATION":                 "ERKLÄRUNG",
+		"UPGRADE CHECK":               "UPGRADE-PRÜFUNG",
+		"Removed":                     "Entfernt",
+		"Deprecated":                  "Veraltet",
+		"MIGRATION PLAN":              "MIGRATIONSPLAN",
+		"CAPACITY":                    "KAPAZITÄT",
+		"Node Pools":                  "Node-Pools",
+		"Pending Pods":                "Ausstehende Pods",
+		"Largest Workloads":           "Größte Workloads",
+		"Autoscaler Events":           "Autoscaler-Ereignisse",
+		"RECOMMENDATIONS":             "EMPFEHLUNGEN",
+		"ROLLOUT PROFILE":             "ROLLOUT-PROFIL",
+		"Risks":                       "Risiken",
+		"KUBECTL COMMANDS":            "KUBECTL-BEFEHLE",
+		"Notes":                       "Hinweise",
+		"Namespaces":                  "Namespaces",
+		"Values Changes":              "Geänderte Werte",
+		"Rendered Changes":            "Gerenderte Änderungen",
+		"RISK ASSESSMENT":             "RISIKOBEWERTUNG",
+		"IMAGES":                      "IMAGES",
+		"PATCHING PLAN":               "PATCH-PLAN",
+		"BENCHMARK":                   "BENCHMARK",
+		"REMEDIATION":                 "BEHEBUNG",
+		"SECRET HYGIENE":              "SECRET-HYGIENE",
+		"Long-Lived Tokens":           "Langlebige Tokens",
+		"Unneeded Token Mounts":       "Unnötig eingebundene Tokens",
+		"Broadly Mounted Secrets":     "Breit eingebundene Secrets",
+		"Registry Credentials":        "Registry-Zugangsdaten",
+		"Unused Secrets":              "Unbenutzte Secrets",
+		"CLEANUP PLAN":                "BEREINIGUNGSPLAN",
+		"PROMPT PREVIEW":              "PROMPT-VORSCHAU",
+		"MODEL CONSENSUS":             "MODELL-KONSENS",
+		"Agreements":                  "Übereinstimmungen",
+		"Disagreements":               "Abweichungen",
+		"ARGO CD APPLICATION":         "ARGO-CD-ANWENDUNG",
+		"Resources":                   "Ressourcen",
+		"Drift":                       "Drift",
+		"FLUX OBJECTS":                "FLUX-OBJEKTE",
+		"MESH CONFIGURATION":          "MESH-KONFIGURATION",
+		"Sidecar Logs":                "Sidecar-Logs",
+		"CERTIFICATE":                 "ZERTIFIKAT",
+		"CLUSTER DNS":                 "CLUSTER-DNS",
+		"Errors":                      "Fehler",
+		"Lookups":                     "Abfragen",
+		"JOB RUNS":                    "JOB-AUSFÜHRUNGEN",
+		"GPUS":                        "GPUS",
+		"GPU Pods":                    "GPU-Pods",
+		"STATEFULSET":                 "STATEFULSET",
+		"NODE AUTOSCALING":            "KNOTEN-AUTOSCALING",
+		"Scaling Events":              "Skalierungsereignisse",
+		"Resource Usage":              "Ressourcennutzung",
+		"TIMELINE":                    "ZEITLEISTE",
+		"REDACTED TEXT":               "GESCHWÄRZTER TEXT",
+		"Redactions":                  "Schwärzungen",
+		"LAST 7 DAYS":                 "LETZTE 7 TAGE",
+		"NAMESPACE SUMMARY":           "NAMESPACE-ÜBERSICHT",
+		"Recent Warnings":             "Aktuelle Warnungen",
+	},
+	"pt": {
+		"LOG ENTRIES":                 "ENTRADAS DE LOG",
+		"LOG SUMMARY":                 "RESUMO DOS LOGS",
+		"AI ANALYSIS":                 "ANÁLISE DE IA",
+		"CLUSTER COMPARISON":          "COMPARAÇÃO DE CLUSTERS",
+		"TRANSCRIPT":                  "TRANSCRIÇÃO",
+		"ANSWER":                      "RESPOSTA",
+		"Error Hotspots":              "Pontos críticos de erros",
+		"Summary":                     "Resumo",
+		"Root Causes":                 "Causas raiz",
+		"Recommended Solutions":       "Soluções recomendadas",
+		"Additional Information":      "Informações adicionais",
+		"Severity":                    "Severidade",
+		"Total Entries":               "Total de entradas",
+		"Time Range":                  "Intervalo de tempo",
+		"errors":                      "erros",
+		"warnings":                    "avisos",
+		"CHANGES FROM BASELINE":       "MUDANÇAS EM RELAÇÃO À LINHA DE BASE",
+		"New Patterns":                "Novos padrões",
+		"Rare Patterns":               "Padrões raros",
+		"Rate Changes":                "Mudanças de frequência",
+		"LIFECYCLE EVENTS":            "EVENTOS DO CICLO DE VIDA",
+		"confidence":                  "confiança",
+		"not found in collected logs": "não encontrado nos logs coletados",
+		"FOLLOW-UP QUESTIONS":         "PERGUNTAS DE ACOMPANHAMENTO",
+		"ANALYSIS COMPARISON":         "COMPARAÇÃO DE ANÁLISES",
+		"Error rate":                  "Taxa de erros",
+		"Warning rate":                "Taxa de avisos",
+		"Resolved Root Causes":        "Causas raiz resolvidas",
+		"Persisting Root Causes":      "Causas raiz persistentes",
+		"New Root Causes":             "Novas causas raiz",
+		"Assessment":                  "Avaliação",
+		"GENERATED MANIFEST":          "MANIFESTO GERADO",
+		"PLANNED CHANGES":             "ALTERAÇÕES PREVISTAS",
+		"Validation":                  "Validação",
+		"FIELD SCHEMA":                "ESQUEMA DO CAMPO",
+		"EXPLANATION":                 "EXPLICAÇÃO",
+		"UPGRADE CHECK":               "VERIFICAÇÃO DE ATUALIZAÇÃO",
+		"Removed":                     "Removido",
+		"Deprecated":                  "Obsoleto",
+		"MIGRATION PLAN":              "PLANO DE MIGRAÇÃO",
+		"CAPACITY":                    "CAPACIDADE",
+		"Node Pools":                  "Pools de nós",
+		"Pending Pods":                "Pods pendentes",
+		"Largest Workloads":           "Maiores cargas de trabalho",
+		"Autoscaler Events":           "Eventos do autoescalador",
+		"RECOMMENDATIONS":             "RECOMENDAÇÕES",
+		"ROLLOUT PROFILE":             "PERFIL DE IMPLANTAÇÃO",
+		"Risks":                       "Riscos",
+		"KUBECTL COMMANDS":            "COMANDOS DO KUBECTL",
+		"Notes":                       "Notas",
+		"Namespaces":                  "Namespaces",
+		"Values Changes":              "Valores alterados",
+		"Rendered Changes":            "Alterações renderizadas",
+		"RISK ASSESSMENT":             "AVALIAÇÃO DE RISCOS",
+		"IMAGES":                      "IMAGENS",
+		"PATCHING PLAN":               "PLANO DE CORREÇÕES",
+		"BENCHMARK":                   "BENCHMARK",
+		"REMEDIATION":                 "REMEDIAÇÃO",
+		"SECRET HYGIENE":              "HIGIENE DE SECRETS",
+		"Long-Lived Tokens":           "Tokens de longa duração",
+		"Unneeded Token Mounts":       "Montagens de tokens desnecessárias",
+		"Broadly Mounted Secrets":     "Secrets montados amplamente",
+		"Registry Credentials":        "Credenciais de registro",
+		"Unused Secrets":              "Secrets não utilizados",
+		"CLEANUP PLAN":                "PLANO DE LIMPEZA",
+		"PROMPT PREVIEW":              "PRÉVIA DO PROMPT",
+		"MODEL CONSENSUS":             "CONSENSO DOS MODELOS",
+		"Agreements":                  "Concordâncias",
+		"Disagreements":               "Divergências",
+		"ARGO CD APPLICATION":         "APLICAÇÃO DO ARGO CD",
+		"Resources":                   "Recursos",
+		"Drift":                       "Desvios",
+		"FLUX OBJECTS":                "OBJETOS DO FLUX",
+		"MESH CONFIGURATION":          "CONFIGURAÇÃO DA MALHA",
+		"Sidecar Logs":                "Logs do sidecar",
+		"CERTIFICATE":                 "CERTIFICADO",
+		"CLUSTER DNS":                 "DNS DO CLUSTER",
+		"Errors":                      "Erros",
+		"Lookups":                     "Resoluções",
+		"JOB RUNS":                    "EXECUÇÕES DO JOB",
+		"GPUS":                        "GPUS",
+		"GPU Pods":                    "Pods com GPU",
+		"STATEFULSET":                 "STATEFULSET",
+		"NODE AUTOSCALING":            "AUTOESCALONAMENTO DE NÓS",
+		"Scaling Events":              "Eventos de escalonamento",
+		"Resource Usage":              "Uso de recursos",
+		"TIMELINE":                    "LINHA DO TEMPO",
+		"REDACTED TEXT":               "TEXTO OCULTADO",
+		"Redactions":                  "Ocultações",
+		"LAST 7 DAYS":                 "ÚLTIMOS 7 DIAS",
+		"NAMESPACE SUMMARY":           "RESUMO DO NAMESPACE",
+		"Recent Warnings":             "Avisos recentes",
+	},
+	"ja": {
+		"LOG ENTRIES":                 "ログエントリ",
+		"LOG SUMMARY":                 "ログの概要",
+		"AI ANALYSIS":                 "AI 分析",
+		"CLUSTER COMPARISON":          "クラスター比較",
+		"TRANSCRIPT":                  "実行記録",
+		"ANSWER":                      "回答",
+		"Error Hotspots":              "エラーの多い箇所",
+		"Summary":                     "概要",
+		"Root Causes":                 "根本原因",
+		"Recommended Solutions":       "推奨される解決策",
+		"Additional Information":      "補足情報",
+		"Severity":                    "重大度",
+		"Total Entries":               "総エントリ数",
+		"Time Range":                  "期間",
+		"errors":                      "エラー",
+		"warnings":                    "警告",
+		"CHANGES FROM BASELINE":       "ベースラインからの変化",
+		"New Patterns":                "新しいパターン",
+		"Rare Patterns":               "まれなパターン",
+		"Rate Changes":                "頻度の変化",
+		"LIFECYCLE EVENTS":            "ライフサイクルイベント",
+		"confidence":                  "確信度",
+		"not found in collected logs": "収集したログに見つかりません",
+		"FOLLOW-UP QUESTIONS":         "フォローアップの質問",
+		"ANALYSIS COMPARISON":         "分析の比較",
+		"Error rate":                  "エラー率",
+		"Warning rate":                "警告率",
+		"Resolved Root Causes":        "解消した根本原因",
+		"Persisting Root Causes":      "継続している根本原因",
+		"New Root Causes":             "新しい根本原因",
+		"Assessment":                  "評価",
+		"GENERATED MANIFEST":          "生成されたマニフェスト",
+		"PLANNED CHANGES":             "予定される変更",
+		"Validation":                  "検証",
+		"FIELD SCHEMA":                "フィールドスキーマ",
+		"EXPLANATION":                 "説明",
+		"UPGRADE CHECK":               "アップグレードチェック",
+		"Removed":                     "削除済み",
+		"Deprecated":                  "非推奨",
+		"MIGRATION PLAN":              "移行計画",
+		"CAPACITY":                    "キャパシティ",
+		"Node Pools":                  "ノードプール",
+		"Pending Pods":                "保留中の Pod",
+		"Largest Workloads":           "最大のワークロード",
+		"Autoscaler Events":           "オートスケーラーのイベント",
+		"RECOMMENDATIONS":             "推奨事項",
+		"ROLLOUT PROFILE":             "ロールアウトプロファイル",
+		"Risks":                       "リスク",
+		"KUBECTL COMMANDS":            "KUBECTL コマンド",
+		"Notes":                       "注意事項",
+		"Namespaces":                  "名前空間",
+		"Values Changes":              "値の変更",
+		"Rendered Changes":            "レンダリング結果の変更",
+		"RISK ASSESSMENT":             "リスク評価",
+		"IMAGES":                      "イメージ",
+		"PATCHING PLAN":               "パッチ適用計画",
+		"BENCHMARK":                   "ベンチマーク",
+		"REMEDIATION":                 "修正方法",
+		"SECRET HYGIENE":              "シークレットの衛生状態",
+		"Long-Lived Tokens":           "長期間有効なトークン",
+		"Unneeded Token Mounts":       "不要なトークンのマウント",
+		"Broadly Mounted Secrets":     "広くマウントされたシークレット",
+		"Registry Credentials":        "レジストリ認証情報",
+		"Unused Secrets":              "未使用のシークレット",
+		"CLEANUP PLAN":                "クリーンアップ計画",
+		"PROMPT PREVIEW":              "プロンプトのプレビュー",
+		"MODEL CONSENSUS":             "モデル間の合意",
+		"Agreements":                  "一致点",
+		"Disagreements":               "相違点",
+		"ARGO CD APPLICATION":         "ARGO CD アプリケーション",
+		"Resources":                   "リソース",
+		"Drift":                       "ドリフト",
+		"FLUX OBJECTS":                "FLUX オブジェクト",
+		"MESH CONFIGURATION":          "メッシュ構成",
+		"Sidecar Logs":                "サイドカーログ",
+		"CERTIFICATE":                 "証明書",
+		"CLUSTER DNS":                 "クラスター DNS",
+		"Errors":                      "エラー",
+		"Lookups":                     "名前解決",
+		"JOB RUNS":                    "ジョブの実行",
+		"GPUS":                        "GPU",
+		"GPU Pods":                    "GPU Pod",
+		"STATEFULSET":                 "StatefulSet",
+		"NODE AUTOSCALING":            "ノードのオートスケーリング",
+		"Scaling Events":              "スケーリングイベント",
+		"Resource Usage":              "リソース使用量",
+		"TIMELINE":                    "タイムライン",
+		"REDACTED TEXT":               "マスク済みテキスト",
+		"Redactions":                  "マスクされた値",
+		"LAST 7 DAYS":                 "過去7日間",
+		"NAMESPACE SUMMARY":           "ネームスペースの概要",
+		"Recent Warnings":             "最近の警告",
+	},
+	"zh": {
+		"LOG ENTRIES":                 "日志条目",
+		"LOG SUMMARY":                 "日志摘要",
+		"AI ANALYSIS":                 "AI 分析",
+		"CLUSTER COMPARISON":          "集群对比",
+		"TRANSCRIPT":                  "执行记录",
+		"ANSWER":                      "回答",
+		"Error Hotspots":              "错误热点",
+		"Summary":                     "摘要",
+		"Root Causes":                 "根本原因",
+		"Recommended Solutions":       "建议的解决方案",
+		"Additional Information":      "附加信息",
+		"Severity":                    "严重程度",
+		"Total Entries":               "条目总数",
+		"Time Range":                  "时间范围",
+		"errors":                      "错误",
+		"warnings":                    "警告",
+		"CHANGES FROM BASELINE":       "与基线相比的变化",
+		"New Patterns":                "新模式",
+		"Rare Patterns":               "罕见模式",
+		"Rate Changes":                "频率变化",
+		"LIFECYCLE EVENTS":            "生命周期事件",
+		"confidence":                  "置信度",
+		"not found in collected logs": "在收集的日志中未找到",
+		"FOLLOW-UP QUESTIONS":         "后续问题",
+		"ANALYSIS COMPARISON":         "分析对比",
+		"Error rate":                  "错误率",
+		"Warning rate":                "警告率",
+		"Resolved Root Causes":        "已解决的根本原因",
+		"Persisting Root Causes":      "持续存在的根本原因",
+		"New Root Causes":             "新的根本原因",
+		"Assessment":                  "评估",
+		"GENERATED MANIFEST":          "生成的清单",
+		"PLANNED CHANGES":             "计划的变更",
+		"Validation":                  "验证",
+		"FIELD SCHEMA":                "字段架构",
+		"EXPLANATION":                 "解释",
+		"UPGRADE CHECK":               "升级检查",
+		"Removed":                     "已移除",
+		"Deprecated":                  "已弃用",
+		"MIGRATION PLAN":              "迁移计划",
+		"CAPACITY":                    "容量",
+		"Node Pools":                  "节点池",
+		"Pending Pods":                "待调度的 Pod",
+		"Largest Workloads":           "最大的工作负载",
+		"Autoscaler Events":           "自动扩缩器事件",
+		"RECOMMENDATIONS":             "建议",
+		"ROLLOUT PROFILE":             "发布概况",
+		"Risks":                       "风险",
+		"KUBECTL COMMANDS":            "KUBECTL 命令",
+		"Notes":                       "说明",
+		"Namespaces":                  "命名空间",
+		"Values Changes":              "值的变更",
+		"Rendered Changes":            "渲染结果的变更",
+		"RISK ASSESSMENT":             "风险评估",
+		"IMAGES":                      "镜像",
+		"PATCHING PLAN":               "补丁计划",
+		"BENCHMARK":                   "基准检查",
+		"REMEDIATION":                 "修复建议",
+		"SECRET HYGIENE":              "密钥卫生",
+		"Long-Lived Tokens":           "长期令牌",
+		"Unneeded Token Mounts":       "不必要的令牌挂载",
+		"Broadly Mounted Secrets":     "广泛挂载的密钥",
+		"Registry Credentials":        "镜像仓库凭据",
+		"Unused Secrets":              "未使用的密钥",
+		"CLEANUP PLAN":                "清理计划",
+		"PROMPT PREVIEW":              "提示词预览",
+		"MODEL CONSENSUS":             "模型共识",
+		"Agreements":                  "一致结论",
+		"Disagreements":               "分歧",
+		"ARGO CD APPLICATION":         "ARGO CD 应用",
+		"Resources":                   "资源",
+		"Drift":                       "漂移",
+		"FLUX OBJECTS":                "FLUX 对象",
+		"MESH CONFIGURATION":          "网格配置",
+		"Sidecar Logs":                "Sidecar 日志",
+		"CERTIFICATE":                 "证书",
+		"CLUSTER DNS":                 "集群 DNS",
+		"Errors":                      "错误",
+		"Lookups":                     "解析",
+		"JOB RUNS":                    "作业运行",
+		"GPUS":                        "GPU",
+		"GPU Pods":                    "GPU Pod",
+		"STATEFULSET":                 "StatefulSet",
+		"NODE AUTOSCALING":            "节点自动扩缩容",
+		"Scaling Events":              "扩缩容事件",
+		"Resource Usage":              "资源使用情况",
+		"TIMELINE":                    "时间线",
+		"REDACTED TEXT":               "脱敏后的文本",
+		"Redactions":                  "脱敏内容",
+		"LAST 7 DAYS":                 "最近 7 天",
+		"NAMESPACE SUMMARY":           "命名空间概览",
+		"Recent Warnings":             "最近的警告",
+	},
+}
+
+var (
+	mu       sync.RWMutex
+	language = "en"
+)
+
+// Normalize converts a language code or locale (e.g. "es_ES.UTF-8", "pt-BR", "German") to a short code.
+// Unknown languages are returned unchanged so the AI can still be asked to use them.
+func Normalize(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return "en"
+	}
+
+	code := strings.ToLower(lang)
+	if i := strings.IndexAny(code, "_-."); i > 0 {
+		code = code[:i]
+	}
+	if _, ok := languageNames[code]; ok {
+		return code
+	}
+
+	// Accept English language names as well
+	for c, name := range languageNames {
+		if strings.EqualFold(name, lang) {
+			return c
+		}
+	}
+
+	return lang
+}
+
+// SetLanguage sets the language used for CLI output
+func SetLanguage(lang string) {
+	mu.Lock()
+	defer mu.Unlock()
+	language = Normalize(lang)
+}
+
+// Language returns the current language code
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return language
+}
+
+// IsEnglish reports whether a language is English (the default)
+func IsEnglish(lang string) bool {
+	return Normalize(lang) == "en"
+}
+
+// LanguageName returns a human-readable name for a language, suitable for an AI instruction
+func LanguageName(lang string) string {
+	code := Normalize(lang)
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// T translates a piece of CLI output into the current language, falling back to English
+func T(text string) string {
+	if catalog, ok := catalogs[Language()]; ok {
+		if translated, ok := catalog[text]; ok {
+			return translated
+		}
+	}
+	return text
+}
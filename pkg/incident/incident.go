@@ -0,0 +1,93 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxNoteLength bounds the notes added to incidents, which incident management services limit
+// in size; longer notes are cut
+const MaxNoteLength = 25000
+
+// Incident is an incident or alert raised in an incident management service
+type Incident struct {
+	// Service the incident comes from: pagerduty or opsgenie
+	Service string `json:"service"`
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	// Link to the incident in the service's web UI, if known
+	URL string `json:"url,omitempty"`
+	// Description of the alert, if any
+	Description string `json:"description,omitempty"`
+	// Alert payloads as sent by the monitoring system: details, custom fields and tags
+	Payloads []interface{} `json:"-"`
+}
+
+// Client reads incidents from an incident management service and adds notes to them
+type Client interface {
+	// Get reads an incident and the payloads of its alerts
+	Get(ctx context.Context, id string) (*Incident, error)
+	// AddNote adds a note to an incident, cutting it to MaxNoteLength
+	AddNote(ctx context.Context, id, note string) error
+}
+
+// Alert describes the incident for a prompt: its title, description and labels
+func (i *Incident) Alert() string {
+	var b strings.Builder
+	b.WriteString(i.Title)
+	if i.Description != "" && i.Description != i.Title {
+		b.WriteString("\n")
+		b.WriteString(truncate(i.Description, 1000))
+	}
+	labels := i.labels()
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels))
+		for _, label := range labels {
+			pairs = append(pairs, label.key+"="+label.value)
+			if len(pairs) == 30 {
+				break
+			}
+		}
+		b.WriteString("\nLabels: ")
+		b.WriteString(strings.Join(pairs, ", "))
+	}
+	return b.String()
+}
+
+// truncate cuts text to at most n bytes, on a line or word boundary when possible
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	const marker = "\n[... cut ...]"
+	cut := text[:n-len(marker)]
+	if i := strings.LastIndexAny(cut, "\n "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.ToValidUTF8(cut, "") + marker
+}
+
+// doJSON sends a request with a JSON body, if any, and decodes the JSON response into v, if not nil
+func doJSON(client *http.Client, req *http.Request, service string, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", service, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding %s response: %w", service, err)
+	}
+	return nil
+}
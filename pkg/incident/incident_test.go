@@ -0,0 +1,102 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWorkload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payloads []interface{}
+		want     Workload
+		wantErr  bool
+	}{
+		{
+			name: "Prometheus labels",
+			payloads: []interface{}{map[string]interface{}{
+				"details": map[string]interface{}{"namespace": "shop", "pod": "api-7d9f-x2x", "deployment": "api"},
+			}},
+			want: Workload{Namespace: "shop", Kind: "deployment", Name: "api"},
+		},
+		{
+			name: "OpenTelemetry attributes",
+			payloads: []interface{}{map[string]interface{}{
+				"k8s.namespace.name": "shop", "k8s.statefulset.name": "db", "k8s.container.name": "postgres",
+			}},
+			want: Workload{Namespace: "shop", Kind: "statefulset", Name: "db", Container: "postgres"},
+		},
+		{
+			name:     "labels in a description",
+			payloads: []interface{}{"Labels:\n - namespace = shop\n - pod = api-0\n", "severity:critical"},
+			want:     Workload{Namespace: "shop", Kind: "pod", Name: "api-0"},
+		},
+		{
+			name:     "replicaset resolved to its deployment",
+			payloads: []interface{}{map[string]string{"replicaset": "api-7d9f8c6b5"}},
+			want:     Workload{Kind: "deployment", Name: "api"},
+		},
+		{
+			name:     "no workload",
+			payloads: []interface{}{map[string]string{"host": "node-1"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident := &Incident{Service: "pagerduty", ID: "P1", Payloads: tt.payloads}
+			got, err := incident.Workload()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Workload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Workload() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpsgenie(t *testing.T) {
+	var note map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GenieKey key" || r.URL.Query().Get("identifierType") != "tiny" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v2/alerts/42":
+			w.Write([]byte(`{"data": {"id": "abc", "message": "High error rate", "details": {"namespace": "shop"}, "tags": ["deployment:api"]}}`))
+		case "POST /v2/alerts/42/notes":
+			json.NewDecoder(r.Body).Decode(&note)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpsgenie(server.URL, "key")
+	incident, err := client.Get(context.Background(), "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workload, err := incident.Workload()
+	if err != nil || workload != (Workload{Namespace: "shop", Kind: "deployment", Name: "api"}) {
+		t.Errorf("unexpected workload %+v: %v", workload, err)
+	}
+	if alert := incident.Alert(); !strings.Contains(alert, "High error rate") || !strings.Contains(alert, "namespace=shop") {
+		t.Errorf("unexpected alert:\n%s", alert)
+	}
+
+	if err := client.AddNote(context.Background(), "42", strings.Repeat("a ", MaxNoteLength)); err != nil {
+		t.Fatal(err)
+	}
+	if len(note["note"]) > MaxNoteLength || note["source"] != "kube-ai" {
+		t.Errorf("unexpected note of %d bytes from %q", len(note["note"]), note["source"])
+	}
+}
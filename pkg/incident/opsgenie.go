@@ -0,0 +1,108 @@
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Opsgenie reads alerts and adds notes through the Opsgenie Alert API
+type Opsgenie struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie client
+func NewOpsgenie(baseURL, apiKey string) *Opsgenie {
+	if baseURL == "" {
+		baseURL = "https://api.opsgenie.com"
+	}
+	return &Opsgenie{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+// Get reads an alert by its ID or, for a number, its tiny ID
+func (o *Opsgenie) Get(ctx context.Context, id string) (*Incident, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.alertURL(id, ""), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	o.authorize(req)
+
+	var alert struct {
+		Data struct {
+			ID          string            `json:"id"`
+			TinyID      string            `json:"tinyId"`
+			Message     string            `json:"message"`
+			Description string            `json:"description"`
+			Details     map[string]string `json:"details"`
+			Tags        []string          `json:"tags"`
+		} `json:"data"`
+	}
+	if err := doJSON(o.client, req, "Opsgenie", &alert); err != nil {
+		return nil, err
+	}
+
+	result := &Incident{
+		Service:     "opsgenie",
+		ID:          alert.Data.ID,
+		Title:       alert.Data.Message,
+		Description: alert.Data.Description,
+		Payloads:    []interface{}{alert.Data.Details, alert.Data.Description},
+	}
+	for _, tag := range alert.Data.Tags {
+		result.Payloads = append(result.Payloads, tag)
+	}
+	return result, nil
+}
+
+// AddNote adds a note to an alert. Opsgenie processes requests asynchronously, so the note may
+// show up shortly after.
+func (o *Opsgenie) AddNote(ctx context.Context, id, note string) error {
+	body, err := json.Marshal(map[string]string{
+		"note":   truncate(note, MaxNoteLength),
+		"source": "kube-ai",
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding note: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.alertURL(id, "/notes"), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	o.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+	return doJSON(o.client, req, "Opsgenie", nil)
+}
+
+// alertURL returns the URL of an alert, or of a resource under it
+func (o *Opsgenie) alertURL(id, path string) string {
+	identifierType := "id"
+	if isDigits(id) {
+		identifierType = "tiny"
+	}
+	return o.baseURL + "/v2/alerts/" + url.PathEscape(id) + path + "?identifierType=" + identifierType
+}
+
+// authorize adds the API key to a request
+func (o *Opsgenie) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "GenieKey "+o.apiKey)
+}
+
+// isDigits reports whether a string is a non-empty run of digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
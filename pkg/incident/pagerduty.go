@@ -0,0 +1,107 @@
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PagerDuty reads incidents and adds notes through the PagerDuty REST API
+type PagerDuty struct {
+	baseURL string
+	apiKey  string
+	// Email of the PagerDuty user notes are added as, required by the API
+	from   string
+	client *http.Client
+}
+
+// NewPagerDuty creates a PagerDuty client
+func NewPagerDuty(baseURL, apiKey, from string) *PagerDuty {
+	if baseURL == "" {
+		baseURL = "https://api.pagerduty.com"
+	}
+	return &PagerDuty{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		from:    from,
+		client:  &http.Client{},
+	}
+}
+
+// Get reads an incident and the bodies of its alerts
+func (p *PagerDuty) Get(ctx context.Context, id string) (*Incident, error) {
+	var incident struct {
+		Incident struct {
+			ID          string `json:"id"`
+			Title       string `json:"title"`
+			HTMLURL     string `json:"html_url"`
+			Description string `json:"description"`
+		} `json:"incident"`
+	}
+	if err := p.get(ctx, "/incidents/"+url.PathEscape(id), &incident); err != nil {
+		return nil, err
+	}
+
+	var alerts struct {
+		Alerts []struct {
+			Summary string      `json:"summary"`
+			Body    interface{} `json:"body"`
+		} `json:"alerts"`
+	}
+	if err := p.get(ctx, "/incidents/"+url.PathEscape(id)+"/alerts", &alerts); err != nil {
+		return nil, err
+	}
+
+	result := &Incident{
+		Service:     "pagerduty",
+		ID:          incident.Incident.ID,
+		Title:       incident.Incident.Title,
+		URL:         incident.Incident.HTMLURL,
+		Description: incident.Incident.Description,
+	}
+	for _, alert := range alerts.Alerts {
+		result.Payloads = append(result.Payloads, alert.Body)
+	}
+	return result, nil
+}
+
+// AddNote adds a note to an incident
+func (p *PagerDuty) AddNote(ctx context.Context, id, note string) error {
+	if p.from == "" {
+		return fmt.Errorf("PagerDuty needs the email of the user notes are added as: set pagerdutyFrom")
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"note": map[string]string{"content": truncate(note, MaxNoteLength)},
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding note: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/incidents/"+url.PathEscape(id)+"/notes", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	p.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("From", p.from)
+	return doJSON(p.client, req, "PagerDuty", nil)
+}
+
+// get reads a resource of the API
+func (p *PagerDuty) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	p.authorize(req)
+	return doJSON(p.client, req, "PagerDuty", v)
+}
+
+// authorize adds the API key and API version to a request
+func (p *PagerDuty) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Token token="+p.apiKey)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+}
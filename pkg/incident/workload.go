@@ -0,0 +1,148 @@
+package incident
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Workload is the Kubernetes workload an alert implicates
+type Workload struct {
+	Namespace string `json:"namespace,omitempty"`
+	// Kind of the workload: deployment, statefulset, daemonset or pod
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+// String returns the workload as kind/name
+func (w Workload) String() string {
+	return w.Kind + "/" + w.Name
+}
+
+// workloadKeys maps the label keys monitoring systems use (Prometheus, kube-state-metrics,
+// OpenTelemetry) to what they name. Keys are compared lowercased.
+var workloadKeys = map[string]string{
+	"namespace":            "namespace",
+	"exported_namespace":   "namespace",
+	"kubernetes_namespace": "namespace",
+	"k8s.namespace.name":   "namespace",
+	"deployment":           "deployment",
+	"k8s.deployment.name":  "deployment",
+	"statefulset":          "statefulset",
+	"k8s.statefulset.name": "statefulset",
+	"daemonset":            "daemonset",
+	"k8s.daemonset.name":   "daemonset",
+	"replicaset":           "replicaset",
+	"k8s.replicaset.name":  "replicaset",
+	"pod":                  "pod",
+	"pod_name":             "pod",
+	"exported_pod":         "pod",
+	"kubernetes_pod_name":  "pod",
+	"k8s.pod.name":         "pod",
+	"container":            "container",
+	"container_name":       "container",
+	"k8s.container.name":   "container",
+}
+
+// workloadKinds are the kinds a workload is identified by, most specific last: a deployment
+// is preferred over one of its pods since pods come and go
+var workloadKinds = []string{"deployment", "statefulset", "daemonset", "pod"}
+
+// dnsName matches valid Kubernetes object names
+var dnsName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]{0,251}[a-z0-9])?$`)
+
+// labelLine matches "key=value" and "key: value" in alert descriptions and tags
+var labelLine = regexp.MustCompile(`^\s*-?\s*([A-Za-z][A-Za-z0-9_.]*)\s*[:=]\s*"?([^"\s,]+)"?\s*,?\s*$`)
+
+// label is a key/value pair found in an alert payload
+type label struct {
+	key   string
+	value string
+}
+
+// Workload identifies the Kubernetes workload the incident's alerts implicate from their
+// labels. A deployment, statefulset or daemonset is preferred over a pod, and a replicaset
+// is resolved to its deployment by name.
+func (i *Incident) Workload() (Workload, error) {
+	found := make(map[string]string)
+	for _, l := range i.labels() {
+		kind, ok := workloadKeys[strings.ToLower(l.key)]
+		if !ok || found[kind] != "" || !dnsName.MatchString(l.value) {
+			continue
+		}
+		found[kind] = l.value
+	}
+	if found["deployment"] == "" && found["replicaset"] != "" {
+		if i := strings.LastIndex(found["replicaset"], "-"); i > 0 {
+			found["deployment"] = found["replicaset"][:i]
+		}
+	}
+
+	workload := Workload{Namespace: found["namespace"], Container: found["container"]}
+	for _, kind := range workloadKinds {
+		if found[kind] != "" {
+			workload.Kind, workload.Name = kind, found[kind]
+			return workload, nil
+		}
+	}
+	return workload, fmt.Errorf("no Kubernetes workload found in the alert labels of %s incident %s: use --resource", i.Service, i.ID)
+}
+
+// labels returns the key/value pairs found in the incident's alert payloads, walking nested
+// objects and reading "key=value" lines from text. Duplicate keys keep their first value.
+func (i *Incident) labels() []label {
+	var labels []label
+	seen := make(map[string]bool)
+	add := func(key, value string) {
+		if key == "" || value == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		labels = append(labels, label{key: key, value: value})
+	}
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if s, ok := v[key].(string); ok && !strings.ContainsAny(s, "\n") && len(s) < 256 {
+					add(key, s)
+				}
+			}
+			for _, key := range keys {
+				walk(v[key])
+			}
+		case map[string]string:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				add(key, v[key])
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case string:
+			for _, line := range strings.Split(v, "\n") {
+				if m := labelLine.FindStringSubmatch(line); m != nil {
+					add(m[1], m[2])
+				}
+			}
+		}
+	}
+	for _, payload := range i.Payloads {
+		walk(payload)
+	}
+	return labels
+}
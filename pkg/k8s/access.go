@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterScopedResources are the resources in FeaturePermissions that do not live in a
+// namespace, so access to them is checked cluster-wide
+var clusterScopedResources = map[string]bool{
+	"nodes":                           true,
+	"namespaces":                      true,
+	"persistentvolumes":               true,
+	"clusterroles":                    true,
+	"clusterrolebindings":             true,
+	"customresourcedefinitions":       true,
+	"apiservices":                     true,
+	"mutatingwebhookconfigurations":   true,
+	"validatingwebhookconfigurations": true,
+	"certificatesigningrequests":      true,
+	"flowschemas":                     true,
+	"prioritylevelconfigurations":     true,
+	"runtimeclasses":                  true,
+	"priorityclasses":                 true,
+	"ingressclasses":                  true,
+	"storageclasses":                  true,
+	"csidrivers":                      true,
+	"csinodes":                        true,
+	"volumeattachments":               true,
+	"nodepools":                       true,
+	"nodeclaims":                      true,
+}
+
+// IsClusterScopedResource reports whether a resource of FeaturePermissions, optionally with a
+// subresource, is not namespaced
+func IsClusterScopedResource(resource string) bool {
+	resource, _, _ = strings.Cut(resource, "/")
+	return clusterScopedResources[resource]
+}
+
+// PermissionError reports the permissions a feature needs that the caller does not have
+type PermissionError struct {
+	// Feature whose permissions were checked
+	Feature string
+	// Namespace the permissions were checked in ("" for all namespaces)
+	Namespace string
+	// Permissions the caller lacks, one verb each
+	Missing []Permission
+}
+
+func (e *PermissionError) Error() string {
+	scope := "across all namespaces"
+	if e.Namespace != "" {
+		scope = fmt.Sprintf("in namespace %s", e.Namespace)
+	}
+	return fmt.Sprintf("missing permissions for %s %s: %s", e.Feature, scope, strings.Join(e.MissingVerbs(), ", "))
+}
+
+// MissingVerbs describes each missing permission as "<verb> <resource>", with the API group
+// for resources outside the core group
+func (e *PermissionError) MissingVerbs() []string {
+	verbs := make([]string, 0, len(e.Missing))
+	for _, perm := range e.Missing {
+		resource := perm.Resource
+		if perm.APIGroup != "" {
+			resource = fmt.Sprintf("%s.%s", perm.Resource, perm.APIGroup)
+		}
+		for _, verb := range perm.Verbs {
+			verbs = append(verbs, fmt.Sprintf("%s %s", verb, resource))
+		}
+	}
+	return verbs
+}
+
+// CheckFeatureAccess checks with SelfSubjectAccessReviews that the current credentials have
+// every permission a feature needs in a namespace ("" for all namespaces), and returns a
+// *PermissionError listing the missing ones. Optional permissions are not checked. An error
+// from the reviews themselves is returned as is, so callers can carry on when the API server
+// does not answer them.
+func (c *Client) CheckFeatureAccess(ctx context.Context, feature, namespace string) error {
+	permissions, ok := FeaturePermissions[feature]
+	if !ok {
+		return fmt.Errorf("unknown feature %q (known features: %s)", feature, strings.Join(FeatureNames(), ", "))
+	}
+
+	var required []Permission
+	for _, perm := range permissions {
+		if !perm.Optional {
+			required = append(required, perm)
+		}
+	}
+	missing, err := c.MissingPermissions(ctx, namespace, required)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return &PermissionError{Feature: feature, Namespace: namespace, Missing: missing}
+	}
+	return nil
+}
+
+// MissingPermissions returns the permissions the current credentials lack in a namespace
+// ("" for all namespaces), split into one permission per missing verb
+func (c *Client) MissingPermissions(ctx context.Context, namespace string, permissions []Permission) ([]Permission, error) {
+	var missing []Permission
+	checked := make(map[string]bool)
+
+	for _, perm := range permissions {
+		resource, subresource, _ := strings.Cut(perm.Resource, "/")
+		reviewNamespace := namespace
+		if IsClusterScopedResource(resource) {
+			reviewNamespace = ""
+		}
+
+		for _, verb := range perm.Verbs {
+			key := strings.Join([]string{perm.APIGroup, perm.Resource, verb}, "|")
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace:   reviewNamespace,
+						Verb:        verb,
+						Group:       perm.APIGroup,
+						Resource:    resource,
+						Subresource: subresource,
+					},
+				},
+			}
+			result, err := c.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("error checking permissions: %w", err)
+			}
+			if !result.Status.Allowed {
+				missing = append(missing, Permission{APIGroup: perm.APIGroup, Resource: perm.Resource, Verbs: []string{verb}})
+			}
+		}
+	}
+
+	return missing, nil
+}
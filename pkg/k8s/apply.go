@@ -0,0 +1,213 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// FieldManager identifies kube-ai as the owner of fields it applies
+const FieldManager = "kube-ai"
+
+// ResourceDiff is how applying a manifest would change one of its resources
+type ResourceDiff struct {
+	// Resource as kind/name
+	Resource string
+	// Whether the resource does not exist yet
+	Created bool
+	// Unified diff of the live resource and the resource as applied, in YAML ("" if unchanged)
+	Diff string
+}
+
+// ApplyManifest server-side applies every document of a YAML manifest, using namespace for
+// namespaced resources that do not set one. It returns the applied resources as kind/name.
+// Like every write, it is refused unless the client was created with writes allowed.
+func (c *Client) ApplyManifest(ctx context.Context, manifest, namespace string) ([]string, error) {
+	if c.config.ReadOnly {
+		return nil, fmt.Errorf("%w: applying a manifest requires --allow-writes", ErrReadOnly)
+	}
+
+	var applied []string
+	err := c.eachManifestObject(manifest, namespace, func(obj *unstructured.Unstructured, target dynamic.ResourceInterface, resource string) error {
+		if _, err := c.applyObject(ctx, obj, target, false); err != nil {
+			return err
+		}
+		applied = append(applied, resource)
+		return nil
+	})
+	return applied, err
+}
+
+// DiffManifest compares every resource of a manifest with what server-side applying it would
+// make of it, by applying it as a dry run like kubectl diff. Nothing is changed, but dry runs are
+// sent as writes, so it is refused unless the client was created with writes allowed.
+func (c *Client) DiffManifest(ctx context.Context, content, namespace string) ([]ResourceDiff, error) {
+	if c.config.ReadOnly {
+		return nil, fmt.Errorf("%w: comparing a manifest with the cluster requires --allow-writes", ErrReadOnly)
+	}
+
+	var diffs []ResourceDiff
+	err := c.eachManifestObject(content, namespace, func(obj *unstructured.Unstructured, target dynamic.ResourceInterface, resource string) error {
+		live, err := target.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return fmt.Errorf("error reading %s: %w", resource, err)
+		}
+		applied, err := c.applyObject(ctx, obj, target, true)
+		if err != nil {
+			return err
+		}
+
+		maskSecretData(live, applied)
+		from, err := diffableYAML(live)
+		if err != nil {
+			return err
+		}
+		to, err := diffableYAML(applied)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, ResourceDiff{
+			Resource: resource,
+			Created:  live == nil,
+			Diff:     manifest.Diff(from, to, "live/"+resource, "applied/"+resource),
+		})
+		return nil
+	})
+	return diffs, err
+}
+
+// eachManifestObject decodes every document of a YAML manifest and calls fn with the object, the
+// client of its resource and its kind/name, setting namespace on namespaced resources that do
+// not set one
+func (c *Client) eachManifestObject(content, namespace string, fn func(obj *unstructured.Unstructured, target dynamic.ResourceInterface, resource string) error) error {
+	if c.dynamic == nil {
+		return fmt.Errorf("applying manifests is not supported by this client")
+	}
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	mapper := c.restMapper()
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(content), 4096)
+
+	for {
+		var obj unstructured.Unstructured
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error decoding manifest: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("error resolving %s: %w", gvk.Kind, err)
+		}
+
+		resource := c.dynamic.Resource(mapping.Resource)
+		var target dynamic.ResourceInterface = resource
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(namespace)
+			}
+			target = resource.Namespace(obj.GetNamespace())
+		}
+
+		if err := fn(&obj, target, strings.ToLower(gvk.Kind)+"/"+obj.GetName()); err != nil {
+			return err
+		}
+	}
+}
+
+// applyObject server-side applies an object, only as a dry run if dryRun is set, and returns the
+// object as the server made it
+func (c *Client) applyObject(ctx context.Context, obj *unstructured.Unstructured, target dynamic.ResourceInterface, dryRun bool) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	options := metav1.PatchOptions{FieldManager: FieldManager}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	// Conflicts with fields owned by other managers are reported rather than forced
+	applied, err := target.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options)
+	if err != nil {
+		return nil, fmt.Errorf("error applying %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return applied, nil
+}
+
+// diffableYAML renders an object as YAML for a diff, without the managed fields bookkeeping, or
+// returns "" for no object
+func diffableYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("error encoding %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return string(data), nil
+}
+
+// maskSecretData hides the values of Secrets before they are shown in a diff, as kubectl diff
+// does, keeping whether each value changed
+func maskSecretData(live, applied *unstructured.Unstructured) {
+	if applied == nil || applied.GetKind() != "Secret" {
+		return
+	}
+	for _, field := range []string{"data", "stringData"} {
+		var before map[string]interface{}
+		if live != nil {
+			before, _, _ = unstructured.NestedMap(live.Object, field)
+		}
+		after, _, _ := unstructured.NestedMap(applied.Object, field)
+
+		for key, value := range after {
+			old, existed := before[key]
+			switch {
+			case existed && old == value:
+				before[key], after[key] = "***", "***"
+			case existed:
+				before[key], after[key] = "*** (before)", "*** (after)"
+			default:
+				after[key] = "***"
+			}
+		}
+		for key := range before {
+			if _, kept := after[key]; !kept {
+				before[key] = "***"
+			}
+		}
+		if live != nil && before != nil {
+			unstructured.SetNestedMap(live.Object, before, field)
+		}
+		if after != nil {
+			unstructured.SetNestedMap(applied.Object, after, field)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultArgoNamespace is the namespace Argo CD is installed in and its Applications live in by default
+const DefaultArgoNamespace = "argocd"
+
+// maxArgoDrifts limits the out-of-sync resources whose live state is compared with the desired state
+const maxArgoDrifts = 10
+
+// maxDriftFields limits the differing fields reported for a resource
+const maxDriftFields = 30
+
+// lastAppliedConfiguration records the desired state of objects applied with client-side apply,
+// as Argo CD does by default
+const lastAppliedConfiguration = "kubectl.kubernetes.io/last-applied-configuration"
+
+// argoApplication is the Argo CD Application kind
+var argoApplication = schema.GroupKind{Group: "argoproj.io", Kind: "Application"}
+
+// ArgoApplication summarizes an Argo CD Application: where its desired state comes from, its
+// sync and health status, and the resources that are out of sync or unhealthy
+type ArgoApplication struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Project   string `json:"project,omitempty"`
+	// Sources of the desired state, such as a Git path or Helm chart with its values
+	Sources []ArgoSource `json:"sources"`
+	// Cluster and namespace the application deploys to
+	DestinationServer    string `json:"destinationServer,omitempty"`
+	DestinationNamespace string `json:"destinationNamespace,omitempty"`
+	// Automated sync options, such as prune and selfHeal, if automated sync is on
+	AutoSync []string `json:"autoSync,omitempty"`
+	// Synced, OutOfSync or Unknown, and the revision compared with
+	SyncStatus   string `json:"syncStatus"`
+	SyncRevision string `json:"syncRevision,omitempty"`
+	// Healthy, Progressing, Degraded, Suspended, Missing or Unknown
+	HealthStatus  string `json:"healthStatus"`
+	HealthMessage string `json:"healthMessage,omitempty"`
+	// Phase and message of the last sync operation, such as Failed with the apply error
+	OperationPhase   string `json:"operationPhase,omitempty"`
+	OperationMessage string `json:"operationMessage,omitempty"`
+	// Conditions such as ComparisonError or SyncError
+	Conditions []string `json:"conditions,omitempty"`
+	// Resources that are out of sync or not healthy
+	Resources []ArgoResource `json:"resources"`
+	// Differences between the desired and live state of out-of-sync resources
+	Drifts []ArgoDrift `json:"drifts,omitempty"`
+	// Number of resources the application manages
+	TotalResources int `json:"totalResources"`
+}
+
+// ArgoSource is where an Argo CD Application's desired state comes from
+type ArgoSource struct {
+	RepoURL        string `json:"repoURL"`
+	Path           string `json:"path,omitempty"`
+	Chart          string `json:"chart,omitempty"`
+	TargetRevision string `json:"targetRevision,omitempty"`
+	// Helm value files, parameters and inline values, or Kustomize images and patches
+	Helm      string `json:"helm,omitempty"`
+	Kustomize string `json:"kustomize,omitempty"`
+}
+
+// ArgoResource is a resource managed by an Argo CD Application
+type ArgoResource struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Synced, OutOfSync or Unknown
+	Status string `json:"status"`
+	// Health status and message, for kinds Argo CD assesses
+	Health        string `json:"health,omitempty"`
+	HealthMessage string `json:"healthMessage,omitempty"`
+	// Set when the resource is no longer in the desired state and would be pruned
+	RequiresPruning bool `json:"requiresPruning,omitempty"`
+}
+
+// String returns the resource as kind/namespace/name
+func (r ArgoResource) String() string {
+	if r.Namespace == "" {
+		return r.Kind + "/" + r.Name
+	}
+	return r.Kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// ArgoDrift describes how the live state of an out-of-sync resource differs from its desired state
+type ArgoDrift struct {
+	Resource string `json:"resource"`
+	// Fields whose live value differs from the desired one, as path: desired -> live
+	Fields []string `json:"fields,omitempty"`
+	// Why the difference could not be computed, such as a resource missing from the cluster
+	Note string `json:"note,omitempty"`
+}
+
+// GetArgoApplication reads an Argo CD Application and compares the live state of its out-of-sync
+// resources with the desired state they were last applied with
+func (c *Client) GetArgoApplication(ctx context.Context, namespace, name string) (*ArgoApplication, error) {
+	obj, err := c.GetObject(ctx, argoApplication, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Argo CD application %s/%s: %w", namespace, name, err)
+	}
+
+	app := &ArgoApplication{Name: name, Namespace: namespace}
+	app.Project, _, _ = unstructured.NestedString(obj.Object, "spec", "project")
+	app.DestinationServer, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "server")
+	if app.DestinationServer == "" {
+		app.DestinationServer, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "name")
+	}
+	app.DestinationNamespace, _, _ = unstructured.NestedString(obj.Object, "spec", "destination", "namespace")
+
+	if source, ok, _ := unstructured.NestedMap(obj.Object, "spec", "source"); ok {
+		app.Sources = append(app.Sources, argoSource(source))
+	}
+	sources, _, _ := unstructured.NestedSlice(obj.Object, "spec", "sources")
+	for _, source := range sources {
+		if source, ok := source.(map[string]interface{}); ok {
+			app.Sources = append(app.Sources, argoSource(source))
+		}
+	}
+
+	if automated, ok, _ := unstructured.NestedMap(obj.Object, "spec", "syncPolicy", "automated"); ok {
+		app.AutoSync = []string{"automated"}
+		for _, option := range []string{"prune", "selfHeal", "allowEmpty"} {
+			if enabled, _, _ := unstructured.NestedBool(automated, option); enabled {
+				app.AutoSync = append(app.AutoSync, option)
+			}
+		}
+	}
+
+	app.SyncStatus, _, _ = unstructured.NestedString(obj.Object, "status", "sync", "status")
+	app.SyncRevision, _, _ = unstructured.NestedString(obj.Object, "status", "sync", "revision")
+	app.HealthStatus, _, _ = unstructured.NestedString(obj.Object, "status", "health", "status")
+	app.HealthMessage, _, _ = unstructured.NestedString(obj.Object, "status", "health", "message")
+	app.OperationPhase, _, _ = unstructured.NestedString(obj.Object, "status", "operationState", "phase")
+	app.OperationMessage, _, _ = unstructured.NestedString(obj.Object, "status", "operationState", "message")
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if condition, ok := condition.(map[string]interface{}); ok {
+			conditionType, _, _ := unstructured.NestedString(condition, "type")
+			message, _, _ := unstructured.NestedString(condition, "message")
+			app.Conditions = append(app.Conditions, conditionType+": "+message)
+		}
+	}
+
+	resources, _, _ := unstructured.NestedSlice(obj.Object, "status", "resources")
+	app.TotalResources = len(resources)
+	for _, item := range resources {
+		item, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var resource ArgoResource
+		resource.Group, _, _ = unstructured.NestedString(item, "group")
+		resource.Kind, _, _ = unstructured.NestedString(item, "kind")
+		resource.Namespace, _, _ = unstructured.NestedString(item, "namespace")
+		resource.Name, _, _ = unstructured.NestedString(item, "name")
+		resource.Status, _, _ = unstructured.NestedString(item, "status")
+		resource.Health, _, _ = unstructured.NestedString(item, "health", "status")
+		resource.HealthMessage, _, _ = unstructured.NestedString(item, "health", "message")
+		resource.RequiresPruning, _, _ = unstructured.NestedBool(item, "requiresPruning")
+		if resource.Status == "Synced" && (resource.Health == "" || resource.Health == "Healthy") {
+			continue
+		}
+		app.Resources = append(app.Resources, resource)
+	}
+
+	for _, resource := range app.Resources {
+		if resource.Status != "OutOfSync" || resource.RequiresPruning {
+			continue
+		}
+		if len(app.Drifts) == maxArgoDrifts {
+			break
+		}
+		app.Drifts = append(app.Drifts, c.argoDrift(ctx, resource))
+	}
+	return app, nil
+}
+
+// argoSource summarizes a source of an Argo CD Application
+func argoSource(source map[string]interface{}) ArgoSource {
+	var result ArgoSource
+	result.RepoURL, _, _ = unstructured.NestedString(source, "repoURL")
+	result.Path, _, _ = unstructured.NestedString(source, "path")
+	result.Chart, _, _ = unstructured.NestedString(source, "chart")
+	result.TargetRevision, _, _ = unstructured.NestedString(source, "targetRevision")
+	if helm, ok, _ := unstructured.NestedMap(source, "helm"); ok {
+		result.Helm = compactYAML(helm)
+	}
+	if kustomize, ok, _ := unstructured.NestedMap(source, "kustomize"); ok {
+		result.Kustomize = compactYAML(kustomize)
+	}
+	return result
+}
+
+// argoDrift compares the live state of an out-of-sync resource with the configuration it was
+// last applied with
+func (c *Client) argoDrift(ctx context.Context, resource ArgoResource) ArgoDrift {
+	drift := ArgoDrift{Resource: resource.String()}
+	live, err := c.GetObject(ctx, schema.GroupKind{Group: resource.Group, Kind: resource.Kind}, resource.Namespace, resource.Name)
+	if apierrors.IsNotFound(err) {
+		drift.Note = "missing from the cluster: it has not been created yet, or was deleted"
+		return drift
+	}
+	if err != nil {
+		drift.Note = fmt.Sprintf("could not be read: %v", err)
+		return drift
+	}
+
+	applied, ok := live.GetAnnotations()[lastAppliedConfiguration]
+	if !ok {
+		drift.Note = "no last-applied configuration recorded (server-side apply); the desired state is only in the source"
+		return drift
+	}
+	var desired map[string]interface{}
+	if err := json.Unmarshal([]byte(applied), &desired); err != nil {
+		drift.Note = fmt.Sprintf("invalid last-applied configuration: %v", err)
+		return drift
+	}
+	delete(desired, "status")
+	drift.Fields = diffFields("", desired, live.Object)
+	if len(drift.Fields) > maxDriftFields {
+		drift.Fields = append(drift.Fields[:maxDriftFields], fmt.Sprintf("... and %d more fields", len(drift.Fields)-maxDriftFields))
+	}
+	if len(drift.Fields) == 0 {
+		drift.Note = "the live state matches the last applied configuration; the desired state changed in the source since the last sync"
+	}
+	return drift
+}
+
+// diffFields lists the fields set in desired whose value differs in live, as path: desired -> live.
+// Fields only set in live, such as defaults filled in by the API server, are ignored.
+func diffFields(path string, desired, live interface{}) []string {
+	var fields []string
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %s -> %s", path, compactJSON(desired), compactJSON(live))}
+		}
+		keys := make([]string, 0, len(d))
+		for key := range d {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if path == "metadata" && key == "annotations" {
+				continue
+			}
+			value, found := l[key]
+			if !found {
+				fields = append(fields, fmt.Sprintf("%s: %s -> (not set)", joinPath(path, key), compactJSON(d[key])))
+				continue
+			}
+			fields = append(fields, diffFields(joinPath(path, key), d[key], value)...)
+		}
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			return []string{fmt.Sprintf("%s: %s -> %s", path, compactJSON(desired), compactJSON(live))}
+		}
+		for i := range d {
+			fields = append(fields, diffFields(fmt.Sprintf("%s[%d]", path, i), d[i], l[i])...)
+		}
+	default:
+		// JSON numbers decode as float64 while live objects hold int64
+		if fmt.Sprint(desired) != fmt.Sprint(live) && !reflect.DeepEqual(desired, live) {
+			return []string{fmt.Sprintf("%s: %s -> %s", path, compactJSON(desired), compactJSON(live))}
+		}
+	}
+	return fields
+}
+
+// joinPath appends a key to a dotted field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// compactJSON formats a value on one line, cutting long values
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	if len(data) > 200 {
+		return string(data[:200]) + "..."
+	}
+	return string(data)
+}
+
+// compactYAML formats a value as YAML for a prompt
+func compactYAML(value interface{}) string {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,402 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Node autoscalers recognized by the name or app.kubernetes.io/name label of their Deployment
+const (
+	ClusterAutoscaler = "cluster-autoscaler"
+	Karpenter         = "karpenter"
+)
+
+var (
+	karpenterNodePool  = schema.GroupKind{Group: "karpenter.sh", Kind: "NodePool"}
+	karpenterNodeClaim = schema.GroupKind{Group: "karpenter.sh", Kind: "NodeClaim"}
+)
+
+// nodeScalingReasons are the reasons of node events that show nodes joining, failing or leaving
+// the cluster, whichever controller reports them
+var nodeScalingReasons = []string{"RegisteredNode", "NodeNotReady", "RemovingNode", "DeletingNode", "NodeNotSchedulable"}
+
+// failedScaleUpReasons are the reasons of cluster-autoscaler events reporting a scale-up that was
+// attempted and did not produce a node
+var failedScaleUpReasons = []string{"FailedToScaleUpGroup", "ScaleUpTimedOut", "FailedScaleUp"}
+
+// Scaling report limits: pending pods described, events kept, events kept per pending pod, and
+// lines kept from the cluster-autoscaler status
+const (
+	maxScalingPendingPods = 30
+	maxScalingEvents      = 100
+	maxPendingPodEvents   = 5
+	maxScalingStatusLines = 40
+)
+
+// ScalingReport describes the node autoscaling of a cluster over a time window: the autoscalers
+// running, Karpenter's node pools and node claims, the pods left pending, the scaling events, and
+// the problems found
+type ScalingReport struct {
+	Since       time.Time    `json:"since"`
+	Autoscalers []Autoscaler `json:"autoscalers"`
+	// Status the cluster-autoscaler publishes in its cluster-autoscaler-status ConfigMap
+	Status        string               `json:"status,omitempty"`
+	NodePools     []KarpenterNodePool  `json:"nodePools"`
+	NodeClaims    []KarpenterNodeClaim `json:"nodeClaims"`
+	NotReadyNodes []string             `json:"notReadyNodes"`
+	PendingPods   []UnscheduledPod     `json:"pendingPods"`
+	Events        []ScalingEvent       `json:"events"`
+	Findings      []string             `json:"findings"`
+}
+
+// Autoscaler is a cluster-autoscaler or Karpenter Deployment and the pods running it
+type Autoscaler struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Desired   int32    `json:"desired"`
+	Ready     int32    `json:"ready"`
+	Pods      []string `json:"pods"`
+	// Log lines about scaling decisions and failures, when collected
+	Logs []string `json:"logs,omitempty"`
+}
+
+// KarpenterNodePool is a Karpenter NodePool with its limits and disruption settings
+type KarpenterNodePool struct {
+	Name   string            `json:"name"`
+	Ready  string            `json:"ready"`
+	Limits map[string]string `json:"limits,omitempty"`
+	// Consolidation policy and delay, and the disruption budgets limiting how many nodes go at once
+	ConsolidationPolicy string   `json:"consolidationPolicy,omitempty"`
+	ConsolidateAfter    string   `json:"consolidateAfter,omitempty"`
+	Budgets             []string `json:"budgets,omitempty"`
+	Nodes               int      `json:"nodes"`
+}
+
+// KarpenterNodeClaim is a Karpenter NodeClaim, the request for a node, that has not become a
+// ready node
+type KarpenterNodeClaim struct {
+	Name     string `json:"name"`
+	NodePool string `json:"nodePool,omitempty"`
+	Node     string `json:"node,omitempty"`
+	// First lifecycle condition (Launched, Registered, Initialized) that is not true, and why
+	Condition string `json:"condition"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// UnscheduledPod is a pod the scheduler cannot place, with the scheduler's message and its
+// recent events
+type UnscheduledPod struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Age       string   `json:"age"`
+	Message   string   `json:"message,omitempty"`
+	Events    []string `json:"events,omitempty"`
+}
+
+// ScalingEvent is an event reported by an autoscaler, or a node event showing a node joining,
+// failing or leaving the cluster
+type ScalingEvent struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Object  string    `json:"object"`
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+	Count   int32     `json:"count,omitempty"`
+}
+
+// GetScalingReport collects the node autoscalers of the cluster and their events since a time,
+// and the pending pods of namespace, or of all namespaces when namespace is empty
+func (c *Client) GetScalingReport(ctx context.Context, namespace string, since time.Time) (*ScalingReport, error) {
+	report := &ScalingReport{
+		Since:         since,
+		Autoscalers:   []Autoscaler{},
+		NodePools:     []KarpenterNodePool{},
+		NodeClaims:    []KarpenterNodeClaim{},
+		NotReadyNodes: []string{},
+		PendingPods:   []UnscheduledPod{},
+		Events:        []ScalingEvent{},
+		Findings:      []string{},
+	}
+
+	deployments, err := c.clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %w", err)
+	}
+	for _, deployment := range deployments.Items {
+		if autoscaler, ok := c.describeAutoscaler(ctx, deployment); ok {
+			report.Autoscalers = append(report.Autoscalers, autoscaler)
+		}
+	}
+	for _, autoscaler := range report.Autoscalers {
+		if autoscaler.Kind != ClusterAutoscaler {
+			continue
+		}
+		status, err := c.clientset.CoreV1().ConfigMaps(autoscaler.Namespace).Get(ctx, "cluster-autoscaler-status", metav1.GetOptions{})
+		if err == nil {
+			lines := strings.Split(strings.TrimSpace(status.Data["status"]), "\n")
+			if len(lines) > maxScalingStatusLines {
+				lines = lines[:maxScalingStatusLines]
+			}
+			report.Status = strings.Join(lines, "\n")
+			break
+		}
+	}
+
+	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+	poolNodes := make(map[string]int)
+	for _, node := range nodes.Items {
+		poolNodes[node.Labels["karpenter.sh/nodepool"]]++
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status != corev1.ConditionTrue {
+				report.NotReadyNodes = append(report.NotReadyNodes, fmt.Sprintf("%s (%s since %s)", node.Name, condition.Reason, condition.LastTransitionTime.Format(time.RFC3339)))
+			}
+		}
+	}
+
+	pools, err := c.ListObjects(ctx, karpenterNodePool, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range pools {
+		pool := describeNodePool(&pools[i])
+		pool.Nodes = poolNodes[pool.Name]
+		report.NodePools = append(report.NodePools, pool)
+	}
+	claims, err := c.ListObjects(ctx, karpenterNodeClaim, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range claims {
+		if claim, ok := describeNodeClaim(&claims[i]); ok {
+			report.NodeClaims = append(report.NodeClaims, claim)
+		}
+	}
+
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: "status.phase=Pending"})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pending pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if len(report.PendingPods) == maxScalingPendingPods {
+			break
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				report.PendingPods = append(report.PendingPods, UnscheduledPod{
+					Name:      pod.Name,
+					Namespace: pod.Namespace,
+					Age:       time.Since(pod.CreationTimestamp.Time).Round(time.Second).String(),
+					Message:   condition.Message,
+					Events:    c.recentEvents(ctx, pod.Namespace, "Pod", pod.Name, maxPendingPodEvents),
+				})
+			}
+		}
+	}
+
+	events, err := c.clientset.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing events: %w", err)
+	}
+	report.Events = scalingEvents(events.Items, since)
+
+	report.Findings = append(report.Findings, report.findings()...)
+	return report, nil
+}
+
+// describeAutoscaler returns the autoscaler a Deployment runs, if it runs one
+func (c *Client) describeAutoscaler(ctx context.Context, deployment appsv1.Deployment) (Autoscaler, bool) {
+	kind := ""
+	for _, name := range []string{deployment.Labels["app.kubernetes.io/name"], deployment.Name} {
+		switch {
+		case kind != "":
+		case strings.Contains(name, ClusterAutoscaler):
+			kind = ClusterAutoscaler
+		case strings.Contains(name, Karpenter):
+			kind = Karpenter
+		}
+	}
+	if kind == "" {
+		return Autoscaler{}, false
+	}
+
+	autoscaler := Autoscaler{
+		Kind:      kind,
+		Name:      deployment.Name,
+		Namespace: deployment.Namespace,
+		Desired:   1,
+		Ready:     deployment.Status.ReadyReplicas,
+		Pods:      []string{},
+	}
+	if deployment.Spec.Replicas != nil {
+		autoscaler.Desired = *deployment.Spec.Replicas
+	}
+	if selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector); err == nil {
+		pods, err := c.ListPods(ctx, deployment.Namespace, selector.String())
+		if err == nil {
+			for _, pod := range pods {
+				if pod.Status.Phase == corev1.PodRunning {
+					autoscaler.Pods = append(autoscaler.Pods, pod.Name)
+				}
+			}
+		}
+	}
+	return autoscaler, true
+}
+
+// describeNodePool describes a Karpenter NodePool
+func describeNodePool(obj *unstructured.Unstructured) KarpenterNodePool {
+	pool := KarpenterNodePool{Name: obj.GetName(), Ready: fluxConditionStatus(obj, "Ready")}
+	if limits, found, _ := unstructured.NestedMap(obj.Object, "spec", "limits"); found {
+		pool.Limits = make(map[string]string)
+		for name, value := range limits {
+			pool.Limits[name] = fmt.Sprint(value)
+		}
+	}
+	pool.ConsolidationPolicy, _, _ = unstructured.NestedString(obj.Object, "spec", "disruption", "consolidationPolicy")
+	pool.ConsolidateAfter, _, _ = unstructured.NestedString(obj.Object, "spec", "disruption", "consolidateAfter")
+	budgets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "disruption", "budgets")
+	for _, value := range budgets {
+		budget, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		description := fmt.Sprintf("nodes=%v", budget["nodes"])
+		if reasons, ok := budget["reasons"].([]interface{}); ok {
+			description += fmt.Sprintf(" reasons=%v", reasons)
+		}
+		if schedule, ok := budget["schedule"].(string); ok {
+			description += fmt.Sprintf(" schedule=%q duration=%v", schedule, budget["duration"])
+		}
+		pool.Budgets = append(pool.Budgets, description)
+	}
+	return pool
+}
+
+// describeNodeClaim describes a Karpenter NodeClaim that has not become a ready node
+func describeNodeClaim(obj *unstructured.Unstructured) (KarpenterNodeClaim, bool) {
+	conditions := fluxConditions(obj.Object)
+	for _, conditionType := range []string{"Launched", "Registered", "Initialized", "Ready"} {
+		for _, condition := range conditions {
+			if condition.Type != conditionType || condition.Status == "True" {
+				continue
+			}
+			claim := KarpenterNodeClaim{
+				Name:      obj.GetName(),
+				NodePool:  obj.GetLabels()["karpenter.sh/nodepool"],
+				Condition: condition.Type + "=" + condition.Status,
+				Reason:    condition.Reason,
+				Message:   condition.Message,
+			}
+			claim.Node, _, _ = unstructured.NestedString(obj.Object, "status", "nodeName")
+			return claim, true
+		}
+	}
+	return KarpenterNodeClaim{}, false
+}
+
+// scalingEvents returns the events since a time reported by an autoscaler, and the node events
+// of nodes joining, failing or leaving the cluster, oldest first
+func scalingEvents(events []corev1.Event, since time.Time) []ScalingEvent {
+	sort.SliceStable(events, func(i, j int) bool { return eventTime(events[i]).Before(eventTime(events[j])) })
+	scaling := []ScalingEvent{}
+	for _, event := range events {
+		if eventTime(event).Before(since) {
+			continue
+		}
+		source := event.Source.Component
+		if source == "" {
+			source = event.ReportingController
+		}
+		fromAutoscaler := strings.Contains(source, ClusterAutoscaler) || strings.Contains(source, Karpenter)
+		if !fromAutoscaler && (event.InvolvedObject.Kind != "Node" || !slices.Contains(nodeScalingReasons, event.Reason)) {
+			continue
+		}
+		object := event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name
+		if event.InvolvedObject.Namespace != "" {
+			object = event.InvolvedObject.Kind + "/" + event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		}
+		scaling = append(scaling, ScalingEvent{
+			Time:    eventTime(event),
+			Source:  source,
+			Object:  object,
+			Type:    event.Type,
+			Reason:  event.Reason,
+			Message: strings.TrimSpace(event.Message),
+			Count:   event.Count,
+		})
+	}
+	if len(scaling) > maxScalingEvents {
+		scaling = scaling[len(scaling)-maxScalingEvents:]
+	}
+	return scaling
+}
+
+// findings reports missing or unhealthy autoscalers, pods that did not trigger a scale-up,
+// scale-ups that failed, node claims that did not become nodes, and nodes that are not ready
+func (r *ScalingReport) findings() []string {
+	var findings []string
+	if len(r.Autoscalers) == 0 {
+		findings = append(findings, "no cluster-autoscaler or Karpenter deployment was found, so pending pods do not add nodes")
+	}
+	for _, autoscaler := range r.Autoscalers {
+		if autoscaler.Ready < autoscaler.Desired {
+			findings = append(findings, fmt.Sprintf("%s %s/%s has %d/%d ready replicas, so no scaling decisions are made", autoscaler.Kind, autoscaler.Namespace, autoscaler.Name, autoscaler.Ready, autoscaler.Desired))
+		}
+	}
+	if strings.Contains(r.Status, "Unhealthy") {
+		findings = append(findings, "cluster-autoscaler reports itself or a node group as unhealthy in its status")
+	}
+
+	reported := make(map[string]bool)
+	for _, event := range r.Events {
+		var finding string
+		switch {
+		case event.Reason == "NotTriggerScaleUp":
+			finding = fmt.Sprintf("%s did not trigger a scale-up: %s", event.Object, event.Message)
+		case slices.Contains(failedScaleUpReasons, event.Reason):
+			finding = fmt.Sprintf("scale-up failed (%s): %s", event.Reason, event.Message)
+		case event.Reason == "DisruptionBlocked":
+			finding = fmt.Sprintf("Karpenter cannot disrupt %s: %s", event.Object, event.Message)
+		}
+		if finding != "" && !reported[event.Object+event.Reason] {
+			reported[event.Object+event.Reason] = true
+			findings = append(findings, finding)
+		}
+	}
+
+	for _, pool := range r.NodePools {
+		if pool.Ready == "False" {
+			findings = append(findings, fmt.Sprintf("NodePool %s is not ready, so Karpenter does not launch nodes from it", pool.Name))
+		}
+	}
+	for _, claim := range r.NodeClaims {
+		finding := fmt.Sprintf("NodeClaim %s has %s", claim.Name, claim.Condition)
+		if claim.Reason != "" {
+			finding += " (" + claim.Reason + ")"
+		}
+		if claim.Message != "" {
+			finding += ": " + claim.Message
+		}
+		findings = append(findings, finding)
+	}
+	for _, node := range r.NotReadyNodes {
+		findings = append(findings, fmt.Sprintf("node %s is not ready", node))
+	}
+	return findings
+}
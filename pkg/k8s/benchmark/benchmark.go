@@ -0,0 +1,214 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kube-ai/pkg/k8s"
+)
+
+// Statuses of check results
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	// The check could not run, for lack of permission to read what it checks
+	StatusSkip = "skip"
+)
+
+// Severities of checks, most severe first
+const (
+	SeverityHigh   = "High"
+	SeverityMedium = "Medium"
+	SeverityLow    = "Low"
+)
+
+// SystemNamespaces are the namespaces of the Kubernetes control plane, whose workloads
+// legitimately need host access and are left out of workload checks by default
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// Result is the outcome of a benchmark check
+type Result struct {
+	// Number of the CIS Kubernetes Benchmark recommendation, such as 5.2.2
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Section  string `json:"section"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+	// Objects that fail the check, such as namespace/deployment/name (container)
+	Offenders []string `json:"offenders,omitempty"`
+	// Why the check was skipped
+	Reason string `json:"reason,omitempty"`
+}
+
+// Options control which objects the benchmark checks
+type Options struct {
+	// Check the workloads of the system namespaces too
+	IncludeSystem bool
+}
+
+// state is the cluster state the checks read. Lists that could not be read have an error.
+type state struct {
+	pods                []corev1.Pod
+	namespaces          []corev1.Namespace
+	serviceAccounts     []corev1.ServiceAccount
+	services            []corev1.Service
+	networkPolicies     []networkingv1.NetworkPolicy
+	roles               []rbacv1.Role
+	clusterRoles        []rbacv1.ClusterRole
+	roleBindings        []rbacv1.RoleBinding
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+
+	errors map[string]error
+}
+
+// Run reads the cluster's RBAC, workloads, service accounts and network policies and runs every
+// check against them. Checks whose resources cannot be read are skipped.
+func Run(ctx context.Context, client *k8s.Client, options Options) ([]Result, error) {
+	s, err := load(ctx, client, options)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		result := Result{ID: c.id, Title: c.title, Section: c.section, Severity: c.severity, Status: StatusPass}
+		for _, resource := range c.reads {
+			if err := s.errors[resource]; err != nil {
+				result.Status = StatusSkip
+				result.Reason = fmt.Sprintf("cannot list %s: %v", resource, err)
+				break
+			}
+		}
+		if result.Status == StatusSkip {
+			results = append(results, result)
+			continue
+		}
+
+		result.Offenders = c.run(s)
+		if len(result.Offenders) > 0 {
+			result.Status = StatusFail
+			sort.Strings(result.Offenders)
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return compareIDs(results[i].ID, results[j].ID) < 0
+	})
+	return results, nil
+}
+
+// load lists the resources the checks read, leaving out the workloads of system namespaces
+// unless options include them, and the pods opted out with the kube-ai.io/ignore annotation.
+// Permission errors are recorded so that checks can be skipped.
+func load(ctx context.Context, client *k8s.Client, options Options) (*state, error) {
+	clientset := client.GetClientset()
+	s := &state{errors: make(map[string]error)}
+	all := metav1.ListOptions{}
+
+	record := func(resource string, err error) error {
+		if err == nil {
+			return nil
+		}
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) {
+			s.errors[resource] = err
+			return nil
+		}
+		return fmt.Errorf("error listing %s: %w", resource, err)
+	}
+
+	// Lists are read a page at a time, and pods left out are never held
+	ignored := client.NewOptOutFilter(ctx, "")
+	err := k8s.EachCachedListItem(ctx, client, corev1.SchemeGroupVersion.WithResource("pods"), "", clientset.CoreV1().Pods("").List, all, func(pod *corev1.Pod) error {
+		if !ignored.IgnoresPod(*pod) && (options.IncludeSystem || !isSystemNamespace(pod.Namespace)) {
+			s.pods = append(s.pods, *pod)
+		}
+		return nil
+	})
+	if err := record("pods", err); err != nil {
+		return nil, err
+	}
+	if namespaces, err := k8s.ListAllCached[*corev1.NamespaceList, corev1.Namespace](ctx, client, corev1.SchemeGroupVersion.WithResource("namespaces"), "", clientset.CoreV1().Namespaces().List, all); err == nil {
+		s.namespaces = namespaces
+	} else if err := record("namespaces", err); err != nil {
+		return nil, err
+	}
+	if serviceAccounts, err := k8s.ListAllCached[*corev1.ServiceAccountList, corev1.ServiceAccount](ctx, client, corev1.SchemeGroupVersion.WithResource("serviceaccounts"), "", clientset.CoreV1().ServiceAccounts("").List, all); err == nil {
+		s.serviceAccounts = serviceAccounts
+	} else if err := record("serviceaccounts", err); err != nil {
+		return nil, err
+	}
+	if services, err := k8s.ListAllCached[*corev1.ServiceList, corev1.Service](ctx, client, corev1.SchemeGroupVersion.WithResource("services"), "", clientset.CoreV1().Services("").List, all); err == nil {
+		s.services = services
+	} else if err := record("services", err); err != nil {
+		return nil, err
+	}
+	if policies, err := k8s.ListAllCached[*networkingv1.NetworkPolicyList, networkingv1.NetworkPolicy](ctx, client, networkingv1.SchemeGroupVersion.WithResource("networkpolicies"), "", clientset.NetworkingV1().NetworkPolicies("").List, all); err == nil {
+		s.networkPolicies = policies
+	} else if err := record("networkpolicies", err); err != nil {
+		return nil, err
+	}
+	if roles, err := k8s.ListAllCached[*rbacv1.RoleList, rbacv1.Role](ctx, client, rbacv1.SchemeGroupVersion.WithResource("roles"), "", clientset.RbacV1().Roles("").List, all); err == nil {
+		s.roles = roles
+	} else if err := record("roles", err); err != nil {
+		return nil, err
+	}
+	if clusterRoles, err := k8s.ListAllCached[*rbacv1.ClusterRoleList, rbacv1.ClusterRole](ctx, client, rbacv1.SchemeGroupVersion.WithResource("clusterroles"), "", clientset.RbacV1().ClusterRoles().List, all); err == nil {
+		s.clusterRoles = clusterRoles
+	} else if err := record("clusterroles", err); err != nil {
+		return nil, err
+	}
+	if bindings, err := k8s.ListAllCached[*rbacv1.RoleBindingList, rbacv1.RoleBinding](ctx, client, rbacv1.SchemeGroupVersion.WithResource("rolebindings"), "", clientset.RbacV1().RoleBindings("").List, all); err == nil {
+		s.roleBindings = bindings
+	} else if err := record("rolebindings", err); err != nil {
+		return nil, err
+	}
+	if bindings, err := k8s.ListAllCached[*rbacv1.ClusterRoleBindingList, rbacv1.ClusterRoleBinding](ctx, client, rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), "", clientset.RbacV1().ClusterRoleBindings().List, all); err == nil {
+		s.clusterRoleBindings = bindings
+	} else if err := record("clusterrolebindings", err); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Summary counts results by status
+func Summary(results []Result) map[string]int {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// isSystemNamespace reports whether a namespace belongs to the control plane
+func isSystemNamespace(namespace string) bool {
+	for _, system := range SystemNamespaces {
+		if namespace == system {
+			return true
+		}
+	}
+	return false
+}
+
+// compareIDs orders recommendation numbers such as 5.1.10 numerically
+func compareIDs(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,458 @@
+package benchmark
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"kube-ai/pkg/k8s"
+)
+
+// Sections of the CIS Kubernetes Benchmark the checks belong to
+const (
+	sectionRBAC            = "RBAC and Service Accounts"
+	sectionPodSecurity     = "Pod Security Standards"
+	sectionNetworkPolicies = "Network Policies and CNI"
+	sectionSecrets         = "Secrets Management"
+	sectionGeneral         = "General Policies"
+)
+
+// defaultRoles are the user-facing roles Kubernetes ships; like system: roles, they are expected
+// to be broad
+var defaultRoles = map[string]bool{"cluster-admin": true, "admin": true, "edit": true, "view": true}
+
+// check is a benchmark check: the resources it reads and a function returning the objects that fail it
+type check struct {
+	id       string
+	title    string
+	section  string
+	severity string
+	reads    []string
+	run      func(s *state) []string
+}
+
+// checks are the CIS Kubernetes Benchmark recommendations that can be assessed from readable
+// cluster state. Control plane and node configuration (sections 1 to 4) need host access.
+var checks = []check{
+	{
+		id: "5.1.1", title: "Ensure that the cluster-admin role is only used where required",
+		section: sectionRBAC, severity: SeverityHigh, reads: []string{"clusterrolebindings", "rolebindings"},
+		run: func(s *state) []string {
+			var offenders []string
+			for _, binding := range s.clusterRoleBindings {
+				if binding.RoleRef.Name == "cluster-admin" {
+					offenders = append(offenders, bindingSubjects("clusterrolebinding/"+binding.Name, binding.Subjects, true)...)
+				}
+			}
+			for _, binding := range s.roleBindings {
+				if binding.RoleRef.Kind == "ClusterRole" && binding.RoleRef.Name == "cluster-admin" {
+					offenders = append(offenders, bindingSubjects(binding.Namespace+"/rolebinding/"+binding.Name, binding.Subjects, true)...)
+				}
+			}
+			return offenders
+		},
+	},
+	{
+		id: "5.1.2", title: "Minimize access to secrets",
+		section: sectionRBAC, severity: SeverityMedium, reads: []string{"roles", "clusterroles"},
+		run: func(s *state) []string {
+			return rolesGranting(s, func(rule rbacv1.PolicyRule) bool {
+				return matches(rule.APIGroups, "") && matches(rule.Resources, "secrets") &&
+					(matches(rule.Verbs, "get") || matches(rule.Verbs, "list") || matches(rule.Verbs, "watch"))
+			})
+		},
+	},
+	{
+		id: "5.1.3", title: "Minimize wildcard use in Roles and ClusterRoles",
+		section: sectionRBAC, severity: SeverityMedium, reads: []string{"roles", "clusterroles"},
+		run: func(s *state) []string {
+			return rolesGranting(s, func(rule rbacv1.PolicyRule) bool {
+				return slices.Contains(rule.APIGroups, "*") || slices.Contains(rule.Resources, "*") || slices.Contains(rule.Verbs, "*")
+			})
+		},
+	},
+	{
+		id: "5.1.4", title: "Minimize access to create pods",
+		section: sectionRBAC, severity: SeverityMedium, reads: []string{"roles", "clusterroles"},
+		run: func(s *state) []string {
+			return rolesGranting(s, func(rule rbacv1.PolicyRule) bool {
+				return matches(rule.APIGroups, "") && matches(rule.Resources, "pods") && matches(rule.Verbs, "create")
+			})
+		},
+	},
+	{
+		id: "5.1.5", title: "Ensure that default service accounts are not actively used",
+		section: sectionRBAC, severity: SeverityMedium, reads: []string{"serviceaccounts", "rolebindings", "clusterrolebindings"},
+		run: func(s *state) []string {
+			var offenders []string
+			for _, account := range s.serviceAccounts {
+				if account.Name == "default" && !isSystemNamespace(account.Namespace) &&
+					(account.AutomountServiceAccountToken == nil || *account.AutomountServiceAccountToken) {
+					offenders = append(offenders, account.Namespace+"/serviceaccount/default: automountServiceAccountToken is not false")
+				}
+			}
+			isDefault := func(subject rbacv1.Subject) bool {
+				return subject.Kind == rbacv1.ServiceAccountKind && subject.Name == "default" && !isSystemNamespace(subject.Namespace)
+			}
+			for _, binding := range s.roleBindings {
+				for _, subject := range binding.Subjects {
+					if isDefault(subject) {
+						offenders = append(offenders, fmt.Sprintf("%s/rolebinding/%s binds %s to %s/serviceaccount/default", binding.Namespace, binding.Name, binding.RoleRef.Name, subject.Namespace))
+					}
+				}
+			}
+			for _, binding := range s.clusterRoleBindings {
+				for _, subject := range binding.Subjects {
+					if isDefault(subject) {
+						offenders = append(offenders, fmt.Sprintf("clusterrolebinding/%s binds %s to %s/serviceaccount/default", binding.Name, binding.RoleRef.Name, subject.Namespace))
+					}
+				}
+			}
+			return offenders
+		},
+	},
+	{
+		id: "5.1.6", title: "Ensure that Service Account Tokens are only mounted where necessary",
+		section: sectionRBAC, severity: SeverityLow, reads: []string{"pods", "serviceaccounts"},
+		run: func(s *state) []string {
+			automount := make(map[string]bool)
+			for _, account := range s.serviceAccounts {
+				automount[account.Namespace+"/"+account.Name] = account.AutomountServiceAccountToken == nil || *account.AutomountServiceAccountToken
+			}
+			return workloadsWhere(s.pods, func(pod corev1.Pod) string {
+				if pod.Spec.AutomountServiceAccountToken != nil {
+					if *pod.Spec.AutomountServiceAccountToken {
+						return "automountServiceAccountToken: true"
+					}
+					return ""
+				}
+				account := pod.Spec.ServiceAccountName
+				if account == "" {
+					account = "default"
+				}
+				if mount, ok := automount[pod.Namespace+"/"+account]; !ok || mount {
+					return "token of " + account + " mounted"
+				}
+				return ""
+			})
+		},
+	},
+	{
+		id: "5.1.8", title: "Limit use of the Bind, Impersonate and Escalate permissions",
+		section: sectionRBAC, severity: SeverityHigh, reads: []string{"roles", "clusterroles"},
+		run: func(s *state) []string {
+			return rolesGranting(s, func(rule rbacv1.PolicyRule) bool {
+				return slices.Contains(rule.Verbs, "bind") || slices.Contains(rule.Verbs, "impersonate") || slices.Contains(rule.Verbs, "escalate")
+			})
+		},
+	},
+	{
+		id: "5.2.1", title: "Ensure that the cluster has at least one active policy control mechanism in place",
+		section: sectionPodSecurity, severity: SeverityMedium, reads: []string{"namespaces"},
+		run: func(s *state) []string {
+			var offenders []string
+			for _, namespace := range s.namespaces {
+				if isSystemNamespace(namespace.Name) {
+					continue
+				}
+				if namespace.Labels["pod-security.kubernetes.io/enforce"] == "" {
+					offenders = append(offenders, "namespace/"+namespace.Name+": no pod-security.kubernetes.io/enforce label")
+				}
+			}
+			return offenders
+		},
+	},
+	{
+		id: "5.2.2", title: "Minimize the admission of privileged containers",
+		section: sectionPodSecurity, severity: SeverityHigh, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				return container.SecurityContext != nil && container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged
+			})
+		},
+	},
+	{
+		id: "5.2.3", title: "Minimize the admission of containers wishing to share the host process ID namespace",
+		section: sectionPodSecurity, severity: SeverityHigh, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return workloadsWhere(s.pods, func(pod corev1.Pod) string { return when(pod.Spec.HostPID, "hostPID") })
+		},
+	},
+	{
+		id: "5.2.4", title: "Minimize the admission of containers wishing to share the host IPC namespace",
+		section: sectionPodSecurity, severity: SeverityHigh, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return workloadsWhere(s.pods, func(pod corev1.Pod) string { return when(pod.Spec.HostIPC, "hostIPC") })
+		},
+	},
+	{
+		id: "5.2.5", title: "Minimize the admission of containers wishing to share the host network namespace",
+		section: sectionPodSecurity, severity: SeverityMedium, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return workloadsWhere(s.pods, func(pod corev1.Pod) string { return when(pod.Spec.HostNetwork, "hostNetwork") })
+		},
+	},
+	{
+		id: "5.2.6", title: "Minimize the admission of containers with allowPrivilegeEscalation",
+		section: sectionPodSecurity, severity: SeverityMedium, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				context := container.SecurityContext
+				return context == nil || context.AllowPrivilegeEscalation == nil || *context.AllowPrivilegeEscalation
+			})
+		},
+	},
+	{
+		id: "5.2.7", title: "Minimize the admission of root containers",
+		section: sectionPodSecurity, severity: SeverityMedium, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				runAsNonRoot := pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.RunAsNonRoot != nil && *pod.Spec.SecurityContext.RunAsNonRoot
+				runAsUser := (*int64)(nil)
+				if pod.Spec.SecurityContext != nil {
+					runAsUser = pod.Spec.SecurityContext.RunAsUser
+				}
+				if context := container.SecurityContext; context != nil {
+					if context.RunAsNonRoot != nil {
+						runAsNonRoot = *context.RunAsNonRoot
+					}
+					if context.RunAsUser != nil {
+						runAsUser = context.RunAsUser
+					}
+				}
+				if runAsUser != nil {
+					return *runAsUser == 0
+				}
+				return !runAsNonRoot
+			})
+		},
+	},
+	{
+		id: "5.2.8", title: "Minimize the admission of containers with the NET_RAW capability",
+		section: sectionPodSecurity, severity: SeverityLow, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				if container.SecurityContext == nil || container.SecurityContext.Capabilities == nil {
+					return true
+				}
+				for _, capability := range container.SecurityContext.Capabilities.Drop {
+					if capability == "ALL" || capability == "NET_RAW" {
+						return false
+					}
+				}
+				return true
+			})
+		},
+	},
+	{
+		id: "5.2.9", title: "Minimize the admission of containers with added capabilities",
+		section: sectionPodSecurity, severity: SeverityMedium, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				return container.SecurityContext != nil && container.SecurityContext.Capabilities != nil &&
+					len(container.SecurityContext.Capabilities.Add) > 0
+			})
+		},
+	},
+	{
+		id: "5.2.12", title: "Minimize the admission of HostPath volumes",
+		section: sectionPodSecurity, severity: SeverityHigh, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return workloadsWhere(s.pods, func(pod corev1.Pod) string {
+				var paths []string
+				for _, volume := range pod.Spec.Volumes {
+					if volume.HostPath != nil {
+						paths = append(paths, volume.HostPath.Path)
+					}
+				}
+				if len(paths) == 0 {
+					return ""
+				}
+				return "hostPath " + strings.Join(paths, ", ")
+			})
+		},
+	},
+	{
+		id: "5.2.13", title: "Minimize the admission of containers which use HostPorts",
+		section: sectionPodSecurity, severity: SeverityLow, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				for _, port := range container.Ports {
+					if port.HostPort != 0 {
+						return true
+					}
+				}
+				return false
+			})
+		},
+	},
+	{
+		id: "5.3.2", title: "Ensure that all Namespaces have NetworkPolicies defined",
+		section: sectionNetworkPolicies, severity: SeverityMedium, reads: []string{"namespaces", "networkpolicies"},
+		run: func(s *state) []string {
+			covered := make(map[string]bool)
+			for _, policy := range s.networkPolicies {
+				covered[policy.Namespace] = true
+			}
+			var offenders []string
+			for _, namespace := range s.namespaces {
+				if !isSystemNamespace(namespace.Name) && !covered[namespace.Name] {
+					offenders = append(offenders, "namespace/"+namespace.Name)
+				}
+			}
+			return offenders
+		},
+	},
+	{
+		id: "5.4.1", title: "Prefer using Secrets as files over Secrets as environment variables",
+		section: sectionSecrets, severity: SeverityLow, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				for _, env := range container.Env {
+					if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+						return true
+					}
+				}
+				for _, source := range container.EnvFrom {
+					if source.SecretRef != nil {
+						return true
+					}
+				}
+				return false
+			})
+		},
+	},
+	{
+		id: "5.7.2", title: "Ensure that the seccomp profile is set to RuntimeDefault in Pod definitions",
+		section: sectionGeneral, severity: SeverityLow, reads: []string{"pods"},
+		run: func(s *state) []string {
+			return containersWhere(s.pods, func(pod corev1.Pod, container corev1.Container) bool {
+				profile := (*corev1.SeccompProfile)(nil)
+				if pod.Spec.SecurityContext != nil {
+					profile = pod.Spec.SecurityContext.SeccompProfile
+				}
+				if container.SecurityContext != nil && container.SecurityContext.SeccompProfile != nil {
+					profile = container.SecurityContext.SeccompProfile
+				}
+				return profile == nil || profile.Type == corev1.SeccompProfileTypeUnconfined
+			})
+		},
+	},
+	{
+		id: "5.7.4", title: "The default namespace should not be used",
+		section: sectionGeneral, severity: SeverityLow, reads: []string{"pods", "services"},
+		run: func(s *state) []string {
+			var offenders []string
+			for _, pod := range s.pods {
+				if pod.Namespace == "default" {
+					kind, name := k8s.PodWorkload(pod)
+					offenders = k8s.AppendUnique(offenders, "default/"+kind+"/"+name)
+				}
+			}
+			for _, service := range s.services {
+				// The API server's own service always lives in default
+				if service.Namespace == "default" && service.Name != "kubernetes" {
+					offenders = append(offenders, "default/service/"+service.Name)
+				}
+			}
+			return offenders
+		},
+	},
+}
+
+// rolesGranting returns the Roles and ClusterRoles with a rule the predicate matches, leaving
+// out the system: roles and the default user-facing roles
+func rolesGranting(s *state, predicate func(rbacv1.PolicyRule) bool) []string {
+	var offenders []string
+	for _, role := range s.clusterRoles {
+		if strings.HasPrefix(role.Name, "system:") || defaultRoles[role.Name] || isAggregated(role) {
+			continue
+		}
+		for _, rule := range role.Rules {
+			if predicate(rule) {
+				offenders = append(offenders, "clusterrole/"+role.Name)
+				break
+			}
+		}
+	}
+	for _, role := range s.roles {
+		if strings.HasPrefix(role.Name, "system:") || isSystemNamespace(role.Namespace) {
+			continue
+		}
+		for _, rule := range role.Rules {
+			if predicate(rule) {
+				offenders = append(offenders, role.Namespace+"/role/"+role.Name)
+				break
+			}
+		}
+	}
+	return offenders
+}
+
+// isAggregated reports whether a ClusterRole's rules are aggregated from other roles, which are
+// then checked themselves
+func isAggregated(role rbacv1.ClusterRole) bool {
+	return role.AggregationRule != nil && len(role.AggregationRule.ClusterRoleSelectors) > 0
+}
+
+// bindingSubjects describes the subjects of a binding, leaving out the system:masters group when
+// skipMasters is set
+func bindingSubjects(binding string, subjects []rbacv1.Subject, skipMasters bool) []string {
+	var offenders []string
+	for _, subject := range subjects {
+		if skipMasters && subject.Kind == rbacv1.GroupKind && subject.Name == "system:masters" {
+			continue
+		}
+		name := subject.Name
+		if subject.Namespace != "" {
+			name = subject.Namespace + "/" + name
+		}
+		offenders = append(offenders, fmt.Sprintf("%s: %s %s", binding, strings.ToLower(subject.Kind), name))
+	}
+	return offenders
+}
+
+// workloadsWhere returns the workloads of the pods for which problem returns a description
+func workloadsWhere(pods []corev1.Pod, problem func(corev1.Pod) string) []string {
+	var offenders []string
+	for _, pod := range pods {
+		if description := problem(pod); description != "" {
+			kind, name := k8s.PodWorkload(pod)
+			offenders = k8s.AppendUnique(offenders, fmt.Sprintf("%s/%s/%s: %s", pod.Namespace, kind, name, description))
+		}
+	}
+	return offenders
+}
+
+// containersWhere returns the containers, init containers included, of the pods' workloads that
+// the predicate matches
+func containersWhere(pods []corev1.Pod, predicate func(corev1.Pod, corev1.Container) bool) []string {
+	var offenders []string
+	for _, pod := range pods {
+		kind, name := k8s.PodWorkload(pod)
+		for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+			for _, container := range containers {
+				if predicate(pod, container) {
+					offenders = k8s.AppendUnique(offenders, fmt.Sprintf("%s/%s/%s (%s)", pod.Namespace, kind, name, container.Name))
+				}
+			}
+		}
+	}
+	return offenders
+}
+
+// matches reports whether an RBAC rule list contains the value or the wildcard
+func matches(values []string, value string) bool {
+	return slices.Contains(values, value) || slices.Contains(values, "*")
+}
+
+// when returns the description if the condition holds, else ""
+func when(condition bool, description string) string {
+	if condition {
+		return description
+	}
+	return ""
+}
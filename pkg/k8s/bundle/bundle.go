@@ -0,0 +1,319 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/logs"
+	"kube-ai/pkg/version"
+)
+
+// Layout of files inside a bundle archive
+const (
+	metadataFile = "metadata.json"
+	eventsFile   = "events.json"
+	metricsFile  = "metrics.json"
+	manifestsDir = "manifests/"
+	logsDir      = "logs/"
+)
+
+// Metadata describes how and from where a bundle was collected
+type Metadata struct {
+	// Kind of the workload (deployment, statefulset, pod, ...)
+	ResourceType string `json:"resourceType"`
+	// Name of the workload
+	ResourceName string `json:"resourceName"`
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+	// Time the bundle was created
+	CreatedAt time.Time `json:"createdAt"`
+	// Version of kube-ai that created the bundle
+	KubeAIVersion string `json:"kubeAiVersion"`
+	// Data sources that could not be collected
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Bundle is an offline snapshot of everything needed to analyze a workload
+type Bundle struct {
+	// Metadata about the collection
+	Metadata Metadata
+	// Manifests keyed by file name (e.g. deployment-checkout.yaml)
+	Manifests map[string]string
+	// Raw log text keyed by "<pod>/<container>"
+	Logs map[string]string
+	// Events related to the workload and its pods
+	Events []corev1.Event
+	// Live resource usage at collection time
+	Metrics []k8s.PodMetrics
+}
+
+// CreateOptions controls what is collected into a bundle
+type CreateOptions struct {
+	// Workload kind
+	ResourceType string
+	// Workload name
+	ResourceName string
+	// Namespace of the workload
+	Namespace string
+	// Number of log lines per container
+	TailLines int64
+	// Include logs of previously terminated containers
+	Previous bool
+}
+
+// Collect gathers manifests, logs, events, and metrics for a workload
+func Collect(ctx context.Context, client *k8s.Client, options CreateOptions) (*Bundle, error) {
+	b := &Bundle{
+		Metadata: Metadata{
+			ResourceType:  options.ResourceType,
+			ResourceName:  options.ResourceName,
+			Namespace:     options.Namespace,
+			CreatedAt:     time.Now().UTC(),
+			KubeAIVersion: version.Version,
+		},
+		Manifests: make(map[string]string),
+		Logs:      make(map[string]string),
+	}
+
+	workloadYAML, err := client.GetResourceYAML(ctx, options.ResourceType, options.ResourceName, options.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	b.Manifests[fmt.Sprintf("%s-%s.yaml", strings.ToLower(options.ResourceType), options.ResourceName)] = workloadYAML
+
+	pods, _, err := client.GetWorkloadPods(ctx, options.ResourceType, options.ResourceName, options.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := logs.NewLogCollector(client.GetClientset())
+	objectNames := map[string]bool{options.ResourceName: true}
+
+	for _, pod := range pods {
+		objectNames[pod.Name] = true
+
+		podYAML, err := k8s.ObjectToYAML(&pod, "v1", "Pod")
+		if err != nil {
+			b.warn("manifest for pod %s: %v", pod.Name, err)
+		} else {
+			b.Manifests[fmt.Sprintf("pod-%s.yaml", pod.Name)] = podYAML
+		}
+
+		for _, container := range pod.Spec.Containers {
+			tailLines := options.TailLines
+			entries, err := collector.GetPodLogs(ctx, logs.LogOptions{
+				ResourceType: "pod",
+				ResourceName: pod.Name,
+				Namespace:    options.Namespace,
+				Container:    container.Name,
+				TailLines:    &tailLines,
+				Previous:     options.Previous,
+				Timestamps:   true,
+			})
+			if err != nil {
+				b.warn("logs for %s/%s: %v", pod.Name, container.Name, err)
+				continue
+			}
+
+			// Store lines in the API server's timestamped format so they can be re-parsed offline
+			var sb strings.Builder
+			for _, entry := range entries {
+				sb.WriteString(entry.Timestamp.Format(time.RFC3339Nano))
+				sb.WriteString(" ")
+				sb.WriteString(entry.Content)
+				sb.WriteString("\n")
+			}
+			b.Logs[pod.Name+"/"+container.Name] = sb.String()
+		}
+	}
+
+	events, err := client.ListEvents(ctx, options.Namespace, "")
+	if err != nil {
+		b.warn("events: %v", err)
+	} else {
+		for _, event := range events {
+			if objectNames[event.InvolvedObject.Name] || strings.HasPrefix(event.InvolvedObject.Name, options.ResourceName) {
+				b.Events = append(b.Events, event)
+			}
+		}
+	}
+
+	metrics, err := client.GetPodMetrics(ctx, options.Namespace)
+	if err != nil {
+		b.warn("metrics: %v", err)
+	} else {
+		for _, m := range metrics {
+			if objectNames[m.Name] {
+				b.Metrics = append(b.Metrics, m)
+			}
+		}
+	}
+
+	return b, nil
+}
+
+// warn records a data source that could not be collected
+func (b *Bundle) warn(format string, args ...interface{}) {
+	b.Metadata.Warnings = append(b.Metadata.Warnings, fmt.Sprintf(format, args...))
+}
+
+// LogEntries parses all bundled logs into structured entries
+func (b *Bundle) LogEntries() []logs.LogEntry {
+	keys := make([]string, 0, len(b.Logs))
+	for key := range b.Logs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []logs.LogEntry
+	for _, key := range keys {
+		podName, containerName, _ := strings.Cut(key, "/")
+		entries = append(entries, logs.ParseLogText(b.Logs[key], podName, containerName)...)
+	}
+
+	// Merge the streams chronologically so cross-pod causality is visible
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries
+}
+
+// Write stores the bundle as a gzip-compressed tar archive
+func (b *Bundle) Write(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating bundle file: %w", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	addJSON := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding %s: %w", name, err)
+		}
+		return addFile(tw, name, data, b.Metadata.CreatedAt)
+	}
+
+	if err := addJSON(metadataFile, b.Metadata); err != nil {
+		return err
+	}
+	if err := addJSON(eventsFile, b.Events); err != nil {
+		return err
+	}
+	if err := addJSON(metricsFile, b.Metrics); err != nil {
+		return err
+	}
+	for name, content := range b.Manifests {
+		if err := addFile(tw, manifestsDir+name, []byte(content), b.Metadata.CreatedAt); err != nil {
+			return err
+		}
+	}
+	for key, content := range b.Logs {
+		name := logsDir + strings.ReplaceAll(key, "/", "_") + ".log"
+		if err := addFile(tw, name, []byte(content), b.Metadata.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("error finalizing bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("error finalizing bundle: %w", err)
+	}
+
+	return nil
+}
+
+// addFile writes a single file entry to the archive
+func addFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("error writing %s to bundle: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("error writing %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// Read loads a bundle previously written with Write
+func Read(filename string) (*Bundle, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening bundle: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle (not a tar.gz file?): %w", err)
+	}
+	defer gz.Close()
+
+	b := &Bundle{
+		Manifests: make(map[string]string),
+		Logs:      make(map[string]string),
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading bundle: %w", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s from bundle: %w", header.Name, err)
+		}
+
+		switch {
+		case header.Name == metadataFile:
+			err = json.Unmarshal(data, &b.Metadata)
+		case header.Name == eventsFile:
+			err = json.Unmarshal(data, &b.Events)
+		case header.Name == metricsFile:
+			err = json.Unmarshal(data, &b.Metrics)
+		case strings.HasPrefix(header.Name, manifestsDir):
+			b.Manifests[path.Base(header.Name)] = string(data)
+		case strings.HasPrefix(header.Name, logsDir):
+			key := strings.TrimSuffix(path.Base(header.Name), ".log")
+			// Pod names cannot contain underscores, so the first one separates the container
+			key = strings.Replace(key, "_", "/", 1)
+			b.Logs[key] = string(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s from bundle: %w", header.Name, err)
+		}
+	}
+
+	if b.Metadata.ResourceName == "" {
+		return nil, fmt.Errorf("bundle is missing %s", metadataFile)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,324 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+// discoveryCacheTTL is how long the APIs discovered in a cluster are reused. Discovery has no
+// resource versions to compare, so like kubectl's cache it simply expires.
+const discoveryCacheTTL = 6 * time.Hour
+
+// maxRefetched is the number of objects changed since a list was cached that are fetched one by
+// one; when more changed, the whole collection is listed again
+const maxRefetched = 20
+
+// DefaultCacheDir returns the default directory of the cluster cache (~/.kube-ai/cache)
+func DefaultCacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube-ai", "cache"), nil
+}
+
+// ClearCache removes everything cached in a cache directory
+func ClearCache(dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// clusterCache keeps the lists and discovered APIs of one cluster, as one identity sees them,
+// between kube-ai runs
+type clusterCache struct {
+	dir      string
+	metadata metadata.Interface
+}
+
+// newClusterCache creates the cache of the cluster and identity of a REST configuration. Each
+// gets its own directory so that a user never reads objects listed with other permissions.
+func newClusterCache(baseDir string, restConfig *rest.Config, config ClientConfig) (*clusterCache, error) {
+	metadataClient, err := metadata.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	identity := strings.Join(append([]string{
+		restConfig.Host, config.Context, config.User, restConfig.Username, restConfig.BearerToken,
+		restConfig.CertFile, config.Impersonate,
+	}, config.ImpersonateGroups...), "\x00")
+	return &clusterCache{dir: filepath.Join(baseDir, hashKey(identity)), metadata: metadataClient}, nil
+}
+
+// hashKey returns a short, file-name safe hash of a cache key
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:12])
+}
+
+// restMapper returns a mapper serving kinds from the APIs discovered by a previous run, falling
+// back to discovering them live for kinds installed since
+func (cc *clusterCache) restMapper(client discovery.DiscoveryInterface) meta.RESTMapper {
+	live := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client))
+	path := filepath.Join(cc.dir, "discovery.json")
+
+	var groups []*restmapper.APIGroupResources
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < discoveryCacheTTL {
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &groups) != nil {
+			groups = nil
+		}
+	}
+	if groups == nil {
+		discovered, err := restmapper.GetAPIGroupResources(client)
+		if err != nil {
+			return live
+		}
+		groups = discovered
+		if data, err := json.Marshal(groups); err == nil {
+			_ = writeFileAtomic(path, data)
+		}
+	}
+	return meta.FirstHitRESTMapper{MultiRESTMapper: meta.MultiRESTMapper{restmapper.NewDiscoveryRESTMapper(groups), live}}
+}
+
+// cachedList is the index of a cached list: the resource version of each object it holds, by
+// namespace/name
+type cachedList struct {
+	Versions map[string]string `json:"versions"`
+}
+
+// EachCachedListItem works like EachListItem, but reuses the objects a previous run cached for the
+// same collection. A list of the objects' metadata only tells which were added, changed or deleted
+// since, from their resource versions, and only those are fetched; the cache is then updated.
+// Clients without a cache, such as those created with NewClientForClientset, and secrets, whose
+// data must not be written to disk, are listed directly.
+func EachCachedListItem[L runtime.Object, T any](ctx context.Context, c *Client, resource schema.GroupVersionResource, namespace string, list ListFunc[L], options metav1.ListOptions, fn func(*T) error) error {
+	if c.cache == nil || resource.Resource == "secrets" {
+		return EachListItem(ctx, list, options, fn)
+	}
+
+	// Without the metadata API the cache cannot be checked
+	current := make(map[string]string)
+	metadataList := c.cache.metadata.Resource(resource).Namespace(namespace).List
+	err := EachListItem(ctx, metadataList, options, func(item *metav1.PartialObjectMetadata) error {
+		current[item.Namespace+"/"+item.Name] = item.ResourceVersion
+		return nil
+	})
+	if err != nil {
+		return EachListItem(ctx, list, options, fn)
+	}
+
+	base := filepath.Join(c.cache.dir, resource.GroupResource().String(), hashKey(namespace+"\x00"+options.LabelSelector+"\x00"+options.FieldSelector))
+	writer := newListWriter(base)
+	defer writer.abort()
+	deliver := func(item *T) error {
+		writer.add(item)
+		return fn(item)
+	}
+
+	var index cachedList
+	if data, err := os.ReadFile(base + ".index.json"); err == nil {
+		_ = json.Unmarshal(data, &index)
+	}
+	stale := 0
+	for key, version := range current {
+		if index.Versions[key] != version {
+			stale++
+		}
+	}
+	file, err := os.Open(base + ".jsonl")
+	if err != nil || index.Versions == nil || stale > maxRefetched {
+		if file != nil {
+			file.Close()
+		}
+		if err := EachListItem(ctx, list, options, deliver); err != nil {
+			return err
+		}
+		writer.commit()
+		return nil
+	}
+
+	// Reuse the cached objects that are unchanged. Each object is delivered once, even if a
+	// server ignores the field selectors used to fetch the others.
+	delivered := make(map[string]bool)
+	deliverOnce := func(item *T) error {
+		object, ok := any(item).(metav1.Object)
+		if !ok {
+			return fmt.Errorf("cannot cache %T", item)
+		}
+		key := object.GetNamespace() + "/" + object.GetName()
+		if delivered[key] {
+			return nil
+		}
+		delivered[key] = true
+		return deliver(item)
+	}
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for {
+		item := new(T)
+		if decoder.Decode(item) != nil {
+			break
+		}
+		object, ok := any(item).(metav1.Object)
+		if !ok {
+			break
+		}
+		if current[object.GetNamespace()+"/"+object.GetName()] != object.GetResourceVersion() {
+			continue
+		}
+		if err := deliverOnce(item); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	file.Close()
+
+	// Fetch the others one by one, or list the collection again when the cache fell short
+	var missing []string
+	for key := range current {
+		if !delivered[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > maxRefetched {
+		if err := EachListItem(ctx, list, options, deliverOnce); err != nil {
+			return err
+		}
+		writer.commit()
+		return nil
+	}
+	for _, key := range missing {
+		objectNamespace, name, _ := strings.Cut(key, "/")
+		selector := "metadata.name=" + name
+		if objectNamespace != "" {
+			selector += ",metadata.namespace=" + objectNamespace
+		}
+		if options.FieldSelector != "" {
+			selector += "," + options.FieldSelector
+		}
+		one := options
+		one.FieldSelector = selector
+		if err := EachListItem(ctx, list, one, deliverOnce); err != nil {
+			return err
+		}
+	}
+	writer.commit()
+	return nil
+}
+
+// ListAllCached returns every item of a collection like ListAll, reusing the objects cached by a
+// previous run like EachCachedListItem
+func ListAllCached[L runtime.Object, T any](ctx context.Context, c *Client, resource schema.GroupVersionResource, namespace string, list ListFunc[L], options metav1.ListOptions) ([]T, error) {
+	var items []T
+	err := EachCachedListItem(ctx, c, resource, namespace, list, options, func(item *T) error {
+		items = append(items, *item)
+		return nil
+	})
+	return items, err
+}
+
+// listWriter writes the objects of a list to the cache as they are delivered, one JSON document
+// per line, and replaces the cached list once the whole collection was read. Caching is best
+// effort: a list that cannot be written is simply not cached.
+type listWriter struct {
+	base    string
+	file    *os.File
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	index   cachedList
+	err     error
+}
+
+// newListWriter starts writing a cached list next to base
+func newListWriter(base string) *listWriter {
+	w := &listWriter{base: base, index: cachedList{Versions: make(map[string]string)}}
+	if w.err = os.MkdirAll(filepath.Dir(base), 0700); w.err != nil {
+		return w
+	}
+	w.file, w.err = os.CreateTemp(filepath.Dir(base), filepath.Base(base)+".*.tmp")
+	if w.err != nil {
+		return w
+	}
+	w.buffer = bufio.NewWriter(w.file)
+	w.encoder = json.NewEncoder(w.buffer)
+	return w
+}
+
+// add writes an object to the list
+func (w *listWriter) add(item interface{}) {
+	if w.err != nil {
+		return
+	}
+	object, ok := item.(metav1.Object)
+	if !ok {
+		w.err = fmt.Errorf("cannot cache %T", item)
+		return
+	}
+	w.index.Versions[object.GetNamespace()+"/"+object.GetName()] = object.GetResourceVersion()
+	w.err = w.encoder.Encode(item)
+}
+
+// commit replaces the cached list with the one written. The index is replaced last, so a list
+// read with an older index only has objects fetched again.
+func (w *listWriter) commit() {
+	if w.err != nil || w.file == nil {
+		return
+	}
+	flushErr := w.buffer.Flush()
+	closeErr := w.file.Close()
+	tmp := w.file.Name()
+	w.file = nil
+	if errors.Join(flushErr, closeErr) != nil || os.Rename(tmp, w.base+".jsonl") != nil {
+		os.Remove(tmp)
+		return
+	}
+	if data, err := json.Marshal(w.index); err == nil {
+		_ = writeFileAtomic(w.base+".index.json", data)
+	}
+}
+
+// abort discards a list that was not committed
+func (w *listWriter) abort() {
+	if w.file == nil {
+		return
+	}
+	w.file.Close()
+	os.Remove(w.file.Name())
+	w.file = nil
+}
+
+// writeFileAtomic writes a cache file through a temporary file, so that concurrent runs never
+// read a partial one
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, writeErr := file.Write(data)
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,447 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodePoolLabels identify the node pool of a node on common platforms, in order of preference
+var nodePoolLabels = []string{
+	"karpenter.sh/nodepool",
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"node.kubernetes.io/instance-type",
+}
+
+// autoscalerReasons are event reasons of the cluster autoscaler and Karpenter
+var autoscalerReasons = map[string]bool{
+	"TriggeredScaleUp":          true,
+	"NotTriggerScaleUp":         true,
+	"FailedToScaleUpGroup":      true,
+	"ScaleDown":                 true,
+	"ScaleDownFailed":           true,
+	"ScaleDownEmpty":            true,
+	"Nominated":                 true,
+	"DisruptionBlocked":         true,
+	"InsufficientCapacityError": true,
+}
+
+// NodeCapacity summarizes the resources of a node. CPU is in millicores and memory in bytes.
+type NodeCapacity struct {
+	Name string `json:"name"`
+	// Node pool, from the pool label of the platform or autoscaler, else the instance type
+	Pool          string `json:"pool"`
+	InstanceType  string `json:"instanceType,omitempty"`
+	Ready         bool   `json:"ready"`
+	Unschedulable bool   `json:"unschedulable"`
+
+	AllocatableCPU    int64 `json:"allocatableCPU"`
+	AllocatableMemory int64 `json:"allocatableMemory"`
+	AllocatablePods   int64 `json:"allocatablePods"`
+
+	// Sums of the requests of the pods running on the node
+	RequestedCPU    int64 `json:"requestedCPU"`
+	RequestedMemory int64 `json:"requestedMemory"`
+	Pods            int64 `json:"pods"`
+
+	// Usage from the metrics API, or -1 when it is not available
+	UsedCPU    int64 `json:"usedCPU"`
+	UsedMemory int64 `json:"usedMemory"`
+}
+
+// FreeCPU returns the allocatable CPU not requested by pods
+func (n NodeCapacity) FreeCPU() int64 {
+	return n.AllocatableCPU - n.RequestedCPU
+}
+
+// FreeMemory returns the allocatable memory not requested by pods
+func (n NodeCapacity) FreeMemory() int64 {
+	return n.AllocatableMemory - n.RequestedMemory
+}
+
+// PoolCapacity sums the capacity of the nodes of a node pool
+type PoolCapacity struct {
+	Name string `json:"name"`
+	// Number of nodes, and of those the ones that are ready and schedulable
+	Nodes       int `json:"nodes"`
+	Schedulable int `json:"schedulable"`
+	NodeCapacity
+}
+
+// PendingPod is a pod waiting to be scheduled
+type PendingPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Scheduler message, such as 0/3 nodes are available: 3 Insufficient cpu
+	Reason string `json:"reason,omitempty"`
+	CPU    int64  `json:"cpu"`
+	Memory int64  `json:"memory"`
+}
+
+// WorkloadRequests are the per-pod requests of a workload
+type WorkloadRequests struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Number of running or pending pods
+	Pods int `json:"pods"`
+	// Requests of its largest pod
+	CPU    int64 `json:"cpu"`
+	Memory int64 `json:"memory"`
+}
+
+// AutoscalerEvent is an event of the cluster autoscaler or Karpenter
+type AutoscalerEvent struct {
+	Time    time.Time `json:"time"`
+	Reason  string    `json:"reason,omitempty"`
+	Object  string    `json:"object"`
+	Message string    `json:"message,omitempty"`
+}
+
+// ClusterCapacity is the capacity of the cluster's nodes and the demand on them
+type ClusterCapacity struct {
+	Nodes []NodeCapacity `json:"nodes"`
+	// Pods waiting to be scheduled
+	Pending []PendingPod `json:"pending,omitempty"`
+	// Workloads with the largest pods, by requests
+	LargestWorkloads []WorkloadRequests `json:"largestWorkloads"`
+	AutoscalerEvents []AutoscalerEvent  `json:"autoscalerEvents,omitempty"`
+	// Status reported by the cluster autoscaler, if it runs
+	AutoscalerStatus string `json:"autoscalerStatus,omitempty"`
+	// Whether node usage is available from the metrics API
+	MetricsAvailable bool `json:"metricsAvailable"`
+}
+
+// GetClusterCapacity collects node allocatable resources, pod requests and usage, pending pods,
+// the largest workloads and autoscaler activity across the cluster
+func (c *Client) GetClusterCapacity(ctx context.Context, largest int) (*ClusterCapacity, error) {
+	capacity := &ClusterCapacity{}
+	byName := make(map[string]int)
+	err := EachListItem(ctx, c.clientset.CoreV1().Nodes().List, metav1.ListOptions{}, func(node *corev1.Node) error {
+		allocatable := node.Status.Allocatable
+		byName[node.Name] = len(capacity.Nodes)
+		capacity.Nodes = append(capacity.Nodes, NodeCapacity{
+			Name:              node.Name,
+			Pool:              nodePool(*node),
+			InstanceType:      node.Labels["node.kubernetes.io/instance-type"],
+			Ready:             nodeReady(*node),
+			Unschedulable:     node.Spec.Unschedulable,
+			AllocatableCPU:    allocatable.Cpu().MilliValue(),
+			AllocatableMemory: allocatable.Memory().Value(),
+			AllocatablePods:   allocatable.Pods().Value(),
+			UsedCPU:           -1,
+			UsedMemory:        -1,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+
+	workloads := make(map[string]*WorkloadRequests)
+	// Pods are summed up a page at a time rather than all held in memory
+	err = EachCachedListItem(ctx, c, corev1.SchemeGroupVersion.WithResource("pods"), "", c.clientset.CoreV1().Pods("").List, metav1.ListOptions{}, func(pod *corev1.Pod) error {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			return nil
+		}
+		cpu, memory := podRequests(*pod)
+
+		if i, ok := byName[pod.Spec.NodeName]; ok {
+			capacity.Nodes[i].RequestedCPU += cpu
+			capacity.Nodes[i].RequestedMemory += memory
+			capacity.Nodes[i].Pods++
+		} else if pod.Spec.NodeName == "" {
+			capacity.Pending = append(capacity.Pending, PendingPod{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Reason:    schedulingMessage(*pod),
+				CPU:       cpu,
+				Memory:    memory,
+			})
+		}
+
+		kind, name := PodWorkload(*pod)
+		key := pod.Namespace + "/" + kind + "/" + name
+		workload, ok := workloads[key]
+		if !ok {
+			workload = &WorkloadRequests{Kind: kind, Namespace: pod.Namespace, Name: name}
+			workloads[key] = workload
+		}
+		workload.Pods++
+		if cpu > workload.CPU {
+			workload.CPU = cpu
+		}
+		if memory > workload.Memory {
+			workload.Memory = memory
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+
+	for _, workload := range workloads {
+		capacity.LargestWorkloads = append(capacity.LargestWorkloads, *workload)
+	}
+	// Rank by the larger of the CPU and memory share of a typical node
+	cpuUnit, memoryUnit := capacity.typicalNode()
+	sort.Slice(capacity.LargestWorkloads, func(i, j int) bool {
+		return workloadSize(capacity.LargestWorkloads[i], cpuUnit, memoryUnit) > workloadSize(capacity.LargestWorkloads[j], cpuUnit, memoryUnit)
+	})
+	if len(capacity.LargestWorkloads) > largest {
+		capacity.LargestWorkloads = capacity.LargestWorkloads[:largest]
+	}
+
+	if usage, err := c.nodeUsage(ctx); err == nil {
+		capacity.MetricsAvailable = true
+		for name, used := range usage {
+			if i, ok := byName[name]; ok {
+				capacity.Nodes[i].UsedCPU = used[0]
+				capacity.Nodes[i].UsedMemory = used[1]
+			}
+		}
+	}
+
+	// Autoscaler activity is optional context; clusters without an autoscaler have none
+	err = EachListItem(ctx, c.clientset.CoreV1().Events("").List, metav1.ListOptions{}, func(event *corev1.Event) error {
+		component := strings.ToLower(event.Source.Component + " " + event.ReportingController)
+		if !autoscalerReasons[event.Reason] && !strings.Contains(component, "autoscaler") && !strings.Contains(component, "karpenter") {
+			return nil
+		}
+		capacity.AutoscalerEvents = append(capacity.AutoscalerEvents, AutoscalerEvent{
+			Time:    eventTime(*event),
+			Reason:  event.Reason,
+			Object:  strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name,
+			Message: event.Message,
+		})
+		return nil
+	})
+	if err != nil {
+		capacity.AutoscalerEvents = nil
+		SkipSource(SourceEvents, err)
+	} else {
+		sort.Slice(capacity.AutoscalerEvents, func(i, j int) bool {
+			return capacity.AutoscalerEvents[i].Time.After(capacity.AutoscalerEvents[j].Time)
+		})
+	}
+	if status, err := c.clientset.CoreV1().ConfigMaps("kube-system").Get(ctx, "cluster-autoscaler-status", metav1.GetOptions{}); err == nil {
+		capacity.AutoscalerStatus = status.Data["status"]
+	}
+
+	return capacity, nil
+}
+
+// Pools sums the nodes by node pool, sorted by name
+func (c *ClusterCapacity) Pools() []PoolCapacity {
+	byName := make(map[string]*PoolCapacity)
+	var names []string
+	for _, node := range c.Nodes {
+		pool, ok := byName[node.Pool]
+		if !ok {
+			pool = &PoolCapacity{Name: node.Pool, NodeCapacity: NodeCapacity{Name: node.Pool, Pool: node.Pool, InstanceType: node.InstanceType}}
+			byName[node.Pool] = pool
+			names = append(names, node.Pool)
+		}
+		pool.Nodes++
+		if node.Ready && !node.Unschedulable {
+			pool.Schedulable++
+		}
+		pool.AllocatableCPU += node.AllocatableCPU
+		pool.AllocatableMemory += node.AllocatableMemory
+		pool.AllocatablePods += node.AllocatablePods
+		pool.RequestedCPU += node.RequestedCPU
+		pool.RequestedMemory += node.RequestedMemory
+		pool.Pods += node.Pods
+		if node.UsedCPU >= 0 && pool.UsedCPU >= 0 {
+			pool.UsedCPU += node.UsedCPU
+			pool.UsedMemory += node.UsedMemory
+		} else {
+			pool.UsedCPU, pool.UsedMemory = -1, -1
+		}
+		if pool.InstanceType != node.InstanceType {
+			pool.InstanceType = "mixed"
+		}
+	}
+
+	sort.Strings(names)
+	pools := make([]PoolCapacity, 0, len(names))
+	for _, name := range names {
+		pools = append(pools, *byName[name])
+	}
+	return pools
+}
+
+// Headroom returns how many ready, schedulable nodes could run one more pod of the workload, and
+// how many more of its pods would fit on them in total
+func (c *ClusterCapacity) Headroom(workload WorkloadRequests) (int, int64) {
+	nodes := 0
+	var pods int64
+	for _, node := range c.Nodes {
+		if !node.Ready || node.Unschedulable {
+			continue
+		}
+		fit := node.AllocatablePods - node.Pods
+		if workload.CPU > 0 {
+			fit = min(fit, node.FreeCPU()/workload.CPU)
+		}
+		if workload.Memory > 0 {
+			fit = min(fit, node.FreeMemory()/workload.Memory)
+		}
+		if fit > 0 {
+			nodes++
+			pods += fit
+		}
+	}
+	return nodes, pods
+}
+
+// typicalNode returns the median allocatable CPU and memory of the nodes
+func (c *ClusterCapacity) typicalNode() (int64, int64) {
+	if len(c.Nodes) == 0 {
+		return 1, 1
+	}
+	cpus := make([]int64, len(c.Nodes))
+	memories := make([]int64, len(c.Nodes))
+	for i, node := range c.Nodes {
+		cpus[i], memories[i] = node.AllocatableCPU, node.AllocatableMemory
+	}
+	sort.Slice(cpus, func(i, j int) bool { return cpus[i] < cpus[j] })
+	sort.Slice(memories, func(i, j int) bool { return memories[i] < memories[j] })
+	return max(cpus[len(cpus)/2], 1), max(memories[len(memories)/2], 1)
+}
+
+// workloadSize is the larger share of a node's CPU or memory one pod of a workload requests
+func workloadSize(workload WorkloadRequests, cpuUnit, memoryUnit int64) float64 {
+	return max(float64(workload.CPU)/float64(cpuUnit), float64(workload.Memory)/float64(memoryUnit))
+}
+
+// nodeUsage returns the CPU (millicores) and memory (bytes) usage of each node from the metrics API
+func (c *Client) nodeUsage(ctx context.Context) (map[string][2]int64, error) {
+	data, err := c.clientset.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/nodes").
+		DoRaw(ctx)
+	if err != nil {
+		SkipSource(SourceMetrics, err)
+		return nil, fmt.Errorf("error querying metrics API (is metrics-server installed?): %w", err)
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Usage struct {
+				CPU    string `json:"cpu"`
+				Memory string `json:"memory"`
+			} `json:"usage"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error decoding metrics response: %w", err)
+	}
+
+	usage := make(map[string][2]int64, len(list.Items))
+	for _, item := range list.Items {
+		cpu, err := resource.ParseQuantity(item.Usage.CPU)
+		if err != nil {
+			continue
+		}
+		memory, err := resource.ParseQuantity(item.Usage.Memory)
+		if err != nil {
+			continue
+		}
+		usage[item.Metadata.Name] = [2]int64{cpu.MilliValue(), memory.Value()}
+	}
+	return usage, nil
+}
+
+// podRequests returns the CPU (millicores) and memory (bytes) a pod requests from the scheduler:
+// the sum of its containers, or its largest init container if that is more, plus overhead
+func podRequests(pod corev1.Pod) (int64, int64) {
+	var cpu, memory int64
+	for _, container := range pod.Spec.Containers {
+		cpu += container.Resources.Requests.Cpu().MilliValue()
+		memory += container.Resources.Requests.Memory().Value()
+	}
+	for _, container := range pod.Spec.InitContainers {
+		cpu = max(cpu, container.Resources.Requests.Cpu().MilliValue())
+		memory = max(memory, container.Resources.Requests.Memory().Value())
+	}
+	cpu += pod.Spec.Overhead.Cpu().MilliValue()
+	memory += pod.Spec.Overhead.Memory().Value()
+	return cpu, memory
+}
+
+// PodWorkload returns the kind and name of the workload a pod belongs to, resolving the
+// ReplicaSets of Deployments to the Deployment
+func PodWorkload(pod corev1.Pod) (string, string) {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		if hash := pod.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && hash != "" {
+			return "deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+		return strings.ToLower(owner.Kind), owner.Name
+	}
+	return "pod", pod.Name
+}
+
+// schedulingMessage returns why a pending pod is not scheduled, from its PodScheduled condition
+func schedulingMessage(pod corev1.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return condition.Message
+		}
+	}
+	return ""
+}
+
+// nodePool returns the node pool of a node from well-known labels
+func nodePool(node corev1.Node) string {
+	for _, label := range nodePoolLabels {
+		if pool := node.Labels[label]; pool != "" {
+			return pool
+		}
+	}
+	return "default"
+}
+
+// nodeReady reports whether the node's Ready condition is true
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// FormatCPU formats millicores, such as 250m or 3.5 cores
+func FormatCPU(millicores int64) string {
+	if millicores < 1000 {
+		return fmt.Sprintf("%dm", millicores)
+	}
+	return fmt.Sprintf("%.1f cores", float64(millicores)/1000)
+}
+
+// FormatMemory formats bytes in binary units, such as 512Mi or 15.6Gi
+func FormatMemory(bytes int64) string {
+	const mi = 1 << 20
+	const gi = 1 << 30
+	if bytes < gi {
+		return fmt.Sprintf("%dMi", bytes/mi)
+	}
+	return fmt.Sprintf("%.1fGi", float64(bytes)/gi)
+}
@@ -0,0 +1,304 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxCertificateEvents limits the events reported for each resource of an issuance
+const maxCertificateEvents = 8
+
+// cert-manager kinds walked from a Certificate to the ACME challenges of its issuance
+var (
+	certManagerCertificate        = schema.GroupKind{Group: "cert-manager.io", Kind: "Certificate"}
+	certManagerCertificateRequest = schema.GroupKind{Group: "cert-manager.io", Kind: "CertificateRequest"}
+	certManagerOrder              = schema.GroupKind{Group: "acme.cert-manager.io", Kind: "Order"}
+	certManagerChallenge          = schema.GroupKind{Group: "acme.cert-manager.io", Kind: "Challenge"}
+)
+
+// CertificateIssuance describes a cert-manager Certificate and the chain of resources created to
+// issue it: its issuer, latest CertificateRequest, ACME Order and Challenges, with their events
+// and the reasons issuance is stuck
+type CertificateIssuance struct {
+	Name        string   `json:"name"`
+	Namespace   string   `json:"namespace"`
+	DNSNames    []string `json:"dnsNames"`
+	SecretName  string   `json:"secretName"`
+	NotAfter    string   `json:"notAfter,omitempty"`
+	RenewalTime string   `json:"renewalTime,omitempty"`
+	// Issuer as Kind/name, and the status of its Ready condition, or NotFound
+	Issuer        string                `json:"issuer"`
+	IssuerReady   string                `json:"issuerReady"`
+	IssuerMessage string                `json:"issuerMessage,omitempty"`
+	Conditions    []FluxCondition       `json:"conditions"`
+	Resources     []CertificateResource `json:"resources"`
+	Problems      []CertificateProblem  `json:"problems"`
+}
+
+// CertificateResource is a resource in the issuance chain of a Certificate, from the Certificate
+// itself to its ACME Challenges
+type CertificateResource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Ready condition status of Certificates and CertificateRequests, state of Orders and Challenges
+	State   string `json:"state"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Challenge type and domain, and whether the solver presented the record or route
+	Type      string   `json:"type,omitempty"`
+	DNSName   string   `json:"dnsName,omitempty"`
+	Presented bool     `json:"presented,omitempty"`
+	Events    []string `json:"events,omitempty"`
+}
+
+// String returns the resource as Kind/name
+func (r CertificateResource) String() string {
+	return r.Kind + "/" + r.Name
+}
+
+// CertificateProblem is a known reason issuance is stuck, with the next steps to unblock it
+type CertificateProblem struct {
+	Problem string `json:"problem"`
+	Hint    string `json:"hint"`
+}
+
+// certificateProblems are the reasons recognized in the messages and events of the issuance
+// chain, most specific first. A problem with a challenge type only matches Challenges of that type.
+var certificateProblems = []struct {
+	CertificateProblem
+	challengeType string
+	message       *regexp.Regexp
+}{
+	{CertificateProblem{"rate limited", "The ACME server refuses new orders for these names until the rate limit window passes (a week for duplicate certificates on Let's Encrypt): stop deleting and recreating the Certificate, and test with the staging server."},
+		"", regexp.MustCompile(`(?i)rateLimited|too many (certificates|failed authorizations|new orders|registrations)|rate limit`)},
+	{CertificateProblem{"DNS01 propagation", "The TXT record is not visible to cert-manager's self check yet: check that _acme-challenge records exist in the authoritative zone (dig TXT _acme-challenge.<domain> @<nameserver>), that the solver manages the right zone, and that --dns01-recursive-nameservers points to resolvers that see it."},
+		"DNS-01", regexp.MustCompile(`(?i)propagation|NXDOMAIN|SERVFAIL|When querying the SOA|not yet propagated|no TXT record|could not find the zone|zone .* not found`)},
+	{CertificateProblem{"DNS01 provider credentials", "The DNS provider rejected cert-manager's credentials: check the secret referenced by the issuer's dns01 solver and the permissions of the API token or cloud identity."},
+		"DNS-01", regexp.MustCompile(`(?i)unauthorized|forbidden|access ?denied|authentication|invalid (api )?(token|credentials)|secret .* not found`)},
+	{CertificateProblem{"HTTP01 routing", "The ACME server or the self check cannot reach the solver at /.well-known/acme-challenge/: check that the domain resolves to the ingress controller, that the solver's ingress class matches it, and that nothing (redirects, auth, a firewall) intercepts plain HTTP on port 80."},
+		"HTTP-01", regexp.MustCompile(`(?i)wrong status code|self check|connection refused|no such host|i/o timeout|did not get expected response|no route to host|\b(404|503)\b`)},
+	{CertificateProblem{"CAA record", "A CAA record on the domain does not allow the issuing CA: add the CA (such as letsencrypt.org) to the CAA record."},
+		"", regexp.MustCompile(`(?i)\bCAA\b`)},
+	{CertificateProblem{"authorization failed", "The ACME server tried to validate a challenge and failed, so the order is invalid: read the challenge message, fix the cause, then delete the failed Order or run cmctl renew to retry."},
+		"", regexp.MustCompile(`(?i)authorization .* (failed|invalid)|urn:ietf:params:acme:error`)},
+}
+
+// GetCertificateIssuance walks a Certificate to its issuer, latest CertificateRequest, Orders and
+// Challenges, and finds why issuance is stuck
+func (c *Client) GetCertificateIssuance(ctx context.Context, namespace, name string) (*CertificateIssuance, error) {
+	obj, err := c.GetObject(ctx, certManagerCertificate, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Certificate %s/%s: %w", namespace, name, err)
+	}
+	issuance := &CertificateIssuance{
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Conditions: fluxConditions(obj.Object),
+		Resources:  []CertificateResource{},
+		Problems:   []CertificateProblem{},
+	}
+	issuance.DNSNames, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+	if commonName, _, _ := unstructured.NestedString(obj.Object, "spec", "commonName"); commonName != "" && !slices.Contains(issuance.DNSNames, commonName) {
+		issuance.DNSNames = append([]string{commonName}, issuance.DNSNames...)
+	}
+	issuance.SecretName, _, _ = unstructured.NestedString(obj.Object, "spec", "secretName")
+	issuance.NotAfter, _, _ = unstructured.NestedString(obj.Object, "status", "notAfter")
+	issuance.RenewalTime, _, _ = unstructured.NestedString(obj.Object, "status", "renewalTime")
+	issuance.Resources = append(issuance.Resources, c.certificateResource(ctx, obj))
+
+	c.describeIssuer(ctx, issuance, obj)
+
+	requests, err := c.ownedObjects(ctx, certManagerCertificateRequest, namespace, obj.GetUID())
+	if err != nil {
+		return nil, err
+	}
+	// Earlier requests belong to previous issuances, only the latest explains the current one
+	if len(requests) > 0 {
+		request := requests[len(requests)-1]
+		issuance.Resources = append(issuance.Resources, c.certificateResource(ctx, &request))
+
+		orders, err := c.ownedObjects(ctx, certManagerOrder, namespace, request.GetUID())
+		if err != nil {
+			return nil, err
+		}
+		for i := range orders {
+			issuance.Resources = append(issuance.Resources, c.certificateResource(ctx, &orders[i]))
+			challenges, err := c.ownedObjects(ctx, certManagerChallenge, namespace, orders[i].GetUID())
+			if err != nil {
+				return nil, err
+			}
+			for j := range challenges {
+				issuance.Resources = append(issuance.Resources, c.certificateResource(ctx, &challenges[j]))
+			}
+		}
+	}
+
+	issuance.Problems = issuance.problems()
+	return issuance, nil
+}
+
+// describeIssuer reads the readiness of the Issuer or ClusterIssuer a Certificate references
+func (c *Client) describeIssuer(ctx context.Context, issuance *CertificateIssuance, certificate *unstructured.Unstructured) {
+	name, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "name")
+	kind, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "kind")
+	group, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "group")
+	if kind == "" {
+		kind = "Issuer"
+	}
+	if group == "" {
+		group = certManagerCertificate.Group
+	}
+	issuance.Issuer = kind + "/" + name
+
+	// External issuers have their own kinds and conditions, only cert-manager's are read
+	if group != certManagerCertificate.Group {
+		issuance.IssuerReady = "Unknown"
+		return
+	}
+	issuer, err := c.GetObject(ctx, schema.GroupKind{Group: group, Kind: kind}, issuance.Namespace, name)
+	if apierrors.IsNotFound(err) {
+		issuance.IssuerReady = "NotFound"
+		return
+	}
+	if err != nil {
+		issuance.IssuerReady, issuance.IssuerMessage = "Unknown", err.Error()
+		return
+	}
+	issuance.IssuerReady = fluxConditionStatus(issuer, "Ready")
+	for _, condition := range fluxConditions(issuer.Object) {
+		if condition.Type == "Ready" {
+			issuance.IssuerMessage = condition.Message
+		}
+	}
+}
+
+// ownedObjects lists the objects of a kind in namespace that an owner created, oldest first
+func (c *Client) ownedObjects(ctx context.Context, groupKind schema.GroupKind, namespace string, owner types.UID) ([]unstructured.Unstructured, error) {
+	objects, err := c.ListObjects(ctx, groupKind, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %ss: %w", groupKind.Kind, err)
+	}
+	var owned []unstructured.Unstructured
+	for _, obj := range objects {
+		for _, ref := range obj.GetOwnerReferences() {
+			if ref.UID == owner {
+				owned = append(owned, obj)
+				break
+			}
+		}
+	}
+	sort.SliceStable(owned, func(i, j int) bool {
+		created, other := owned[i].GetCreationTimestamp(), owned[j].GetCreationTimestamp()
+		return created.Before(&other)
+	})
+	return owned, nil
+}
+
+// certificateResource describes the state and recent events of a resource of the issuance chain
+func (c *Client) certificateResource(ctx context.Context, obj *unstructured.Unstructured) CertificateResource {
+	resource := CertificateResource{Kind: obj.GetKind(), Name: obj.GetName()}
+	switch resource.Kind {
+	case certManagerOrder.Kind, certManagerChallenge.Kind:
+		resource.State, _, _ = unstructured.NestedString(obj.Object, "status", "state")
+		if resource.State == "" {
+			resource.State = "pending"
+		}
+		resource.Message, _, _ = unstructured.NestedString(obj.Object, "status", "reason")
+		resource.Type, _, _ = unstructured.NestedString(obj.Object, "spec", "type")
+		resource.DNSName, _, _ = unstructured.NestedString(obj.Object, "spec", "dnsName")
+		resource.Presented, _, _ = unstructured.NestedBool(obj.Object, "status", "presented")
+	default:
+		resource.State = fluxConditionStatus(obj, "Ready")
+		for _, condition := range fluxConditions(obj.Object) {
+			if condition.Type == "Ready" {
+				resource.Reason, resource.Message = condition.Reason, condition.Message
+			}
+		}
+	}
+
+	list, err := c.ListEvents(ctx, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		SkipSource(SourceEvents, err)
+		return resource
+	}
+	var events []corev1.Event
+	for _, event := range list {
+		if event.InvolvedObject.Kind == resource.Kind && event.InvolvedObject.Name == resource.Name {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return eventTime(events[i]).Before(eventTime(events[j])) })
+	if len(events) > maxCertificateEvents {
+		events = events[len(events)-maxCertificateEvents:]
+	}
+	for _, event := range events {
+		resource.Events = append(resource.Events, fmt.Sprintf("%s %s: %s", event.Type, event.Reason, strings.TrimSpace(event.Message)))
+	}
+	return resource
+}
+
+// problems finds the known reasons issuance is stuck in the issuer status and in the messages
+// and warning events of the issuance chain
+func (i *CertificateIssuance) problems() []CertificateProblem {
+	problems := []CertificateProblem{}
+	switch i.IssuerReady {
+	case "NotFound":
+		problems = append(problems, CertificateProblem{"issuer not found", fmt.Sprintf("%s does not exist: create it or fix spec.issuerRef, an Issuer must be in the Certificate's namespace.", i.Issuer)})
+	case "True", "Unknown":
+	default:
+		problems = append(problems, CertificateProblem{"issuer not ready", fmt.Sprintf("%s is not ready, so no request is signed: fix it first, often its ACME account registration or the secret it references.", i.Issuer)})
+	}
+
+	for _, problem := range certificateProblems {
+		matched := false
+		for _, resource := range i.Resources {
+			if problem.challengeType != "" && (resource.Kind != certManagerChallenge.Kind || resource.Type != problem.challengeType) {
+				continue
+			}
+			signals := []string{resource.Message}
+			for _, event := range resource.Events {
+				if strings.HasPrefix(event, corev1.EventTypeWarning) {
+					signals = append(signals, event)
+				}
+			}
+			for _, signal := range signals {
+				if signal != "" && problem.message.MatchString(signal) {
+					matched = true
+				}
+			}
+		}
+		if matched {
+			problems = append(problems, problem.CertificateProblem)
+		}
+	}
+	return problems
+}
+
+// Ready returns the status of the Certificate's Ready condition
+func (i *CertificateIssuance) Ready() FluxCondition {
+	for _, condition := range i.Conditions {
+		if condition.Type == "Ready" {
+			return condition
+		}
+	}
+	return FluxCondition{Type: "Ready", Status: "Unknown"}
+}
+
+// IsCertificateKind reports whether kind names a cert-manager Certificate
+func IsCertificateKind(kind string) bool {
+	switch strings.ToLower(kind) {
+	case "certificate", "certificates", "cert", "certs":
+		return true
+	}
+	return false
+}
@@ -1,8 +1,15 @@
 package k8s
 
 import (
+	"net/http"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -16,64 +23,169 @@ type ClientConfig struct {
 	Namespace string
 	// If true, operations will target all namespaces
 	AllNamespaces bool
+	// If true, any request that could mutate cluster state is refused
+	ReadOnly bool
+
+	// Name of the kubeconfig cluster to use
+	Cluster string
+	// Name of the kubeconfig user to use
+	User string
+	// Address of the Kubernetes API server, overriding the kubeconfig
+	Server string
+	// Bearer token for authentication to the API server
+	Token string
+	// Path to a certificate authority file for the API server
+	CertificateAuthority string
+	// If true, the API server's certificate is not checked for validity
+	InsecureSkipTLSVerify bool
+
+	// If true, the pod's service account is used instead of a kubeconfig
+	InCluster bool
+	// User to impersonate for all requests
+	Impersonate string
+	// Groups to impersonate for all requests
+	ImpersonateGroups []string
+
+	// Sustained rate of API requests per second (DefaultQPS if zero)
+	QPS float32
+	// Requests allowed above the sustained rate for short bursts (DefaultBurst if zero)
+	Burst int
+	// Maximum number of API requests in flight at the same time (DefaultMaxInFlight if zero,
+	// unlimited if negative)
+	MaxInFlight int
+	// If true, lists and discovered APIs are kept in the cache directory between runs
+	Cache bool
 }
 
 // Client represents a Kubernetes client wrapper
 type Client struct {
 	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
 	config    ClientConfig
+
+	// Maps kinds to resources, discovering them on first use
+	mapperOnce sync.Once
+	mapper     meta.RESTMapper
+
+	// Lists and discovered APIs kept between runs, if enabled
+	cache *clusterCache
 }
 
-// NewClient creates a new Kubernetes client
+// NewClient creates a new read-only Kubernetes client
 func NewClient(kubeconfig string) (*Client, error) {
-	return NewClientWithConfig(ClientConfig{KubeconfigPath: kubeconfig})
+	return NewClientWithConfig(ClientConfig{KubeconfigPath: kubeconfig, ReadOnly: true})
 }
 
-// NewClientWithConfig creates a new Kubernetes client with the given configuration
-func NewClientWithConfig(config ClientConfig) (*Client, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if config.KubeconfigPath != "" {
-		loadingRules.ExplicitPath = config.KubeconfigPath
-	}
+// clientFactory replaces the clients NewClientWithConfig connects when set with UseClientFactory
+var (
+	clientFactoryMu sync.RWMutex
+	clientFactory   func(ClientConfig) (*Client, error)
+)
 
-	// Create config overrides
-	overrides := &clientcmd.ConfigOverrides{}
+// UseClientFactory makes NewClientWithConfig, and so every kube-ai command, get its clients from a
+// factory instead of connecting to a cluster, such as one returning NewClientForClientset with the
+// fake clientsets of k8s.io/client-go in tests. It returns a function restoring the default.
+func UseClientFactory(factory func(ClientConfig) (*Client, error)) (restore func()) {
+	clientFactoryMu.Lock()
+	previous := clientFactory
+	clientFactory = factory
+	clientFactoryMu.Unlock()
 
-	// Apply context override if specified
-	if config.Context != "" {
-		overrides.CurrentContext = config.Context
+	return func() {
+		clientFactoryMu.Lock()
+		clientFactory = previous
+		clientFactoryMu.Unlock()
 	}
+}
 
-	// Apply namespace override if specified
-	if config.Namespace != "" {
-		overrides.Context.Namespace = config.Namespace
+// NewClientForClientset creates a client around existing clientsets, such as the fake clientsets
+// of k8s.io/client-go. The dynamic client is optional; the namespace defaults to "default".
+func NewClientForClientset(clientset kubernetes.Interface, dynamicClient dynamic.Interface, config ClientConfig) *Client {
+	if config.Namespace == "" && !config.AllNamespaces {
+		config.Namespace = "default"
+	}
+	return &Client{
+		clientset: clientset,
+		dynamic:   dynamicClient,
+		config:    config,
 	}
+}
 
-	// Create client config
-	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules,
-		overrides,
-	)
+// NewClientWithConfig creates a new Kubernetes client with the given configuration
+func NewClientWithConfig(config ClientConfig) (*Client, error) {
+	clientFactoryMu.RLock()
+	factory := clientFactory
+	clientFactoryMu.RUnlock()
+	if factory != nil {
+		return factory(config)
+	}
 
-	// Create rest config
-	restConfig, err := clientConfig.ClientConfig()
-	if err != nil {
-		// If we couldn't load from kubeconfig, try in-cluster config
+	clientConfig := newClientConfig(config)
+
+	// Create rest config, using the pod's service account when requested or when no kubeconfig is usable
+	var restConfig *rest.Config
+	var err error
+	inCluster := config.InCluster
+	if !inCluster {
+		restConfig, err = clientConfig.ClientConfig()
+		if err != nil {
+			inCluster = true
+		}
+	}
+	if inCluster {
 		restConfig, err = rest.InClusterConfig()
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Impersonate another identity, e.g. to audit a namespace with that tenant's permissions
+	if config.Impersonate != "" || len(config.ImpersonateGroups) > 0 {
+		restConfig.Impersonate = rest.ImpersonationConfig{
+			UserName: config.Impersonate,
+			Groups:   config.ImpersonateGroups,
+		}
+	}
+
+	// Enforce read-only mode at the transport level so no code path can mutate the cluster
+	if config.ReadOnly {
+		restConfig.Wrap(NewReadOnlyRoundTripper)
+	}
+
+	// Keep cluster-wide reports from overwhelming the API server of large clusters
+	restConfig.QPS, restConfig.Burst = config.QPS, config.Burst
+	if restConfig.QPS == 0 {
+		restConfig.QPS = DefaultQPS
+	}
+	if restConfig.Burst == 0 {
+		restConfig.Burst = DefaultBurst
+	}
+	maxInFlight := config.MaxInFlight
+	if maxInFlight == 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	restConfig.Wrap(func(next http.RoundTripper) http.RoundTripper {
+		return NewInFlightRoundTripper(next, maxInFlight)
+	})
+
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	// Dynamic client for resources without typed clients, e.g. when applying manifests
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	// If namespace wasn't explicitly provided, get it from the client config
 	if config.Namespace == "" && !config.AllNamespaces {
 		namespace, _, err := clientConfig.Namespace()
+		if inCluster {
+			namespace, err = InClusterNamespace(), nil
+		}
 		if err == nil && namespace != "" {
 			config.Namespace = namespace
 		} else {
@@ -82,10 +194,105 @@ func NewClientWithConfig(config ClientConfig) (*Client, error) {
 		}
 	}
 
-	return &Client{
+	client := &Client{
 		clientset: clientset,
+		dynamic:   dynamicClient,
 		config:    config,
-	}, nil
+	}
+
+	// The cache only saves time, so a cache that cannot be set up is not an error
+	if config.Cache {
+		if dir, err := DefaultCacheDir(); err == nil {
+			client.cache, _ = newClusterCache(dir, restConfig, config)
+		}
+	}
+	return client, nil
+}
+
+// newClientConfig builds a kubeconfig loader honoring the path, context and namespace overrides
+func newClientConfig(config ClientConfig) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if config.KubeconfigPath != "" {
+		loadingRules.ExplicitPath = config.KubeconfigPath
+	}
+
+	// Create config overrides
+	overrides := &clientcmd.ConfigOverrides{}
+
+	// Apply context override if specified
+	if config.Context != "" {
+		overrides.CurrentContext = config.Context
+	}
+
+	// Apply namespace override if specified
+	if config.Namespace != "" {
+		overrides.Context.Namespace = config.Namespace
+	}
+
+	// Apply cluster and user selection overrides if specified
+	if config.Cluster != "" {
+		overrides.Context.Cluster = config.Cluster
+	}
+	if config.User != "" {
+		overrides.Context.AuthInfo = config.User
+	}
+
+	// Apply connection overrides so direct API server and token-based access work
+	if config.Server != "" {
+		overrides.ClusterInfo.Server = config.Server
+	}
+	if config.CertificateAuthority != "" {
+		overrides.ClusterInfo.CertificateAuthority = config.CertificateAuthority
+	}
+	if config.InsecureSkipTLSVerify {
+		overrides.ClusterInfo.InsecureSkipTLSVerify = true
+	}
+	if config.Token != "" {
+		overrides.AuthInfo.Token = config.Token
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// CurrentContext returns the kubeconfig context and namespace a configuration resolves to,
+// without contacting the cluster. Missing values are returned as empty strings.
+func CurrentContext(config ClientConfig) (string, string) {
+	if config.InCluster {
+		namespace := config.Namespace
+		if namespace == "" {
+			namespace = InClusterNamespace()
+		}
+		return "in-cluster", namespace
+	}
+
+	clientConfig := newClientConfig(config)
+
+	contextName := config.Context
+	if contextName == "" {
+		if rawConfig, err := clientConfig.RawConfig(); err == nil {
+			contextName = rawConfig.CurrentContext
+		}
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		namespace = config.Namespace
+	}
+
+	return contextName, namespace
+}
+
+// restMapper returns the client's kind to resource mapper, which discovers the cluster's APIs
+// once, or reuses those a previous run discovered, and then serves lookups from memory
+func (c *Client) restMapper() meta.RESTMapper {
+	c.mapperOnce.Do(func() {
+		if c.cache != nil {
+			c.mapper = c.cache.restMapper(c.clientset.Discovery())
+			return
+		}
+		c.mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.clientset.Discovery()))
+	})
+	return c.mapper
 }
 
 // GetClientset returns the underlying Kubernetes clientset
@@ -98,6 +305,11 @@ func (c *Client) GetNamespace() string {
 	return c.config.Namespace
 }
 
+// IsReadOnly returns whether mutating requests are refused
+func (c *Client) IsReadOnly() bool {
+	return c.config.ReadOnly
+}
+
 // IsAllNamespaces returns whether operations should target all namespaces
 func (c *Client) IsAllNamespaces() bool {
 	return c.config.AllNamespaces
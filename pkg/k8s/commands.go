@@ -0,0 +1,411 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// commandLabelValues is how many values of each node label are described to the AI
+const commandLabelValues = 5
+
+// CommandContext is what kubectl commands for a cluster can refer to: its namespaces, the labels
+// of its nodes and the resource types it serves
+type CommandContext struct {
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Labels of the nodes by key, with up to commandLabelValues of their values
+	NodeLabels map[string][]string `json:"nodeLabels,omitempty"`
+	// Resource types, by plural name
+	Resources []string `json:"resources,omitempty"`
+}
+
+// CommandCheck is what checking a kubectl command against the cluster found
+type CommandCheck struct {
+	// Facts confirmed, such as how many objects a label selector matches
+	Notes []string `json:"notes,omitempty"`
+	// Problems, such as unknown resource types, missing objects or selectors matching nothing
+	Problems []string `json:"problems,omitempty"`
+	// Whether the command only reads from the cluster
+	ReadOnly bool `json:"readOnly"`
+}
+
+// readOnlyVerbs are the kubectl commands that do not change the cluster, with the subcommands
+// that do not for commands that have both
+var readOnlyVerbs = map[string][]string{
+	"get":           nil,
+	"describe":      nil,
+	"logs":          nil,
+	"top":           nil,
+	"explain":       nil,
+	"events":        nil,
+	"diff":          nil,
+	"wait":          nil,
+	"version":       nil,
+	"cluster-info":  nil,
+	"api-resources": nil,
+	"api-versions":  nil,
+	"auth":          {"can-i", "whoami"},
+	"rollout":       {"status", "history"},
+	"config":        {"view", "current-context", "get-contexts", "get-clusters", "get-users"},
+}
+
+// resourceVerbs are the kubectl commands whose arguments name resource types and objects, after
+// a subcommand for rollout and set
+var resourceVerbs = map[string]bool{
+	"get": true, "describe": true, "delete": true, "edit": true, "label": true, "annotate": true,
+	"patch": true, "scale": true, "autoscale": true, "expose": true, "wait": true, "top": true,
+	"rollout": true, "set": true,
+}
+
+// nodeVerbs are the kubectl commands that act on nodes given by name or selector
+var nodeVerbs = map[string]bool{"cordon": true, "uncordon": true, "drain": true}
+
+// podVerbs are the kubectl commands that act on a pod, or on type/name
+var podVerbs = map[string]bool{"logs": true, "exec": true, "attach": true, "port-forward": true}
+
+// valueFlags are the kubectl flags that take a value as the next argument when not given with =
+var valueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "-l": true, "--selector": true, "-o": true, "--output": true,
+	"-c": true, "--container": true, "--context": true, "--kubeconfig": true, "--cluster": true,
+	"--user": true, "--as": true, "--server": true, "--token": true, "--type": true, "-p": true,
+	"--patch": true, "--patch-file": true, "--replicas": true, "--timeout": true,
+	"--grace-period": true, "--for": true, "-f": true, "--filename": true, "--field-selector": true,
+	"--sort-by": true, "--since": true, "--tail": true, "--to-revision": true, "--image": true,
+	"--port": true, "-L": true, "--label-columns": true, "--current-replicas": true,
+	"--resource-version": true, "--pod-running-timeout": true, "--max-unavailable": true,
+	"-s": true, "--as-group": true, "--as-uid": true, "--certificate-authority": true,
+	"--client-certificate": true, "--client-key": true, "--username": true, "--password": true,
+	"--tls-server-name": true,
+}
+
+// connectionFlags are the kubectl flags that select the cluster, credentials or identity a
+// command runs with. kube-ai sets them itself, from its own flags.
+var connectionFlags = map[string]bool{
+	"--context": true, "--kubeconfig": true, "--cluster": true, "--user": true, "-s": true,
+	"--server": true, "--token": true, "--as": true, "--as-group": true, "--as-uid": true,
+	"--certificate-authority": true, "--client-certificate": true, "--client-key": true,
+	"--insecure-skip-tls-verify": true, "--username": true, "--password": true,
+	"--tls-server-name": true,
+}
+
+// GetCommandContext describes the namespaces, node labels and resource types of the cluster, for
+// the AI to write commands that refer to what exists. Each part is best effort: what cannot be
+// read, such as for lack of permissions, is left out.
+func (c *Client) GetCommandContext(ctx context.Context) (*CommandContext, error) {
+	commandContext := &CommandContext{NodeLabels: make(map[string][]string)}
+
+	if namespaces, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); err == nil {
+		for _, namespace := range namespaces.Items {
+			commandContext.Namespaces = append(commandContext.Namespaces, namespace.Name)
+		}
+	}
+
+	if nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
+		for _, node := range nodes.Items {
+			for key, value := range node.Labels {
+				values := commandContext.NodeLabels[key]
+				if len(values) < commandLabelValues {
+					commandContext.NodeLabels[key] = AppendUnique(values, value)
+				}
+			}
+		}
+	}
+	for _, values := range commandContext.NodeLabels {
+		sort.Strings(values)
+	}
+
+	// Partial discovery failures, such as an unavailable aggregated API, still return the rest
+	lists, _ := c.clientset.Discovery().ServerPreferredResources()
+	for _, list := range lists {
+		for _, resource := range list.APIResources {
+			if !strings.Contains(resource.Name, "/") {
+				commandContext.Resources = AppendUnique(commandContext.Resources, resource.Name)
+			}
+		}
+	}
+
+	sort.Strings(commandContext.Namespaces)
+	sort.Strings(commandContext.Resources)
+	return commandContext, nil
+}
+
+// SplitCommandLine splits a command line into words like a shell does for a simple command,
+// honoring quotes and backslashes. Pipes, redirections, command substitution, variables and
+// command lists are refused, as only a shell could run them.
+func SplitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			case '$', '`':
+				return nil, fmt.Errorf("%q needs a shell: it uses %c", line, r)
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case strings.ContainsRune("|&;<>()$`\n", r):
+			return nil, fmt.Errorf("%q needs a shell: it uses %c", line, r)
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("%q has an unterminated quote", line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// kubectlArgs are the arguments of a kubectl command that tell what it acts on
+type kubectlArgs struct {
+	positional    []string
+	namespace     string
+	selector      string
+	allNamespaces bool
+	// Connection flags given, such as --context
+	connection []string
+}
+
+// parseKubectlArgs separates the arguments of a kubectl command, without the kubectl word, into
+// positional arguments and the flags that select objects
+func parseKubectlArgs(args []string) kubectlArgs {
+	var parsed kubectlArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			parsed.positional = append(parsed.positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && valueFlags[name] && i+1 < len(args) {
+			i++
+			value, hasValue = args[i], true
+		}
+		switch name {
+		case "-n", "--namespace":
+			parsed.namespace = value
+		case "-l", "--selector":
+			parsed.selector = value
+		case "-A", "--all-namespaces":
+			parsed.allNamespaces = !hasValue || value == "true"
+		}
+		if connectionFlags[name] {
+			parsed.connection = append(parsed.connection, name)
+		}
+	}
+	return parsed
+}
+
+// IsReadOnlyCommand reports whether a kubectl command, given as words including kubectl, only
+// reads from the cluster
+func IsReadOnlyCommand(args []string) bool {
+	if len(args) < 2 || args[0] != "kubectl" {
+		return false
+	}
+	positional := parseKubectlArgs(args[1:]).positional
+	if len(positional) == 0 {
+		return false
+	}
+	subcommands, ok := readOnlyVerbs[positional[0]]
+	if !ok {
+		return false
+	}
+	if subcommands == nil {
+		return true
+	}
+	return len(positional) > 1 && slices.Contains(subcommands, positional[1])
+}
+
+// CheckKubectlCommand checks a kubectl command, given as words including kubectl, against the
+// cluster: that it leaves the cluster and credentials to kube-ai, that its namespace, resource
+// types and named objects exist, and how many objects its label selector matches
+func (c *Client) CheckKubectlCommand(ctx context.Context, args []string) CommandCheck {
+	check := CommandCheck{ReadOnly: IsReadOnlyCommand(args)}
+	if len(args) == 0 || args[0] != "kubectl" {
+		check.Problems = append(check.Problems, "not a kubectl command")
+		return check
+	}
+	parsed := parseKubectlArgs(args[1:])
+	if len(parsed.positional) == 0 {
+		check.Problems = append(check.Problems, "no kubectl command given")
+		return check
+	}
+	for _, flag := range parsed.connection {
+		check.Problems = append(check.Problems, fmt.Sprintf("%s changes the cluster or credentials the command runs with", flag))
+	}
+
+	namespace := parsed.namespace
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+	if parsed.namespace != "" {
+		_, err := c.clientset.CoreV1().Namespaces().Get(ctx, parsed.namespace, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			check.Problems = append(check.Problems, fmt.Sprintf("namespace %s does not exist", parsed.namespace))
+			return check
+		}
+	}
+
+	verb, rest := parsed.positional[0], parsed.positional[1:]
+	if (verb == "rollout" || verb == "set") && len(rest) > 0 {
+		rest = rest[1:]
+	}
+
+	// What the command acts on: resource types, and names of objects of the first type
+	var resources, names []string
+	switch {
+	case nodeVerbs[verb]:
+		resources, names = []string{"nodes"}, rest
+	case verb == "taint" && len(rest) > 0:
+		// Only the type and the first name precede the taints
+		resources, names = splitResourceArgs(rest[:min(len(rest), 2)])
+	case podVerbs[verb] && len(rest) > 0:
+		if kind, name, found := strings.Cut(rest[0], "/"); found {
+			resources, names = []string{kind}, []string{name}
+		} else {
+			resources, names = []string{"pods"}, rest[:1]
+		}
+	case resourceVerbs[verb] && len(rest) > 0:
+		resources, names = splitResourceArgs(rest)
+	}
+
+	mapper := restmapper.NewShortcutExpander(c.restMapper(), c.clientset.Discovery(), nil)
+	for i, resource := range resources {
+		target, mapping, where, err := c.resourceClient(mapper, resource, namespace, parsed.allNamespaces)
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("the cluster serves no resource type %q", resource))
+			continue
+		}
+		if target == nil {
+			continue
+		}
+		kind := strings.ToLower(mapping.GroupVersionKind.Kind)
+		if i == 0 {
+			for _, name := range names {
+				if _, err := target.Get(ctx, name, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+					check.Problems = append(check.Problems, fmt.Sprintf("%s %s does not exist%s", kind, name, where))
+				}
+			}
+		}
+		if parsed.selector != "" {
+			list, err := target.List(ctx, metav1.ListOptions{LabelSelector: parsed.selector})
+			switch {
+			case err != nil:
+				if apierrors.IsBadRequest(err) {
+					check.Problems = append(check.Problems, fmt.Sprintf("invalid label selector %q", parsed.selector))
+				}
+			case len(list.Items) == 0:
+				check.Problems = append(check.Problems, fmt.Sprintf("-l %s matches no %s%s", parsed.selector, kind, where))
+			case len(list.Items) == 1:
+				check.Notes = append(check.Notes, fmt.Sprintf("-l %s matches 1 %s%s", parsed.selector, kind, where))
+			default:
+				check.Notes = append(check.Notes, fmt.Sprintf("-l %s matches %d %s%s", parsed.selector, len(list.Items), mapping.Resource.Resource, where))
+			}
+		}
+	}
+	return check
+}
+
+// resourceClient resolves a resource type as kubectl accepts it (plural, singular, short name or
+// resource.group) to a client of its objects in namespace, or in all namespaces, with where they
+// are for messages. The client is nil when objects cannot be read, with no dynamic client.
+func (c *Client) resourceClient(mapper meta.RESTMapper, resource, namespace string, allNamespaces bool) (dynamic.ResourceInterface, *meta.RESTMapping, string, error) {
+	gvr := schema.GroupVersionResource{Resource: strings.ToLower(resource)}
+	if fullySpecified, groupResource := schema.ParseResourceArg(gvr.Resource); fullySpecified != nil {
+		gvr = *fullySpecified
+	} else {
+		gvr = groupResource.WithVersion("")
+	}
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if c.dynamic == nil {
+		return nil, mapping, "", nil
+	}
+
+	client := c.dynamic.Resource(mapping.Resource)
+	switch {
+	case mapping.Scope.Name() != meta.RESTScopeNameNamespace:
+		return client, mapping, "", nil
+	case allNamespaces:
+		return client, mapping, " in all namespaces", nil
+	default:
+		return client.Namespace(namespace), mapping, " in namespace " + namespace, nil
+	}
+}
+
+// splitResourceArgs splits the resource arguments of a kubectl command, given as type name...,
+// type1,type2 or type/name..., into resource types and the names of objects of the first.
+// Arguments such as labels (key=value), taints and removals (key-) are not names.
+func splitResourceArgs(args []string) ([]string, []string) {
+	var resources, names []string
+	for i, arg := range args {
+		if strings.ContainsAny(arg, "=:") || strings.HasSuffix(arg, "-") {
+			continue
+		}
+		if kind, name, found := strings.Cut(arg, "/"); found {
+			if !slices.Contains(resources, kind) {
+				resources = append(resources, kind)
+			}
+			if kind == resources[0] {
+				names = append(names, name)
+			}
+			continue
+		}
+		if i == 0 {
+			resources = strings.Split(arg, ",")
+		} else if len(resources) == 1 && !strings.Contains(args[0], "/") {
+			names = append(names, arg)
+		}
+	}
+	return resources, names
+}
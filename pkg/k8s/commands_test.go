@@ -0,0 +1,207 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"kubectl get pods", []string{"kubectl", "get", "pods"}},
+		{"  kubectl\tget   pods  ", []string{"kubectl", "get", "pods"}},
+		{`kubectl get pods -l 'app in (web, api)'`, []string{"kubectl", "get", "pods", "-l", "app in (web, api)"}},
+		{`kubectl annotate pod web note="a \"quoted\" value"`, []string{"kubectl", "annotate", "pod", "web", `note=a "quoted" value`}},
+		{`kubectl get pod my\ pod`, []string{"kubectl", "get", "pod", "my pod"}},
+		{`kubectl get pods -o jsonpath='{.items[*].metadata.name}'`, []string{"kubectl", "get", "pods", "-o", "jsonpath={.items[*].metadata.name}"}},
+		{`kubectl get pods -l ''`, []string{"kubectl", "get", "pods", "-l", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.line)
+		if err != nil {
+			t.Errorf("SplitCommandLine(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCommandLineRefusesShell(t *testing.T) {
+	for _, line := range []string{
+		"kubectl get pods | grep web",
+		"kubectl get pods > pods.txt",
+		"kubectl get pods < input",
+		"kubectl get pods; kubectl delete pods --all",
+		"kubectl get pods && rm -rf /",
+		"kubectl get pods &",
+		"kubectl get pod $(cat name)",
+		"kubectl get pod `cat name`",
+		"kubectl get pod $POD",
+		`kubectl get pod "$POD"`,
+		"kubectl get pod \"`cat name`\"",
+		"kubectl get pods\nkubectl delete pods --all",
+		"kubectl get pod 'web",
+		`kubectl get pod "web`,
+		`kubectl get pod web\`,
+	} {
+		if words, err := SplitCommandLine(line); err == nil {
+			t.Errorf("SplitCommandLine(%q) = %q, want an error", line, words)
+		}
+	}
+
+	// Quoted in single quotes, shell characters are plain text
+	words, err := SplitCommandLine(`kubectl get pods -o 'jsonpath={$.items[0]}' -l 'a|b'`)
+	if err != nil || words[4] != "jsonpath={$.items[0]}" || words[6] != "a|b" {
+		t.Errorf("single quotes did not keep shell characters literal: %q, %v", words, err)
+	}
+}
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"kubectl get pods -A", true},
+		{"kubectl -n shop describe deployment web", true},
+		{"kubectl logs web -c app --tail 50", true},
+		{"kubectl auth can-i list pods", true},
+		{"kubectl rollout status deployment/web", true},
+		{"kubectl rollout history deployment/web", true},
+		{"kubectl config view", true},
+		{"kubectl delete pod web", false},
+		{"kubectl apply -f web.yaml", false},
+		{"kubectl rollout restart deployment/web", false},
+		{"kubectl rollout undo deployment/web", false},
+		{"kubectl config use-context prod", false},
+		{"kubectl auth reconcile -f rbac.yaml", false},
+		{"kubectl exec web -- ls", false},
+		{"kubectl scale deployment web --replicas 0", false},
+		// Flag values are not mistaken for the command
+		{"kubectl -n get delete pod web", false},
+		{"kubectl --context get delete pod web", false},
+		{"kubectl", false},
+		{"kubectl -A", false},
+		{"helm list", false},
+	}
+	for _, tt := range tests {
+		if got := IsReadOnlyCommand(strings.Fields(tt.command)); got != tt.want {
+			t.Errorf("IsReadOnlyCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+// newCommandsClient returns a client of a fake cluster serving namespaces, pods, nodes and
+// deployments, with the given objects
+func newCommandsClient(objects ...runtime.Object) *Client {
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "namespaces", SingularName: "namespace", Kind: "Namespace", ShortNames: []string{"ns"}, Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "pods", SingularName: "pod", Kind: "Pod", Namespaced: true, ShortNames: []string{"po"}, Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "nodes", SingularName: "node", Kind: "Node", ShortNames: []string{"no"}, Verbs: metav1.Verbs{"get", "list"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", SingularName: "deployment", Kind: "Deployment", Namespaced: true, ShortNames: []string{"deploy"}, Verbs: metav1.Verbs{"get", "list"}},
+		}},
+	}
+
+	scheme := runtime.NewScheme()
+	dynamic := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "pods"}:                       "PodList",
+		{Version: "v1", Resource: "nodes"}:                      "NodeList",
+		{Version: "v1", Resource: "namespaces"}:                 "NamespaceList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	}, unstructuredObjects(objects)...)
+	return NewClientForClientset(clientset, dynamic, ClientConfig{Namespace: "shop"})
+}
+
+// unstructuredObjects converts typed objects for the fake dynamic client
+func unstructuredObjects(objects []runtime.Object) []runtime.Object {
+	var converted []runtime.Object
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			panic(err)
+		}
+		converted = append(converted, &unstructured.Unstructured{Object: content})
+	}
+	return converted
+}
+
+func TestCheckKubectlCommand(t *testing.T) {
+	client := newCommandsClient(
+		&corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Pod{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}, ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}, ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "shop", Labels: map[string]string{"app": "web"}}},
+		&corev1.Node{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Node"}, ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+		&appsv1.Deployment{TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}},
+	)
+
+	tests := []struct {
+		command  string
+		readOnly bool
+		// Problems and notes expected, as substrings; none when empty
+		problems, notes []string
+	}{
+		{command: "kubectl get pods", readOnly: true},
+		{command: "kubectl get po web-1", readOnly: true},
+		{command: "kubectl get deploy/web pod/web-2", readOnly: true},
+		{command: "kubectl logs deployment/web", readOnly: true},
+		{command: "kubectl get pod web-9", readOnly: true, problems: []string{"pod web-9 does not exist in namespace shop"}},
+		{command: "kubectl get widgets", readOnly: true, problems: []string{`no resource type "widgets"`}},
+		{command: "kubectl -n nowhere get pods", readOnly: true, problems: []string{"namespace nowhere does not exist"}},
+		{command: "kubectl get pods -l app=web", readOnly: true, notes: []string{"-l app=web matches 2 pods in namespace shop"}},
+		{command: "kubectl get pods -l app=api", readOnly: true, problems: []string{"-l app=api matches no pod in namespace shop"}},
+		{command: "kubectl cordon node-b", problems: []string{"node node-b does not exist"}},
+		{command: "kubectl rollout restart deployment/web"},
+		{command: "kubectl label pod web-1 tier=frontend"},
+		{command: "kubectl delete pod web-1 --grace-period 0"},
+		{command: "kubectl get pods --context prod", readOnly: true, problems: []string{"--context changes the cluster or credentials"}},
+		{command: "kubectl get pods --kubeconfig=/tmp/admin.conf", readOnly: true, problems: []string{"--kubeconfig changes"}},
+		{command: "kubectl get pods --token abc --as admin", readOnly: true, problems: []string{"--token changes", "--as changes"}},
+		{command: "kubectl get pods -s https://10.0.0.1", readOnly: true, problems: []string{"-s changes"}},
+		{command: "kubectl get pods --insecure-skip-tls-verify", readOnly: true, problems: []string{"--insecure-skip-tls-verify changes"}},
+		{command: "helm list", problems: []string{"not a kubectl command"}},
+		{command: "kubectl -n shop", problems: []string{"no kubectl command given"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			check := client.CheckKubectlCommand(context.Background(), strings.Fields(tt.command))
+			if check.ReadOnly != tt.readOnly {
+				t.Errorf("ReadOnly = %v, want %v", check.ReadOnly, tt.readOnly)
+			}
+			assertFindings(t, "problems", check.Problems, tt.problems)
+			assertFindings(t, "notes", check.Notes, tt.notes)
+		})
+	}
+}
+
+// assertFindings checks that each finding contains the expected substring at its position
+func assertFindings(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %q, want %d matching %q", what, got, len(want), want)
+		return
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("%s[%d] = %q, want it to contain %q", what, i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// SessionContextEnv is the environment variable selecting the kubeconfig context of kube-ai
+// commands in one shell, overriding the context selected with kube-ai ctx
+const SessionContextEnv = "KUBE_AI_CONTEXT"
+
+// ContextInfo describes a kubeconfig context and, when probed, the cluster it points at
+type ContextInfo struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	Server    string `json:"server,omitempty"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+	// Whether this is the kubeconfig's current-context
+	Current bool `json:"current"`
+	// Whether kube-ai commands use this context: the session context, or else the current-context
+	Active bool `json:"active"`
+
+	// Set when the cluster was probed
+	Probed    bool   `json:"probed"`
+	Reachable bool   `json:"reachable"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Cloud provider from the nodes' provider IDs, such as aws or gce
+	Provider string `json:"provider,omitempty"`
+	// Regions and zones from the nodes' topology labels
+	Regions []string `json:"regions,omitempty"`
+	Zones   []string `json:"zones,omitempty"`
+	// Number of nodes, or -1 if they cannot be listed
+	Nodes int `json:"nodes,omitempty"`
+}
+
+// SessionContextPath returns the file storing the context selected with kube-ai ctx
+// (~/.kube-ai/context)
+func SessionContextPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kube-ai", "context"), nil
+}
+
+// SessionContext returns the kubeconfig context kube-ai commands use when --context is not
+// given: the KUBE_AI_CONTEXT environment variable, else the context selected with kube-ai ctx.
+// It returns "" when neither is set, meaning the kubeconfig's current-context.
+func SessionContext() string {
+	if name := os.Getenv(SessionContextEnv); name != "" {
+		return name
+	}
+	path, err := SessionContextPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SetSessionContext stores the context kube-ai commands use from now on. An empty name clears
+// the selection, going back to the kubeconfig's current-context.
+func SetSessionContext(name string) error {
+	path, err := SessionContextPath()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error clearing session context: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(name+"\n"), 0600); err != nil {
+		return fmt.Errorf("error writing session context: %w", err)
+	}
+	return nil
+}
+
+// DescribeContexts returns the contexts of the kubeconfig, sorted by name. With probe set, each
+// context's cluster is contacted concurrently, within the timeout, for its version, nodes and
+// topology.
+func DescribeContexts(ctx context.Context, config ClientConfig, probe bool, timeout time.Duration) ([]ContextInfo, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if config.KubeconfigPath != "" {
+		loadingRules.ExplicitPath = config.KubeconfigPath
+	}
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+
+	active := config.Context
+	if active == "" {
+		active = rawConfig.CurrentContext
+	}
+
+	infos := make([]ContextInfo, 0, len(rawConfig.Contexts))
+	for name, kubeContext := range rawConfig.Contexts {
+		info := ContextInfo{
+			Name:      name,
+			Cluster:   kubeContext.Cluster,
+			User:      kubeContext.AuthInfo,
+			Namespace: kubeContext.Namespace,
+			Current:   name == rawConfig.CurrentContext,
+			Active:    name == active,
+		}
+		if cluster, ok := rawConfig.Clusters[kubeContext.Cluster]; ok {
+			info.Server = cluster.Server
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	if probe {
+		var wg sync.WaitGroup
+		for i := range infos {
+			wg.Add(1)
+			go func(info *ContextInfo) {
+				defer wg.Done()
+				contextConfig := config
+				contextConfig.Context = info.Name
+				probeContext(ctx, contextConfig, timeout, info)
+			}(&infos[i])
+		}
+		wg.Wait()
+	}
+	return infos, nil
+}
+
+// probeContext contacts the cluster of a context for its version and node topology. It never
+// falls back to the in-cluster configuration, so that a broken context is reported as such.
+func probeContext(ctx context.Context, config ClientConfig, timeout time.Duration, info *ContextInfo) {
+	info.Probed = true
+	restConfig, err := newClientConfig(config).ClientConfig()
+	if err != nil {
+		info.Error = err.Error()
+		return
+	}
+	restConfig.Timeout = timeout
+	restConfig.Wrap(NewReadOnlyRoundTripper)
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		info.Error = err.Error()
+		return
+	}
+
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		info.Error = err.Error()
+		return
+	}
+	info.Reachable = true
+	info.Version = version.GitVersion
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		info.Nodes = -1
+		return
+	}
+	info.Nodes = len(nodes.Items)
+	for _, node := range nodes.Items {
+		if region := node.Labels["topology.kubernetes.io/region"]; region != "" {
+			info.Regions = AppendUnique(info.Regions, region)
+		}
+		if zone := node.Labels["topology.kubernetes.io/zone"]; zone != "" {
+			info.Zones = AppendUnique(info.Zones, zone)
+		}
+		if i := strings.Index(node.Spec.ProviderID, "://"); i > 0 && info.Provider == "" {
+			info.Provider = node.Spec.ProviderID[:i]
+		}
+	}
+	sort.Strings(info.Regions)
+	sort.Strings(info.Zones)
+}
+
+// MatchContexts returns the context names matching a query: the name itself if it exists, else
+// the names containing the query, else the names whose initials start with it, such as pue for
+// prod-us-east, else the names containing its characters in order. Matching ignores case.
+func MatchContexts(names []string, query string) []string {
+	for _, name := range names {
+		if name == query {
+			return []string{name}
+		}
+	}
+
+	query = strings.ToLower(query)
+	var matches []string
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), query) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(initials(strings.ToLower(name)), query) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	for _, name := range names {
+		if subsequence(strings.ToLower(name), query) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// initials returns the first character of each word of a context name, words being separated
+// by characters other than letters and digits, as in arn:aws:eks:us-east-1:123:cluster/prod
+func initials(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteRune([]rune(word)[0])
+	}
+	return b.String()
+}
+
+// subsequence reports whether the characters of query appear in s in order
+func subsequence(s, query string) bool {
+	remaining := []rune(query)
+	for _, r := range s {
+		if len(remaining) > 0 && remaining[0] == r {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// crdResource is the API resource of CustomResourceDefinitions
+var crdResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// CustomResourceDefinition is a CRD installed in the cluster, with the schema of its storage version
+type CustomResourceDefinition struct {
+	// Name of the CRD, such as rollouts.argoproj.io
+	Name string
+	// API group of the custom resource
+	Group string
+	// Version whose schema is used: the storage version, or the first served one
+	Version string
+	// Every served version
+	ServedVersions []string
+	// Kind of the custom resource
+	Kind string
+	// Plural resource name
+	Plural string
+	// Short names, such as ro for rollouts
+	ShortNames []string
+	// Whether the custom resource is namespaced
+	Namespaced bool
+	// OpenAPI v3 schema of the version, or nil when the CRD does not publish one
+	Schema *spec.Schema
+}
+
+// APIVersion returns the group/version of the custom resource
+func (d CustomResourceDefinition) APIVersion() string {
+	return d.Group + "/" + d.Version
+}
+
+// GroupVersionKind returns the group, version and kind of the custom resource
+func (d CustomResourceDefinition) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: d.Group, Version: d.Version, Kind: d.Kind}
+}
+
+// ListCustomResourceDefinitions returns the CRDs installed in the cluster, sorted by name
+func (c *Client) ListCustomResourceDefinitions(ctx context.Context) ([]CustomResourceDefinition, error) {
+	if c.dynamic == nil {
+		return nil, fmt.Errorf("listing custom resource definitions is not supported by this client")
+	}
+
+	list, err := c.dynamic.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing custom resource definitions: %w", err)
+	}
+
+	definitions := make([]CustomResourceDefinition, 0, len(list.Items))
+	for _, item := range list.Items {
+		definition, err := parseCustomResourceDefinition(item.Object)
+		if err != nil {
+			return nil, fmt.Errorf("error reading custom resource definition %s: %w", item.GetName(), err)
+		}
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions, nil
+}
+
+// parseCustomResourceDefinition reads the names and the schema of the storage version of a CRD
+func parseCustomResourceDefinition(obj map[string]interface{}) (CustomResourceDefinition, error) {
+	definition := CustomResourceDefinition{}
+	definition.Name, _, _ = unstructured.NestedString(obj, "metadata", "name")
+	definition.Group, _, _ = unstructured.NestedString(obj, "spec", "group")
+	definition.Kind, _, _ = unstructured.NestedString(obj, "spec", "names", "kind")
+	definition.Plural, _, _ = unstructured.NestedString(obj, "spec", "names", "plural")
+	definition.ShortNames, _, _ = unstructured.NestedStringSlice(obj, "spec", "names", "shortNames")
+	scope, _, _ := unstructured.NestedString(obj, "spec", "scope")
+	definition.Namespaced = scope == "Namespaced"
+
+	versions, _, _ := unstructured.NestedSlice(obj, "spec", "versions")
+	var selected map[string]interface{}
+	for _, item := range versions {
+		version, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		served, _, _ := unstructured.NestedBool(version, "served")
+		if served {
+			name, _, _ := unstructured.NestedString(version, "name")
+			definition.ServedVersions = append(definition.ServedVersions, name)
+		}
+		if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+			selected = version
+		} else if served && selected == nil {
+			selected = version
+		}
+	}
+	if selected == nil {
+		return definition, nil
+	}
+	definition.Version, _, _ = unstructured.NestedString(selected, "name")
+
+	raw, found, _ := unstructured.NestedMap(selected, "schema", "openAPIV3Schema")
+	if !found {
+		return definition, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return definition, err
+	}
+	definition.Schema = &spec.Schema{}
+	if err := definition.Schema.UnmarshalJSON(data); err != nil {
+		return definition, err
+	}
+	return definition, nil
+}
+
+// FindCustomResourceDefinition returns the CRD referenced by name (rollouts.argoproj.io), plural,
+// short name, kind or kind.group, or nil
+func FindCustomResourceDefinition(definitions []CustomResourceDefinition, reference string) *CustomResourceDefinition {
+	reference = strings.ToLower(strings.TrimSpace(reference))
+	for i, definition := range definitions {
+		kind := strings.ToLower(definition.Kind)
+		if reference == definition.Name || reference == definition.Plural || reference == kind ||
+			reference == kind+"."+definition.Group {
+			return &definitions[i]
+		}
+		for _, shortName := range definition.ShortNames {
+			if reference == shortName {
+				return &definitions[i]
+			}
+		}
+	}
+	return nil
+}
+
+// MentionedCustomResourceDefinitions returns the CRDs whose kind, plural or name occurs as a word
+// in text, such as "Rollout" in a description or "rollouts.argoproj.io" in an error message
+func MentionedCustomResourceDefinitions(definitions []CustomResourceDefinition, text string) []CustomResourceDefinition {
+	var mentioned []CustomResourceDefinition
+	for _, definition := range definitions {
+		words := quoteAll([]string{definition.Name, definition.Plural, definition.Kind})
+		if len(words) == 0 {
+			continue
+		}
+		pattern := `(?i)\b(` + strings.Join(words, "|") + `)\b`
+		if regexp.MustCompile(pattern).MatchString(text) {
+			mentioned = append(mentioned, definition)
+		}
+	}
+	return mentioned
+}
+
+// quoteAll quotes regular expression metacharacters in each non-empty string
+func quoteAll(values []string) []string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			quoted = append(quoted, regexp.QuoteMeta(value))
+		}
+	}
+	return quoted
+}
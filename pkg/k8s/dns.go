@@ -0,0 +1,329 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Where kubeadm, managed clusters and most distributions install CoreDNS. The deployment is named
+// coredns, the service keeps the kube-dns name of its predecessor, and both use the kube-dns label.
+const (
+	dnsNamespace  = "kube-system"
+	dnsDeployment = "coredns"
+	dnsService    = "kube-dns"
+	dnsConfigMap  = "coredns"
+	dnsPodLabel   = "k8s-app=kube-dns"
+)
+
+// DefaultDNSDebugImage is the image of the debug pod that runs lookups
+const DefaultDNSDebugImage = "busybox:1.36"
+
+// dnsName matches the names the debug pod may look up, which are passed to its shell
+var dnsName = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_.-]*[A-Za-z0-9.])?$`)
+
+// dnsLookupTimeout bounds how long the debug pod may take to run its lookups
+const dnsLookupTimeout = 90 * time.Second
+
+// DNSDiagnosis describes the health of the cluster DNS: the CoreDNS deployment and pods, its
+// Corefile, the kube-dns service and its endpoints, the results of lookups run from a debug pod,
+// and the problems found in them
+type DNSDiagnosis struct {
+	Deployment string `json:"deployment"`
+	// Ready and desired replicas of the deployment
+	ReadyReplicas int32       `json:"readyReplicas"`
+	Replicas      int32       `json:"replicas"`
+	Image         string      `json:"image,omitempty"`
+	Pods          []DNSPod    `json:"pods"`
+	Corefile      string      `json:"corefile,omitempty"`
+	ServiceIP     string      `json:"serviceIP,omitempty"`
+	Endpoints     []string    `json:"endpoints"`
+	NodeLocalDNS  bool        `json:"nodeLocalDNS"`
+	Lookups       []DNSLookup `json:"lookups,omitempty"`
+	// Live CPU and memory usage of the CoreDNS containers, when metrics-server runs
+	Usage    []ContainerUsage `json:"usage,omitempty"`
+	Findings []string         `json:"findings"`
+}
+
+// DNSPod is a CoreDNS pod with its readiness and restarts
+type DNSPod struct {
+	Name     string `json:"name"`
+	Node     string `json:"node"`
+	Phase    string `json:"phase"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	// Reason the container is waiting or last terminated, such as CrashLoopBackOff or OOMKilled
+	Reason string `json:"reason,omitempty"`
+}
+
+// DNSLookup is the result of resolving a name from the debug pod
+type DNSLookup struct {
+	Name   string `json:"name"`
+	Failed bool   `json:"failed"`
+	Output string `json:"output"`
+}
+
+// GetDNSDiagnosis collects the CoreDNS deployment, pods, Corefile, service and endpoints, and
+// finds the problems that break name resolution in the cluster
+func (c *Client) GetDNSDiagnosis(ctx context.Context) (*DNSDiagnosis, error) {
+	diagnosis := &DNSDiagnosis{
+		Deployment: dnsNamespace + "/" + dnsDeployment,
+		Pods:       []DNSPod{},
+		Endpoints:  []string{},
+		Findings:   []string{},
+	}
+
+	deployment, err := c.clientset.AppsV1().Deployments(dnsNamespace).Get(ctx, dnsDeployment, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		diagnosis.Deployment = ""
+		diagnosis.Findings = append(diagnosis.Findings, fmt.Sprintf("no %s deployment in %s: the cluster may run another DNS server, or CoreDNS was removed", dnsDeployment, dnsNamespace))
+	case err != nil:
+		return nil, fmt.Errorf("error getting deployment %s/%s: %w", dnsNamespace, dnsDeployment, err)
+	default:
+		diagnosis.describeDeployment(deployment)
+	}
+
+	pods, err := c.clientset.CoreV1().Pods(dnsNamespace).List(ctx, metav1.ListOptions{LabelSelector: dnsPodLabel})
+	if err != nil {
+		return nil, fmt.Errorf("error listing DNS pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		diagnosis.Pods = append(diagnosis.Pods, dnsPod(pod))
+	}
+
+	configMap, err := c.clientset.CoreV1().ConfigMaps(dnsNamespace).Get(ctx, dnsConfigMap, metav1.GetOptions{})
+	if err == nil {
+		diagnosis.Corefile = configMap.Data["Corefile"]
+	} else if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error getting configmap %s/%s: %w", dnsNamespace, dnsConfigMap, err)
+	}
+
+	service, err := c.clientset.CoreV1().Services(dnsNamespace).Get(ctx, dnsService, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		diagnosis.Findings = append(diagnosis.Findings, fmt.Sprintf("no %s service in %s: pods send queries to a cluster IP nothing answers", dnsService, dnsNamespace))
+	case err != nil:
+		return nil, fmt.Errorf("error getting service %s/%s: %w", dnsNamespace, dnsService, err)
+	default:
+		diagnosis.ServiceIP = service.Spec.ClusterIP
+		endpoints, err := c.clientset.CoreV1().Endpoints(dnsNamespace).Get(ctx, dnsService, metav1.GetOptions{})
+		if err == nil {
+			for _, subset := range endpoints.Subsets {
+				for _, address := range subset.Addresses {
+					diagnosis.Endpoints = append(diagnosis.Endpoints, address.IP)
+				}
+			}
+		}
+		if len(diagnosis.Endpoints) == 0 {
+			diagnosis.Findings = append(diagnosis.Findings, fmt.Sprintf("service %s has no ready endpoints, so every DNS query in the cluster times out", dnsService))
+		}
+	}
+
+	daemonSets, err := c.clientset.AppsV1().DaemonSets(dnsNamespace).List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=node-local-dns"})
+	if err == nil && len(daemonSets.Items) > 0 {
+		diagnosis.NodeLocalDNS = true
+	}
+
+	diagnosis.Findings = append(diagnosis.Findings, diagnosis.podFindings()...)
+	diagnosis.Findings = append(diagnosis.Findings, corefileFindings(diagnosis.Corefile, diagnosis.Deployment != "")...)
+	return diagnosis, nil
+}
+
+// describeDeployment records the replicas and image of the CoreDNS deployment
+func (d *DNSDiagnosis) describeDeployment(deployment *appsv1.Deployment) {
+	d.Replicas = 1
+	if deployment.Spec.Replicas != nil {
+		d.Replicas = *deployment.Spec.Replicas
+	}
+	d.ReadyReplicas = deployment.Status.ReadyReplicas
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == dnsDeployment {
+			d.Image = container.Image
+		}
+	}
+	switch {
+	case d.Replicas == 0:
+		d.Findings = append(d.Findings, "the CoreDNS deployment is scaled to 0 replicas")
+	case d.ReadyReplicas < d.Replicas:
+		d.Findings = append(d.Findings, fmt.Sprintf("only %d of %d CoreDNS replicas are ready", d.ReadyReplicas, d.Replicas))
+	}
+	if d.Replicas == 1 {
+		d.Findings = append(d.Findings, "CoreDNS runs a single replica, so cluster DNS fails whenever that pod restarts or its node drains")
+	}
+}
+
+// dnsPod summarizes the state of a CoreDNS pod
+func dnsPod(pod corev1.Pod) DNSPod {
+	dns := DNSPod{Name: pod.Name, Node: pod.Spec.NodeName, Phase: string(pod.Status.Phase)}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			dns.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		dns.Restarts += status.RestartCount
+		if status.State.Waiting != nil {
+			dns.Reason = status.State.Waiting.Reason
+		} else if status.LastTerminationState.Terminated != nil && dns.Reason == "" {
+			dns.Reason = status.LastTerminationState.Terminated.Reason
+		}
+	}
+	return dns
+}
+
+// podFindings reports CoreDNS pods that are not ready or restart, and replicas sharing a node
+func (d *DNSDiagnosis) podFindings() []string {
+	var findings []string
+	nodes := make(map[string]int)
+	for _, pod := range d.Pods {
+		nodes[pod.Node]++
+		switch {
+		case !pod.Ready:
+			reason := pod.Phase
+			if pod.Reason != "" {
+				reason = pod.Reason
+			}
+			findings = append(findings, fmt.Sprintf("CoreDNS pod %s is not ready (%s)", pod.Name, reason))
+		case pod.Restarts > 0:
+			finding := fmt.Sprintf("CoreDNS pod %s restarted %d times", pod.Name, pod.Restarts)
+			if pod.Reason != "" {
+				finding += ", last terminated with " + pod.Reason
+			}
+			findings = append(findings, finding)
+		}
+	}
+	if len(d.Pods) > 1 && len(nodes) == 1 {
+		findings = append(findings, "all CoreDNS pods run on the same node, so losing that node stops cluster DNS")
+	}
+	return findings
+}
+
+// corefileFindings reports Corefile settings known to break or slow down resolution
+func corefileFindings(corefile string, installed bool) []string {
+	if corefile == "" {
+		if !installed {
+			return nil
+		}
+		return []string{fmt.Sprintf("the %s/%s configmap has no Corefile", dnsNamespace, dnsConfigMap)}
+	}
+	var findings []string
+	if !strings.Contains(corefile, "kubernetes ") {
+		findings = append(findings, "the Corefile has no kubernetes plugin, so service names do not resolve")
+	}
+	if !strings.Contains(corefile, "forward ") && !strings.Contains(corefile, "proxy ") {
+		findings = append(findings, "the Corefile has no forward plugin, so names outside the cluster do not resolve")
+	}
+	if !strings.Contains(corefile, "loop") {
+		findings = append(findings, "the Corefile has no loop plugin, so a forwarding loop to the node's resolver goes undetected and exhausts CoreDNS")
+	}
+	if !strings.Contains(corefile, "cache") {
+		findings = append(findings, "the Corefile has no cache plugin, so every query is forwarded upstream")
+	}
+	return findings
+}
+
+// RunDNSLookups resolves names from a short-lived pod in namespace, using the cluster DNS like any
+// application pod, and deletes the pod when done. Like every write, it is refused unless the
+// client was created with writes allowed.
+func (c *Client) RunDNSLookups(ctx context.Context, namespace, image string, names []string) ([]DNSLookup, error) {
+	if c.config.ReadOnly {
+		return nil, fmt.Errorf("%w: running a DNS debug pod requires --allow-writes", ErrReadOnly)
+	}
+	for _, name := range names {
+		if !dnsName.MatchString(name) {
+			return nil, fmt.Errorf("invalid name to look up %q", name)
+		}
+	}
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	// Each lookup is delimited by a marker line, so the logs can be split back per name
+	var script strings.Builder
+	script.WriteString("cat /etc/resolv.conf; ")
+	for _, name := range names {
+		fmt.Fprintf(&script, "echo '### %s'; nslookup %s 2>&1; ", name, name)
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kube-ai-dns-",
+			Namespace:    namespace,
+			Labels:       map[string]string{"app.kubernetes.io/managed-by": FieldManager},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:    "lookup",
+				Image:   image,
+				Command: []string{"sh", "-c", script.String()},
+			}},
+		},
+	}
+	pods := c.clientset.CoreV1().Pods(namespace)
+	created, err := pods.Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating DNS debug pod: %w", err)
+	}
+	defer func() {
+		_ = pods.Delete(context.Background(), created.Name, metav1.DeleteOptions{})
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		current, err := pods.Get(waitCtx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error waiting for DNS debug pod %s: %w", created.Name, err)
+		}
+		if current.Status.Phase == corev1.PodSucceeded || current.Status.Phase == corev1.PodFailed {
+			break
+		}
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("DNS debug pod %s did not complete within %s (is image %s pullable?)", created.Name, dnsLookupTimeout, image)
+		case <-ticker.C:
+		}
+	}
+
+	output, err := pods.GetLogs(created.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DNS debug pod logs: %w", err)
+	}
+	return parseDNSLookups(string(output)), nil
+}
+
+// parseDNSLookups splits the debug pod output into the pod's resolv.conf and one result per name
+func parseDNSLookups(output string) []DNSLookup {
+	var lookups []DNSLookup
+	current := &DNSLookup{Name: "/etc/resolv.conf"}
+	var lines []string
+	flush := func() {
+		current.Output = strings.TrimSpace(strings.Join(lines, "\n"))
+		lower := strings.ToLower(current.Output)
+		current.Failed = current.Name != "/etc/resolv.conf" && (strings.Contains(lower, "can't find") ||
+			strings.Contains(lower, "can't resolve") || strings.Contains(lower, "nxdomain") ||
+			strings.Contains(lower, "timed out") || strings.Contains(lower, "no servers could be reached") ||
+			strings.Contains(lower, "servfail"))
+		lookups = append(lookups, *current)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if name, ok := strings.CutPrefix(line, "### "); ok {
+			flush()
+			current, lines = &DNSLookup{Name: strings.TrimSpace(name)}, nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return lookups
+}
@@ -0,0 +1,168 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// environmentNodeSample is how many nodes are read to tell the platform a cluster runs on
+const environmentNodeSample = 20
+
+// ClusterEnvironment describes where and with what a cluster runs, so answers can be tailored to
+// it, such as EKS, GKE or on-premises differences
+type ClusterEnvironment struct {
+	// Platform or cloud provider, such as EKS, GKE, AKS or kind ("" if unknown)
+	Platform string `json:"platform,omitempty"`
+	// Network plugins found running, such as Cilium or Calico
+	CNI []string `json:"cni,omitempty"`
+	// Ingress classes, as name (controller)
+	IngressClasses []string `json:"ingressClasses,omitempty"`
+	// Operators and add-ons found by the API groups they serve, such as cert-manager
+	Operators []string `json:"operators,omitempty"`
+}
+
+// platformLabels tell platforms apart by a label of their nodes
+var platformLabels = []struct {
+	label    string
+	platform string
+}{
+	{"eks.amazonaws.com/nodegroup", "EKS"},
+	{"eks.amazonaws.com/compute-type", "EKS"},
+	{"cloud.google.com/gke-nodepool", "GKE"},
+	{"kubernetes.azure.com/cluster", "AKS"},
+	{"node.openshift.io/os_id", "OpenShift"},
+	{"doks.digitalocean.com/node-pool", "DigitalOcean Kubernetes"},
+	{"oke.oraclecloud.com/node.info.private_subnet", "OKE"},
+	{"minikube.k8s.io/name", "minikube"},
+	{"node.kubernetes.io/instance-type=k3s", "k3s"},
+}
+
+// providerPlatforms name platforms by the scheme of their nodes' provider IDs
+var providerPlatforms = map[string]string{
+	"aws":          "AWS",
+	"gce":          "Google Cloud",
+	"azure":        "Azure",
+	"kind":         "kind",
+	"digitalocean": "DigitalOcean",
+	"openstack":    "OpenStack",
+	"vsphere":      "vSphere",
+	"k3s":          "k3s",
+	"oci":          "Oracle Cloud",
+	"hcloud":       "Hetzner Cloud",
+}
+
+// cniDaemonSets name network plugins by the prefix of the DaemonSets that run them
+var cniDaemonSets = []struct {
+	prefix string
+	cni    string
+}{
+	{"cilium", "Cilium"},
+	{"calico-node", "Calico"},
+	{"canal", "Canal"},
+	{"aws-node", "Amazon VPC CNI"},
+	{"kube-flannel", "Flannel"},
+	{"weave-net", "Weave Net"},
+	{"antrea-agent", "Antrea"},
+	{"kube-router", "kube-router"},
+	{"azure-cns", "Azure CNI"},
+	{"ovnkube-node", "OVN-Kubernetes"},
+	{"kindnet", "kindnet"},
+	{"netd", "GKE Dataplane"},
+}
+
+// operatorGroups name operators and add-ons by a suffix of the API groups they serve
+var operatorGroups = []struct {
+	suffix   string
+	operator string
+}{
+	{"cert-manager.io", "cert-manager"},
+	{"argoproj.io", "Argo"},
+	{"toolkit.fluxcd.io", "Flux"},
+	{"istio.io", "Istio"},
+	{"linkerd.io", "Linkerd"},
+	{"monitoring.coreos.com", "Prometheus Operator"},
+	{"karpenter.sh", "Karpenter"},
+	{"keda.sh", "KEDA"},
+	{"external-secrets.io", "External Secrets"},
+	{"kyverno.io", "Kyverno"},
+	{"gatekeeper.sh", "Gatekeeper"},
+	{"velero.io", "Velero"},
+	{"traefik.io", "Traefik"},
+	{"crossplane.io", "Crossplane"},
+	{"knative.dev", "Knative"},
+	{"tekton.dev", "Tekton"},
+	{"kubevirt.io", "KubeVirt"},
+	{"ceph.rook.io", "Rook"},
+	{"postgresql.cnpg.io", "CloudNativePG"},
+	{"strimzi.io", "Strimzi"},
+	{"k8s.elastic.co", "Elastic"},
+	{"gateway.networking.k8s.io", "Gateway API"},
+}
+
+// GetClusterEnvironment describes the platform, network plugin, ingress classes and operators of
+// the cluster. Each part is best effort: what cannot be read, such as for lack of permissions,
+// is left out.
+func (c *Client) GetClusterEnvironment(ctx context.Context) (*ClusterEnvironment, error) {
+	env := &ClusterEnvironment{}
+
+	if nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: environmentNodeSample}); err == nil {
+		for _, node := range nodes.Items {
+			if env.Platform = nodePlatform(node.Labels, node.Spec.ProviderID); env.Platform != "" {
+				break
+			}
+		}
+	}
+
+	if daemonSets, err := c.clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{}); err == nil {
+		for _, daemonSet := range daemonSets.Items {
+			for _, known := range cniDaemonSets {
+				if strings.HasPrefix(daemonSet.Name, known.prefix) {
+					env.CNI = AppendUnique(env.CNI, known.cni)
+				}
+			}
+		}
+	}
+
+	if classes, err := c.clientset.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{}); err == nil {
+		for _, class := range classes.Items {
+			env.IngressClasses = append(env.IngressClasses, fmt.Sprintf("%s (%s)", class.Name, class.Spec.Controller))
+		}
+	}
+
+	groups, err := c.clientset.Discovery().ServerGroups()
+	if err == nil {
+		for _, group := range groups.Groups {
+			for _, known := range operatorGroups {
+				if group.Name == known.suffix || strings.HasSuffix(group.Name, "."+known.suffix) {
+					env.Operators = AppendUnique(env.Operators, known.operator)
+				}
+			}
+		}
+	}
+
+	sort.Strings(env.CNI)
+	sort.Strings(env.IngressClasses)
+	sort.Strings(env.Operators)
+	return env, nil
+}
+
+// nodePlatform tells the platform of a node from its labels, else from its provider ID
+func nodePlatform(labels map[string]string, providerID string) string {
+	for _, known := range platformLabels {
+		key, value, hasValue := strings.Cut(known.label, "=")
+		if actual, ok := labels[key]; ok && (!hasValue || actual == value) {
+			return known.platform
+		}
+	}
+	if scheme, _, found := strings.Cut(providerID, "://"); found {
+		if platform, ok := providerPlatforms[scheme]; ok {
+			return platform
+		}
+		return scheme
+	}
+	return ""
+}
@@ -0,0 +1,408 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxFluxEvents limits the events reported for a Flux object and its source
+const maxFluxEvents = 15
+
+// maxFluxValues limits the inline Helm values of a HelmRelease included in prompts, in bytes
+const maxFluxValues = 2000
+
+// Flux kinds that reconcile workloads, and the group of the sources they reconcile from
+var (
+	fluxKustomization = schema.GroupKind{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}
+	fluxHelmRelease   = schema.GroupKind{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"}
+	fluxSourceGroup   = "source.toolkit.fluxcd.io"
+)
+
+// FluxObject describes a Flux Kustomization or HelmRelease: what it reconciles, its conditions,
+// the state of its source and dependencies, recent events, and the failure modes they show
+type FluxObject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Suspended bool   `json:"suspended"`
+	// Kustomization path in the source, or HelmRelease chart as name@version
+	Path  string `json:"path,omitempty"`
+	Chart string `json:"chart,omitempty"`
+	// Inline Helm values, as YAML cut to a few kilobytes, and where other values come from
+	Values     string   `json:"values,omitempty"`
+	ValuesFrom []string `json:"valuesFrom,omitempty"`
+	// Revisions last applied and last attempted
+	Revision          string           `json:"revision,omitempty"`
+	AttemptedRevision string           `json:"attemptedRevision,omitempty"`
+	Conditions        []FluxCondition  `json:"conditions"`
+	Source            *FluxSource      `json:"source,omitempty"`
+	DependsOn         []FluxDependency `json:"dependsOn,omitempty"`
+	Events            []FluxEvent      `json:"events,omitempty"`
+	Failures          []FluxFailure    `json:"failures,omitempty"`
+}
+
+// FluxCondition is a status condition of a Flux object
+type FluxCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// FluxSource is the source a Flux object reconciles from: a GitRepository, OCIRepository,
+// Bucket, HelmRepository or the HelmChart built for a HelmRelease
+type FluxSource struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	URL       string `json:"url,omitempty"`
+	// Revision of the artifact the source controller last fetched
+	Revision   string          `json:"revision,omitempty"`
+	Conditions []FluxCondition `json:"conditions,omitempty"`
+	// Set when the source does not exist
+	Missing bool `json:"missing,omitempty"`
+}
+
+// FluxDependency is an object a Flux object depends on, with its readiness
+type FluxDependency struct {
+	Name string `json:"name"`
+	// Status of its Ready condition, or NotFound
+	Ready   string `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+// FluxEvent is an event recorded for a Flux object or its source
+type FluxEvent struct {
+	Time    time.Time `json:"time"`
+	Object  string    `json:"object"`
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+}
+
+// FluxFailure is a known failure mode found in a Flux object's status, source or events, with
+// where to look for the fix
+type FluxFailure struct {
+	Mode string `json:"mode"`
+	Hint string `json:"hint"`
+}
+
+// fluxFailureModes are the failure modes recognized in the reasons and messages of Flux
+// conditions and events, most specific first
+var fluxFailureModes = []struct {
+	FluxFailure
+	reasons []string
+	message *regexp.Regexp
+}{
+	{FluxFailure{"chart not found", "The chart name or version does not exist in the Helm repository: check spec.chart.spec.chart and version against the repository index."},
+		nil, regexp.MustCompile(`(?i)no chart (name|version) found|chart "?[^"]*"? version "?[^"]*"? not found|invalid chart reference|failed to get chart version|chart not found`)},
+	{FluxFailure{"values schema error", "The Helm values do not validate against the chart's values.schema.json: fix the values named in the message."},
+		nil, regexp.MustCompile(`(?i)values don't meet the specifications of the schema|values\.schema\.json`)},
+	{FluxFailure{"dependency not ready", "An object listed in spec.dependsOn is not ready yet: fix that object first, reconciliation resumes once it is ready."},
+		[]string{"DependencyNotReady"}, regexp.MustCompile(`(?i)dependency .* (is not ready|not found)`)},
+	{FluxFailure{"source not ready", "The source artifact could not be fetched: check the source's conditions, URL, credentials and reference."},
+		[]string{"ArtifactFailed", "SourceNotReady", "GitOperationFailed", "FetchFailed", "IndexationFailed"}, regexp.MustCompile(`(?i)source artifact not found|source .* not found|failed to checkout|unable to clone`)},
+	{FluxFailure{"authentication failed", "The source controller was refused access: check the secretRef of the source and that the credentials are still valid."},
+		[]string{"AuthenticationFailed"}, regexp.MustCompile(`(?i)authentication required|401 unauthorized|403 forbidden|could not read username|permission denied \(publickey\)`)},
+	{FluxFailure{"kustomize build failed", "The manifests at the Kustomization path do not build: check kustomization.yaml, patches and the path in the source."},
+		[]string{"BuildFailed"}, regexp.MustCompile(`(?i)kustomize build failed|accumulating resources`)},
+	{FluxFailure{"dry-run failed", "The API server rejected a manifest: a field is invalid or a CRD the manifests use is not installed yet."},
+		nil, regexp.MustCompile(`(?i)dry-run failed|no matches for kind`)},
+	{FluxFailure{"health check failed", "The applied workloads did not become ready within the timeout: diagnose their pods, the manifests applied fine."},
+		[]string{"HealthCheckFailed"}, regexp.MustCompile(`(?i)health check failed|timeout waiting for`)},
+	{FluxFailure{"install or upgrade failed", "Helm could not install or upgrade the release: check the chart's templates and hooks, and remediation settings once retries are exhausted."},
+		[]string{"InstallFailed", "UpgradeFailed", "RetriesExceeded", "TestFailed", "RollbackFailed"}, regexp.MustCompile(`(?i)(install|upgrade) retries exhausted|Helm (install|upgrade) failed`)},
+}
+
+// GetFluxObject describes a Kustomization or HelmRelease
+func (c *Client) GetFluxObject(ctx context.Context, kind, namespace, name string) (*FluxObject, error) {
+	groupKind, err := FluxGroupKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := c.GetObject(ctx, groupKind, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s %s/%s: %w", groupKind.Kind, namespace, name, err)
+	}
+	return c.describeFluxObject(ctx, obj), nil
+}
+
+// ListFailingFluxObjects describes the Kustomizations and HelmReleases in namespace, or in all
+// namespaces when namespace is empty, that are not ready
+func (c *Client) ListFailingFluxObjects(ctx context.Context, namespace string) ([]*FluxObject, error) {
+	var failing []*FluxObject
+	for _, groupKind := range []schema.GroupKind{fluxKustomization, fluxHelmRelease} {
+		objects, err := c.ListObjects(ctx, groupKind, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for i := range objects {
+			if fluxConditionStatus(&objects[i], "Ready") == "True" {
+				continue
+			}
+			failing = append(failing, c.describeFluxObject(ctx, &objects[i]))
+		}
+	}
+	return failing, nil
+}
+
+// FluxGroupKind resolves the kinds and short names accepted for Flux objects
+func FluxGroupKind(kind string) (schema.GroupKind, error) {
+	switch strings.ToLower(kind) {
+	case "kustomization", "kustomizations", "ks":
+		return fluxKustomization, nil
+	case "helmrelease", "helmreleases", "hr":
+		return fluxHelmRelease, nil
+	default:
+		return schema.GroupKind{}, fmt.Errorf("unsupported Flux kind %q, use kustomization or helmrelease", kind)
+	}
+}
+
+// describeFluxObject collects the conditions, source, dependencies and events of a Flux object
+func (c *Client) describeFluxObject(ctx context.Context, obj *unstructured.Unstructured) *FluxObject {
+	flux := &FluxObject{
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Conditions: fluxConditions(obj.Object),
+	}
+	flux.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+	flux.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "lastAppliedRevision")
+	flux.AttemptedRevision, _, _ = unstructured.NestedString(obj.Object, "status", "lastAttemptedRevision")
+
+	var sourceRef map[string]interface{}
+	switch flux.Kind {
+	case fluxKustomization.Kind:
+		flux.Path, _, _ = unstructured.NestedString(obj.Object, "spec", "path")
+		sourceRef, _, _ = unstructured.NestedMap(obj.Object, "spec", "sourceRef")
+	case fluxHelmRelease.Kind:
+		chart, _, _ := unstructured.NestedString(obj.Object, "spec", "chart", "spec", "chart")
+		version, _, _ := unstructured.NestedString(obj.Object, "spec", "chart", "spec", "version")
+		flux.Chart = chart
+		if version != "" {
+			flux.Chart += "@" + version
+		}
+		if values, ok, _ := unstructured.NestedMap(obj.Object, "spec", "values"); ok {
+			flux.Values = compactYAML(values)
+			if len(flux.Values) > maxFluxValues {
+				flux.Values = flux.Values[:maxFluxValues] + "\n# ... cut"
+			}
+		}
+		valuesFrom, _, _ := unstructured.NestedSlice(obj.Object, "spec", "valuesFrom")
+		for _, item := range valuesFrom {
+			if item, ok := item.(map[string]interface{}); ok {
+				kind, _, _ := unstructured.NestedString(item, "kind")
+				name, _, _ := unstructured.NestedString(item, "name")
+				flux.ValuesFrom = append(flux.ValuesFrom, kind+"/"+name)
+			}
+		}
+		// The source of a HelmRelease is the HelmChart built for it, or the one it references
+		if helmChart, _, _ := unstructured.NestedString(obj.Object, "status", "helmChart"); helmChart != "" {
+			namespace, name, _ := strings.Cut(helmChart, "/")
+			sourceRef = map[string]interface{}{"kind": "HelmChart", "namespace": namespace, "name": name}
+		} else if chartRef, ok, _ := unstructured.NestedMap(obj.Object, "spec", "chartRef"); ok {
+			sourceRef = chartRef
+		} else {
+			sourceRef, _, _ = unstructured.NestedMap(obj.Object, "spec", "chart", "spec", "sourceRef")
+		}
+	}
+	if sourceRef != nil {
+		flux.Source = c.fluxSource(ctx, sourceRef, flux.Namespace)
+	}
+
+	dependsOn, _, _ := unstructured.NestedSlice(obj.Object, "spec", "dependsOn")
+	for _, item := range dependsOn {
+		if item, ok := item.(map[string]interface{}); ok {
+			flux.DependsOn = append(flux.DependsOn, c.fluxDependency(ctx, obj.GroupVersionKind().GroupKind(), item, flux.Namespace))
+		}
+	}
+
+	flux.Events = c.fluxEvents(ctx, flux)
+	flux.Failures = flux.failureModes()
+	return flux
+}
+
+// fluxSource describes the source a reference points to
+func (c *Client) fluxSource(ctx context.Context, ref map[string]interface{}, defaultNamespace string) *FluxSource {
+	source := &FluxSource{Namespace: defaultNamespace}
+	source.Kind, _, _ = unstructured.NestedString(ref, "kind")
+	source.Name, _, _ = unstructured.NestedString(ref, "name")
+	if namespace, _, _ := unstructured.NestedString(ref, "namespace"); namespace != "" {
+		source.Namespace = namespace
+	}
+
+	obj, err := c.GetObject(ctx, schema.GroupKind{Group: fluxSourceGroup, Kind: source.Kind}, source.Namespace, source.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			source.Missing = true
+		} else {
+			source.Conditions = []FluxCondition{{Type: "Ready", Status: "Unknown", Message: err.Error()}}
+		}
+		return source
+	}
+	source.URL, _, _ = unstructured.NestedString(obj.Object, "spec", "url")
+	if source.URL == "" {
+		source.URL, _, _ = unstructured.NestedString(obj.Object, "spec", "chart")
+	}
+	source.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "artifact", "revision")
+	source.Conditions = fluxConditions(obj.Object)
+	return source
+}
+
+// fluxDependency describes an object listed in dependsOn, of the same kind as the dependent
+func (c *Client) fluxDependency(ctx context.Context, groupKind schema.GroupKind, ref map[string]interface{}, defaultNamespace string) FluxDependency {
+	name, _, _ := unstructured.NestedString(ref, "name")
+	namespace, _, _ := unstructured.NestedString(ref, "namespace")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	dependency := FluxDependency{Name: namespace + "/" + name}
+
+	obj, err := c.GetObject(ctx, groupKind, namespace, name)
+	if apierrors.IsNotFound(err) {
+		dependency.Ready = "NotFound"
+		return dependency
+	}
+	if err != nil {
+		dependency.Ready, dependency.Message = "Unknown", err.Error()
+		return dependency
+	}
+	dependency.Ready = fluxConditionStatus(obj, "Ready")
+	for _, condition := range fluxConditions(obj.Object) {
+		if condition.Type == "Ready" {
+			dependency.Message = condition.Message
+		}
+	}
+	return dependency
+}
+
+// fluxEvents returns the recent events of a Flux object and its source, newest last
+func (c *Client) fluxEvents(ctx context.Context, flux *FluxObject) []FluxEvent {
+	type object struct{ kind, namespace, name string }
+	objects := []object{{flux.Kind, flux.Namespace, flux.Name}}
+	if flux.Source != nil && !flux.Source.Missing {
+		objects = append(objects, object{flux.Source.Kind, flux.Source.Namespace, flux.Source.Name})
+	}
+
+	var events []FluxEvent
+	for _, o := range objects {
+		list, err := c.ListEvents(ctx, o.namespace, o.name)
+		if err != nil {
+			SkipSource(SourceEvents, err)
+			continue
+		}
+		for _, event := range list {
+			if event.InvolvedObject.Kind != o.kind || event.InvolvedObject.Name != o.name {
+				continue
+			}
+			events = append(events, FluxEvent{
+				Time:    eventTime(event),
+				Object:  o.kind + "/" + o.name,
+				Type:    event.Type,
+				Reason:  event.Reason,
+				Message: strings.TrimSpace(event.Message),
+			})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	if len(events) > maxFluxEvents {
+		events = events[len(events)-maxFluxEvents:]
+	}
+	return events
+}
+
+// failureModes finds the known failure modes in the object's conditions, source, dependencies
+// and warning events
+func (f *FluxObject) failureModes() []FluxFailure {
+	type signal struct{ reason, message string }
+	var signals []signal
+	for _, condition := range f.Conditions {
+		if condition.Status != "True" || condition.Type == "Stalled" {
+			signals = append(signals, signal{condition.Reason, condition.Message})
+		}
+	}
+	if f.Source != nil {
+		for _, condition := range f.Source.Conditions {
+			if condition.Type == "Ready" && condition.Status != "True" {
+				signals = append(signals, signal{"SourceNotReady", condition.Message})
+			}
+		}
+	}
+	for _, event := range f.Events {
+		if event.Type == corev1.EventTypeWarning {
+			signals = append(signals, signal{event.Reason, event.Message})
+		}
+	}
+
+	var failures []FluxFailure
+	if f.Suspended {
+		failures = append(failures, FluxFailure{"suspended", "Reconciliation is suspended: resume it with flux resume once the cause is fixed."})
+	}
+	if f.Source != nil && f.Source.Missing {
+		failures = append(failures, FluxFailure{"source not found", fmt.Sprintf("The %s %s/%s does not exist: create it or fix the source reference.", f.Source.Kind, f.Source.Namespace, f.Source.Name)})
+	}
+	for _, dependency := range f.DependsOn {
+		if dependency.Ready != "True" {
+			signals = append(signals, signal{"DependencyNotReady", ""})
+			break
+		}
+	}
+	for _, mode := range fluxFailureModes {
+		for _, s := range signals {
+			if slices.Contains(mode.reasons, s.reason) || (s.message != "" && mode.message.MatchString(s.message)) {
+				failures = append(failures, mode.FluxFailure)
+				break
+			}
+		}
+	}
+	return failures
+}
+
+// Ready returns the status, reason and message of the object's Ready condition
+func (f *FluxObject) Ready() FluxCondition {
+	for _, condition := range f.Conditions {
+		if condition.Type == "Ready" {
+			return condition
+		}
+	}
+	return FluxCondition{Type: "Ready", Status: "Unknown"}
+}
+
+// fluxConditions reads the status conditions of a Flux object
+func fluxConditions(obj map[string]interface{}) []FluxCondition {
+	items, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	conditions := make([]FluxCondition, 0, len(items))
+	for _, item := range items {
+		item, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var condition FluxCondition
+		condition.Type, _, _ = unstructured.NestedString(item, "type")
+		condition.Status, _, _ = unstructured.NestedString(item, "status")
+		condition.Reason, _, _ = unstructured.NestedString(item, "reason")
+		condition.Message, _, _ = unstructured.NestedString(item, "message")
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// fluxConditionStatus returns the status of a condition of a Flux object, or Unknown
+func fluxConditionStatus(obj *unstructured.Unstructured, conditionType string) string {
+	for _, condition := range fluxConditions(obj.Object) {
+		if condition.Type == conditionType {
+			return condition.Status
+		}
+	}
+	return "Unknown"
+}
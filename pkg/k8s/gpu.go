@@ -0,0 +1,305 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// gpuResourcePrefixes are the extended resources device plugins advertise for GPUs: whole NVIDIA
+// GPUs, MIG slices under the mixed strategy such as nvidia.com/mig-1g.10gb, AMD and Intel GPUs
+var gpuResourcePrefixes = []string{"nvidia.com/gpu", "nvidia.com/mig-", "amd.com/gpu", "gpu.intel.com/"}
+
+// gpuNodeLabels are node labels set by GPU feature discovery and the GPU operator that describe
+// a node's GPUs and MIG configuration
+var gpuNodeLabels = []string{
+	"nvidia.com/gpu.product",
+	"nvidia.com/gpu.memory",
+	"nvidia.com/cuda.driver.major",
+	"nvidia.com/mig.strategy",
+	"nvidia.com/mig.config",
+	"nvidia.com/mig.config.state",
+	"nvidia.com/gpu.deploy.device-plugin",
+}
+
+// maxGPUPods limits the GPU pods reported
+const maxGPUPods = 50
+
+// GPUReport describes the GPUs of a cluster: nodes advertising them, the health of the device
+// plugins that advertise them, the pods requesting them, and the problems found
+type GPUReport struct {
+	Nodes         []GPUNode         `json:"nodes"`
+	DevicePlugins []GPUDevicePlugin `json:"devicePlugins"`
+	Pods          []GPUPod          `json:"pods"`
+	Findings      []string          `json:"findings"`
+}
+
+// GPUNode is a node with GPU resources, how many its pods request, and its GPU labels and taints
+type GPUNode struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	// GPU resources in capacity and allocatable, and requested by the node's pods, by resource name
+	Capacity    map[string]int64  `json:"capacity"`
+	Allocatable map[string]int64  `json:"allocatable"`
+	Requested   map[string]int64  `json:"requested"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Taints      []string          `json:"taints,omitempty"`
+}
+
+// GPUDevicePlugin is a DaemonSet running a GPU device plugin, with how many of its pods are ready
+type GPUDevicePlugin struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Desired   int32  `json:"desired"`
+	Ready     int32  `json:"ready"`
+}
+
+// GPUPod is a pod requesting GPUs, with where it is scheduled and how its containers are doing
+type GPUPod struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Node      string           `json:"node,omitempty"`
+	Phase     string           `json:"phase"`
+	Requests  map[string]int64 `json:"requests"`
+	// Node selector and tolerations, which decide which GPU nodes the pod can run on
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	Tolerations  []string          `json:"tolerations,omitempty"`
+	Restarts     int32             `json:"restarts"`
+	// Reason a container last terminated or is waiting, such as OOMKilled or CrashLoopBackOff
+	Reason string `json:"reason,omitempty"`
+	// Why the scheduler could not place the pod, such as Insufficient nvidia.com/gpu
+	Unschedulable string `json:"unschedulable,omitempty"`
+	// Log lines with CUDA, NCCL, NVML or out of memory errors, when collected
+	Errors []string `json:"errors,omitempty"`
+}
+
+// IsGPUResource reports whether a resource name is a GPU advertised by a device plugin
+func IsGPUResource(name corev1.ResourceName) bool {
+	for _, prefix := range gpuResourcePrefixes {
+		if strings.HasPrefix(string(name), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGPUReport collects the GPU nodes and device plugins of the cluster, and the pods requesting
+// GPUs in namespace, or in all namespaces when namespace is empty. Pods opted out with the
+// kube-ai.io/ignore annotation are left out of the pods listed but still count as requesting GPUs.
+func (c *Client) GetGPUReport(ctx context.Context, namespace string) (*GPUReport, error) {
+	report := &GPUReport{Nodes: []GPUNode{}, DevicePlugins: []GPUDevicePlugin{}, Pods: []GPUPod{}, Findings: []string{}}
+
+	// Pods first, to know what each node's GPUs are requested by when its page is read
+	ignored := c.NewOptOutFilter(ctx, namespace)
+	requested := make(map[string]map[string]int64)
+	err := EachCachedListItem(ctx, c, corev1.SchemeGroupVersion.WithResource("pods"), "", c.clientset.CoreV1().Pods("").List, metav1.ListOptions{}, func(pod *corev1.Pod) error {
+		requests := gpuRequests(*pod)
+		if len(requests) == 0 {
+			return nil
+		}
+		if pod.Spec.NodeName != "" && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			if requested[pod.Spec.NodeName] == nil {
+				requested[pod.Spec.NodeName] = make(map[string]int64)
+			}
+			for name, count := range requests {
+				requested[pod.Spec.NodeName][name] += count
+			}
+		}
+		if (namespace == "" || pod.Namespace == namespace) && len(report.Pods) < maxGPUPods && !ignored.IgnoresPod(*pod) {
+			report.Pods = append(report.Pods, gpuPod(*pod, requests))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+
+	err = EachListItem(ctx, c.clientset.CoreV1().Nodes().List, metav1.ListOptions{}, func(node *corev1.Node) error {
+		gpuNode := GPUNode{Name: node.Name, Capacity: gpuResources(node.Status.Capacity), Allocatable: gpuResources(node.Status.Allocatable), Requested: requested[node.Name]}
+		for _, label := range gpuNodeLabels {
+			if value, ok := node.Labels[label]; ok {
+				if gpuNode.Labels == nil {
+					gpuNode.Labels = make(map[string]string)
+				}
+				gpuNode.Labels[label] = value
+			}
+		}
+		// Nodes labeled by GPU feature discovery have GPUs even when no plugin advertises them
+		if len(gpuNode.Capacity) == 0 && gpuNode.Labels["nvidia.com/gpu.product"] == "" {
+			return nil
+		}
+		if gpuNode.Requested == nil {
+			gpuNode.Requested = map[string]int64{}
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				gpuNode.Ready = condition.Status == corev1.ConditionTrue
+			}
+		}
+		for _, taint := range node.Spec.Taints {
+			gpuNode.Taints = append(gpuNode.Taints, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+		}
+		report.Nodes = append(report.Nodes, gpuNode)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+
+	err = EachListItem(ctx, c.clientset.AppsV1().DaemonSets("").List, metav1.ListOptions{}, func(daemonSet *appsv1.DaemonSet) error {
+		if isGPUDevicePlugin(daemonSet.Name) {
+			report.DevicePlugins = append(report.DevicePlugins, GPUDevicePlugin{
+				Name:      daemonSet.Name,
+				Namespace: daemonSet.Namespace,
+				Desired:   daemonSet.Status.DesiredNumberScheduled,
+				Ready:     daemonSet.Status.NumberReady,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing daemonsets: %w", err)
+	}
+
+	report.Findings = report.findings()
+	return report, nil
+}
+
+// isGPUDevicePlugin reports whether a DaemonSet name is that of a GPU device plugin, such as
+// nvidia-device-plugin-daemonset, amdgpu-device-plugin or intel-gpu-plugin
+func isGPUDevicePlugin(name string) bool {
+	name = strings.ToLower(name)
+	if !strings.Contains(name, "device-plugin") && !strings.Contains(name, "gpu-plugin") {
+		return false
+	}
+	return strings.Contains(name, "gpu") || strings.Contains(name, "nvidia")
+}
+
+// gpuResources returns the GPU resources of a resource list
+func gpuResources(list corev1.ResourceList) map[string]int64 {
+	resources := make(map[string]int64)
+	for name, quantity := range list {
+		if IsGPUResource(name) {
+			resources[string(name)] = quantity.Value()
+		}
+	}
+	return resources
+}
+
+// gpuRequests returns the GPUs a pod requests, summed over its containers. Extended resources
+// can only be set as limits, which then default the requests, so limits are read too.
+func gpuRequests(pod corev1.Pod) map[string]int64 {
+	requests := make(map[string]int64)
+	for _, container := range pod.Spec.Containers {
+		counts := gpuResources(container.Resources.Limits)
+		for name, count := range gpuResources(container.Resources.Requests) {
+			if count > counts[name] {
+				counts[name] = count
+			}
+		}
+		for name, count := range counts {
+			if count > 0 {
+				requests[name] += count
+			}
+		}
+	}
+	return requests
+}
+
+// gpuPod summarizes a pod requesting GPUs
+func gpuPod(pod corev1.Pod, requests map[string]int64) GPUPod {
+	gpuPod := GPUPod{
+		Name:         pod.Name,
+		Namespace:    pod.Namespace,
+		Node:         pod.Spec.NodeName,
+		Phase:        string(pod.Status.Phase),
+		Requests:     requests,
+		NodeSelector: pod.Spec.NodeSelector,
+	}
+	for _, toleration := range pod.Spec.Tolerations {
+		gpuPod.Tolerations = append(gpuPod.Tolerations, fmt.Sprintf("%s %s %s:%s", toleration.Key, toleration.Operator, toleration.Value, toleration.Effect))
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		gpuPod.Restarts += status.RestartCount
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			gpuPod.Reason = status.State.Waiting.Reason
+		} else if terminated := status.LastTerminationState.Terminated; terminated != nil && gpuPod.Reason == "" {
+			gpuPod.Reason = terminated.Reason
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			gpuPod.Unschedulable = condition.Message
+		}
+	}
+	return gpuPod
+}
+
+// findings reports device plugins that are not ready, GPU nodes whose GPUs are not advertised or
+// are all taken, and GPU pods that cannot be scheduled or keep failing
+func (r *GPUReport) findings() []string {
+	findings := []string{}
+	if len(r.Nodes) == 0 {
+		findings = append(findings, "no node advertises GPUs: the device plugin is not installed or not running on the GPU nodes")
+	}
+	for _, plugin := range r.DevicePlugins {
+		if plugin.Ready < plugin.Desired {
+			findings = append(findings, fmt.Sprintf("device plugin %s/%s has %d of %d pods ready, so the GPUs of the other nodes are not advertised", plugin.Namespace, plugin.Name, plugin.Ready, plugin.Desired))
+		}
+	}
+	if len(r.DevicePlugins) == 0 && len(r.Nodes) > 0 {
+		findings = append(findings, "no GPU device plugin DaemonSet was found; GPUs may be advertised by a plugin with an unusual name")
+	}
+
+	for _, node := range r.Nodes {
+		var allocatable, requested int64
+		for name, count := range node.Allocatable {
+			allocatable += count
+			requested += node.Requested[name]
+		}
+		switch {
+		case !node.Ready:
+			findings = append(findings, fmt.Sprintf("GPU node %s is not ready", node.Name))
+		case allocatable == 0 && node.Labels["nvidia.com/gpu.product"] != "":
+			findings = append(findings, fmt.Sprintf("node %s has %s GPUs but advertises none allocatable: the device plugin is not running there, or the driver failed to load", node.Name, node.Labels["nvidia.com/gpu.product"]))
+		case allocatable > 0 && requested >= allocatable:
+			findings = append(findings, fmt.Sprintf("all %d GPUs of node %s are requested", allocatable, node.Name))
+		}
+		if state := node.Labels["nvidia.com/mig.config.state"]; state == "failed" {
+			findings = append(findings, fmt.Sprintf("node %s failed to apply MIG configuration %q", node.Name, node.Labels["nvidia.com/mig.config"]))
+		}
+	}
+
+	for _, pod := range r.Pods {
+		name := pod.Namespace + "/" + pod.Name
+		if pod.Unschedulable != "" {
+			findings = append(findings, fmt.Sprintf("GPU pod %s cannot be scheduled: %s", name, pod.Unschedulable))
+		}
+		for resource := range pod.Requests {
+			if strings.HasPrefix(resource, "nvidia.com/mig-") && !r.advertises(resource) {
+				findings = append(findings, fmt.Sprintf("GPU pod %s requests %s, which no node advertises: the nodes use a different MIG profile or the single MIG strategy", name, resource))
+			}
+		}
+		switch {
+		case pod.Reason == "OOMKilled":
+			findings = append(findings, fmt.Sprintf("GPU pod %s was OOMKilled: its host memory limit is too low for the data loaders and CUDA context", name))
+		case pod.Reason != "" && pod.Restarts > 0:
+			findings = append(findings, fmt.Sprintf("GPU pod %s restarted %d times (%s)", name, pod.Restarts, pod.Reason))
+		}
+	}
+	return findings
+}
+
+// advertises reports whether any node has a resource allocatable
+func (r *GPUReport) advertises(resource string) bool {
+	for _, node := range r.Nodes {
+		if node.Allocatable[resource] > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Release is a revision of a Helm release, as Helm stores it in the cluster
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Revision  int    `json:"revision"`
+	// deployed, failed, superseded, pending-upgrade...
+	Status       string `json:"status"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chartVersion"`
+	// Values the release was installed or upgraded with, in YAML, without the chart's defaults
+	Values []byte `json:"-"`
+}
+
+// storedRelease is the part of a release record Helm stores that kube-ai reads
+type storedRelease struct {
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace"`
+	Version   int                    `json:"version"`
+	Config    map[string]interface{} `json:"config"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// GetRelease reads the current revision of a release from the Secrets Helm 3 stores releases in:
+// the latest deployed revision, or the latest one when none is deployed
+func GetRelease(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*Release, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "owner=helm,name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the revisions of release %s: %w", name, err)
+	}
+
+	var latest, deployed *storedRelease
+	for _, secret := range secrets.Items {
+		if secret.Type != "helm.sh/release.v1" {
+			continue
+		}
+		stored, err := decodeRelease(secret.Data["release"])
+		if err != nil {
+			return nil, fmt.Errorf("error reading release %s from secret %s: %w", name, secret.Name, err)
+		}
+		if version, err := strconv.Atoi(secret.Labels["version"]); err == nil && stored.Version == 0 {
+			stored.Version = version
+		}
+		if latest == nil || stored.Version > latest.Version {
+			latest = stored
+		}
+		if stored.Info.Status == "deployed" && (deployed == nil || stored.Version > deployed.Version) {
+			deployed = stored
+		}
+	}
+	if deployed == nil {
+		deployed = latest
+	}
+	if deployed == nil {
+		return nil, fmt.Errorf("release %s not found in namespace %s", name, namespace)
+	}
+
+	release := &Release{
+		Name:         deployed.Name,
+		Namespace:    deployed.Namespace,
+		Revision:     deployed.Version,
+		Status:       deployed.Info.Status,
+		Chart:        deployed.Chart.Metadata.Name,
+		ChartVersion: deployed.Chart.Metadata.Version,
+	}
+	if len(deployed.Config) > 0 {
+		if release.Values, err = yaml.Marshal(deployed.Config); err != nil {
+			return nil, fmt.Errorf("error encoding the values of release %s: %w", name, err)
+		}
+	}
+	return release, nil
+}
+
+// decodeRelease decodes a release record as Helm stores it: base64 of gzipped JSON, or of JSON
+// for old records
+func decodeRelease(data []byte) (*storedRelease, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(decoded, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(decoded))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		if decoded, err = io.ReadAll(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	var stored storedRelease
+	if err := json.Unmarshal(decoded, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
@@ -0,0 +1,64 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Render renders a chart with helm template, as it would be installed as release in namespace,
+// with values applied over the chart's defaults. The chart is a directory, archive or chart
+// reference, as helm template takes it.
+func Render(ctx context.Context, chart, release, namespace string, values []byte) ([]byte, error) {
+	if _, err := exec.LookPath("helm"); err != nil {
+		return nil, fmt.Errorf("helm is not installed or not in PATH: %w", err)
+	}
+
+	args := []string{"template", release, chart, "--namespace", namespace}
+	if len(values) > 0 {
+		file, err := os.CreateTemp("", "kube-ai-values-*.yaml")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(file.Name())
+		_, err = file.Write(values)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error writing values: %w", err)
+		}
+		args = append(args, "--values", file.Name())
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, "helm", args...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		// The last line of helm's output usually says what went wrong
+		if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); lines[len(lines)-1] != "" {
+			return nil, fmt.Errorf("helm template failed on %s: %v: %s", chart, err, lines[len(lines)-1])
+		}
+		return nil, fmt.Errorf("helm template failed on %s: %w", chart, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// NormalizeValues re-encodes values YAML with sorted keys and without comments, so that only
+// changes of the values themselves show in a diff
+func NormalizeValues(values []byte) ([]byte, error) {
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal(values, &parsed); err != nil {
+		return nil, fmt.Errorf("error parsing values: %w", err)
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(parsed)
+}
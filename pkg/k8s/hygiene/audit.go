@@ -0,0 +1,419 @@
+package hygiene
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kube-ai/pkg/k8s"
+)
+
+// Kinds of findings
+const (
+	// A legacy, non-expiring service account token stored in a secret
+	FindingLongLivedToken = "long-lived-token"
+	// Pods that mount a service account token the account has no permissions to use
+	FindingAutomountedToken = "automounted-token"
+	// A secret nothing in its namespace references
+	FindingUnusedSecret = "unused-secret"
+	// A secret used by many workloads
+	FindingBroadSecret = "broad-secret"
+	// Registry credentials nothing in their namespace pulls with, or copied across namespaces
+	FindingRegistrySecret = "registry-secret"
+)
+
+// FindingKinds lists the kinds of findings in the order they are reported
+var FindingKinds = []string{FindingLongLivedToken, FindingAutomountedToken, FindingBroadSecret, FindingRegistrySecret, FindingUnusedSecret}
+
+// Severities of findings
+const (
+	SeverityHigh   = "High"
+	SeverityMedium = "Medium"
+	SeverityLow    = "Low"
+)
+
+// legacyTokenLastUsed is the label the API server sets on legacy token secrets with the date
+// they were last used, since Kubernetes 1.26
+const legacyTokenLastUsed = "kubernetes.io/legacy-token-last-used"
+
+// ignoredSecretTypes are secret types that controllers read without any reference to them
+var ignoredSecretTypes = map[corev1.SecretType]bool{
+	corev1.SecretTypeBootstrapToken: true,
+	"helm.sh/release.v1":            true,
+}
+
+// minCopies is the number of namespaces holding the same registry credentials from which they
+// are reported as copied
+const minCopies = 3
+
+// Finding is a secret or service account token problem
+type Finding struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	// Object the finding is about, such as namespace/secret/name or namespace/deployment/name
+	Object string `json:"object"`
+	Detail string `json:"detail"`
+}
+
+// Report is the result of a secret hygiene audit
+type Report struct {
+	Namespace       string    `json:"namespace,omitempty"`
+	Secrets         int       `json:"secrets"`
+	ServiceAccounts int       `json:"serviceAccounts"`
+	Findings        []Finding `json:"findings"`
+	// Checks that could not run, for lack of permission to read what they need
+	Skipped []string `json:"skipped,omitempty"`
+	// Number of findings left out because their namespace or workload is opted out with the
+	// kube-ai.io/ignore annotation
+	Ignored int `json:"ignored,omitempty"`
+}
+
+// Options control the audit
+type Options struct {
+	// Number of workloads using a secret from which it is reported as broadly mounted
+	BroadThreshold int
+}
+
+// usage records what references a secret
+type usage struct {
+	workloads       []string
+	serviceAccounts []string
+	ingresses       []string
+}
+
+// referenced reports whether anything references the secret
+func (u *usage) referenced() bool {
+	return u != nil && len(u.workloads)+len(u.serviceAccounts)+len(u.ingresses) > 0
+}
+
+// Audit reads the secrets, pods, service accounts, ingresses and RBAC bindings of a namespace
+// (all namespaces if empty) and reports long-lived service account tokens, tokens mounted by
+// pods whose service account has no permissions, unused and broadly mounted secrets, and registry
+// credentials that are unused in their namespace or copied across namespaces. Secret values are
+// only hashed to compare registry credentials; they are never returned.
+func Audit(ctx context.Context, client *k8s.Client, namespace string, options Options) (*Report, error) {
+	clientset := client.GetClientset()
+	report := &Report{Namespace: namespace, Findings: []Finding{}}
+	all := metav1.ListOptions{}
+
+	pods, err := k8s.ListAllCached[*corev1.PodList, corev1.Pod](ctx, client, corev1.SchemeGroupVersion.WithResource("pods"), namespace, clientset.CoreV1().Pods(namespace).List, all)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+
+	// Optional resources: without them some references or checks are missing
+	optional := func(resource string, err error) error {
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("cannot list %s: %v", resource, err))
+			return nil
+		}
+		return fmt.Errorf("error listing %s: %w", resource, err)
+	}
+	var serviceAccounts []corev1.ServiceAccount
+	if list, err := k8s.ListAllCached[*corev1.ServiceAccountList, corev1.ServiceAccount](ctx, client, corev1.SchemeGroupVersion.WithResource("serviceaccounts"), namespace, clientset.CoreV1().ServiceAccounts(namespace).List, all); err == nil {
+		serviceAccounts = list
+	} else if err := optional("serviceaccounts", err); err != nil {
+		return nil, err
+	}
+	report.ServiceAccounts = len(serviceAccounts)
+	var ingresses []networkingv1.Ingress
+	err = k8s.EachListItem(ctx, clientset.NetworkingV1().Ingresses(namespace).List, all, func(ingress *networkingv1.Ingress) error {
+		ingresses = append(ingresses, *ingress)
+		return nil
+	})
+	if err != nil {
+		if err := optional("ingresses", err); err != nil {
+			return nil, err
+		}
+	}
+	var roleBindings []rbacv1.RoleBinding
+	var clusterRoleBindings []rbacv1.ClusterRoleBinding
+	rbacReadable := true
+	if list, err := k8s.ListAllCached[*rbacv1.RoleBindingList, rbacv1.RoleBinding](ctx, client, rbacv1.SchemeGroupVersion.WithResource("rolebindings"), namespace, clientset.RbacV1().RoleBindings(namespace).List, all); err == nil {
+		roleBindings = list
+	} else if err := optional("rolebindings", err); err != nil {
+		return nil, err
+	} else {
+		rbacReadable = false
+	}
+	if list, err := k8s.ListAllCached[*rbacv1.ClusterRoleBindingList, rbacv1.ClusterRoleBinding](ctx, client, rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), "", clientset.RbacV1().ClusterRoleBindings().List, all); err == nil {
+		clusterRoleBindings = list
+	} else if err := optional("clusterrolebindings", err); err != nil {
+		return nil, err
+	} else {
+		rbacReadable = false
+	}
+
+	usages := secretUsages(pods, serviceAccounts, ingresses)
+	accounts := make(map[string]corev1.ServiceAccount)
+	for _, account := range serviceAccounts {
+		accounts[account.Namespace+"/"+account.Name] = account
+	}
+
+	add := func(kind, severity, object, detail string) {
+		report.Findings = append(report.Findings, Finding{Kind: kind, Severity: severity, Object: object, Detail: detail})
+	}
+
+	registryCopies := make(map[string][]string)
+	// Secrets hold their data, so they are checked a page at a time rather than all held
+	err = k8s.EachListItem(ctx, clientset.CoreV1().Secrets(namespace).List, all, func(item *corev1.Secret) error {
+		secret := *item
+		report.Secrets++
+		key := secret.Namespace + "/" + secret.Name
+		object := secret.Namespace + "/secret/" + secret.Name
+		use := usages[key]
+
+		switch {
+		case ignoredSecretTypes[secret.Type] || len(secret.OwnerReferences) > 0:
+			// Read by the controller that owns or manages the secret
+
+		case secret.Type == corev1.SecretTypeServiceAccountToken:
+			account := secret.Annotations[corev1.ServiceAccountNameKey]
+			detail := "long-lived token of serviceaccount " + account
+			if _, ok := accounts[secret.Namespace+"/"+account]; !ok && serviceAccounts != nil {
+				detail += ", which no longer exists"
+			}
+			if lastUsed := secret.Labels[legacyTokenLastUsed]; lastUsed != "" {
+				detail += "; last used " + lastUsed
+			}
+			if use != nil && len(use.workloads) > 0 {
+				detail += "; mounted by " + strings.Join(use.workloads, ", ")
+			}
+			add(FindingLongLivedToken, SeverityHigh, object, detail)
+
+		case secret.Type == corev1.SecretTypeDockerConfigJson || secret.Type == corev1.SecretTypeDockercfg:
+			if !use.referenced() {
+				add(FindingRegistrySecret, SeverityMedium, object, "registry credentials no pod or serviceaccount in the namespace pulls with")
+			}
+			for _, data := range secret.Data {
+				sum := sha256.Sum256(data)
+				hash := hex.EncodeToString(sum[:])
+				registryCopies[hash] = append(registryCopies[hash], object)
+			}
+
+		case !use.referenced():
+			add(FindingUnusedSecret, SeverityLow, object, fmt.Sprintf("%s secret not referenced by any pod, serviceaccount or ingress", secretType(secret)))
+		}
+
+		// Pull secrets are meant to be shared by the workloads of a namespace
+		registry := secret.Type == corev1.SecretTypeDockerConfigJson || secret.Type == corev1.SecretTypeDockercfg
+		if use != nil && !registry && options.BroadThreshold > 0 && len(use.workloads) >= options.BroadThreshold {
+			add(FindingBroadSecret, SeverityMedium, object, fmt.Sprintf("used by %d workloads: %s", len(use.workloads), strings.Join(use.workloads, ", ")))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets: %w", err)
+	}
+
+	hashes := make([]string, 0, len(registryCopies))
+	for hash := range registryCopies {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		copies := registryCopies[hash]
+		if len(copies) < minCopies {
+			continue
+		}
+		sort.Strings(copies)
+		add(FindingRegistrySecret, SeverityMedium, copies[0], fmt.Sprintf("the same registry credentials are copied into %d namespaces: %s", len(copies), strings.Join(copies, ", ")))
+	}
+
+	if rbacReadable {
+		report.Findings = append(report.Findings, automountedTokens(pods, accounts, roleBindings, clusterRoleBindings)...)
+	} else {
+		report.Skipped = append(report.Skipped, "tokens mounted without permissions: RBAC bindings cannot be read")
+	}
+
+	// Unused secrets are still found through every pod, but nothing is reported about the
+	// namespaces and workloads opted out
+	ignored := client.NewOptOutFilter(ctx, namespace)
+	findings := report.Findings[:0]
+	for _, finding := range report.Findings {
+		if ignored.IgnoresObject(finding.Object) {
+			report.Ignored++
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	report.Findings = findings
+
+	SortFindings(report.Findings)
+	return report, nil
+}
+
+// secretUsages maps namespace/name of secrets to the workloads, service accounts and ingresses
+// that reference them
+func secretUsages(pods []corev1.Pod, serviceAccounts []corev1.ServiceAccount, ingresses []networkingv1.Ingress) map[string]*usage {
+	usages := make(map[string]*usage)
+	get := func(namespace, name string) *usage {
+		key := namespace + "/" + name
+		if usages[key] == nil {
+			usages[key] = &usage{}
+		}
+		return usages[key]
+	}
+
+	for _, pod := range pods {
+		kind, name := k8s.PodWorkload(pod)
+		workload := pod.Namespace + "/" + kind + "/" + name
+		use := func(secret string) {
+			if secret != "" {
+				u := get(pod.Namespace, secret)
+				u.workloads = k8s.AppendUnique(u.workloads, workload)
+			}
+		}
+
+		for _, volume := range pod.Spec.Volumes {
+			if volume.Secret != nil {
+				use(volume.Secret.SecretName)
+			}
+			if volume.Projected != nil {
+				for _, source := range volume.Projected.Sources {
+					if source.Secret != nil {
+						use(source.Secret.Name)
+					}
+				}
+			}
+		}
+		for _, secret := range pod.Spec.ImagePullSecrets {
+			use(secret.Name)
+		}
+		for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+			for _, container := range containers {
+				for _, env := range container.Env {
+					if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+						use(env.ValueFrom.SecretKeyRef.Name)
+					}
+				}
+				for _, source := range container.EnvFrom {
+					if source.SecretRef != nil {
+						use(source.SecretRef.Name)
+					}
+				}
+			}
+		}
+	}
+
+	for _, account := range serviceAccounts {
+		for _, secret := range account.Secrets {
+			u := get(account.Namespace, secret.Name)
+			u.serviceAccounts = k8s.AppendUnique(u.serviceAccounts, account.Name)
+		}
+		for _, secret := range account.ImagePullSecrets {
+			u := get(account.Namespace, secret.Name)
+			u.serviceAccounts = k8s.AppendUnique(u.serviceAccounts, account.Name)
+		}
+	}
+
+	for _, ingress := range ingresses {
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName != "" {
+				u := get(ingress.Namespace, tls.SecretName)
+				u.ingresses = k8s.AppendUnique(u.ingresses, ingress.Name)
+			}
+		}
+	}
+	return usages
+}
+
+// automountedTokens reports the workloads that mount the token of a service account no RBAC
+// binding grants anything to, so the token only exposes API discovery to an attacker
+func automountedTokens(pods []corev1.Pod, accounts map[string]corev1.ServiceAccount, roleBindings []rbacv1.RoleBinding, clusterRoleBindings []rbacv1.ClusterRoleBinding) []Finding {
+	bound := make(map[string]bool)
+	boundGroups := make(map[string]bool)
+	addSubjects := func(bindingNamespace string, subjects []rbacv1.Subject) {
+		for _, subject := range subjects {
+			switch subject.Kind {
+			case rbacv1.ServiceAccountKind:
+				namespace := subject.Namespace
+				if namespace == "" {
+					namespace = bindingNamespace
+				}
+				bound[namespace+"/"+subject.Name] = true
+			case rbacv1.GroupKind:
+				boundGroups[subject.Name] = true
+			}
+		}
+	}
+	for _, binding := range roleBindings {
+		addSubjects(binding.Namespace, binding.Subjects)
+	}
+	for _, binding := range clusterRoleBindings {
+		addSubjects("", binding.Subjects)
+	}
+
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, pod := range pods {
+		account := pod.Spec.ServiceAccountName
+		if account == "" {
+			account = "default"
+		}
+		key := pod.Namespace + "/" + account
+		if bound[key] || boundGroups["system:serviceaccounts"] || boundGroups["system:serviceaccounts:"+pod.Namespace] {
+			continue
+		}
+		if !automounts(pod, accounts[key]) {
+			continue
+		}
+
+		kind, name := k8s.PodWorkload(pod)
+		workload := pod.Namespace + "/" + kind + "/" + name
+		if seen[workload] {
+			continue
+		}
+		seen[workload] = true
+		findings = append(findings, Finding{
+			Kind:     FindingAutomountedToken,
+			Severity: SeverityMedium,
+			Object:   workload,
+			Detail:   fmt.Sprintf("mounts the token of serviceaccount %s, which has no RBAC bindings; set automountServiceAccountToken: false", account),
+		})
+	}
+	return findings
+}
+
+// automounts reports whether a pod mounts its service account token: the pod's setting wins
+// over the service account's, and both default to true
+func automounts(pod corev1.Pod, account corev1.ServiceAccount) bool {
+	if pod.Spec.AutomountServiceAccountToken != nil {
+		return *pod.Spec.AutomountServiceAccountToken
+	}
+	if account.AutomountServiceAccountToken != nil {
+		return *account.AutomountServiceAccountToken
+	}
+	return true
+}
+
+// SortFindings orders findings by the order of their kinds, then by object
+func SortFindings(findings []Finding) {
+	rank := make(map[string]int)
+	for i, kind := range FindingKinds {
+		rank[kind] = i
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Kind != findings[j].Kind {
+			return rank[findings[i].Kind] < rank[findings[j].Kind]
+		}
+		return findings[i].Object < findings[j].Object
+	})
+}
+
+// secretType describes the type of a secret for findings, such as Opaque or kubernetes.io/tls
+func secretType(secret corev1.Secret) string {
+	if secret.Type == "" {
+		return string(corev1.SecretTypeOpaque)
+	}
+	return string(secret.Type)
+}
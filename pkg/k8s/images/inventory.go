@@ -0,0 +1,177 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kube-ai/pkg/k8s"
+)
+
+// DockerHub is the registry of image references without a registry host
+const DockerHub = "docker.io"
+
+// mutableTags are tags that registries conventionally move to new builds
+var mutableTags = map[string]bool{
+	"latest": true, "stable": true, "edge": true, "main": true, "master": true, "develop": true,
+	"dev": true, "nightly": true, "canary": true, "beta": true, "alpha": true, "lts": true, "current": true,
+}
+
+// floatingVersion matches version tags naming only a major or minor version, such as 1, v2 or
+// 1.25-alpine, which move to each new patch release
+var floatingVersion = regexp.MustCompile(`^v?\d{1,3}(\.\d+)?(-[a-z0-9.-]+)?$`)
+
+// Reference is a parsed container image reference
+type Reference struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	// Tag, empty when the reference only has a digest; a reference with neither means latest
+	Tag    string `json:"tag,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// ParseReference parses an image reference such as nginx, ghcr.io/org/app:1.2.3 or
+// registry:5000/app@sha256:...
+func ParseReference(image string) Reference {
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	// The first component is a registry host if it looks like one
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.Registry, ref.Repository = name[:i], name[i+1:]
+	} else {
+		ref.Registry, ref.Repository = DockerHub, name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+	return ref
+}
+
+// Mutable reports whether the reference can resolve to different images over time: it has no
+// digest and its tag is latest, a channel name or a floating major or minor version
+func (r Reference) Mutable() bool {
+	if r.Digest != "" {
+		return false
+	}
+	return mutableTags[strings.ToLower(r.Tag)] || floatingVersion.MatchString(r.Tag)
+}
+
+// Image is an image used by the pods of a namespace
+type Image struct {
+	Image string `json:"image"`
+	Reference
+	// Workloads running the image, as namespace/kind/name
+	Workloads []string `json:"workloads"`
+	// Image digests the pods are running, from their container statuses
+	RunningDigests []string `json:"runningDigests,omitempty"`
+	// Pull policies the containers use
+	PullPolicies []string `json:"pullPolicies,omitempty"`
+	// Tag hygiene problems, such as a latest or mutable tag
+	Issues []string `json:"issues,omitempty"`
+	// Vulnerabilities reported by a scanner, if one ran
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+	// Why the image could not be scanned
+	ScanError string `json:"scanError,omitempty"`
+}
+
+// Inventory lists the images of the containers, init containers and ephemeral containers of
+// the pods in a namespace (all namespaces if empty), with the workloads that run them and any
+// tag problems. Pods whose owners opted them out with the kube-ai.io/ignore annotation are left out.
+func Inventory(ctx context.Context, client *k8s.Client, namespace string) ([]Image, error) {
+	ignored := client.NewOptOutFilter(ctx, namespace)
+	byImage := make(map[string]*Image)
+	add := func(pod *corev1.Pod, image string, pullPolicy corev1.PullPolicy, imageID string) {
+		entry, ok := byImage[image]
+		if !ok {
+			entry = &Image{Image: image, Reference: ParseReference(image)}
+			byImage[image] = entry
+		}
+		kind, name := k8s.PodWorkload(*pod)
+		entry.Workloads = k8s.AppendUnique(entry.Workloads, pod.Namespace+"/"+kind+"/"+name)
+		if pullPolicy != "" {
+			entry.PullPolicies = k8s.AppendUnique(entry.PullPolicies, string(pullPolicy))
+		}
+		if i := strings.Index(imageID, "@"); i >= 0 {
+			entry.RunningDigests = k8s.AppendUnique(entry.RunningDigests, imageID[i+1:])
+		}
+	}
+
+	// Only the images are kept, so pods are read a page at a time
+	pods := client.GetClientset().CoreV1().Pods(namespace)
+	err := k8s.EachCachedListItem(ctx, client, corev1.SchemeGroupVersion.WithResource("pods"), namespace, pods.List, metav1.ListOptions{}, func(pod *corev1.Pod) error {
+		if ignored.IgnoresPod(*pod) {
+			return nil
+		}
+		imageIDs := make(map[string]string)
+		for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+			for _, status := range statuses {
+				imageIDs[status.Name] = status.ImageID
+			}
+		}
+		for _, container := range pod.Spec.InitContainers {
+			add(pod, container.Image, container.ImagePullPolicy, imageIDs[container.Name])
+		}
+		for _, container := range pod.Spec.Containers {
+			add(pod, container.Image, container.ImagePullPolicy, imageIDs[container.Name])
+		}
+		for _, container := range pod.Spec.EphemeralContainers {
+			add(pod, container.Image, container.ImagePullPolicy, imageIDs[container.Name])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+
+	images := make([]Image, 0, len(byImage))
+	for _, image := range byImage {
+		image.Issues = imageIssues(*image)
+		sort.Strings(image.Workloads)
+		images = append(images, *image)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Image < images[j].Image
+	})
+	return images, nil
+}
+
+// imageIssues returns the tag and pull hygiene problems of an image
+func imageIssues(image Image) []string {
+	var issues []string
+	ref := image.Reference
+
+	switch {
+	case ref.Digest == "" && !strings.Contains(image.Image, ":"+ref.Tag):
+		issues = append(issues, "no tag: the image resolves to latest")
+	case ref.Digest == "" && strings.EqualFold(ref.Tag, "latest"):
+		issues = append(issues, "latest tag: the image can change on any pull")
+	case ref.Mutable():
+		issues = append(issues, fmt.Sprintf("mutable tag %s: the image can change on any pull", ref.Tag))
+	}
+	if ref.Mutable() && len(image.RunningDigests) > 1 {
+		issues = append(issues, fmt.Sprintf("pods run %d different builds of the tag", len(image.RunningDigests)))
+	}
+	if ref.Mutable() && slices.Contains(image.PullPolicies, string(corev1.PullIfNotPresent)) {
+		issues = append(issues, "pull policy IfNotPresent with a mutable tag: each node keeps the build it pulled first")
+	}
+	if ref.Registry == DockerHub {
+		issues = append(issues, "pulled from Docker Hub, which rate-limits anonymous pulls")
+	}
+	return issues
+}
@@ -0,0 +1,227 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Supported vulnerability scanners
+const (
+	ScannerTrivy = "trivy"
+	ScannerGrype = "grype"
+)
+
+// Scanners lists the supported vulnerability scanners
+var Scanners = []string{ScannerTrivy, ScannerGrype}
+
+// severityRank orders vulnerability severities, most severe first
+var severityRank = map[string]int{
+	"CRITICAL":   0,
+	"HIGH":       1,
+	"MEDIUM":     2,
+	"LOW":        3,
+	"NEGLIGIBLE": 4,
+	"UNKNOWN":    5,
+}
+
+// Vulnerability is a CVE or advisory a scanner found in an image
+type Vulnerability struct {
+	ID               string `json:"id"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installedVersion"`
+	// Version that fixes the vulnerability, empty if there is no fix yet
+	FixedVersion string `json:"fixedVersion,omitempty"`
+	// CRITICAL, HIGH, MEDIUM, LOW, NEGLIGIBLE or UNKNOWN
+	Severity string `json:"severity"`
+	Title    string `json:"title,omitempty"`
+}
+
+// Fixable reports whether a fixed version of the package is available
+func (v Vulnerability) Fixable() bool {
+	return v.FixedVersion != ""
+}
+
+// AtLeast reports whether the vulnerability is at least as severe as the given severity
+func (v Vulnerability) AtLeast(severity string) bool {
+	rank, ok := severityRank[strings.ToUpper(severity)]
+	if !ok {
+		return true
+	}
+	return severityRank[v.Severity] <= rank
+}
+
+// ValidSeverity reports whether a severity name is known
+func ValidSeverity(severity string) bool {
+	_, ok := severityRank[strings.ToUpper(severity)]
+	return ok
+}
+
+// Scan runs a vulnerability scanner binary on an image and returns its findings, most severe
+// and fixable first. The scanner pulls the image itself, using its own registry credentials.
+func Scan(ctx context.Context, scanner, image string) ([]Vulnerability, error) {
+	var args []string
+	switch scanner {
+	case ScannerTrivy:
+		args = []string{"image", "--quiet", "--format", "json", "--scanners", "vuln", image}
+	case ScannerGrype:
+		args = []string{"--quiet", "--output", "json", image}
+	default:
+		return nil, fmt.Errorf("unsupported scanner %q (expected %s)", scanner, strings.Join(Scanners, " or "))
+	}
+
+	if _, err := exec.LookPath(scanner); err != nil {
+		return nil, fmt.Errorf("%s is not installed or not in PATH: %w", scanner, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, scanner, args...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		// The last line of the scanner's output usually says what went wrong
+		if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); lines[len(lines)-1] != "" {
+			return nil, fmt.Errorf("%s failed on %s: %v: %s", scanner, image, err, lines[len(lines)-1])
+		}
+		return nil, fmt.Errorf("%s failed on %s: %w", scanner, image, err)
+	}
+
+	var vulnerabilities []Vulnerability
+	var err error
+	if scanner == ScannerTrivy {
+		vulnerabilities, err = parseTrivy(stdout.Bytes())
+	} else {
+		vulnerabilities, err = parseGrype(stdout.Bytes())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s output for %s: %w", scanner, image, err)
+	}
+
+	SortVulnerabilities(vulnerabilities)
+	return vulnerabilities, nil
+}
+
+// SortVulnerabilities orders vulnerabilities by severity, fixable ones first, then by ID
+func SortVulnerabilities(vulnerabilities []Vulnerability) {
+	sort.SliceStable(vulnerabilities, func(i, j int) bool {
+		a, b := vulnerabilities[i], vulnerabilities[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] < severityRank[b.Severity]
+		}
+		if a.Fixable() != b.Fixable() {
+			return a.Fixable()
+		}
+		return a.ID < b.ID
+	})
+}
+
+// CountBySeverity counts vulnerabilities by severity
+func CountBySeverity(vulnerabilities []Vulnerability) map[string]int {
+	counts := make(map[string]int)
+	for _, vulnerability := range vulnerabilities {
+		counts[vulnerability.Severity]++
+	}
+	return counts
+}
+
+// parseTrivy reads the JSON report of trivy image
+func parseTrivy(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				FixedVersion     string
+				Severity         string
+				Title            string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulnerabilities []Vulnerability
+	seen := make(map[string]bool)
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			key := v.VulnerabilityID + "/" + v.PkgName + "/" + v.InstalledVersion
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			vulnerabilities = append(vulnerabilities, Vulnerability{
+				ID:               v.VulnerabilityID,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         normalizeSeverity(v.Severity),
+				Title:            v.Title,
+			})
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// parseGrype reads the JSON report of grype
+func parseGrype(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID          string `json:"id"`
+				Severity    string `json:"severity"`
+				Description string `json:"description"`
+				Fix         struct {
+					Versions []string `json:"versions"`
+					State    string   `json:"state"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := make([]Vulnerability, 0, len(report.Matches))
+	for _, match := range report.Matches {
+		vulnerability := Vulnerability{
+			ID:               match.Vulnerability.ID,
+			Package:          match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+			Severity:         normalizeSeverity(match.Vulnerability.Severity),
+			Title:            firstLine(match.Vulnerability.Description),
+		}
+		if match.Vulnerability.Fix.State == "fixed" {
+			vulnerability.FixedVersion = strings.Join(match.Vulnerability.Fix.Versions, ", ")
+		}
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+	return vulnerabilities, nil
+}
+
+// normalizeSeverity maps scanner severities to upper case names, unknown ones to UNKNOWN
+func normalizeSeverity(severity string) string {
+	severity = strings.ToUpper(severity)
+	if _, ok := severityRank[severity]; !ok {
+		return "UNKNOWN"
+	}
+	return severity
+}
+
+// firstLine returns the first line of a text, trimmed
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return text
+}
@@ -0,0 +1,29 @@
+package k8s
+
+import (
+	"os"
+	"strings"
+)
+
+// serviceAccountNamespaceFile is where Kubernetes mounts the namespace of a pod's service account
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// IsInCluster reports whether kube-ai is running inside a pod
+func IsInCluster() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != ""
+}
+
+// InClusterNamespace returns the namespace kube-ai's pod runs in. The POD_NAMESPACE environment
+// variable (typically set from the downward API via fieldRef metadata.namespace) takes precedence
+// over the service account mount. An empty string is returned when neither is available.
+func InClusterNamespace() string {
+	if namespace := strings.TrimSpace(os.Getenv("POD_NAMESPACE")); namespace != "" {
+		return namespace
+	}
+
+	data, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
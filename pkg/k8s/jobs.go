@@ -0,0 +1,380 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxCronJobRuns limits the most recent Jobs of a CronJob that are described
+const maxCronJobRuns = 5
+
+// maxJobEvents limits the events reported for a Job or CronJob
+const maxJobEvents = 15
+
+// cronJobScheduleEvents matches the reasons of events the CronJob controller records when it
+// skips, misses or fails to create a run
+var cronJobScheduleEvents = regexp.MustCompile(`^(MissSchedule|JobAlreadyActive|FailedCreate|FailedNeedsStart|UnparseableSchedule|InvalidSchedule|TooManyMissedTimes|UnexpectedJob|FailedGet|FailedList)$`)
+
+// JobReport describes a Job, or a CronJob and its recent Jobs, with each run's pods, the events
+// recorded for them and why they fail or never run
+type JobReport struct {
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace"`
+	CronJob   *CronJobInfo `json:"cronJob,omitempty"`
+	Jobs      []JobRun     `json:"jobs"`
+	Events    []string     `json:"events"`
+	Findings  []string     `json:"findings"`
+}
+
+// CronJobInfo is the schedule and concurrency settings of a CronJob, and when it last ran
+type CronJobInfo struct {
+	Schedule                string   `json:"schedule"`
+	TimeZone                string   `json:"timeZone,omitempty"`
+	ConcurrencyPolicy       string   `json:"concurrencyPolicy"`
+	Suspended               bool     `json:"suspended"`
+	StartingDeadlineSeconds *int64   `json:"startingDeadlineSeconds,omitempty"`
+	SuccessfulJobsHistory   int32    `json:"successfulJobsHistoryLimit"`
+	FailedJobsHistory       int32    `json:"failedJobsHistoryLimit"`
+	LastScheduleTime        string   `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime      string   `json:"lastSuccessfulTime,omitempty"`
+	Active                  []string `json:"active,omitempty"`
+}
+
+// JobRun is a Job with its retry budget, outcome and pods
+type JobRun struct {
+	Name                  string `json:"name"`
+	Status                string `json:"status"`
+	BackoffLimit          int32  `json:"backoffLimit"`
+	Completions           int32  `json:"completions"`
+	Parallelism           int32  `json:"parallelism"`
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	TTLAfterFinished      *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	Succeeded             int32  `json:"succeeded"`
+	Failed                int32  `json:"failed"`
+	Active                int32  `json:"active"`
+	StartTime             string `json:"startTime,omitempty"`
+	CompletionTime        string `json:"completionTime,omitempty"`
+	// Reason and message of the Failed condition, such as BackoffLimitExceeded or DeadlineExceeded
+	Reason  string   `json:"reason,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Pods    []JobPod `json:"pods"`
+}
+
+// JobPod is a pod created for a Job, one per attempt, with how its containers ended
+type JobPod struct {
+	Name      string `json:"name"`
+	Phase     string `json:"phase"`
+	Container string `json:"container,omitempty"`
+	// Reason and exit code of the container that failed, such as OOMKilled or Error and 137
+	Reason   string `json:"reason,omitempty"`
+	ExitCode int32  `json:"exitCode,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Last lines of the failed container's logs, when collected
+	Logs []string `json:"logs,omitempty"`
+}
+
+// Failed reports whether the pod's attempt failed
+func (p JobPod) Failed() bool {
+	return p.Phase == string(corev1.PodFailed) || p.ExitCode != 0
+}
+
+// IsJobKind reports whether kind names a Job or CronJob
+func IsJobKind(kind string) bool {
+	switch strings.ToLower(kind) {
+	case "job", "jobs", "cronjob", "cronjobs", "cj":
+		return true
+	}
+	return false
+}
+
+// GetJobReport describes a Job, or a CronJob and its most recent Jobs, and finds why they fail or
+// never run
+func (c *Client) GetJobReport(ctx context.Context, kind, namespace, name string) (*JobReport, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+	report := &JobReport{Name: name, Namespace: namespace, Jobs: []JobRun{}, Events: []string{}, Findings: []string{}}
+
+	var jobs []batchv1.Job
+	switch strings.ToLower(kind) {
+	case "cronjob", "cronjobs", "cj":
+		report.Kind = "CronJob"
+		cronJob, err := c.clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting cronjob %s: %w", name, err)
+		}
+		report.CronJob = cronJobInfo(cronJob)
+		list, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing jobs: %w", err)
+		}
+		for _, job := range list.Items {
+			for _, ref := range job.OwnerReferences {
+				if ref.UID == cronJob.UID {
+					jobs = append(jobs, job)
+				}
+			}
+		}
+		sort.Slice(jobs, func(i, j int) bool {
+			return jobs[i].CreationTimestamp.Before(&jobs[j].CreationTimestamp)
+		})
+		if len(jobs) > maxCronJobRuns {
+			jobs = jobs[len(jobs)-maxCronJobRuns:]
+		}
+		report.Findings = append(report.Findings, cronJobFindings(cronJob, jobs)...)
+	default:
+		report.Kind = "Job"
+		job, err := c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting job %s: %w", name, err)
+		}
+		jobs = []batchv1.Job{*job}
+	}
+
+	for _, job := range jobs {
+		run := jobRun(&job)
+		selector := metav1.FormatLabelSelector(job.Spec.Selector)
+		if pods, err := c.ListPods(ctx, namespace, selector); err == nil {
+			sort.Slice(pods, func(i, j int) bool { return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp) })
+			for _, pod := range pods {
+				run.Pods = append(run.Pods, jobPod(pod))
+			}
+		}
+		report.Jobs = append(report.Jobs, run)
+		report.Findings = append(report.Findings, run.findings()...)
+	}
+
+	report.Events = c.jobEvents(ctx, report)
+	return report, nil
+}
+
+// cronJobInfo reads the schedule, concurrency settings and last runs of a CronJob
+func cronJobInfo(cronJob *batchv1.CronJob) *CronJobInfo {
+	info := &CronJobInfo{
+		Schedule:                cronJob.Spec.Schedule,
+		ConcurrencyPolicy:       string(cronJob.Spec.ConcurrencyPolicy),
+		StartingDeadlineSeconds: cronJob.Spec.StartingDeadlineSeconds,
+		SuccessfulJobsHistory:   3,
+		FailedJobsHistory:       1,
+	}
+	if info.ConcurrencyPolicy == "" {
+		info.ConcurrencyPolicy = string(batchv1.AllowConcurrent)
+	}
+	if cronJob.Spec.TimeZone != nil {
+		info.TimeZone = *cronJob.Spec.TimeZone
+	}
+	if cronJob.Spec.Suspend != nil {
+		info.Suspended = *cronJob.Spec.Suspend
+	}
+	if cronJob.Spec.SuccessfulJobsHistoryLimit != nil {
+		info.SuccessfulJobsHistory = *cronJob.Spec.SuccessfulJobsHistoryLimit
+	}
+	if cronJob.Spec.FailedJobsHistoryLimit != nil {
+		info.FailedJobsHistory = *cronJob.Spec.FailedJobsHistoryLimit
+	}
+	if cronJob.Status.LastScheduleTime != nil {
+		info.LastScheduleTime = cronJob.Status.LastScheduleTime.Format(time.RFC3339)
+	}
+	if cronJob.Status.LastSuccessfulTime != nil {
+		info.LastSuccessfulTime = cronJob.Status.LastSuccessfulTime.Format(time.RFC3339)
+	}
+	for _, active := range cronJob.Status.Active {
+		info.Active = append(info.Active, active.Name)
+	}
+	return info
+}
+
+// cronJobFindings reports CronJob settings and states that keep runs from starting or hide why
+// they fail
+func cronJobFindings(cronJob *batchv1.CronJob, jobs []batchv1.Job) []string {
+	var findings []string
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+		findings = append(findings, "the CronJob is suspended, so no run is scheduled until spec.suspend is false")
+	}
+	if cronJob.Status.LastScheduleTime == nil && time.Since(cronJob.CreationTimestamp.Time) > 24*time.Hour {
+		findings = append(findings, fmt.Sprintf("the CronJob was created over a day ago and never scheduled a run: check the schedule %q and the CronJob controller events", cronJob.Spec.Schedule))
+	}
+	if deadline := cronJob.Spec.StartingDeadlineSeconds; deadline != nil && *deadline < 10 {
+		findings = append(findings, fmt.Sprintf("startingDeadlineSeconds is %d, below the 10 seconds between CronJob controller checks, so runs can be missed", *deadline))
+	}
+	if cronJob.Spec.ConcurrencyPolicy == batchv1.ForbidConcurrent && len(cronJob.Status.Active) > 0 {
+		findings = append(findings, fmt.Sprintf("concurrencyPolicy is Forbid and job %s is still active, so new runs are skipped until it finishes", cronJob.Status.Active[0].Name))
+	}
+	if cronJob.Spec.FailedJobsHistoryLimit != nil && *cronJob.Spec.FailedJobsHistoryLimit == 0 {
+		findings = append(findings, "failedJobsHistoryLimit is 0, so failed Jobs and their pods are deleted at once and their logs are lost")
+	}
+	if last, success := cronJob.Status.LastScheduleTime, cronJob.Status.LastSuccessfulTime; last != nil && len(jobs) > 0 && (success == nil || success.Before(last)) {
+		if latest := jobs[len(jobs)-1]; jobStatus(&latest) == "Failed" {
+			findings = append(findings, "the last scheduled run failed")
+		}
+	}
+	return findings
+}
+
+// jobRun reads the retry budget, counters and outcome of a Job
+func jobRun(job *batchv1.Job) JobRun {
+	run := JobRun{
+		Name:                  job.Name,
+		Status:                jobStatus(job),
+		BackoffLimit:          6,
+		Completions:           1,
+		Parallelism:           1,
+		ActiveDeadlineSeconds: job.Spec.ActiveDeadlineSeconds,
+		TTLAfterFinished:      job.Spec.TTLSecondsAfterFinished,
+		Succeeded:             job.Status.Succeeded,
+		Failed:                job.Status.Failed,
+		Active:                job.Status.Active,
+		Pods:                  []JobPod{},
+	}
+	if job.Spec.BackoffLimit != nil {
+		run.BackoffLimit = *job.Spec.BackoffLimit
+	}
+	if job.Spec.Completions != nil {
+		run.Completions = *job.Spec.Completions
+	}
+	if job.Spec.Parallelism != nil {
+		run.Parallelism = *job.Spec.Parallelism
+	}
+	if job.Status.StartTime != nil {
+		run.StartTime = job.Status.StartTime.Format(time.RFC3339)
+	}
+	if job.Status.CompletionTime != nil {
+		run.CompletionTime = job.Status.CompletionTime.Format(time.RFC3339)
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			run.Reason, run.Message = condition.Reason, condition.Message
+		}
+	}
+	return run
+}
+
+// jobStatus returns Complete, Failed, Suspended or Running from the conditions of a Job
+func jobStatus(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "Complete"
+		case batchv1.JobFailed:
+			return "Failed"
+		case batchv1.JobSuspended:
+			return "Suspended"
+		}
+	}
+	return "Running"
+}
+
+// jobPod reads how the containers of a Job's pod ended. The first container that failed, or is
+// stuck waiting, explains the attempt.
+func jobPod(pod corev1.Pod) JobPod {
+	jobPod := JobPod{Name: pod.Name, Phase: string(pod.Status.Phase)}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			jobPod.Container, jobPod.Reason, jobPod.ExitCode, jobPod.Message = status.Name, terminated.Reason, terminated.ExitCode, strings.TrimSpace(terminated.Message)
+			return jobPod
+		}
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "PodInitializing" && waiting.Reason != "ContainerCreating" {
+			jobPod.Container, jobPod.Reason, jobPod.Message = status.Name, waiting.Reason, strings.TrimSpace(waiting.Message)
+			return jobPod
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			jobPod.Reason, jobPod.Message = condition.Reason, condition.Message
+		}
+	}
+	return jobPod
+}
+
+// findings reports why a Job stopped retrying, and the failures its attempts share
+func (r *JobRun) findings() []string {
+	var findings []string
+	switch r.Reason {
+	case "BackoffLimitExceeded":
+		findings = append(findings, fmt.Sprintf("job %s failed after %d attempts, exceeding its backoffLimit of %d", r.Name, r.Failed, r.BackoffLimit))
+	case "DeadlineExceeded":
+		findings = append(findings, fmt.Sprintf("job %s was stopped by its activeDeadlineSeconds before completing", r.Name))
+	case "":
+	default:
+		findings = append(findings, fmt.Sprintf("job %s failed: %s %s", r.Name, r.Reason, r.Message))
+	}
+
+	reasons := make(map[string]int)
+	var order []string
+	for _, pod := range r.Pods {
+		reason := pod.Reason
+		if reason == "" {
+			continue
+		}
+		if pod.ExitCode != 0 {
+			reason = fmt.Sprintf("%s (exit code %d)", reason, pod.ExitCode)
+		}
+		if reasons[reason] == 0 {
+			order = append(order, reason)
+		}
+		reasons[reason]++
+	}
+	for _, reason := range order {
+		findings = append(findings, fmt.Sprintf("job %s: %d pods ended with %s", r.Name, reasons[reason], reason))
+	}
+	if r.Status == "Failed" && len(r.Pods) == 0 {
+		findings = append(findings, fmt.Sprintf("job %s failed but its pods are gone, so their logs cannot be read", r.Name))
+	}
+	return findings
+}
+
+// jobEvents returns the recent events of the Job or CronJob and its runs, newest last. CronJob
+// events are limited to the controller's reports on scheduling.
+func (c *Client) jobEvents(ctx context.Context, report *JobReport) []string {
+	list, err := c.ListEvents(ctx, report.Namespace, "")
+	if err != nil {
+		SkipSource(SourceEvents, err)
+		return []string{}
+	}
+	names := map[string]bool{report.Kind + "/" + report.Name: true}
+	for _, run := range report.Jobs {
+		names["Job/"+run.Name] = true
+		for _, pod := range run.Pods {
+			names["Pod/"+pod.Name] = true
+		}
+	}
+
+	var events []corev1.Event
+	for _, event := range list {
+		if !names[event.InvolvedObject.Kind+"/"+event.InvolvedObject.Name] {
+			continue
+		}
+		// Routine pod events such as Pulled and Started say nothing about failures
+		if event.InvolvedObject.Kind == "Pod" && event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return eventTime(events[i]).Before(eventTime(events[j])) })
+	if len(events) > maxJobEvents {
+		events = events[len(events)-maxJobEvents:]
+	}
+
+	lines := []string{}
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s/%s %s: %s", event.Type, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason, strings.TrimSpace(event.Message))
+		lines = append(lines, line)
+		if event.InvolvedObject.Kind == "CronJob" && cronJobScheduleEvents.MatchString(event.Reason) {
+			report.Findings = append(report.Findings, fmt.Sprintf("the CronJob controller reported %s: %s", event.Reason, strings.TrimSpace(event.Message)))
+		}
+	}
+	return lines
+}
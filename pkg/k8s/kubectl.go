@@ -1,6 +1,8 @@
 package k8s
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +21,22 @@ func AddKubectlFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("certificate-authority", "", "Path to a certificate authority file")
 	cmd.PersistentFlags().String("server", "", "Kubernetes API server address")
 	cmd.PersistentFlags().String("token", "", "Bearer token for authentication")
+
+	// Impersonation and in-cluster configuration
+	cmd.PersistentFlags().String("as", "", "Username to impersonate for the operation")
+	cmd.PersistentFlags().StringArray("as-group", []string{}, "Group to impersonate for the operation, can be repeated")
+	cmd.PersistentFlags().Bool("in-cluster", false, "Use the pod's service account instead of a kubeconfig (detected automatically when no kubeconfig is available)")
+
+	// Client-side limits on API server usage
+	cmd.PersistentFlags().Float32("qps", DefaultQPS, "Sustained rate of requests per second to the API server")
+	cmd.PersistentFlags().Int("burst", DefaultBurst, "Requests allowed above --qps for short bursts")
+	cmd.PersistentFlags().Int("max-in-flight", DefaultMaxInFlight, "Maximum number of API requests in flight at the same time (followed log streams excluded, 0 for no limit)")
+
+	// Lists and discovered APIs reused between runs, which keeps cluster objects on disk
+	cmd.PersistentFlags().Bool("cache", false, "Keep the cluster lists and APIs in ~/.kube-ai/cache and reuse them in later runs, fetching only objects changed since")
+
+	// kube-ai is read-only unless writes are explicitly allowed
+	cmd.PersistentFlags().Bool("allow-writes", false, "Allow kube-ai to send mutating requests to the cluster (read-only by default)")
 }
 
 // GetClientConfigFromFlags extracts a ClientConfig from command flags
@@ -30,12 +48,50 @@ func GetClientConfigFromFlags(cmd *cobra.Command) (ClientConfig, error) {
 	context, _ := cmd.Flags().GetString("context")
 	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
 	allNamespaces, _ := cmd.Flags().GetBool("all-namespaces")
+	allowWrites, _ := cmd.Flags().GetBool("allow-writes")
+	cluster, _ := cmd.Flags().GetString("cluster")
+	user, _ := cmd.Flags().GetString("user")
+	server, _ := cmd.Flags().GetString("server")
+	token, _ := cmd.Flags().GetString("token")
+	certificateAuthority, _ := cmd.Flags().GetString("certificate-authority")
+	insecureSkipTLSVerify, _ := cmd.Flags().GetBool("insecure-skip-tls-verify")
+	inCluster, _ := cmd.Flags().GetBool("in-cluster")
+	impersonate, _ := cmd.Flags().GetString("as")
+	impersonateGroups, _ := cmd.Flags().GetStringArray("as-group")
+	qps, _ := cmd.Flags().GetFloat32("qps")
+	burst, _ := cmd.Flags().GetInt("burst")
+	maxInFlight, _ := cmd.Flags().GetInt("max-in-flight")
+	cache, _ := cmd.Flags().GetBool("cache")
+	if burst < 0 || maxInFlight < 0 {
+		return config, fmt.Errorf("--burst and --max-in-flight must not be negative")
+	}
 
 	// Set the config values
 	config.Namespace = namespace
 	config.Context = context
+	if context == "" {
+		// The context selected with kube-ai ctx, if any
+		config.Context = SessionContext()
+	}
 	config.KubeconfigPath = kubeconfig
 	config.AllNamespaces = allNamespaces
+	config.ReadOnly = !allowWrites
+	config.Cluster = cluster
+	config.User = user
+	config.Server = server
+	config.Token = token
+	config.CertificateAuthority = certificateAuthority
+	config.InsecureSkipTLSVerify = insecureSkipTLSVerify
+	config.InCluster = inCluster
+	config.Impersonate = impersonate
+	config.ImpersonateGroups = impersonateGroups
+	config.QPS = qps
+	config.Burst = burst
+	config.MaxInFlight = maxInFlight
+	config.Cache = cache
+	if cmd.Flags().Changed("max-in-flight") && maxInFlight == 0 {
+		config.MaxInFlight = -1
+	}
 
 	return config, nil
 }
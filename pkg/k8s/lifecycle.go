@@ -0,0 +1,201 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// lifecycleLookback is how long before the first log line lifecycle events are still considered relevant
+const lifecycleLookback = 5 * time.Minute
+
+// maxLifecycleEvents caps the number of events kept, preferring the most recent
+const maxLifecycleEvents = 30
+
+// lifecycleReasons are normal (non-warning) event reasons that mark lifecycle transitions
+var lifecycleReasons = map[string]bool{
+	"Scheduled":         true,
+	"Started":           true,
+	"Killing":           true,
+	"ScalingReplicaSet": true,
+	"SuccessfulCreate":  true,
+	"SuccessfulDelete":  true,
+}
+
+// ContainerRestart describes how often a container restarted and why it last terminated
+type ContainerRestart struct {
+	// Pod of the container
+	Pod string `json:"pod"`
+	// Container name
+	Container string `json:"container"`
+	// Number of restarts
+	RestartCount int32 `json:"restartCount"`
+	// Reason of the last termination (e.g. OOMKilled, Error)
+	LastTerminationReason string `json:"lastTerminationReason,omitempty"`
+	// Exit code of the last termination
+	LastExitCode int32 `json:"lastExitCode,omitempty"`
+	// Time of the last termination
+	LastTerminatedAt time.Time `json:"lastTerminatedAt,omitempty"`
+}
+
+// LifecycleEvent is a point in time where a workload or its pods changed state
+type LifecycleEvent struct {
+	// Time of the event (last occurrence for repeated events)
+	Time time.Time `json:"time"`
+	// Object the event is about (e.g. pod/checkout-7d9f)
+	Object string `json:"object"`
+	// Normal or Warning
+	Type string `json:"type"`
+	// Short reason (e.g. BackOff, OOMKilling, Unhealthy, NotReady)
+	Reason string `json:"reason"`
+	// Human-readable details
+	Message string `json:"message,omitempty"`
+	// Number of occurrences
+	Count int32 `json:"count,omitempty"`
+}
+
+// WorkloadLifecycle holds restarts, events, and readiness changes of a workload around a time window
+type WorkloadLifecycle struct {
+	// Containers that restarted at least once
+	Restarts []ContainerRestart `json:"restarts,omitempty"`
+	// Events, terminations, and readiness changes in chronological order
+	Events []LifecycleEvent `json:"events,omitempty"`
+	// Restarts, OOM kills, rollouts, scaling and the error rate of the logs in chronological order
+	Timeline []TimelineEntry `json:"timeline,omitempty"`
+}
+
+// HasEvents reports whether any lifecycle information was found
+func (l *WorkloadLifecycle) HasEvents() bool {
+	return l != nil && (len(l.Restarts) > 0 || len(l.Events) > 0)
+}
+
+// GetWorkloadLifecycle collects restarts, warning and lifecycle events, terminations, and readiness
+// changes of a workload's pods between start and end, so log errors can be tied to them
+func (c *Client) GetWorkloadLifecycle(ctx context.Context, resourceType, name, namespace string, start, end time.Time) (*WorkloadLifecycle, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	pods, _, err := c.GetWorkloadPods(ctx, resourceType, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	from := start.Add(-lifecycleLookback)
+	inWindow := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(from) && !t.After(end)
+	}
+
+	lifecycle := &WorkloadLifecycle{}
+	podNames := map[string]bool{name: true}
+
+	for _, pod := range pods {
+		podNames[pod.Name] = true
+		object := "pod/" + pod.Name
+
+		for _, status := range pod.Status.ContainerStatuses {
+			restart := ContainerRestart{Pod: pod.Name, Container: status.Name, RestartCount: status.RestartCount}
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				restart.LastTerminationReason = terminated.Reason
+				restart.LastExitCode = terminated.ExitCode
+				restart.LastTerminatedAt = terminated.FinishedAt.Time
+
+				if inWindow(terminated.FinishedAt.Time) {
+					lifecycle.Events = append(lifecycle.Events, LifecycleEvent{
+						Time:    terminated.FinishedAt.Time,
+						Object:  object,
+						Type:    corev1.EventTypeWarning,
+						Reason:  "Terminated",
+						Message: fmt.Sprintf("container %s terminated with %s (exit code %d)", status.Name, terminated.Reason, terminated.ExitCode),
+					})
+				}
+			}
+			if restart.RestartCount > 0 {
+				lifecycle.Restarts = append(lifecycle.Restarts, restart)
+			}
+		}
+
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodReady || !inWindow(condition.LastTransitionTime.Time) {
+				continue
+			}
+			event := LifecycleEvent{
+				Time:    condition.LastTransitionTime.Time,
+				Object:  object,
+				Type:    corev1.EventTypeNormal,
+				Reason:  "Ready",
+				Message: condition.Message,
+			}
+			if condition.Status != corev1.ConditionTrue {
+				event.Type = corev1.EventTypeWarning
+				event.Reason = "NotReady"
+			}
+			lifecycle.Events = append(lifecycle.Events, event)
+		}
+	}
+
+	// Restarts and readiness changes are still reported without events
+	events, err := c.ListEvents(ctx, namespace, "")
+	if err != nil {
+		SkipSource(SourceEvents, err)
+	}
+
+	for _, event := range events {
+		involved := event.InvolvedObject.Name
+		if !podNames[involved] && !strings.HasPrefix(involved, name) {
+			continue
+		}
+		if event.Type != corev1.EventTypeWarning && !lifecycleReasons[event.Reason] {
+			continue
+		}
+
+		last := eventTime(event)
+		first := event.FirstTimestamp.Time
+		if first.IsZero() {
+			first = last
+		}
+		// Keep events that overlap the window, even if they started before it
+		if last.Before(from) || first.After(end) {
+			continue
+		}
+
+		lifecycle.Events = append(lifecycle.Events, LifecycleEvent{
+			Time:    last,
+			Object:  strings.ToLower(event.InvolvedObject.Kind) + "/" + involved,
+			Type:    event.Type,
+			Reason:  event.Reason,
+			Message: strings.TrimSpace(event.Message),
+			Count:   event.Count,
+		})
+	}
+
+	sort.SliceStable(lifecycle.Events, func(i, j int) bool {
+		return lifecycle.Events[i].Time.Before(lifecycle.Events[j].Time)
+	})
+	if len(lifecycle.Events) > maxLifecycleEvents {
+		lifecycle.Events = lifecycle.Events[len(lifecycle.Events)-maxLifecycleEvents:]
+	}
+	sort.Slice(lifecycle.Restarts, func(i, j int) bool {
+		return lifecycle.Restarts[i].RestartCount > lifecycle.Restarts[j].RestartCount
+	})
+
+	return lifecycle, nil
+}
+
+// eventTime returns the time an event last occurred
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.FirstTimestamp.Time
+	}
+}
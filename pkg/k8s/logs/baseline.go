@@ -0,0 +1,258 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Thresholds used when comparing logs against a baseline
+const (
+	// Patterns seen at most this many times in the baseline are considered rare
+	rareBaselineCount = 2
+	// Minimum occurrences before a rate change is reported
+	minRateCount = 5
+	// Factor by which a pattern's rate must change to be reported
+	rateChangeFactor = 3.0
+	// Maximum number of patterns reported per category
+	maxDeviations = 10
+)
+
+// Baseline is a fingerprint of the log patterns a workload produces when it is healthy
+type Baseline struct {
+	// Kind of the workload
+	ResourceType string `json:"resourceType"`
+	// Name of the workload
+	ResourceName string `json:"resourceName"`
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+	// Time the baseline was recorded
+	CreatedAt time.Time `json:"createdAt"`
+	// Time span covered by the baseline logs
+	Duration time.Duration `json:"duration"`
+	// Number of log entries in the baseline
+	TotalEntries int `json:"totalEntries"`
+	// Occurrence counts keyed by pattern fingerprint
+	Patterns map[string]BaselinePattern `json:"patterns"`
+}
+
+// BaselinePattern records how often a log pattern occurred in the baseline
+type BaselinePattern struct {
+	// Log level of the pattern
+	Level string `json:"level"`
+	// Number of occurrences
+	Count int `json:"count"`
+}
+
+// PatternDeviation is a log pattern whose presence or rate differs from the baseline
+type PatternDeviation struct {
+	// Normalized pattern
+	Pattern string `json:"pattern"`
+	// Log level of the pattern
+	Level string `json:"level"`
+	// Occurrences in the current logs
+	Count int `json:"count"`
+	// Occurrences per minute in the baseline
+	BaselineRate float64 `json:"baselineRate"`
+	// Occurrences per minute in the current logs
+	CurrentRate float64 `json:"currentRate"`
+	// Example log line for the pattern
+	Example string `json:"example,omitempty"`
+}
+
+// BaselineDiff summarizes how current logs differ from a baseline
+type BaselineDiff struct {
+	// Time the baseline was recorded
+	BaselineCreatedAt time.Time `json:"baselineCreatedAt"`
+	// Patterns never seen in the baseline
+	New []PatternDeviation `json:"new,omitempty"`
+	// Patterns seen only a few times in the baseline
+	Rare []PatternDeviation `json:"rare,omitempty"`
+	// Known patterns whose rate rose or fell sharply
+	RateChanges []PatternDeviation `json:"rateChanges,omitempty"`
+}
+
+// HasDeviations reports whether anything changed compared to the baseline
+func (d *BaselineDiff) HasDeviations() bool {
+	return d != nil && (len(d.New) > 0 || len(d.Rare) > 0 || len(d.RateChanges) > 0)
+}
+
+// Fingerprint reduces a log entry to a stable pattern key, so repeated messages that differ
+// only in IDs, addresses, or numbers share a key
+func Fingerprint(entry LogEntry) string {
+	parts := strings.Split(normalizeLogMessage(entry.Content), " ")
+	if len(parts) > 10 {
+		parts = parts[:10]
+	}
+	return strings.Join(parts, " ")
+}
+
+// NewBaseline builds a baseline from log entries of a healthy workload
+func NewBaseline(resourceType, resourceName, namespace string, entries []LogEntry) *Baseline {
+	summary := ParseLogs(entries)
+	baseline := &Baseline{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		Namespace:    namespace,
+		CreatedAt:    time.Now().UTC(),
+		Duration:     summary.TimeRange.Duration,
+		TotalEntries: len(entries),
+		Patterns:     make(map[string]BaselinePattern),
+	}
+
+	for _, entry := range entries {
+		key := Fingerprint(entry)
+		pattern := baseline.Patterns[key]
+		pattern.Level = entry.LogLevel
+		pattern.Count++
+		baseline.Patterns[key] = pattern
+	}
+
+	return baseline
+}
+
+// perMinute converts a count over a duration to a rate, treating short spans as one minute
+func perMinute(count int, d time.Duration) float64 {
+	minutes := d.Minutes()
+	if minutes < 1 {
+		minutes = 1
+	}
+	return float64(count) / minutes
+}
+
+// Compare highlights new, rare, and sharply changed patterns in entries relative to the baseline
+func (b *Baseline) Compare(entries []LogEntry) *BaselineDiff {
+	summary := ParseLogs(entries)
+	counts := make(map[string]int)
+	examples := make(map[string]LogEntry)
+	for _, entry := range entries {
+		key := Fingerprint(entry)
+		if _, ok := examples[key]; !ok {
+			examples[key] = entry
+		}
+		counts[key]++
+	}
+
+	diff := &BaselineDiff{BaselineCreatedAt: b.CreatedAt}
+	for key, count := range counts {
+		known, seen := b.Patterns[key]
+		deviation := PatternDeviation{
+			Pattern:      key,
+			Level:        examples[key].LogLevel,
+			Count:        count,
+			BaselineRate: perMinute(known.Count, b.Duration),
+			CurrentRate:  perMinute(count, summary.TimeRange.Duration),
+			Example:      examples[key].Content,
+		}
+
+		switch {
+		case !seen:
+			diff.New = append(diff.New, deviation)
+		case known.Count <= rareBaselineCount:
+			diff.Rare = append(diff.Rare, deviation)
+		case count >= minRateCount && deviation.CurrentRate >= deviation.BaselineRate*rateChangeFactor:
+			diff.RateChanges = append(diff.RateChanges, deviation)
+		}
+	}
+
+	// Patterns that were common but disappeared can mean a component stopped working
+	currentMinutes := summary.TimeRange.Duration.Minutes()
+	for key, known := range b.Patterns {
+		if _, ok := counts[key]; ok {
+			continue
+		}
+		rate := perMinute(known.Count, b.Duration)
+		if rate*currentMinutes < minRateCount {
+			continue
+		}
+		diff.RateChanges = append(diff.RateChanges, PatternDeviation{
+			Pattern:      key,
+			Level:        known.Level,
+			BaselineRate: rate,
+		})
+	}
+
+	diff.New = topDeviations(diff.New)
+	diff.Rare = topDeviations(diff.Rare)
+	diff.RateChanges = topDeviations(diff.RateChanges)
+	return diff
+}
+
+// topDeviations sorts deviations by severity and then by count, keeping the most significant
+func topDeviations(deviations []PatternDeviation) []PatternDeviation {
+	sort.Slice(deviations, func(i, j int) bool {
+		if ri, rj := levelRank[deviations[i].Level], levelRank[deviations[j].Level]; ri != rj {
+			return ri > rj
+		}
+		if deviations[i].Count != deviations[j].Count {
+			return deviations[i].Count > deviations[j].Count
+		}
+		return deviations[i].Pattern < deviations[j].Pattern
+	})
+	if len(deviations) > maxDeviations {
+		deviations = deviations[:maxDeviations]
+	}
+	return deviations
+}
+
+// DefaultBaselineDir returns the default directory for stored baselines (~/.kube-ai/baselines)
+func DefaultBaselineDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube-ai", "baselines"), nil
+}
+
+// BaselinePath returns the file that stores the baseline of a workload in a cluster context
+func BaselinePath(dir, context, namespace, resourceType, resourceName string) string {
+	return workloadFile(dir, context, namespace, resourceType, resourceName)
+}
+
+// workloadFile returns the file under dir that stores data about a workload in a cluster context
+func workloadFile(dir, context, namespace, resourceType, resourceName string) string {
+	if context == "" {
+		context = "default"
+	}
+	name := fmt.Sprintf("%s_%s_%s.json", namespace, strings.ToLower(resourceType), resourceName)
+	return filepath.Join(dir, strings.ReplaceAll(context, string(filepath.Separator), "_"), name)
+}
+
+// LoadBaseline reads a stored baseline, returning nil if none exists
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline: %w", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// Save writes the baseline to path, creating parent directories as needed. It is only readable by
+// the user, as its patterns quote log lines.
+func (b *Baseline) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating baseline directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding baseline: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing baseline: %w", err)
+	}
+	return nil
+}
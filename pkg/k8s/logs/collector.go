@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,11 +18,15 @@ import (
 
 // LogOptions defines options for collecting logs
 type LogOptions struct {
-	// Resource type (pod, deployment, statefulset, etc.)
+	// Resource type (pod, deployment, statefulset, node, control-plane)
 	ResourceType string
 	// Resource name
 	ResourceName string
-	// Namespace
+	// Label selector of the pods to collect from, in place of a resource (optional)
+	Selector string
+	// Field selector of the pods to collect from, such as spec.nodeName=node-1 (optional)
+	FieldSelector string
+	// Namespace ("" with selectors for every namespace)
 	Namespace string
 	// Container name (optional)
 	Container string
@@ -34,14 +40,43 @@ type LogOptions struct {
 	SinceTime *metav1.Time
 	// Duration from now to start returning logs
 	SinceSeconds *int64
+	// End time for logs; later lines are dropped during collection
+	UntilTime *metav1.Time
 	// Time to wait if Follow=true
 	Timeout time.Duration
+	// If true, the API server prefixes each line with an RFC3339 timestamp
+	Timestamps bool
+	// Maximum number of pods whose logs are fetched at the same time (DefaultMaxConcurrency if zero)
+	MaxConcurrency int
+	// Maximum number of lines to collect from each pod (no cap beyond TailLines if zero)
+	MaxLinesPerPod int64
+	// Optional filter applied to containers and lines during collection
+	Filter *LogFilter
+	// Also collect logs from init containers that have run
+	InitContainers bool
+	// Also collect logs from ephemeral debug containers that have run
+	EphemeralContainers bool
+	// Collect logs from every container of each pod instead of its default container
+	AllContainers bool
+	// Containers skipped when enumerating containers, unless selected explicitly by the filter
+	IgnoreContainers []string
+	// Called as the logs of each pod or container are fetched, with how many were fetched out of
+	// the total (nil for none)
+	Progress func(done, total int)
 }
 
+// DefaultIgnoreContainers are service mesh sidecars skipped when collecting from all containers
+var DefaultIgnoreContainers = []string{"istio-proxy", "linkerd-proxy"}
+
+// DefaultMaxConcurrency is the default number of pods whose logs are fetched concurrently
+const DefaultMaxConcurrency = 8
+
 // LogEntry represents a structured log entry
 type LogEntry struct {
 	// Timestamp of the log entry
 	Timestamp time.Time
+	// Source namespace
+	Namespace string
 	// Source pod name
 	PodName string
 	// Source container name
@@ -75,6 +110,8 @@ func (c *LogCollector) GetPodLogs(ctx context.Context, options LogOptions) ([]Lo
 		SinceSeconds: options.SinceSeconds,
 		SinceTime:    options.SinceTime,
 		TailLines:    options.TailLines,
+		// The API server has no end time, so --until needs timestamps to cut the stream
+		Timestamps: options.Timestamps || options.UntilTime != nil,
 	}
 
 	req := c.clientset.CoreV1().Pods(options.Namespace).GetLogs(options.ResourceName, podLogOpts)
@@ -98,15 +135,26 @@ func (c *LogCollector) GetPodLogs(ctx context.Context, options LogOptions) ([]Lo
 					// Add the last line if it's not empty
 					if line != "" {
 						entry := parseLogLine(line, options.ResourceName, options.Container)
-						logEntries = append(logEntries, entry)
+						entry.Namespace = options.Namespace
+						if options.Filter.Match(entry) && !options.pastUntil(entry) {
+							logEntries = append(logEntries, entry)
+						}
 					}
 					return logEntries, nil
 				}
 				return logEntries, fmt.Errorf("error reading log stream: %w", err)
 			}
 
-			// Parse and add the log entry
+			// Parse and add the log entry, dropping lines excluded by the filter
 			entry := parseLogLine(line, options.ResourceName, options.Container)
+			entry.Namespace = options.Namespace
+			if options.pastUntil(entry) {
+				// Lines arrive in order, so nothing after this one can be in range
+				return logEntries, nil
+			}
+			if !options.Filter.Match(entry) {
+				continue
+			}
 			logEntries = append(logEntries, entry)
 
 			// For non-follow logs, we limit the number of entries to prevent memory issues
@@ -117,21 +165,92 @@ func (c *LogCollector) GetPodLogs(ctx context.Context, options LogOptions) ([]Lo
 	}
 }
 
+// enumeratesContainers reports whether containers must be listed from the pod spec
+// instead of reading the pod's default container
+func (o LogOptions) enumeratesContainers() bool {
+	return o.Container == "" && (o.Filter.selectsContainers() || o.AllContainers || o.InitContainers || o.EphemeralContainers)
+}
+
+// wantsContainer reports whether logs of a container should be collected when enumerating containers.
+// A container filter takes precedence over the ignore list so sidecars can still be selected explicitly.
+func (o LogOptions) wantsContainer(name string) bool {
+	if o.Filter.selectsContainers() {
+		return o.Filter.MatchContainer(name)
+	}
+	for _, ignored := range o.IgnoreContainers {
+		if name == ignored {
+			return false
+		}
+	}
+	return true
+}
+
+// pastUntil reports whether an entry was logged after the requested end time
+func (o LogOptions) pastUntil(entry LogEntry) bool {
+	return o.UntilTime != nil && entry.Timestamp.After(o.UntilTime.Time)
+}
+
 // GetResourceLogs retrieves logs from a Kubernetes resource
-// This handles different resource types (e.g., deployments, statefulsets)
+// This handles different resource types (e.g., deployments, statefulsets), the services of a
+// node and the control plane static pods
 func (c *LogCollector) GetResourceLogs(ctx context.Context, options LogOptions) ([]LogEntry, error) {
+	if options.SelectsPods() {
+		return c.getSelectorLogs(ctx, options)
+	}
 	switch options.ResourceType {
 	case "pod":
+		// Selecting containers by name or kind requires the pod spec
+		if options.enumeratesContainers() {
+			pod, err := c.clientset.CoreV1().Pods(options.Namespace).Get(ctx, options.ResourceName, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("error getting pod %s: %w", options.ResourceName, err)
+			}
+			return c.getLogsFromPods(ctx, []corev1.Pod{*pod}, options)
+		}
 		return c.GetPodLogs(ctx, options)
 	case "deployment", "deploy":
 		return c.getDeploymentLogs(ctx, options)
 	case "statefulset", "sts":
 		return c.getStatefulSetLogs(ctx, options)
+	case "node", "no":
+		return c.getNodeLogs(ctx, options)
+	case "control-plane", "controlplane":
+		return c.getControlPlaneLogs(ctx, options)
 	default:
 		return nil, fmt.Errorf("unsupported resource type: %s", options.ResourceType)
 	}
 }
 
+// SelectsPods reports whether the options select pods by label or field rather than name a resource
+func (o LogOptions) SelectsPods() bool {
+	return o.Selector != "" || o.FieldSelector != ""
+}
+
+// Selectors describes the label and field selectors of the options, such as app=web,status.phase=Running
+func (o LogOptions) Selectors() string {
+	var selectors []string
+	for _, selector := range []string{o.Selector, o.FieldSelector} {
+		if selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	return strings.Join(selectors, ",")
+}
+
+// getSelectorLogs retrieves logs from the pods matching label and field selectors, in one
+// namespace or in every namespace
+func (c *LogCollector) getSelectorLogs(ctx context.Context, options LogOptions) ([]LogEntry, error) {
+	pods, err := c.clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: options.Selector,
+		FieldSelector: options.FieldSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods matching %s: %w", options.Selectors(), err)
+	}
+
+	return c.getLogsFromPods(ctx, pods.Items, options)
+}
+
 // getDeploymentLogs retrieves logs from all pods in a deployment
 func (c *LogCollector) getDeploymentLogs(ctx context.Context, options LogOptions) ([]LogEntry, error) {
 	// Get the deployment to find its selector
@@ -172,33 +291,140 @@ func (c *LogCollector) getStatefulSetLogs(ctx context.Context, options LogOption
 	return c.getLogsFromPods(ctx, pods.Items, options)
 }
 
-// getLogsFromPods retrieves logs from a list of pods
+// getLogsFromPods retrieves logs from a list of pods using a bounded pool of workers
+// and merges them into a single timestamp-ordered result
 func (c *LogCollector) getLogsFromPods(ctx context.Context, pods []corev1.Pod, options LogOptions) ([]LogEntry, error) {
-	var allLogs []LogEntry
+	workers := options.MaxConcurrency
+	if workers <= 0 {
+		workers = DefaultMaxConcurrency
+	}
+
+	// Ask the API server for timestamps so entries from different pods can be merged chronologically
+	options.Timestamps = true
 
-	// Collect logs from each pod
+	// Cap the lines fetched per pod, keeping a smaller tail if one was requested
+	if options.MaxLinesPerPod > 0 && (options.TailLines == nil || *options.TailLines > options.MaxLinesPerPod) {
+		maxLines := options.MaxLinesPerPod
+		options.TailLines = &maxLines
+	}
+
+	// Build the list of log streams to fetch, one per pod or per selected container
+	type logStream struct {
+		namespace string
+		pod       string
+		container string
+	}
+	var streams []logStream
 	for _, pod := range pods {
-		podOpts := options
-		podOpts.ResourceType = "pod"
-		podOpts.ResourceName = pod.Name
-
-		logs, err := c.GetPodLogs(ctx, podOpts)
-		if err != nil {
-			// Continue to next pod if we can't get logs from this one
-			fmt.Printf("Warning: error getting logs from pod %s: %v\n", pod.Name, err)
+		if !options.enumeratesContainers() {
+			streams = append(streams, logStream{namespace: pod.Namespace, pod: pod.Name, container: options.Container})
 			continue
 		}
 
-		allLogs = append(allLogs, logs...)
+		if options.AllContainers || options.Filter.selectsContainers() {
+			for _, container := range pod.Spec.Containers {
+				if options.wantsContainer(container.Name) {
+					streams = append(streams, logStream{namespace: pod.Namespace, pod: pod.Name, container: container.Name})
+				}
+			}
+		} else {
+			streams = append(streams, logStream{namespace: pod.Namespace, pod: pod.Name})
+		}
+
+		// Init and ephemeral containers only have logs once they have started
+		if options.InitContainers {
+			for _, container := range pod.Spec.InitContainers {
+				if options.wantsContainer(container.Name) && containerStarted(pod.Status.InitContainerStatuses, container.Name) {
+					streams = append(streams, logStream{namespace: pod.Namespace, pod: pod.Name, container: container.Name})
+				}
+			}
+		}
+		if options.EphemeralContainers {
+			for _, container := range pod.Spec.EphemeralContainers {
+				if options.wantsContainer(container.Name) && containerStarted(pod.Status.EphemeralContainerStatuses, container.Name) {
+					streams = append(streams, logStream{namespace: pod.Namespace, pod: pod.Name, container: container.Name})
+				}
+			}
+		}
+	}
+
+	streamLogs := make([][]LogEntry, len(streams))
+	streamErrs := make([]error, len(streams))
+	semaphore := make(chan struct{}, workers)
+
+	// Collect logs from each stream concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	fetched := 0
+	for i, stream := range streams {
+		wg.Add(1)
+		go func(index int, stream logStream) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			podOpts := options
+			podOpts.ResourceType = "pod"
+			podOpts.ResourceName = stream.pod
+			podOpts.Container = stream.container
+			if stream.namespace != "" {
+				podOpts.Namespace = stream.namespace
+			}
+
+			streamLogs[index], streamErrs[index] = c.GetPodLogs(ctx, podOpts)
+
+			if options.Progress != nil {
+				mu.Lock()
+				fetched++
+				options.Progress(fetched, len(streams))
+				mu.Unlock()
+			}
+		}(i, stream)
+	}
+	wg.Wait()
+
+	var allLogs []LogEntry
+	for i, stream := range streams {
+		if streamErrs[i] != nil {
+			// Skip pods we can't get logs from
+			source := stream.pod
+			if options.SelectsPods() {
+				source = stream.namespace + "/" + source
+			}
+			if stream.container != "" {
+				source += "/" + stream.container
+			}
+			fmt.Fprintf(os.Stderr, "Warning: error getting logs from pod %s: %v\n", source, streamErrs[i])
+			continue
+		}
+		allLogs = append(allLogs, streamLogs[i]...)
 	}
 
 	if len(allLogs) == 0 {
+		if options.SelectsPods() {
+			return nil, fmt.Errorf("no logs found for pods matching %s", options.Selectors())
+		}
 		return nil, fmt.Errorf("no logs found for %s %s", options.ResourceType, options.ResourceName)
 	}
 
+	// Order the merged result by timestamp; the stable sort keeps each pod's lines in order
+	sort.SliceStable(allLogs, func(i, j int) bool {
+		return allLogs[i].Timestamp.Before(allLogs[j].Timestamp)
+	})
+
 	return allLogs, nil
 }
 
+// containerStarted reports whether a container is running or has run, and so has logs
+func containerStarted(statuses []corev1.ContainerStatus, name string) bool {
+	for _, status := range statuses {
+		if status.Name == name {
+			return status.State.Running != nil || status.State.Terminated != nil || status.LastTerminationState.Terminated != nil
+		}
+	}
+	return false
+}
+
 // parseLogLine parses a log line into a structured LogEntry
 func parseLogLine(line string, podName, containerName string) LogEntry {
 	line = strings.TrimSuffix(line, "\n")
@@ -211,19 +437,23 @@ func parseLogLine(line string, podName, containerName string) LogEntry {
 		Data:          make(map[string]string),
 	}
 
-	// Try to extract timestamp
+	// Try to extract timestamp, keeping only the message as content
 	if timestampEnd := strings.IndexByte(line, ' '); timestampEnd > 0 {
 		if t, err := time.Parse(time.RFC3339, line[:timestampEnd]); err == nil {
 			entry.Timestamp = t
 			line = line[timestampEnd+1:]
+			entry.Content = line
 		}
 	}
 
-	// Try to extract log level
-	for _, level := range []string{"DEBUG", "INFO", "WARN", "WARNING", "ERROR", "FATAL"} {
-		if strings.Contains(line, level) {
-			entry.LogLevel = level
-			break
+	// Try to extract log level, first from the klog header of Kubernetes components
+	entry.LogLevel = klogLevel(line)
+	if entry.LogLevel == "" {
+		for _, level := range []string{"DEBUG", "INFO", "WARN", "WARNING", "ERROR", "FATAL"} {
+			if strings.Contains(line, level) {
+				entry.LogLevel = level
+				break
+			}
 		}
 	}
 
@@ -245,6 +475,18 @@ func parseLogLine(line string, podName, containerName string) LogEntry {
 	return entry
 }
 
+// ParseLogText parses raw log output (one entry per line) into structured entries
+func ParseLogText(text string, podName, containerName string) []LogEntry {
+	var entries []LogEntry
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entries = append(entries, parseLogLine(line, podName, containerName))
+	}
+	return entries
+}
+
 // extractStructuredData attempts to extract structured data from log content
 func extractStructuredData(entry *LogEntry) {
 	content := entry.Content
@@ -327,8 +569,11 @@ func (c *LogCollector) streamPodLogs(ctx context.Context, options LogOptions, lo
 				return err
 			}
 
-			// Parse and send the log entry
+			// Parse and send the log entry, dropping lines excluded by the filter
 			entry := parseLogLine(line, options.ResourceName, options.Container)
+			if !options.Filter.Match(entry) {
+				continue
+			}
 			select {
 			case logChan <- entry:
 				// Successfully sent the log entry
@@ -0,0 +1,160 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseLogText(t *testing.T) {
+	text := "2026-05-01T10:00:00Z INFO server started port=8080\n" +
+		"\n" +
+		"2026-05-01T10:00:05Z failed to connect to db\n" +
+		"E0501 10:00:06.123456       1 controller.go:42] sync failed\n" +
+		"no timestamp, just a warning sign\n"
+	entries := ParseLogText(text, "web-1", "app")
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if !first.Timestamp.Equal(time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)) || first.Content != "INFO server started port=8080" {
+		t.Errorf("the timestamp was not split from the content: %+v", first)
+	}
+	if first.LogLevel != "INFO" || first.Data["port"] != "8080" || first.PodName != "web-1" || first.ContainerName != "app" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	for i, want := range []string{"INFO", "ERROR", "ERROR", "WARN"} {
+		if entries[i].LogLevel != want {
+			t.Errorf("entry %d %q has level %s, want %s", i, entries[i].Content, entries[i].LogLevel, want)
+		}
+	}
+}
+
+// newLogServer serves the logs of pods and containers, keyed pod or pod/container, from a fake
+// API server; a missing key answers 500
+func newLogServer(t *testing.T, logs map[string]string) (kubernetes.Interface, *[]string) {
+	t.Helper()
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /api/v1/namespaces/<namespace>/pods/<pod>/log
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) != 8 || parts[7] != "log" {
+			http.NotFound(w, r)
+			return
+		}
+		key := parts[6]
+		if container := r.URL.Query().Get("container"); container != "" {
+			key += "/" + container
+		}
+		requested = append(requested, key)
+		text, ok := logs[key]
+		if !ok {
+			http.Error(w, "no logs", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, text)
+	}))
+	t.Cleanup(server.Close)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientset, &requested
+}
+
+func TestGetLogsFromPodsMerges(t *testing.T) {
+	clientset, _ := newLogServer(t, map[string]string{
+		"web-1": "2026-05-01T10:00:00Z first\n2026-05-01T10:00:02Z third\n2026-05-01T10:00:02Z fourth\n",
+		"web-2": "2026-05-01T10:00:01Z second\n2026-05-01T10:00:03Z fifth\n",
+	})
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "shop"}},
+		// Pods whose logs cannot be read are skipped
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-3", Namespace: "shop"}},
+	}
+
+	entries, err := NewLogCollector(clientset).getLogsFromPods(context.Background(), pods, LogOptions{Namespace: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Content)
+	}
+	if want := "first second third fourth fifth"; strings.Join(got, " ") != want {
+		t.Errorf("entries are not merged by timestamp: %q, want %q", got, want)
+	}
+}
+
+func TestGetLogsFromPodsFilters(t *testing.T) {
+	clientset, requested := newLogServer(t, map[string]string{
+		"web-1/app":         "2026-05-01T10:00:00Z ERROR payment failed\n2026-05-01T10:00:01Z INFO GET /healthz\n",
+		"web-1/istio-proxy": "2026-05-01T10:00:00Z ERROR upstream reset\n",
+		"web-1/migrate":     "2026-05-01T09:59:00Z ERROR migration failed\n",
+	})
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			Containers:     []corev1.Container{{Name: "app"}, {Name: "istio-proxy"}},
+			InitContainers: []corev1.Container{{Name: "migrate"}, {Name: "never-ran"}},
+		},
+		Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+			{Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+			{Name: "never-ran", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}},
+		}},
+	}
+	filter, err := NewLogFilter("", "", "error", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	options := LogOptions{
+		Namespace:        "shop",
+		AllContainers:    true,
+		InitContainers:   true,
+		IgnoreContainers: DefaultIgnoreContainers,
+		Filter:           filter,
+		Progress:         func(done, n int) { total = n },
+	}
+	entries, err := NewLogCollector(clientset).getLogsFromPods(context.Background(), []corev1.Pod{pod}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(*requested, " ") != "web-1/app web-1/migrate" && strings.Join(*requested, " ") != "web-1/migrate web-1/app" {
+		t.Errorf("expected only the app and the init container that ran to be read, got %v", *requested)
+	}
+	if total != 2 {
+		t.Errorf("expected progress over 2 streams, got %d", total)
+	}
+	if len(entries) != 2 || entries[0].Content != "ERROR migration failed" || entries[1].ContainerName != "app" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestGetPodLogsUntil(t *testing.T) {
+	clientset, _ := newLogServer(t, map[string]string{
+		"web-1": "2026-05-01T10:00:00Z before\n2026-05-01T10:05:00Z at the end\n2026-05-01T10:06:00Z after\n2026-05-01T10:07:00Z later",
+	})
+	until := metav1.NewTime(time.Date(2026, 5, 1, 10, 5, 0, 0, time.UTC))
+	entries, err := NewLogCollector(clientset).GetPodLogs(context.Background(), LogOptions{Namespace: "shop", ResourceName: "web-1", UntilTime: &until})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Content != "at the end" {
+		t.Errorf("expected the lines up to --until, got %+v", entries)
+	}
+}
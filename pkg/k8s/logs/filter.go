@@ -0,0 +1,94 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// levelRank orders log levels from least to most severe
+var levelRank = map[string]int{
+	"DEBUG":   0,
+	"INFO":    1,
+	"WARN":    2,
+	"WARNING": 2,
+	"ERROR":   3,
+	"FATAL":   4,
+}
+
+// LogFilter selects which containers and log lines are collected
+type LogFilter struct {
+	// Only keep lines matching this expression
+	Include *regexp.Regexp
+	// Drop lines matching this expression
+	Exclude *regexp.Regexp
+	// Minimum log level to keep (empty keeps all levels)
+	MinLevel string
+	// Only collect containers whose name matches this expression
+	Containers *regexp.Regexp
+}
+
+// NewLogFilter compiles filter expressions; empty arguments disable the corresponding filter.
+// It returns nil when no filter is requested.
+func NewLogFilter(include, exclude, minLevel, containers string) (*LogFilter, error) {
+	if include == "" && exclude == "" && minLevel == "" && containers == "" {
+		return nil, nil
+	}
+
+	filter := &LogFilter{}
+	var err error
+
+	if include != "" {
+		if filter.Include, err = regexp.Compile(include); err != nil {
+			return nil, fmt.Errorf("invalid --grep expression: %w", err)
+		}
+	}
+	if exclude != "" {
+		if filter.Exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("invalid --exclude expression: %w", err)
+		}
+	}
+	if containers != "" {
+		if filter.Containers, err = regexp.Compile(containers); err != nil {
+			return nil, fmt.Errorf("invalid --containers expression: %w", err)
+		}
+	}
+	if minLevel != "" {
+		filter.MinLevel = strings.ToUpper(minLevel)
+		if _, ok := levelRank[filter.MinLevel]; !ok {
+			return nil, fmt.Errorf("invalid --level %q (use debug, info, warn, error or fatal)", minLevel)
+		}
+	}
+
+	return filter, nil
+}
+
+// Match reports whether a log entry passes the line filters
+func (f *LogFilter) Match(entry LogEntry) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinLevel != "" && levelRank[entry.LogLevel] < levelRank[f.MinLevel] {
+		return false
+	}
+	if f.Include != nil && !f.Include.MatchString(entry.Content) {
+		return false
+	}
+	if f.Exclude != nil && f.Exclude.MatchString(entry.Content) {
+		return false
+	}
+	return true
+}
+
+// MatchContainer reports whether logs should be collected from a container
+func (f *LogFilter) MatchContainer(name string) bool {
+	if f == nil || f.Containers == nil {
+		return true
+	}
+	return f.Containers.MatchString(name)
+}
+
+// selectsContainers reports whether the filter picks containers by name
+func (f *LogFilter) selectsContainers() bool {
+	return f != nil && f.Containers != nil
+}
@@ -0,0 +1,70 @@
+package logs
+
+import "testing"
+
+func TestNewLogFilter(t *testing.T) {
+	if filter, err := NewLogFilter("", "", "", ""); filter != nil || err != nil {
+		t.Errorf("expected no filter without expressions, got %+v, %v", filter, err)
+	}
+	for _, args := range [][4]string{
+		{"(", "", "", ""},
+		{"", "[", "", ""},
+		{"", "", "verbose", ""},
+		{"", "", "", "*"},
+	} {
+		if _, err := NewLogFilter(args[0], args[1], args[2], args[3]); err == nil {
+			t.Errorf("NewLogFilter%q: expected an error", args)
+		}
+	}
+}
+
+func TestLogFilterMatch(t *testing.T) {
+	tests := []struct {
+		name                              string
+		include, exclude, level, contents string
+		entry                             LogEntry
+		want                              bool
+	}{
+		{name: "include", include: "timeout", entry: LogEntry{LogLevel: "ERROR", Content: "upstream timeout"}, want: true},
+		{name: "include misses", include: "timeout", entry: LogEntry{LogLevel: "ERROR", Content: "connection refused"}},
+		{name: "exclude", exclude: "healthz", entry: LogEntry{LogLevel: "INFO", Content: "GET /healthz 200"}},
+		{name: "exclude wins", include: "GET", exclude: "healthz", entry: LogEntry{LogLevel: "INFO", Content: "GET /healthz 200"}},
+		{name: "level above", level: "warn", entry: LogEntry{LogLevel: "ERROR", Content: "failed"}, want: true},
+		{name: "level equal", level: "warning", entry: LogEntry{LogLevel: "WARN", Content: "slow"}, want: true},
+		{name: "level below", level: "warn", entry: LogEntry{LogLevel: "INFO", Content: "started"}},
+		{name: "unknown level ranks lowest", level: "info", entry: LogEntry{LogLevel: "TRACE", Content: "step"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewLogFilter(tt.include, tt.exclude, tt.level, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.Match(tt.entry); got != tt.want {
+				t.Errorf("Match(%+v) = %v, want %v", tt.entry, got, tt.want)
+			}
+		})
+	}
+
+	var none *LogFilter
+	if !none.Match(LogEntry{Content: "anything"}) || !none.MatchContainer("istio-proxy") {
+		t.Errorf("a nil filter must keep everything")
+	}
+}
+
+func TestWantsContainer(t *testing.T) {
+	options := LogOptions{AllContainers: true, IgnoreContainers: DefaultIgnoreContainers}
+	if !options.wantsContainer("app") || options.wantsContainer("istio-proxy") {
+		t.Errorf("expected sidecars to be skipped when collecting all containers")
+	}
+
+	// A container filter selects sidecars explicitly
+	filter, err := NewLogFilter("", "", "", "^istio-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Filter = filter
+	if options.wantsContainer("app") || !options.wantsContainer("istio-proxy") {
+		t.Errorf("expected the container filter to take precedence over the ignore list")
+	}
+}
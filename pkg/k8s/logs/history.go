@@ -0,0 +1,308 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Limits of the rolling log history of a workload
+const (
+	// HistoryRetention is how far back the history of a workload reaches
+	HistoryRetention = 7 * 24 * time.Hour
+	// Maximum number of patterns kept per hour; the least severe and least frequent are dropped
+	maxHourPatterns = 50
+	// Maximum number of patterns reported in a trend
+	maxTrendPatterns = 10
+)
+
+// LogHistory is a rolling summary of the logs of a workload, one bucket per hour, kept so that
+// later analyses can see trends in logs the cluster no longer holds
+type LogHistory struct {
+	// Kind of the workload
+	ResourceType string `json:"resourceType"`
+	// Name of the workload
+	ResourceName string `json:"resourceName"`
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+	// Timestamp of the newest entry recorded; entries up to it that a later run reads again are
+	// not counted twice
+	RecordedThrough time.Time `json:"recordedThrough"`
+	// Hourly summaries, oldest first
+	Hours []HourSummary `json:"hours"`
+}
+
+// HourSummary counts the log entries of one hour
+type HourSummary struct {
+	// Start of the hour in UTC
+	Start time.Time `json:"start"`
+	// Number of log entries
+	Entries int `json:"entries"`
+	// Number of error and fatal entries
+	Errors int `json:"errors"`
+	// Number of warning entries
+	Warnings int `json:"warnings"`
+	// Occurrence counts keyed by pattern fingerprint
+	Patterns map[string]BaselinePattern `json:"patterns,omitempty"`
+}
+
+// LogTrend summarizes the history of a workload for display and prompts
+type LogTrend struct {
+	// Start of the oldest hour recorded
+	Since time.Time `json:"since"`
+	// Number of hours with recorded logs
+	HoursRecorded int `json:"hoursRecorded"`
+	// Totals per day, oldest first
+	Days []DayTrend `json:"days"`
+	// Most severe and frequent patterns over the whole history
+	Patterns []PatternTrend `json:"patterns,omitempty"`
+}
+
+// DayTrend totals the recorded log entries of one day
+type DayTrend struct {
+	// Day in UTC
+	Date time.Time `json:"date"`
+	// Number of log entries
+	Entries int `json:"entries"`
+	// Number of error and fatal entries
+	Errors int `json:"errors"`
+	// Number of warning entries
+	Warnings int `json:"warnings"`
+	// Share of entries that are errors, in percent
+	ErrorPercent float64 `json:"errorPercent"`
+	// Start of the hour with the most errors
+	PeakHour time.Time `json:"peakHour"`
+	// Number of errors in that hour
+	PeakErrors int `json:"peakErrors,omitempty"`
+}
+
+// PatternTrend is a log pattern seen over the history of a workload
+type PatternTrend struct {
+	// Normalized pattern
+	Pattern string `json:"pattern"`
+	// Log level of the pattern
+	Level string `json:"level"`
+	// Occurrences over the history
+	Count int `json:"count"`
+	// Number of hours in which the pattern occurred
+	Hours int `json:"hours"`
+	// Start of the first hour the pattern occurred in
+	FirstSeen time.Time `json:"firstSeen"`
+	// Start of the last hour the pattern occurred in
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// NewLogHistory creates an empty history for a workload
+func NewLogHistory(resourceType, resourceName, namespace string) *LogHistory {
+	return &LogHistory{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		Namespace:    namespace,
+	}
+}
+
+// Record adds log entries newer than those already recorded to their hours, and drops hours
+// older than HistoryRetention. Entries without a timestamp cannot be placed and are skipped. It
+// returns the number of entries recorded.
+func (h *LogHistory) Record(entries []LogEntry, now time.Time) int {
+	cutoff := now.Add(-HistoryRetention).UTC().Truncate(time.Hour)
+	hours := make(map[time.Time]*HourSummary)
+	for i := range h.Hours {
+		hours[h.Hours[i].Start] = &h.Hours[i]
+	}
+
+	recorded := 0
+	newest := h.RecordedThrough
+	added := make(map[time.Time]*HourSummary)
+	for _, entry := range entries {
+		if entry.Timestamp.IsZero() || !entry.Timestamp.After(h.RecordedThrough) {
+			continue
+		}
+		start := entry.Timestamp.UTC().Truncate(time.Hour)
+		if start.Before(cutoff) {
+			continue
+		}
+
+		hour, ok := hours[start]
+		if !ok {
+			hour = &HourSummary{Start: start}
+			hours[start] = hour
+			added[start] = hour
+		}
+		if hour.Patterns == nil {
+			hour.Patterns = make(map[string]BaselinePattern)
+		}
+		hour.Entries++
+		switch entry.LogLevel {
+		case "ERROR", "FATAL":
+			hour.Errors++
+		case "WARN", "WARNING":
+			hour.Warnings++
+		}
+		key := Fingerprint(entry)
+		pattern := hour.Patterns[key]
+		pattern.Level = entry.LogLevel
+		pattern.Count++
+		hour.Patterns[key] = pattern
+
+		if entry.Timestamp.After(newest) {
+			newest = entry.Timestamp
+		}
+		recorded++
+	}
+	h.RecordedThrough = newest
+
+	kept := h.Hours[:0]
+	for _, hour := range h.Hours {
+		if !hour.Start.Before(cutoff) {
+			kept = append(kept, hour)
+		}
+	}
+	for _, hour := range added {
+		kept = append(kept, *hour)
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].Start.Before(kept[j].Start) })
+	for i := range kept {
+		kept[i].Patterns = topPatterns(kept[i].Patterns, maxHourPatterns)
+	}
+	h.Hours = kept
+	return recorded
+}
+
+// topPatterns keeps the most severe and then most frequent of a set of patterns
+func topPatterns(patterns map[string]BaselinePattern, max int) map[string]BaselinePattern {
+	if len(patterns) <= max {
+		return patterns
+	}
+	keys := make([]string, 0, len(patterns))
+	for key := range patterns {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := patterns[keys[i]], patterns[keys[j]]
+		if ri, rj := levelRank[pi.Level], levelRank[pj.Level]; ri != rj {
+			return ri > rj
+		}
+		if pi.Count != pj.Count {
+			return pi.Count > pj.Count
+		}
+		return keys[i] < keys[j]
+	})
+	top := make(map[string]BaselinePattern, max)
+	for _, key := range keys[:max] {
+		top[key] = patterns[key]
+	}
+	return top
+}
+
+// Trend summarizes the history per day, with its most severe and frequent patterns. It returns
+// nil when nothing was recorded.
+func (h *LogHistory) Trend() *LogTrend {
+	if h == nil || len(h.Hours) == 0 {
+		return nil
+	}
+
+	trend := &LogTrend{Since: h.Hours[0].Start, HoursRecorded: len(h.Hours)}
+	patterns := make(map[string]*PatternTrend)
+	for _, hour := range h.Hours {
+		date := hour.Start.Truncate(24 * time.Hour)
+		if len(trend.Days) == 0 || !trend.Days[len(trend.Days)-1].Date.Equal(date) {
+			trend.Days = append(trend.Days, DayTrend{Date: date})
+		}
+		day := &trend.Days[len(trend.Days)-1]
+		day.Entries += hour.Entries
+		day.Errors += hour.Errors
+		day.Warnings += hour.Warnings
+		if hour.Errors > day.PeakErrors {
+			day.PeakHour = hour.Start
+			day.PeakErrors = hour.Errors
+		}
+
+		for key, pattern := range hour.Patterns {
+			seen, ok := patterns[key]
+			if !ok {
+				seen = &PatternTrend{Pattern: key, FirstSeen: hour.Start}
+				patterns[key] = seen
+			}
+			seen.Level = pattern.Level
+			seen.Count += pattern.Count
+			seen.Hours++
+			seen.LastSeen = hour.Start
+		}
+	}
+	for i := range trend.Days {
+		if trend.Days[i].Entries > 0 {
+			trend.Days[i].ErrorPercent = float64(trend.Days[i].Errors) * 100 / float64(trend.Days[i].Entries)
+		}
+	}
+
+	for _, pattern := range patterns {
+		trend.Patterns = append(trend.Patterns, *pattern)
+	}
+	sort.Slice(trend.Patterns, func(i, j int) bool {
+		pi, pj := trend.Patterns[i], trend.Patterns[j]
+		if ri, rj := levelRank[pi.Level], levelRank[pj.Level]; ri != rj {
+			return ri > rj
+		}
+		if pi.Count != pj.Count {
+			return pi.Count > pj.Count
+		}
+		return pi.Pattern < pj.Pattern
+	})
+	if len(trend.Patterns) > maxTrendPatterns {
+		trend.Patterns = trend.Patterns[:maxTrendPatterns]
+	}
+	return trend
+}
+
+// DefaultHistoryDir returns the default directory for log histories (~/.kube-ai/history)
+func DefaultHistoryDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".kube-ai", "history"), nil
+}
+
+// HistoryPath returns the file that stores the log history of a workload in a cluster context
+func HistoryPath(dir, context, namespace, resourceType, resourceName string) string {
+	return workloadFile(dir, context, namespace, resourceType, resourceName)
+}
+
+// LoadHistory reads a stored log history, returning nil if none exists
+func LoadHistory(path string) (*LogHistory, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading log history: %w", err)
+	}
+
+	var history LogHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("error parsing log history %s: %w", path, err)
+	}
+	return &history, nil
+}
+
+// Save writes the history to path, creating parent directories as needed. It is only readable by
+// the user, as its patterns quote log lines.
+func (h *LogHistory) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating log history directory: %w", err)
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("error encoding log history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing log history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultNodeService is the node service whose logs are read when no container is given
+const DefaultNodeService = "kubelet"
+
+// ControlPlaneNamespace is the namespace of the static pods running the control plane
+const ControlPlaneNamespace = "kube-system"
+
+// journalLine matches a line of journalctl short-precise output, the format of the node log
+// query: "Jan 02 15:04:05.123456 host kubelet[1234]: message"
+var journalLine = regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d+)?) \S+ [^:\s]+: (.*)$`)
+
+// klogHeader matches the header of a klog line, used by the kubelet and control plane components:
+// "E0102 15:04:05.123456    1234 file.go:42] message"
+var klogHeader = regexp.MustCompile(`^([IWEF])(\d{4} \d{2}:\d{2}:\d{2}\.\d+)\s+\d+ [^\]]+\] `)
+
+// klogLevels maps the severity letter of a klog header to a log level
+var klogLevels = map[string]string{"I": "INFO", "W": "WARN", "E": "ERROR", "F": "FATAL"}
+
+// IsNodeResource reports whether a resource type reads the logs of a node service
+func IsNodeResource(resourceType string) bool {
+	return resourceType == "node" || resourceType == "no"
+}
+
+// IsControlPlaneResource reports whether a resource type reads the logs of the control plane
+// static pods
+func IsControlPlaneResource(resourceType string) bool {
+	return resourceType == "control-plane" || resourceType == "controlplane"
+}
+
+// ControlPlaneSelector returns the label selector of the kubeadm static pods running a control
+// plane component, such as kube-apiserver or etcd, or all of them when component is "all"
+func ControlPlaneSelector(component string) string {
+	set := labels.Set{"tier": "control-plane"}
+	if component != "all" {
+		set["component"] = component
+	}
+	return set.String()
+}
+
+// getNodeLogs retrieves the logs of a service of a node, the kubelet unless options.Container
+// names another one such as containerd. They are read through the node log query of the API
+// server proxy, falling back to the service's log file under /var/log.
+func (c *LogCollector) getNodeLogs(ctx context.Context, options LogOptions) ([]LogEntry, error) {
+	service := options.Container
+	if service == "" {
+		service = DefaultNodeService
+	}
+	var since time.Time
+	switch {
+	case options.SinceTime != nil:
+		since = options.SinceTime.Time
+	case options.SinceSeconds != nil:
+		since = time.Now().Add(-time.Duration(*options.SinceSeconds) * time.Second)
+	}
+
+	// The node log query reads the journal or log file of a service; it needs the NodeLogQuery
+	// feature gate and enableSystemLogQuery in the kubelet configuration
+	query := c.clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", options.ResourceName, "proxy", "logs/").
+		Param("query", service)
+	if options.TailLines != nil {
+		query = query.Param("tailLines", strconv.FormatInt(*options.TailLines, 10))
+	}
+	if !since.IsZero() {
+		query = query.Param("sinceTime", since.UTC().Format(time.RFC3339))
+	}
+	data, err := query.DoRaw(ctx)
+	if err != nil {
+		file, fileErr := c.clientset.CoreV1().RESTClient().Get().
+			AbsPath("/api/v1/nodes", options.ResourceName, "proxy", "logs", service+".log").
+			DoRaw(ctx)
+		if fileErr != nil {
+			return nil, fmt.Errorf("error reading %s logs of node %s (the node log query requires the NodeLogQuery feature gate and enableSystemLogQuery in the kubelet configuration): %w", service, options.ResourceName, err)
+		}
+		data = file
+	}
+
+	var entries []LogEntry
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry := parseNodeLogLine(line, options.ResourceName, service)
+		if (!since.IsZero() && entry.Timestamp.Before(since)) || options.pastUntil(entry) || !options.Filter.Match(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	// The log file is read whole, so the tail is applied here
+	if options.TailLines != nil && int64(len(entries)) > *options.TailLines {
+		entries = entries[int64(len(entries))-*options.TailLines:]
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no %s logs found for node %s", service, options.ResourceName)
+	}
+	return entries, nil
+}
+
+// getControlPlaneLogs retrieves the logs of the static pods running a control plane component,
+// or all of them when the resource name is "all". Managed control planes run no such pods.
+func (c *LogCollector) getControlPlaneLogs(ctx context.Context, options LogOptions) ([]LogEntry, error) {
+	options.Namespace = ControlPlaneNamespace
+	pods, err := c.clientset.CoreV1().Pods(ControlPlaneNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: ControlPlaneSelector(options.ResourceName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing control plane pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no control plane pods for %s in %s; managed control planes (EKS, GKE, AKS) do not run them as pods", options.ResourceName, ControlPlaneNamespace)
+	}
+	return c.getLogsFromPods(ctx, pods.Items, options)
+}
+
+// parseNodeLogLine parses a line of the journal or of a node log file into a LogEntry, taking the
+// timestamp from the journal prefix or the klog header
+func parseNodeLogLine(line, nodeName, service string) LogEntry {
+	entry := parseLogLine(line, nodeName, service)
+	if match := journalLine.FindStringSubmatch(entry.Content); match != nil {
+		if t, err := time.Parse("Jan _2 15:04:05.999999", match[1]); err == nil {
+			entry.Timestamp = withCurrentYear(t)
+		}
+		entry.Content = match[2]
+		if level := klogLevel(entry.Content); level != "" {
+			entry.LogLevel = level
+		}
+	} else if match := klogHeader.FindStringSubmatch(entry.Content); match != nil {
+		if t, err := time.Parse("0102 15:04:05.999999", match[2]); err == nil {
+			entry.Timestamp = withCurrentYear(t)
+		}
+	}
+	return entry
+}
+
+// klogLevel returns the log level of a line starting with a klog header, or "" for other lines
+func klogLevel(line string) string {
+	if match := klogHeader.FindStringSubmatch(line); match != nil {
+		return klogLevels[match[1]]
+	}
+	return ""
+}
+
+// withCurrentYear sets the year of a timestamp logged without one, taking the previous year for
+// dates that would otherwise be in the future
+func withCurrentYear(t time.Time) time.Time {
+	now := time.Now().UTC()
+	dated := time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if dated.After(now.Add(24 * time.Hour)) {
+		dated = dated.AddDate(-1, 0, 0)
+	}
+	return dated
+}
@@ -31,6 +31,8 @@ type LogSummary struct {
 	CommonWarnings []LogPattern
 	// Resources with the most errors
 	ErrorHotspots []ResourceErrorCount
+	// Entries of each namespace, when the logs come from more than one
+	Namespaces []NamespaceLogCount
 	// Potential issues detected
 	PotentialIssues []string
 	// Time range of logs
@@ -55,6 +57,18 @@ type ResourceErrorCount struct {
 	ErrorCount int
 }
 
+// NamespaceLogCount attributes the entries of logs collected across namespaces to their namespace
+type NamespaceLogCount struct {
+	// Namespace name
+	Namespace string
+	// Total number of log entries
+	TotalEntries int
+	// Number of error entries
+	ErrorCount int
+	// Number of warning entries
+	WarningCount int
+}
+
 // LogTimeRange represents the time span of analyzed logs
 type LogTimeRange struct {
 	// Start time of the log range
@@ -91,6 +105,15 @@ func ParseLogs(logs []LogEntry) LogSummary {
 	errorExamples := make(map[string][]LogEntry)
 	warningExamples := make(map[string][]LogEntry)
 
+	// Pods are only unique within a namespace, so hotspots name the namespace when there are several
+	namespaceCounts := make(map[string]*NamespaceLogCount)
+	for _, entry := range logs {
+		if namespaceCounts[entry.Namespace] == nil {
+			namespaceCounts[entry.Namespace] = &NamespaceLogCount{Namespace: entry.Namespace}
+		}
+	}
+	multiNamespace := len(namespaceCounts) > 1
+
 	// Analyze each log entry
 	for _, entry := range logs {
 		// Update time range
@@ -103,11 +126,18 @@ func ParseLogs(logs []LogEntry) LogSummary {
 
 		// Process based on log level
 		content := normalizeLogMessage(entry.Content)
+		namespaceCount := namespaceCounts[entry.Namespace]
+		namespaceCount.TotalEntries++
 
 		switch entry.LogLevel {
 		case "ERROR", "FATAL":
 			summary.ErrorCount++
-			resourceErrorMap[entry.PodName]++
+			namespaceCount.ErrorCount++
+			if multiNamespace {
+				resourceErrorMap[entry.Namespace+"/"+entry.PodName]++
+			} else {
+				resourceErrorMap[entry.PodName]++
+			}
 
 			// Extract key part of the error message
 			errorKey := extractErrorKey(content)
@@ -122,6 +152,7 @@ func ParseLogs(logs []LogEntry) LogSummary {
 
 		case "WARN", "WARNING":
 			summary.WarningCount++
+			namespaceCount.WarningCount++
 
 			// Extract key part of the warning message
 			warningKey := extractWarningKey(content)
@@ -146,6 +177,19 @@ func ParseLogs(logs []LogEntry) LogSummary {
 	// Convert resource error map to sorted slice
 	summary.ErrorHotspots = convertToResourceErrors(resourceErrorMap)
 
+	if multiNamespace {
+		for _, count := range namespaceCounts {
+			summary.Namespaces = append(summary.Namespaces, *count)
+		}
+		sort.Slice(summary.Namespaces, func(i, j int) bool {
+			a, b := summary.Namespaces[i], summary.Namespaces[j]
+			if a.ErrorCount != b.ErrorCount {
+				return a.ErrorCount > b.ErrorCount
+			}
+			return a.Namespace < b.Namespace
+		})
+	}
+
 	// Detect potential issues
 	summary.PotentialIssues = detectIssues(logs, summary)
 
@@ -306,8 +350,14 @@ func detectIssues(logs []LogEntry, summary LogSummary) []string {
 
 // hasErrorSpikes checks if there are sudden spikes in error frequency
 func hasErrorSpikes(logs []LogEntry) bool {
+	_, ok := FindErrorSpike(logs)
+	return ok
+}
+
+// FindErrorSpike returns the start of the minute with the largest unusual spike in error frequency, if any
+func FindErrorSpike(logs []LogEntry) (time.Time, bool) {
 	if len(logs) < 100 {
-		return false
+		return time.Time{}, false
 	}
 
 	// Group errors by minute
@@ -331,21 +381,25 @@ func hasErrorSpikes(logs []LogEntry) bool {
 
 	// Need at least a few minutes of data
 	if len(errorCounts) < 3 {
-		return false
+		return time.Time{}, false
 	}
 
 	// Calculate average and standard deviation
 	avg := average(errorCounts)
 	stdDev := standardDeviation(errorCounts, avg)
 
-	// Check for any minute with error count > avg + 2*stdDev
-	for _, count := range errorCounts {
-		if float64(count) > avg+2*stdDev && count > 5 {
-			return true
+	// Find the minute with the highest error count > avg + 2*stdDev
+	spikeMinute, spikeCount := 0, 0
+	for minute, count := range errorsByMinute {
+		if float64(count) > avg+2*stdDev && count > 5 && (count > spikeCount || (count == spikeCount && minute < spikeMinute)) {
+			spikeMinute, spikeCount = minute, count
 		}
 	}
+	if spikeCount == 0 {
+		return time.Time{}, false
+	}
 
-	return false
+	return baseTime.Add(time.Duration(spikeMinute) * time.Minute), true
 }
 
 // hasRepeatedRestarts checks for patterns indicating frequent restarts
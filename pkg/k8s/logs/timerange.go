@@ -0,0 +1,54 @@
+package logs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses a human-friendly duration such as "15m", "2h", "1h30m" or "7d".
+// A bare number is interpreted as seconds for compatibility with the old --since flag.
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	// time.ParseDuration has no unit for days, so expand a leading day count
+	var days time.Duration
+	rest := value
+	if i := strings.IndexByte(value, 'd'); i > 0 {
+		n, err := strconv.ParseInt(value[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q (use e.g. 30s, 15m, 2h or 1d)", value)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		rest = value[i+1:]
+		if rest == "" {
+			return days, nil
+		}
+	}
+
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30s, 15m, 2h or 1d)", value)
+	}
+	return days + d, nil
+}
+
+// ParseTime parses an RFC3339 timestamp, or a duration meaning that long before now
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use an RFC3339 timestamp or a duration such as 30m)", value)
+	}
+	return now.Add(-d), nil
+}
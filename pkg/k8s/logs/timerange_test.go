@@ -0,0 +1,54 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"15m", 15 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"300", 300 * time.Second},
+		{" 2h ", 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "soon", "d", "xd", "1d2", "15 minutes", "1w"} {
+		if got, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-04-30T08:15:00Z", time.Date(2026, 4, 30, 8, 15, 0, 0, time.UTC)},
+		{"30m", now.Add(-30 * time.Minute)},
+		{"1d", now.Add(-24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.value, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := ParseTime("yesterday", now); err == nil {
+		t.Errorf("expected an error for an invalid time")
+	}
+}
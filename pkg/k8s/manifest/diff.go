@@ -0,0 +1,195 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround the changes of a diff hunk
+const diffContext = 3
+
+// Diff returns the unified diff of two texts, line by line, labelled with fromName and toName as
+// diff -u does. It returns "" when the texts are equal.
+func Diff(from, to, fromName, toName string) string {
+	a, b := splitLines(from), splitLines(to)
+	ops := diffLines(a, b)
+
+	changed := false
+	for _, op := range ops {
+		changed = changed || op.kind != ' '
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk, merging changes closer than twice the
+		// context
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+		begin, end := max(first-diffContext, start), min(last+diffContext+1, len(ops))
+
+		fromLine, toLine := ops[begin].fromLine, ops[begin].toLine
+		fromCount, toCount := 0, 0
+		for _, op := range ops[begin:end] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+		for _, op := range ops[begin:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.text)
+		}
+		start = end
+	}
+	return out.String()
+}
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+'), with the 0-based
+// positions reached in both texts before it
+type diffOp struct {
+	kind             byte
+	text             string
+	fromLine, toLine int
+}
+
+// diffLines returns the edit script of the longest common subsequence of two lists of lines
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		}
+	}
+	return ops
+}
+
+// hunkRange formats the start and length of a hunk in one text, as diff -u does: 1-based, and
+// the line before for an empty range
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+// splitLines splits a text into lines, without a trailing empty line
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// DocumentDiff is how a resource differs between two manifests
+type DocumentDiff struct {
+	// Resource as kind/name
+	Resource string `json:"resource"`
+	// added, removed or changed
+	Change string `json:"change"`
+	// Unified diff of the resource's YAML
+	Diff string `json:"diff"`
+}
+
+// DiffDocuments compares two manifests resource by resource, matched by kind and name, and
+// returns the resources that were added, removed or changed, in the order of the manifests
+func DiffDocuments(from, to []byte) ([]DocumentDiff, error) {
+	before, err := Parse(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := Parse(to)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceYAML := func(documents []Document) (map[string]string, []string, error) {
+		texts := make(map[string]string, len(documents))
+		var order []string
+		for i := range documents {
+			data, err := documents[i].YAML()
+			if err != nil {
+				return nil, nil, err
+			}
+			resource := documents[i].Kind + "/" + documents[i].Name
+			if _, seen := texts[resource]; !seen {
+				order = append(order, resource)
+			}
+			texts[resource] = string(data)
+		}
+		return texts, order, nil
+	}
+	beforeYAML, beforeOrder, err := resourceYAML(before)
+	if err != nil {
+		return nil, err
+	}
+	afterYAML, afterOrder, err := resourceYAML(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []DocumentDiff
+	for _, resource := range afterOrder {
+		old, existed := beforeYAML[resource]
+		diff := Diff(old, afterYAML[resource], "a/"+resource, "b/"+resource)
+		switch {
+		case !existed:
+			diffs = append(diffs, DocumentDiff{Resource: resource, Change: "added", Diff: diff})
+		case diff != "":
+			diffs = append(diffs, DocumentDiff{Resource: resource, Change: "changed", Diff: diff})
+		}
+	}
+	for _, resource := range beforeOrder {
+		if _, kept := afterYAML[resource]; !kept {
+			diffs = append(diffs, DocumentDiff{Resource: resource, Change: "removed", Diff: Diff(beforeYAML[resource], "", "a/"+resource, "b/"+resource)})
+		}
+	}
+	return diffs, nil
+}
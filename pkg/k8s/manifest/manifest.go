@@ -0,0 +1,177 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a single resource of a YAML manifest file, with source positions
+type Document struct {
+	// Kind of the resource
+	Kind string
+	// Name of the resource
+	Name string
+	// Line the document starts on (1-based)
+	Line int
+
+	root *yaml.Node
+}
+
+// Location is the position of a field in a manifest file
+type Location struct {
+	// Path that was resolved, such as spec.template.spec.containers[0].resources. When a field does
+	// not exist, this is its closest existing parent.
+	Path string
+	// Line of the resolved field (1-based)
+	Line int
+	// Whether the complete path was resolved
+	Exact bool
+}
+
+// Parse reads every document of a YAML manifest, keeping node positions
+func Parse(data []byte) ([]Document, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var documents []Document
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing manifest: %w", err)
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		root := node.Content[0]
+		document := Document{Line: root.Line, root: root}
+		if kind := mappingValue(root, "kind"); kind != nil {
+			document.Kind = kind.Value
+		}
+		if metadata := mappingValue(root, "metadata"); metadata != nil {
+			if name := mappingValue(metadata, "name"); name != nil {
+				document.Name = name.Value
+			}
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+// Find returns the document of a resource given as kind/name (or just a name), or nil
+func Find(documents []Document, resource string) *Document {
+	kind, name, found := strings.Cut(resource, "/")
+	if !found {
+		kind, name = "", resource
+	}
+
+	for i := range documents {
+		if (kind == "" || strings.EqualFold(documents[i].Kind, kind)) && documents[i].Name == name {
+			return &documents[i]
+		}
+	}
+	// A manifest with a single resource is unambiguous even if the reference is not
+	if len(documents) == 1 {
+		return &documents[0]
+	}
+	return nil
+}
+
+// Locate resolves a dotted field path such as spec.template.spec.containers[0].resources in the
+// document. List items can also be selected by name, as in containers[web].
+func (d *Document) Locate(path string) Location {
+	location := Location{Line: d.Line, Exact: true}
+	node := d.root
+
+	var resolved []string
+	for _, segment := range strings.Split(path, ".") {
+		key, selector, hasSelector := parseSegment(segment)
+		if key == "" {
+			continue
+		}
+
+		// Block values start on the line after their key, so report the key's line
+		keyNode, value := mappingEntry(node, key)
+		if value == nil {
+			location.Exact = false
+			break
+		}
+		node = value
+		location.Line = keyNode.Line
+		resolved = append(resolved, key)
+
+		if !hasSelector {
+			continue
+		}
+		item := sequenceItem(node, selector)
+		if item == nil {
+			location.Exact = false
+			break
+		}
+		node = item
+		location.Line = item.Line
+		resolved[len(resolved)-1] += "[" + selector + "]"
+	}
+
+	location.Path = strings.Join(resolved, ".")
+	return location
+}
+
+// parseSegment splits a path segment such as containers[0] into its key and item selector
+func parseSegment(segment string) (string, string, bool) {
+	key, rest, found := strings.Cut(strings.TrimSpace(segment), "[")
+	if !found {
+		return key, "", false
+	}
+	return key, strings.TrimSuffix(rest, "]"), true
+}
+
+// mappingEntry returns the key and value nodes of key in a mapping node, or nils
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	_, value := mappingEntry(node, key)
+	return value
+}
+
+// sequenceItem returns the item of a sequence node at a numeric index or with a matching name, or nil
+func sequenceItem(node *yaml.Node, selector string) *yaml.Node {
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index >= 0 && index < len(node.Content) {
+			return node.Content[index]
+		}
+		return nil
+	}
+
+	selector = strings.Trim(strings.TrimPrefix(selector, "name="), `"'`)
+	for _, item := range node.Content {
+		if name := mappingValue(item, "name"); name != nil && name.Value == selector {
+			return item
+		}
+	}
+	return nil
+}
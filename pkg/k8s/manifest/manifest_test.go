@@ -0,0 +1,256 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: shop/web:1.2.0
+        resources:
+          limits:
+            memory: 256Mi
+      - name: istio-proxy
+        image: istio/proxyv2:1.22
+`
+
+const service = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+`
+
+func TestParse(t *testing.T) {
+	documents, err := Parse([]byte("---\n" + deployment + "---\n# empty\n---\n" + service))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(documents))
+	}
+	if documents[0].Kind != "Deployment" || documents[0].Name != "web" || documents[0].Line != 2 {
+		t.Errorf("unexpected first document: %+v", documents[0])
+	}
+	if documents[1].Kind != "Service" || documents[1].Line != 28 {
+		t.Errorf("unexpected second document: %+v", documents[1])
+	}
+
+	if _, err := Parse([]byte("kind: [Deployment")); err == nil {
+		t.Errorf("expected a syntax error")
+	}
+}
+
+func TestFind(t *testing.T) {
+	documents, err := Parse([]byte(deployment + "---\n" + service))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		resource string
+		want     string
+	}{
+		{"deployment/web", "Deployment"},
+		{"Service/web", "Service"},
+		{"web", "Deployment"},
+		{"deployment/api", ""},
+		{"configmap/web", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if document := Find(documents, tt.resource); document != nil {
+			got = document.Kind
+		}
+		if got != tt.want {
+			t.Errorf("Find(%q) = %q, want %q", tt.resource, got, tt.want)
+		}
+	}
+
+	// A single resource is found whatever the reference
+	single := documents[:1]
+	if document := Find(single, "deployment/api"); document == nil || document.Name != "web" {
+		t.Errorf("the only resource of a manifest was not found")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	documents, err := Parse([]byte(deployment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want Location
+	}{
+		{"spec.replicas", Location{Path: "spec.replicas", Line: 7, Exact: true}},
+		{"spec.template.spec.containers[0].resources.limits", Location{Path: "spec.template.spec.containers[0].resources.limits", Line: 20, Exact: true}},
+		{"spec.template.spec.containers[istio-proxy].image", Location{Path: "spec.template.spec.containers[istio-proxy].image", Line: 23, Exact: true}},
+		{`spec.template.spec.containers[name="web"].image`, Location{Path: `spec.template.spec.containers[name="web"].image`, Line: 18, Exact: true}},
+		// Missing fields resolve to their closest existing parent
+		{"spec.template.spec.containers[0].resources.requests", Location{Path: "spec.template.spec.containers[0].resources", Line: 19, Exact: false}},
+		{"spec.template.spec.containers[5].image", Location{Path: "spec.template.spec.containers", Line: 16, Exact: false}},
+		{"spec.strategy.type", Location{Path: "spec", Line: 6, Exact: false}},
+		{"", Location{Path: "", Line: 1, Exact: true}},
+	}
+	for _, tt := range tests {
+		if got := documents[0].Locate(tt.path); got != tt.want {
+			t.Errorf("Locate(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		// Problems expected, as substrings; none when empty
+		want []string
+	}{
+		{"valid", deployment + "---\n" + service, nil},
+		{"syntax error", "kind: [Deployment", []string{"error parsing manifest"}},
+		{"empty", "# nothing\n", []string{"contains no resources"}},
+		{"missing kind", "apiVersion: v1\nmetadata:\n  name: web\n", []string{"resource at line 1: kind is missing"}},
+		{"missing name", "apiVersion: v1\nkind: ConfigMap\n", []string{"ConfigMap (line 1): metadata.name is missing"}},
+		{"generated name", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  generateName: web-\n", nil},
+		{"missing apiVersion", "kind: ConfigMap\nmetadata:\n  name: web\n", []string{"ConfigMap web: apiVersion is missing"}},
+		{"unknown field", strings.Replace(deployment, "replicas: 2", "replicas: 2\n  replica: 3", 1), []string{`unknown field "spec.replica"`}},
+		{"wrong type", strings.Replace(deployment, "replicas: 2", "replicas: two", 1), []string{"Deployment web:"}},
+		// Without schemas, custom resources are only checked for syntax and identity
+		{"custom resource", "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 3\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := Validate([]byte(tt.manifest))
+			if len(problems) != len(tt.want) {
+				t.Fatalf("problems = %q, want %d matching %q", problems, len(tt.want), tt.want)
+			}
+			for i := range tt.want {
+				if !strings.Contains(problems[i], tt.want[i]) {
+					t.Errorf("problems[%d] = %q, want it to contain %q", i, problems[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	// With schemas, custom resources of kinds not installed are reported
+	problems := ValidateWithSchemas([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"), Schemas{})
+	if len(problems) != 1 || !strings.Contains(problems[0], "no CustomResourceDefinition for it is installed") {
+		t.Errorf("expected the missing CRD to be reported, got %q", problems)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if got := Diff("a\nb\n", "a\nb\n", "old", "new"); got != "" {
+		t.Errorf("equal texts have a diff: %q", got)
+	}
+
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := "--- old\n+++ new\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n"
+	if got := Diff(from, to, "old", "new"); got != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", got, want)
+	}
+
+	if got, want := Diff("", "a\n", "old", "new"), "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n"; got != want {
+		t.Errorf("Diff of an added file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  mode: fast\n"
+	before := deployment + "---\n" + configMap
+	after := strings.Replace(deployment, "shop/web:1.2.0", "shop/web:1.3.0", 1) + "---\n" + service
+
+	diffs, err := DiffDocuments([]byte(before), []byte(after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, diff := range diffs {
+		got = append(got, diff.Change+" "+diff.Resource)
+	}
+	if want := "changed Deployment/web, added Service/web, removed ConfigMap/web"; strings.Join(got, ", ") != want {
+		t.Fatalf("DiffDocuments = %q, want %s", got, want)
+	}
+	if !strings.Contains(diffs[0].Diff, "-          image: shop/web:1.2.0\n+          image: shop/web:1.3.0") {
+		t.Errorf("unexpected diff of the changed resource:\n%s", diffs[0].Diff)
+	}
+}
+
+func TestCreatePatches(t *testing.T) {
+	modified := strings.Replace(deployment, "replicas: 2", "replicas: 3", 1) + "---\n" + service
+	tests := []struct {
+		format, patchType string
+		want              []string
+	}{
+		{PatchStrategic, PatchStrategic, []string{"kind: Deployment", "name: web", "namespace: shop", "replicas: 3"}},
+		{PatchJSON, PatchJSON, []string{"op: replace", "path: /spec/replicas", "value: 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			patches, added, err := CreatePatches([]byte(deployment), []byte(modified), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(added) != 1 || added[0] != "Service/web" {
+				t.Errorf("added = %q, want Service/web", added)
+			}
+			if len(patches) != 1 || patches[0].Type != tt.patchType || patches[0].Namespace != "shop" {
+				t.Fatalf("unexpected patches: %+v", patches)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(patches[0].Patch), want) {
+					t.Errorf("the patch does not contain %q:\n%s", want, patches[0].Patch)
+				}
+			}
+			if strings.Contains(string(patches[0].Patch), "image") {
+				t.Errorf("the patch holds unchanged fields:\n%s", patches[0].Patch)
+			}
+		})
+	}
+
+	// Custom resources get a JSON merge patch, with removed fields set to null
+	widget := "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 3\n  color: red\n"
+	patches, _, err := CreatePatches([]byte(widget), []byte(strings.Replace(widget, "  color: red\n", "", 1)), PatchStrategic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || patches[0].Type != "merge" || !strings.Contains(string(patches[0].Patch), "color: null") {
+		t.Errorf("expected a merge patch removing the color, got %+v", patches)
+	}
+
+	if _, _, err := CreatePatches([]byte(deployment), []byte(deployment), "xml"); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
+
+func TestJSONPatchEscapesKeys(t *testing.T) {
+	from := map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{}}}
+	to := map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/a~b": "x"}}}
+	operations := jsonPatch("", from, to)
+	if len(operations) != 1 || operations[0].Op != "add" || operations[0].Path != "/metadata/annotations/example.com~1a~0b" {
+		t.Errorf("unexpected operations: %+v", operations)
+	}
+}
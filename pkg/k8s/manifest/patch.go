@@ -0,0 +1,252 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// Patch formats for CreatePatches
+const (
+	PatchStrategic = "strategic"
+	PatchJSON      = "json"
+)
+
+// PatchFormats lists the supported patch formats
+var PatchFormats = []string{PatchStrategic, PatchJSON}
+
+// ResourcePatch is the patch that turns a resource of one manifest into its version in another
+type ResourcePatch struct {
+	// Kind, name and namespace of the resource ("" if the manifest sets none)
+	Kind      string
+	Name      string
+	Namespace string
+	// Type of the patch for kubectl patch --type: strategic, merge or json
+	Type string
+	// Patch in YAML: a merge patch that names its resource, or a list of JSON patch operations
+	Patch []byte
+}
+
+// patchOperation is a JSON patch (RFC 6902) operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// CreatePatches compares two manifests resource by resource, matched by kind and name, and
+// returns a patch of only the changed fields of each resource that changed. Strategic merge
+// patches are only possible for built-in kinds; custom resources get a JSON merge patch instead,
+// as kubectl patch does. Resources of modified that are not in original cannot be patched and
+// are returned as kind/name.
+func CreatePatches(original, modified []byte, format string) ([]ResourcePatch, []string, error) {
+	if format != PatchStrategic && format != PatchJSON {
+		return nil, nil, fmt.Errorf("unsupported patch format %q, use %s", format, strings.Join(PatchFormats, " or "))
+	}
+	from, err := Parse(original)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := Parse(modified)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var patches []ResourcePatch
+	var added []string
+	for i := range to {
+		resource := to[i].Kind + "/" + to[i].Name
+		var before *Document
+		for j := range from {
+			if from[j].Kind == to[i].Kind && from[j].Name == to[i].Name {
+				before = &from[j]
+				break
+			}
+		}
+		if before == nil {
+			added = append(added, resource)
+			continue
+		}
+
+		beforeJSON, err := before.json()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", resource, err)
+		}
+		afterJSON, err := to[i].json()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", resource, err)
+		}
+		patch := ResourcePatch{Kind: to[i].Kind, Name: to[i].Name}
+		var after map[string]interface{}
+		if err := json.Unmarshal(afterJSON, &after); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", resource, err)
+		}
+		metadata, _ := after["metadata"].(map[string]interface{})
+		patch.Namespace, _ = metadata["namespace"].(string)
+
+		var body interface{}
+		if format == PatchJSON {
+			var beforeValue interface{}
+			if err := json.Unmarshal(beforeJSON, &beforeValue); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", resource, err)
+			}
+			operations := jsonPatch("", beforeValue, after)
+			if len(operations) == 0 {
+				continue
+			}
+			patch.Type, body = PatchJSON, operations
+		} else {
+			changes, patchType, err := mergePatch(beforeJSON, afterJSON, after)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", resource, err)
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			// Name the resource in the patch, so it also serves as a kustomize patch
+			changes["apiVersion"], changes["kind"] = after["apiVersion"], after["kind"]
+			identity := map[string]interface{}{"name": to[i].Name}
+			if patch.Namespace != "" {
+				identity["namespace"] = patch.Namespace
+			}
+			if changed, ok := changes["metadata"].(map[string]interface{}); ok {
+				for key, value := range identity {
+					changed[key] = value
+				}
+			} else {
+				changes["metadata"] = identity
+			}
+			patch.Type, body = patchType, changes
+		}
+
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", resource, err)
+		}
+		if patch.Patch, err = yaml.JSONToYAML(data); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", resource, err)
+		}
+		patches = append(patches, patch)
+	}
+	return patches, added, nil
+}
+
+// mergePatch returns the strategic merge patch between two versions of a resource when its kind
+// is built in, else their JSON merge patch, with the type of the patch returned
+func mergePatch(before, after []byte, afterObject map[string]interface{}) (map[string]interface{}, string, error) {
+	apiVersion, _ := afterObject["apiVersion"].(string)
+	kind, _ := afterObject["kind"].(string)
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err == nil {
+		if typed, err := scheme.Scheme.New(gv.WithKind(kind)); err == nil {
+			data, err := strategicpatch.CreateTwoWayMergePatch(before, after, typed)
+			if err != nil {
+				return nil, "", err
+			}
+			var patch map[string]interface{}
+			if err := json.Unmarshal(data, &patch); err != nil {
+				return nil, "", err
+			}
+			return patch, PatchStrategic, nil
+		}
+	}
+
+	var beforeObject map[string]interface{}
+	if err := json.Unmarshal(before, &beforeObject); err != nil {
+		return nil, "", err
+	}
+	return jsonMergePatch(beforeObject, afterObject), "merge", nil
+}
+
+// jsonMergePatch returns the JSON merge patch (RFC 7386) that turns from into to: changed fields,
+// with nested objects merged, lists replaced and removed fields set to null
+func jsonMergePatch(from, to map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for key, value := range to {
+		old, existed := from[key]
+		oldObject, wasObject := old.(map[string]interface{})
+		newObject, isObject := value.(map[string]interface{})
+		if wasObject && isObject {
+			if nested := jsonMergePatch(oldObject, newObject); len(nested) > 0 {
+				patch[key] = nested
+			}
+			continue
+		}
+		if !existed || !reflect.DeepEqual(old, value) {
+			patch[key] = value
+		}
+	}
+	for key := range from {
+		if _, kept := to[key]; !kept {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// jsonPatch returns the JSON patch operations that turn from into to at path. Objects are
+// compared field by field and lists of the same length item by item; other changes replace the
+// whole value.
+func jsonPatch(path string, from, to interface{}) []patchOperation {
+	if reflect.DeepEqual(from, to) {
+		return nil
+	}
+
+	switch to := to.(type) {
+	case map[string]interface{}:
+		from, ok := from.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var operations []patchOperation
+		for _, key := range sortedKeys(from) {
+			if _, kept := to[key]; !kept {
+				operations = append(operations, patchOperation{Op: "remove", Path: path + "/" + escapePointer(key)})
+			}
+		}
+		for _, key := range sortedKeys(to) {
+			old, existed := from[key]
+			if !existed {
+				operations = append(operations, patchOperation{Op: "add", Path: path + "/" + escapePointer(key), Value: to[key]})
+				continue
+			}
+			operations = append(operations, jsonPatch(path+"/"+escapePointer(key), old, to[key])...)
+		}
+		return operations
+
+	case []interface{}:
+		from, ok := from.([]interface{})
+		if !ok || len(from) != len(to) {
+			break
+		}
+		var operations []patchOperation
+		for i := range to {
+			operations = append(operations, jsonPatch(path+"/"+strconv.Itoa(i), from[i], to[i])...)
+		}
+		return operations
+	}
+	return []patchOperation{{Op: "replace", Path: path, Value: to}}
+}
+
+// sortedKeys returns the keys of an object in order, for stable patches
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer escapes a key for a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,295 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Policy engines for generated admission policies
+const (
+	EngineGatekeeper = "gatekeeper"
+	EngineKyverno    = "kyverno"
+)
+
+// Engines lists the supported policy engines
+var Engines = []string{EngineKyverno, EngineGatekeeper}
+
+// gatekeeperTarget is the admission target of Gatekeeper constraint templates
+const gatekeeperTarget = "admission.k8s.gatekeeper.sh"
+
+// regoPackage matches the package declaration of a Rego module
+var regoPackage = regexp.MustCompile(`^package\s+[A-Za-z_][A-Za-z0-9_.]*\s*$`)
+
+// regoViolation matches the head of a Gatekeeper violation rule, in the old or the contains syntax
+var regoViolation = regexp.MustCompile(`(?m)^\s*violation(\s*\[|\s+contains\s)`)
+
+// kyvernoRuleTypes are the mutually exclusive actions of a Kyverno rule
+var kyvernoRuleTypes = []string{"validate", "mutate", "generate", "verifyImages"}
+
+// kyvernoValidations are the ways a Kyverno validate rule can check resources
+var kyvernoValidations = []string{"pattern", "anyPattern", "deny", "foreach", "podSecurity", "cel", "manifests"}
+
+// CheckPolicy checks the syntax and structure of Gatekeeper ConstraintTemplates and Constraints or
+// Kyverno policies: the kinds of the engine, the fields each needs, and for Gatekeeper that the
+// Rego parses at the level of its package, violation rule and brackets and that each Constraint
+// has a template. It returns a description of each problem.
+func CheckPolicy(documents []Document, engine string) []string {
+	if len(documents) == 0 {
+		return []string{"the manifest contains no policies"}
+	}
+
+	switch engine {
+	case EngineGatekeeper:
+		return checkGatekeeper(documents)
+	case EngineKyverno:
+		return checkKyverno(documents)
+	default:
+		return []string{fmt.Sprintf("unsupported policy engine %q", engine)}
+	}
+}
+
+// checkGatekeeper checks ConstraintTemplates and the Constraints that use them
+func checkGatekeeper(documents []Document) []string {
+	var problems []string
+	templates := make(map[string]bool)
+	var constraints []*unstructured.Unstructured
+
+	for _, document := range documents {
+		obj, resource, err := policyObject(document)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		gv, _ := schema.ParseGroupVersion(obj.GetAPIVersion())
+
+		switch {
+		case document.Kind == "ConstraintTemplate":
+			if gv.Group != "templates.gatekeeper.sh" {
+				problems = append(problems, fmt.Sprintf("%s: apiVersion must be templates.gatekeeper.sh/v1", resource))
+			}
+			kind, _, _ := unstructured.NestedString(obj.Object, "spec", "crd", "spec", "names", "kind")
+			if kind == "" {
+				problems = append(problems, fmt.Sprintf("%s: spec.crd.spec.names.kind is missing", resource))
+			} else {
+				templates[kind] = true
+				if obj.GetName() != strings.ToLower(kind) {
+					problems = append(problems, fmt.Sprintf("%s: metadata.name must be %q, the lowercase of spec.crd.spec.names.kind", resource, strings.ToLower(kind)))
+				}
+			}
+
+			targets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "targets")
+			if len(targets) == 0 {
+				problems = append(problems, fmt.Sprintf("%s: spec.targets is missing", resource))
+			}
+			for i, value := range targets {
+				target, _ := value.(map[string]interface{})
+				path := fmt.Sprintf("spec.targets[%d]", i)
+				if name, _, _ := unstructured.NestedString(target, "target"); name != gatekeeperTarget {
+					problems = append(problems, fmt.Sprintf("%s: %s.target must be %s", resource, path, gatekeeperTarget))
+				}
+				rego, _, _ := unstructured.NestedString(target, "rego")
+				code, _, _ := unstructured.NestedSlice(target, "code")
+				if rego == "" && len(code) == 0 {
+					problems = append(problems, fmt.Sprintf("%s: %s has no rego", resource, path))
+				}
+				for _, problem := range CheckRego(rego) {
+					problems = append(problems, fmt.Sprintf("%s: %s.rego: %s", resource, path, problem))
+				}
+			}
+
+		case gv.Group == "constraints.gatekeeper.sh":
+			constraints = append(constraints, obj)
+			action, _, _ := unstructured.NestedString(obj.Object, "spec", "enforcementAction")
+			switch action {
+			case "", "deny", "dryrun", "warn", "scoped":
+			default:
+				problems = append(problems, fmt.Sprintf("%s: spec.enforcementAction must be deny, dryrun, warn or scoped", resource))
+			}
+
+		default:
+			problems = append(problems, fmt.Sprintf("%s: not a Gatekeeper ConstraintTemplate or constraint (constraints.gatekeeper.sh)", resource))
+		}
+	}
+
+	if len(templates) == 0 && len(problems) == 0 {
+		problems = append(problems, "the manifest has no ConstraintTemplate")
+	}
+	for _, constraint := range constraints {
+		if !templates[constraint.GetKind()] {
+			problems = append(problems, fmt.Sprintf("%s %s: no ConstraintTemplate in the manifest defines the kind %s", constraint.GetKind(), constraint.GetName(), constraint.GetKind()))
+		}
+	}
+	if len(constraints) == 0 && len(templates) > 0 {
+		problems = append(problems, "the manifest has no constraint that uses the ConstraintTemplate")
+	}
+	return problems
+}
+
+// checkKyverno checks Kyverno ClusterPolicies and Policies
+func checkKyverno(documents []Document) []string {
+	var problems []string
+	for _, document := range documents {
+		obj, resource, err := policyObject(document)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if document.Kind != "ClusterPolicy" && document.Kind != "Policy" {
+			problems = append(problems, fmt.Sprintf("%s: not a Kyverno ClusterPolicy or Policy", resource))
+			continue
+		}
+		if gv, _ := schema.ParseGroupVersion(obj.GetAPIVersion()); gv.Group != "kyverno.io" {
+			problems = append(problems, fmt.Sprintf("%s: apiVersion must be kyverno.io/v1", resource))
+		}
+
+		action, _, _ := unstructured.NestedString(obj.Object, "spec", "validationFailureAction")
+		switch strings.ToLower(action) {
+		case "", "enforce", "audit":
+		default:
+			problems = append(problems, fmt.Sprintf("%s: spec.validationFailureAction must be Enforce or Audit", resource))
+		}
+
+		rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+		if len(rules) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: spec.rules is missing", resource))
+		}
+		names := make(map[string]bool)
+		for i, value := range rules {
+			rule, _ := value.(map[string]interface{})
+			path := fmt.Sprintf("spec.rules[%d]", i)
+			name, _, _ := unstructured.NestedString(rule, "name")
+			switch {
+			case name == "":
+				problems = append(problems, fmt.Sprintf("%s: %s.name is missing", resource, path))
+			case names[name]:
+				problems = append(problems, fmt.Sprintf("%s: %s: duplicate rule name %q", resource, path, name))
+			}
+			names[name] = true
+
+			if match, _, _ := unstructured.NestedMap(rule, "match"); len(match) == 0 {
+				problems = append(problems, fmt.Sprintf("%s: %s.match is missing", resource, path))
+			}
+
+			var types []string
+			for _, ruleType := range kyvernoRuleTypes {
+				if _, ok := rule[ruleType]; ok {
+					types = append(types, ruleType)
+				}
+			}
+			if len(types) != 1 {
+				problems = append(problems, fmt.Sprintf("%s: %s must have exactly one of %s", resource, path, strings.Join(kyvernoRuleTypes, ", ")))
+				continue
+			}
+			if types[0] != "validate" {
+				continue
+			}
+			validate, _, _ := unstructured.NestedMap(rule, "validate")
+			found := false
+			for _, validation := range kyvernoValidations {
+				if _, ok := validate[validation]; ok {
+					found = true
+				}
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("%s: %s.validate must have one of %s", resource, path, strings.Join(kyvernoValidations, ", ")))
+			}
+		}
+	}
+	return problems
+}
+
+// policyObject decodes a policy document, returning it with a name for problem descriptions
+func policyObject(document Document) (*unstructured.Unstructured, string, error) {
+	name := document.Name
+	if name == "" {
+		name = fmt.Sprintf("(line %d)", document.Line)
+	}
+	resource := document.Kind + " " + name
+	if document.Kind == "" {
+		return nil, resource, fmt.Errorf("resource at line %d: kind is missing", document.Line)
+	}
+	if document.Name == "" {
+		return nil, resource, fmt.Errorf("%s: metadata.name is missing", resource)
+	}
+
+	obj, err := document.Object()
+	if err != nil {
+		return nil, resource, fmt.Errorf("%s: %v", resource, err)
+	}
+	return &unstructured.Unstructured{Object: obj}, resource, nil
+}
+
+// CheckRego checks the syntax of a Gatekeeper Rego module as far as possible without a Rego
+// parser: a package declaration first, a violation rule, and balanced brackets outside strings
+// and comments
+func CheckRego(rego string) []string {
+	if strings.TrimSpace(rego) == "" {
+		return nil
+	}
+
+	var problems []string
+	var statements []string
+	for _, line := range strings.Split(rego, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			statements = append(statements, line)
+		}
+	}
+	if len(statements) == 0 || !regoPackage.MatchString(statements[0]) {
+		problems = append(problems, "the module must start with a package declaration")
+	}
+	if !regoViolation.MatchString(rego) {
+		problems = append(problems, "there is no violation rule, such as violation[{\"msg\": msg}] { ... }")
+	}
+
+	// Track brackets, skipping strings, raw strings and comments
+	closing := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []rune
+	line := 1
+	var quote rune
+	escaped := false
+	comment := false
+	for _, r := range rego {
+		if r == '\n' {
+			line++
+			comment = false
+			if quote == '"' {
+				problems = append(problems, fmt.Sprintf("line %d: unterminated string", line-1))
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case comment:
+		case quote != 0:
+			if escaped {
+				escaped = false
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '#':
+			comment = true
+		case r == '"' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			stack = append(stack, r)
+		case closing[r] != 0:
+			if len(stack) == 0 || stack[len(stack)-1] != closing[r] {
+				return append(problems, fmt.Sprintf("line %d: unexpected %c", line, r))
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		problems = append(problems, "unterminated string")
+	}
+	if len(stack) > 0 {
+		problems = append(problems, fmt.Sprintf("unclosed %c", stack[len(stack)-1]))
+	}
+	return problems
+}
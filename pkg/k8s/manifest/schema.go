@@ -0,0 +1,230 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+)
+
+// Schemas are the OpenAPI v3 schemas of custom resources, such as those of the CRDs installed in a cluster
+type Schemas map[schema.GroupVersionKind]*spec.Schema
+
+// objectFields are the fields every resource has, which CRD schemas usually leave out
+var objectFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+
+// validateSchema checks a custom resource against its schema, reporting unknown fields like a
+// strict server-side apply would
+func validateSchema(resource string, raw []byte, s *spec.Schema) []string {
+	var obj interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return []string{fmt.Sprintf("%s: %v", resource, err)}
+	}
+
+	var problems []string
+	result := validate.NewSchemaValidator(s, nil, "", strfmt.Default).Validate(obj)
+	for _, err := range result.Errors {
+		problems = append(problems, fmt.Sprintf("%s: %v", resource, err))
+	}
+	for _, field := range unknownFields(obj, s, "", true) {
+		problems = append(problems, fmt.Sprintf("%s: unknown field %q", resource, field))
+	}
+	return problems
+}
+
+// unknownFields returns the paths of fields in value that the schema does not declare
+func unknownFields(value interface{}, s *spec.Schema, path string, root bool) []string {
+	if s == nil {
+		return nil
+	}
+	if preserve, _ := s.Extensions.GetBool("x-kubernetes-preserve-unknown-fields"); preserve {
+		return nil
+	}
+	// Embedded resources have their own apiVersion, kind and metadata
+	if embedded, _ := s.Extensions.GetBool("x-kubernetes-embedded-resource"); embedded {
+		root = true
+	}
+
+	var unknown []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldPath := joinPath(path, key)
+			if property, ok := s.Properties[key]; ok {
+				if !(root && key == "metadata") {
+					unknown = append(unknown, unknownFields(v[key], &property, fieldPath, false)...)
+				}
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				if s.AdditionalProperties.Schema != nil {
+					unknown = append(unknown, unknownFields(v[key], s.AdditionalProperties.Schema, fieldPath, false)...)
+				}
+				continue
+			}
+			// Schemas without properties, such as int-or-string fields, do not restrict fields
+			if len(s.Properties) > 0 && !(root && objectFields[key]) {
+				unknown = append(unknown, fieldPath)
+			}
+		}
+
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+		for i, item := range v {
+			unknown = append(unknown, unknownFields(item, s.Items.Schema, fmt.Sprintf("%s[%d]", path, i), false)...)
+		}
+	}
+	return unknown
+}
+
+// joinPath appends a field to a dotted path
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// SchemaOutline describes the fields of a resource schema as an indented outline with types,
+// required fields, allowed values and the first sentence of each description. The fields every
+// resource has and the status are left out. It stops after maxLines lines, noting how many fields
+// were left out.
+func SchemaOutline(s *spec.Schema, maxLines int) string {
+	return outline(s, maxLines, true)
+}
+
+// FieldOutline is SchemaOutline for the schema of a field, describing all of its fields
+func FieldOutline(s *spec.Schema, maxLines int) string {
+	return outline(s, maxLines, false)
+}
+
+// outline implements SchemaOutline and FieldOutline
+func outline(s *spec.Schema, maxLines int, resource bool) string {
+	var lines []string
+	omitted := 0
+
+	var walk func(s *spec.Schema, depth int)
+	walk = func(s *spec.Schema, depth int) {
+		// Describe the fields of the items of arrays and maps
+		s = elementSchema(s)
+		if s == nil {
+			return
+		}
+
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			// Leave out the common fields and the status, which manifests do not set
+			if resource && depth == 0 && (objectFields[name] || name == "status") {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		required := make(map[string]bool, len(s.Required))
+		for _, name := range s.Required {
+			required[name] = true
+		}
+
+		for _, name := range names {
+			property := s.Properties[name]
+			if len(lines) >= maxLines {
+				omitted++
+				continue
+			}
+			lines = append(lines, strings.Repeat("  ", depth)+fieldSummary(name, &property, required[name]))
+			walk(&property, depth+1)
+		}
+	}
+	walk(s, 0)
+
+	if omitted > 0 {
+		lines = append(lines, fmt.Sprintf("... (%d more fields)", omitted))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// elementSchema returns the schema of the items of an array or map schema, or the schema itself
+func elementSchema(s *spec.Schema) *spec.Schema {
+	for s != nil {
+		switch {
+		case s.Items != nil && s.Items.Schema != nil:
+			s = s.Items.Schema
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			s = s.AdditionalProperties.Schema
+		default:
+			return s
+		}
+	}
+	return nil
+}
+
+// fieldSummary describes a single field for SchemaOutline
+func fieldSummary(name string, s *spec.Schema, required bool) string {
+	details := []string{SchemaType(s)}
+	if required {
+		details = append(details, "required")
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, value := range s.Enum {
+			values[i] = fmt.Sprint(value)
+		}
+		details = append(details, "one of: "+strings.Join(values, ", "))
+	}
+
+	summary := fmt.Sprintf("%s (%s)", name, strings.Join(details, ", "))
+	if description := firstSentence(s.Description); description != "" {
+		summary += ": " + description
+	}
+	return summary
+}
+
+// SchemaType names the type of a schema, such as []string or map[string]integer
+func SchemaType(s *spec.Schema) string {
+	if s == nil {
+		return "any"
+	}
+	if intOrString, _ := s.Extensions.GetBool("x-kubernetes-int-or-string"); intOrString || s.Format == "int-or-string" {
+		return "int-or-string"
+	}
+	if len(s.Type) == 0 {
+		return "any"
+	}
+	switch s.Type[0] {
+	case "array":
+		if s.Items != nil {
+			return "[]" + SchemaType(s.Items.Schema)
+		}
+	case "object":
+		if len(s.Properties) == 0 && s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+			return "map[string]" + SchemaType(s.AdditionalProperties.Schema)
+		}
+	}
+	return s.Type[0]
+}
+
+// firstSentence returns the first sentence of a description, shortened to a single line
+func firstSentence(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if end := strings.Index(description, ". "); end >= 0 {
+		description = description[:end+1]
+	}
+	if len(description) > 120 {
+		description = description[:117] + "..."
+	}
+	return description
+}
@@ -0,0 +1,198 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StackKinds are the resources of a generated application stack, in apply order
+var StackKinds = []string{
+	"Deployment",
+	"Service",
+	"Ingress",
+	"HorizontalPodAutoscaler",
+	"PodDisruptionBudget",
+	"NetworkPolicy",
+}
+
+// Stack layouts for WriteStack
+const (
+	LayoutPlain     = "plain"
+	LayoutKustomize = "kustomize"
+	LayoutHelm      = "helm"
+)
+
+// Layouts lists the supported stack layouts
+var Layouts = []string{LayoutPlain, LayoutKustomize, LayoutHelm}
+
+// CheckStack reports the stack kinds missing from documents and resources whose name differs
+// from the stack name
+func CheckStack(documents []Document, name string) []string {
+	var problems []string
+	for _, kind := range StackKinds {
+		found := false
+		for _, document := range documents {
+			if document.Kind == kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("the stack has no %s", kind))
+		}
+	}
+
+	for _, document := range documents {
+		if document.Name != "" && document.Name != name {
+			problems = append(problems, fmt.Sprintf("%s %s: expected the stack name %q", document.Kind, document.Name, name))
+		}
+	}
+	return problems
+}
+
+// SetLabel sets a label in the metadata of the document, creating the labels if needed
+func (d *Document) SetLabel(key, value string) {
+	metadata := mappingValue(d.root, "metadata")
+	if metadata == nil || metadata.Kind != yaml.MappingNode {
+		return
+	}
+
+	labels := mappingValue(metadata, "labels")
+	if labels == nil || labels.Kind != yaml.MappingNode {
+		labels = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		metadata.Content = append(metadata.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "labels"}, labels)
+	}
+
+	if existing := mappingValue(labels, key); existing != nil {
+		existing.Value = value
+		return
+	}
+	labels.Content = append(labels.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// YAML encodes the document, including any changes made to it
+func (d *Document) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(d.root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Join encodes documents as a single multi-document manifest
+func Join(documents []Document) ([]byte, error) {
+	var parts [][]byte
+	for i := range documents {
+		data, err := documents[i].YAML()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, bytes.TrimSpace(data))
+	}
+	return append(bytes.Join(parts, []byte("\n---\n")), '\n'), nil
+}
+
+// WriteStack writes the documents of a stack to dir, one file per resource, and returns the
+// written paths. The kustomize layout adds a kustomization.yaml listing the resources; the helm
+// layout writes a chart scaffold with the resources as templates.
+func WriteStack(dir, name, layout string, documents []Document) ([]string, error) {
+	resourceDir := dir
+	switch layout {
+	case LayoutPlain, LayoutKustomize:
+	case LayoutHelm:
+		resourceDir = filepath.Join(dir, "templates")
+	default:
+		return nil, fmt.Errorf("unknown layout %q (supported: %s)", layout, strings.Join(Layouts, ", "))
+	}
+	if err := os.MkdirAll(resourceDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %w", err)
+	}
+
+	var written []string
+	write := func(path string, data []byte) error {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+		written = append(written, path)
+		return nil
+	}
+
+	var files []string
+	for i, file := range stackFileNames(documents) {
+		data, err := documents[i].YAML()
+		if err != nil {
+			return written, fmt.Errorf("error encoding %s %s: %w", documents[i].Kind, documents[i].Name, err)
+		}
+		if err := write(filepath.Join(resourceDir, file), data); err != nil {
+			return written, err
+		}
+		files = append(files, file)
+	}
+
+	switch layout {
+	case LayoutKustomize:
+		var kustomization strings.Builder
+		kustomization.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n")
+		for _, file := range files {
+			kustomization.WriteString("  - " + file + "\n")
+		}
+		if err := write(filepath.Join(dir, "kustomization.yaml"), []byte(kustomization.String())); err != nil {
+			return written, err
+		}
+
+	case LayoutHelm:
+		chart := fmt.Sprintf("apiVersion: v2\nname: %s\ndescription: A Helm chart for %s\ntype: application\nversion: 0.1.0\nappVersion: \"1.0.0\"\n", name, name)
+		if err := write(filepath.Join(dir, "Chart.yaml"), []byte(chart)); err != nil {
+			return written, err
+		}
+		values := "# Values for the templates. The generated templates are plain manifests; move\n# settings such as the image or replica count here as you parameterize them.\n"
+		if err := write(filepath.Join(dir, "values.yaml"), []byte(values)); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// stackFileNames names the file of each document after its kind, adding the resource name when
+// a kind occurs more than once
+func stackFileNames(documents []Document) []string {
+	counts := make(map[string]int)
+	for _, document := range documents {
+		kind := strings.ToLower(document.Kind)
+		if kind == "" {
+			kind = "resource"
+		}
+		counts[kind]++
+	}
+
+	names := make([]string, len(documents))
+	for i, document := range documents {
+		kind := strings.ToLower(document.Kind)
+		if kind == "" {
+			kind = "resource"
+		}
+		switch {
+		case counts[kind] > 1 && document.Name != "":
+			names[i] = fmt.Sprintf("%s-%s.yaml", kind, document.Name)
+		case counts[kind] > 1:
+			names[i] = fmt.Sprintf("%s-%d.yaml", kind, i+1)
+		default:
+			names[i] = kind + ".yaml"
+		}
+	}
+	return names
+}
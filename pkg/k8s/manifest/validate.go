@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// strictDecoder decodes built-in kinds, rejecting unknown and duplicate fields
+var strictDecoder = serializer.NewCodecFactory(scheme.Scheme, serializer.EnableStrict).UniversalDeserializer()
+
+// Validate checks a manifest without a cluster: YAML syntax, the identifying fields every
+// resource needs, and the schema of built-in kinds. It returns a description of each problem.
+// Custom resources are only checked for syntax and identifying fields.
+func Validate(data []byte) []string {
+	return ValidateWithSchemas(data, nil)
+}
+
+// ValidateWithSchemas is Validate with the schemas of custom resources, such as those of the CRDs
+// installed in a cluster. Custom resources are checked against their schema; when schemas is not
+// nil, resources of kinds that are neither built in nor in schemas are reported.
+func ValidateWithSchemas(data []byte, schemas Schemas) []string {
+	documents, err := Parse(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if len(documents) == 0 {
+		return []string{"the manifest contains no resources"}
+	}
+
+	var problems []string
+	for _, document := range documents {
+		name := document.Name
+		if name == "" {
+			name = fmt.Sprintf("(line %d)", document.Line)
+		}
+		resource := document.Kind + " " + name
+
+		if document.Kind == "" {
+			problems = append(problems, fmt.Sprintf("resource at line %d: kind is missing", document.Line))
+			continue
+		}
+		metadata := mappingValue(document.root, "metadata")
+		if document.Name == "" && mappingValue(metadata, "generateName") == nil {
+			problems = append(problems, fmt.Sprintf("%s: metadata.name is missing", resource))
+		}
+		// Without an apiVersion the schema cannot be looked up
+		if mappingValue(document.root, "apiVersion") == nil {
+			problems = append(problems, fmt.Sprintf("%s: apiVersion is missing", resource))
+			continue
+		}
+
+		raw, err := document.json()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", resource, err))
+			continue
+		}
+		_, gvk, err := strictDecoder.Decode(raw, nil, nil)
+		if err == nil {
+			continue
+		}
+		if !runtime.IsNotRegisteredError(err) {
+			problems = append(problems, fmt.Sprintf("%s: %v", resource, err))
+			continue
+		}
+		if schemas == nil || gvk == nil {
+			continue
+		}
+		customSchema, ok := schemas[*gvk]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s %s is not a built-in kind and no CustomResourceDefinition for it is installed", resource, gvk.GroupVersion(), gvk.Kind))
+			continue
+		}
+		if customSchema != nil {
+			problems = append(problems, validateSchema(resource, raw, customSchema)...)
+		}
+	}
+
+	return problems
+}
+
+// Object decodes the document into a generic object, as unmarshaled from JSON
+func (d *Document) Object() (map[string]interface{}, error) {
+	raw, err := d.json()
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// json re-encodes the document as JSON for decoding into typed objects
+func (d *Document) json() ([]byte, error) {
+	var value interface{}
+	if err := d.root.Decode(&value); err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.YAMLToJSON(data)
+}
@@ -0,0 +1,544 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// IstioProxyContainer is the name of the Envoy sidecar container Istio injects
+const IstioProxyContainer = "istio-proxy"
+
+// istioRootNamespace holds the mesh-wide Istio configuration, such as the default PeerAuthentication
+const istioRootNamespace = "istio-system"
+
+// Istio configuration kinds
+var (
+	istioVirtualService     = schema.GroupKind{Group: "networking.istio.io", Kind: "VirtualService"}
+	istioDestinationRule    = schema.GroupKind{Group: "networking.istio.io", Kind: "DestinationRule"}
+	istioGateway            = schema.GroupKind{Group: "networking.istio.io", Kind: "Gateway"}
+	istioPeerAuthentication = schema.GroupKind{Group: "security.istio.io", Kind: "PeerAuthentication"}
+)
+
+// MeshConfig is the Istio configuration that applies to a workload: its sidecar injection status,
+// the services selecting it, and the VirtualServices, DestinationRules, Gateways and
+// PeerAuthentications that route or secure its traffic, with the problems found in them
+type MeshConfig struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Sidecar injection configured on the namespace, such as enabled, disabled or revision 1-22
+	NamespaceInjection string    `json:"namespaceInjection"`
+	Pods               []MeshPod `json:"pods"`
+	// Services selecting the workload's pods, as fully qualified hosts
+	Services            []string     `json:"services"`
+	VirtualServices     []MeshObject `json:"virtualServices"`
+	DestinationRules    []MeshObject `json:"destinationRules"`
+	Gateways            []MeshObject `json:"gateways"`
+	PeerAuthentications []MeshObject `json:"peerAuthentications"`
+	// Effective mTLS mode of the workload: STRICT, PERMISSIVE, DISABLE or UNSET
+	MTLSMode string `json:"mtlsMode"`
+	// Routing conflicts, missing subsets, mTLS mismatches and injection problems
+	Findings []string `json:"findings"`
+}
+
+// MeshPod is the sidecar injection status of a pod
+type MeshPod struct {
+	Name    string `json:"name"`
+	Sidecar bool   `json:"sidecar"`
+	// Value of the sidecar.istio.io/inject label or annotation, if set
+	Inject string `json:"inject,omitempty"`
+}
+
+// MeshObject is an Istio configuration object
+type MeshObject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Spec as YAML, for prompts
+	Spec string `json:"-"`
+}
+
+// String returns the object as kind namespace/name
+func (o MeshObject) String() string {
+	return o.Kind + " " + o.Namespace + "/" + o.Name
+}
+
+// meshRoute is a destination a VirtualService routes to
+type meshRoute struct {
+	host   string
+	subset string
+}
+
+// GetMeshConfig collects the Istio configuration of a workload and checks it for routing
+// conflicts, missing subsets, mTLS mismatches and injection problems
+func (c *Client) GetMeshConfig(ctx context.Context, resourceType, name, namespace string) (*MeshConfig, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+	pods, _, err := c.GetWorkloadPods(ctx, resourceType, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	mesh := &MeshConfig{
+		Kind:             strings.ToLower(resourceType),
+		Name:             name,
+		Namespace:        namespace,
+		Pods:             []MeshPod{},
+		Services:         []string{},
+		VirtualServices:  []MeshObject{},
+		DestinationRules: []MeshObject{},
+		Gateways:         []MeshObject{},
+		Findings:         []string{},
+	}
+
+	mesh.NamespaceInjection = "not set"
+	if ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err == nil {
+		if revision := ns.Labels["istio.io/rev"]; revision != "" {
+			mesh.NamespaceInjection = "revision " + revision
+		}
+		if injection := ns.Labels["istio-injection"]; injection != "" {
+			mesh.NamespaceInjection = injection
+		}
+	}
+
+	sidecars := 0
+	for _, pod := range pods {
+		meshPod := MeshPod{Name: pod.Name, Sidecar: hasIstioProxy(pod), Inject: pod.Labels["sidecar.istio.io/inject"]}
+		if meshPod.Inject == "" {
+			meshPod.Inject = pod.Annotations["sidecar.istio.io/inject"]
+		}
+		if meshPod.Sidecar {
+			sidecars++
+		}
+		mesh.Pods = append(mesh.Pods, meshPod)
+	}
+
+	// Services whose selector matches the workload's pods
+	services, err := c.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing services: %w", err)
+	}
+	for _, service := range services.Items {
+		if len(service.Spec.Selector) == 0 || len(pods) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(pods[0].Labels)) {
+			mesh.Services = append(mesh.Services, serviceHost(service.Name, namespace))
+		}
+	}
+
+	virtualServices, err := c.listMeshObjects(ctx, istioVirtualService, namespace)
+	if err != nil {
+		return nil, err
+	}
+	destinationRules, err := c.listMeshObjects(ctx, istioDestinationRule, namespace)
+	if err != nil {
+		return nil, err
+	}
+	gateways, err := c.listMeshObjects(ctx, istioGateway, namespace)
+	if err != nil {
+		return nil, err
+	}
+	peerAuthentications, err := c.listMeshObjects(ctx, istioPeerAuthentication, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	isWorkloadHost := func(host string) bool {
+		return slices.Contains(mesh.Services, host)
+	}
+
+	// VirtualServices for the workload's hosts or routing to them
+	var relatedVirtualServices []unstructured.Unstructured
+	for _, vs := range virtualServices {
+		related := false
+		for _, host := range vsHosts(vs) {
+			related = related || isWorkloadHost(host)
+		}
+		for _, route := range vsRoutes(vs) {
+			related = related || isWorkloadHost(route.host)
+		}
+		if related {
+			relatedVirtualServices = append(relatedVirtualServices, vs)
+			mesh.VirtualServices = append(mesh.VirtualServices, meshObject(vs))
+		}
+	}
+
+	// DestinationRules for the workload's hosts or the hosts its VirtualServices route to
+	routedHosts := make(map[string]bool)
+	for _, host := range mesh.Services {
+		routedHosts[host] = true
+	}
+	for _, vs := range relatedVirtualServices {
+		for _, route := range vsRoutes(vs) {
+			routedHosts[route.host] = true
+		}
+	}
+	rulesByHost := make(map[string][]unstructured.Unstructured)
+	for _, dr := range destinationRules {
+		host, _, _ := unstructured.NestedString(dr.Object, "spec", "host")
+		host = qualifyHost(host, dr.GetNamespace())
+		if routedHosts[host] {
+			rulesByHost[host] = append(rulesByHost[host], dr)
+			mesh.DestinationRules = append(mesh.DestinationRules, meshObject(dr))
+		}
+	}
+
+	// Gateways the workload's VirtualServices are bound to
+	gatewayNames := make(map[string]bool)
+	for _, gateway := range gateways {
+		gatewayNames[gateway.GetNamespace()+"/"+gateway.GetName()] = true
+	}
+	boundGateways := make(map[string]bool)
+	for _, vs := range relatedVirtualServices {
+		for _, gateway := range vsGateways(vs) {
+			if gateway == "mesh" {
+				continue
+			}
+			if !gatewayNames[gateway] {
+				mesh.Findings = append(mesh.Findings, fmt.Sprintf("VirtualService %s/%s is bound to gateway %s, which does not exist", vs.GetNamespace(), vs.GetName(), gateway))
+				continue
+			}
+			boundGateways[gateway] = true
+		}
+	}
+	for _, gateway := range gateways {
+		if boundGateways[gateway.GetNamespace()+"/"+gateway.GetName()] {
+			mesh.Gateways = append(mesh.Gateways, meshObject(gateway))
+		}
+	}
+
+	mesh.Findings = append(mesh.Findings, injectionFindings(mesh, sidecars)...)
+	mesh.Findings = append(mesh.Findings, routingFindings(relatedVirtualServices, isWorkloadHost)...)
+	mesh.Findings = append(mesh.Findings, subsetFindings(relatedVirtualServices, rulesByHost, mesh.Services, pods)...)
+
+	mesh.MTLSMode, mesh.PeerAuthentications = effectiveMTLS(peerAuthentications, namespace, pods)
+	mesh.Findings = append(mesh.Findings, mtlsFindings(mesh.MTLSMode, sidecars, len(pods), mesh.Services, rulesByHost)...)
+	return mesh, nil
+}
+
+// listMeshObjects lists Istio objects in all namespaces, falling back to the workload's namespace
+// and the root namespace when listing cluster-wide is not allowed
+func (c *Client) listMeshObjects(ctx context.Context, groupKind schema.GroupKind, namespace string) ([]unstructured.Unstructured, error) {
+	objects, err := c.ListObjects(ctx, groupKind, "")
+	if err == nil {
+		return objects, nil
+	}
+	objects, err = c.ListObjects(ctx, groupKind, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if root, err := c.ListObjects(ctx, groupKind, istioRootNamespace); err == nil && namespace != istioRootNamespace {
+		objects = append(objects, root...)
+	}
+	return objects, nil
+}
+
+// meshObject describes an Istio object for reports and prompts
+func meshObject(obj unstructured.Unstructured) MeshObject {
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	return MeshObject{Kind: obj.GetKind(), Name: obj.GetName(), Namespace: obj.GetNamespace(), Spec: compactYAML(spec)}
+}
+
+// hasIstioProxy reports whether a pod runs the Istio sidecar, as a container or as a native
+// sidecar init container
+func hasIstioProxy(pod corev1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == IstioProxyContainer {
+			return true
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == IstioProxyContainer {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceHost returns the fully qualified host of a service
+func serviceHost(name, namespace string) string {
+	return name + "." + namespace + ".svc.cluster.local"
+}
+
+// qualifyHost resolves a host as Istio does: short names are relative to the namespace of the
+// object that names them
+func qualifyHost(host, namespace string) string {
+	if strings.Contains(host, "*") {
+		return host
+	}
+	parts := strings.Split(strings.TrimSuffix(host, "."), ".")
+	switch {
+	case len(parts) == 1:
+		return serviceHost(parts[0], namespace)
+	case len(parts) == 2:
+		return serviceHost(parts[0], parts[1])
+	case len(parts) == 3 && parts[2] == "svc":
+		return serviceHost(parts[0], parts[1])
+	}
+	return host
+}
+
+// vsHosts returns the hosts a VirtualService applies to, qualified
+func vsHosts(vs unstructured.Unstructured) []string {
+	hosts, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "hosts")
+	for i, host := range hosts {
+		hosts[i] = qualifyHost(host, vs.GetNamespace())
+	}
+	return hosts
+}
+
+// vsGateways returns the gateways a VirtualService is bound to as namespace/name, or mesh
+func vsGateways(vs unstructured.Unstructured) []string {
+	gateways, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "gateways")
+	if len(gateways) == 0 {
+		return []string{"mesh"}
+	}
+	for i, gateway := range gateways {
+		if gateway != "mesh" && !strings.Contains(gateway, "/") {
+			gateways[i] = vs.GetNamespace() + "/" + gateway
+		}
+	}
+	return gateways
+}
+
+// vsRoutes returns the destinations of a VirtualService's HTTP, TLS and TCP routes
+func vsRoutes(vs unstructured.Unstructured) []meshRoute {
+	var routes []meshRoute
+	for _, protocol := range []string{"http", "tls", "tcp"} {
+		rules, _, _ := unstructured.NestedSlice(vs.Object, "spec", protocol)
+		for _, rule := range rules {
+			rule, ok := rule.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			destinations, _, _ := unstructured.NestedSlice(rule, "route")
+			for _, destination := range destinations {
+				destination, ok := destination.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(destination, "destination", "host")
+				subset, _, _ := unstructured.NestedString(destination, "destination", "subset")
+				routes = append(routes, meshRoute{host: qualifyHost(host, vs.GetNamespace()), subset: subset})
+			}
+		}
+	}
+	return routes
+}
+
+// injectionFindings reports pods missing the sidecar that injection should have added, and
+// workloads only partly in the mesh
+func injectionFindings(mesh *MeshConfig, sidecars int) []string {
+	var findings []string
+	namespaceEnabled := mesh.NamespaceInjection == "enabled" || strings.HasPrefix(mesh.NamespaceInjection, "revision ")
+	for _, pod := range mesh.Pods {
+		if pod.Sidecar {
+			continue
+		}
+		if pod.Inject == "true" || (namespaceEnabled && pod.Inject != "false") {
+			findings = append(findings, fmt.Sprintf("pod %s has no %s sidecar although injection is enabled: it was probably created before injection was turned on, or the injection webhook failed; restart it", pod.Name, IstioProxyContainer))
+		}
+	}
+	if sidecars > 0 && sidecars < len(mesh.Pods) {
+		findings = append(findings, fmt.Sprintf("only %d of %d pods have the sidecar, so traffic policies and mTLS apply to some replicas only", sidecars, len(mesh.Pods)))
+	}
+	return findings
+}
+
+// routingFindings reports hosts defined by several VirtualServices for the same gateway, and
+// routes that can never match because a catch-all route comes before them
+func routingFindings(virtualServices []unstructured.Unstructured, isWorkloadHost func(string) bool) []string {
+	var findings []string
+	owners := make(map[string][]string)
+	for _, vs := range virtualServices {
+		for _, host := range vsHosts(vs) {
+			if !isWorkloadHost(host) {
+				continue
+			}
+			for _, gateway := range vsGateways(vs) {
+				key := host + " on " + gateway
+				owners[key] = append(owners[key], vs.GetNamespace()+"/"+vs.GetName())
+			}
+		}
+
+		rules, _, _ := unstructured.NestedSlice(vs.Object, "spec", "http")
+		for i, rule := range rules {
+			rule, ok := rule.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, hasMatch := rule["match"]; !hasMatch && i < len(rules)-1 {
+				findings = append(findings, fmt.Sprintf("VirtualService %s/%s: HTTP route %d has no match conditions, so the %d routes after it are never used", vs.GetNamespace(), vs.GetName(), i+1, len(rules)-1-i))
+				break
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(owners))
+	for key := range owners {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if len(owners[key]) > 1 {
+			findings = append(findings, fmt.Sprintf("routing conflict: VirtualServices %s all define host %s; Istio applies only one of them for sidecars and merges them unpredictably for gateways", strings.Join(owners[key], ", "), key))
+		}
+	}
+	return findings
+}
+
+// subsetFindings reports subsets routed to that no DestinationRule defines, several rules for
+// the same host, and subsets of the workload's hosts that select none of its pods
+func subsetFindings(virtualServices []unstructured.Unstructured, rulesByHost map[string][]unstructured.Unstructured, workloadHosts []string, pods []corev1.Pod) []string {
+	var findings []string
+	for _, vs := range virtualServices {
+		for _, route := range vsRoutes(vs) {
+			if route.subset == "" {
+				continue
+			}
+			rules := rulesByHost[route.host]
+			if len(rules) == 0 {
+				findings = append(findings, fmt.Sprintf("missing subset: VirtualService %s/%s routes to subset %s of %s, but no DestinationRule exists for that host, so requests fail with 503 (NR)", vs.GetNamespace(), vs.GetName(), route.subset, route.host))
+				continue
+			}
+			if _, found := findSubset(rules, route.subset); !found {
+				findings = append(findings, fmt.Sprintf("missing subset: VirtualService %s/%s routes to subset %s of %s, which no DestinationRule for that host defines, so requests fail with 503 (NR)", vs.GetNamespace(), vs.GetName(), route.subset, route.host))
+			}
+		}
+	}
+
+	hosts := make([]string, 0, len(rulesByHost))
+	for host := range rulesByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		rules := rulesByHost[host]
+		if len(rules) > 1 {
+			names := make([]string, 0, len(rules))
+			for _, rule := range rules {
+				names = append(names, rule.GetNamespace()+"/"+rule.GetName())
+			}
+			findings = append(findings, fmt.Sprintf("DestinationRules %s all apply to host %s; only one takes effect for each client", strings.Join(names, ", "), host))
+		}
+		if !slices.Contains(workloadHosts, host) || len(pods) == 0 {
+			continue
+		}
+		for _, rule := range rules {
+			subsets, _, _ := unstructured.NestedSlice(rule.Object, "spec", "subsets")
+			for _, subset := range subsets {
+				subset, ok := subset.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _, _ := unstructured.NestedString(subset, "name")
+				subsetLabels, _, _ := unstructured.NestedStringMap(subset, "labels")
+				if !anyPodMatches(pods, subsetLabels) {
+					findings = append(findings, fmt.Sprintf("DestinationRule %s/%s: subset %s selects %s, which matches none of the workload's pods", rule.GetNamespace(), rule.GetName(), name, labels.Set(subsetLabels)))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// findSubset returns the labels of a named subset in DestinationRules
+func findSubset(rules []unstructured.Unstructured, name string) (map[string]string, bool) {
+	for _, rule := range rules {
+		subsets, _, _ := unstructured.NestedSlice(rule.Object, "spec", "subsets")
+		for _, subset := range subsets {
+			subset, ok := subset.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if subsetName, _, _ := unstructured.NestedString(subset, "name"); subsetName == name {
+				subsetLabels, _, _ := unstructured.NestedStringMap(subset, "labels")
+				return subsetLabels, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// anyPodMatches reports whether any pod has all the given labels
+func anyPodMatches(pods []corev1.Pod, set map[string]string) bool {
+	selector := labels.SelectorFromSet(set)
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveMTLS returns the mTLS mode that applies to the workload's pods, the most specific
+// PeerAuthentication winning: one selecting the pods, then the namespace's, then the mesh-wide one
+// in the root namespace. It also returns the PeerAuthentications considered.
+func effectiveMTLS(peerAuthentications []unstructured.Unstructured, namespace string, pods []corev1.Pod) (string, []MeshObject) {
+	considered := []MeshObject{}
+	modes := make(map[string]string)
+	for _, pa := range peerAuthentications {
+		mode, _, _ := unstructured.NestedString(pa.Object, "spec", "mtls", "mode")
+		if mode == "" {
+			mode = "UNSET"
+		}
+		selector, hasSelector, _ := unstructured.NestedStringMap(pa.Object, "spec", "selector", "matchLabels")
+		switch {
+		case pa.GetNamespace() == namespace && hasSelector:
+			if len(pods) == 0 || !anyPodMatches(pods, selector) {
+				continue
+			}
+			modes["workload"] = mode
+		case pa.GetNamespace() == namespace:
+			modes["namespace"] = mode
+		case pa.GetNamespace() == istioRootNamespace && !hasSelector:
+			modes["mesh"] = mode
+		default:
+			continue
+		}
+		considered = append(considered, meshObject(pa))
+	}
+	for _, scope := range []string{"workload", "namespace", "mesh"} {
+		if mode, ok := modes[scope]; ok && mode != "UNSET" {
+			return mode, considered
+		}
+	}
+	// Istio defaults to PERMISSIVE
+	return "PERMISSIVE", considered
+}
+
+// mtlsFindings reports client TLS settings of DestinationRules that do not match the mTLS mode
+// and sidecars of the workload
+func mtlsFindings(mode string, sidecars, pods int, workloadHosts []string, rulesByHost map[string][]unstructured.Unstructured) []string {
+	var findings []string
+	for _, host := range workloadHosts {
+		for _, rule := range rulesByHost[host] {
+			tlsMode, _, _ := unstructured.NestedString(rule.Object, "spec", "trafficPolicy", "tls", "mode")
+			name := rule.GetNamespace() + "/" + rule.GetName()
+			switch {
+			case tlsMode == "DISABLE" && mode == "STRICT":
+				findings = append(findings, fmt.Sprintf("mTLS mismatch: DestinationRule %s disables TLS to %s, but the workload requires STRICT mTLS, so clients are rejected (connection reset or 503 UC)", name, host))
+			case tlsMode == "ISTIO_MUTUAL" && sidecars == 0 && pods > 0:
+				findings = append(findings, fmt.Sprintf("mTLS mismatch: DestinationRule %s sends Istio mTLS to %s, but its pods have no sidecar to terminate it", name, host))
+			case tlsMode == "ISTIO_MUTUAL" && mode == "DISABLE":
+				findings = append(findings, fmt.Sprintf("mTLS mismatch: DestinationRule %s sends Istio mTLS to %s, but the workload's PeerAuthentication disables mTLS", name, host))
+			case (tlsMode == "SIMPLE" || tlsMode == "MUTUAL") && sidecars > 0:
+				findings = append(findings, fmt.Sprintf("DestinationRule %s originates %s TLS to %s, whose pods have sidecars expecting Istio mTLS; use ISTIO_MUTUAL unless the application itself serves TLS", name, tlsMode, host))
+			}
+		}
+	}
+	if mode == "STRICT" && sidecars == 0 && pods > 0 {
+		findings = append(findings, "STRICT mTLS applies to the workload, but its pods have no sidecar to enforce it")
+	}
+	return findings
+}
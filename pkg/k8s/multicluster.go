@@ -0,0 +1,86 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ClusterClient pairs a client with the kubeconfig context it was created for
+type ClusterClient struct {
+	// Name of the kubeconfig context
+	Context string
+	// Client connected to the context's cluster
+	Client *Client
+}
+
+// AddMultiClusterFlags adds flags for running a command against several kubeconfig contexts
+func AddMultiClusterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("contexts", []string{}, "Comma-separated kubeconfig contexts to run against concurrently")
+	cmd.Flags().Bool("all-contexts", false, "Run against every context in the kubeconfig concurrently")
+}
+
+// IsMultiCluster reports whether the multi-cluster flags were used
+func IsMultiCluster(cmd *cobra.Command) bool {
+	contexts, _ := cmd.Flags().GetStringSlice("contexts")
+	allContexts, _ := cmd.Flags().GetBool("all-contexts")
+	return len(contexts) > 0 || allContexts
+}
+
+// ListContexts returns the sorted context names in the kubeconfig
+func ListContexts(kubeconfigPath string) ([]string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfigPath != "" {
+		loadingRules.ExplicitPath = kubeconfigPath
+	}
+
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+
+	names := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// NewClientsFromFlags creates one client per context selected with --contexts or --all-contexts.
+// All other kubectl flags (namespace, impersonation, read-only mode, ...) apply to every client.
+func NewClientsFromFlags(cmd *cobra.Command) ([]ClusterClient, error) {
+	config, err := GetClientConfigFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts, _ := cmd.Flags().GetStringSlice("contexts")
+	allContexts, _ := cmd.Flags().GetBool("all-contexts")
+	if allContexts {
+		contexts, err = ListContexts(config.KubeconfigPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("no kubeconfig contexts selected")
+	}
+
+	clients := make([]ClusterClient, 0, len(contexts))
+	for _, contextName := range contexts {
+		contextConfig := config
+		contextConfig.Context = contextName
+
+		client, err := NewClientWithConfig(contextConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error creating client for context %s: %w", contextName, err)
+		}
+		clients = append(clients, ClusterClient{Context: contextName, Client: client})
+	}
+
+	return clients, nil
+}
@@ -0,0 +1,322 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Namespace summary limits: warning events reported, and how recent they must be
+const (
+	maxNamespaceEvents = 20
+	namespaceEventAge  = 24 * time.Hour
+)
+
+// quotaWarnRatio is the share of a quota in use from which it is reported as nearly exhausted
+const quotaWarnRatio = 0.9
+
+// NamespaceSummary describes what runs in a namespace and how it is doing, for onboarding
+// engineers to it: workloads, pods, services, ingresses, quotas, recent warning events and the
+// risks found
+type NamespaceSummary struct {
+	Namespace string              `json:"namespace"`
+	Labels    map[string]string   `json:"labels,omitempty"`
+	Workloads []NamespaceWorkload `json:"workloads"`
+	Pods      PodCounts           `json:"pods"`
+	Services  []NamespaceService  `json:"services"`
+	Ingresses []NamespaceIngress  `json:"ingresses"`
+	Quotas    []NamespaceQuota    `json:"quotas"`
+	Events    []NamespaceEvent    `json:"events"`
+	Findings  []string            `json:"findings"`
+}
+
+// NamespaceWorkload is a Deployment, StatefulSet, DaemonSet or CronJob with its readiness and
+// images, which tell what it runs
+type NamespaceWorkload struct {
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Desired int32    `json:"desired"`
+	Ready   int32    `json:"ready"`
+	Health  string   `json:"health"`
+	Images  []string `json:"images"`
+	// Schedule of CronJobs
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// PodCounts counts the pods of a namespace by phase, with their container restarts
+type PodCounts struct {
+	Total     int   `json:"total"`
+	Running   int   `json:"running"`
+	Pending   int   `json:"pending"`
+	Succeeded int   `json:"succeeded"`
+	Failed    int   `json:"failed"`
+	Restarts  int32 `json:"restarts"`
+}
+
+// NamespaceService is a Service with its type, ports and how many pods it selects
+type NamespaceService struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Ports []string `json:"ports"`
+	// Pods matching its selector; -1 for services without a selector
+	Pods int `json:"pods"`
+}
+
+// NamespaceIngress is an Ingress with its class, hosts and backends
+type NamespaceIngress struct {
+	Name     string   `json:"name"`
+	Class    string   `json:"class,omitempty"`
+	Hosts    []string `json:"hosts"`
+	Backends []string `json:"backends"`
+	TLS      bool     `json:"tls"`
+}
+
+// NamespaceQuota is a ResourceQuota with the usage of each resource it limits, as used/hard
+type NamespaceQuota struct {
+	Name  string            `json:"name"`
+	Usage map[string]string `json:"usage"`
+}
+
+// NamespaceEvent is a recent warning event of the namespace
+type NamespaceEvent struct {
+	Time    time.Time `json:"time"`
+	Object  string    `json:"object"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+	Count   int32     `json:"count"`
+}
+
+// GetNamespaceSummary collects the workloads, pods, services, ingresses, quotas and recent
+// warning events of a namespace, and the risks they show. Events are optional: without them the
+// summary goes on and the gap is recorded with SkipSource.
+func (c *Client) GetNamespaceSummary(ctx context.Context, namespace string) (*NamespaceSummary, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+	summary := &NamespaceSummary{Namespace: namespace}
+
+	ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace %s: %w", namespace, err)
+	}
+	summary.Labels = ns.Labels
+
+	if err := c.collectNamespaceWorkloads(ctx, summary); err != nil {
+		return nil, err
+	}
+
+	pods, err := c.ListPods(ctx, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	summarizePods(summary, pods)
+
+	services, err := c.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing services in namespace %s: %w", namespace, err)
+	}
+	for _, svc := range services.Items {
+		service := NamespaceService{Name: svc.Name, Type: string(svc.Spec.Type), Pods: -1}
+		for _, port := range svc.Spec.Ports {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d/%s->%s", port.Port, port.Protocol, port.TargetPort.String()))
+		}
+		if len(svc.Spec.Selector) > 0 {
+			selector := labels.SelectorFromSet(svc.Spec.Selector)
+			service.Pods = 0
+			for _, pod := range pods {
+				if pod.Status.Phase == corev1.PodRunning && selector.Matches(labels.Set(pod.Labels)) {
+					service.Pods++
+				}
+			}
+			if service.Pods == 0 {
+				summary.Findings = append(summary.Findings, fmt.Sprintf("Service %s selects no running pods, so it has no endpoints", svc.Name))
+			}
+		}
+		summary.Services = append(summary.Services, service)
+	}
+
+	ingresses, err := c.clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing ingresses in namespace %s: %w", namespace, err)
+	}
+	for _, ing := range ingresses.Items {
+		ingress := NamespaceIngress{Name: ing.Name, TLS: len(ing.Spec.TLS) > 0}
+		if ing.Spec.IngressClassName != nil {
+			ingress.Class = *ing.Spec.IngressClassName
+		}
+		for _, rule := range ing.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			ingress.Hosts = AppendUnique(ingress.Hosts, host)
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil {
+					ingress.Backends = AppendUnique(ingress.Backends, path.Backend.Service.Name)
+				}
+			}
+		}
+		if !ingress.TLS && len(ingress.Hosts) > 0 {
+			summary.Findings = append(summary.Findings, fmt.Sprintf("Ingress %s serves %s without TLS", ing.Name, strings.Join(ingress.Hosts, ", ")))
+		}
+		summary.Ingresses = append(summary.Ingresses, ingress)
+	}
+
+	quotas, err := c.clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing resource quotas in namespace %s: %w", namespace, err)
+	}
+	for _, q := range quotas.Items {
+		quota := NamespaceQuota{Name: q.Name, Usage: make(map[string]string)}
+		for name, hard := range q.Status.Hard {
+			used := q.Status.Used[name]
+			quota.Usage[string(name)] = fmt.Sprintf("%s/%s", used.String(), hard.String())
+			if quotaExhausted(used, hard) {
+				summary.Findings = append(summary.Findings, fmt.Sprintf("ResourceQuota %s has %s of %s %s in use", q.Name, used.String(), hard.String(), name))
+			}
+		}
+		summary.Quotas = append(summary.Quotas, quota)
+	}
+
+	events, err := c.ListEvents(ctx, namespace, "")
+	if err != nil {
+		SkipSource(SourceEvents, err)
+	}
+	since := time.Now().Add(-namespaceEventAge)
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning || eventTime(event).Before(since) {
+			continue
+		}
+		summary.Events = append(summary.Events, NamespaceEvent{
+			Time:    eventTime(event),
+			Object:  fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+			Reason:  event.Reason,
+			Message: strings.TrimSpace(event.Message),
+			Count:   event.Count,
+		})
+	}
+	sort.Slice(summary.Events, func(i, j int) bool { return summary.Events[i].Time.After(summary.Events[j].Time) })
+	if len(summary.Events) > maxNamespaceEvents {
+		summary.Events = summary.Events[:maxNamespaceEvents]
+	}
+
+	return summary, nil
+}
+
+// collectNamespaceWorkloads adds the Deployments, StatefulSets, DaemonSets and CronJobs of the
+// summary's namespace, with a finding for each workload that is not fully ready
+func (c *Client) collectNamespaceWorkloads(ctx context.Context, summary *NamespaceSummary) error {
+	namespace := summary.Namespace
+	add := func(kind, name string, desired, ready int32, spec corev1.PodSpec) *NamespaceWorkload {
+		status := WorkloadStatus{Kind: kind, Name: name, Namespace: namespace, Desired: desired, Ready: ready}
+		workload := NamespaceWorkload{Kind: kind, Name: name, Desired: desired, Ready: ready, Health: status.Health()}
+		for _, container := range spec.Containers {
+			workload.Images = AppendUnique(workload.Images, container.Image)
+		}
+		if workload.Health != HealthHealthy {
+			summary.Findings = append(summary.Findings, fmt.Sprintf("%s %s is %s: %d/%d replicas ready", kind, name, strings.ToLower(workload.Health), ready, desired))
+		}
+		summary.Workloads = append(summary.Workloads, workload)
+		return &summary.Workloads[len(summary.Workloads)-1]
+	}
+
+	deployments, err := c.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing deployments in namespace %s: %w", namespace, err)
+	}
+	for _, d := range deployments.Items {
+		desired := int32(1)
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+		add("deployment", d.Name, desired, d.Status.ReadyReplicas, d.Spec.Template.Spec)
+	}
+
+	statefulSets, err := c.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing statefulsets in namespace %s: %w", namespace, err)
+	}
+	for _, s := range statefulSets.Items {
+		desired := int32(1)
+		if s.Spec.Replicas != nil {
+			desired = *s.Spec.Replicas
+		}
+		add("statefulset", s.Name, desired, s.Status.ReadyReplicas, s.Spec.Template.Spec)
+	}
+
+	daemonSets, err := c.clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing daemonsets in namespace %s: %w", namespace, err)
+	}
+	for _, ds := range daemonSets.Items {
+		add("daemonset", ds.Name, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady, ds.Spec.Template.Spec)
+	}
+
+	cronJobs, err := c.clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing cronjobs in namespace %s: %w", namespace, err)
+	}
+	for _, cj := range cronJobs.Items {
+		// CronJobs have no replicas; they count as ready unless suspended
+		workload := add("cronjob", cj.Name, 0, 0, cj.Spec.JobTemplate.Spec.Template.Spec)
+		workload.Schedule = cj.Spec.Schedule
+		if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+			workload.Schedule += " (suspended)"
+		}
+	}
+
+	return nil
+}
+
+// summarizePods counts the pods of the summary by phase, and adds findings for containers stuck
+// waiting, such as in CrashLoopBackOff, and for containers without a memory limit
+func summarizePods(summary *NamespaceSummary, pods []corev1.Pod) {
+	unlimited, containers := 0, 0
+	for _, pod := range pods {
+		summary.Pods.Total++
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			summary.Pods.Running++
+		case corev1.PodPending:
+			summary.Pods.Pending++
+		case corev1.PodSucceeded:
+			summary.Pods.Succeeded++
+		case corev1.PodFailed:
+			summary.Pods.Failed++
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			summary.Pods.Restarts += status.RestartCount
+			if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "ContainerCreating" && waiting.Reason != "PodInitializing" {
+				summary.Findings = append(summary.Findings, fmt.Sprintf("Pod %s: container %s is waiting in %s (%d restarts)", pod.Name, status.Name, waiting.Reason, status.RestartCount))
+			}
+		}
+		for _, container := range pod.Spec.Containers {
+			containers++
+			if _, ok := container.Resources.Limits[corev1.ResourceMemory]; !ok {
+				unlimited++
+			}
+		}
+	}
+	if unlimited > 0 {
+		summary.Findings = append(summary.Findings, fmt.Sprintf("%d of %d containers set no memory limit", unlimited, containers))
+	}
+}
+
+// quotaExhausted reports whether a quota's usage reached quotaWarnRatio of its hard limit
+func quotaExhausted(used, hard resource.Quantity) bool {
+	if hard.IsZero() {
+		return !used.IsZero()
+	}
+	return used.AsApproximateFloat64() >= quotaWarnRatio*hard.AsApproximateFloat64()
+}
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ListObjects lists the objects of a kind, in the version the cluster prefers, in namespace or in
+// all namespaces when namespace is empty. Cluster-scoped kinds ignore namespace. A kind the
+// cluster does not serve has no objects; one whose API is unavailable, such as during a failed
+// discovery, has none either and is recorded as a skipped data source.
+func (c *Client) ListObjects(ctx context.Context, groupKind schema.GroupKind, namespace string) ([]unstructured.Unstructured, error) {
+	if c.dynamic == nil {
+		return nil, fmt.Errorf("listing objects is not supported by this client")
+	}
+
+	mapping, err := c.restMapper().RESTMapping(groupKind)
+	if meta.IsNoMatchError(err) {
+		return nil, nil
+	}
+	if IsAPIUnavailable(err) {
+		SkipSource(groupKind.String(), err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %w", groupKind, err)
+	}
+
+	resource := c.dynamic.Resource(mapping.Resource)
+	var list *unstructured.UnstructuredList
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != "" {
+		list, err = resource.Namespace(namespace).List(ctx, metav1.ListOptions{})
+	} else {
+		list, err = resource.List(ctx, metav1.ListOptions{})
+	}
+	if IsAPIUnavailable(err) {
+		SkipSource(groupKind.String(), err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", groupKind, err)
+	}
+	return list.Items, nil
+}
+
+// GetObject gets an object of a kind, in the version the cluster prefers. Cluster-scoped kinds
+// ignore namespace.
+func (c *Client) GetObject(ctx context.Context, groupKind schema.GroupKind, namespace, name string) (*unstructured.Unstructured, error) {
+	if c.dynamic == nil {
+		return nil, fmt.Errorf("getting objects is not supported by this client")
+	}
+
+	mapping, err := c.restMapper().RESTMapping(groupKind)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %w", groupKind, err)
+	}
+
+	resource := c.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return resource.Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return resource.Get(ctx, name, metav1.GetOptions{})
+}
+
+// AppendUnique appends a value to a slice unless it is already present
+func AppendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,240 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// schemaRefPrefix prefixes references to the component schemas of an OpenAPI v3 document
+const schemaRefPrefix = "#/components/schemas/"
+
+// fieldSchemaDepth is how many levels of nested fields FieldSchema resolves
+const fieldSchemaDepth = 4
+
+// FieldSchema is the OpenAPI schema of a resource field, as published by the API server
+type FieldSchema struct {
+	// Kind of the resource
+	Kind string
+	// API version of the resource
+	APIVersion string
+	// Path of the field below the resource, such as spec.strategy; empty for the resource itself
+	Path string
+	// Schema of the field, with nested fields resolved a few levels deep
+	Schema *spec.Schema
+}
+
+// FieldSchema looks up the schema of a field given as resource.field.path, such as
+// deployment.spec.strategy, from the cluster's OpenAPI v3 documents. The resource can be given
+// as kind, plural or short name; apiVersion selects a group version other than the preferred one.
+// Custom resources are supported like built-in ones.
+func (c *Client) FieldSchema(ctx context.Context, reference, apiVersion string) (*FieldSchema, error) {
+	resource, path, _ := strings.Cut(strings.TrimSpace(reference), ".")
+	if resource == "" {
+		return nil, fmt.Errorf("no resource given")
+	}
+
+	gvr := schema.GroupVersionResource{Resource: strings.ToLower(resource)}
+	if apiVersion != "" {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API version %q: %w", apiVersion, err)
+		}
+		gvr.Group, gvr.Version = gv.Group, gv.Version
+	}
+
+	mapper := restmapper.NewShortcutExpander(c.restMapper(), c.clientset.Discovery(), nil)
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving resource %q: %w", resource, err)
+	}
+
+	components, err := c.openAPIComponents(gvk.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	var current *spec.Schema
+	for _, component := range components {
+		if hasGroupVersionKind(component, gvk) {
+			current = component
+			break
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("the cluster publishes no schema for %s", gvk.Kind)
+	}
+
+	var resolved []string
+	if path != "" {
+		for _, field := range strings.Split(path, ".") {
+			parent := elementOf(resolveRef(current, components), components)
+			property, ok := parent.Properties[field]
+			if !ok {
+				return nil, fmt.Errorf("field %q does not exist in %s", strings.Join(append(resolved, field), "."), gvk.Kind)
+			}
+			current = &property
+			resolved = append(resolved, field)
+		}
+	}
+
+	return &FieldSchema{
+		Kind:       gvk.Kind,
+		APIVersion: gvk.GroupVersion().String(),
+		Path:       strings.Join(resolved, "."),
+		Schema:     inlineRefs(current, components, fieldSchemaDepth, map[string]bool{}),
+	}, nil
+}
+
+// openAPIComponents fetches the component schemas of the OpenAPI v3 document of a group version
+func (c *Client) openAPIComponents(gv schema.GroupVersion) (map[string]*spec.Schema, error) {
+	paths, err := c.clientset.Discovery().OpenAPIV3().Paths()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching OpenAPI paths: %w", err)
+	}
+
+	key := "apis/" + gv.String()
+	if gv.Group == "" {
+		key = "api/" + gv.Version
+	}
+	groupVersion, ok := paths[key]
+	if !ok {
+		return nil, fmt.Errorf("the cluster publishes no OpenAPI v3 document for %s", gv)
+	}
+
+	data, err := groupVersion.Schema("application/json")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching OpenAPI document for %s: %w", gv, err)
+	}
+	var document struct {
+		Components struct {
+			Schemas map[string]*spec.Schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error parsing OpenAPI document for %s: %w", gv, err)
+	}
+	return document.Components.Schemas, nil
+}
+
+// hasGroupVersionKind reports whether a component schema is the schema of a resource kind
+func hasGroupVersionKind(s *spec.Schema, gvk schema.GroupVersionKind) bool {
+	values, _ := s.Extensions["x-kubernetes-group-version-kind"].([]interface{})
+	for _, value := range values {
+		entry, _ := value.(map[string]interface{})
+		if entry["group"] == gvk.Group && entry["version"] == gvk.Version && entry["kind"] == gvk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRef follows a reference to a component schema. OpenAPI v3 documents wrap references in
+// a single allOf to attach a description, which is kept.
+func resolveRef(s *spec.Schema, components map[string]*spec.Schema) *spec.Schema {
+	for s != nil {
+		if name := strings.TrimPrefix(s.Ref.String(), schemaRefPrefix); name != "" {
+			target, ok := components[name]
+			if !ok {
+				return s
+			}
+			s = target
+			continue
+		}
+		if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+			target := *resolveRef(&s.AllOf[0], components)
+			if s.Description != "" {
+				target.Description = s.Description
+			}
+			if s.Default != nil {
+				target.Default = s.Default
+			}
+			return &target
+		}
+		return s
+	}
+	return nil
+}
+
+// elementOf returns the resolved schema of the items of an array or map schema, or the schema itself
+func elementOf(s *spec.Schema, components map[string]*spec.Schema) *spec.Schema {
+	for {
+		switch {
+		case s.Items != nil && s.Items.Schema != nil:
+			s = resolveRef(s.Items.Schema, components)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			s = resolveRef(s.AdditionalProperties.Schema, components)
+		default:
+			return s
+		}
+	}
+}
+
+// inlineRefs returns a copy of a schema with references replaced by the schemas they refer to,
+// down to depth levels of nested fields. Recursive schemas are inlined once.
+func inlineRefs(s *spec.Schema, components map[string]*spec.Schema, depth int, seen map[string]bool) *spec.Schema {
+	name := strings.TrimPrefix(s.Ref.String(), schemaRefPrefix)
+	if len(s.AllOf) == 1 {
+		name = strings.TrimPrefix(s.AllOf[0].Ref.String(), schemaRefPrefix)
+	}
+	cycle := name != "" && seen[name]
+	if name != "" {
+		seen = copySeen(seen, name)
+	}
+
+	resolved := *resolveRef(s, components)
+	resolved.Ref = spec.Ref{}
+	resolved.AllOf = nil
+
+	if cycle {
+		// Describe recursive schemas by their type only
+		if len(resolved.Properties) > 0 && len(resolved.Type) == 0 {
+			resolved.Type = spec.StringOrArray{"object"}
+		}
+		resolved.Properties = nil
+		resolved.Items = nil
+		resolved.AdditionalProperties = nil
+		return &resolved
+	}
+
+	if resolved.Items != nil && resolved.Items.Schema != nil {
+		resolved.Items = &spec.SchemaOrArray{Schema: inlineRefs(resolved.Items.Schema, components, depth, seen)}
+	}
+	if resolved.AdditionalProperties != nil && resolved.AdditionalProperties.Schema != nil {
+		resolved.AdditionalProperties = &spec.SchemaOrBool{
+			Allows: true,
+			Schema: inlineRefs(resolved.AdditionalProperties.Schema, components, depth, seen),
+		}
+	}
+
+	if depth <= 0 {
+		// Keep the type of objects whose fields are not resolved
+		if len(resolved.Properties) > 0 && len(resolved.Type) == 0 {
+			resolved.Type = spec.StringOrArray{"object"}
+		}
+		resolved.Properties = nil
+		return &resolved
+	}
+
+	properties := make(map[string]spec.Schema, len(resolved.Properties))
+	for key, property := range resolved.Properties {
+		properties[key] = *inlineRefs(&property, components, depth-1, seen)
+	}
+	resolved.Properties = properties
+	return &resolved
+}
+
+// copySeen returns a copy of seen with name added
+func copySeen(seen map[string]bool, name string) map[string]bool {
+	copied := make(map[string]bool, len(seen)+1)
+	for key := range seen {
+		copied[key] = true
+	}
+	copied[name] = true
+	return copied
+}
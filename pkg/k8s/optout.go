@@ -0,0 +1,189 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotations resource owners set on a namespace or workload to opt it out of AI analysis
+const (
+	// Leave the namespace or workload out of AI analysis and of reports covering many workloads
+	IgnoreAnnotation = "kube-ai.io/ignore"
+	// Mask the logs of the namespace or workload with the strict redaction policy before they are
+	// sent to an AI provider
+	RedactLogsAnnotation = "kube-ai.io/redact-logs"
+)
+
+// OptOut tells what the annotations of a workload and its namespace opt it out of
+type OptOut struct {
+	// Object whose kube-ai.io/ignore annotation opts the workload out, such as namespace/payments
+	// or deployment/checkout; empty when none does
+	IgnoredBy string `json:"ignoredBy,omitempty"`
+	// Object whose kube-ai.io/redact-logs annotation asks for its logs to be masked
+	RedactLogsBy string `json:"redactLogsBy,omitempty"`
+}
+
+// annotated reports whether an annotation is set to a true value
+func annotated(annotations map[string]string, key string) bool {
+	value, err := strconv.ParseBool(strings.TrimSpace(annotations[key]))
+	return err == nil && value
+}
+
+// merge records the opt-outs of an object's annotations not already set by another object
+func (o *OptOut) merge(object string, annotations map[string]string) {
+	if o.IgnoredBy == "" && annotated(annotations, IgnoreAnnotation) {
+		o.IgnoredBy = object
+	}
+	if o.RedactLogsBy == "" && annotated(annotations, RedactLogsAnnotation) {
+		o.RedactLogsBy = object
+	}
+}
+
+// GetOptOut reads the opt-out annotations of a workload, of the workload owning it when it is a
+// pod, and of its namespace. Objects that do not exist or cannot be read count as not annotated,
+// so collection reports them as usual.
+func (c *Client) GetOptOut(ctx context.Context, resourceType, name, namespace string) (OptOut, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	var optOut OptOut
+	meta, err := c.workloadMeta(ctx, resourceType, name, namespace)
+	if err != nil {
+		return optOut, err
+	}
+	if meta != nil {
+		optOut.merge(strings.ToLower(resourceType)+"/"+name, meta.GetAnnotations())
+		if pod, ok := meta.(*corev1.Pod); ok {
+			if kind, owner := PodWorkload(*pod); kind != "pod" {
+				owning, err := c.workloadMeta(ctx, kind, owner, namespace)
+				if err != nil {
+					return optOut, err
+				}
+				if owning != nil {
+					optOut.merge(kind+"/"+owner, owning.GetAnnotations())
+				}
+			}
+		}
+	}
+
+	ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		optOut.merge("namespace/"+namespace, ns.Annotations)
+	} else if !apierrors.IsNotFound(err) && !apierrors.IsForbidden(err) {
+		return optOut, fmt.Errorf("error getting namespace %s: %w", namespace, err)
+	}
+	return optOut, nil
+}
+
+// workloadMeta returns the metadata of a workload, or nil when it does not exist or its type has
+// no annotations to honor, such as nodes
+func (c *Client) workloadMeta(ctx context.Context, resourceType, name, namespace string) (metav1.Object, error) {
+	var obj metav1.Object
+	var err error
+	switch strings.ToLower(resourceType) {
+	case "pod", "pods", "po":
+		obj, err = c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "deployment", "deployments", "deploy":
+		obj, err = c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "statefulset", "statefulsets", "sts":
+		obj, err = c.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "daemonset", "daemonsets", "ds":
+		obj, err = c.clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "replicaset", "replicasets", "rs":
+		obj, err = c.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "job", "jobs":
+		obj, err = c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "cronjob", "cronjobs", "cj":
+		obj, err = c.clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return nil, nil
+	}
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s %s: %w", resourceType, name, err)
+	}
+	return obj, nil
+}
+
+// OptOutFilter tells which namespaces, workloads and pods of a report covering many workloads
+// their owners opted out of with the kube-ai.io/ignore annotation. A nil filter ignores nothing.
+type OptOutFilter struct {
+	namespaces map[string]bool
+	// namespace/kind/name of the ignored workloads
+	workloads map[string]bool
+}
+
+// NewOptOutFilter reads the ignore annotations of the namespaces and workloads in a namespace (all
+// namespaces if empty). Objects that cannot be listed are not filtered, as for clusters where
+// namespaces are not readable.
+func (c *Client) NewOptOutFilter(ctx context.Context, namespace string) *OptOutFilter {
+	filter := &OptOutFilter{namespaces: make(map[string]bool), workloads: make(map[string]bool)}
+	all := metav1.ListOptions{}
+
+	if namespace == "" {
+		_ = EachCachedListItem(ctx, c, corev1.SchemeGroupVersion.WithResource("namespaces"), "", c.clientset.CoreV1().Namespaces().List, all, func(ns *corev1.Namespace) error {
+			if annotated(ns.Annotations, IgnoreAnnotation) {
+				filter.namespaces[ns.Name] = true
+			}
+			return nil
+		})
+	} else if ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err == nil && annotated(ns.Annotations, IgnoreAnnotation) {
+		filter.namespaces[namespace] = true
+	}
+
+	add := func(kind string, meta metav1.ObjectMeta) {
+		if annotated(meta.Annotations, IgnoreAnnotation) {
+			filter.workloads[meta.Namespace+"/"+kind+"/"+meta.Name] = true
+		}
+	}
+	_ = EachCachedListItem(ctx, c, appsv1.SchemeGroupVersion.WithResource("deployments"), namespace, c.clientset.AppsV1().Deployments(namespace).List, all, func(item *appsv1.Deployment) error {
+		add("deployment", item.ObjectMeta)
+		return nil
+	})
+	_ = EachCachedListItem(ctx, c, appsv1.SchemeGroupVersion.WithResource("statefulsets"), namespace, c.clientset.AppsV1().StatefulSets(namespace).List, all, func(item *appsv1.StatefulSet) error {
+		add("statefulset", item.ObjectMeta)
+		return nil
+	})
+	_ = EachCachedListItem(ctx, c, appsv1.SchemeGroupVersion.WithResource("daemonsets"), namespace, c.clientset.AppsV1().DaemonSets(namespace).List, all, func(item *appsv1.DaemonSet) error {
+		add("daemonset", item.ObjectMeta)
+		return nil
+	})
+	_ = EachCachedListItem(ctx, c, batchv1.SchemeGroupVersion.WithResource("jobs"), namespace, c.clientset.BatchV1().Jobs(namespace).List, all, func(item *batchv1.Job) error {
+		add("job", item.ObjectMeta)
+		return nil
+	})
+	return filter
+}
+
+// IgnoresObject reports whether an object, given as namespace/kind/name, is in an ignored
+// namespace or is an ignored workload
+func (f *OptOutFilter) IgnoresObject(object string) bool {
+	if f == nil {
+		return false
+	}
+	namespace, _, _ := strings.Cut(object, "/")
+	return f.namespaces[namespace] || f.workloads[object]
+}
+
+// IgnoresPod reports whether a pod, the workload owning it or its namespace is ignored
+func (f *OptOutFilter) IgnoresPod(pod corev1.Pod) bool {
+	if f == nil {
+		return false
+	}
+	if annotated(pod.Annotations, IgnoreAnnotation) {
+		return true
+	}
+	kind, name := PodWorkload(pod)
+	return f.IgnoresObject(pod.Namespace + "/" + kind + "/" + name)
+}
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ListPageSize is the number of items requested per page when listing a collection, so that the
+// API server of a large cluster never has to build and send thousands of pods in one response
+const ListPageSize = 500
+
+// ListFunc lists one page of a collection, such as clientset.CoreV1().Pods(namespace).List
+type ListFunc[L runtime.Object] func(ctx context.Context, options metav1.ListOptions) (L, error)
+
+// EachListItem calls fn with each item of a collection, such as *corev1.Pod, fetched in pages of
+// ListPageSize items with the Limit and Continue list options. Only one page is held at a time
+// unless fn keeps the items. Listing stops at the first error fn returns.
+func EachListItem[L runtime.Object, T any](ctx context.Context, list ListFunc[L], options metav1.ListOptions, fn func(*T) error) error {
+	options.Limit = ListPageSize
+	for {
+		page, err := list(ctx, options)
+		if err != nil {
+			return err
+		}
+		err = meta.EachListItem(page, func(obj runtime.Object) error {
+			item, ok := any(obj).(*T)
+			if !ok {
+				return fmt.Errorf("unexpected list item %T", obj)
+			}
+			return fn(item)
+		})
+		if err != nil {
+			return err
+		}
+
+		accessor, err := meta.ListAccessor(page)
+		if err != nil {
+			return err
+		}
+		if accessor.GetContinue() == "" {
+			return nil
+		}
+		// The continue token pins the snapshot the first page was read from
+		options.Continue = accessor.GetContinue()
+		options.ResourceVersion = ""
+	}
+}
@@ -0,0 +1,511 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/restmapper"
+
+	"kube-ai/pkg/k8s/logs"
+)
+
+// Operators of a QueryFilter
+const (
+	FilterEquals    = "="
+	FilterNotEquals = "!="
+	FilterGreater   = ">"
+	FilterLess      = "<"
+	FilterContains  = "contains"
+	FilterExists    = "exists"
+	FilterNotExists = "notexists"
+)
+
+// Fields a QueryFilter can use that are computed from an object rather than read from it
+const (
+	computedAge      = "age"
+	computedReady    = "ready"
+	computedStatus   = "status"
+	computedRestarts = "restarts"
+)
+
+// FilterOperators lists the operators a QueryFilter can use
+var FilterOperators = []string{FilterEquals, FilterNotEquals, FilterGreater, FilterLess, FilterContains, FilterExists, FilterNotExists}
+
+// ComputedFields lists the fields a QueryFilter can use besides paths into the objects
+var ComputedFields = []string{computedAge, computedReady, computedStatus, computedRestarts}
+
+// ResourceQuery is a typed query for objects of one resource type: what the API server selects,
+// and the filters applied to the listed objects
+type ResourceQuery struct {
+	// Resource type as kubectl takes it, such as pods, deploy or certificates.cert-manager.io
+	Resource string `json:"resource"`
+	// Namespace to list in, "" for the current one
+	Namespace     string `json:"namespace"`
+	AllNamespaces bool   `json:"allNamespaces"`
+	LabelSelector string `json:"labelSelector"`
+	FieldSelector string `json:"fieldSelector"`
+	// Filters the objects must all match
+	Filters []QueryFilter `json:"filters"`
+}
+
+// QueryFilter compares a field of an object with a value. The field is a dotted path such as
+// status.phase, where a path through a list matches any item, or one of ComputedFields: age (a
+// duration such as 1h), ready (true or false), status as kubectl shows it, and restarts.
+type QueryFilter struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// String describes a filter, such as age > 1h
+func (f QueryFilter) String() string {
+	if f.Operator == FilterExists || f.Operator == FilterNotExists {
+		return f.Field + " " + f.Operator
+	}
+	return f.Field + " " + f.Operator + " " + f.Value
+}
+
+// String describes a query in the terms of kubectl get, followed by its filters
+func (q ResourceQuery) String() string {
+	parts := []string{q.Resource}
+	switch {
+	case q.AllNamespaces:
+		parts = append(parts, "-A")
+	case q.Namespace != "":
+		parts = append(parts, "-n", q.Namespace)
+	}
+	if q.LabelSelector != "" {
+		parts = append(parts, "-l", q.LabelSelector)
+	}
+	if q.FieldSelector != "" {
+		parts = append(parts, "--field-selector", q.FieldSelector)
+	}
+	description := strings.Join(parts, " ")
+	if len(q.Filters) > 0 {
+		filters := make([]string, len(q.Filters))
+		for i, filter := range q.Filters {
+			filters[i] = filter.String()
+		}
+		description += " where " + strings.Join(filters, " and ")
+	}
+	return description
+}
+
+// Validate checks that a query names a resource type and that its filters can be evaluated
+func (q ResourceQuery) Validate() error {
+	if strings.TrimSpace(q.Resource) == "" {
+		return fmt.Errorf("the query names no resource type")
+	}
+	for _, filter := range q.Filters {
+		if filter.Field == "" {
+			return fmt.Errorf("filter %q has no field", filter)
+		}
+		known := false
+		for _, operator := range FilterOperators {
+			known = known || filter.Operator == operator
+		}
+		if !known {
+			return fmt.Errorf("filter %q has an unsupported operator, use one of %s", filter, strings.Join(FilterOperators, ", "))
+		}
+		if filter.Field == computedAge && filter.Operator != FilterExists && filter.Operator != FilterNotExists {
+			if _, err := logs.ParseDuration(filter.Value); err != nil {
+				return fmt.Errorf("filter %q: %w", filter, err)
+			}
+		}
+	}
+	return nil
+}
+
+// QueryResult is the table of the objects a query selected, as kubectl get prints it
+type QueryResult struct {
+	Kind string
+	// Whether the rows start with the namespace of their object
+	Namespaced bool
+	Columns    []string
+	Rows       [][]string
+}
+
+// SelectedObject is an object a query selected
+type SelectedObject struct {
+	Namespace string
+	Name      string
+}
+
+// String names an object as namespace/name, or by name when it is cluster-scoped
+func (o SelectedObject) String() string {
+	if o.Namespace == "" {
+		return o.Name
+	}
+	return o.Namespace + "/" + o.Name
+}
+
+// RunQuery lists the objects a query selects and returns them as a table. The selectors are
+// applied by the API server and the filters by the client, on every page of the list.
+func (c *Client) RunQuery(ctx context.Context, query ResourceQuery) (*QueryResult, error) {
+	var rows [][]string
+	now := time.Now()
+	mapping, err := c.eachQueryItem(ctx, query, now, func(object *unstructured.Unstructured, mapping *meta.RESTMapping) {
+		row := append([]string{object.GetName()}, queryCells(*object, mapping.GroupVersionKind.Kind, now)...)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && query.AllNamespaces {
+			row = append([]string{object.GetNamespace()}, row...)
+		}
+		rows = append(rows, row)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QueryResult{
+		Kind:       mapping.GroupVersionKind.Kind,
+		Namespaced: mapping.Scope.Name() == meta.RESTScopeNameNamespace && query.AllNamespaces,
+		Rows:       rows,
+	}
+	result.Columns = append([]string{"NAME"}, queryColumns(result.Kind)...)
+	if result.Namespaced {
+		result.Columns = append([]string{"NAMESPACE"}, result.Columns...)
+	}
+	sort.SliceStable(result.Rows, func(i, j int) bool {
+		return strings.Join(result.Rows[i], "\x00") < strings.Join(result.Rows[j], "\x00")
+	})
+	return result, nil
+}
+
+// SelectObjects lists the objects a query selects, sorted by namespace and name, for commands
+// that act on every object matching a selector rather than on one named object
+func (c *Client) SelectObjects(ctx context.Context, query ResourceQuery) ([]SelectedObject, error) {
+	var objects []SelectedObject
+	_, err := c.eachQueryItem(ctx, query, time.Now(), func(object *unstructured.Unstructured, _ *meta.RESTMapping) {
+		objects = append(objects, SelectedObject{Namespace: object.GetNamespace(), Name: object.GetName()})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].String() < objects[j].String()
+	})
+	return objects, nil
+}
+
+// eachQueryItem calls fn with each object a query selects, listed page by page, and returns the
+// mapping of the query's resource type
+func (c *Client) eachQueryItem(ctx context.Context, query ResourceQuery, now time.Time, fn func(*unstructured.Unstructured, *meta.RESTMapping)) (*meta.RESTMapping, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if c.dynamic == nil {
+		return nil, fmt.Errorf("listing objects is not supported by this client")
+	}
+	namespace := query.Namespace
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	mapper := restmapper.NewShortcutExpander(c.restMapper(), c.clientset.Discovery(), nil)
+	target, mapping, _, err := c.resourceClient(mapper, query.Resource, namespace, query.AllNamespaces)
+	if err != nil {
+		return nil, fmt.Errorf("the cluster serves no resource type %q", query.Resource)
+	}
+
+	options := metav1.ListOptions{LabelSelector: query.LabelSelector, FieldSelector: query.FieldSelector}
+	err = EachListItem(ctx, target.List, options, func(object *unstructured.Unstructured) error {
+		if matchesFilters(*object, query.Filters, now) {
+			fn(object, mapping)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", mapping.Resource.Resource, err)
+	}
+	return mapping, nil
+}
+
+// queryColumns returns the columns kubectl get prints for a kind after the name
+func queryColumns(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"READY", "STATUS", "RESTARTS", "AGE"}
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		return []string{"READY", "AGE"}
+	case "Node", "Namespace", "PersistentVolumeClaim", "PersistentVolume":
+		return []string{"STATUS", "AGE"}
+	default:
+		return []string{"AGE"}
+	}
+}
+
+// queryCells returns the cells of an object for the columns of queryColumns
+func queryCells(object unstructured.Unstructured, kind string, now time.Time) []string {
+	age := shortDuration(now.Sub(object.GetCreationTimestamp().Time))
+	switch kind {
+	case "Pod":
+		statuses, _, _ := unstructured.NestedSlice(object.Object, "status", "containerStatuses")
+		ready := 0
+		for _, status := range statuses {
+			if status, ok := status.(map[string]interface{}); ok && status["ready"] == true {
+				ready++
+			}
+		}
+		containers, _, _ := unstructured.NestedSlice(object.Object, "spec", "containers")
+		return []string{fmt.Sprintf("%d/%d", ready, len(containers)), objectStatus(object), strconv.FormatInt(podRestarts(object), 10), age}
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		readyReplicas, _, _ := unstructured.NestedInt64(object.Object, "status", "readyReplicas")
+		replicas, found, _ := unstructured.NestedInt64(object.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		return []string{fmt.Sprintf("%d/%d", readyReplicas, replicas), age}
+	case "Node", "Namespace", "PersistentVolumeClaim", "PersistentVolume":
+		return []string{objectStatus(object), age}
+	default:
+		return []string{age}
+	}
+}
+
+// matchesFilters reports whether an object matches all the filters
+func matchesFilters(object unstructured.Unstructured, filters []QueryFilter, now time.Time) bool {
+	for _, filter := range filters {
+		if !matchesFilter(object, filter, now) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesFilter reports whether an object matches a filter. A path that reaches several values,
+// through a list, matches when any of them does, and != when none equals the value.
+func matchesFilter(object unstructured.Unstructured, filter QueryFilter, now time.Time) bool {
+	var values []interface{}
+	switch filter.Field {
+	case computedAge:
+		age := now.Sub(object.GetCreationTimestamp().Time)
+		if filter.Operator == FilterExists || filter.Operator == FilterNotExists {
+			return filter.Operator == FilterExists
+		}
+		limit, err := logs.ParseDuration(filter.Value)
+		if err != nil {
+			return false
+		}
+		return compareOrdered(age, limit, filter.Operator)
+	case computedReady:
+		values = []interface{}{objectReady(object)}
+	case computedStatus:
+		values = []interface{}{objectStatus(object)}
+	case computedRestarts:
+		values = []interface{}{podRestarts(object)}
+	default:
+		values = fieldValues(object.Object, strings.Split(filter.Field, "."))
+	}
+
+	switch filter.Operator {
+	case FilterExists:
+		return len(values) > 0
+	case FilterNotExists:
+		return len(values) == 0
+	case FilterNotEquals:
+		for _, value := range values {
+			if valueEquals(value, filter.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, value := range values {
+		switch filter.Operator {
+		case FilterEquals:
+			if valueEquals(value, filter.Value) {
+				return true
+			}
+		case FilterContains:
+			if strings.Contains(strings.ToLower(fmt.Sprint(value)), strings.ToLower(filter.Value)) {
+				return true
+			}
+		case FilterGreater, FilterLess:
+			if compareValues(value, filter.Value, filter.Operator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldValues returns the values at a path in an object, following every item of the lists the
+// path goes through
+func fieldValues(value interface{}, path []string) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		var values []interface{}
+		for _, item := range list {
+			values = append(values, fieldValues(item, path)...)
+		}
+		return values
+	}
+	if len(path) == 0 {
+		if value == nil {
+			return nil
+		}
+		return []interface{}{value}
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	next, found := object[path[0]]
+	if !found {
+		return nil
+	}
+	return fieldValues(next, path[1:])
+}
+
+// valueEquals compares a field value with a filter value: numbers numerically, and anything else
+// as text, ignoring case
+func valueEquals(value interface{}, want string) bool {
+	if number, ok := toFloat(value); ok {
+		if wanted, err := strconv.ParseFloat(want, 64); err == nil {
+			return number == wanted
+		}
+	}
+	return strings.EqualFold(fmt.Sprint(value), want)
+}
+
+// compareValues orders a field value against a filter value: numbers numerically, quantities
+// such as 512Mi and durations by their size, and anything else, such as timestamps, as text
+func compareValues(value interface{}, limit, operator string) bool {
+	if number, ok := toFloat(value); ok {
+		if wanted, err := strconv.ParseFloat(limit, 64); err == nil {
+			return compareOrdered(number, wanted, operator)
+		}
+	}
+	text := fmt.Sprint(value)
+	if duration, err := time.ParseDuration(text); err == nil {
+		if wanted, err := logs.ParseDuration(limit); err == nil {
+			return compareOrdered(duration, wanted, operator)
+		}
+	}
+	if quantity, err := resource.ParseQuantity(text); err == nil {
+		if wanted, err := resource.ParseQuantity(limit); err == nil {
+			return compareOrdered(quantity.AsApproximateFloat64(), wanted.AsApproximateFloat64(), operator)
+		}
+	}
+	return compareOrdered(text, limit, operator)
+}
+
+// compareOrdered reports whether a is greater (>) or less (<) than b
+func compareOrdered[T int64 | float64 | string | time.Duration](a, b T, operator string) bool {
+	if operator == FilterGreater {
+		return a > b
+	}
+	return a < b
+}
+
+// toFloat returns the value of a JSON number
+func toFloat(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+// objectReady reports whether an object is ready: all the containers of a pod, all the replicas
+// of a workload, or else its Ready condition
+func objectReady(object unstructured.Unstructured) bool {
+	switch object.GetKind() {
+	case "Pod":
+		statuses, _, _ := unstructured.NestedSlice(object.Object, "status", "containerStatuses")
+		if len(statuses) == 0 {
+			return false
+		}
+		for _, status := range statuses {
+			if status, ok := status.(map[string]interface{}); !ok || status["ready"] != true {
+				return false
+			}
+		}
+		return true
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		readyReplicas, _, _ := unstructured.NestedInt64(object.Object, "status", "readyReplicas")
+		replicas, found, _ := unstructured.NestedInt64(object.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		return readyReplicas >= replicas
+	}
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if condition, ok := condition.(map[string]interface{}); ok && condition["type"] == "Ready" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
+
+// objectStatus returns the status of an object as kubectl get shows it: the reason a container
+// of a pod is waiting or terminated, else the phase; Ready or NotReady for nodes
+func objectStatus(object unstructured.Unstructured) string {
+	switch object.GetKind() {
+	case "Pod":
+		if object.GetDeletionTimestamp() != nil {
+			return "Terminating"
+		}
+		statuses, _, _ := unstructured.NestedSlice(object.Object, "status", "containerStatuses")
+		for _, status := range statuses {
+			for _, state := range []string{"waiting", "terminated"} {
+				if reason, _, _ := unstructured.NestedString(asObject(status), "state", state, "reason"); reason != "" && reason != "Completed" {
+					return reason
+				}
+			}
+		}
+	case "Node":
+		status := "NotReady"
+		if objectReady(object) {
+			status = "Ready"
+		}
+		if unschedulable, _, _ := unstructured.NestedBool(object.Object, "spec", "unschedulable"); unschedulable {
+			status += ",SchedulingDisabled"
+		}
+		return status
+	}
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	return phase
+}
+
+// podRestarts returns the total restarts of the containers of a pod
+func podRestarts(object unstructured.Unstructured) int64 {
+	statuses, _, _ := unstructured.NestedSlice(object.Object, "status", "containerStatuses")
+	var restarts int64
+	for _, status := range statuses {
+		count, _, _ := unstructured.NestedInt64(asObject(status), "restartCount")
+		restarts += count
+	}
+	return restarts
+}
+
+// asObject returns a value as a JSON object, or an empty one
+func asObject(value interface{}) map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	return object
+}
+
+// shortDuration formats a duration in its largest unit, as kubectl prints ages: 45s, 10m, 5h, 3d
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,86 @@
+package k8s
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Client-side limits on API server usage. client-go's own defaults of 5 queries per second with
+// bursts of 10 make cluster-wide reports crawl on large clusters, while unbounded concurrency lets
+// them flood the API server; these sit in between.
+const (
+	// DefaultQPS is the sustained rate of API requests per second
+	DefaultQPS float32 = 50
+	// DefaultBurst is the number of API requests allowed above the sustained rate for short bursts
+	DefaultBurst = 100
+	// DefaultMaxInFlight is the number of API requests that may be in flight at the same time
+	DefaultMaxInFlight = 16
+)
+
+// inFlightRoundTripper caps the number of requests in flight to the API server. A request holds
+// its slot until its response body is read or closed, so large lists count for as long as they
+// are transferred. Followed log streams and watches are long-lived and do not take a slot;
+// holding one would stall every other request for as long as they stay open.
+type inFlightRoundTripper struct {
+	next  http.RoundTripper
+	slots chan struct{}
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *inFlightRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isLongRunningRequest(req) {
+		return rt.next.RoundTrip(req)
+	}
+
+	select {
+	case rt.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-rt.slots })
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// NewInFlightRoundTripper wraps a transport so that at most maxInFlight requests are in flight at
+// the same time; zero or less leaves requests unlimited
+func NewInFlightRoundTripper(next http.RoundTripper, maxInFlight int) http.RoundTripper {
+	if maxInFlight <= 0 {
+		return next
+	}
+	return &inFlightRoundTripper{next: next, slots: make(chan struct{}, maxInFlight)}
+}
+
+// isLongRunningRequest reports whether a request follows logs or watches for changes
+func isLongRunningRequest(req *http.Request) bool {
+	query := req.URL.Query()
+	return query.Get("follow") == "true" || query.Get("watch") == "true" || query.Get("watch") == "1"
+}
+
+// releasingBody releases the slot of its request once fully read or closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Read implements io.Reader
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.release()
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,468 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Permission describes a set of verbs on a resource required by a feature
+type Permission struct {
+	// API group of the resource ("" for core)
+	APIGroup string
+	// Resource name, optionally with a subresource (e.g. pods/log)
+	Resource string
+	// Verbs required on the resource
+	Verbs []string
+	// Whether the feature works without it, leaving out what it reads, such as resource usage
+	// from the metrics API. Generated roles grant it, but CheckFeatureAccess does not require it.
+	Optional bool
+}
+
+// readVerbs are the verbs needed to inspect a resource
+var readVerbs = []string{"get", "list"}
+
+// FeaturePermissions maps each kube-ai feature to the minimal permissions it needs
+var FeaturePermissions = map[string][]Permission{
+	"analyze": {
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+	},
+	"analyze-logs": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: []string{"get"}},
+		// Other workload kinds, the rollouts and autoscaling of the timeline, resource usage and
+		// node logs through the kubelet
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: []string{"get"}, Optional: true},
+		{APIGroup: "batch", Resource: "jobs", Verbs: []string{"get"}, Optional: true},
+		{APIGroup: "apps", Resource: "replicasets", Verbs: readVerbs, Optional: true},
+		{APIGroup: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs, Optional: true},
+		{APIGroup: "metrics.k8s.io", Resource: "pods", Verbs: readVerbs, Optional: true},
+		{APIGroup: "", Resource: "nodes/proxy", Verbs: []string{"get"}, Optional: true},
+	},
+	// Drift is checked on the managed objects, which need read access to their kinds too
+	"analyze-argo": {
+		{APIGroup: "argoproj.io", Resource: "applications", Verbs: []string{"get"}},
+	},
+	"analyze-flux": {
+		{APIGroup: "kustomize.toolkit.fluxcd.io", Resource: "kustomizations", Verbs: readVerbs},
+		{APIGroup: "helm.toolkit.fluxcd.io", Resource: "helmreleases", Verbs: readVerbs},
+		{APIGroup: "source.toolkit.fluxcd.io", Resource: "gitrepositories", Verbs: []string{"get"}},
+		{APIGroup: "source.toolkit.fluxcd.io", Resource: "helmrepositories", Verbs: []string{"get"}},
+		{APIGroup: "source.toolkit.fluxcd.io", Resource: "helmcharts", Verbs: []string{"get"}},
+		{APIGroup: "source.toolkit.fluxcd.io", Resource: "ocirepositories", Verbs: []string{"get"}},
+		{APIGroup: "source.toolkit.fluxcd.io", Resource: "buckets", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+	},
+	"analyze-gpu": {
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+	},
+	"analyze-images": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+	},
+	"analyze-mesh": {
+		{APIGroup: "", Resource: "namespaces", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: []string{"get"}},
+		{APIGroup: "networking.istio.io", Resource: "virtualservices", Verbs: readVerbs},
+		{APIGroup: "networking.istio.io", Resource: "destinationrules", Verbs: readVerbs},
+		{APIGroup: "networking.istio.io", Resource: "gateways", Verbs: readVerbs},
+		{APIGroup: "security.istio.io", Resource: "peerauthentications", Verbs: readVerbs},
+	},
+	"analyze-values": {
+		// Helm stores releases in secrets
+		{APIGroup: "", Resource: "secrets", Verbs: readVerbs},
+	},
+	"agent": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "", Resource: "configmaps", Verbs: readVerbs},
+		{APIGroup: "", Resource: "persistentvolumeclaims", Verbs: readVerbs},
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "replicasets", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "jobs", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "cronjobs", Verbs: readVerbs},
+		{APIGroup: "networking.k8s.io", Resource: "ingresses", Verbs: readVerbs},
+		{APIGroup: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs},
+		{APIGroup: "policy", Resource: "poddisruptionbudgets", Verbs: readVerbs},
+		{APIGroup: "metrics.k8s.io", Resource: "pods", Verbs: readVerbs},
+	},
+	"capacity": {
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "", Resource: "configmaps", Verbs: []string{"get"}},
+		{APIGroup: "metrics.k8s.io", Resource: "nodes", Verbs: readVerbs, Optional: true},
+	},
+	"ctx": {
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+	},
+	"diagnose": {
+		// dns
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+		{APIGroup: "", Resource: "configmaps", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "endpoints", Verbs: []string{"get"}},
+		// statefulset
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "", Resource: "persistentvolumeclaims", Verbs: readVerbs},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		// Both
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+	},
+	"enrich-incident": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "replicasets", Verbs: readVerbs, Optional: true},
+	},
+	"explain": {
+		// Only read to explain custom resources
+		{APIGroup: "apiextensions.k8s.io", Resource: "customresourcedefinitions", Verbs: readVerbs, Optional: true},
+	},
+	"explain-scaling-events": {
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "", Resource: "configmaps", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "karpenter.sh", Resource: "nodepools", Verbs: readVerbs, Optional: true},
+		{APIGroup: "karpenter.sh", Resource: "nodeclaims", Verbs: readVerbs, Optional: true},
+	},
+	"generate": {
+		// Only read to generate custom resources
+		{APIGroup: "apiextensions.k8s.io", Resource: "customresourcedefinitions", Verbs: readVerbs, Optional: true},
+	},
+	// Queries on other resource types need read access to them too
+	"get": {
+		{APIGroup: "", Resource: "namespaces", Verbs: readVerbs},
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "", Resource: "persistentvolumeclaims", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "jobs", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "cronjobs", Verbs: readVerbs},
+	},
+	// Checking commands on other resource types needs read access to them too
+	"how": {
+		{APIGroup: "", Resource: "namespaces", Verbs: readVerbs},
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+	},
+	"rollout-risk": {
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: []string{"get"}},
+		{APIGroup: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs},
+		{APIGroup: "policy", Resource: "poddisruptionbudgets", Verbs: readVerbs},
+		{APIGroup: "", Resource: "nodes", Verbs: readVerbs},
+	},
+	"tui": {
+		{APIGroup: "", Resource: "namespaces", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: []string{"get"}},
+		{APIGroup: "batch", Resource: "jobs", Verbs: []string{"get"}},
+		{APIGroup: "metrics.k8s.io", Resource: "pods", Verbs: readVerbs, Optional: true},
+	},
+	// Kinds that cannot be listed are skipped and reported
+	"upgrade-check": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "replicasets", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "jobs", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "cronjobs", Verbs: readVerbs},
+		{APIGroup: "networking.k8s.io", Resource: "ingresses", Verbs: readVerbs},
+		{APIGroup: "networking.k8s.io", Resource: "ingressclasses", Verbs: readVerbs, Optional: true},
+		{APIGroup: "networking.k8s.io", Resource: "networkpolicies", Verbs: readVerbs, Optional: true},
+		{APIGroup: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs, Optional: true},
+		{APIGroup: "policy", Resource: "poddisruptionbudgets", Verbs: readVerbs, Optional: true},
+		{APIGroup: "discovery.k8s.io", Resource: "endpointslices", Verbs: readVerbs, Optional: true},
+		{APIGroup: "events.k8s.io", Resource: "events", Verbs: readVerbs, Optional: true},
+		{APIGroup: "coordination.k8s.io", Resource: "leases", Verbs: readVerbs, Optional: true},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "roles", Verbs: readVerbs, Optional: true},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "rolebindings", Verbs: readVerbs, Optional: true},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "clusterroles", Verbs: readVerbs, Optional: true},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "clusterrolebindings", Verbs: readVerbs, Optional: true},
+		{APIGroup: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations", Verbs: readVerbs, Optional: true},
+		{APIGroup: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations", Verbs: readVerbs, Optional: true},
+		{APIGroup: "apiextensions.k8s.io", Resource: "customresourcedefinitions", Verbs: readVerbs, Optional: true},
+		{APIGroup: "apiregistration.k8s.io", Resource: "apiservices", Verbs: readVerbs, Optional: true},
+		{APIGroup: "certificates.k8s.io", Resource: "certificatesigningrequests", Verbs: readVerbs, Optional: true},
+		{APIGroup: "flowcontrol.apiserver.k8s.io", Resource: "flowschemas", Verbs: readVerbs, Optional: true},
+		{APIGroup: "flowcontrol.apiserver.k8s.io", Resource: "prioritylevelconfigurations", Verbs: readVerbs, Optional: true},
+		{APIGroup: "node.k8s.io", Resource: "runtimeclasses", Verbs: readVerbs, Optional: true},
+		{APIGroup: "scheduling.k8s.io", Resource: "priorityclasses", Verbs: readVerbs, Optional: true},
+		{APIGroup: "storage.k8s.io", Resource: "storageclasses", Verbs: readVerbs, Optional: true},
+		{APIGroup: "storage.k8s.io", Resource: "csidrivers", Verbs: readVerbs, Optional: true},
+		{APIGroup: "storage.k8s.io", Resource: "csinodes", Verbs: readVerbs, Optional: true},
+		{APIGroup: "storage.k8s.io", Resource: "csistoragecapacities", Verbs: readVerbs, Optional: true},
+		{APIGroup: "storage.k8s.io", Resource: "volumeattachments", Verbs: readVerbs, Optional: true},
+	},
+	"benchmark": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "namespaces", Verbs: readVerbs},
+		{APIGroup: "", Resource: "serviceaccounts", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "networking.k8s.io", Resource: "networkpolicies", Verbs: readVerbs},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "roles", Verbs: readVerbs},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "clusterroles", Verbs: readVerbs},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "rolebindings", Verbs: readVerbs},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "clusterrolebindings", Verbs: readVerbs},
+	},
+	"audit-secrets": {
+		{APIGroup: "", Resource: "secrets", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "serviceaccounts", Verbs: readVerbs},
+		{APIGroup: "networking.k8s.io", Resource: "ingresses", Verbs: readVerbs},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "rolebindings", Verbs: readVerbs},
+		{APIGroup: "rbac.authorization.k8s.io", Resource: "clusterrolebindings", Verbs: readVerbs},
+	},
+	"bundle": {
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: []string{"get"}},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: []string{"get"}},
+		{APIGroup: "batch", Resource: "jobs", Verbs: []string{"get"}},
+		{APIGroup: "metrics.k8s.io", Resource: "pods", Verbs: readVerbs},
+	},
+	"summarize": {
+		{APIGroup: "", Resource: "namespaces", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "pods", Verbs: readVerbs},
+		{APIGroup: "", Resource: "services", Verbs: readVerbs},
+		{APIGroup: "", Resource: "resourcequotas", Verbs: readVerbs},
+		{APIGroup: "", Resource: "events", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{APIGroup: "apps", Resource: "daemonsets", Verbs: readVerbs},
+		{APIGroup: "batch", Resource: "cronjobs", Verbs: readVerbs},
+		{APIGroup: "networking.k8s.io", Resource: "ingresses", Verbs: readVerbs},
+	},
+	"suggest-scaling": {
+		{APIGroup: "apps", Resource: "deployments", Verbs: readVerbs},
+	},
+}
+
+// FeatureNames returns the sorted list of features with known permissions
+func FeatureNames() []string {
+	names := make([]string, 0, len(FeaturePermissions))
+	for name := range FeaturePermissions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildPolicyRules merges the permissions of the given features into minimal RBAC rules
+func BuildPolicyRules(features []string) ([]rbacv1.PolicyRule, error) {
+	var permissions []Permission
+	for _, feature := range features {
+		featurePermissions, ok := FeaturePermissions[feature]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature %q (known features: %s)", feature, strings.Join(FeatureNames(), ", "))
+		}
+		permissions = append(permissions, featurePermissions...)
+	}
+	return buildPolicyRules(permissions), nil
+}
+
+// buildPolicyRules merges permissions into minimal RBAC rules
+func buildPolicyRules(permissions []Permission) []rbacv1.PolicyRule {
+	// group -> resource -> verb set
+	merged := make(map[string]map[string]map[string]bool)
+
+	for _, perm := range permissions {
+		if merged[perm.APIGroup] == nil {
+			merged[perm.APIGroup] = make(map[string]map[string]bool)
+		}
+		if merged[perm.APIGroup][perm.Resource] == nil {
+			merged[perm.APIGroup][perm.Resource] = make(map[string]bool)
+		}
+		for _, verb := range perm.Verbs {
+			merged[perm.APIGroup][perm.Resource][verb] = true
+		}
+	}
+
+	groups := make([]string, 0, len(merged))
+	for group := range merged {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	// Emit one rule per (group, verb set) to keep the manifest short and reviewable
+	var rules []rbacv1.PolicyRule
+	for _, group := range groups {
+		byVerbs := make(map[string][]string)
+		for resource, verbSet := range merged[group] {
+			verbs := make([]string, 0, len(verbSet))
+			for verb := range verbSet {
+				verbs = append(verbs, verb)
+			}
+			sort.Strings(verbs)
+			key := strings.Join(verbs, ",")
+			byVerbs[key] = append(byVerbs[key], resource)
+		}
+
+		keys := make([]string, 0, len(byVerbs))
+		for key := range byVerbs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			resources := byVerbs[key]
+			sort.Strings(resources)
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups: []string{group},
+				Resources: resources,
+				Verbs:     strings.Split(key, ","),
+			})
+		}
+	}
+
+	return rules
+}
+
+// RBACOptions controls the generated RBAC manifest
+type RBACOptions struct {
+	// Name of the role and binding
+	Name string
+	// Namespace for a namespaced Role; empty generates a ClusterRole
+	Namespace string
+	// Features to grant permissions for
+	Features []string
+	// Permissions to grant besides those of the features
+	Permissions []Permission
+	// Subject to bind the role to (user name or "serviceaccount:<ns>:<name>")
+	Subject string
+}
+
+// GenerateRBACManifest renders a Role/ClusterRole and binding as a multi-document YAML string
+func GenerateRBACManifest(options RBACOptions) (string, error) {
+	permissions := append([]Permission(nil), options.Permissions...)
+	for _, feature := range options.Features {
+		featurePermissions, ok := FeaturePermissions[feature]
+		if !ok {
+			return "", fmt.Errorf("unknown feature %q (known features: %s)", feature, strings.Join(FeatureNames(), ", "))
+		}
+		permissions = append(permissions, featurePermissions...)
+	}
+	rules := buildPolicyRules(permissions)
+
+	var docs []interface{}
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Name: options.Name}
+	labels := map[string]string{"app.kubernetes.io/managed-by": "kube-ai"}
+
+	if options.Namespace != "" {
+		roleRef.Kind = "Role"
+		docs = append(docs, &rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: options.Name, Namespace: options.Namespace, Labels: labels},
+			Rules:      rules,
+		})
+	} else {
+		roleRef.Kind = "ClusterRole"
+		docs = append(docs, &rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: options.Name, Labels: labels},
+			Rules:      rules,
+		})
+	}
+
+	if options.Subject != "" {
+		subject := parseSubject(options.Subject)
+		if options.Namespace != "" {
+			docs = append(docs, &rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: options.Name, Namespace: options.Namespace, Labels: labels},
+				Subjects:   []rbacv1.Subject{subject},
+				RoleRef:    roleRef,
+			})
+		} else {
+			docs = append(docs, &rbacv1.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: options.Name, Labels: labels},
+				Subjects:   []rbacv1.Subject{subject},
+				RoleRef:    roleRef,
+			})
+		}
+	}
+
+	var sb strings.Builder
+	for i, doc := range docs {
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return "", fmt.Errorf("error converting RBAC manifest to YAML: %w", err)
+		}
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.Write(data)
+	}
+
+	return sb.String(), nil
+}
+
+// parseSubject converts a subject string into an RBAC subject
+func parseSubject(subject string) rbacv1.Subject {
+	parts := strings.Split(subject, ":")
+	if len(parts) == 3 && (parts[0] == "serviceaccount" || parts[0] == "sa") {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: parts[1], Name: parts[2]}
+	}
+	if len(parts) == 2 && parts[0] == "group" {
+		return rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: parts[1]}
+	}
+	return rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: subject}
+}
+
+// WhoAmI returns the username the API server associates with the current credentials
+func (c *Client) WhoAmI(ctx context.Context) (string, error) {
+	review, err := c.clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error determining current user: %w", err)
+	}
+	return review.Status.UserInfo.Username, nil
+}
@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrReadOnly is returned when a mutating request is attempted in read-only mode
+var ErrReadOnly = errors.New("kube-ai is running in read-only mode")
+
+// readOnlyReviewResources are create-only APIs that do not modify cluster state and
+// are needed to inspect the caller's own identity and permissions
+var readOnlyReviewResources = []string{
+	"/selfsubjectaccessreviews",
+	"/selfsubjectrulesreviews",
+	"/selfsubjectreviews",
+	"/subjectaccessreviews",
+}
+
+// readOnlyRoundTripper rejects every request that could mutate cluster state
+type readOnlyRoundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *readOnlyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !IsReadOnlyRequest(req.Method, req.URL.Path) {
+		return nil, fmt.Errorf("%w: refusing %s %s (use --allow-writes to permit changes)", ErrReadOnly, req.Method, req.URL.Path)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// NewReadOnlyRoundTripper wraps a transport so that only non-mutating verbs reach the API server
+func NewReadOnlyRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return &readOnlyRoundTripper{next: next}
+}
+
+// IsReadOnlyRequest reports whether an API request is safe to send in read-only mode
+func IsReadOnlyRequest(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		for _, suffix := range readOnlyReviewResources {
+			if strings.HasSuffix(path, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
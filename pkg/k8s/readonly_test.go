@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsReadOnlyRequest(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodGet, "/api/v1/namespaces/default/pods", true},
+		{http.MethodHead, "/api/v1/nodes", true},
+		{http.MethodOptions, "/apis", true},
+		{http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", true},
+		{http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectrulesreviews", true},
+		{http.MethodPost, "/apis/authentication.k8s.io/v1/selfsubjectreviews", true},
+		{http.MethodPost, "/apis/authorization.k8s.io/v1/subjectaccessreviews", true},
+		{http.MethodPost, "/api/v1/namespaces/default/pods", false},
+		{http.MethodPost, "/api/v1/namespaces/default/pods/web/exec", false},
+		{http.MethodPost, "/api/v1/namespaces/default/pods/web/eviction", false},
+		{http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews/extra", false},
+		{http.MethodPut, "/api/v1/namespaces/default/configmaps/app", false},
+		{http.MethodPatch, "/apis/apps/v1/namespaces/default/deployments/web", false},
+		{http.MethodDelete, "/api/v1/namespaces/default/pods/web", false},
+		{"CONNECT", "/api/v1/namespaces/default/pods/web/portforward", false},
+	}
+	for _, tt := range tests {
+		if got := IsReadOnlyRequest(tt.method, tt.path); got != tt.want {
+			t.Errorf("IsReadOnlyRequest(%s, %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyRoundTripper(t *testing.T) {
+	var reached []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = append(reached, r.Method+" "+r.URL.Path)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewReadOnlyRoundTripper(http.DefaultTransport)}
+
+	resp, err := client.Get(server.URL + "/api/v1/pods")
+	if err != nil {
+		t.Fatalf("GET was refused: %v", err)
+	}
+	resp.Body.Close()
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		req, err := http.NewRequest(method, server.URL+"/api/v1/namespaces/default/pods/web", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Do(req); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", method, err)
+		}
+	}
+
+	if len(reached) != 1 || reached[0] != "GET /api/v1/pods" {
+		t.Errorf("only the GET may reach the API server, got %v", reached)
+	}
+}
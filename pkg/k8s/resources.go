@@ -0,0 +1,269 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"kube-ai/pkg/k8s/logs"
+)
+
+// PodMetrics holds the live resource usage of a pod as reported by the metrics API
+type PodMetrics struct {
+	// Name of the pod
+	Name string
+	// Namespace of the pod
+	Namespace string
+	// Per-container usage
+	Containers []ContainerMetrics
+}
+
+// ContainerMetrics holds the live resource usage of a single container
+type ContainerMetrics struct {
+	// Name of the container
+	Name string
+	// CPU usage in Kubernetes quantity format (e.g. "12m")
+	CPU string
+	// Memory usage in Kubernetes quantity format (e.g. "64Mi")
+	Memory string
+}
+
+// GetResourceYAML returns the YAML representation of a single resource.
+// Secrets are deliberately not supported so their data never reaches a prompt.
+func (c *Client) GetResourceYAML(ctx context.Context, resourceType, name, namespace string) (string, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	var obj runtime.Object
+	var kind, apiVersion string
+	var err error
+
+	switch strings.ToLower(resourceType) {
+	case "pod", "pods", "po":
+		obj, err = c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "Pod", "v1"
+	case "deployment", "deployments", "deploy":
+		obj, err = c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "Deployment", "apps/v1"
+	case "statefulset", "statefulsets", "sts":
+		obj, err = c.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "StatefulSet", "apps/v1"
+	case "daemonset", "daemonsets", "ds":
+		obj, err = c.clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "DaemonSet", "apps/v1"
+	case "replicaset", "replicasets", "rs":
+		obj, err = c.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "ReplicaSet", "apps/v1"
+	case "service", "services", "svc":
+		obj, err = c.clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "Service", "v1"
+	case "configmap", "configmaps", "cm":
+		obj, err = c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "ConfigMap", "v1"
+	case "persistentvolumeclaim", "persistentvolumeclaims", "pvc":
+		obj, err = c.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "PersistentVolumeClaim", "v1"
+	case "node", "nodes", "no":
+		obj, err = c.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "Node", "v1"
+	case "job", "jobs":
+		obj, err = c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "Job", "batch/v1"
+	case "cronjob", "cronjobs", "cj":
+		obj, err = c.clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "CronJob", "batch/v1"
+	case "ingress", "ingresses", "ing":
+		obj, err = c.clientset.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "Ingress", "networking.k8s.io/v1"
+	case "horizontalpodautoscaler", "horizontalpodautoscalers", "hpa":
+		obj, err = c.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "HorizontalPodAutoscaler", "autoscaling/v2"
+	case "poddisruptionbudget", "poddisruptionbudgets", "pdb":
+		obj, err = c.clientset.PolicyV1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
+		kind, apiVersion = "PodDisruptionBudget", "policy/v1"
+	default:
+		return "", fmt.Errorf("unsupported resource type: %s", resourceType)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error getting %s %s: %w", resourceType, name, err)
+	}
+
+	return ObjectToYAML(obj, apiVersion, kind)
+}
+
+// ObjectToYAML serializes a typed object to YAML, restoring the type metadata that
+// typed clients drop and removing noisy managed fields
+func ObjectToYAML(obj runtime.Object, apiVersion, kind string) (string, error) {
+	obj = obj.DeepCopyObject()
+	obj.GetObjectKind().SetGroupVersionKind(schema.FromAPIVersionAndKind(apiVersion, kind))
+
+	if accessor, ok := obj.(metav1.Object); ok {
+		accessor.SetManagedFields(nil)
+	}
+
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("error converting %s to YAML: %w", kind, err)
+	}
+
+	return string(data), nil
+}
+
+// ListPods lists pods in a namespace, optionally filtered by a label selector, a page at a time
+func (c *Client) ListPods(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	pods, err := ListAllCached[*corev1.PodList, corev1.Pod](ctx, c, corev1.SchemeGroupVersion.WithResource("pods"), namespace, c.clientset.CoreV1().Pods(namespace).List, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods in namespace %s: %w", namespace, err)
+	}
+
+	return pods, nil
+}
+
+// ListEvents lists events in a namespace, optionally limited to a single involved object
+func (c *Client) ListEvents(ctx context.Context, namespace, objectName string) ([]corev1.Event, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	options := metav1.ListOptions{}
+	if objectName != "" {
+		options.FieldSelector = "involvedObject.name=" + objectName
+	}
+
+	events, err := c.clientset.CoreV1().Events(namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing events in namespace %s: %w", namespace, err)
+	}
+
+	return events.Items, nil
+}
+
+// GetPodMetrics returns live pod usage from the metrics.k8s.io API.
+// The API is queried directly so that metrics-server is an optional dependency.
+func (c *Client) GetPodMetrics(ctx context.Context, namespace string) ([]PodMetrics, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	// Clientsets without a REST client, such as fake ones, cannot reach the metrics API
+	if restClient, ok := c.clientset.CoreV1().RESTClient().(*rest.RESTClient); ok && restClient == nil {
+		err := fmt.Errorf("the metrics API is not available")
+		SkipSource(SourceMetrics, err)
+		return nil, err
+	}
+
+	data, err := c.clientset.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+		DoRaw(ctx)
+	if err != nil {
+		SkipSource(SourceMetrics, err)
+		return nil, fmt.Errorf("error querying metrics API (is metrics-server installed?): %w", err)
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Containers []struct {
+				Name  string `json:"name"`
+				Usage struct {
+					CPU    string `json:"cpu"`
+					Memory string `json:"memory"`
+				} `json:"usage"`
+			} `json:"containers"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error decoding metrics response: %w", err)
+	}
+
+	metrics := make([]PodMetrics, 0, len(list.Items))
+	for _, item := range list.Items {
+		pm := PodMetrics{Name: item.Metadata.Name, Namespace: item.Metadata.Namespace}
+		for _, container := range item.Containers {
+			pm.Containers = append(pm.Containers, ContainerMetrics{
+				Name:   container.Name,
+				CPU:    container.Usage.CPU,
+				Memory: container.Usage.Memory,
+			})
+		}
+		metrics = append(metrics, pm)
+	}
+
+	return metrics, nil
+}
+
+// GetWorkloadPods returns the pods that belong to a workload together with the label
+// selector used to find them. For pods the pod itself is returned.
+func (c *Client) GetWorkloadPods(ctx context.Context, resourceType, name, namespace string) ([]corev1.Pod, string, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	var selector *metav1.LabelSelector
+
+	switch strings.ToLower(resourceType) {
+	case "pod", "pods", "po":
+		pod, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("error getting pod %s: %w", name, err)
+		}
+		return []corev1.Pod{*pod}, "", nil
+	case "deployment", "deployments", "deploy":
+		deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("error getting deployment %s: %w", name, err)
+		}
+		selector = deployment.Spec.Selector
+	case "statefulset", "statefulsets", "sts":
+		statefulset, err := c.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("error getting statefulset %s: %w", name, err)
+		}
+		selector = statefulset.Spec.Selector
+	case "daemonset", "daemonsets", "ds":
+		daemonset, err := c.clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("error getting daemonset %s: %w", name, err)
+		}
+		selector = daemonset.Spec.Selector
+	case "job", "jobs":
+		job, err := c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("error getting job %s: %w", name, err)
+		}
+		selector = job.Spec.Selector
+	case "control-plane", "controlplane":
+		labelSelector := logs.ControlPlaneSelector(name)
+		pods, err := c.ListPods(ctx, namespace, labelSelector)
+		return pods, labelSelector, err
+	default:
+		return nil, "", fmt.Errorf("unsupported workload type: %s", resourceType)
+	}
+
+	labelSelector := metav1.FormatLabelSelector(selector)
+	pods, err := c.ListPods(ctx, namespace, labelSelector)
+	if err != nil {
+		return nil, labelSelector, err
+	}
+
+	return pods, labelSelector, nil
+}
@@ -0,0 +1,390 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// spotNodeLabels mark nodes that the cloud provider can reclaim at short notice, with the
+// label value that marks them (compared case-insensitively)
+var spotNodeLabels = map[string]string{
+	"karpenter.sh/capacity-type":            "spot",
+	"eks.amazonaws.com/capacityType":        "spot",
+	"cloud.google.com/gke-spot":             "true",
+	"cloud.google.com/gke-preemptible":      "true",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+	"node.kubernetes.io/lifecycle":          "spot",
+}
+
+// RolloutProfile describes how a workload behaves during rollouts and voluntary disruptions
+// such as node drains
+type RolloutProfile struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Desired and ready replicas; for daemonsets the nodes the daemonset should run on
+	Replicas      int32 `json:"replicas"`
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Update strategy: RollingUpdate, Recreate or OnDelete
+	Strategy string `json:"strategy"`
+	// Rolling update limits as declared, such as 25%, and resolved to a number of pods
+	MaxUnavailable     string `json:"maxUnavailable,omitempty"`
+	MaxSurge           string `json:"maxSurge,omitempty"`
+	MaxUnavailablePods int32  `json:"maxUnavailablePods"`
+	MaxSurgePods       int32  `json:"maxSurgePods"`
+	MinReadySeconds    int32  `json:"minReadySeconds"`
+	// Containers without a readiness probe
+	NoReadinessProbe []string `json:"noReadinessProbe,omitempty"`
+	// Topology spread constraints, such as topology.kubernetes.io/zone maxSkew=1 DoNotSchedule
+	TopologySpread []string `json:"topologySpread,omitempty"`
+	// Topology keys of pod anti-affinity terms against the workload's own pods
+	AntiAffinity []string `json:"antiAffinity,omitempty"`
+	// Horizontal pod autoscaler bounds, such as 2-10 replicas
+	Autoscaler string             `json:"autoscaler,omitempty"`
+	Budgets    []DisruptionBudget `json:"budgets"`
+	Placement  []PodPlacement     `json:"placement"`
+	// Workload manifest, without managed fields
+	Manifest string `json:"-"`
+}
+
+// DisruptionBudget summarizes a PodDisruptionBudget that covers a workload's pods
+type DisruptionBudget struct {
+	Name               string `json:"name"`
+	MinAvailable       string `json:"minAvailable,omitempty"`
+	MaxUnavailable     string `json:"maxUnavailable,omitempty"`
+	CurrentHealthy     int32  `json:"currentHealthy"`
+	ExpectedPods       int32  `json:"expectedPods"`
+	DisruptionsAllowed int32  `json:"disruptionsAllowed"`
+}
+
+// PodPlacement is where a pod of a workload runs
+type PodPlacement struct {
+	Pod   string `json:"pod"`
+	Node  string `json:"node,omitempty"`
+	Zone  string `json:"zone,omitempty"`
+	Pool  string `json:"pool,omitempty"`
+	Spot  bool   `json:"spot"`
+	Ready bool   `json:"ready"`
+}
+
+// GetRolloutProfile collects the replicas, update strategy, disruption budgets, spreading and pod
+// placement of a deployment, statefulset or daemonset
+func (c *Client) GetRolloutProfile(ctx context.Context, resourceType, name, namespace string) (*RolloutProfile, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	profile := &RolloutProfile{Name: name, Namespace: namespace}
+	var obj runtime.Object
+	var template corev1.PodTemplateSpec
+	var selector *metav1.LabelSelector
+	var kind string
+
+	switch strings.ToLower(resourceType) {
+	case "deployment", "deployments", "deploy":
+		deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting deployment %s: %w", name, err)
+		}
+		obj, template, selector, kind = deployment, deployment.Spec.Template, deployment.Spec.Selector, "Deployment"
+		profile.Replicas = replicasOrDefault(deployment.Spec.Replicas)
+		profile.ReadyReplicas = deployment.Status.ReadyReplicas
+		profile.MinReadySeconds = deployment.Spec.MinReadySeconds
+		profile.Strategy = string(deployment.Spec.Strategy.Type)
+		if deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+			var maxUnavailable, maxSurge *intstr.IntOrString
+			if update := deployment.Spec.Strategy.RollingUpdate; update != nil {
+				maxUnavailable, maxSurge = update.MaxUnavailable, update.MaxSurge
+			}
+			profile.setRollingUpdate(maxUnavailable, maxSurge)
+		}
+	case "statefulset", "statefulsets", "sts":
+		statefulset, err := c.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting statefulset %s: %w", name, err)
+		}
+		obj, template, selector, kind = statefulset, statefulset.Spec.Template, statefulset.Spec.Selector, "StatefulSet"
+		profile.Replicas = replicasOrDefault(statefulset.Spec.Replicas)
+		profile.ReadyReplicas = statefulset.Status.ReadyReplicas
+		profile.MinReadySeconds = statefulset.Spec.MinReadySeconds
+		profile.Strategy = string(statefulset.Spec.UpdateStrategy.Type)
+		if statefulset.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType {
+			// StatefulSets update one pod at a time unless maxUnavailable is set, and never surge
+			maxUnavailable := intstr.FromInt32(1)
+			if update := statefulset.Spec.UpdateStrategy.RollingUpdate; update != nil && update.MaxUnavailable != nil {
+				maxUnavailable = *update.MaxUnavailable
+			}
+			zero := intstr.FromInt32(0)
+			profile.setRollingUpdate(&maxUnavailable, &zero)
+		}
+	case "daemonset", "daemonsets", "ds":
+		daemonset, err := c.clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting daemonset %s: %w", name, err)
+		}
+		obj, template, selector, kind = daemonset, daemonset.Spec.Template, daemonset.Spec.Selector, "DaemonSet"
+		profile.Replicas = daemonset.Status.DesiredNumberScheduled
+		profile.ReadyReplicas = daemonset.Status.NumberReady
+		profile.MinReadySeconds = daemonset.Spec.MinReadySeconds
+		profile.Strategy = string(daemonset.Spec.UpdateStrategy.Type)
+		if daemonset.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType {
+			var maxUnavailable, maxSurge *intstr.IntOrString
+			if update := daemonset.Spec.UpdateStrategy.RollingUpdate; update != nil {
+				maxUnavailable, maxSurge = update.MaxUnavailable, update.MaxSurge
+			}
+			if maxUnavailable == nil {
+				one := intstr.FromInt32(1)
+				maxUnavailable = &one
+			}
+			if maxSurge == nil {
+				zero := intstr.FromInt32(0)
+				maxSurge = &zero
+			}
+			profile.setRollingUpdate(maxUnavailable, maxSurge)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload type: %s (expected deployment, statefulset or daemonset)", resourceType)
+	}
+	profile.Kind = strings.ToLower(kind)
+
+	manifest, err := ObjectToYAML(obj, "apps/v1", kind)
+	if err != nil {
+		return nil, err
+	}
+	profile.Manifest = manifest
+
+	for _, container := range template.Spec.Containers {
+		if container.ReadinessProbe == nil {
+			profile.NoReadinessProbe = append(profile.NoReadinessProbe, container.Name)
+		}
+	}
+	for _, constraint := range template.Spec.TopologySpreadConstraints {
+		profile.TopologySpread = append(profile.TopologySpread, fmt.Sprintf("%s maxSkew=%d %s",
+			constraint.TopologyKey, constraint.MaxSkew, constraint.WhenUnsatisfiable))
+	}
+	if affinity := template.Spec.Affinity; affinity != nil && affinity.PodAntiAffinity != nil {
+		podLabels := labels.Set(template.Labels)
+		for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			if selectsLabels(term.LabelSelector, podLabels) {
+				profile.AntiAffinity = append(profile.AntiAffinity, term.TopologyKey+" (required)")
+			}
+		}
+		for _, term := range affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if selectsLabels(term.PodAffinityTerm.LabelSelector, podLabels) {
+				profile.AntiAffinity = append(profile.AntiAffinity, term.PodAffinityTerm.TopologyKey+" (preferred)")
+			}
+		}
+	}
+
+	budgets, err := c.clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pod disruption budgets in namespace %s: %w", namespace, err)
+	}
+	for _, pdb := range budgets.Items {
+		if !selectsLabels(pdb.Spec.Selector, labels.Set(template.Labels)) {
+			continue
+		}
+		budget := DisruptionBudget{
+			Name:               pdb.Name,
+			CurrentHealthy:     pdb.Status.CurrentHealthy,
+			ExpectedPods:       pdb.Status.ExpectedPods,
+			DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+		}
+		if pdb.Spec.MinAvailable != nil {
+			budget.MinAvailable = pdb.Spec.MinAvailable.String()
+		}
+		if pdb.Spec.MaxUnavailable != nil {
+			budget.MaxUnavailable = pdb.Spec.MaxUnavailable.String()
+		}
+		profile.Budgets = append(profile.Budgets, budget)
+	}
+
+	// Autoscaling is optional context
+	if autoscalers, err := c.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, hpa := range autoscalers.Items {
+			if hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name {
+				profile.Autoscaler = fmt.Sprintf("%d-%d replicas (HorizontalPodAutoscaler %s)",
+					replicasOrDefault(hpa.Spec.MinReplicas), hpa.Spec.MaxReplicas, hpa.Name)
+			}
+		}
+	}
+
+	pods, err := c.ListPods(ctx, namespace, metav1.FormatLabelSelector(selector))
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*corev1.Node)
+	for _, pod := range pods {
+		placement := PodPlacement{Pod: pod.Name, Node: pod.Spec.NodeName, Ready: podReady(pod)}
+		if pod.Spec.NodeName != "" {
+			node, ok := nodes[pod.Spec.NodeName]
+			if !ok {
+				// Nodes are cluster-scoped; without access to them the placement stays unknown
+				node, err = c.clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+				if err != nil {
+					node = nil
+				}
+				nodes[pod.Spec.NodeName] = node
+			}
+			if node != nil {
+				placement.Zone = node.Labels[corev1.LabelTopologyZone]
+				placement.Pool = nodePool(*node)
+				placement.Spot = spotNode(*node)
+			}
+		}
+		profile.Placement = append(profile.Placement, placement)
+	}
+	sort.Slice(profile.Placement, func(i, j int) bool {
+		return profile.Placement[i].Pod < profile.Placement[j].Pod
+	})
+
+	return profile, nil
+}
+
+// setRollingUpdate records the rolling update limits, resolving percentages against the
+// replicas the way the controllers do: maxUnavailable rounds down and maxSurge rounds up
+func (p *RolloutProfile) setRollingUpdate(maxUnavailable, maxSurge *intstr.IntOrString) {
+	defaultLimit := intstr.FromString("25%")
+	if maxUnavailable == nil {
+		maxUnavailable = &defaultLimit
+	}
+	if maxSurge == nil {
+		maxSurge = &defaultLimit
+	}
+	p.MaxUnavailable = maxUnavailable.String()
+	p.MaxSurge = maxSurge.String()
+	if value, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, int(p.Replicas), false); err == nil {
+		p.MaxUnavailablePods = int32(value)
+	}
+	if value, err := intstr.GetScaledValueFromIntOrPercent(maxSurge, int(p.Replicas), true); err == nil {
+		p.MaxSurgePods = int32(value)
+	}
+	// Controllers make progress with at least one pod when both round to zero
+	if p.MaxUnavailablePods == 0 && p.MaxSurgePods == 0 {
+		p.MaxUnavailablePods = 1
+	}
+}
+
+// Risks returns the rollout and eviction risks that follow directly from the profile
+func (p *RolloutProfile) Risks() []string {
+	var risks []string
+	daemonset := p.Kind == "daemonset"
+
+	if !daemonset && p.Replicas == 1 {
+		risks = append(risks, "single replica: node drains, evictions and node failures take the workload down")
+	}
+	if p.ReadyReplicas < p.Replicas {
+		risks = append(risks, fmt.Sprintf("only %d of %d replicas are ready", p.ReadyReplicas, p.Replicas))
+	}
+
+	switch p.Strategy {
+	case string(appsv1.RecreateDeploymentStrategyType):
+		risks = append(risks, "Recreate strategy: every rollout stops all pods before starting new ones")
+	case string(appsv1.OnDeleteStatefulSetStrategyType):
+		risks = append(risks, "OnDelete strategy: spec changes only roll out when pods are deleted by hand")
+	default:
+		if p.Replicas > 0 && p.MaxUnavailablePods >= p.Replicas {
+			risks = append(risks, fmt.Sprintf("maxUnavailable %s allows a rollout to take down all %d replicas at once", p.MaxUnavailable, p.Replicas))
+		}
+	}
+	if len(p.NoReadinessProbe) > 0 {
+		risks = append(risks, fmt.Sprintf("no readiness probe on %s: rollouts continue and traffic is sent before pods can serve", strings.Join(p.NoReadinessProbe, ", ")))
+	}
+
+	if !daemonset {
+		switch {
+		case len(p.Budgets) == 0 && p.Replicas > 1:
+			risks = append(risks, "no PodDisruptionBudget: a node drain or cluster upgrade can evict all replicas at once")
+		case len(p.Budgets) == 0:
+			risks = append(risks, "no PodDisruptionBudget")
+		case len(p.Budgets) > 1:
+			risks = append(risks, "more than one PodDisruptionBudget selects the pods: the eviction API refuses to evict them")
+		}
+		for _, budget := range p.Budgets {
+			if budget.DisruptionsAllowed == 0 && budget.CurrentHealthy >= budget.ExpectedPods {
+				risks = append(risks, fmt.Sprintf("PodDisruptionBudget %s allows no disruptions even with all pods healthy: node drains and upgrades block", budget.Name))
+			}
+		}
+
+		if p.Replicas > 1 && len(p.TopologySpread) == 0 && len(p.AntiAffinity) == 0 {
+			risks = append(risks, "no topology spread constraints or pod anti-affinity: replicas may be scheduled onto the same node or zone")
+		}
+	}
+
+	nodes := make(map[string]bool)
+	zones := make(map[string]bool)
+	spot := 0
+	for _, placement := range p.Placement {
+		if placement.Node != "" {
+			nodes[placement.Node] = true
+		}
+		if placement.Zone != "" {
+			zones[placement.Zone] = true
+		}
+		if placement.Spot {
+			spot++
+		}
+	}
+	if !daemonset && len(p.Placement) > 1 {
+		if len(nodes) == 1 {
+			risks = append(risks, fmt.Sprintf("all %d pods run on one node", len(p.Placement)))
+		} else if len(zones) == 1 {
+			risks = append(risks, fmt.Sprintf("all %d pods run in one zone", len(p.Placement)))
+		}
+	}
+	if spot == 1 && len(p.Placement) == 1 {
+		risks = append(risks, "the only pod runs on a spot or preemptible node, which can be reclaimed at short notice")
+	} else if spot > 0 && spot == len(p.Placement) {
+		risks = append(risks, fmt.Sprintf("all %d pods run on spot or preemptible nodes, which can be reclaimed at short notice", spot))
+	} else if spot > 0 {
+		risks = append(risks, fmt.Sprintf("%d of %d pods run on spot or preemptible nodes", spot, len(p.Placement)))
+	}
+
+	return risks
+}
+
+// replicasOrDefault returns the replicas of an optional field, which defaults to 1
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// selectsLabels reports whether a label selector matches a set of labels
+func selectsLabels(selector *metav1.LabelSelector, set labels.Set) bool {
+	parsed, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return parsed.Matches(set)
+}
+
+// spotNode reports whether a node is a spot or preemptible instance
+func spotNode(node corev1.Node) bool {
+	for label, value := range spotNodeLabels {
+		if strings.EqualFold(node.Labels[label], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// podReady reports whether the pod's Ready condition is true
+func podReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
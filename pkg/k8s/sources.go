@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+)
+
+// Optional data sources that collection goes without when the cluster does not serve them
+const (
+	// Live pod and node usage served by metrics-server
+	SourceMetrics = "metrics.k8s.io (metrics-server)"
+	// Kubernetes events
+	SourceEvents = "events"
+)
+
+// SkippedSource is an optional data source that was left out of a command's collection
+type SkippedSource struct {
+	// Data source, such as an API group or a custom resource
+	Source string `json:"source"`
+	// Why it was left out
+	Reason string `json:"reason"`
+}
+
+// skipped holds the data sources skipped since the last ResetSkippedSources, by source
+var (
+	skippedMu sync.Mutex
+	skipped   = map[string]string{}
+)
+
+// SkipSource records that an optional data source was left out because of err, so commands can
+// report the gap instead of failing. Only the first reason of each source is kept; an API the
+// cluster does not serve is reported as such rather than with the raw error.
+func SkipSource(source string, err error) {
+	skippedMu.Lock()
+	defer skippedMu.Unlock()
+	if _, ok := skipped[source]; ok {
+		return
+	}
+	reason := "not served by the cluster"
+	if err != nil && !IsAPIUnavailable(err) {
+		reason = err.Error()
+	}
+	skipped[source] = reason
+}
+
+// SkippedSources returns the data sources skipped since the last ResetSkippedSources, sorted
+func SkippedSources() []SkippedSource {
+	skippedMu.Lock()
+	defer skippedMu.Unlock()
+	sources := make([]SkippedSource, 0, len(skipped))
+	for source, reason := range skipped {
+		sources = append(sources, SkippedSource{Source: source, Reason: reason})
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Source < sources[j].Source })
+	return sources
+}
+
+// ResetSkippedSources forgets the skipped data sources, at the start of a command
+func ResetSkippedSources() {
+	skippedMu.Lock()
+	defer skippedMu.Unlock()
+	skipped = map[string]string{}
+}
+
+// IsAPIUnavailable reports whether an error means the cluster does not serve an API, such as an
+// uninstalled CRD or an aggregated API like metrics.k8s.io whose backing service is down
+func IsAPIUnavailable(err error) bool {
+	var discoveryErr *discovery.ErrGroupDiscoveryFailed
+	return meta.IsNoMatchError(err) || apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) ||
+		errors.As(err, &discoveryErr)
+}
@@ -0,0 +1,374 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// maxStatefulPVCEvents limits the events reported for a claim that is not bound
+const maxStatefulPVCEvents = 3
+
+// statefulEngines recognize the clustered databases and brokers a StatefulSet runs from its
+// images, and the log lines that show quorum, leader election and replication problems
+var statefulEngines = []struct {
+	name   string
+	images *regexp.Regexp
+	// Whether the engine needs a majority of members, so an even member count adds no tolerance
+	quorum bool
+	logs   *regexp.Regexp
+}{
+	{"etcd", regexp.MustCompile(`(^|/)etcd[:@-]|/etcd$`), true,
+		regexp.MustCompile(`(?i)lost leader|elected leader|leader changed|election|failed to reach the peer|cluster ID mismatch|has already been bootstrapped|database space exceeded|apply request took too long|rejected connection|raft`)},
+	{"zookeeper", regexp.MustCompile(`zookeeper`), true,
+		regexp.MustCompile(`(?i)leader election|LOOKING|QuorumPeer|Cannot open channel|Have smaller server identifier|Exception causing close of session|fsync-ing the write ahead log`)},
+	{"kafka", regexp.MustCompile(`kafka`), false,
+		regexp.MustCompile(`(?i)NotLeaderOrFollower|NotLeaderForPartition|under.?replicated|Shrinking ISR|Expanding ISR|InconsistentClusterId|controller.*(elected|failover|resigned)|session expired|Broker may not be available|Log directory .* failed`)},
+	{"postgres", regexp.MustCompile(`postgres|patroni|spilo|cloudnative-pg|timescale`), false,
+		regexp.MustCompile(`(?i)FATAL|PANIC|could not connect to the primary|WAL segment .* has already been removed|timeline|replication slot|promot|failover|lost the leader lock|acquired session lock as a leader|demot`)},
+	{"mysql", regexp.MustCompile(`mysql|mariadb|percona|galera`), false,
+		regexp.MustCompile(`(?i)\[ERROR\]|wsrep.*(non-primary|failed)|Group Replication.*(error|unreachable)|split.?brain|replica.*(stopped|error)`)},
+	{"mongodb", regexp.MustCompile(`mongo`), false,
+		regexp.MustCompile(`(?i)election|stepping down|no primary|replSet.*(error|unreachable)|heartbeat failed|RECOVERING|ROLLBACK`)},
+	{"redis", regexp.MustCompile(`redis|valkey`), false,
+		regexp.MustCompile(`(?i)failover|MASTER <-> REPLICA sync.*(error|failed)|Error condition on socket|cluster.*(fail|state changed)|MISCONF`)},
+	{"elasticsearch", regexp.MustCompile(`elasticsearch|opensearch`), true,
+		regexp.MustCompile(`(?i)master not discovered|no master|cluster-manager not discovered|ClusterBlockException|high disk watermark|flood stage|unassigned`)},
+	{"rabbitmq", regexp.MustCompile(`rabbitmq`), true,
+		regexp.MustCompile(`(?i)partial partition|network partition|node down|mnesia.*(inconsistent|timeout)|quorum queue.*(leader|election)`)},
+	{"cassandra", regexp.MustCompile(`cassandra|scylla`), false,
+		regexp.MustCompile(`(?i)UnavailableException|is now DOWN|Gossip|hints|Unable to gossip|SchemaDisagreement`)},
+}
+
+// statefulGenericLogs matches log lines that point to storage or cluster membership problems in
+// any stateful workload
+var statefulGenericLogs = regexp.MustCompile(`(?i)no space left on device|read-only file system|split.?brain|quorum|permission denied.*(data|/var/lib)|corrupt`)
+
+// StatefulReport describes a StatefulSet as a clustered data store: its rollout, its pods by
+// ordinal with their volume claims, the headless service that gives them stable DNS names, the
+// operator managing it, and the problems found
+type StatefulReport struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Clustered engine recognized from the images, such as etcd, kafka or postgres
+	Engine string `json:"engine,omitempty"`
+	// Owner of the StatefulSet when an operator manages it, as Kind/name
+	Operator            string `json:"operator,omitempty"`
+	Replicas            int32  `json:"replicas"`
+	ReadyReplicas       int32  `json:"readyReplicas"`
+	PodManagementPolicy string `json:"podManagementPolicy"`
+	UpdateStrategy      string `json:"updateStrategy"`
+	Partition           int32  `json:"partition,omitempty"`
+	CurrentRevision     string `json:"currentRevision,omitempty"`
+	UpdateRevision      string `json:"updateRevision,omitempty"`
+	ServiceName         string `json:"serviceName"`
+	// Whether the governing service exists and is headless
+	ServiceHeadless bool          `json:"serviceHeadless"`
+	ServiceMissing  bool          `json:"serviceMissing,omitempty"`
+	Pods            []StatefulPod `json:"pods"`
+	// Live CPU and memory usage of the member containers, when metrics-server runs
+	Usage    []ContainerUsage `json:"usage,omitempty"`
+	Findings []string         `json:"findings"`
+}
+
+// StatefulPod is the pod of an ordinal, with its DNS record and volume claims
+type StatefulPod struct {
+	Ordinal int    `json:"ordinal"`
+	Name    string `json:"name"`
+	Missing bool   `json:"missing,omitempty"`
+	Phase   string `json:"phase,omitempty"`
+	Ready   bool   `json:"ready"`
+	Node    string `json:"node,omitempty"`
+	// Whether the pod runs the update revision of the StatefulSet
+	Updated  bool  `json:"updated"`
+	Restarts int32 `json:"restarts"`
+	// Reason a container is waiting or last terminated, or why the pod cannot be scheduled
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Stable DNS name of the pod, and whether the headless service publishes it
+	DNSName      string        `json:"dnsName"`
+	DNSPublished bool          `json:"dnsPublished"`
+	Claims       []StatefulPVC `json:"claims"`
+	// Log lines with quorum, replication or storage problems, when collected
+	Logs []string `json:"logs,omitempty"`
+}
+
+// StatefulPVC is a volume claim of an ordinal, created from a volumeClaimTemplate
+type StatefulPVC struct {
+	Name         string `json:"name"`
+	Phase        string `json:"phase"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Capacity     string `json:"capacity,omitempty"`
+	// Recent events of a claim that is not bound, such as ProvisioningFailed
+	Events []string `json:"events,omitempty"`
+}
+
+// LogPattern returns the pattern of log lines worth reporting for the StatefulSet's engine
+func (r *StatefulReport) LogPattern() *regexp.Regexp {
+	for _, engine := range statefulEngines {
+		if engine.name == r.Engine {
+			return regexp.MustCompile(engine.logs.String() + "|" + statefulGenericLogs.String())
+		}
+	}
+	return statefulGenericLogs
+}
+
+// GetStatefulReport collects the rollout, pods, volume claims and headless service of a
+// StatefulSet, and finds why its members are missing, not ready or not reachable
+func (c *Client) GetStatefulReport(ctx context.Context, namespace, name string) (*StatefulReport, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+	statefulSet, err := c.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting statefulset %s: %w", name, err)
+	}
+
+	report := &StatefulReport{
+		Name:                statefulSet.Name,
+		Namespace:           namespace,
+		Replicas:            1,
+		ReadyReplicas:       statefulSet.Status.ReadyReplicas,
+		PodManagementPolicy: string(statefulSet.Spec.PodManagementPolicy),
+		UpdateStrategy:      string(statefulSet.Spec.UpdateStrategy.Type),
+		CurrentRevision:     statefulSet.Status.CurrentRevision,
+		UpdateRevision:      statefulSet.Status.UpdateRevision,
+		ServiceName:         statefulSet.Spec.ServiceName,
+		Pods:                []StatefulPod{},
+		Findings:            []string{},
+	}
+	if statefulSet.Spec.Replicas != nil {
+		report.Replicas = *statefulSet.Spec.Replicas
+	}
+	if report.PodManagementPolicy == "" {
+		report.PodManagementPolicy = string(appsv1.OrderedReadyPodManagement)
+	}
+	if report.UpdateStrategy == "" {
+		report.UpdateStrategy = string(appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		report.Partition = *rollingUpdate.Partition
+	}
+	for _, ref := range statefulSet.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			report.Operator = ref.Kind + "/" + ref.Name
+		}
+	}
+	report.Engine = statefulEngine(statefulSet.Spec.Template.Spec.Containers)
+
+	published := c.describeStatefulService(ctx, report, statefulSet)
+	for ordinal := 0; ordinal < int(report.Replicas); ordinal++ {
+		pod := c.statefulPod(ctx, statefulSet, ordinal)
+		pod.DNSPublished = published[pod.Name]
+		report.Pods = append(report.Pods, pod)
+	}
+
+	report.Findings = append(report.Findings, report.findings()...)
+	return report, nil
+}
+
+// statefulEngine recognizes the clustered engine a StatefulSet runs from its container images
+func statefulEngine(containers []corev1.Container) string {
+	for _, container := range containers {
+		image := strings.ToLower(container.Image)
+		for _, engine := range statefulEngines {
+			if engine.images.MatchString(image) {
+				return engine.name
+			}
+		}
+	}
+	return ""
+}
+
+// describeStatefulService checks the governing service of a StatefulSet and returns the pods
+// its endpoints publish a DNS record for
+func (c *Client) describeStatefulService(ctx context.Context, report *StatefulReport, statefulSet *appsv1.StatefulSet) map[string]bool {
+	published := make(map[string]bool)
+	if report.ServiceName == "" {
+		report.ServiceMissing = true
+		return published
+	}
+	service, err := c.clientset.CoreV1().Services(report.Namespace).Get(ctx, report.ServiceName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		report.ServiceMissing = true
+		return published
+	}
+	if err != nil {
+		return published
+	}
+	report.ServiceHeadless = service.Spec.ClusterIP == corev1.ClusterIPNone
+	if len(service.Spec.Selector) > 0 && !labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(statefulSet.Spec.Template.Labels)) {
+		report.Findings = append(report.Findings, fmt.Sprintf("service %s selects %s, which does not match the pod labels, so no member gets a DNS record", service.Name, labels.Set(service.Spec.Selector)))
+	}
+
+	endpoints, err := c.clientset.CoreV1().Endpoints(report.Namespace).Get(ctx, report.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return published
+	}
+	for _, subset := range endpoints.Subsets {
+		// Not ready addresses resolve too when the service publishes them, which peers often need
+		// to discover each other before they are ready
+		addresses := subset.Addresses
+		if service.Spec.PublishNotReadyAddresses {
+			addresses = append(append([]corev1.EndpointAddress{}, addresses...), subset.NotReadyAddresses...)
+		}
+		for _, address := range addresses {
+			if address.TargetRef != nil {
+				published[address.TargetRef.Name] = true
+			}
+		}
+	}
+	return published
+}
+
+// statefulPod describes the pod of an ordinal and its volume claims
+func (c *Client) statefulPod(ctx context.Context, statefulSet *appsv1.StatefulSet, ordinal int) StatefulPod {
+	name := fmt.Sprintf("%s-%d", statefulSet.Name, ordinal)
+	pod := StatefulPod{
+		Ordinal: ordinal,
+		Name:    name,
+		DNSName: fmt.Sprintf("%s.%s.%s.svc", name, statefulSet.Spec.ServiceName, statefulSet.Namespace),
+		Claims:  []StatefulPVC{},
+	}
+
+	current, err := c.clientset.CoreV1().Pods(statefulSet.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		pod.Missing = true
+	} else {
+		pod.Phase = string(current.Status.Phase)
+		pod.Node = current.Spec.NodeName
+		pod.Updated = statefulSet.Status.UpdateRevision == "" || current.Labels[appsv1.ControllerRevisionHashLabelKey] == statefulSet.Status.UpdateRevision
+		for _, condition := range current.Status.Conditions {
+			switch {
+			case condition.Type == corev1.PodReady:
+				pod.Ready = condition.Status == corev1.ConditionTrue
+			case condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse:
+				pod.Reason, pod.Message = condition.Reason, condition.Message
+			}
+		}
+		for _, status := range append(append([]corev1.ContainerStatus{}, current.Status.InitContainerStatuses...), current.Status.ContainerStatuses...) {
+			pod.Restarts += status.RestartCount
+			if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "PodInitializing" {
+				pod.Reason, pod.Message = waiting.Reason, strings.TrimSpace(waiting.Message)
+			} else if terminated := status.LastTerminationState.Terminated; terminated != nil && pod.Reason == "" {
+				pod.Reason = terminated.Reason
+			}
+		}
+	}
+
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		claim := StatefulPVC{Name: template.Name + "-" + name, Phase: "Missing"}
+		pvc, err := c.clientset.CoreV1().PersistentVolumeClaims(statefulSet.Namespace).Get(ctx, claim.Name, metav1.GetOptions{})
+		if err == nil {
+			claim.Phase = string(pvc.Status.Phase)
+			if pvc.Spec.StorageClassName != nil {
+				claim.StorageClass = *pvc.Spec.StorageClassName
+			}
+			if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+				claim.Capacity = capacity.String()
+			}
+		}
+		if claim.Phase != string(corev1.ClaimBound) {
+			claim.Events = c.recentEvents(ctx, statefulSet.Namespace, "PersistentVolumeClaim", claim.Name, maxStatefulPVCEvents)
+		}
+		pod.Claims = append(pod.Claims, claim)
+	}
+	return pod
+}
+
+// recentEvents returns the most recent events of an object as "Type Reason: message"
+func (c *Client) recentEvents(ctx context.Context, namespace, kind, name string, limit int) []string {
+	list, err := c.ListEvents(ctx, namespace, name)
+	if err != nil {
+		SkipSource(SourceEvents, err)
+		return nil
+	}
+	var events []corev1.Event
+	for _, event := range list {
+		if event.InvolvedObject.Kind == kind && event.InvolvedObject.Name == name {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return eventTime(events[i]).Before(eventTime(events[j])) })
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	var lines []string
+	for _, event := range events {
+		lines = append(lines, fmt.Sprintf("%s %s: %s", event.Type, event.Reason, strings.TrimSpace(event.Message)))
+	}
+	return lines
+}
+
+// findings reports the ordinals blocking an ordered rollout, stuck updates, claims that are not
+// bound, DNS records that are not published, and member counts that weaken quorum
+func (r *StatefulReport) findings() []string {
+	var findings []string
+	if r.ServiceMissing {
+		findings = append(findings, fmt.Sprintf("governing service %q does not exist, so members have no stable DNS names to find each other", r.ServiceName))
+	} else if !r.ServiceHeadless {
+		findings = append(findings, fmt.Sprintf("service %s is not headless (clusterIP: None), so it publishes no per-pod DNS records", r.ServiceName))
+	}
+
+	ordered := r.PodManagementPolicy == string(appsv1.OrderedReadyPodManagement)
+	for i, pod := range r.Pods {
+		switch {
+		case pod.Missing && ordered && i > 0 && !r.Pods[i-1].Ready:
+			findings = append(findings, fmt.Sprintf("%s is not created because %s is not ready: with OrderedReady pod management, ordinals start one at a time", pod.Name, r.Pods[i-1].Name))
+		case pod.Missing:
+			findings = append(findings, fmt.Sprintf("%s does not exist", pod.Name))
+		case !pod.Ready:
+			reason := pod.Phase
+			if pod.Reason != "" {
+				reason = pod.Reason
+			}
+			finding := fmt.Sprintf("%s is not ready (%s)", pod.Name, reason)
+			if pod.Message != "" {
+				finding += ": " + pod.Message
+			}
+			findings = append(findings, finding)
+		case pod.Restarts > 0:
+			findings = append(findings, fmt.Sprintf("%s restarted %d times", pod.Name, pod.Restarts))
+		}
+		for _, claim := range pod.Claims {
+			if claim.Phase != string(corev1.ClaimBound) {
+				findings = append(findings, fmt.Sprintf("claim %s of %s is %s, so the pod cannot start", claim.Name, pod.Name, claim.Phase))
+			}
+		}
+		if !pod.Missing && !r.ServiceMissing && r.ServiceHeadless && !pod.DNSPublished && pod.Phase == string(corev1.PodRunning) {
+			findings = append(findings, fmt.Sprintf("%s has no DNS record yet; peers that resolve it before it is ready need publishNotReadyAddresses on service %s", pod.DNSName, r.ServiceName))
+		}
+	}
+
+	if r.UpdateRevision != "" && r.CurrentRevision != r.UpdateRevision {
+		var outdated []string
+		for _, pod := range r.Pods {
+			if !pod.Missing && !pod.Updated {
+				outdated = append(outdated, pod.Name)
+			}
+		}
+		switch {
+		case r.UpdateStrategy == string(appsv1.OnDeleteStatefulSetStrategyType) && len(outdated) > 0:
+			findings = append(findings, fmt.Sprintf("the update strategy is OnDelete, so %s keep the old revision until they are deleted", strings.Join(outdated, ", ")))
+		case r.Partition > 0 && len(outdated) > 0:
+			findings = append(findings, fmt.Sprintf("the rolling update is partitioned at %d, so ordinals below it (%s) keep the old revision", r.Partition, strings.Join(outdated, ", ")))
+		case len(outdated) > 0:
+			findings = append(findings, fmt.Sprintf("the rolling update is in progress or stuck, from the highest ordinal down: %s still run the old revision", strings.Join(outdated, ", ")))
+		}
+	}
+
+	for _, engine := range statefulEngines {
+		if engine.name == r.Engine && engine.quorum && r.Replicas > 1 && r.Replicas%2 == 0 {
+			findings = append(findings, fmt.Sprintf("%s needs a majority of members and runs %d replicas; an odd count tolerates as many failures with one member less", r.Engine, r.Replicas))
+		}
+	}
+	return findings
+}
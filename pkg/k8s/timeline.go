@@ -0,0 +1,277 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kube-ai/pkg/k8s/logs"
+)
+
+// Kinds of timeline entries
+const (
+	TimelineRestart = "Restart"
+	TimelineOOMKill = "OOMKill"
+	TimelineRollout = "Rollout"
+	TimelineScaling = "Scaling"
+	TimelineErrors  = "Errors"
+)
+
+// maxTimelineEntries caps the number of timeline entries kept, preferring the most recent
+const maxTimelineEntries = 50
+
+// timelineBuckets is the number of intervals the error rate of the logs is counted over; each is
+// at least a minute long
+const timelineBuckets = 20
+
+// revisionAnnotation is the revision a deployment controller gives each of its ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// TimelineEntry is a point of the timeline of a workload
+type TimelineEntry struct {
+	Time time.Time `json:"time"`
+	// Restart, OOMKill, Rollout, Scaling or Errors
+	Kind   string `json:"kind"`
+	Object string `json:"object"`
+	// Human-readable details
+	Message string `json:"message"`
+}
+
+// BuildTimeline merges the container restarts and OOM kills of a workload's lifecycle, its rollouts,
+// the scaling of its HorizontalPodAutoscaler and the error rate of its logs into one chronological
+// view, so what happened first is visible at a glance
+func (c *Client) BuildTimeline(ctx context.Context, resourceType, name, namespace string, lifecycle *WorkloadLifecycle, entries []logs.LogEntry, start, end time.Time) ([]TimelineEntry, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+	from := start.Add(-lifecycleLookback)
+	inWindow := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(from) && !t.After(end)
+	}
+
+	var timeline []TimelineEntry
+	if lifecycle != nil {
+		timeline = append(timeline, lifecycleTimeline(lifecycle.Events)...)
+	}
+
+	kind := ""
+	switch strings.ToLower(resourceType) {
+	case "deployment", "deployments", "deploy":
+		kind = "Deployment"
+		rollouts, err := c.deploymentRollouts(ctx, namespace, name, inWindow)
+		if err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, rollouts...)
+	case "statefulset", "statefulsets", "sts":
+		kind = "StatefulSet"
+	case "daemonset", "daemonsets", "ds":
+		kind = "DaemonSet"
+	}
+	if kind == "StatefulSet" || kind == "DaemonSet" {
+		rollouts, err := c.controllerRevisions(ctx, namespace, kind, name, inWindow)
+		if err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, rollouts...)
+	}
+	if kind != "" {
+		scaling, err := c.autoscalerTimeline(ctx, namespace, kind, name, inWindow)
+		if err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, scaling...)
+	}
+
+	timeline = append(timeline, errorRateTimeline(entries, resourceType+"/"+name)...)
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+	if len(timeline) > maxTimelineEntries {
+		timeline = timeline[len(timeline)-maxTimelineEntries:]
+	}
+	return timeline, nil
+}
+
+// lifecycleTimeline takes the container terminations and back-offs from lifecycle events
+func lifecycleTimeline(events []LifecycleEvent) []TimelineEntry {
+	var timeline []TimelineEntry
+	for _, event := range events {
+		entry := TimelineEntry{Time: event.Time, Kind: TimelineRestart, Object: event.Object, Message: event.Message}
+		switch event.Reason {
+		case "Terminated":
+			if strings.Contains(event.Message, "OOMKilled") {
+				entry.Kind = TimelineOOMKill
+			}
+		case "OOMKilling":
+			entry.Kind = TimelineOOMKill
+		case "BackOff":
+			if event.Count > 1 {
+				entry.Message = fmt.Sprintf("%s (x%d)", event.Message, event.Count)
+			}
+		default:
+			continue
+		}
+		timeline = append(timeline, entry)
+	}
+	return timeline
+}
+
+// deploymentRollouts returns the ReplicaSets of a deployment created in the window, one for each
+// revision rolled out
+func (c *Client) deploymentRollouts(ctx context.Context, namespace, name string, inWindow func(time.Time) bool) ([]TimelineEntry, error) {
+	replicaSets, err := c.clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing replicasets: %w", err)
+	}
+	var timeline []TimelineEntry
+	for _, replicaSet := range replicaSets.Items {
+		if !ownedBy(replicaSet.OwnerReferences, "Deployment", name) || !inWindow(replicaSet.CreationTimestamp.Time) {
+			continue
+		}
+		timeline = append(timeline, TimelineEntry{
+			Time:    replicaSet.CreationTimestamp.Time,
+			Kind:    TimelineRollout,
+			Object:  "replicaset/" + replicaSet.Name,
+			Message: fmt.Sprintf("revision %s rolled out with %s", valueOrUnknown(replicaSet.Annotations[revisionAnnotation]), strings.Join(templateImages(replicaSet.Spec.Template.Spec.Containers), ", ")),
+		})
+	}
+	return timeline, nil
+}
+
+// controllerRevisions returns the revisions of a statefulset or daemonset created in the window
+func (c *Client) controllerRevisions(ctx context.Context, namespace, kind, name string, inWindow func(time.Time) bool) ([]TimelineEntry, error) {
+	revisions, err := c.clientset.AppsV1().ControllerRevisions(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing controller revisions: %w", err)
+	}
+	var timeline []TimelineEntry
+	for _, revision := range revisions.Items {
+		if !ownedBy(revision.OwnerReferences, kind, name) || !inWindow(revision.CreationTimestamp.Time) {
+			continue
+		}
+		timeline = append(timeline, TimelineEntry{
+			Time:    revision.CreationTimestamp.Time,
+			Kind:    TimelineRollout,
+			Object:  "controllerrevision/" + revision.Name,
+			Message: fmt.Sprintf("revision %d rolled out", revision.Revision),
+		})
+	}
+	return timeline, nil
+}
+
+// autoscalerTimeline returns the rescales of the HorizontalPodAutoscalers targeting a workload
+func (c *Client) autoscalerTimeline(ctx context.Context, namespace, kind, name string, inWindow func(time.Time) bool) ([]TimelineEntry, error) {
+	// Autoscaling is optional context
+	autoscalers, err := c.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil
+	}
+	var timeline []TimelineEntry
+	for _, hpa := range autoscalers.Items {
+		if hpa.Spec.ScaleTargetRef.Kind != kind || hpa.Spec.ScaleTargetRef.Name != name {
+			continue
+		}
+		events, err := c.ListEvents(ctx, namespace, hpa.Name)
+		if err != nil {
+			SkipSource(SourceEvents, err)
+			continue
+		}
+		for _, event := range events {
+			if event.InvolvedObject.Kind != "HorizontalPodAutoscaler" || event.InvolvedObject.Name != hpa.Name || !inWindow(eventTime(event)) {
+				continue
+			}
+			if event.Reason != "SuccessfulRescale" && event.Type != corev1.EventTypeWarning {
+				continue
+			}
+			timeline = append(timeline, TimelineEntry{
+				Time:    eventTime(event),
+				Kind:    TimelineScaling,
+				Object:  "horizontalpodautoscaler/" + hpa.Name,
+				Message: strings.TrimSpace(event.Message),
+			})
+		}
+	}
+	return timeline, nil
+}
+
+// errorRateTimeline counts the error lines of the logs over equal intervals and returns the
+// intervals that have errors
+func errorRateTimeline(entries []logs.LogEntry, object string) []TimelineEntry {
+	var first, last time.Time
+	for _, entry := range entries {
+		if entry.Timestamp.IsZero() {
+			continue
+		}
+		if first.IsZero() || entry.Timestamp.Before(first) {
+			first = entry.Timestamp
+		}
+		if entry.Timestamp.After(last) {
+			last = entry.Timestamp
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	interval := (last.Sub(first) / timelineBuckets).Truncate(time.Minute)
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	lines := make(map[time.Time]int)
+	errorLines := make(map[time.Time]int)
+	for _, entry := range entries {
+		if entry.Timestamp.IsZero() {
+			continue
+		}
+		bucket := first.Add(entry.Timestamp.Sub(first).Truncate(interval))
+		lines[bucket]++
+		if entry.LogLevel == "ERROR" || entry.LogLevel == "FATAL" {
+			errorLines[bucket]++
+		}
+	}
+
+	var timeline []TimelineEntry
+	for bucket, count := range errorLines {
+		timeline = append(timeline, TimelineEntry{
+			Time:    bucket,
+			Kind:    TimelineErrors,
+			Object:  object,
+			Message: fmt.Sprintf("%d errors in %d lines (%d%%) over %s", count, lines[bucket], count*100/lines[bucket], interval),
+		})
+	}
+	return timeline
+}
+
+// ownedBy reports whether owner references name the given controller
+func ownedBy(references []metav1.OwnerReference, kind, name string) bool {
+	for _, ref := range references {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// templateImages returns the images of the containers of a pod template
+func templateImages(containers []corev1.Container) []string {
+	var images []string
+	for _, container := range containers {
+		images = append(images, container.Image)
+	}
+	return images
+}
+
+// valueOrUnknown returns value, or "unknown" when it is empty
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,403 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/manifest"
+)
+
+// Severity of findings
+const (
+	// The target release no longer serves or honors what the object uses
+	SeverityRemoved = "removed"
+	// The target release still serves it, but a later one will not
+	SeverityDeprecated = "deprecated"
+)
+
+// lastAppliedAnnotation records the manifest kubectl last applied, including its apiVersion
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Finding is a deprecated API version or field used by a live object or manifest file
+type Finding struct {
+	// Where the object was found: a file and line, or namespace/kind/name in the cluster
+	Source     string `json:"source"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	APIVersion string `json:"apiVersion"`
+	// Field or annotation that is deprecated; empty for a deprecated API version
+	Field       string `json:"field,omitempty"`
+	Severity    string `json:"severity"`
+	Problem     string `json:"problem"`
+	Replacement string `json:"replacement,omitempty"`
+	RemovedIn   string `json:"removedIn,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+
+	// YAML of the manifest document, for findings in files
+	Manifest string `json:"-"`
+}
+
+func (f Finding) String() string {
+	text := fmt.Sprintf("%s (%s %s): %s", f.Source, f.APIVersion, f.Kind, f.Problem)
+	if f.Replacement != "" {
+		text += "; use " + f.Replacement
+	}
+	if f.Notes != "" {
+		text += " (" + f.Notes + ")"
+	}
+	return text
+}
+
+// severity returns the severity of something deprecated and possibly removed for the target
+// release, or "" when the target is not affected
+func severity(deprecatedIn, removedIn, target Version) string {
+	switch {
+	case removedIn.AtMost(target):
+		return SeverityRemoved
+	case deprecatedIn.AtMost(target):
+		return SeverityDeprecated
+	}
+	return ""
+}
+
+// CheckObject checks an object declared with apiVersion against the target release: its API
+// version, deprecated annotations, node labels in scheduling constraints, and removed fields
+func CheckObject(source string, obj map[string]interface{}, apiVersion string, target Version) []Finding {
+	u := unstructured.Unstructured{Object: obj}
+	base := Finding{
+		Source:     source,
+		Kind:       u.GetKind(),
+		Name:       u.GetName(),
+		Namespace:  u.GetNamespace(),
+		APIVersion: apiVersion,
+	}
+
+	var findings []Finding
+	add := func(field, severity, problem, replacement string, removedIn Version, notes string) {
+		finding := base
+		finding.Field = field
+		finding.Severity = severity
+		finding.Problem = problem
+		finding.Replacement = replacement
+		finding.RemovedIn = removedIn.String()
+		finding.Notes = notes
+		findings = append(findings, finding)
+	}
+
+	if api := FindDeprecatedAPI(apiVersion, base.Kind); api != nil {
+		switch severity(api.DeprecatedIn, api.RemovedIn, target) {
+		case SeverityRemoved:
+			add("", SeverityRemoved, fmt.Sprintf("%s was removed in %s", api.APIVersion, api.RemovedIn), api.Replacement, api.RemovedIn, api.Notes)
+		case SeverityDeprecated:
+			add("", SeverityDeprecated, fmt.Sprintf("%s is deprecated and will be removed in %s", api.APIVersion, api.RemovedIn), api.Replacement, api.RemovedIn, api.Notes)
+		}
+	}
+
+	templatePath, template := podTemplate(obj)
+	annotationSources := map[string]map[string]string{"metadata.annotations": u.GetAnnotations()}
+	if template != nil {
+		templateAnnotations, _, _ := unstructured.NestedStringMap(template, "metadata", "annotations")
+		annotationSources[joinField(templatePath, "metadata.annotations")] = templateAnnotations
+	}
+	for path, annotations := range annotationSources {
+		isTemplate := path != "metadata.annotations" || base.Kind == "Pod"
+		for key := range annotations {
+			for _, annotation := range deprecatedAnnotations {
+				if !matchesAnnotation(annotation, key, base.Kind, isTemplate) {
+					continue
+				}
+				field := fmt.Sprintf("%s[%s]", path, key)
+				switch severity(annotation.DeprecatedIn, annotation.RemovedIn, target) {
+				case SeverityRemoved:
+					add(field, SeverityRemoved, fmt.Sprintf("annotation %s is ignored since %s", key, annotation.RemovedIn), annotation.Replacement, annotation.RemovedIn, "")
+				case SeverityDeprecated:
+					add(field, SeverityDeprecated, fmt.Sprintf("annotation %s is deprecated", key), annotation.Replacement, annotation.RemovedIn, "")
+				}
+			}
+		}
+	}
+
+	if template != nil {
+		spec, _, _ := unstructured.NestedMap(template, "spec")
+		for _, label := range deprecatedLabels {
+			if !label.DeprecatedIn.AtMost(target) {
+				continue
+			}
+			for _, field := range labelReferences(spec, label.Key) {
+				add(joinField(templatePath, "spec."+field), SeverityDeprecated,
+					fmt.Sprintf("node label %s is deprecated", label.Key), label.Replacement, Version{}, "")
+			}
+		}
+	}
+
+	if base.Kind == "Service" {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj, "spec", "topologyKeys"); found && v(22).AtMost(target) {
+			add("spec.topologyKeys", SeverityRemoved, "spec.topologyKeys was removed in 1.22",
+				"topology aware routing (annotation service.kubernetes.io/topology-mode: Auto) or spec.trafficDistribution", v(22), "")
+		}
+	}
+
+	return findings
+}
+
+// matchesAnnotation reports whether an annotation key on an object of kind is deprecated
+func matchesAnnotation(annotation deprecatedAnnotation, key, kind string, isTemplate bool) bool {
+	matches := key == annotation.Key ||
+		(strings.HasSuffix(annotation.Key, "/") && strings.HasPrefix(key, annotation.Key))
+	if !matches {
+		return false
+	}
+	if len(annotation.Kinds) == 0 {
+		return isTemplate
+	}
+	for _, k := range annotation.Kinds {
+		if k == kind && !isTemplate {
+			return true
+		}
+	}
+	return false
+}
+
+// podTemplate returns the path and content of the pod (template) of a workload, or nil
+func podTemplate(obj map[string]interface{}) (string, map[string]interface{}) {
+	kind, _, _ := unstructured.NestedString(obj, "kind")
+	switch kind {
+	case "Pod":
+		return "", obj
+	case "CronJob":
+		template, _, _ := unstructured.NestedMap(obj, "spec", "jobTemplate", "spec", "template")
+		return "spec.jobTemplate.spec.template", template
+	case "PodTemplate":
+		template, _, _ := unstructured.NestedMap(obj, "template")
+		return "template", template
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		template, _, _ := unstructured.NestedMap(obj, "spec", "template")
+		return "spec.template", template
+	}
+	return "", nil
+}
+
+// labelReferences returns the fields of a pod spec that select nodes or topology by a label:
+// node selectors, affinity match expressions and topology keys
+func labelReferences(spec map[string]interface{}, label string) []string {
+	var fields []string
+	if selector, _, _ := unstructured.NestedStringMap(spec, "nodeSelector"); selector != nil {
+		if _, ok := selector[label]; ok {
+			fields = append(fields, "nodeSelector")
+		}
+	}
+
+	var walk func(value interface{}, path string)
+	walk = func(value interface{}, path string) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if (key == "key" || key == "topologyKey") && item == label {
+					fields = append(fields, joinField(path, key))
+					continue
+				}
+				walk(item, joinField(path, key))
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(spec["affinity"], "affinity")
+	walk(spec["topologySpreadConstraints"], "topologySpreadConstraints")
+
+	sort.Strings(fields)
+	return fields
+}
+
+// joinField appends a field path to a parent path
+func joinField(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+// scannedKinds are the kinds ScanCluster lists: every kind with a deprecated API version, and the
+// kinds whose fields and pod templates are checked
+func scannedKinds() []schema.GroupKind {
+	seen := make(map[schema.GroupKind]bool)
+	var kinds []schema.GroupKind
+	add := func(group, kind string) {
+		groupKind := schema.GroupKind{Group: group, Kind: kind}
+		if !seen[groupKind] {
+			seen[groupKind] = true
+			kinds = append(kinds, groupKind)
+		}
+	}
+
+	for _, api := range DeprecatedAPIs {
+		// List objects through the current API group, such as Ingresses through networking.k8s.io
+		group := api.Group()
+		if gv, err := schema.ParseGroupVersion(api.Replacement); err == nil && api.Replacement != "" {
+			group = gv.Group
+		}
+		add(group, api.Kind)
+	}
+	for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"} {
+		add("apps", kind)
+	}
+	add("batch", "Job")
+	add("batch", "CronJob")
+	add("", "Pod")
+	add("", "Service")
+	add("networking.k8s.io", "Ingress")
+	return kinds
+}
+
+// ScanCluster checks the live objects of the cluster against the target release, in namespace or
+// in all namespaces when namespace is empty. Objects are checked by the API version they were
+// last applied with, falling back to the version the cluster serves them in. It returns the
+// findings and the kinds that could not be listed, such as for lack of permissions.
+func ScanCluster(ctx context.Context, client *k8s.Client, namespace string, target Version) ([]Finding, []string, error) {
+	var findings []Finding
+	var skipped []string
+
+	for _, groupKind := range scannedKinds() {
+		objects, err := client.ListObjects(ctx, groupKind, namespace)
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) {
+			skipped = append(skipped, groupKind.String())
+			continue
+		}
+		if err != nil {
+			return findings, skipped, err
+		}
+
+		for _, obj := range objects {
+			// Pods, ReplicaSets and Jobs of controllers are checked through their owner's template
+			switch groupKind.Kind {
+			case "Pod", "ReplicaSet", "Job":
+				if isControlled(obj) {
+					continue
+				}
+			}
+
+			apiVersion := obj.GetAPIVersion()
+			if lastApplied := obj.GetAnnotations()[lastAppliedAnnotation]; lastApplied != "" {
+				var applied struct {
+					APIVersion string `json:"apiVersion"`
+				}
+				if json.Unmarshal([]byte(lastApplied), &applied) == nil && applied.APIVersion != "" {
+					apiVersion = applied.APIVersion
+				}
+			}
+
+			source := strings.ToLower(obj.GetKind()) + "/" + obj.GetName()
+			if obj.GetNamespace() != "" {
+				source = obj.GetNamespace() + "/" + source
+			}
+			findings = append(findings, CheckObject(source, obj.Object, apiVersion, target)...)
+		}
+	}
+
+	SortFindings(findings)
+	return findings, skipped, nil
+}
+
+// isControlled reports whether an object is managed by a controller
+func isControlled(obj unstructured.Unstructured) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Controller != nil && *owner.Controller {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanFiles checks the manifests in files and directories (searched for .yaml, .yml and .json
+// files) against the target release. It returns the findings and the files that could not be
+// parsed, such as Helm templates, with the reason.
+func ScanFiles(paths []string, target Version) ([]Finding, []string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, file)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var findings []Finding
+	var skipped []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return findings, skipped, err
+		}
+		documents, err := manifest.Parse(data)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+
+		for i := range documents {
+			obj, err := documents[i].Object()
+			if err != nil || documents[i].Kind == "" {
+				continue
+			}
+			apiVersion, _, _ := unstructured.NestedString(obj, "apiVersion")
+			source := fmt.Sprintf("%s:%d", file, documents[i].Line)
+
+			documentFindings := CheckObject(source, obj, apiVersion, target)
+			if len(documentFindings) > 0 {
+				if data, err := documents[i].YAML(); err == nil {
+					for j := range documentFindings {
+						documentFindings[j].Manifest = string(data)
+					}
+				}
+			}
+			findings = append(findings, documentFindings...)
+		}
+	}
+
+	SortFindings(findings)
+	return findings, skipped, nil
+}
+
+// SortFindings orders findings by severity, removed first, then by source and field
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity == SeverityRemoved
+		}
+		if findings[i].Source != findings[j].Source {
+			return findings[i].Source < findings[j].Source
+		}
+		return findings[i].Field < findings[j].Field
+	})
+}
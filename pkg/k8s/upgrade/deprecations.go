@@ -0,0 +1,207 @@
+package upgrade
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a Kubernetes minor release, such as 1.31
+type Version struct {
+	Major int
+	Minor int
+}
+
+// ParseVersion parses a Kubernetes version such as 1.31, v1.31 or v1.31.2-gke.100
+func ParseVersion(value string) (Version, error) {
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(value), "v"), ".", 3)
+	if len(parts) < 2 {
+		return Version{}, fmt.Errorf("invalid Kubernetes version %q, expected e.g. 1.31", value)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid Kubernetes version %q, expected e.g. 1.31", value)
+	}
+	// Minor versions of some distributions carry a suffix, such as 31+
+	minor, err := strconv.Atoi(strings.TrimRight(parts[1], "+"))
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid Kubernetes version %q, expected e.g. 1.31", value)
+	}
+	return Version{Major: major, Minor: minor}, nil
+}
+
+// IsZero reports whether the version is unset
+func (v Version) IsZero() bool {
+	return v.Major == 0 && v.Minor == 0
+}
+
+// AtMost reports whether the version is set and not after other
+func (v Version) AtMost(other Version) bool {
+	if v.IsZero() {
+		return false
+	}
+	return v.Major < other.Major || (v.Major == other.Major && v.Minor <= other.Minor)
+}
+
+func (v Version) String() string {
+	if v.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// v is shorthand for the 1.x versions of the tables below
+func v(minor int) Version {
+	return Version{Major: 1, Minor: minor}
+}
+
+// API is a deprecated API version of a kind
+type API struct {
+	// API version, such as extensions/v1beta1
+	APIVersion string
+	// Kind served by the API version
+	Kind string
+	// Release that deprecated the API version
+	DeprecatedIn Version
+	// Release that removed the API version
+	RemovedIn Version
+	// API version to migrate to; empty when the kind was removed without a replacement
+	Replacement string
+	// What else changes when migrating
+	Notes string
+}
+
+// Group returns the API group of the deprecated version
+func (a API) Group() string {
+	group, _, found := strings.Cut(a.APIVersion, "/")
+	if !found {
+		return ""
+	}
+	return group
+}
+
+// DeprecatedAPIs are the API versions Kubernetes deprecated and removed, after the upstream
+// deprecated API migration guide
+var DeprecatedAPIs = []API{
+	// Removed in 1.16
+	{APIVersion: "extensions/v1beta1", Kind: "Deployment", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1",
+		Notes: "spec.selector is required and immutable"},
+	{APIVersion: "extensions/v1beta1", Kind: "DaemonSet", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1",
+		Notes: "spec.selector is required; the default updateStrategy is RollingUpdate"},
+	{APIVersion: "extensions/v1beta1", Kind: "ReplicaSet", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1",
+		Notes: "spec.selector is required and immutable"},
+	{APIVersion: "extensions/v1beta1", Kind: "NetworkPolicy", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "networking.k8s.io/v1"},
+	{APIVersion: "extensions/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: v(10), RemovedIn: v(16), Replacement: "policy/v1beta1"},
+	{APIVersion: "apps/v1beta1", Kind: "Deployment", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1"},
+	{APIVersion: "apps/v1beta1", Kind: "StatefulSet", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "Deployment", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "StatefulSet", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "DaemonSet", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "ReplicaSet", DeprecatedIn: v(9), RemovedIn: v(16), Replacement: "apps/v1"},
+
+	// Removed in 1.22
+	{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", DeprecatedIn: v(16), RemovedIn: v(22),
+		Replacement: "admissionregistration.k8s.io/v1", Notes: "webhooks[*].sideEffects and admissionReviewVersions are required"},
+	{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "ValidatingWebhookConfiguration", DeprecatedIn: v(16), RemovedIn: v(22),
+		Replacement: "admissionregistration.k8s.io/v1", Notes: "webhooks[*].sideEffects and admissionReviewVersions are required"},
+	{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", DeprecatedIn: v(16), RemovedIn: v(22),
+		Replacement: "apiextensions.k8s.io/v1", Notes: "a structural schema per version is required; spec.validation and spec.version are removed"},
+	{APIVersion: "apiregistration.k8s.io/v1beta1", Kind: "APIService", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "apiregistration.k8s.io/v1"},
+	{APIVersion: "authentication.k8s.io/v1beta1", Kind: "TokenReview", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "authentication.k8s.io/v1"},
+	{APIVersion: "authorization.k8s.io/v1beta1", Kind: "SubjectAccessReview", DeprecatedIn: v(19), RemovedIn: v(22),
+		Replacement: "authorization.k8s.io/v1", Notes: "spec.group is renamed to spec.groups"},
+	{APIVersion: "certificates.k8s.io/v1beta1", Kind: "CertificateSigningRequest", DeprecatedIn: v(19), RemovedIn: v(22),
+		Replacement: "certificates.k8s.io/v1", Notes: "spec.signerName is required"},
+	{APIVersion: "coordination.k8s.io/v1beta1", Kind: "Lease", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "coordination.k8s.io/v1"},
+	{APIVersion: "extensions/v1beta1", Kind: "Ingress", DeprecatedIn: v(14), RemovedIn: v(22), Replacement: "networking.k8s.io/v1",
+		Notes: "backends use service.name and service.port; pathType is required; use spec.ingressClassName"},
+	{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "networking.k8s.io/v1",
+		Notes: "backends use service.name and service.port; pathType is required; use spec.ingressClassName"},
+	{APIVersion: "networking.k8s.io/v1beta1", Kind: "IngressClass", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "networking.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRole", DeprecatedIn: v(17), RemovedIn: v(22), Replacement: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRoleBinding", DeprecatedIn: v(17), RemovedIn: v(22), Replacement: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "Role", DeprecatedIn: v(17), RemovedIn: v(22), Replacement: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "RoleBinding", DeprecatedIn: v(17), RemovedIn: v(22), Replacement: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "scheduling.k8s.io/v1beta1", Kind: "PriorityClass", DeprecatedIn: v(14), RemovedIn: v(22), Replacement: "scheduling.k8s.io/v1"},
+	{APIVersion: "storage.k8s.io/v1beta1", Kind: "CSIDriver", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "storage.k8s.io/v1"},
+	{APIVersion: "storage.k8s.io/v1beta1", Kind: "CSINode", DeprecatedIn: v(17), RemovedIn: v(22), Replacement: "storage.k8s.io/v1"},
+	{APIVersion: "storage.k8s.io/v1beta1", Kind: "StorageClass", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "storage.k8s.io/v1"},
+	{APIVersion: "storage.k8s.io/v1beta1", Kind: "VolumeAttachment", DeprecatedIn: v(19), RemovedIn: v(22), Replacement: "storage.k8s.io/v1"},
+
+	// Removed in 1.25
+	{APIVersion: "batch/v1beta1", Kind: "CronJob", DeprecatedIn: v(21), RemovedIn: v(25), Replacement: "batch/v1"},
+	{APIVersion: "discovery.k8s.io/v1beta1", Kind: "EndpointSlice", DeprecatedIn: v(21), RemovedIn: v(25), Replacement: "discovery.k8s.io/v1",
+		Notes: "endpoints[*].topology is replaced by nodeName and zone"},
+	{APIVersion: "events.k8s.io/v1beta1", Kind: "Event", DeprecatedIn: v(22), RemovedIn: v(25), Replacement: "events.k8s.io/v1"},
+	{APIVersion: "autoscaling/v2beta1", Kind: "HorizontalPodAutoscaler", DeprecatedIn: v(22), RemovedIn: v(25), Replacement: "autoscaling/v2",
+		Notes: "metric targets use target.type and target.averageUtilization"},
+	{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", DeprecatedIn: v(21), RemovedIn: v(25), Replacement: "policy/v1",
+		Notes: "an empty spec.selector selects all pods in the namespace instead of none"},
+	{APIVersion: "policy/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: v(21), RemovedIn: v(25),
+		Notes: "PodSecurityPolicy has no replacement API; enforce Pod Security Standards with Pod Security admission namespace labels (pod-security.kubernetes.io/enforce) or a policy engine"},
+	{APIVersion: "node.k8s.io/v1beta1", Kind: "RuntimeClass", DeprecatedIn: v(20), RemovedIn: v(25), Replacement: "node.k8s.io/v1"},
+
+	// Removed in 1.26
+	{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta1", Kind: "FlowSchema", DeprecatedIn: v(23), RemovedIn: v(26), Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta1", Kind: "PriorityLevelConfiguration", DeprecatedIn: v(23), RemovedIn: v(26), Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{APIVersion: "autoscaling/v2beta2", Kind: "HorizontalPodAutoscaler", DeprecatedIn: v(23), RemovedIn: v(26), Replacement: "autoscaling/v2"},
+
+	// Removed in 1.27
+	{APIVersion: "storage.k8s.io/v1beta1", Kind: "CSIStorageCapacity", DeprecatedIn: v(24), RemovedIn: v(27), Replacement: "storage.k8s.io/v1"},
+
+	// Removed in 1.29
+	{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta2", Kind: "FlowSchema", DeprecatedIn: v(26), RemovedIn: v(29), Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta2", Kind: "PriorityLevelConfiguration", DeprecatedIn: v(26), RemovedIn: v(29), Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+
+	// Removed in 1.32
+	{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", Kind: "FlowSchema", DeprecatedIn: v(29), RemovedIn: v(32), Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", Kind: "PriorityLevelConfiguration", DeprecatedIn: v(29), RemovedIn: v(32), Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// FindDeprecatedAPI returns the deprecation of an API version and kind, or nil
+func FindDeprecatedAPI(apiVersion, kind string) *API {
+	for i, api := range DeprecatedAPIs {
+		if api.APIVersion == apiVersion && api.Kind == kind {
+			return &DeprecatedAPIs[i]
+		}
+	}
+	return nil
+}
+
+// deprecatedAnnotation is an annotation or annotation prefix that Kubernetes deprecated
+type deprecatedAnnotation struct {
+	// Annotation key, or prefix when it ends with /
+	Key string
+	// Kinds the annotation is checked on; pod templates are always checked
+	Kinds        []string
+	DeprecatedIn Version
+	// Release that stopped honoring the annotation; unset when it still works
+	RemovedIn   Version
+	Replacement string
+}
+
+// deprecatedAnnotations are annotations that newer releases ignore or will ignore
+var deprecatedAnnotations = []deprecatedAnnotation{
+	{Key: "seccomp.security.alpha.kubernetes.io/pod", DeprecatedIn: v(19), RemovedIn: v(27),
+		Replacement: "spec.securityContext.seccompProfile"},
+	{Key: "container.seccomp.security.alpha.kubernetes.io/", DeprecatedIn: v(19), RemovedIn: v(27),
+		Replacement: "spec.containers[*].securityContext.seccompProfile"},
+	{Key: "container.apparmor.security.beta.kubernetes.io/", DeprecatedIn: v(30),
+		Replacement: "spec.containers[*].securityContext.appArmorProfile"},
+	{Key: "scheduler.alpha.kubernetes.io/critical-pod", DeprecatedIn: v(13), RemovedIn: v(16),
+		Replacement: "spec.priorityClassName: system-cluster-critical or system-node-critical"},
+	{Key: "kubernetes.io/ingress.class", Kinds: []string{"Ingress"}, DeprecatedIn: v(18),
+		Replacement: "spec.ingressClassName"},
+}
+
+// deprecatedLabels are well-known node labels deprecated in favor of their GA equivalents
+var deprecatedLabels = []struct {
+	Key          string
+	DeprecatedIn Version
+	Replacement  string
+}{
+	{Key: "beta.kubernetes.io/os", DeprecatedIn: v(14), Replacement: "kubernetes.io/os"},
+	{Key: "beta.kubernetes.io/arch", DeprecatedIn: v(14), Replacement: "kubernetes.io/arch"},
+	{Key: "failure-domain.beta.kubernetes.io/zone", DeprecatedIn: v(17), Replacement: "topology.kubernetes.io/zone"},
+	{Key: "failure-domain.beta.kubernetes.io/region", DeprecatedIn: v(17), Replacement: "topology.kubernetes.io/region"},
+}
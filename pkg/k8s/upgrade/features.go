@@ -0,0 +1,191 @@
+package upgrade
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/version"
+
+	"kube-ai/pkg/k8s"
+)
+
+// Feature is a field or API that suggestions may rely on, with the release that enabled it by
+// default
+type Feature struct {
+	// Name of the field or API, as users know it
+	Name string
+	// Release that enabled the feature by default
+	Since Version
+	// Feature gate that enables it on earlier releases, if any
+	Gate string
+	// Case-insensitive patterns of suggestions relying on the feature
+	Patterns []*regexp.Regexp
+}
+
+func (f Feature) String() string {
+	return fmt.Sprintf("%s (Kubernetes %s+)", f.Name, f.Since)
+}
+
+// patterns compiles the case-insensitive patterns of a feature
+func patterns(exprs ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(exprs))
+	for i, expr := range exprs {
+		compiled[i] = regexp.MustCompile("(?i)" + expr)
+	}
+	return compiled
+}
+
+// Features are the fields and APIs that AI suggestions commonly rely on and that older clusters
+// do not support, after the Kubernetes feature gate reference
+var Features = []Feature{
+	{Name: "startupProbe", Since: v(18), Gate: "StartupProbe", Patterns: patterns(`startup ?probe`)},
+	{Name: "topologySpreadConstraints", Since: v(18), Gate: "EvenPodsSpread", Patterns: patterns(`topology ?spread ?constraints`)},
+	{Name: "seccompProfile", Since: v(19), Patterns: patterns(`seccompprofile`)},
+	{Name: "policy/v1 PodDisruptionBudget", Since: v(21), Patterns: patterns(`policy/v1([^b]|$)`)},
+	{Name: "autoscaling/v2 HorizontalPodAutoscaler", Since: v(23), Patterns: patterns(`autoscaling/v2([^b]|$)`)},
+	{Name: "ephemeral containers", Since: v(23), Gate: "EphemeralContainers", Patterns: patterns(`ephemeral ?containers?`, `kubectl debug`)},
+	{Name: "Pod Security Admission", Since: v(23), Gate: "PodSecurity", Patterns: patterns(`pod-security\.kubernetes\.io`, `pod security admission`)},
+	{Name: "gRPC probes", Since: v(24), Gate: "GRPCContainerProbe", Patterns: patterns(`\bgrpc (liveness |readiness |startup )?probes?\b`, `probe[^.]*\bgrpc:`)},
+	{Name: "Job podFailurePolicy", Since: v(26), Gate: "JobPodFailurePolicy", Patterns: patterns(`podfailurepolicy`, `pod failure polic`)},
+	{Name: "matchLabelKeys in topology spread constraints", Since: v(27), Gate: "MatchLabelKeysInPodTopologySpread", Patterns: patterns(`matchlabelkeys`)},
+	{Name: "Pod schedulingGates", Since: v(27), Gate: "PodSchedulingReadiness", Patterns: patterns(`scheduling ?gates`)},
+	{Name: "HPA ContainerResource metrics", Since: v(27), Gate: "HPAContainerMetrics", Patterns: patterns(`containerresource`)},
+	{Name: "native sidecar containers", Since: v(29), Gate: "SidecarContainers", Patterns: patterns(`native sidecars?`, `sidecar containers feature`, `init ?containers?[^.]*restartpolicy:? *always`)},
+	{Name: "Job backoffLimitPerIndex", Since: v(29), Gate: "JobBackoffLimitPerIndex", Patterns: patterns(`backofflimitperindex`)},
+	{Name: "preStop sleep action", Since: v(30), Gate: "PodLifecycleSleepAction", Patterns: patterns(`sleep action`, `sleep: *\{? *seconds`)},
+	{Name: "ValidatingAdmissionPolicy", Since: v(30), Gate: "ValidatingAdmissionPolicy", Patterns: patterns(`validating ?admission ?polic`)},
+	{Name: "Service trafficDistribution", Since: v(31), Gate: "ServiceTrafficDistribution", Patterns: patterns(`traffic ?distribution`)},
+	{Name: "in-place pod resize", Since: v(33), Gate: "InPlacePodVerticalScaling", Patterns: patterns(`in-place (pod )?(vertical )?(resize|resizing|scaling)`, `resizepolicy`)},
+	{Name: "user namespaces (hostUsers)", Since: v(33), Gate: "UserNamespacesSupport", Patterns: patterns(`hostusers`, `user namespaces`)},
+}
+
+// featureGatesSince is the release from which API servers report their feature gates as the
+// kubernetes_feature_enabled metric
+var featureGatesSince = v(26)
+
+// Cluster describes what a cluster supports: its release and the feature gates it enabled
+type Cluster struct {
+	// Release of the API server
+	Version Version
+	// Feature gates of the API server, by name, when it reports them
+	Gates map[string]bool
+}
+
+// DetectCluster reads the release of the API server and, when features it lacks by release
+// could be enabled by a gate, its feature gates. Gates are best effort: reading them needs get
+// on the /metrics non-resource URL.
+func DetectCluster(ctx context.Context, client *k8s.Client) (*Cluster, error) {
+	gitVersion, err := serverVersion(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the Kubernetes version: %w", err)
+	}
+	version, err := ParseVersion(gitVersion)
+	if err != nil {
+		return nil, err
+	}
+	if version.IsZero() {
+		return nil, fmt.Errorf("the API server reports no Kubernetes version")
+	}
+	cluster := &Cluster{Version: version}
+
+	gated := false
+	for _, feature := range cluster.Unsupported() {
+		gated = gated || feature.Gate != ""
+	}
+	if gated && featureGatesSince.AtMost(version) {
+		cluster.Gates = featureGates(ctx, client)
+	}
+	return cluster, nil
+}
+
+// serverVersion reads the version of the API server, within the deadline of ctx
+func serverVersion(ctx context.Context, client *k8s.Client) (string, error) {
+	discovery := client.GetClientset().Discovery()
+	restClient := discovery.RESTClient()
+	if restClient == nil {
+		// Clientsets without a REST client, such as fake ones, only serve the version directly
+		info, err := discovery.ServerVersion()
+		if err != nil {
+			return "", err
+		}
+		return info.GitVersion, nil
+	}
+
+	data, err := restClient.Get().AbsPath("/version").DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	var info version.Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", fmt.Errorf("error decoding the version response: %w", err)
+	}
+	return info.GitVersion, nil
+}
+
+// featureGates reads the feature gates of the API server from its metrics, or returns nil
+func featureGates(ctx context.Context, client *k8s.Client) map[string]bool {
+	restClient := client.GetClientset().Discovery().RESTClient()
+	if restClient == nil {
+		return nil
+	}
+	data, err := restClient.Get().AbsPath("/metrics").DoRaw(ctx)
+	if err != nil {
+		return nil
+	}
+
+	// Lines look like kubernetes_feature_enabled{name="SidecarContainers",stage="BETA"} 1
+	gates := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		labels, ok := strings.CutPrefix(line, "kubernetes_feature_enabled{")
+		if !ok {
+			continue
+		}
+		_, name, found := strings.Cut(labels, `name="`)
+		if !found {
+			continue
+		}
+		name, _, _ = strings.Cut(name, `"`)
+		gates[name] = strings.HasSuffix(strings.TrimSpace(line), " 1")
+	}
+	return gates
+}
+
+// Supports reports whether the cluster supports a feature, by release or by feature gate
+func (c *Cluster) Supports(feature Feature) bool {
+	if feature.Since.AtMost(c.Version) {
+		return true
+	}
+	return feature.Gate != "" && c.Gates[feature.Gate]
+}
+
+// Unsupported returns the features the cluster does not support
+func (c *Cluster) Unsupported() []Feature {
+	var unsupported []Feature
+	for _, feature := range Features {
+		if !c.Supports(feature) {
+			unsupported = append(unsupported, feature)
+		}
+	}
+	return unsupported
+}
+
+// CheckSuggestion returns the first feature a suggestion relies on that the cluster does not
+// support, described for users, or "" when the cluster supports everything it mentions
+func (c *Cluster) CheckSuggestion(suggestion string) string {
+	for _, feature := range c.Unsupported() {
+		for _, pattern := range feature.Patterns {
+			if pattern.MatchString(suggestion) {
+				return feature.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// usageLimitPercent is the share of a limit at which a container is reported as close to it:
+// memory near its limit is about to be OOM killed, CPU near its limit is throttled
+const usageLimitPercent = 90
+
+// ContainerUsage is the live CPU and memory usage of a container next to its requests and limits
+type ContainerUsage struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// Usage from the metrics API, CPU in millicores and memory in MiB
+	CPU           string `json:"cpu"`
+	CPURequest    string `json:"cpuRequest,omitempty"`
+	CPULimit      string `json:"cpuLimit,omitempty"`
+	Memory        string `json:"memory"`
+	MemoryRequest string `json:"memoryRequest,omitempty"`
+	MemoryLimit   string `json:"memoryLimit,omitempty"`
+	// Usage as a percentage of the limit, 0 without a limit
+	CPULimitPercent    int64 `json:"cpuLimitPercent,omitempty"`
+	MemoryLimitPercent int64 `json:"memoryLimitPercent,omitempty"`
+	Restarts           int32 `json:"restarts"`
+	// Reason the container last terminated, such as OOMKilled
+	LastTermination string `json:"lastTermination,omitempty"`
+}
+
+// GetResourceUsage returns the live usage of the containers of pods, all in one namespace, next
+// to their requests and limits. Containers the metrics API has no usage for are left out.
+func (c *Client) GetResourceUsage(ctx context.Context, pods []corev1.Pod) ([]ContainerUsage, error) {
+	if len(pods) == 0 {
+		return nil, nil
+	}
+	metrics, err := c.GetPodMetrics(ctx, pods[0].Namespace)
+	if err != nil {
+		return nil, err
+	}
+	byPod := make(map[string]PodMetrics, len(metrics))
+	for _, m := range metrics {
+		byPod[m.Name] = m
+	}
+
+	var usage []ContainerUsage
+	for _, pod := range pods {
+		for _, m := range byPod[pod.Name].Containers {
+			usage = append(usage, containerUsage(pod, m))
+		}
+	}
+	return usage, nil
+}
+
+// containerUsage combines the metrics of a container with its spec and status
+func containerUsage(pod corev1.Pod, metrics ContainerMetrics) ContainerUsage {
+	usage := ContainerUsage{Pod: pod.Name, Container: metrics.Name, CPU: metrics.CPU, Memory: metrics.Memory}
+	cpu, cpuErr := resource.ParseQuantity(metrics.CPU)
+	if cpuErr == nil {
+		usage.CPU = fmt.Sprintf("%dm", cpu.MilliValue())
+	}
+	memory, memoryErr := resource.ParseQuantity(metrics.Memory)
+	if memoryErr == nil {
+		usage.Memory = fmt.Sprintf("%dMi", memory.Value()/(1<<20))
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if container.Name != metrics.Name {
+			continue
+		}
+		resources := container.Resources
+		if request, ok := resources.Requests[corev1.ResourceCPU]; ok {
+			usage.CPURequest = request.String()
+		}
+		if request, ok := resources.Requests[corev1.ResourceMemory]; ok {
+			usage.MemoryRequest = request.String()
+		}
+		if limit, ok := resources.Limits[corev1.ResourceCPU]; ok {
+			usage.CPULimit = limit.String()
+			if cpuErr == nil && limit.MilliValue() > 0 {
+				usage.CPULimitPercent = cpu.MilliValue() * 100 / limit.MilliValue()
+			}
+		}
+		if limit, ok := resources.Limits[corev1.ResourceMemory]; ok {
+			usage.MemoryLimit = limit.String()
+			if memoryErr == nil && limit.Value() > 0 {
+				usage.MemoryLimitPercent = memory.Value() * 100 / limit.Value()
+			}
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != metrics.Name {
+			continue
+		}
+		usage.Restarts = status.RestartCount
+		if terminated := status.LastTerminationState.Terminated; terminated != nil {
+			usage.LastTermination = terminated.Reason
+		}
+	}
+	return usage
+}
+
+// UsageFindings reports containers that were OOM killed, use memory close to their limit, or use
+// CPU close to their limit and are likely throttled
+func UsageFindings(usage []ContainerUsage) []string {
+	var findings []string
+	for _, u := range usage {
+		name := u.Pod + "/" + u.Container
+		if u.LastTermination == "OOMKilled" {
+			findings = append(findings, fmt.Sprintf("%s was OOM killed and now uses %s of its %s memory limit", name, u.Memory, u.MemoryLimit))
+		} else if u.MemoryLimitPercent >= usageLimitPercent {
+			findings = append(findings, fmt.Sprintf("%s uses %d%% of its memory limit (%s of %s) and is close to being OOM killed", name, u.MemoryLimitPercent, u.Memory, u.MemoryLimit))
+		}
+		if u.CPULimitPercent >= usageLimitPercent {
+			findings = append(findings, fmt.Sprintf("%s uses %d%% of its CPU limit (%s of %s) and is likely CPU throttled", name, u.CPULimitPercent, u.CPU, u.CPULimit))
+		}
+	}
+	return findings
+}
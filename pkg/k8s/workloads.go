@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Health indicators for workloads
+const (
+	HealthHealthy  = "Healthy"
+	HealthDegraded = "Degraded"
+	HealthDown     = "Down"
+)
+
+// WorkloadStatus summarizes the rollout state of a workload
+type WorkloadStatus struct {
+	// Kind of the workload (deployment, statefulset, daemonset)
+	Kind string
+	// Name of the workload
+	Name string
+	// Namespace of the workload
+	Namespace string
+	// Desired number of replicas
+	Desired int32
+	// Number of ready replicas
+	Ready int32
+}
+
+// Health returns a coarse health indicator derived from ready vs desired replicas
+func (w WorkloadStatus) Health() string {
+	switch {
+	case w.Desired == 0 || w.Ready >= w.Desired:
+		return HealthHealthy
+	case w.Ready == 0:
+		return HealthDown
+	default:
+		return HealthDegraded
+	}
+}
+
+// ListNamespaces returns the names of all namespaces, sorted
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
+	namespaces, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing namespaces: %w", err)
+	}
+
+	names := make([]string, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// ListWorkloads returns the deployments, statefulsets and daemonsets in a namespace
+func (c *Client) ListWorkloads(ctx context.Context, namespace string) ([]WorkloadStatus, error) {
+	if namespace == "" {
+		namespace = c.GetNamespace()
+	}
+
+	var workloads []WorkloadStatus
+
+	deployments, err := c.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments in namespace %s: %w", namespace, err)
+	}
+	for _, d := range deployments.Items {
+		desired := int32(1)
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+		workloads = append(workloads, WorkloadStatus{
+			Kind: "deployment", Name: d.Name, Namespace: namespace,
+			Desired: desired, Ready: d.Status.ReadyReplicas,
+		})
+	}
+
+	statefulsets, err := c.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets in namespace %s: %w", namespace, err)
+	}
+	for _, s := range statefulsets.Items {
+		desired := int32(1)
+		if s.Spec.Replicas != nil {
+			desired = *s.Spec.Replicas
+		}
+		workloads = append(workloads, WorkloadStatus{
+			Kind: "statefulset", Name: s.Name, Namespace: namespace,
+			Desired: desired, Ready: s.Status.ReadyReplicas,
+		})
+	}
+
+	daemonsets, err := c.clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing daemonsets in namespace %s: %w", namespace, err)
+	}
+	for _, d := range daemonsets.Items {
+		workloads = append(workloads, WorkloadStatus{
+			Kind: "daemonset", Name: d.Name, Namespace: namespace,
+			Desired: d.Status.DesiredNumberScheduled, Ready: d.Status.NumberReady,
+		})
+	}
+
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Kind != workloads[j].Kind {
+			return workloads[i].Kind < workloads[j].Kind
+		}
+		return workloads[i].Name < workloads[j].Name
+	})
+
+	return workloads, nil
+}
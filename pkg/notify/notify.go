@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kube-ai/internal/config"
+)
+
+// Notification is the result of a command delivered to a sink
+type Notification struct {
+	// Short description, such as the command line
+	Title string `json:"title"`
+	// Command path, such as analyze-logs
+	Command string `json:"command"`
+	// Target cluster context and namespace
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Severity of the result (Low, Medium, High, Critical), for commands that assess one
+	Severity string `json:"severity,omitempty"`
+	// Output of the command as shown in the terminal
+	Text string `json:"text"`
+	// Structured result, for commands that produce one
+	Result interface{} `json:"result,omitempty"`
+	// When the command finished
+	Time time.Time `json:"time"`
+}
+
+// Sink delivers notifications
+type Sink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// NewSink creates a sink from its configuration
+func NewSink(sink config.Sink) (Sink, error) {
+	switch sink.Type {
+	case config.SinkWebhook:
+		return newWebhookSink(sink)
+	case config.SinkSMTP:
+		return newSMTPSink(sink)
+	case "":
+		return nil, fmt.Errorf("sink type not set (expected %s or %s)", config.SinkWebhook, config.SinkSMTP)
+	}
+	return nil, fmt.Errorf("unsupported sink type %q (expected %s or %s)", sink.Type, config.SinkWebhook, config.SinkSMTP)
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kube-ai/internal/config"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var received Notification
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(config.Sink{Type: config.SinkWebhook, URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Send(context.Background(), Notification{Title: "kube-ai analyze-logs pod web", Severity: "High", Text: "output"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Severity != "High" || received.Text != "output" || auth != "Bearer token" {
+		t.Errorf("unexpected request: %+v, authorization %q", received, auth)
+	}
+}
+
+func TestWebhookSinkFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such hook", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(config.Sink{Type: config.SinkWebhook, URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Send(context.Background(), Notification{})
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no such hook") {
+		t.Errorf("expected the status and message in the error, got %v", err)
+	}
+}
+
+func TestNewSinkErrors(t *testing.T) {
+	for _, sink := range []config.Sink{
+		{},
+		{Type: "pager"},
+		{Type: config.SinkWebhook, URL: "hooks.example.com/x"},
+		{Type: config.SinkSMTP, Server: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}},
+		{Type: config.SinkSMTP, Server: "smtp.example.com:587", From: "a@example.com"},
+	} {
+		if _, err := NewSink(sink); err == nil {
+			t.Errorf("expected an error for %+v", sink)
+		}
+	}
+}
+
+func TestSMTPMessage(t *testing.T) {
+	sink, err := newSMTPSink(config.Sink{Type: config.SinkSMTP, Server: "smtp.example.com:465", From: "kube-ai@example.com", To: []string{"oncall@example.com", "sre@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sink.tls {
+		t.Errorf("port 465 should use implicit TLS")
+	}
+
+	message := string(sink.message(Notification{
+		Title:     "kube-ai analyze-logs pod web",
+		Severity:  "Critical",
+		Context:   "prod",
+		Namespace: "payments",
+		Text:      "The pod is crash looping",
+		Time:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}))
+	for _, want := range []string{
+		"To: oncall@example.com, sre@example.com\r\n",
+		"Subject: [kube-ai] kube-ai analyze-logs pod web (Critical)\r\n",
+		"Date: Wed, 01 May 2024 10:00:00 +0000\r\n",
+		"\r\n\r\nContext: prod\r\nNamespace: payments\r\n\r\nThe pod is crash looping",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message does not contain %q:\n%s", want, message)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"kube-ai/internal/config"
+)
+
+// smtpSink emails notifications as plain text
+type smtpSink struct {
+	server   string
+	host     string
+	tls      bool
+	from     string
+	to       []string
+	username string
+	password string
+}
+
+// newSMTPSink creates an SMTP sink
+func newSMTPSink(sink config.Sink) (*smtpSink, error) {
+	host, port, err := net.SplitHostPort(sink.Server)
+	if err != nil || host == "" {
+		return nil, fmt.Errorf("smtp sink needs a server as host:port, got %q", sink.Server)
+	}
+	if sink.From == "" || len(sink.To) == 0 {
+		return nil, fmt.Errorf("smtp sink needs a from address and at least one to address")
+	}
+	return &smtpSink{
+		server:   sink.Server,
+		host:     host,
+		tls:      port == "465",
+		from:     sink.From,
+		to:       sink.To,
+		username: sink.Username,
+		password: sink.Password,
+	}, nil
+}
+
+// Send emails the notification. Credentials are only sent over TLS, or to localhost.
+func (s *smtpSink) Send(ctx context.Context, n Notification) error {
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if s.tls {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.host}}).DialContext(ctx, "tcp", s.server)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.server)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", s.server, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error connecting to %s: %w", s.server, err)
+	}
+	defer client.Close()
+
+	if !s.tls {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+				return fmt.Errorf("error starting TLS with %s: %w", s.server, err)
+			}
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("error authenticating with %s: %w", s.server, err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("error sending mail from %s: %w", s.from, err)
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("error sending mail to %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	if _, err := w.Write(s.message(n)); err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	return client.Quit()
+}
+
+// message formats the notification as an email
+func (s *smtpSink) message(n Notification) []byte {
+	subject := "[kube-ai] " + n.Title
+	if n.Severity != "" {
+		subject += " (" + n.Severity + ")"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+
+	if n.Context != "" {
+		fmt.Fprintf(&buf, "Context: %s\r\n", n.Context)
+	}
+	if n.Namespace != "" {
+		fmt.Fprintf(&buf, "Namespace: %s\r\n", n.Namespace)
+	}
+	if n.Context != "" || n.Namespace != "" {
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString(n.Text)
+	return buf.Bytes()
+}
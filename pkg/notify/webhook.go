@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"kube-ai/internal/config"
+)
+
+// webhookSink posts notifications as JSON to a URL, for ticketing systems and alerting pipelines
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newWebhookSink creates a webhook sink
+func newWebhookSink(sink config.Sink) (*webhookSink, error) {
+	parsed, err := url.Parse(sink.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("webhook sink needs an http or https url, got %q", sink.URL)
+	}
+	return &webhookSink{
+		url:     sink.URL,
+		headers: sink.Headers,
+		client:  &http.Client{},
+	}, nil
+}
+
+// Send posts the notification
+func (s *webhookSink) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
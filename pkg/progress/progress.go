@@ -0,0 +1,166 @@
+// Package progress reports the progress of long operations on terminals: counted ones, such as
+// the pods whose logs are collected, with the count and the estimated time left, and uncounted
+// ones, such as waiting for an AI answer, with a spinner. Both redraw a single line and print
+// nothing when the output is not a terminal, so files and pipes stay clean.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// redrawInterval is how often a line is redrawn at most
+	redrawInterval = 100 * time.Millisecond
+	// clearLine returns to the start of the line and erases it
+	clearLine = "\r\033[K"
+)
+
+// spinnerFrames are drawn in turn by spinners
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// IsTerminal reports whether w is a terminal
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// Remaining estimates the time left to finish total steps once done of them took elapsed, or
+// returns 0 before the first step is done
+func Remaining(elapsed time.Duration, done, total int) time.Duration {
+	if done <= 0 || done >= total {
+		return 0
+	}
+	return elapsed / time.Duration(done) * time.Duration(total-done)
+}
+
+// FormatRemaining describes an estimated time left, such as "about 1m20s left", or returns ""
+// when there is no estimate
+func FormatRemaining(remaining time.Duration) string {
+	if remaining <= 0 {
+		return ""
+	}
+	if remaining < time.Second {
+		return "about 1s left"
+	}
+	return fmt.Sprintf("about %s left", remaining.Round(time.Second))
+}
+
+// Counter reports the progress of a counted operation, as "label: 34/120 pods (about 40s left)".
+// It is safe for concurrent use.
+type Counter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	label    string
+	unit     string
+	total    int
+	done     int
+	start    time.Time
+	drawn    time.Time
+	terminal bool
+}
+
+// NewCounter returns a counter drawn on w when it is a terminal
+func NewCounter(w io.Writer, label, unit string) *Counter {
+	return &Counter{w: w, label: label, unit: unit, start: time.Now(), terminal: IsTerminal(w)}
+}
+
+// Set records that done of total steps are finished and redraws the counter
+func (c *Counter) Set(done, total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done, c.total = done, total
+	now := time.Now()
+	if !c.terminal || (now.Sub(c.drawn) < redrawInterval && done < c.total) {
+		return
+	}
+	c.drawn = now
+
+	line := fmt.Sprintf("%s%s: %d/%d %s", clearLine, c.label, done, c.total, c.unit)
+	if remaining := FormatRemaining(Remaining(now.Sub(c.start), done, c.total)); remaining != "" {
+		line += " (" + remaining + ")"
+	}
+	fmt.Fprint(c.w, line)
+}
+
+// Finish erases the counter, so the line can be used by what follows
+func (c *Counter) Finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.terminal && !c.drawn.IsZero() {
+		fmt.Fprint(c.w, clearLine)
+	}
+}
+
+// Spinner shows that an uncounted operation is under way, as "⠙ label (12s)". Operations may
+// overlap, such as concurrent AI requests: the spinner runs from the first Start to the last
+// Stop. It is safe for concurrent use.
+type Spinner struct {
+	mu       sync.Mutex
+	w        io.Writer
+	label    string
+	terminal bool
+	active   int
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewSpinner returns a spinner drawn on w when it is a terminal
+func NewSpinner(w io.Writer, label string) *Spinner {
+	return &Spinner{w: w, label: label, terminal: IsTerminal(w)}
+}
+
+// Start starts an operation, drawing the spinner until it and the overlapping ones stop. It
+// returns the function that stops the operation.
+func (s *Spinner) Start() func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.terminal {
+		return func() {}
+	}
+	s.active++
+	if s.active == 1 {
+		s.stop, s.stopped = make(chan struct{}), make(chan struct{})
+		go s.run(s.stop, s.stopped)
+	}
+
+	var once sync.Once
+	return func() { once.Do(s.finish) }
+}
+
+// finish ends an operation, erasing the spinner once none is left
+func (s *Spinner) finish() {
+	s.mu.Lock()
+	s.active--
+	if s.active > 0 {
+		s.mu.Unlock()
+		return
+	}
+	stop, stopped := s.stop, s.stopped
+	s.mu.Unlock()
+
+	close(stop)
+	<-stopped
+	fmt.Fprint(s.w, clearLine)
+}
+
+// run draws the spinner until stop is closed
+func (s *Spinner) run(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	start := time.Now()
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+	for frame := 0; ; frame++ {
+		fmt.Fprintf(s.w, "%s%s %s (%s)", clearLine, spinnerFrames[frame%len(spinnerFrames)], s.label, time.Since(start).Truncate(time.Second))
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemaining(t *testing.T) {
+	if got := Remaining(30*time.Second, 10, 40); got != 90*time.Second {
+		t.Errorf("Remaining = %s, want 1m30s", got)
+	}
+	if got := Remaining(time.Second, 0, 40); got != 0 {
+		t.Errorf("Remaining before any step = %s, want 0", got)
+	}
+	if got := FormatRemaining(90 * time.Second); got != "about 1m30s left" {
+		t.Errorf("FormatRemaining = %q", got)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	// Output that is not a terminal gets nothing
+	var out bytes.Buffer
+	counter := NewCounter(&out, "Collecting logs", "pods")
+	counter.Set(34, 120)
+	counter.Finish()
+	if out.Len() != 0 {
+		t.Errorf("expected no output off a terminal, got %q", out.String())
+	}
+
+	counter = NewCounter(&out, "Collecting logs", "pods")
+	counter.terminal = true
+	counter.Set(34, 120)
+	if !strings.Contains(out.String(), "Collecting logs: 34/120 pods (about ") {
+		t.Errorf("unexpected counter line %q", out.String())
+	}
+	counter.Finish()
+	if !strings.HasSuffix(out.String(), clearLine) {
+		t.Errorf("expected Finish to erase the line, got %q", out.String())
+	}
+}
+
+func TestSpinner(t *testing.T) {
+	var out bytes.Buffer
+	spinner := NewSpinner(&out, "Waiting for the AI provider")
+	spinner.terminal = true
+
+	// Overlapping operations share the spinner until the last one stops
+	first, second := spinner.Start(), spinner.Start()
+	first()
+	first()
+	spinner.mu.Lock()
+	active := spinner.active
+	spinner.mu.Unlock()
+	if active != 1 {
+		t.Fatalf("expected 1 active operation, got %d", active)
+	}
+	second()
+
+	if !strings.Contains(out.String(), "Waiting for the AI provider (0s)") || !strings.HasSuffix(out.String(), clearLine) {
+		t.Errorf("unexpected spinner output %q", out.String())
+	}
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHubAnnotation is a GitHub Actions workflow command that shows a message inline on a file
+type GitHubAnnotation struct {
+	// Annotation level: error, warning or notice
+	Level string
+	// Path of the annotated file, relative to the repository root
+	File string
+	// Line of the annotation (0 to annotate the whole file)
+	Line int
+	// Short title shown above the message
+	Title string
+	// Message text
+	Message string
+}
+
+// WriteGitHubAnnotations writes annotations as ::level file=...,line=...,title=...::message workflow commands
+func WriteGitHubAnnotations(w io.Writer, annotations []GitHubAnnotation) error {
+	for _, annotation := range annotations {
+		properties := []string{"file=" + escapeProperty(annotation.File)}
+		if annotation.Line > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", annotation.Line))
+		}
+		if annotation.Title != "" {
+			properties = append(properties, "title="+escapeProperty(annotation.Title))
+		}
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", annotation.Level, strings.Join(properties, ","), escapeData(annotation.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// JUnitSuites is the root element of a JUnit XML report
+type JUnitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []JUnitSuite `xml:"testsuite"`
+}
+
+// JUnitSuite groups test cases, e.g. the findings for one input
+type JUnitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []JUnitCase `xml:"testcase"`
+}
+
+// JUnitCase is a single check; it passes unless Failure is set
+type JUnitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure describes why a test case failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Details string `xml:",cdata"`
+}
+
+// WriteJUnit writes suites as a JUnit XML report, filling in the test and failure counts
+func WriteJUnit(w io.Writer, name string, suites ...JUnitSuite) error {
+	report := JUnitSuites{Name: name}
+	for _, suite := range suites {
+		suite.Tests = len(suite.Cases)
+		suite.Failures = 0
+		for _, testCase := range suite.Cases {
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("error encoding JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
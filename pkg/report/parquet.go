@@ -0,0 +1,283 @@
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Values from the Parquet format specification (parquet.thrift). Files are written with a single
+// row group of uncompressed, PLAIN encoded data pages, which every Parquet reader supports.
+const (
+	parquetMagic = "PAR1"
+
+	// Physical types
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	// Field repetition types
+	parquetRequired = 0
+	parquetOptional = 1
+
+	// Converted types, understood by old and new readers alike
+	parquetUTF8            = 0
+	parquetTimestampMicros = 10
+
+	// Encodings
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// WriteParquet writes a table as a Parquet file. Strings are UTF-8 byte arrays, integers INT64
+// and timestamps optional INT64 microseconds since the epoch in UTC, null when zero.
+func WriteParquet(w io.Writer, table Table) error {
+	if err := table.check(); err != nil {
+		return err
+	}
+
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(table.Columns))
+	for i, column := range table.Columns {
+		data := encodeParquetColumn(table, i, column)
+
+		header := newThriftWriter()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(data)))
+		header.begin(5)
+		header.i32(1, int32(len(table.Rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.end()
+
+		chunks[i] = chunk{offset: int64(file.Len()), size: int64(header.buf.Len() + len(data))}
+		file.Write(header.buf.Bytes())
+		file.Write(data)
+	}
+
+	// The footer describes the schema and where each column chunk starts
+	footer := newThriftWriter()
+	footer.i32(1, 1)
+	footer.list(2, thriftStruct, len(table.Columns)+1)
+	footer.beginElement()
+	footer.binary(4, "schema")
+	footer.i32(5, int32(len(table.Columns)))
+	footer.end()
+	for _, column := range table.Columns {
+		physical, repetition, converted := parquetColumnType(column.Type)
+		footer.beginElement()
+		footer.i32(1, physical)
+		footer.i32(3, repetition)
+		footer.binary(4, column.Name)
+		if converted >= 0 {
+			footer.i32(6, converted)
+		}
+		footer.end()
+	}
+	footer.i64(3, int64(len(table.Rows)))
+	footer.list(4, thriftStruct, 1)
+	footer.beginElement()
+	footer.list(1, thriftStruct, len(table.Columns))
+	var totalSize int64
+	for i, column := range table.Columns {
+		physical, _, _ := parquetColumnType(column.Type)
+		footer.beginElement()
+		footer.i64(2, chunks[i].offset)
+		footer.begin(3)
+		footer.i32(1, physical)
+		footer.list(2, thriftI32, 2)
+		footer.zigzag(parquetPlain)
+		footer.zigzag(parquetRLE)
+		footer.list(3, thriftBinary, 1)
+		footer.varint(uint64(len(column.Name)))
+		footer.buf.WriteString(column.Name)
+		footer.i32(4, parquetUncompressed)
+		footer.i64(5, int64(len(table.Rows)))
+		footer.i64(6, chunks[i].size)
+		footer.i64(7, chunks[i].size)
+		footer.i64(9, chunks[i].offset)
+		footer.end()
+		footer.end()
+		totalSize += chunks[i].size
+	}
+	footer.i64(2, totalSize)
+	footer.i64(3, int64(len(table.Rows)))
+	footer.end()
+	footer.binary(6, "kube-ai")
+	footer.end()
+
+	file.Write(footer.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(footer.buf.Len()))
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetColumnType returns the physical type, repetition and converted type (-1 for none) a
+// column is stored with
+func parquetColumnType(columnType ColumnType) (physical, repetition, converted int32) {
+	switch columnType {
+	case Int64Column:
+		return parquetInt64, parquetRequired, -1
+	case TimestampColumn:
+		return parquetInt64, parquetOptional, parquetTimestampMicros
+	default:
+		return parquetByteArray, parquetRequired, parquetUTF8
+	}
+}
+
+// encodeParquetColumn encodes the values of a column as the body of a data page: the definition
+// levels of optional columns, then the non-null values
+func encodeParquetColumn(table Table, index int, column Column) []byte {
+	var page bytes.Buffer
+	if column.Type == TimestampColumn {
+		defined := make([]bool, len(table.Rows))
+		for i, row := range table.Rows {
+			defined[i] = !row[index].(time.Time).IsZero()
+		}
+		levels := encodeDefinitionLevels(defined)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+
+	for _, row := range table.Rows {
+		switch value := row[index].(type) {
+		case string:
+			binary.Write(&page, binary.LittleEndian, uint32(len(value)))
+			page.WriteString(value)
+		case int64:
+			binary.Write(&page, binary.LittleEndian, value)
+		case time.Time:
+			if !value.IsZero() {
+				binary.Write(&page, binary.LittleEndian, value.UnixMicro())
+			}
+		}
+	}
+	return page.Bytes()
+}
+
+// encodeDefinitionLevels encodes the levels of an optional column, 1 for a value and 0 for null,
+// as runs of the RLE/bit-packing hybrid encoding with a bit width of 1
+func encodeDefinitionLevels(defined []bool) []byte {
+	var levels bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	for start := 0; start < len(defined); {
+		end := start
+		for end < len(defined) && defined[end] == defined[start] {
+			end++
+		}
+		n := binary.PutUvarint(scratch[:], uint64(end-start)<<1)
+		levels.Write(scratch[:n])
+		if defined[start] {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		start = end
+	}
+	return levels.Bytes()
+}
+
+// Types of the Thrift compact protocol, which Parquet metadata is encoded with
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes Thrift structs with the compact protocol
+type thriftWriter struct {
+	buf bytes.Buffer
+	// Id of the last field written in each open struct, as field ids are encoded as deltas
+	last []int16
+}
+
+// newThriftWriter starts writing a top-level struct
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+// varint writes an unsigned variable-length integer
+func (w *thriftWriter) varint(v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], v)
+	w.buf.Write(scratch[:n])
+}
+
+// zigzag writes a signed integer, as i32 and i64 values and list elements are
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+// field writes the header of a field
+func (w *thriftWriter) field(id int16, fieldType byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.buf.WriteByte(fieldType)
+		w.zigzag(int64(id))
+	}
+	*last = id
+}
+
+// i32 writes an i32 field, which enum fields are too
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+// i64 writes an i64 field
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.zigzag(v)
+}
+
+// binary writes a string field
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// list writes the header of a list field; its elements follow
+func (w *thriftWriter) list(id int16, elementType byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elementType)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elementType)
+	w.varint(uint64(size))
+}
+
+// begin starts a struct field, ended with end
+func (w *thriftWriter) begin(id int16) {
+	w.field(id, thriftStruct)
+	w.last = append(w.last, 0)
+}
+
+// beginElement starts a struct list element, ended with end
+func (w *thriftWriter) beginElement() {
+	w.last = append(w.last, 0)
+}
+
+// end ends the innermost open struct
+func (w *thriftWriter) end() {
+	w.buf.WriteByte(0)
+	w.last = w.last[:len(w.last)-1]
+}
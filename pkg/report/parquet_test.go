@@ -0,0 +1,310 @@
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	seen := time.Date(2026, 3, 14, 15, 9, 26, 535897000, time.FixedZone("CET", 3600))
+
+	wide := Table{}
+	var wideRow []interface{}
+	for i := 0; i < 20; i++ {
+		wide.Columns = append(wide.Columns, Column{Name: fmt.Sprintf("c%d", i), Type: Int64Column})
+		wideRow = append(wideRow, int64(i*i))
+	}
+	wide.Append(wideRow...)
+
+	long := Table{Columns: []Column{{Name: "seen", Type: TimestampColumn}}}
+	for i := 0; i < 40; i++ {
+		if i%7 < 3 {
+			long.Append(time.Time{})
+		} else {
+			long.Append(seen.Add(time.Duration(i) * time.Minute))
+		}
+	}
+
+	tests := map[string]Table{
+		"mixed": {
+			Columns: []Column{
+				{Name: "pod", Type: StringColumn},
+				{Name: "restarts", Type: Int64Column},
+				{Name: "seen", Type: TimestampColumn},
+			},
+			Rows: [][]interface{}{
+				{"checkout-7d4b9", int64(3), seen},
+				{"", int64(-42), time.Time{}},
+				{"café ☕", int64(1) << 40, seen.Add(time.Hour)},
+			},
+		},
+		"no rows": {Columns: []Column{{Name: "pod", Type: StringColumn}, {Name: "seen", Type: TimestampColumn}}},
+		"wide":    wide,
+		"long":    long,
+	}
+
+	for name, table := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteParquet(&buf, table); err != nil {
+				t.Fatalf("WriteParquet: %v", err)
+			}
+			columns, rows := readParquet(t, buf.Bytes())
+
+			if !reflect.DeepEqual(columns, table.Columns) {
+				t.Errorf("columns = %v, want %v", columns, table.Columns)
+			}
+			if len(rows) != len(table.Rows) {
+				t.Fatalf("read %d rows, want %d", len(rows), len(table.Rows))
+			}
+			for i, row := range table.Rows {
+				for j, value := range row {
+					if v, ok := value.(time.Time); ok && !v.IsZero() {
+						value = v.UTC().Truncate(time.Microsecond)
+					}
+					if !reflect.DeepEqual(rows[i][j], value) {
+						t.Errorf("row %d column %s = %#v, want %#v", i, table.Columns[j].Name, rows[i][j], value)
+					}
+				}
+			}
+		})
+	}
+}
+
+// readParquet reads back a Parquet file independently of the writer, following the format
+// specification: the footer, its schema and row groups, and the data pages of each column chunk.
+// Only uncompressed, PLAIN encoded flat columns are supported.
+func readParquet(t *testing.T, data []byte) ([]Column, [][]interface{}) {
+	t.Helper()
+	if len(data) < 12 || string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen > len(data)-12 {
+		t.Fatalf("footer length %d exceeds the file", footerLen)
+	}
+	meta := (&compactReader{t: t, data: data[len(data)-8-footerLen : len(data)-8]}).structure()
+
+	schema := meta[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	if int(root[5].(int64)) != len(schema)-1 {
+		t.Fatalf("root has %v children, schema has %d columns", root[5], len(schema)-1)
+	}
+	var columns []Column
+	var optional []bool
+	for _, element := range schema[1:] {
+		element := element.(map[int16]interface{})
+		column := Column{Name: element[4].(string)}
+		physical, converted := element[1].(int64), element[6]
+		switch {
+		case physical == 6 && converted == int64(0):
+			column.Type = StringColumn
+		case physical == 2 && converted == nil:
+			column.Type = Int64Column
+		case physical == 2 && converted == int64(10):
+			column.Type = TimestampColumn
+		default:
+			t.Fatalf("column %s has physical type %d and converted type %v", column.Name, physical, converted)
+		}
+		columns = append(columns, column)
+		optional = append(optional, element[3].(int64) == 1)
+	}
+
+	var rows [][]interface{}
+	for _, group := range meta[4].([]interface{}) {
+		group := group.(map[int16]interface{})
+		numRows := int(group[3].(int64))
+		chunks := group[1].([]interface{})
+		if len(chunks) != len(columns) {
+			t.Fatalf("row group has %d column chunks for %d columns", len(chunks), len(columns))
+		}
+		groupRows := make([][]interface{}, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(columns))
+		}
+		for j, chunk := range chunks {
+			chunkMeta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			if path := chunkMeta[3].([]interface{}); len(path) != 1 || path[0] != columns[j].Name {
+				t.Fatalf("column chunk %d has path %v, want [%s]", j, path, columns[j].Name)
+			}
+			if codec := chunkMeta[4].(int64); codec != 0 {
+				t.Fatalf("column %s uses compression codec %d", columns[j].Name, codec)
+			}
+			values := readColumnChunk(t, data, int(chunkMeta[9].(int64)), columns[j], optional[j])
+			if len(values) != numRows || int(chunkMeta[5].(int64)) != numRows {
+				t.Fatalf("column %s has %d values for %d rows", columns[j].Name, len(values), numRows)
+			}
+			for i, value := range values {
+				groupRows[i][j] = value
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	if numRows := int(meta[3].(int64)); numRows != len(rows) {
+		t.Fatalf("footer has %d rows, row groups %d", numRows, len(rows))
+	}
+	return columns, rows
+}
+
+// readColumnChunk decodes the single data page of a column chunk starting at offset
+func readColumnChunk(t *testing.T, data []byte, offset int, column Column, optional bool) []interface{} {
+	t.Helper()
+	reader := &compactReader{t: t, data: data[offset:]}
+	header := reader.structure()
+	if pageType := header[1].(int64); pageType != 0 {
+		t.Fatalf("column %s starts with page type %d, want a data page", column.Name, pageType)
+	}
+	pageHeader := header[5].(map[int16]interface{})
+	if encoding := pageHeader[2].(int64); encoding != 0 {
+		t.Fatalf("column %s uses encoding %d, want PLAIN", column.Name, encoding)
+	}
+	numValues := int(pageHeader[1].(int64))
+	page := data[offset+reader.pos : offset+reader.pos+int(header[3].(int64))]
+
+	defined := make([]bool, numValues)
+	for i := range defined {
+		defined[i] = true
+	}
+	if optional {
+		levelsLen := int(binary.LittleEndian.Uint32(page))
+		defined = decodeLevels(t, page[4:4+levelsLen], numValues)
+		page = page[4+levelsLen:]
+	}
+
+	values := make([]interface{}, numValues)
+	for i := range values {
+		if !defined[i] {
+			values[i] = time.Time{}
+			continue
+		}
+		switch column.Type {
+		case StringColumn:
+			n := int(binary.LittleEndian.Uint32(page))
+			values[i] = string(page[4 : 4+n])
+			page = page[4+n:]
+		case Int64Column:
+			values[i] = int64(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		case TimestampColumn:
+			values[i] = time.UnixMicro(int64(binary.LittleEndian.Uint64(page))).UTC()
+			page = page[8:]
+		}
+	}
+	if len(page) != 0 {
+		t.Fatalf("column %s has %d bytes left after its values", column.Name, len(page))
+	}
+	return values
+}
+
+// decodeLevels decodes definition levels with a bit width of 1, written with the RLE/bit-packing
+// hybrid encoding
+func decodeLevels(t *testing.T, data []byte, count int) []bool {
+	t.Helper()
+	var levels []bool
+	for len(data) > 0 {
+		header, n := binary.Uvarint(data)
+		data = data[n:]
+		if header&1 == 0 {
+			for i := uint64(0); i < header>>1; i++ {
+				levels = append(levels, data[0] == 1)
+			}
+			data = data[1:]
+			continue
+		}
+		groups := int(header >> 1)
+		for _, b := range data[:groups] {
+			for bit := 0; bit < 8; bit++ {
+				levels = append(levels, b>>bit&1 == 1)
+			}
+		}
+		data = data[groups:]
+	}
+	if len(levels) < count {
+		t.Fatalf("decoded %d definition levels, want %d", len(levels), count)
+	}
+	return levels[:count]
+}
+
+// compactReader decodes Thrift compact protocol structs into maps of field ids to values
+type compactReader struct {
+	t    *testing.T
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.t.Fatalf("thrift data ends at %d", r.pos)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.t.Fatalf("bad varint at %d", r.pos)
+	}
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		b := r.byte()
+		if b == 0 {
+			return fields
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(b & 0x0f)
+	}
+}
+
+func (r *compactReader) value(fieldType byte) interface{} {
+	switch fieldType {
+	case 1, 2:
+		return fieldType == 1
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		r.pos += 8
+		return binary.LittleEndian.Uint64(r.data[r.pos-8:])
+	case 8:
+		n := int(r.varint())
+		r.pos += n
+		return string(r.data[r.pos-n : r.pos])
+	case 9, 10:
+		header := r.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		elements := make([]interface{}, size)
+		for i := range elements {
+			elements[i] = r.value(header & 0x0f)
+		}
+		return elements
+	case 12:
+		return r.structure()
+	}
+	r.t.Fatalf("unsupported thrift type %d at %d", fieldType, r.pos)
+	return nil
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ColumnType is the type of the values in a table column
+type ColumnType int
+
+const (
+	// StringColumn holds string values
+	StringColumn ColumnType = iota
+	// Int64Column holds int64 values
+	Int64Column
+	// TimestampColumn holds time.Time values; zero times are exported as empty (null) values
+	TimestampColumn
+)
+
+// Column describes one column of a table
+type Column struct {
+	// Column name, used as CSV header and Parquet field name
+	Name string
+	// Type of the values
+	Type ColumnType
+}
+
+// Table is flat, typed data exported for tools such as notebooks, spreadsheets and BI tools
+type Table struct {
+	// Columns in order
+	Columns []Column
+	// Rows holding one value per column, of the Go type matching the column type
+	Rows [][]interface{}
+}
+
+// Append adds a row to the table
+func (t *Table) Append(values ...interface{}) {
+	t.Rows = append(t.Rows, values)
+}
+
+// check reports a row whose values do not match the columns
+func (t *Table) check() error {
+	for i, row := range t.Rows {
+		if len(row) != len(t.Columns) {
+			return fmt.Errorf("row %d has %d values for %d columns", i, len(row), len(t.Columns))
+		}
+		for j, column := range t.Columns {
+			var ok bool
+			switch column.Type {
+			case StringColumn:
+				_, ok = row[j].(string)
+			case Int64Column:
+				_, ok = row[j].(int64)
+			case TimestampColumn:
+				_, ok = row[j].(time.Time)
+			}
+			if !ok {
+				return fmt.Errorf("row %d has a %T value in column %s", i, row[j], column.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes a table as CSV with a header row. Timestamps are written in RFC 3339 format
+// with nanoseconds, in UTC.
+func WriteCSV(w io.Writer, table Table) error {
+	if err := table.check(); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, value := range row {
+			switch value := value.(type) {
+			case string:
+				record[i] = value
+			case int64:
+				record[i] = strconv.FormatInt(value, 10)
+			case time.Time:
+				record[i] = ""
+				if !value.IsZero() {
+					record[i] = value.UTC().Format(time.RFC3339Nano)
+				}
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
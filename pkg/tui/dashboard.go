@@ -0,0 +1,630 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"kube-ai/pkg/ai"
+	"kube-ai/pkg/ai/analyzers"
+	"kube-ai/pkg/ai/prompts"
+	"kube-ai/pkg/i18n"
+	"kube-ai/pkg/k8s"
+	"kube-ai/pkg/k8s/bundle"
+)
+
+// dashboardTailLines is the number of log lines collected per container when analyzing a workload
+const dashboardTailLines = 200
+
+// listWidth is the width of the namespace/workload list column
+const listWidth = 40
+
+// ANSI colors for health indicators
+const (
+	colorReset  = "\x1b[0m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+	colorBold   = "\x1b[1m"
+	colorInvert = "\x1b[7m"
+)
+
+// focus identifies which pane receives key presses
+type focus int
+
+const (
+	focusList focus = iota
+	focusChat
+)
+
+// chatTurn is one question and answer in the chat pane
+type chatTurn struct {
+	Question string
+	Answer   string
+}
+
+// Messages produced by the dashboard's background commands
+type (
+	namespacesMsg struct {
+		names []string
+		err   error
+	}
+	workloadsMsg struct {
+		namespace string
+		workloads []k8s.WorkloadStatus
+		err       error
+	}
+	analysisMsg struct {
+		workload k8s.WorkloadStatus
+		bundle   *bundle.Bundle
+		result   *analyzers.LogAnalysisResult
+		err      error
+	}
+	chatMsg struct {
+		question string
+		answer   string
+		err      error
+	}
+)
+
+// Dashboard is the kube-ai TUI: a namespace/workload list, an analysis pane and a chat pane
+type Dashboard struct {
+	ctx       context.Context
+	client    *k8s.Client
+	aiService *ai.Service
+
+	namespaces []string
+	namespace  string
+	workloads  []k8s.WorkloadStatus
+	cursor     int
+
+	selected *k8s.WorkloadStatus
+	bundle   *bundle.Bundle
+	result   *analyzers.LogAnalysisResult
+	chat     []chatTurn
+	input    string
+	scroll   int
+
+	focus  focus
+	busy   string
+	status string
+}
+
+// NewDashboard creates the dashboard model
+func NewDashboard(ctx context.Context, client *k8s.Client, aiService *ai.Service) *Dashboard {
+	return &Dashboard{
+		ctx:       ctx,
+		client:    client,
+		aiService: aiService,
+	}
+}
+
+// Init loads the namespace list
+func (d *Dashboard) Init() Cmd {
+	d.busy = "Loading namespaces..."
+	return d.loadNamespaces
+}
+
+// Update handles key presses and the results of background work
+func (d *Dashboard) Update(msg Msg) Cmd {
+	switch msg := msg.(type) {
+	case KeyMsg:
+		return d.handleKey(msg.Key)
+
+	case namespacesMsg:
+		d.busy = ""
+		if msg.err != nil {
+			d.status = msg.err.Error()
+			return nil
+		}
+		d.namespaces = msg.names
+		// Start with the cursor on the namespace from the kubeconfig or flags
+		for i, name := range d.namespaces {
+			if name == d.client.GetNamespace() {
+				d.cursor = i
+			}
+		}
+
+	case workloadsMsg:
+		d.busy = ""
+		if msg.err != nil {
+			d.status = msg.err.Error()
+			return nil
+		}
+		if msg.namespace == d.namespace {
+			d.workloads = msg.workloads
+			if d.cursor >= len(d.workloads) {
+				d.cursor = 0
+			}
+		}
+
+	case analysisMsg:
+		d.busy = ""
+		if d.selected == nil || d.selected.Name != msg.workload.Name || d.selected.Kind != msg.workload.Kind {
+			return nil
+		}
+		if msg.err != nil {
+			d.status = msg.err.Error()
+		}
+		d.bundle = msg.bundle
+		d.result = msg.result
+
+	case chatMsg:
+		d.busy = ""
+		if msg.err != nil {
+			d.status = msg.err.Error()
+			d.chat = append(d.chat, chatTurn{Question: msg.question, Answer: "(no answer)"})
+			return nil
+		}
+		d.chat = append(d.chat, chatTurn{Question: msg.question, Answer: msg.answer})
+		d.scroll = 0
+	}
+
+	return nil
+}
+
+// handleKey dispatches a key press to the focused pane
+func (d *Dashboard) handleKey(key string) Cmd {
+	if key == "ctrl+c" {
+		return Quit
+	}
+
+	switch key {
+	case "pgup":
+		d.scroll += 10
+		return nil
+	case "pgdown":
+		d.scroll = max(0, d.scroll-10)
+		return nil
+	}
+
+	if d.focus == focusChat {
+		return d.handleChatKey(key)
+	}
+	return d.handleListKey(key)
+}
+
+// handleListKey handles navigation in the namespace/workload list
+func (d *Dashboard) handleListKey(key string) Cmd {
+	switch key {
+	case "q":
+		return Quit
+	case "up", "k":
+		if d.cursor > 0 {
+			d.cursor--
+		}
+	case "down", "j":
+		if d.cursor < d.listLen()-1 {
+			d.cursor++
+		}
+	case "esc", "backspace", "left", "h":
+		if d.namespace != "" {
+			d.cursor = indexOf(d.namespaces, d.namespace)
+			d.namespace = ""
+			d.workloads = nil
+		}
+	case "r":
+		d.status = ""
+		if d.namespace == "" {
+			d.busy = "Loading namespaces..."
+			return d.loadNamespaces
+		}
+		d.busy = fmt.Sprintf("Loading workloads in %s...", d.namespace)
+		return d.loadWorkloads(d.namespace)
+	case "tab", "c":
+		if d.selected != nil {
+			d.focus = focusChat
+		}
+	case "enter", "right", "l":
+		return d.selectCurrent()
+	}
+	return nil
+}
+
+// handleChatKey handles typing in the chat input
+func (d *Dashboard) handleChatKey(key string) Cmd {
+	switch key {
+	case "tab", "esc":
+		d.focus = focusList
+	case "backspace":
+		if d.input != "" {
+			_, size := utf8.DecodeLastRuneInString(d.input)
+			d.input = d.input[:len(d.input)-size]
+		}
+	case "enter":
+		question := strings.TrimSpace(d.input)
+		if question == "" || d.busy != "" {
+			return nil
+		}
+		d.input = ""
+		d.status = ""
+		d.busy = "Thinking..."
+		return d.ask(question)
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			d.input += key
+		}
+	}
+	return nil
+}
+
+// selectCurrent opens the namespace under the cursor or analyzes the workload under it
+func (d *Dashboard) selectCurrent() Cmd {
+	if d.namespace == "" {
+		if d.cursor >= len(d.namespaces) {
+			return nil
+		}
+		d.namespace = d.namespaces[d.cursor]
+		d.cursor = 0
+		d.status = ""
+		d.busy = fmt.Sprintf("Loading workloads in %s...", d.namespace)
+		return d.loadWorkloads(d.namespace)
+	}
+
+	if d.cursor >= len(d.workloads) {
+		return nil
+	}
+	workload := d.workloads[d.cursor]
+	d.selected = &workload
+	d.bundle = nil
+	d.result = nil
+	d.chat = nil
+	d.scroll = 0
+	d.status = ""
+	d.busy = fmt.Sprintf("Analyzing %s/%s...", workload.Kind, workload.Name)
+	return d.analyze(workload)
+}
+
+// loadNamespaces lists namespaces in the cluster
+func (d *Dashboard) loadNamespaces() Msg {
+	names, err := d.client.ListNamespaces(d.ctx)
+	return namespacesMsg{names: names, err: err}
+}
+
+// loadWorkloads returns a command listing the workloads in a namespace
+func (d *Dashboard) loadWorkloads(namespace string) Cmd {
+	return func() Msg {
+		workloads, err := d.client.ListWorkloads(d.ctx, namespace)
+		return workloadsMsg{namespace: namespace, workloads: workloads, err: err}
+	}
+}
+
+// analyze returns a command collecting and analyzing the logs and events of a workload
+func (d *Dashboard) analyze(workload k8s.WorkloadStatus) Cmd {
+	return func() Msg {
+		b, err := bundle.Collect(d.ctx, d.client, bundle.CreateOptions{
+			ResourceType: workload.Kind,
+			ResourceName: workload.Name,
+			Namespace:    workload.Namespace,
+			TailLines:    dashboardTailLines,
+		})
+		if err != nil {
+			return analysisMsg{workload: workload, err: fmt.Errorf("error collecting data: %w", err)}
+		}
+
+		result, _, err := analyzers.NewBundleAnalyzer(d.aiService).AnalyzeBundle(d.ctx, b)
+		if err != nil {
+			return analysisMsg{workload: workload, bundle: b, err: err}
+		}
+		return analysisMsg{workload: workload, bundle: b, result: result}
+	}
+}
+
+// ask returns a command answering a follow-up question with the selected workload as context
+func (d *Dashboard) ask(question string) Cmd {
+	prompt, err := d.buildChatPrompt(question)
+	return func() Msg {
+		if err != nil {
+			return chatMsg{question: question, err: err}
+		}
+		answer, err := d.aiService.Query(d.ctx, prompt)
+		return chatMsg{question: question, answer: strings.TrimSpace(answer), err: err}
+	}
+}
+
+// buildChatPrompt includes the workload manifest, analysis and previous turns in a follow-up question
+func (d *Dashboard) buildChatPrompt(question string) (string, error) {
+	w := d.selected
+	vars := map[string]interface{}{
+		"Workload": w,
+		"Analysis": d.result,
+		"Chat":     d.chat,
+		"Question": question,
+	}
+	if d.bundle != nil {
+		if manifest, ok := d.bundle.Manifests[fmt.Sprintf("%s-%s.yaml", w.Kind, w.Name)]; ok {
+			if len(manifest) > 6000 {
+				manifest = manifest[:6000] + "\n# ... truncated\n"
+			}
+			vars["Manifest"] = manifest
+		}
+		vars["Events"] = d.bundle.Events
+	}
+	return d.aiService.RenderPrompt(prompts.DashboardChat, vars)
+}
+
+// View renders the dashboard
+func (d *Dashboard) View(width, height int) string {
+	bodyHeight := max(1, height-3)
+	rightWidth := max(10, width-listWidth-3)
+
+	left := d.listLines()
+	right := d.detailLines(rightWidth)
+
+	// Keep the list cursor visible
+	listOffset := 0
+	if d.cursor >= bodyHeight {
+		listOffset = d.cursor - bodyHeight + 1
+	}
+
+	// Show the end of the detail pane unless the user scrolled up
+	rightOffset := max(0, len(right)-bodyHeight-d.scroll)
+
+	var sb strings.Builder
+	title := fmt.Sprintf(" kube-ai | provider: %s | model: %s | persona: %s",
+		d.aiService.GetCurrentProvider(), d.aiService.GetCurrentModel(), d.aiService.GetCurrentPersona())
+	sb.WriteString(colorInvert + padRight(title, width) + colorReset + "\n")
+
+	for i := 0; i < bodyHeight; i++ {
+		leftLine := ""
+		if idx := listOffset + i; idx < len(left) {
+			leftLine = left[idx]
+		}
+		rightLine := ""
+		if idx := rightOffset + i; idx < len(right) {
+			rightLine = right[idx]
+		}
+		sb.WriteString(leftLine + " │ " + truncate(rightLine, rightWidth) + "\n")
+	}
+
+	// Chat input line
+	prompt := "  Ask: "
+	if d.focus == focusChat {
+		prompt = colorBold + "> Ask: " + colorReset
+	}
+	if d.selected == nil {
+		sb.WriteString(prompt + "(select a workload to ask follow-up questions)\n")
+	} else {
+		sb.WriteString(prompt + truncate(d.input, width-8) + "\n")
+	}
+
+	// Status line
+	switch {
+	case d.busy != "":
+		sb.WriteString(colorYellow + truncate(d.busy, width) + colorReset)
+	case d.status != "":
+		sb.WriteString(colorRed + truncate("Error: "+d.status, width) + colorReset)
+	case d.focus == focusChat:
+		sb.WriteString(truncate("enter: send  tab/esc: back to list  pgup/pgdown: scroll  ctrl+c: quit", width))
+	default:
+		sb.WriteString(truncate("↑/↓: move  enter: select  esc: back  tab: chat  r: refresh  q: quit", width))
+	}
+
+	return sb.String()
+}
+
+// listLines renders the namespace or workload list, each padded to listWidth
+func (d *Dashboard) listLines() []string {
+	var lines []string
+
+	if d.namespace == "" {
+		for i, name := range d.namespaces {
+			lines = append(lines, d.listItem(i, "", name))
+		}
+		return lines
+	}
+
+	if len(d.workloads) == 0 && d.busy == "" {
+		return []string{padRight(fmt.Sprintf(" No workloads in %s", d.namespace), listWidth)}
+	}
+	for i, w := range d.workloads {
+		indicator := healthIndicator(w.Health())
+		label := fmt.Sprintf("%s/%s %d/%d", w.Kind, w.Name, w.Ready, w.Desired)
+		lines = append(lines, d.listItem(i, indicator, label))
+	}
+	return lines
+}
+
+// listItem renders a single list row, highlighting the cursor position
+func (d *Dashboard) listItem(index int, indicator, label string) string {
+	marker := "  "
+	if index == d.cursor {
+		marker = "> "
+	}
+	width := listWidth - 2
+	if indicator != "" {
+		// The indicator renders as a single cell followed by a space
+		width -= 2
+		indicator += " "
+	}
+	text := padRight(truncate(label, width), width)
+	if index == d.cursor && d.focus == focusList {
+		text = colorBold + text + colorReset
+	}
+	return marker + indicator + text
+}
+
+// detailLines renders the analysis and chat pane, wrapped to the given width
+func (d *Dashboard) detailLines(width int) []string {
+	if d.selected == nil {
+		if d.namespace == "" {
+			return []string{"Select a namespace to list its workloads."}
+		}
+		return []string{"Select a workload to analyze its logs and events."}
+	}
+
+	var lines []string
+	add := func(text string) {
+		lines = append(lines, wrap(text, width)...)
+	}
+
+	w := d.selected
+	add(fmt.Sprintf("%s%s/%s%s (%s, %d/%d ready)", colorBold, w.Kind, w.Name, colorReset, w.Health(), w.Ready, w.Desired))
+	add("")
+
+	if d.bundle != nil {
+		add(fmt.Sprintf("Collected %d log streams and %d events", len(d.bundle.Logs), len(d.bundle.Events)))
+		for _, warning := range d.bundle.Metadata.Warnings {
+			add("Could not collect " + warning)
+		}
+		add("")
+	}
+
+	if d.result != nil {
+		add(i18n.T("Severity") + ": " + d.result.Severity)
+		add("")
+		add(d.result.Summary)
+		if len(d.result.RootCauses) > 0 {
+			add("")
+			add(i18n.T("Root Causes") + ":")
+			for i, cause := range d.result.RootCauses {
+				line := fmt.Sprintf("%d. %s", i+1, cause.Cause)
+				if cause.Confidence > 0 {
+					line += fmt.Sprintf(" (%s %d%%)", i18n.T("confidence"), cause.Confidence)
+				}
+				add(line)
+				for _, evidence := range cause.Evidence {
+					add("   > " + evidence)
+				}
+			}
+		}
+		if len(d.result.Solutions) > 0 {
+			add("")
+			add(i18n.T("Recommended Solutions") + ":")
+			for i, solution := range d.result.Solutions {
+				add(fmt.Sprintf("%d. %s", i+1, solution))
+			}
+		}
+	}
+
+	for _, turn := range d.chat {
+		add("")
+		add("Q: " + turn.Question)
+		for _, line := range strings.Split(turn.Answer, "\n") {
+			add(line)
+		}
+	}
+
+	return lines
+}
+
+// listLen returns the number of entries in the current list
+func (d *Dashboard) listLen() int {
+	if d.namespace == "" {
+		return len(d.namespaces)
+	}
+	return len(d.workloads)
+}
+
+// healthIndicator returns a colored dot for a health state
+func healthIndicator(health string) string {
+	switch health {
+	case k8s.HealthHealthy:
+		return colorGreen + "●" + colorReset
+	case k8s.HealthDegraded:
+		return colorYellow + "●" + colorReset
+	default:
+		return colorRed + "●" + colorReset
+	}
+}
+
+// indexOf returns the index of value in values, or 0 if it is not present
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// visibleLen returns the number of terminal cells a string occupies, ignoring ANSI escapes
+func visibleLen(s string) int {
+	n := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == 0x1b:
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// padRight pads a string with spaces to the given visible width
+func padRight(s string, width int) string {
+	if n := visibleLen(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// truncate shortens a string to the given visible width, keeping ANSI escapes intact
+func truncate(s string, width int) string {
+	if visibleLen(s) <= width {
+		return s
+	}
+
+	var sb strings.Builder
+	n := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == 0x1b:
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			if n >= width {
+				sb.WriteString(colorReset)
+				return sb.String()
+			}
+			n++
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// wrap breaks text into lines no wider than width, splitting on spaces where possible
+func wrap(text string, width int) []string {
+	if visibleLen(text) <= width {
+		return []string{text}
+	}
+
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		for visibleLen(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case visibleLen(current)+1+visibleLen(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,223 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Msg is an event delivered to a model's Update method
+type Msg interface{}
+
+// Cmd is asynchronous work that produces a Msg when it completes
+type Cmd func() Msg
+
+// KeyMsg is sent when a key is pressed
+type KeyMsg struct {
+	// Key is a named key (e.g. "up", "enter", "ctrl+c") or the typed character
+	Key string
+}
+
+// WindowSizeMsg is sent when the terminal is resized
+type WindowSizeMsg struct {
+	Width  int
+	Height int
+}
+
+// quitMsg stops the program
+type quitMsg struct{}
+
+// Quit is a Cmd that stops the program
+func Quit() Msg {
+	return quitMsg{}
+}
+
+// Model is a terminal UI in the model/update/view style
+type Model interface {
+	// Init returns the first command to run, if any
+	Init() Cmd
+	// Update applies a message to the model and returns a follow-up command, if any
+	Update(msg Msg) Cmd
+	// View renders the model for a terminal of the given size
+	View(width, height int) string
+}
+
+// Program runs a Model against the terminal
+type Program struct {
+	model  Model
+	out    io.Writer
+	msgs   chan Msg
+	width  int
+	height int
+}
+
+// NewProgram creates a program for the given model
+func NewProgram(model Model) *Program {
+	return &Program{
+		model: model,
+		out:   os.Stdout,
+		msgs:  make(chan Msg, 16),
+	}
+}
+
+// Run takes over the terminal and processes messages until the model quits
+func (p *Program) Run() error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("the TUI requires an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("error switching terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	// Use the alternate screen and hide the cursor while running
+	fmt.Fprint(p.out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(p.out, "\x1b[?25h\x1b[?1049l")
+
+	p.width, p.height = terminalSize()
+
+	go p.readKeys()
+	go p.watchSize()
+
+	p.exec(p.model.Init())
+	p.render()
+
+	for msg := range p.msgs {
+		if _, ok := msg.(quitMsg); ok {
+			return nil
+		}
+		if size, ok := msg.(WindowSizeMsg); ok {
+			p.width, p.height = size.Width, size.Height
+		}
+		p.exec(p.model.Update(msg))
+		p.render()
+	}
+
+	return nil
+}
+
+// exec runs a command in the background and feeds its result back into the loop
+func (p *Program) exec(cmd Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		if msg := cmd(); msg != nil {
+			p.msgs <- msg
+		}
+	}()
+}
+
+// render redraws the whole screen
+func (p *Program) render() {
+	view := p.model.View(p.width, p.height)
+	// Raw mode disables output post-processing, so lines need explicit carriage returns
+	fmt.Fprint(p.out, "\x1b[H\x1b[2J"+strings.ReplaceAll(view, "\n", "\r\n"))
+}
+
+// readKeys turns raw stdin input into key messages
+func (p *Program) readKeys() {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			p.msgs <- quitMsg{}
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			p.msgs <- KeyMsg{Key: key}
+		}
+	}
+}
+
+// watchSize polls the terminal size and reports changes
+func (p *Program) watchSize() {
+	width, height := p.width, p.height
+	for range time.Tick(250 * time.Millisecond) {
+		w, h := terminalSize()
+		if w != width || h != height {
+			width, height = w, h
+			p.msgs <- WindowSizeMsg{Width: w, Height: h}
+		}
+	}
+}
+
+// terminalSize returns the size of the terminal, with a fallback when it cannot be determined
+func terminalSize() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// escapeSequences maps terminal escape sequences to key names
+var escapeSequences = map[string]string{
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[C":  "right",
+	"\x1b[D":  "left",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+	"\x1bOC":  "right",
+	"\x1bOD":  "left",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdown",
+}
+
+// parseKeys splits a chunk of raw input into key names
+func parseKeys(input []byte) []string {
+	var keys []string
+	s := string(input)
+
+	for len(s) > 0 {
+		if s[0] == 0x1b {
+			matched := false
+			for seq, name := range escapeSequences {
+				if strings.HasPrefix(s, seq) {
+					keys = append(keys, name)
+					s = s[len(seq):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// A lone escape, or an unknown sequence which we treat as escape
+				keys = append(keys, "esc")
+				s = s[1:]
+				if len(s) > 0 && s[0] == '[' {
+					s = ""
+				}
+			}
+			continue
+		}
+
+		switch s[0] {
+		case 0x03:
+			keys = append(keys, "ctrl+c")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		default:
+			r := []rune(s)[0]
+			if r >= 0x20 {
+				keys = append(keys, string(r))
+			}
+			s = s[len(string(r)):]
+			continue
+		}
+		s = s[1:]
+	}
+
+	return keys
+}